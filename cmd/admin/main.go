@@ -0,0 +1,146 @@
+// Command admin carries out operator tasks against the database outside the API:
+//
+//	go run cmd/admin/main.go create-key ACCOUNT_ID [server flags]
+//	go run cmd/admin/main.go rotate-keys [server flags]
+//
+// create-key issues a signing key with every scope, admin included, to an account, registering
+// it first when it is new, and prints the key's ID and secret. The /admin routes take a request
+// signed with such a key or an admin session, so without auth.jwt_secret the server refuses to
+// start until one exists. It takes the server's configuration, so it acts on MySQL or SQLite as
+// the server would.
+//
+// rotate-keys seals every sensitive field under encryption.key_id, rewrapping the data keys of
+// values sealed under an older master key and sealing values stored before encryption was on,
+// and prints how many values of each column changed. Once it has run, master keys other than the
+// current one can be dropped from encryption.keys.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"orderSystem/internal/config"
+	"orderSystem/internal/crypto"
+	"orderSystem/internal/migration"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/service"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: admin create-key ACCOUNT_ID [server flags]
+       admin rotate-keys [server flags]`
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
+	var command, accountID string
+	var rest []string
+	switch {
+	case len(os.Args) >= 3 && os.Args[1] == "create-key" && !strings.HasPrefix(os.Args[2], "-"):
+		command, accountID, rest = os.Args[1], os.Args[2], os.Args[3:]
+	case len(os.Args) >= 2 && os.Args[1] == "rotate-keys":
+		command, rest = os.Args[1], os.Args[2:]
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cfg, err := config.Load(logger, rest)
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+	keys, err := crypto.NewKeyring(cfg.Encryption.KeyID, cfg.Encryption.Keys)
+	if err != nil {
+		logger.Fatal("Invalid encryption keys", zap.Error(err))
+	}
+	if command == "rotate-keys" && keys == nil {
+		logger.Fatal("rotate-keys needs encryption.key_id and encryption.keys to seal under")
+	}
+	ctx := context.Background()
+
+	repo, closeRepo, err := openRepository(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to open database", zap.Error(err))
+	}
+	defer closeRepo()
+	repo.SealWith(keys)
+
+	if command == "rotate-keys" {
+		changed, err := repo.RewrapSealed(ctx)
+		columns := make([]string, 0, len(changed))
+		for column := range changed {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			fmt.Printf("%s %d\n", column, changed[column])
+		}
+		if err != nil {
+			logger.Fatal("Failed to rotate keys", zap.String("key_id", keys.KeyID()), zap.Error(err))
+		}
+		return
+	}
+
+	matchingService := service.NewMatchingService(ctx, repo, cfg, logger)
+
+	account := &models.Account{AccountID: accountID, Name: accountID, CreatedAt: time.Now()}
+	if err := repo.SaveAccount(ctx, account); err != nil && repository.Classify(err) != repository.ErrDuplicateKey {
+		logger.Fatal("Failed to register account", zap.String("account_id", accountID), zap.Error(err))
+	}
+	scopes := []models.KeyScope{models.ScopeRead, models.ScopeTrade, models.ScopeCancel, models.ScopeAdmin}
+	key, err := matchingService.CreateSigningKey(ctx, accountID, scopes, nil)
+	if err != nil {
+		logger.Fatal("Failed to create signing key", zap.String("account_id", accountID), zap.Error(err))
+	}
+	fmt.Printf("key_id %s\nsecret %s\n", key.KeyID, key.Secret)
+}
+
+// openRepository connects to and migrates the primary and any additional shards, or the SQLite
+// file, as the server does
+func openRepository(cfg config.DatabaseConfig) (repository.Repository, func(), error) {
+	if cfg.Storage == config.StorageSQLite {
+		db, err := repository.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := migration.RunSQLiteMigrations(db); err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return repository.NewSQLiteRepository(db), func() { db.Close() }, nil
+	}
+
+	var dbs []*sql.DB
+	closeAll := func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	}
+	for _, dsn := range append([]string{cfg.DSN}, cfg.ShardDSNs...) {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		dbs = append(dbs, db)
+		if err := migration.RunMigrations(db); err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("shard %d: %v", len(dbs)-1, err)
+		}
+	}
+	if len(dbs) > 1 {
+		return repository.NewShardedRepository(dbs), closeAll, nil
+	}
+	return repository.NewMySQLRepository(dbs[0]), closeAll, nil
+}
@@ -0,0 +1,80 @@
+// Command archive prints an archive file as JSON lines, one trade or order event per line
+// prefixed with its shard, for replay and backtest tooling to consume:
+//
+//	go run cmd/archive/main.go trades 2024-05-01 [server flags]
+//
+// It takes the server's configuration and reads from the store of its archive section.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"orderSystem/internal/archive"
+	"orderSystem/internal/config"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "usage: archive trades|order_events YYYY-MM-DD [server flags]")
+		os.Exit(2)
+	}
+	kind := archive.Kind(os.Args[1])
+	day, err := time.Parse("2006-01-02", os.Args[2])
+	if err != nil || kind != archive.KindTrades && kind != archive.KindOrderEvents {
+		fmt.Fprintln(os.Stderr, "usage: archive trades|order_events YYYY-MM-DD [server flags]")
+		os.Exit(2)
+	}
+	cfg, err := config.Load(logger, os.Args[3:])
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+
+	name := archive.FileName(kind, day)
+	data, err := archive.NewStore(cfg.Archive).Get(context.Background(), name)
+	if err != nil {
+		logger.Fatal("Failed to read archive file", zap.String("file", name), zap.Error(err))
+	}
+	reader, err := archive.NewReader(bytes.NewReader(data))
+	if err != nil {
+		logger.Fatal("Failed to open archive file", zap.String("file", name), zap.Error(err))
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	encoder := json.NewEncoder(out)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			out.Flush()
+			logger.Fatal("Failed to read archive record", zap.String("file", name), zap.Error(err))
+		}
+		line := struct {
+			Shard int `json:"shard"`
+			Row   any `json:"row"`
+		}{Shard: record.Shard, Row: record.Trade}
+		if record.Event != nil {
+			line.Row = record.Event
+		}
+		if err := encoder.Encode(line); err != nil {
+			logger.Fatal("Failed to write record", zap.Error(err))
+		}
+	}
+}
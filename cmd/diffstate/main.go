@@ -0,0 +1,308 @@
+// Command diffstate compares the state of two deployments, such as a DR standby and the primary
+// it took over from, or the blue and green databases of a migration, and writes a JSON diff
+// report to stdout:
+//
+//	go run cmd/diffstate/main.go -a <dsn> [-a <shard dsn>...] -b <dsn> [-b <shard dsn>...]
+//
+// Each deployment is given by the DSNs of its shards, primary first; a restored snapshot is just
+// another database. It compares open and pending orders by order ID, balances by account and
+// currency summed across shards, and each shard's order sequence, last trade ID and last order
+// event ID. Nothing is written to either database. It exits 0 when the states are identical, 1
+// when they differ and 2 on error.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+// dsnList collects the shard DSNs of a deployment from a repeated flag
+type dsnList []string
+
+func (l *dsnList) String() string { return strings.Join(*l, ",") }
+
+func (l *dsnList) Set(dsn string) error {
+	*l = append(*l, dsn)
+	return nil
+}
+
+// sequences are a shard's high-water marks
+type sequences struct {
+	OrderSequence    uint64 `json:"order_sequence"`
+	LastTradeID      uint64 `json:"last_trade_id"`
+	LastOrderEventID uint64 `json:"last_order_event_id"`
+}
+
+// state is what is compared of a deployment, gathered from all its shards
+type state struct {
+	orders    map[uint64]*models.Order
+	balances  map[string]*models.Balance // by account and currency
+	sequences []sequences                // by shard
+}
+
+// summary describes a deployment in the report
+type summary struct {
+	Shards     int         `json:"shards"`
+	OpenOrders int         `json:"open_orders"`
+	Balances   int         `json:"balances"`
+	Sequences  []sequences `json:"sequences"`
+}
+
+// difference is a value that differs between the deployments. Field is empty and A or B null
+// when the row exists in one deployment only.
+type difference struct {
+	Kind  string `json:"kind"` // order, balance or sequence
+	Key   string `json:"key"`
+	Field string `json:"field,omitempty"`
+	A     any    `json:"a"`
+	B     any    `json:"b"`
+}
+
+// report is the diff report written to stdout
+type report struct {
+	Identical   bool         `json:"identical"`
+	A           summary      `json:"a"`
+	B           summary      `json:"b"`
+	Differences []difference `json:"differences"`
+}
+
+// field is a named value compared between the deployments
+type field struct {
+	name  string
+	value any
+}
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
+	var a, b dsnList
+	fs := flag.NewFlagSet("diffstate", flag.ExitOnError)
+	fs.Var(&a, "a", "DSN of a shard of deployment A, primary first; repeat for each shard")
+	fs.Var(&b, "b", "DSN of a shard of deployment B, primary first; repeat for each shard")
+	fs.Parse(os.Args[1:])
+	if len(a) == 0 || len(b) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: diffstate -a <dsn> [-a <shard dsn>...] -b <dsn> [-b <shard dsn>...]")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	stateA, err := load(ctx, a)
+	if err != nil {
+		logger.Error("Failed to read deployment A", zap.Error(err))
+		os.Exit(2)
+	}
+	stateB, err := load(ctx, b)
+	if err != nil {
+		logger.Error("Failed to read deployment B", zap.Error(err))
+		os.Exit(2)
+	}
+
+	r := report{A: stateA.summary(), B: stateB.summary(), Differences: diff(stateA, stateB)}
+	r.Identical = len(r.Differences) == 0
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		logger.Error("Failed to write report", zap.Error(err))
+		os.Exit(2)
+	}
+	if !r.Identical {
+		os.Exit(1)
+	}
+}
+
+// load reads the state of a deployment from each of its shards
+func load(ctx context.Context, dsns []string) (*state, error) {
+	s := &state{orders: make(map[uint64]*models.Order), balances: make(map[string]*models.Balance)}
+	for i, dsn := range dsns {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %v", i, err)
+		}
+		defer db.Close()
+		if err := s.add(ctx, repository.NewMySQLRepository(db)); err != nil {
+			return nil, fmt.Errorf("shard %d: %v", i, err)
+		}
+	}
+	return s, nil
+}
+
+// add merges a shard into the state
+func (s *state) add(ctx context.Context, shard *repository.MySQLRepository) error {
+	orders, err := shard.GetActiveOrders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		s.orders[o.OrderID] = o
+	}
+
+	// Each shard keeps the part of an account's balance produced by its symbols
+	balances, err := shard.GetAllBalances(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range balances {
+		key := b.AccountID + "/" + b.Currency
+		total, ok := s.balances[key]
+		if !ok {
+			total = &models.Balance{AccountID: b.AccountID, Currency: b.Currency}
+			s.balances[key] = total
+		}
+		total.Settled += b.Settled
+		total.Pending += b.Pending
+	}
+
+	var seq sequences
+	if seq.OrderSequence, err = shard.GetMaxOrderSequence(ctx); err != nil {
+		return err
+	}
+	if seq.LastTradeID, err = shard.GetLastTradeID(ctx); err != nil {
+		return err
+	}
+	if seq.LastOrderEventID, err = shard.GetLastOrderEventID(ctx); err != nil {
+		return err
+	}
+	s.sequences = append(s.sequences, seq)
+	return nil
+}
+
+func (s *state) summary() summary {
+	return summary{Shards: len(s.sequences), OpenOrders: len(s.orders), Balances: len(s.balances), Sequences: s.sequences}
+}
+
+// diff lists the differences between two states, orders and balances in key order
+func diff(a, b *state) []difference {
+	differences := []difference{}
+
+	ids := make(map[uint64]bool)
+	for id := range a.orders {
+		ids[id] = true
+	}
+	for id := range b.orders {
+		ids[id] = true
+	}
+	orderIDs := make([]uint64, 0, len(ids))
+	for id := range ids {
+		orderIDs = append(orderIDs, id)
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+	for _, id := range orderIDs {
+		key := strconv.FormatUint(id, 10)
+		differences = append(differences, compare("order", key, a.orders[id], b.orders[id], orderFields)...)
+	}
+
+	keys := make(map[string]bool)
+	for key := range a.balances {
+		keys[key] = true
+	}
+	for key := range b.balances {
+		keys[key] = true
+	}
+	balanceKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		balanceKeys = append(balanceKeys, key)
+	}
+	sort.Strings(balanceKeys)
+	for _, key := range balanceKeys {
+		differences = append(differences, compare("balance", key, a.balances[key], b.balances[key], balanceFields)...)
+	}
+
+	// Sequences are only comparable shard by shard between deployments sharded alike
+	if len(a.sequences) != len(b.sequences) {
+		return append(differences, difference{Kind: "sequence", Key: "shards", A: len(a.sequences), B: len(b.sequences)})
+	}
+	for i := range a.sequences {
+		key := "shard " + strconv.Itoa(i)
+		differences = append(differences, compare("sequence", key, &a.sequences[i], &b.sequences[i], sequenceFields)...)
+	}
+	return differences
+}
+
+// compare lists the differing fields of a row in both deployments, or the row itself when it
+// exists in only one of them
+func compare[T any](kind, key string, a, b *T, fields func(*T) []field) []difference {
+	switch {
+	case a == nil:
+		return []difference{{Kind: kind, Key: key, B: b}}
+	case b == nil:
+		return []difference{{Kind: kind, Key: key, A: a}}
+	}
+	var differences []difference
+	fieldsB := fields(b)
+	for i, f := range fields(a) {
+		if f.value != fieldsB[i].value {
+			differences = append(differences, difference{Kind: kind, Key: key, Field: f.name, A: f.value, B: fieldsB[i].value})
+		}
+	}
+	return differences
+}
+
+func orderFields(o *models.Order) []field {
+	return []field{
+		{"symbol", o.Symbol},
+		{"side", o.Side},
+		{"type", o.Type},
+		{"price", nullDecimal(o.Price)},
+		{"initial_quantity", o.InitialQuantity},
+		{"remaining_quantity", o.RemainingQuantity},
+		{"status", o.Status},
+		{"owner_id", o.OwnerID},
+		{"time_in_force", o.TimeInForce},
+		{"trigger_price", nullDecimal(o.TriggerPrice)},
+		{"display_quantity", nullDecimal(o.DisplayQuantity)},
+		{"sequence", o.Sequence},
+		{"parent_id", nullInt(o.ParentID)},
+		{"group_id", nullInt(o.GroupID)},
+	}
+}
+
+// balanceFields rounds balances to the 8 decimals they are stored with, so that sums of the
+// same shard parts in a different order compare equal
+func balanceFields(b *models.Balance) []field {
+	return []field{
+		{"settled", math.Round(b.Settled*1e8) / 1e8},
+		{"pending", math.Round(b.Pending*1e8) / 1e8},
+	}
+}
+
+func sequenceFields(s *sequences) []field {
+	return []field{
+		{"order_sequence", s.OrderSequence},
+		{"last_trade_id", s.LastTradeID},
+		{"last_order_event_id", s.LastOrderEventID},
+	}
+}
+
+// nullDecimal returns a nullable decimal's value, nil when null
+func nullDecimal(n models.NullDecimal) any {
+	if !n.Valid {
+		return nil
+	}
+	return n.Decimal
+}
+
+// nullInt returns a nullable ID's value, nil when null
+func nullInt(n sql.NullInt64) any {
+	if !n.Valid {
+		return nil
+	}
+	return n.Int64
+}
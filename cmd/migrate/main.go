@@ -0,0 +1,255 @@
+// Command migrate runs and inspects the database migrations apart from the server, which only
+// applies pending ones at startup and refuses a database left dirty by a failed migration:
+//
+//	go run cmd/migrate/main.go status|version [-shard N] [server flags]
+//	go run cmd/migrate/main.go up|down [N] [-dry-run] [-shard N] [server flags]
+//	go run cmd/migrate/main.go force VERSION [-shard N] [server flags]
+//
+// up applies every pending migration, or up to the next N; down rolls back the last one, or up
+// to the last N; -dry-run lists the migrations either would run without running them. force records a
+// database as clean at VERSION once its failed migration has been repaired by hand. Commands
+// act on the primary database and every shard in turn, or with -shard on that shard alone.
+// It takes the server's configuration, so it migrates MySQL or SQLite as the server would.
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"orderSystem/internal/config"
+	"orderSystem/internal/migration"
+	"orderSystem/internal/repository"
+	"os"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: migrate status|version [-shard N] [server flags]
+       migrate up [N] [-dry-run] [-shard N] [server flags]
+       migrate down [N] [-dry-run] [-shard N] [server flags]
+       migrate force VERSION [-shard N] [server flags]`
+
+// command is a parsed command line
+type command struct {
+	name   string
+	number int // steps for up and down, the version for force
+	dryRun bool
+	shard  int // -1 for every database
+}
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
+	cmd, rest, err := parseCommand(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cfg, err := config.Load(logger, rest)
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+
+	migrators, err := openMigrators(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to open database", zap.Error(err))
+	}
+	if cmd.shard >= len(migrators) {
+		logger.Fatal("No such shard", zap.Int("shard", cmd.shard), zap.Int("shards", len(migrators)))
+	}
+	for i, migrator := range migrators {
+		if cmd.shard >= 0 && i != cmd.shard {
+			continue
+		}
+		if len(migrators) > 1 {
+			fmt.Printf("shard %d:\n", i)
+		}
+		if err := run(migrator, cmd); err != nil {
+			logger.Fatal("Migration command failed", zap.String("command", cmd.name), zap.Int("shard", i), zap.Error(err))
+		}
+	}
+}
+
+// parseCommand reads the command and its arguments, returning the server flags that follow
+func parseCommand(args []string) (command, []string, error) {
+	if len(args) == 0 {
+		return command{}, nil, fmt.Errorf("missing command")
+	}
+	cmd := command{name: args[0], shard: -1}
+	args = args[1:]
+	switch cmd.name {
+	case "status", "version":
+	case "up", "down", "force":
+		if cmd.name == "down" {
+			cmd.number = 1
+		}
+		var n int
+		var err error
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+		}
+		if len(args) == 0 || err != nil {
+			if cmd.name == "force" {
+				return command{}, nil, fmt.Errorf("force needs the version to record")
+			}
+			break
+		}
+		if n < 0 || n == 0 && cmd.name != "force" {
+			return command{}, nil, fmt.Errorf("invalid %s argument %d", cmd.name, n)
+		}
+		cmd.number = n
+		args = args[1:]
+	default:
+		return command{}, nil, fmt.Errorf("unknown command %q", cmd.name)
+	}
+
+	for len(args) > 0 {
+		switch {
+		case args[0] == "-dry-run" && (cmd.name == "up" || cmd.name == "down"):
+			cmd.dryRun = true
+			args = args[1:]
+		case args[0] == "-shard" && len(args) > 1:
+			shard, err := strconv.Atoi(args[1])
+			if err != nil || shard < 0 {
+				return command{}, nil, fmt.Errorf("invalid shard %q", args[1])
+			}
+			cmd.shard = shard
+			args = args[2:]
+		case !strings.HasPrefix(args[0], "-"):
+			return command{}, nil, fmt.Errorf("unexpected argument %q", args[0])
+		default:
+			return cmd, args, nil
+		}
+	}
+	return cmd, args, nil
+}
+
+// openMigrators prepares the migrations of every configured database, the primary first
+func openMigrators(cfg config.DatabaseConfig) ([]*migration.Migrator, error) {
+	if cfg.Storage == config.StorageSQLite {
+		db, err := repository.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := migration.NewSQLiteMigrator(db)
+		if err != nil {
+			return nil, err
+		}
+		return []*migration.Migrator{migrator}, nil
+	}
+
+	var migrators []*migration.Migrator
+	for _, dsn := range append([]string{cfg.DSN}, cfg.ShardDSNs...) {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := migration.NewMigrator(db)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %v", len(migrators), err)
+		}
+		migrators = append(migrators, migrator)
+	}
+	return migrators, nil
+}
+
+// run runs a command against one database
+func run(migrator *migration.Migrator, cmd command) error {
+	switch cmd.name {
+	case "version":
+		version, dirty, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+		printVersion(version, dirty)
+		return nil
+	case "status":
+		return printStatus(migrator)
+	case "force":
+		return migrator.Force(cmd.number)
+	}
+
+	if cmd.dryRun {
+		planned, err := plan(migrator, cmd)
+		if err != nil {
+			return err
+		}
+		if len(planned) == 0 {
+			fmt.Println("nothing to run")
+		}
+		for _, m := range planned {
+			fmt.Printf("would %s %06d %s\n", cmd.name, m.Version, m.Name)
+		}
+		return nil
+	}
+	if cmd.name == "down" {
+		return migrator.Down(cmd.number)
+	}
+	return migrator.Up(cmd.number)
+}
+
+// plan lists the migrations an up or down command would run, in the order it would run them
+func plan(migrator *migration.Migrator, cmd command) ([]migration.Migration, error) {
+	list, err := migrator.Status()
+	if err != nil {
+		return nil, err
+	}
+	var planned []migration.Migration
+	if cmd.name == "down" {
+		for i := len(list) - 1; i >= 0 && len(planned) < cmd.number; i-- {
+			if list[i].Applied {
+				planned = append(planned, list[i])
+			}
+		}
+		return planned, nil
+	}
+	for _, m := range list {
+		if !m.Applied && (cmd.number == 0 || len(planned) < cmd.number) {
+			planned = append(planned, m)
+		}
+	}
+	return planned, nil
+}
+
+// printVersion prints the version a database is at
+func printVersion(version uint, dirty bool) {
+	if dirty {
+		fmt.Printf("version %d (dirty: its migration failed part way; repair it, then force a version)\n", version)
+		return
+	}
+	fmt.Printf("version %d\n", version)
+}
+
+// printStatus prints a database's version and every migration, applied or pending
+func printStatus(migrator *migration.Migrator) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	list, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	printVersion(version, dirty)
+	pending := 0
+	for _, m := range list {
+		state := "applied"
+		if !m.Applied {
+			state = "pending"
+			pending++
+		} else if dirty && m.Version == version {
+			state = "dirty"
+		}
+		fmt.Printf("  %-7s %06d %s\n", state, m.Version, m.Name)
+	}
+	fmt.Printf("%d pending\n", pending)
+	return nil
+}
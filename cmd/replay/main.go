@@ -0,0 +1,339 @@
+// Command replay rebuilds the order books from the engine journal in an empty database and
+// checks that matching is deterministic, writing a JSON report to stdout:
+//
+//	go run cmd/replay/main.go <target dsn> [server flags]
+//
+// It takes the server's configuration and reads the journal from its databases, so the risk
+// limits and symbol settings the journal was written under apply to the replay; nothing is
+// written to them. The target database is migrated, must hold no orders and gets a copy of the
+// symbol registry. Each symbol's commands are applied in journal order without the credit check
+// or balance holds or sandbox simulation, taking the last look rejections from the journal rather
+// than offering last looks, and the trades each one prints are compared with those journaled,
+// ignoring trade IDs. The replayed open orders and pending stop orders are
+// then compared with the source's. It exits 0 when the replay matches, 1 when it differs and 2
+// on error.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"orderSystem/internal/config"
+	"orderSystem/internal/migration"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/service"
+	"os"
+	"sort"
+	"strconv"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+// journalBatch is how many journaled commands are read at a time
+const journalBatch = 1000
+
+// symbolSummary describes a replayed symbol in the report
+type symbolSummary struct {
+	Symbol     string `json:"symbol"`
+	Commands   int    `json:"commands"`
+	Trades     int    `json:"trades"`
+	OpenOrders int    `json:"open_orders"`
+}
+
+// difference is something the replay re-derived differently from the source. Field is empty
+// and Source or Replay null when the order exists on one side only.
+type difference struct {
+	Kind   string `json:"kind"` // command, trades or order
+	Key    string `json:"key"`
+	Field  string `json:"field,omitempty"`
+	Source any    `json:"source"`
+	Replay any    `json:"replay"`
+}
+
+// report is the replay report written to stdout
+type report struct {
+	Identical   bool            `json:"identical"`
+	Symbols     []symbolSummary `json:"symbols"`
+	Differences []difference    `json:"differences"`
+}
+
+// trade is what is compared of a trade, its ID being drawn at random
+type trade struct {
+	BuyOrderID  uint64           `json:"buy_order_id"`
+	SellOrderID uint64           `json:"sell_order_id"`
+	Price       string           `json:"price"`
+	Quantity    string           `json:"quantity"`
+	PrintType   models.PrintType `json:"print_type"`
+}
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: replay <target dsn> [server flags]")
+		os.Exit(2)
+	}
+	cfg, err := config.Load(logger, os.Args[2:])
+	if err != nil {
+		logger.Error("Failed to load configuration", zap.Error(err))
+		os.Exit(2)
+	}
+	ctx := context.Background()
+
+	var dbs []*sql.DB
+	for _, dsn := range append([]string{cfg.Database.DSN}, cfg.Database.ShardDSNs...) {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			logger.Error("Failed to connect to database", zap.Error(err))
+			os.Exit(2)
+		}
+		defer db.Close()
+		dbs = append(dbs, db)
+	}
+	var source repository.Repository = repository.NewMySQLRepository(dbs[0])
+	if len(dbs) > 1 {
+		source = repository.NewShardedRepository(dbs)
+	}
+
+	target, err := openTarget(ctx, os.Args[1])
+	if err != nil {
+		logger.Error("Failed to prepare target database", zap.Error(err))
+		os.Exit(2)
+	}
+	if err := copySymbols(ctx, source, target); err != nil {
+		logger.Error("Failed to copy symbol registry", zap.Error(err))
+		os.Exit(2)
+	}
+
+	replayCfg := *cfg
+	replayCfg.Sandbox = config.SandboxConfig{}
+	replayCfg.Risk.CreditCheck = config.CreditNone
+	replayCfg.Engine.Journal = false
+	replayer := service.NewReplayer(service.NewMatchingService(ctx, target, &replayCfg, logger))
+
+	symbols, err := source.GetJournalSymbols(ctx)
+	if err != nil {
+		logger.Error("Failed to list journaled symbols", zap.Error(err))
+		os.Exit(2)
+	}
+	r := report{Symbols: []symbolSummary{}, Differences: []difference{}}
+	for _, symbol := range symbols {
+		summary, differences, err := replay(ctx, source, target, replayer, symbol)
+		if err != nil {
+			logger.Error("Failed to replay symbol", zap.String("symbol", symbol), zap.Error(err))
+			os.Exit(2)
+		}
+		r.Symbols = append(r.Symbols, summary)
+		r.Differences = append(r.Differences, differences...)
+	}
+
+	r.Identical = len(r.Differences) == 0
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		logger.Error("Failed to write report", zap.Error(err))
+		os.Exit(2)
+	}
+	if !r.Identical {
+		os.Exit(1)
+	}
+}
+
+// openTarget connects to and migrates the target database, refusing one that holds orders
+func openTarget(ctx context.Context, dsn string) (*repository.MySQLRepository, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migration.RunMigrations(db); err != nil {
+		return nil, err
+	}
+	target := repository.NewMySQLRepository(db)
+	orders, err := target.GetActiveOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last, err := target.GetLastTradeID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) > 0 || last > 0 {
+		return nil, fmt.Errorf("target database is not empty")
+	}
+	return target, nil
+}
+
+// copySymbols lists the source's registry in the target, delisted symbols as active since they
+// took the journaled orders before they were delisted
+func copySymbols(ctx context.Context, source repository.Repository, target *repository.MySQLRepository) error {
+	symbols, err := source.GetSymbols(ctx)
+	if err != nil {
+		return err
+	}
+	for _, symbol := range symbols {
+		symbol.Status = models.SymbolActive
+		if err := target.SaveSymbol(ctx, symbol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replay applies a symbol's journal and lists where the replay differs from the source
+func replay(ctx context.Context, source repository.Repository, target *repository.MySQLRepository, replayer *service.Replayer, symbol string) (symbolSummary, []difference, error) {
+	summary := symbolSummary{Symbol: symbol}
+	var differences []difference
+	var after uint64
+	for {
+		entries, err := source.GetJournal(ctx, symbol, after, journalBatch)
+		if err != nil {
+			return summary, nil, err
+		}
+		for _, entry := range entries {
+			key := symbol + "/" + strconv.FormatUint(entry.Sequence, 10)
+			trades, err := replayer.Apply(entry)
+			if err != nil {
+				// The journal holds only commands that took effect
+				differences = append(differences, difference{Kind: "command", Key: key, Field: string(entry.Kind), Source: "applied", Replay: err.Error()})
+				continue
+			}
+			journaled, replayed := tradeViews(entry.Trades), tradeViews(trades)
+			if !equalTrades(journaled, replayed) {
+				differences = append(differences, difference{Kind: "trades", Key: key, Field: string(entry.Kind), Source: journaled, Replay: replayed})
+			}
+			summary.Commands++
+			summary.Trades += len(trades)
+			after = entry.Sequence
+		}
+		if len(entries) < journalBatch {
+			break
+		}
+	}
+
+	sourceOrders, err := activeOrders(ctx, source, symbol)
+	if err != nil {
+		return summary, nil, err
+	}
+	replayOrders, err := activeOrders(ctx, target, symbol)
+	if err != nil {
+		return summary, nil, err
+	}
+	summary.OpenOrders = len(replayOrders)
+	return summary, append(differences, diffOrders(sourceOrders, replayOrders)...), nil
+}
+
+// activeOrders returns a symbol's open and pending stop orders by order ID
+func activeOrders(ctx context.Context, repo repository.Repository, symbol string) (map[uint64]*models.Order, error) {
+	book, err := repo.GetOrderBook(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	stops, err := repo.GetPendingStops(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orders := make(map[uint64]*models.Order)
+	for _, o := range append(book, stops...) {
+		if o.Symbol == symbol {
+			orders[o.OrderID] = o
+		}
+	}
+	return orders, nil
+}
+
+func tradeViews(trades []*models.Trade) []trade {
+	views := make([]trade, len(trades))
+	for i, t := range trades {
+		views[i] = trade{t.BuyOrderID, t.SellOrderID, t.Price.String(), t.Quantity.String(), t.PrintType}
+	}
+	return views
+}
+
+func equalTrades(a, b []trade) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffOrders lists the differing fields of each order in order ID order, or the order itself
+// when only one side holds it
+func diffOrders(source, replayed map[uint64]*models.Order) []difference {
+	ids := make(map[uint64]bool)
+	for id := range source {
+		ids[id] = true
+	}
+	for id := range replayed {
+		ids[id] = true
+	}
+	orderIDs := make([]uint64, 0, len(ids))
+	for id := range ids {
+		orderIDs = append(orderIDs, id)
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+
+	var differences []difference
+	for _, id := range orderIDs {
+		key := strconv.FormatUint(id, 10)
+		a, b := source[id], replayed[id]
+		switch {
+		case a == nil:
+			differences = append(differences, difference{Kind: "order", Key: key, Replay: b})
+			continue
+		case b == nil:
+			differences = append(differences, difference{Kind: "order", Key: key, Source: a})
+			continue
+		}
+		fieldsB := orderFields(b)
+		for i, f := range orderFields(a) {
+			if f.value != fieldsB[i].value {
+				differences = append(differences, difference{Kind: "order", Key: key, Field: f.name, Source: f.value, Replay: fieldsB[i].value})
+			}
+		}
+	}
+	return differences
+}
+
+// field is a named value compared between the source and the replay
+type field struct {
+	name  string
+	value any
+}
+
+// orderFields leaves out the order sequence, which the engines of all symbols draw from
+// together and so depends on how the source interleaved them
+func orderFields(o *models.Order) []field {
+	return []field{
+		{"side", o.Side},
+		{"type", o.Type},
+		{"price", nullDecimal(o.Price)},
+		{"initial_quantity", o.InitialQuantity},
+		{"remaining_quantity", o.RemainingQuantity},
+		{"status", o.Status},
+		{"owner_id", o.OwnerID},
+		{"time_in_force", o.TimeInForce},
+		{"trigger_price", nullDecimal(o.TriggerPrice)},
+		{"display_quantity", nullDecimal(o.DisplayQuantity)},
+	}
+}
+
+// nullDecimal returns a nullable decimal's value, nil when null
+func nullDecimal(n models.NullDecimal) any {
+	if !n.Valid {
+		return nil
+	}
+	return n.Decimal
+}
@@ -0,0 +1,290 @@
+// Command seed provisions a demo market: the symbols of a symbols file listed in the registry,
+// registered accounts holding balances and a resting order book around a mid price for every
+// seeded symbol, so a fresh database has a market to trade against. It takes the server's
+// configuration, with the seed settings in its seed section; a fixed seed.random_seed seeds the
+// same market every time.
+//
+// Symbols already listed and books that already have resting orders are left alone and
+// balances are only topped up, so running it again is harmless. Run it before starting the
+// server, which loads books at startup.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"orderSystem/internal/config"
+	"orderSystem/internal/crypto"
+	"orderSystem/internal/migration"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/service"
+	"os"
+	"sort"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+// demoPrices are the symbols and mid prices seeded when neither seed.prices nor a symbols file
+// gives any
+var demoPrices = map[string]float64{"BTCUSD": 30000, "ETHUSD": 2000, "SOLUSD": 100}
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logger.Sync()
+
+	cfg, err := config.Load(logger, os.Args[1:])
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+	ctx := context.Background()
+
+	// Connect to and migrate the primary and any additional shards, or the SQLite file, as the
+	// server does
+	var repo repository.Repository
+	if cfg.Database.Storage == config.StorageSQLite {
+		db, err := repository.OpenSQLite(cfg.Database.SQLitePath)
+		if err != nil {
+			logger.Fatal("Failed to open SQLite database", zap.Error(err))
+		}
+		defer db.Close()
+		if err := migration.RunSQLiteMigrations(db); err != nil {
+			logger.Fatal("Failed to run database migrations", zap.Error(err))
+		}
+		repo = repository.NewSQLiteRepository(db)
+	} else {
+		var dbs []*sql.DB
+		for _, dsn := range append([]string{cfg.Database.DSN}, cfg.Database.ShardDSNs...) {
+			db, err := sql.Open("mysql", dsn)
+			if err != nil {
+				logger.Fatal("Failed to connect to database", zap.Error(err))
+			}
+			defer db.Close()
+			if err := migration.RunMigrations(db); err != nil {
+				logger.Fatal("Failed to run database migrations", zap.Int("shard", len(dbs)), zap.Error(err))
+			}
+			dbs = append(dbs, db)
+		}
+		repo = repository.NewMySQLRepository(dbs[0])
+		if len(dbs) > 1 {
+			repo = repository.NewShardedRepository(dbs)
+		}
+	}
+	keys, err := crypto.NewKeyring(cfg.Encryption.KeyID, cfg.Encryption.Keys)
+	if err != nil {
+		logger.Fatal("Invalid encryption keys", zap.Error(err))
+	}
+	repo.SealWith(keys)
+
+	// Seeded orders go through matching like any other, so they are persisted, published to the
+	// order event outbox and subject to the configured risk limits and credit check
+	matchingService := service.NewMatchingService(ctx, repo, cfg, logger)
+	if cfg.Risk.CreditCheck == config.CreditBalances {
+		matchingService.SetCreditChecker(service.NewBalanceCreditChecker(repo))
+	}
+
+	// Listing goes through the registry like the admin API, so symbols already listed are kept
+	prices := make(map[string]float64)
+	if cfg.Seed.SymbolsFile != "" {
+		mids, err := listSymbols(ctx, matchingService, cfg.Seed.SymbolsFile, logger)
+		if err != nil {
+			logger.Fatal("Failed to list symbols", zap.String("file", cfg.Seed.SymbolsFile), zap.Error(err))
+		}
+		for symbol, mid := range mids {
+			prices[symbol] = mid
+		}
+	}
+	for symbol, mid := range cfg.Seed.Prices {
+		prices[symbol] = mid
+	}
+	if len(prices) == 0 && cfg.Seed.SymbolsFile == "" {
+		prices = demoPrices
+	}
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	randomSeed := cfg.Seed.RandomSeed
+	if randomSeed == 0 {
+		randomSeed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(randomSeed))
+	accounts := make([]string, cfg.Seed.Accounts)
+	for i := range accounts {
+		accounts[i] = fmt.Sprintf("seed-%03d", i+1)
+		account := &models.Account{AccountID: accounts[i], Name: fmt.Sprintf("Seed account %d", i+1), CreatedAt: time.Now()}
+		if err := repo.SaveAccount(ctx, account); err != nil && repository.Classify(err) != repository.ErrDuplicateKey {
+			logger.Fatal("Failed to register demo account", zap.String("account_id", accounts[i]), zap.Error(err))
+		}
+	}
+	logger.Info("Seeding demo market", zap.Strings("symbols", symbols), zap.Int("accounts", len(accounts)),
+		zap.Int64("random_seed", randomSeed))
+
+	for _, symbol := range symbols {
+		book, err := matchingService.GetOrderBook(symbol)
+		if err != nil {
+			logger.Fatal("Failed to get order book", zap.String("symbol", symbol), zap.Error(err))
+		}
+		if len(book) > 0 {
+			logger.Info("Order book already has resting orders, skipping", zap.String("symbol", symbol), zap.Int("orders", len(book)))
+			continue
+		}
+
+		// Orders are only taken for listed symbols, which always have currencies
+		sc, ok := matchingService.SymbolConfig(ctx, symbol)
+		if listing, err := matchingService.Symbol(ctx, symbol); err != nil || listing.Status != models.SymbolActive || !ok {
+			logger.Warn("Symbol is not listed, skipping; configure it under symbols or list it through the admin API",
+				zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		if err := fund(ctx, matchingService, accounts, sc, prices[symbol], cfg.Seed.Deposit); err != nil {
+			logger.Fatal("Failed to fund demo accounts", zap.String("symbol", symbol), zap.Error(err))
+		}
+		placed := seedBook(matchingService, symbol, prices[symbol], accounts, cfg.Seed, rng, logger)
+		logger.Info("Order book seeded", zap.String("symbol", symbol), zap.Int("orders", placed))
+	}
+}
+
+// registryEntry is a symbol of a symbols file, as GET /admin/symbols returns it, with the mid
+// price to seed its book at
+type registryEntry struct {
+	Symbol        string              `json:"symbol"`
+	BaseCurrency  string              `json:"base_currency"`
+	QuoteCurrency string              `json:"quote_currency"`
+	TickSize      models.Decimal      `json:"tick_size"`
+	LotSize       models.Decimal      `json:"lot_size"`
+	MinNotional   float64             `json:"min_notional"`
+	Status        models.SymbolStatus `json:"status"`
+	MidPrice      float64             `json:"mid_price"` // 0 lists the symbol without seeding a book
+}
+
+// listSymbols lists the active symbols of a symbols file that are not in the registry yet, and
+// returns the mid price of those to seed. Symbols already listed keep their registry entry.
+func listSymbols(ctx context.Context, s *service.MatchingService, path string, logger *zap.Logger) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []registryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse symbols file: %v", err)
+	}
+
+	mids := make(map[string]float64)
+	for _, entry := range entries {
+		if entry.Status != "" && entry.Status != models.SymbolActive {
+			logger.Warn("Symbol is not active in the symbols file, skipping", zap.String("symbol", entry.Symbol),
+				zap.String("status", string(entry.Status)))
+			continue
+		}
+		if entry.MidPrice < 0 {
+			return nil, fmt.Errorf("symbol %s: mid_price must not be negative", entry.Symbol)
+		}
+		if entry.MidPrice > 0 {
+			mids[entry.Symbol] = entry.MidPrice
+		}
+		if _, err := s.Symbol(ctx, entry.Symbol); err == nil {
+			logger.Info("Symbol already listed", zap.String("symbol", entry.Symbol))
+			continue
+		} else if err != models.ErrSymbolNotFound {
+			return nil, err
+		}
+		listing := &models.Symbol{
+			Symbol:        entry.Symbol,
+			BaseCurrency:  entry.BaseCurrency,
+			QuoteCurrency: entry.QuoteCurrency,
+			LotSize:       entry.LotSize,
+			MinNotional:   entry.MinNotional,
+		}
+		if err := s.ListSymbol(ctx, listing, entry.TickSize); err != nil {
+			return nil, fmt.Errorf("symbol %s: %v", entry.Symbol, err)
+		}
+	}
+	return mids, nil
+}
+
+// fund tops up every account's settled balances in a symbol's currencies to deposit worth of
+// quote currency, valuing the base currency at the mid price
+func fund(ctx context.Context, s *service.MatchingService, accounts []string, sc config.SymbolConfig, mid, deposit float64) error {
+	targets := map[string]float64{sc.QuoteCurrency: deposit, sc.BaseCurrency: deposit / mid}
+	for _, account := range accounts {
+		balances, err := s.GetBalances(ctx, account)
+		if err != nil {
+			return err
+		}
+		held := make(map[string]float64)
+		for _, balance := range balances {
+			held[balance.Currency] = balance.Settled
+		}
+		for currency, target := range targets {
+			if shortfall := target - held[currency]; shortfall > 0 {
+				if err := s.Deposit(ctx, account, currency, shortfall); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// seedBook places the resting orders of one symbol from the inside out, each for a random
+// account or anonymous when there are none, and returns how many were accepted. Bids are rounded down and asks up to the tick
+// size, so the two sides never cross.
+func seedBook(s *service.MatchingService, symbol string, mid float64, accounts []string, cfg config.SeedConfig,
+	rng *rand.Rand, logger *zap.Logger) int {
+	tick, lot := models.NewDecimal(cfg.TickSize), models.NewDecimal(cfg.LotSize)
+	bestBid, bestAsk := mid*(1-cfg.Spread/2), mid*(1+cfg.Spread/2)
+
+	placed := 0
+	for level := 0; level < cfg.Levels; level++ {
+		offset := mid * cfg.LevelSpacing * float64(level)
+		bid := models.NewDecimal(bestBid-offset) / tick * tick
+		ask := (models.NewDecimal(bestAsk+offset) + tick - 1) / tick * tick
+		mean := cfg.OrderNotional * math.Pow(cfg.DepthGrowth, float64(level))
+
+		for _, quote := range []struct {
+			side  models.OrderSide
+			price models.Decimal
+		}{{models.SideBuy, bid}, {models.SideSell, ask}} {
+			if quote.price <= 0 {
+				continue
+			}
+			for n := 1 + rng.Intn(cfg.OrdersPerLevel); n > 0; n-- {
+				notional := mean * (1 + cfg.Jitter*(2*rng.Float64()-1))
+				quantity := max((models.NewDecimal(notional/quote.price.Float64())+lot/2)/lot*lot, lot)
+				var owner string
+				if len(accounts) > 0 {
+					owner = accounts[rng.Intn(len(accounts))]
+				}
+				order := &models.Order{
+					Symbol:            symbol,
+					Side:              quote.side,
+					Type:              models.TypeLimit,
+					Price:             models.NullDecimal{Decimal: quote.price, Valid: true},
+					InitialQuantity:   quantity,
+					RemainingQuantity: quantity,
+					OwnerID:           owner,
+					TimeInForce:       models.TIFGTC,
+				}
+				if _, err := s.PlaceOrder(context.Background(), order); err != nil {
+					logger.Warn("Seed order rejected", zap.String("symbol", symbol), zap.String("side", string(quote.side)),
+						zap.String("price", quote.price.String()), zap.Error(err))
+					continue
+				}
+				placed++
+			}
+		}
+	}
+	return placed
+}
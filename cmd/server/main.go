@@ -1,15 +1,34 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"net/http"
+	"orderSystem/internal/algo"
 	"orderSystem/internal/api"
+	"orderSystem/internal/archive"
+	"orderSystem/internal/audit"
+	"orderSystem/internal/billing"
+	"orderSystem/internal/candles"
+	"orderSystem/internal/compliance"
 	"orderSystem/internal/config"
+	"orderSystem/internal/crypto"
+	"orderSystem/internal/kafka"
+	"orderSystem/internal/leaderboard"
+	"orderSystem/internal/metrics"
 	"orderSystem/internal/migration"
 	"orderSystem/internal/repository"
+	"orderSystem/internal/risk"
 	"orderSystem/internal/service"
+	"orderSystem/internal/stream"
+	"orderSystem/internal/webhook"
+	"orderSystem/internal/writebehind"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"go.uber.org/zap"
 )
@@ -23,35 +42,210 @@ func main() {
 	defer logger.Sync()
 
 	// Load configuration
-	cfg, err := config.Load(logger)
+	cfg, err := config.Load(logger, os.Args[1:])
 	if err != nil {
 		logger.Fatal("Failed to load configuration", zap.Error(err))
 	}
 
-	// Initialize database connection
-	db, err := sql.Open("mysql", cfg.DatabaseDSN)
+	// An interrupt or SIGTERM cancels startup reads as well as shutting the server down
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Initialize database connections, the primary first and then any additional shards
+	var repo repository.Repository
+	if cfg.Database.Storage == config.StorageSQLite {
+		db, err := repository.OpenSQLite(cfg.Database.SQLitePath)
+		if err != nil {
+			logger.Fatal("Failed to open SQLite database", zap.Error(err))
+		}
+		defer db.Close()
+		if err := migration.RunSQLiteMigrations(db); err != nil {
+			logger.Fatal("Failed to run database migrations", zap.Error(err))
+		}
+		repo = repository.NewSQLiteRepository(db)
+		logger.Info("Using SQLite storage", zap.String("path", cfg.Database.SQLitePath))
+	} else {
+		var dbs []*sql.DB
+		for _, dsn := range append([]string{cfg.Database.DSN}, cfg.Database.ShardDSNs...) {
+			dsn, err := repository.WithTimeouts(dsn, cfg.Database.DialTimeout, cfg.Database.QueryTimeout)
+			if err != nil {
+				logger.Fatal("Invalid database DSN", zap.Int("shard", len(dbs)), zap.Error(err))
+			}
+			db, err := sql.Open("mysql", dsn)
+			if err != nil {
+				logger.Fatal("Failed to connect to database", zap.Error(err))
+			}
+			defer db.Close()
+			db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+			db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+			db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+			db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+			// Run database migrations; every shard carries the full schema
+			if err := migration.RunMigrations(db); err != nil {
+				logger.Fatal("Failed to run database migrations", zap.Int("shard", len(dbs)), zap.Error(err))
+			}
+			dbs = append(dbs, db)
+		}
+
+		// Initialize repository and service
+		repo = repository.NewMySQLRepository(dbs[0])
+		if len(dbs) > 1 {
+			repo = repository.NewShardedRepository(dbs)
+			logger.Info("Database sharding enabled", zap.Int("shards", len(dbs)))
+		}
+	}
+
+	// Sensitive fields are sealed with the configured master keys, or stored as they are without
+	keys, err := crypto.NewKeyring(cfg.Encryption.KeyID, cfg.Encryption.Keys)
 	if err != nil {
-		logger.Fatal("Failed to connect to database", zap.Error(err))
+		logger.Fatal("Invalid encryption keys", zap.Error(err))
+	}
+	if keys == nil {
+		logger.Warn("Encryption at rest is off; sensitive fields are stored unencrypted")
 	}
-	defer db.Close()
+	repo.SealWith(keys)
+	repo = repository.NewInstrumentedRepository(repo, cfg.Database.SlowQueryThreshold, logger)
 
-	// Run database migrations
-	if err := migration.RunMigrations(db); err != nil {
-		logger.Fatal("Failed to run database migrations", zap.Error(err))
+	// With write-behind on the engines' transactions go to a local log and are written to the
+	// shards behind them; whatever the log holds that the shards lack is applied before anything
+	// reads them. The writers outlive the request context so in-flight requests drain into them.
+	var writes *writebehind.Queue
+	writeCtx, stopWrites := context.WithCancel(context.Background())
+	defer stopWrites()
+	if cfg.Engine.WriteBehind {
+		if writes, err = writebehind.Open(ctx, cfg.Engine.WriteBehindDir, repo, cfg.Engine.NodeID, logger); err != nil {
+			logger.Fatal("Failed to open write-behind log", zap.String("dir", cfg.Engine.WriteBehindDir), zap.Error(err))
+		}
+		go writes.Run(writeCtx)
+		logger.Info("Writing matching transactions behind", zap.String("dir", cfg.Engine.WriteBehindDir))
+	}
+	matchingService := service.NewMatchingService(ctx, repo, cfg, logger)
+	if writes != nil {
+		matchingService.SetWriteBehind(writes)
 	}
 
-	// Initialize repository and service
-	repo := repository.NewMySQLRepository(db)
-	matchingService := service.NewMatchingService(repo, logger)
+	// The admin routes need an admin session or an admin signing key; with neither there would
+	// be no way in, so refuse to start rather than serve them to nobody
+	if cfg.Auth.JWTSecret == "" {
+		exists, err := matchingService.HasAdminSigningKey(ctx)
+		if err != nil {
+			logger.Fatal("Failed to look up admin signing keys", zap.Error(err))
+		}
+		if !exists {
+			logger.Fatal("Admin routes need auth.jwt_secret or an admin signing key; create one with cmd/admin create-key")
+		}
+	}
+	switch cfg.Risk.CreditCheck {
+	case config.CreditBalances:
+		matchingService.SetCreditChecker(service.NewBalanceCreditChecker(repo))
+	case config.CreditCollateral:
+		matchingService.SetCreditChecker(service.NewCollateralCreditChecker(matchingService, repo))
+	}
+	var hub *stream.Hub
+	if cfg.Streaming.Enabled {
+		hub = stream.NewHub(cfg.Streaming, logger)
+		matchingService.PublishTo(hub)
+	}
+	var board *leaderboard.Board
+	if cfg.Leaderboard.Enabled {
+		board = leaderboard.NewBoard(cfg.Leaderboard)
+		matchingService.PublishTo(board)
+	}
+	symbols := make([]string, 0, len(cfg.Symbols))
+	for symbol := range cfg.Symbols {
+		symbols = append(symbols, symbol)
+	}
+	matchingService.WarmLoad(symbols)
+	scheduler := algo.NewScheduler(ctx, matchingService, repo, logger)
+	biller := billing.NewBiller(repo, logger)
+	reporter := risk.NewReporter(matchingService, repo, cfg, logger)
+
+	// Start background workers
+	if cfg.Engine.DepthSnapshotInterval > 0 {
+		go matchingService.RunDepthSnapshots(ctx, cfg.Engine.DepthSnapshotInterval, cfg.Engine.DepthSnapshotLevels)
+	}
+	if cfg.Quality.SampleInterval > 0 {
+		go matchingService.RunMarketQuality(ctx, cfg.Quality.SampleInterval)
+	}
+	if cfg.Engine.BookSnapshotInterval > 0 {
+		go matchingService.RunBookSnapshots(ctx, cfg.Engine.BookSnapshotInterval)
+	}
+	if cfg.Engine.StaleOrderSweepInterval > 0 {
+		go matchingService.RunStaleOrderSweep(ctx, cfg.Engine.StaleOrderSweepInterval)
+	}
+	if cfg.Engine.ExpirySweepInterval > 0 {
+		go matchingService.RunExpirySweep(ctx, cfg.Engine.ExpirySweepInterval)
+	}
+	if cfg.Engine.IdleEngineTTL > 0 {
+		go matchingService.RunEngineEviction(ctx, cfg.Engine.IdleEngineTTL)
+	}
+	go matchingService.RunAuctionMonitor(ctx)
+	go scheduler.Run(ctx)
+	go matchingService.RunSettlement(ctx, cfg.Engine.SettlementInterval)
+	go biller.Run(ctx, cfg.Fees.BillingInterval)
+	if cfg.Archive.Enabled {
+		go archive.NewArchiver(repo, cfg.Archive, logger).Run(ctx)
+	}
+	chain := audit.NewChain(repo, cfg.Audit, logger)
+	if cfg.Audit.Enabled {
+		go chain.Run(ctx)
+	}
 
 	// Initialize router
-	router := gin.Default()
-	handler := api.NewHandler(matchingService, logger)
+	router, err := api.NewRouter(cfg.Server)
+	if err != nil {
+		logger.Fatal("Failed to create router", zap.Error(err))
+	}
+	feed := compliance.NewFeed(repo, cfg.Compliance, logger)
+	var users *stream.Router
+	if cfg.Streaming.Enabled {
+		users = stream.NewRouter(feed, repo, cfg.Streaming, logger)
+		matchingService.ReportRejectionsTo(users)
+		matchingService.OfferLastLooksTo(users)
+		go users.Run(ctx, cfg.Compliance.MaxWait)
+	}
+	if cfg.Kafka.Enabled {
+		go kafka.NewPublisher(feed, repo, cfg.Kafka, logger).Run(ctx)
+	}
+	if cfg.Candles.Enabled {
+		go candles.NewAggregator(feed, repo, cfg.Candles, logger).Run(ctx)
+	}
+	var notifier *webhook.Notifier
+	if cfg.Webhooks.Enabled {
+		notifier = webhook.NewNotifier(feed, repo, cfg.Webhooks, logger)
+		go notifier.Run(ctx)
+	}
+	handler := api.NewHandler(matchingService, scheduler, biller, reporter, feed, hub, users, board, chain, notifier, cfg.Public, cfg.Bulkheads, cfg.OrderLimits, cfg.RateLimits, cfg.Signing, cfg.Auth, cfg.Streaming, cfg.Server, logger)
 	api.SetupRoutes(router, handler)
+	metrics.RegisterBookDepth(matchingService.BookDepth)
 
 	// Start server
-	logger.Info("Starting server", zap.String("address", cfg.ServerAddr))
-	if err := router.Run(cfg.ServerAddr); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	go func() {
+		logger.Info("Starting server", zap.String("address", cfg.Server.Addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("Failed to start server", zap.Error(err))
+		}
+	}()
+
+	// Wait for a shutdown signal and drain in-flight requests
+	<-ctx.Done()
+	logger.Info("Shutting down server")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to shut down server gracefully", zap.Error(err))
+	}
+	if writes != nil {
+		if err := writes.Close(shutdownCtx); err != nil {
+			logger.Error("Failed to write behind every queued transaction; the rest is applied at the next start", zap.Error(err))
+		}
 	}
 }
@@ -0,0 +1,242 @@
+package algo
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/service"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tickInterval is how often the scheduler checks parent orders for due slices
+const tickInterval = time.Second
+
+// quantityStep is the lot size slices are rounded to, 0.01
+const quantityStep = models.Decimal(1_000_000)
+
+// parentState tracks an active parent order between slices
+type parentState struct {
+	parent     *models.ParentOrder
+	lastVolume models.Decimal // POV: market volume observed at the previous slice, including own fills
+}
+
+// Scheduler works server-side parent orders by slicing them into child orders
+type Scheduler struct {
+	service *service.MatchingService
+	repo    repository.Repository
+	logger  *zap.Logger
+	mutex   sync.Mutex
+	active  map[uint64]*parentState
+}
+
+// NewScheduler creates a scheduler and resumes parent orders that were active at shutdown
+func NewScheduler(ctx context.Context, s *service.MatchingService, repo repository.Repository, logger *zap.Logger) *Scheduler {
+	scheduler := &Scheduler{
+		service: s,
+		repo:    repo,
+		logger:  logger,
+		active:  make(map[uint64]*parentState),
+	}
+
+	parents, err := repo.GetActiveParentOrders(ctx)
+	if err != nil {
+		logger.Error("Failed to load active parent orders", zap.Error(err))
+	}
+	for _, parent := range parents {
+		scheduler.active[parent.ParentID] = &parentState{parent: parent, lastVolume: s.TradedVolume(parent.Symbol)}
+	}
+	return scheduler
+}
+
+// roundQuantity truncates a quantity to the stored precision
+func roundQuantity(qty models.Decimal) models.Decimal {
+	return qty - qty%quantityStep
+}
+
+// Submit validates and starts working a new parent order
+func (s *Scheduler) Submit(ctx context.Context, parent *models.ParentOrder) error {
+	now := time.Now()
+	if parent.Symbol == "" || parent.TotalQuantity <= 0 || parent.SliceInterval <= 0 || !parent.EndAt.After(now) {
+		return models.ErrInvalidOrder
+	}
+	if parent.LimitPrice.Valid && parent.LimitPrice.Decimal <= 0 {
+		return models.ErrInvalidOrder
+	}
+	switch parent.Strategy {
+	case models.AlgoTWAP:
+	case models.AlgoPOV:
+		if parent.ParticipationRate <= 0 || parent.ParticipationRate >= 1 {
+			return models.ErrInvalidOrder
+		}
+	default:
+		return models.ErrInvalidOrder
+	}
+
+	parent.ParentID = s.service.NextID()
+	parent.FilledQuantity = 0
+	parent.Status = models.ParentActive
+	parent.CreatedAt = now
+	parent.NextSliceAt = now
+	if parent.Strategy == models.AlgoPOV {
+		// POV participates in volume traded after submission
+		parent.NextSliceAt = now.Add(parent.SliceInterval)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.repo.SaveParentOrder(ctx, parent); err != nil {
+		s.logger.Error("Failed to save parent order", zap.Error(err))
+		return err
+	}
+	s.active[parent.ParentID] = &parentState{parent: parent, lastVolume: s.service.TradedVolume(parent.Symbol)}
+	s.logger.Info("Parent order accepted", zap.Uint64("parent_id", parent.ParentID), zap.String("strategy", string(parent.Strategy)))
+	return nil
+}
+
+// Cancel stops working a parent order; child orders already executed are unaffected
+func (s *Scheduler) Cancel(ctx context.Context, parentID uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.active[parentID]
+	if !ok {
+		if _, err := s.repo.GetParentOrder(ctx, parentID); err != nil {
+			return err
+		}
+		return models.ErrParentNotActive
+	}
+	state.parent.Status = models.ParentCanceled
+	if err := s.repo.UpdateParentOrder(ctx, state.parent); err != nil {
+		s.logger.Error("Failed to cancel parent order", zap.Error(err))
+		state.parent.Status = models.ParentActive
+		return err
+	}
+	delete(s.active, parentID)
+	s.logger.Info("Parent order canceled", zap.Uint64("parent_id", parentID))
+	return nil
+}
+
+// Get retrieves a parent order together with its child orders
+func (s *Scheduler) Get(ctx context.Context, parentID uint64) (*models.ParentOrder, []*models.Order, error) {
+	s.mutex.Lock()
+	var parent *models.ParentOrder
+	if state, ok := s.active[parentID]; ok {
+		p := *state.parent
+		parent = &p
+	}
+	s.mutex.Unlock()
+
+	if parent == nil {
+		var err error
+		if parent, err = s.repo.GetParentOrder(ctx, parentID); err != nil {
+			return nil, nil, err
+		}
+	}
+	children, err := s.repo.GetChildOrders(ctx, parentID)
+	if err != nil {
+		s.logger.Error("Failed to get child orders", zap.Error(err))
+		return nil, nil, err
+	}
+	return parent, children, nil
+}
+
+// Run places due child orders on every tick until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mutex.Lock()
+			for id, state := range s.active {
+				if now.Before(state.parent.NextSliceAt) {
+					continue
+				}
+				s.slice(ctx, state, now)
+				if state.parent.Status != models.ParentActive {
+					delete(s.active, id)
+				}
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// sliceQuantity computes the size of the next child order
+func (s *Scheduler) sliceQuantity(state *parentState, now time.Time) models.Decimal {
+	parent := state.parent
+	remaining := parent.TotalQuantity - parent.FilledQuantity
+
+	switch parent.Strategy {
+	case models.AlgoTWAP:
+		slicesLeft := math.Ceil(float64(parent.EndAt.Sub(now)) / float64(parent.SliceInterval))
+		if slicesLeft <= 1 {
+			return remaining
+		}
+		return roundQuantity(remaining / models.Decimal(slicesLeft))
+	case models.AlgoPOV:
+		volume := s.service.TradedVolume(parent.Symbol)
+		delta := volume - state.lastVolume
+		state.lastVolume = volume
+		return roundQuantity(min(remaining, delta.Mul(models.NewDecimal(parent.ParticipationRate))))
+	}
+	return 0
+}
+
+// slice places one child order for a parent and records its progress. Callers must hold the mutex.
+func (s *Scheduler) slice(ctx context.Context, state *parentState, now time.Time) {
+	parent := state.parent
+	if qty := s.sliceQuantity(state, now); qty > 0 {
+		filled, err := s.placeChild(parent, qty)
+		if err != nil {
+			s.logger.Warn("Child order failed", zap.Uint64("parent_id", parent.ParentID), zap.Error(err))
+		}
+		parent.FilledQuantity += filled
+		// Own fills are part of market volume but must not drive further participation
+		state.lastVolume += filled
+	}
+
+	parent.NextSliceAt = now.Add(parent.SliceInterval)
+	if parent.FilledQuantity >= parent.TotalQuantity || !now.Before(parent.EndAt) {
+		parent.Status = models.ParentCompleted
+	}
+	if err := s.repo.UpdateParentOrder(ctx, parent); err != nil {
+		s.logger.Error("Failed to update parent order", zap.Uint64("parent_id", parent.ParentID), zap.Error(err))
+	}
+	if parent.Status == models.ParentCompleted {
+		s.logger.Info("Parent order completed", zap.Uint64("parent_id", parent.ParentID),
+			zap.Stringer("filled", parent.FilledQuantity))
+	}
+}
+
+// placeChild submits an immediate-or-cancel child order so each slice executes immediately
+// or not at all, returning the filled quantity
+func (s *Scheduler) placeChild(parent *models.ParentOrder, qty models.Decimal) (models.Decimal, error) {
+	order := &models.Order{
+		Symbol:            parent.Symbol,
+		Side:              parent.Side,
+		Type:              models.TypeMarket,
+		InitialQuantity:   qty,
+		RemainingQuantity: qty,
+		ParentID:          sql.NullInt64{Int64: int64(parent.ParentID), Valid: true},
+		OwnerID:           parent.OwnerID,
+		TimeInForce:       models.TIFIOC,
+	}
+	if parent.LimitPrice.Valid {
+		order.Type = models.TypeLimit
+		order.Price = parent.LimitPrice
+	}
+
+	if _, err := s.service.PlaceOrder(context.Background(), order); err != nil {
+		return 0, err
+	}
+	return order.InitialQuantity - order.RemainingQuantity, nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// createAccount handles POST /accounts
+func (h *Handler) createAccount(c *gin.Context) {
+	var req CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid account request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	account, key, err := h.service.CreateAccount(c.Request.Context(), req.Name)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	firstKey := newSigningKeyResponse(key, true)
+	c.JSON(http.StatusCreated, AccountResponse{
+		AccountID:  account.AccountID,
+		Name:       account.Name,
+		CreatedAt:  account.CreatedAt,
+		SigningKey: &firstKey,
+	})
+}
+
+// getAccount handles GET /account
+func (h *Handler) getAccount(c *gin.Context) {
+	id, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	account, err := h.service.GetAccount(c.Request.Context(), id)
+	if err == models.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, AccountResponse{AccountID: account.AccountID, Name: account.Name, CreatedAt: account.CreatedAt})
+}
+
+// ownsOrder checks that the caller placed an order before it is changed, responding with 404
+// when it did not, so other accounts' orders can neither be touched nor probed. Anonymous orders
+// are only open to anonymous callers.
+func (h *Handler) ownsOrder(c *gin.Context, orderID uint64) bool {
+	owner, err := h.service.OrderOwner(c.Request.Context(), orderID)
+	if err == nil && owner != accountID(c) {
+		h.logger.Warn("Order belongs to another account", zap.Uint64("order_id", orderID), zap.String("account_id", accountID(c)))
+		err = models.ErrOrderNotFound
+	}
+	if err == models.ErrOrderNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+	} else if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	}
+	return err == nil
+}
+
+// ownsParentOrder checks that the caller placed an algo parent order, like ownsOrder
+func (h *Handler) ownsParentOrder(c *gin.Context, parentID uint64) bool {
+	parent, _, err := h.algos.Get(c.Request.Context(), parentID)
+	if err == nil && parent.OwnerID != accountID(c) {
+		h.logger.Warn("Parent order belongs to another account", zap.Uint64("parent_id", parentID), zap.String("account_id", accountID(c)))
+		err = models.ErrParentNotFound
+	}
+	if err == models.ErrParentNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Parent order not found"})
+	} else if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	}
+	return err == nil
+}
+
+// ownsOrderGroup checks that the caller placed an order group, like ownsOrder
+func (h *Handler) ownsOrderGroup(c *gin.Context, groupID uint64) bool {
+	owner, err := h.service.OrderGroupOwner(c.Request.Context(), groupID)
+	if err == nil && owner != accountID(c) {
+		h.logger.Warn("Order group belongs to another account", zap.Uint64("group_id", groupID), zap.String("account_id", accountID(c)))
+		err = models.ErrGroupNotFound
+	}
+	if err == models.ErrGroupNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order group not found"})
+	} else if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	}
+	return err == nil
+}
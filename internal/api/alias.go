@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// orderAliaser maps order IDs to the identifiers shown in public feeds. When anonymizing, an
+// order's alias is stable within a rotation period, so an order can be followed across the
+// book and the tape for a while, but cannot be linked to its real ID or to earlier periods.
+type orderAliaser struct {
+	anonymize bool
+	rotation  time.Duration
+
+	mutex sync.Mutex
+	epoch int64
+	key   []byte
+}
+
+// newOrderAliaser creates an aliaser; without anonymize it shows real order IDs
+func newOrderAliaser(anonymize bool, rotation time.Duration) *orderAliaser {
+	return &orderAliaser{anonymize: anonymize, rotation: rotation, epoch: -1}
+}
+
+// alias returns the public identifier of an order
+func (a *orderAliaser) alias(orderID uint64) string {
+	if !a.anonymize {
+		return strconv.FormatUint(orderID, 10)
+	}
+
+	mac := hmac.New(sha256.New, a.currentKey())
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], orderID)
+	mac.Write(id[:])
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// currentKey returns the secret of the current rotation period, drawing a fresh one when a
+// period starts; keys are never persisted, so aliases also change across restarts
+func (a *orderAliaser) currentKey() []byte {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	epoch := time.Now().UnixNano() / int64(a.rotation)
+	if epoch != a.epoch {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("crypto/rand failed: " + err.Error())
+		}
+		a.epoch, a.key = epoch, key
+	}
+	return a.key
+}
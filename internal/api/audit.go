@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/audit"
+	"orderSystem/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// summaryDays is how many completed days the daily summary endpoints cover by default
+const summaryDays = 7
+
+// bindSummaryDays binds the days of a daily summary query, responding with 400 when invalid
+func (h *Handler) bindSummaryDays(c *gin.Context) (from, to time.Time, ok bool) {
+	var req DailySummariesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid daily summary query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return from, to, false
+	}
+	to = req.To.UTC().Truncate(24 * time.Hour)
+	if req.To.IsZero() {
+		to = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	}
+	from = req.From.UTC().Truncate(24 * time.Hour)
+	if req.From.IsZero() {
+		from = to.AddDate(0, 0, 1-summaryDays)
+	}
+	if from.After(to) {
+		h.logger.Warn("Invalid daily summary range", zap.Time("from", from), zap.Time("to", to))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must not be after to"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// getDailySummaries handles GET /admin/daily-summaries?from={day}&to={day}
+func (h *Handler) getDailySummaries(c *gin.Context) {
+	from, to, ok := h.bindSummaryDays(c)
+	if !ok {
+		return
+	}
+
+	summaries, err := h.chain.Summaries(c.Request.Context(), from, to)
+	if err != nil {
+		h.logger.Error("Failed to get daily summaries", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	response := make([]DailySummaryResponse, len(summaries))
+	for i, s := range summaries {
+		response[i] = newDailySummaryResponse(s)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// verifyDailySummaries handles GET /admin/daily-summaries/verify?from={day}&to={day}
+func (h *Handler) verifyDailySummaries(c *gin.Context) {
+	from, to, ok := h.bindSummaryDays(c)
+	if !ok {
+		return
+	}
+
+	checked, discrepancies, err := h.chain.Verify(c.Request.Context(), from, to)
+	if err != nil {
+		h.logger.Error("Failed to verify daily summaries", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	if len(discrepancies) > 0 {
+		h.logger.Warn("Daily summaries do not match", zap.Int("discrepancies", len(discrepancies)))
+	}
+	response := VerifySummariesResponse{
+		From:          from.Format("2006-01-02"),
+		To:            to.Format("2006-01-02"),
+		Checked:       checked,
+		Valid:         len(discrepancies) == 0,
+		Discrepancies: newDiscrepancyResponses(discrepancies),
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// newDailySummaryResponse converts a daily summary to its API representation
+func newDailySummaryResponse(s *models.DailySummary) DailySummaryResponse {
+	return DailySummaryResponse{
+		Day:           s.Day.Format("2006-01-02"),
+		Trades:        s.Trades,
+		TradeDigest:   s.TradeDigest,
+		LedgerEntries: s.LedgerEntries,
+		LedgerDigest:  s.LedgerDigest,
+		PreviousHash:  s.PreviousHash,
+		Hash:          s.Hash,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+// newDiscrepancyResponses converts summary discrepancies to their API representation
+func newDiscrepancyResponses(discrepancies []audit.Discrepancy) []DiscrepancyResponse {
+	response := make([]DiscrepancyResponse, len(discrepancies))
+	for i, d := range discrepancies {
+		response[i] = DiscrepancyResponse{Day: d.Day.Format("2006-01-02"), Field: d.Field, Stored: d.Stored, Computed: d.Computed}
+	}
+	return response
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"orderSystem/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Roles a bearer token may carry: traders act for their account, read-only sessions may only
+// read, and admins may also use the /admin routes
+const (
+	roleTrader   = "trader"
+	roleReadOnly = "read_only"
+	roleAdmin    = "admin"
+)
+
+// roleKey is the context key of the role of a request authenticated by a bearer token
+const roleKey = "role"
+
+// errInvalidToken is returned for a bearer token that is malformed, badly signed or expired
+var errInvalidToken = errors.New("invalid or expired bearer token")
+
+// tokenClaims are the JWT claims of a session token
+type tokenClaims struct {
+	Subject   string `json:"sub"` // the account the session acts for
+	Role      string `json:"role"`
+	Issuer    string `json:"iss"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+}
+
+// parseToken verifies an HS256 JWT under secret and returns its claims. Tokens must expire and
+// carry a known role, and the issuer when one is configured.
+func parseToken(token, secret, issuer string, now time.Time) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errInvalidToken
+	}
+
+	claims := &tokenClaims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, errInvalidToken
+	}
+	if claims.ExpiresAt == 0 || !now.Before(time.Unix(claims.ExpiresAt, 0)) || now.Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errInvalidToken
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return nil, errInvalidToken
+	}
+	switch claims.Role {
+	case roleTrader, roleReadOnly, roleAdmin:
+	default:
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// authenticate verifies the bearer token of a request that carries one, which then acts for the
+// token's account. Read-only sessions may only read. Requests without a token pass through, as
+// do all requests while bearer tokens are disabled, to be authenticated by their signature or
+// served anonymously; an account header is refused unless one of the two vouches for it.
+func (h *Handler) authenticate(c *gin.Context) {
+	bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || h.auth.JWTSecret == "" {
+		if c.GetHeader(accountHeader) != "" && c.GetHeader(signatureHeader) == "" {
+			h.logger.Warn("Unauthenticated account header", zap.String("account_id", c.GetHeader(accountHeader)))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Error: accountHeader + " is not accepted on its own; sign the request or send a bearer token"})
+			return
+		}
+		c.Next()
+		return
+	}
+	claims, err := parseToken(bearer, h.auth.JWTSecret, h.auth.Issuer, time.Now())
+	if err != nil {
+		h.logger.Warn("Invalid bearer token", zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if claims.Role == roleReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Read-only session"})
+		return
+	}
+
+	c.Set(roleKey, claims.Role)
+	c.Set(accountKey, claims.Subject)
+	c.Next()
+}
+
+// requireCompliance restricts a route to requests signed with a key with the compliance or admin
+// scope and to admin sessions
+func (h *Handler) requireCompliance(c *gin.Context) {
+	key := signingKey(c)
+	switch {
+	case key != nil && (key.HasScope(models.ScopeCompliance) || key.HasScope(models.ScopeAdmin)):
+		c.Next()
+	case key != nil:
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Signing key lacks the compliance scope"})
+	case c.GetString(roleKey) == roleAdmin:
+		c.Next()
+	case c.GetString(roleKey) == "":
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Compliance signing key or admin session required"})
+	default:
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Admin role required"})
+	}
+}
+
+// requireAdmin restricts a route to sessions with the admin role or requests signed with a key
+// with the admin scope; without bearer tokens only such keys get through
+func (h *Handler) requireAdmin(c *gin.Context) {
+	if key := signingKey(c); key != nil && key.HasScope(models.ScopeAdmin) {
+		c.Next()
+		return
+	}
+	switch c.GetString(roleKey) {
+	case roleAdmin:
+		c.Next()
+	case "":
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Admin session or signing key required"})
+	default:
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Admin role required"})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkhead bounds how many requests of one class run at once, so a flood of one kind of request
+// cannot take the connections and engine capacity another kind needs
+type bulkhead struct {
+	name  string
+	slots chan struct{}
+	wait  time.Duration // how long a request may queue for a slot
+}
+
+// newBulkhead creates a bulkhead admitting limit concurrent requests, nil when limit is 0
+func newBulkhead(name string, limit int, wait time.Duration) *bulkhead {
+	if limit == 0 {
+		return nil
+	}
+	return &bulkhead{name: name, slots: make(chan struct{}, limit), wait: wait}
+}
+
+// acquire takes a slot, waiting up to the bulkhead's wait or until the request is canceled
+func (b *bulkhead) acquire(c *gin.Context) bool {
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if b.wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(b.wait)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire
+func (b *bulkhead) release() {
+	<-b.slots
+}
+
+// isolate runs requests within a bulkhead, rejecting them with 503 when it stays full; a nil
+// bulkhead admits everything
+func isolate(b *bulkhead) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if b == nil {
+			c.Next()
+			return
+		}
+		if !b.acquire(c) {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Too many concurrent " + b.name + " requests"})
+			return
+		}
+		defer b.release()
+		c.Next()
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries is the size above which expired responses are swept on insert
+const maxCacheEntries = 1024
+
+// cacheEntry is a response shared by every caller until it expires
+type cacheEntry struct {
+	ready   chan struct{} // closed once status and body are set
+	status  int
+	body    any
+	expires time.Time
+}
+
+// responseCache shares computed responses between concurrent and subsequent callers so
+// public traffic costs at most one computation per key per TTL
+type responseCache struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// newResponseCache creates a cache holding responses for ttl, 0 disabling caching
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+// get returns the cached response for key, computing it once if it is missing or expired.
+// Callers arriving while a response is being computed wait for it instead of computing again.
+func (rc *responseCache) get(key string, compute func() (int, any)) (int, any) {
+	if rc.ttl <= 0 {
+		return compute()
+	}
+
+	now := time.Now()
+	rc.mutex.Lock()
+	entry, ok := rc.entries[key]
+	if ok {
+		select {
+		case <-entry.ready:
+			ok = now.Before(entry.expires)
+		default:
+		}
+	}
+	if !ok {
+		if len(rc.entries) >= maxCacheEntries {
+			rc.sweep(now)
+		}
+		entry = &cacheEntry{ready: make(chan struct{})}
+		rc.entries[key] = entry
+		rc.mutex.Unlock()
+
+		rc.fill(entry, compute)
+		return entry.status, entry.body
+	}
+	rc.mutex.Unlock()
+
+	<-entry.ready
+	return entry.status, entry.body
+}
+
+// fill computes an entry's response and releases its waiters, even if compute panics
+func (rc *responseCache) fill(entry *cacheEntry, compute func() (int, any)) {
+	entry.status, entry.body = http.StatusInternalServerError, ErrorResponse{Error: "Internal server error"}
+	defer close(entry.ready)
+	entry.status, entry.body = compute()
+	entry.expires = time.Now().Add(rc.ttl)
+}
+
+// sweep drops expired entries. Callers must hold the mutex.
+func (rc *responseCache) sweep(now time.Time) {
+	for key, entry := range rc.entries {
+		select {
+		case <-entry.ready:
+			if !now.Before(entry.expires) {
+				delete(rc.entries, key)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debugRoutes registers the pprof profiles, goroutine dumps among them, and the runtime stats on
+// an admin group
+func debugRoutes(r *gin.RouterGroup) {
+	r.GET("/pprof/", gin.WrapF(pprof.Index))
+	r.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	r.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	r.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	r.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	r.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	r.GET("/pprof/:profile", func(c *gin.Context) {
+		pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+	})
+	r.GET("/runtime", getRuntimeStats)
+}
+
+// getRuntimeStats handles GET /admin/debug/runtime
+func getRuntimeStats(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	gc := debug.GCStats{PauseQuantiles: make([]time.Duration, 5)}
+	debug.ReadGCStats(&gc)
+
+	resp := RuntimeStatsResponse{
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		PauseTotalMs:  milliseconds(gc.PauseTotal),
+		GCCPUFraction: mem.GCCPUFraction,
+	}
+	if gc.NumGC > 0 {
+		resp.LastGC = &gc.LastGC
+		for _, pause := range gc.PauseQuantiles {
+			resp.PauseQuantilesMs = append(resp.PauseQuantilesMs, milliseconds(pause))
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// milliseconds converts a duration into fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"orderSystem/internal/models"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// init has request validation name fields by their json or form key, so errors point at the
+// field as the client sent it
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// bindingError describes a request that failed to bind, naming the first invalid field
+func bindingError(err error) ErrorResponse {
+	resp := ErrorResponse{Error: err.Error(), Code: models.ReasonInvalidRequest}
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) && len(fields) > 0 {
+		resp.Field = fields[0].Field()
+	}
+	return resp
+}
+
+// errorResponse describes a refused request by its error and the error's code
+func errorResponse(err error) ErrorResponse {
+	return ErrorResponse{Error: err.Error(), Code: models.ErrorCode(err)}
+}
@@ -1,12 +1,26 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
+	"orderSystem/internal/algo"
+	"orderSystem/internal/audit"
+	"orderSystem/internal/billing"
+	"orderSystem/internal/compliance"
+	"orderSystem/internal/config"
+	"orderSystem/internal/leaderboard"
+	"orderSystem/internal/metrics"
 	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/risk"
 	"orderSystem/internal/service"
+	"orderSystem/internal/stream"
+	"orderSystem/internal/webhook"
 
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -14,58 +28,452 @@ import (
 
 // Handler manages API endpoints
 type Handler struct {
-	service *service.MatchingService
-	logger  *zap.Logger
+	service  *service.MatchingService
+	algos    *algo.Scheduler
+	biller   *billing.Biller
+	risk     *risk.Reporter
+	feed     *compliance.Feed
+	hub      *stream.Hub
+	users    *stream.Router
+	board    *leaderboard.Board
+	chain    *audit.Chain
+	webhooks *webhook.Notifier
+	logger   *zap.Logger
+
+	// Public market data is cached and rate limited separately from trading endpoints
+	publicCache   *responseCache
+	publicLimiter *rateLimiter
+	publicAliases *orderAliaser
+
+	// Bulkheads isolating order entry, cancels and market data reads from each other
+	orderEntry *bulkhead
+	cancels    *bulkhead
+	marketData *bulkhead
+
+	// Adaptive per-caller order entry rate limit, nil when disabled
+	orderLimits *orderLimiter
+
+	// Order entry and cancel quotas per API key and per client IP, nil when disabled
+	keyLimiter *rateLimiter
+	ipLimiter  *rateLimiter
+
+	// HMAC request signing of order entry and cancels
+	signing config.SigningConfig
+	nonces  *nonceCache
+
+	// JWT bearer tokens of dashboard sessions
+	auth config.AuthConfig
+
+	// How long order entry and cancels may wait for their symbol's engine
+	entryTimeout time.Duration
+
+	// Serve the profiling and runtime debug endpoints under /admin/debug
+	debug bool
+
+	// Market data WebSocket heartbeats
+	streaming config.StreamingConfig
 }
 
 // NewHandler creates a new API handler
-func NewHandler(s *service.MatchingService, logger *zap.Logger) *Handler {
-	return &Handler{service: s, logger: logger}
+func NewHandler(s *service.MatchingService, algos *algo.Scheduler, biller *billing.Biller, reporter *risk.Reporter,
+	feed *compliance.Feed, hub *stream.Hub, users *stream.Router, board *leaderboard.Board, chain *audit.Chain, webhooks *webhook.Notifier,
+	public config.PublicConfig, bulkheads config.BulkheadConfig, orderLimits config.OrderLimitsConfig,
+	rateLimits config.RateLimitsConfig, signing config.SigningConfig, auth config.AuthConfig, streaming config.StreamingConfig, server config.ServerConfig,
+	logger *zap.Logger) *Handler {
+	h := &Handler{
+		service:       s,
+		algos:         algos,
+		biller:        biller,
+		risk:          reporter,
+		feed:          feed,
+		hub:           hub,
+		users:         users,
+		board:         board,
+		chain:         chain,
+		webhooks:      webhooks,
+		logger:        logger,
+		publicCache:   newResponseCache(public.CacheTTL),
+		publicLimiter: newRateLimiter(public.RateLimit, public.RateBurst),
+		publicAliases: newOrderAliaser(public.AnonymizeOrders, public.AliasRotation),
+		orderEntry:    newBulkhead("order entry", bulkheads.OrderEntry, bulkheads.Wait),
+		cancels:       newBulkhead("cancel", bulkheads.Cancels, bulkheads.Wait),
+		marketData:    newBulkhead("market data", bulkheads.MarketData, bulkheads.Wait),
+		orderLimits:   newOrderLimiter(orderLimits),
+		signing:       signing,
+		nonces:        newNonceCache(signing.Window),
+		auth:          auth,
+		entryTimeout:  server.RequestTimeout,
+		debug:         server.Debug,
+		streaming:     streaming,
+	}
+	if rateLimits.Enabled {
+		h.keyLimiter = newRateLimiter(rateLimits.KeyRate, rateLimits.KeyBurst)
+		h.ipLimiter = newRateLimiter(rateLimits.IPRate, rateLimits.IPBurst)
+	}
+	return h
+}
+
+// accountHeader is the header older clients named their account in; it proves nothing, so
+// requests carrying it without a signature or bearer token are refused
+const accountHeader = "X-Account-ID"
+
+// accountKey is the context key of the account a signature or bearer token authenticated
+const accountKey = "account"
+
+// accountID returns the authenticated account the request is made on behalf of, empty when
+// anonymous
+func accountID(c *gin.Context) string {
+	return c.GetString(accountKey)
+}
+
+// storageStatus returns the HTTP status for a typed database error or an unavailable credit
+// check, or fallback for any other error
+func storageStatus(err error, fallback int) int {
+	switch {
+	case repository.IsTransient(err), err == models.ErrCreditUnavailable, err == models.ErrRequestTimeout,
+		err == models.ErrContention:
+		return http.StatusServiceUnavailable
+	case err == repository.ErrDuplicateKey, err == repository.ErrVersionConflict:
+		return http.StatusConflict
+	}
+	return fallback
+}
+
+// marketDataStatus maps a market data read error to a status: 404 for a symbol neither
+// configured nor listed, otherwise as storageStatus with a fallback of 500
+func marketDataStatus(err error) int {
+	if err == models.ErrSymbolNotFound {
+		return http.StatusNotFound
+	}
+	return storageStatus(err, http.StatusInternalServerError)
+}
+
+// withTimeout gives requests a deadline timeout after they arrive; 0 leaves them without one
+func withTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout == 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// requireAccount returns the caller's authenticated account ID or responds with 401 when the
+// request is anonymous
+func (h *Handler) requireAccount(c *gin.Context) (string, bool) {
+	id := accountID(c)
+	if id == "" {
+		h.logger.Warn("Unauthenticated request to an account route")
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Sign the request or send a bearer token to act for an account"})
+		return "", false
+	}
+	return id, true
 }
 
-// SetupRoutes configures API routes
+// NewRouter creates the router serving the API. Only the configured trusted proxies may set the
+// client IP with forwarding headers, as signing key allowlists and per-IP rate limits go by it.
+func NewRouter(cfg config.ServerConfig) (*gin.Engine, error) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// SetupRoutes configures API routes: every route of the current version under /v1, and again
+// unversioned for clients that predate it
 func SetupRoutes(router *gin.Engine, h *Handler) {
-	router.POST("/orders", h.placeOrder)
-	router.DELETE("/orders/:orderId", h.cancelOrder)
-	router.GET("/orderbook", h.getOrderBook)
-	router.GET("/trades", h.getTrades)
-	router.GET("/orders/:orderId", h.getOrder)
+	router.Use(instrument)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	h.routes(router.Group("/v1", servedVersion(1)))
+	h.routes(router.Group("", negotiateVersion))
 }
 
-// placeOrder handles POST /orders
-func (h *Handler) placeOrder(c *gin.Context) {
-	var req PlaceOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
-		return
+// routes registers the API routes on a versioned group
+func (h *Handler) routes(r *gin.RouterGroup) {
+	r.Use(h.authenticate)
+
+	// Order entry, cancels and market data reads each run within their own bulkhead; order entry
+	// and cancels may be HMAC signed, with keys scoped to them, draw on the caller's IP and API
+	// key quotas, and count towards its adaptive order rate limit. The IP quota comes first so
+	// that a flood is turned away before it takes bulkhead slots; API keys are only trusted once
+	// the signature checks. Maintenance mode refuses both before anything else.
+	ipQuota, keyQuota := rateLimit(h.ipLimiter, clientIP), rateLimit(h.keyLimiter, apiKey)
+	entry := r.Group("", h.refuseInMaintenance, withTimeout(h.entryTimeout), ipQuota, isolate(h.orderEntry), h.verifySignature,
+		requireScope(models.ScopeTrade), keyQuota, h.limitOrderEntry)
+	entry.POST("/orders", h.placeOrder)
+	entry.PUT("/orders/:orderId", h.amendOrder)
+	entry.POST("/algo-orders", h.placeParentOrder)
+	entry.POST("/order-groups", h.placeOrderGroup)
+	entry.POST("/block-trades", h.reportBlockTrade)
+	entry.POST("/block-trades/:blockId/affirm", h.affirmBlockTrade)
+
+	cancels := r.Group("", h.refuseInMaintenance, withTimeout(h.entryTimeout), ipQuota, isolate(h.cancels), h.verifySignature,
+		requireScope(models.ScopeCancel), keyQuota, h.countCancels)
+	cancels.DELETE("/orders/:orderId", h.cancelOrder)
+	cancels.DELETE("/client-orders/:clientOrderId", h.cancelClientOrder)
+	cancels.POST("/orders/:orderId/cancel-quantity", h.cancelQuantity)
+	cancels.DELETE("/algo-orders/:parentId", h.cancelParentOrder)
+	cancels.DELETE("/order-groups/:groupId", h.cancelOrderGroup)
+	cancels.DELETE("/block-trades/:blockId", h.declineBlockTrade)
+
+	// Accounts are registered openly; their signing keys are managed with signed requests once
+	// signing is required, by keys with the trade scope
+	r.POST("/accounts", h.createAccount)
+	keys := r.Group("", h.verifySignature)
+	keys.GET("/account", requireScope(models.ScopeRead), h.getAccount)
+	keys.GET("/account/rate-limit", requireScope(models.ScopeRead), h.getOrderRateLimit)
+	keys.POST("/signing-keys", requireScope(models.ScopeTrade), h.createSigningKey)
+	keys.DELETE("/signing-keys/:keyId", requireScope(models.ScopeTrade), h.revokeSigningKey)
+
+	marketData := r.Group("", isolate(h.marketData))
+	marketData.GET("/orderbook", h.getOrderBook)
+	marketData.GET("/trades", h.getTrades)
+	marketData.GET("/depth/history", h.getDepthHistory)
+	marketData.GET("/candles", h.getCandles)
+	marketData.GET("/stats/book", h.getBookStats)
+	marketData.GET("/stats/market-quality", h.getMarketQuality)
+	marketData.GET("/stats/market-quality/history", h.getMarketQualityHistory)
+	marketData.GET("/auction", h.getAuction)
+	if h.board != nil {
+		marketData.GET("/leaderboard", h.getLeaderboard)
+	}
+
+	// Account data may be read with signed requests too, by keys with the read scope
+	reads := r.Group("", h.acceptSignature, requireScope(models.ScopeRead))
+	reads.GET("/orders", h.listOrders)
+	reads.GET("/orders/:orderId", h.getOrder)
+	reads.GET("/client-orders/:clientOrderId", h.getClientOrder)
+	reads.GET("/orders/:orderId/executions", h.getExecutions)
+	reads.GET("/orders/:orderId/trades", h.getOrderTrades)
+	reads.GET("/orders/:orderId/events", h.getOrderHistory)
+	reads.GET("/algo-orders/:parentId", h.getParentOrder)
+	reads.GET("/order-groups/:groupId", h.getOrderGroup)
+	reads.GET("/block-trades", h.getBlockTrades)
+	reads.GET("/wallet/balances", h.getBalances)
+	reads.GET("/wallet/settlements", h.getSettlements)
+	reads.GET("/wallet/ledger", h.getLedger)
+	reads.GET("/billing/invoices", h.getInvoices)
+	reads.GET("/billing/invoices/:invoiceId", h.getInvoice)
+	reads.GET("/billing/invoices/:invoiceId/download", h.downloadInvoice)
+	reads.GET("/risk/portfolio", h.getPortfolio)
+	reads.GET("/fees/schedule", h.getAccountFees)
+
+	// Admin routes take an admin session or a request signed with a key with the admin scope
+	admin := r.Group("/admin", h.acceptSignature, h.requireAdmin, requireScope(models.ScopeAdmin))
+	admin.GET("/shards", h.getShards)
+	admin.GET("/flow", h.getOrderFlow)
+	admin.GET("/last-looks", h.getLastLookStats)
+	admin.GET("/fee-schedules", h.getFeeSchedules)
+	admin.POST("/fee-schedules", h.createFeeSchedule)
+	admin.PUT("/fee-schedules/:scheduleId", h.updateFeeSchedule)
+	admin.GET("/symbols", h.getSymbols)
+	admin.POST("/symbols", h.listSymbol)
+	admin.GET("/symbols/:symbol", h.getSymbol)
+	admin.PUT("/symbols/:symbol", h.updateSymbol)
+	admin.DELETE("/symbols/:symbol", h.delistSymbol)
+	admin.POST("/symbols/:symbol/halt", h.haltSymbol)
+	admin.POST("/symbols/:symbol/resume", h.resumeSymbol)
+	admin.GET("/symbols/:symbol/tick-size", h.getTickSize)
+	admin.PUT("/symbols/:symbol/tick-size", h.changeTickSize)
+	admin.GET("/daily-summaries", h.getDailySummaries)
+	admin.GET("/daily-summaries/verify", h.verifyDailySummaries)
+	admin.GET("/ledger/reconciliation", h.reconcileLedger)
+	admin.GET("/accounts/:accountId/signing-keys", h.getAccountSigningKeys)
+	admin.POST("/accounts/:accountId/signing-keys", h.createAccountSigningKey)
+	admin.POST("/accounts/:accountId/deposits", h.deposit)
+	admin.DELETE("/signing-keys/:keyId", h.revokeAnySigningKey)
+	admin.GET("/maintenance", h.getMaintenance)
+	admin.PUT("/maintenance", h.updateMaintenance)
+	if h.debug {
+		debugRoutes(admin.Group("/debug"))
+	}
+	if _, sandbox := h.service.SandboxSettings(); sandbox {
+		admin.GET("/sandbox", h.getSandbox)
+		admin.PUT("/sandbox", h.updateSandbox)
+	}
+
+	// The compliance feed shows every account's orders, so it takes a compliance key or an admin
+	compliance := r.Group("/compliance", h.acceptSignature, h.requireCompliance)
+	compliance.GET("/subscribers/:subscriberId/events", h.getOrderEvents)
+	compliance.POST("/subscribers/:subscriberId/ack", h.ackOrderEvents)
+
+	// Unauthenticated read-only market data, limited per client IP and sharing the market data bulkhead
+	public := r.Group("", rateLimit(h.publicLimiter, clientIP), isolate(h.marketData))
+	public.GET("/ticker", h.getTicker)
+	public.GET("/depth", h.getDepth)
+	public.GET("/book", h.getBook)
+	public.GET("/trades/recent", h.getRecentTrades)
+
+	// The WebSocket feed holds its connection open, so it is rate limited on connect but kept
+	// out of the market data bulkhead
+	if h.hub != nil {
+		r.GET("/ws", rateLimit(h.publicLimiter, clientIP), h.streamMarketData)
+	}
+	if h.users != nil {
+		listenKeys := r.Group("/user-stream", h.acceptSignature, requireScope(models.ScopeRead))
+		listenKeys.POST("", h.createListenKey)
+		listenKeys.PUT("/:listenKey", h.keepAliveListenKey)
+		listenKeys.DELETE("/:listenKey", h.closeListenKey)
+		r.GET("/ws/user", h.streamOrders)
+		// Last looks are offered on the private stream and answered like signed order entry
+		r.POST("/last-looks/:lastLookId", h.verifySignature, h.respondLastLook)
 	}
+	if h.webhooks != nil {
+		webhooks := r.Group("/webhooks", h.acceptSignature)
+		webhooks.POST("", requireScope(models.ScopeTrade), h.registerWebhook)
+		webhooks.GET("", requireScope(models.ScopeRead), h.getWebhooks)
+		webhooks.DELETE("/:webhookId", requireScope(models.ScopeTrade), h.deleteWebhook)
+	}
+}
 
-	price := sql.NullFloat64{Valid: false}
-	if req.Type == models.TypeLimit {
-		price = sql.NullFloat64{Float64: req.Price, Valid: true}
+// newOrder builds an order for the given account from a place order request
+func newOrder(req PlaceOrderRequest, owner string) *models.Order {
+	price := models.NullDecimal{Valid: false}
+	if req.Type == models.TypeLimit || req.Type == models.TypeStopLimit {
+		price = models.NullDecimal{Decimal: req.Price, Valid: true}
+	}
+	display := models.NullDecimal{Valid: req.DisplayQuantity > 0, Decimal: req.DisplayQuantity}
+	trigger := models.NullDecimal{Valid: false}
+	if req.Type == models.TypeStop || req.Type == models.TypeStopLimit {
+		trigger = models.NullDecimal{Decimal: req.TriggerPrice, Valid: true}
+	}
+	expireAt := sql.NullTime{Valid: false}
+	if req.ExpireAt != nil {
+		expireAt = sql.NullTime{Time: *req.ExpireAt, Valid: true}
 	}
 
-	order := &models.Order{
+	return &models.Order{
 		Symbol:            req.Symbol,
 		Side:              req.Side,
 		Type:              req.Type,
 		Price:             price,
 		InitialQuantity:   req.Quantity,
 		RemainingQuantity: req.Quantity,
+		OwnerID:           owner,
+		TimeInForce:       req.TimeInForce,
+		TriggerPrice:      trigger,
+		DisplayQuantity:   display,
+		ClientOrderID:     sql.NullString{String: req.ClientOrderID, Valid: req.ClientOrderID != ""},
+		ExpireAt:          expireAt,
+		ProtectionPrice:   models.NullDecimal{Valid: req.ProtectionPrice > 0, Decimal: req.ProtectionPrice},
+		MaxSlippage:       models.NullDecimal{Valid: req.MaxSlippage > 0, Decimal: req.MaxSlippage},
+		QuoteQuantity:     models.NullDecimal{Valid: req.QuoteQuantity > 0, Decimal: req.QuoteQuantity},
+	}
+}
+
+// placeOrder handles POST /orders
+func (h *Handler) placeOrder(c *gin.Context) {
+	var req PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	if req.ClientOrderID != "" && accountID(c) == "" {
+		h.logger.Warn("Client order ID without an account")
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "client_order_id requires an authenticated account", Code: models.ReasonInvalidRequest, Field: "client_order_id",
+		})
+		return
+	}
+	if req.ExpireAt != nil && !req.ExpireAt.After(time.Now()) {
+		h.logger.Warn("Expiry time already passed", zap.Time("expire_at", *req.ExpireAt))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "expire_at must be in the future", Code: models.ReasonInvalidRequest, Field: "expire_at"})
+		return
 	}
 
-	trades, err := h.service.PlaceOrder(order)
+	order := newOrder(req, accountID(c))
+	if req.AckMode == "async" {
+		if err := h.service.AcceptOrder(c.Request.Context(), order); err != nil {
+			h.logger.Error("Failed to accept order", zap.Error(err))
+			c.JSON(placementStatus(err), errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusAccepted, OrderAckResponse{OrderID: order.OrderID, Status: "accepted"})
+		return
+	}
+	result, err := h.service.PlaceOrder(c.Request.Context(), order)
 	if err != nil {
 		h.logger.Error("Failed to place order", zap.Error(err))
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		c.JSON(placementStatus(err), errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, PlaceOrderResponse{
+		ExecutionReportResponse: newExecutionReportResponse(service.PlacementReport(order, result)),
+		Trades:                  result.Trades,
+		Executions:              newExecutionResponses(result.Executions),
+		Resubmitted:             result.Resubmitted,
+	})
+}
+
+// placementStatus returns the HTTP status for an order that could not be placed
+func placementStatus(err error) int {
+	if err == models.ErrDuplicateClientID {
+		return http.StatusConflict
+	}
+	return storageStatus(err, http.StatusBadRequest)
+}
+
+// amendOrder handles PUT /orders/:orderId
+func (h *Handler) amendOrder(c *gin.Context) {
+	orderIDStr := c.Param("orderId")
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
+		return
+	}
+
+	var req AmendOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if !h.ownsOrder(c, orderID) {
+		return
+	}
+
+	price := models.NullDecimal{Decimal: req.Price, Valid: req.Price > 0}
+	result, err := h.service.AmendOrder(c.Request.Context(), orderID, price, req.Quantity)
+	if err != nil {
+		h.logger.Error("Failed to amend order", zap.Error(err))
+		switch err {
+		case models.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+		case models.ErrOrderNotOpen:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Order is not open", Code: models.ReasonOrderNotOpen})
+		case models.ErrInvalidOrder, models.ErrOffTick, models.ErrOffLot, models.ErrBelowMinNotional,
+			models.ErrSymbolHalted, models.ErrPriceBand, models.ErrRiskLimitExceeded, models.ErrInsufficientCredit:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(storageStatus(err, http.StatusInternalServerError), errorResponse(err))
+		}
 		return
 	}
 
+	order, err := h.service.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	executions, err := h.service.GetExecutions(c.Request.Context(), orderID)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, PlaceOrderResponse{
-		OrderID: order.OrderID,
-		Status:  order.Status,
-		Trades:  trades,
+		ExecutionReportResponse: newExecutionReportResponse(service.AmendReport(order, result, executions)),
+		Trades:                  result.Trades,
+		Executions:              newExecutionResponses(result.Executions),
 	})
 }
 
@@ -75,18 +483,26 @@ func (h *Handler) cancelOrder(c *gin.Context) {
 	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
 	if err != nil {
 		h.logger.Warn("Invalid order ID", zap.Error(err))
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
+		return
+	}
+
+	if !h.ownsOrder(c, orderID) {
 		return
 	}
+	h.cancel(c, orderID)
+}
 
-	if err := h.service.CancelOrder(orderID); err != nil {
+// cancel cancels an order the caller owns and responds with the outcome
+func (h *Handler) cancel(c *gin.Context, orderID uint64) {
+	if err := h.service.CancelOrder(c.Request.Context(), orderID); err != nil {
 		h.logger.Error("Failed to cancel order", zap.Error(err))
 		if err == models.ErrOrderNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
 		} else if err == models.ErrOrderNotOpen {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Order is not open"})
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Order is not open", Code: models.ReasonOrderNotOpen})
 		} else {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
 		}
 		return
 	}
@@ -94,42 +510,75 @@ func (h *Handler) cancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Order canceled"})
 }
 
-// getOrderBook handles GET /orderbook?symbol={symbol}
+// getOrderBook handles GET /orderbook?symbol={symbol}&depth={depth}, aggregating the in-memory
+// book into price levels; without depth every level is returned
 func (h *Handler) getOrderBook(c *gin.Context) {
-	symbol := c.Query("symbol")
-	if symbol == "" {
-		h.logger.Warn("Missing symbol parameter")
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Symbol is required"})
+	var req OrderBookRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid order book query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
 		return
 	}
 
-	orders, err := h.service.GetOrderBook(symbol)
+	bids, asks, err := h.service.GetDepth(req.Symbol, req.Depth)
 	if err != nil {
 		h.logger.Error("Failed to get order book", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.JSON(marketDataStatus(err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, DepthResponse{
+		Symbol: req.Symbol,
+		Bids:   newPriceLevelResponses(bids),
+		Asks:   newPriceLevelResponses(asks),
+	})
 }
 
-// getTrades handles GET /trades?symbol={symbol}
+// getTrades handles GET /trades?symbol={symbol}&before={cursor}&after={cursor}&limit={n}
 func (h *Handler) getTrades(c *gin.Context) {
-	symbol := c.Query("symbol")
-	if symbol == "" {
-		h.logger.Warn("Missing symbol parameter")
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Symbol is required"})
+	var req TradesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid trades query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	filter := models.TradeFilter{Symbol: req.Symbol, Limit: req.Limit}
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+	var err error
+	if filter.BeforeID, filter.Before, err = parseTradeCursor(req.Before); err != nil {
+		h.logger.Warn("Invalid before cursor", zap.String("before", req.Before))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid before cursor"})
+		return
+	}
+	if filter.AfterID, filter.After, err = parseTradeCursor(req.After); err != nil {
+		h.logger.Warn("Invalid after cursor", zap.String("after", req.After))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid after cursor"})
 		return
 	}
 
-	trades, err := h.service.GetTrades(symbol)
+	trades, err := h.service.GetTrades(c.Request.Context(), filter)
 	if err != nil {
 		h.logger.Error("Failed to get trades", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, trades)
+	c.JSON(http.StatusOK, h.publicTrades(trades))
+}
+
+// parseTradeCursor parses a trade list cursor: a trade ID, or an RFC 3339 time. An empty cursor
+// is no bound.
+func parseTradeCursor(cursor string) (uint64, time.Time, error) {
+	if cursor == "" {
+		return 0, time.Time{}, nil
+	}
+	if id, err := strconv.ParseUint(cursor, 10, 64); err == nil {
+		return id, time.Time{}, nil
+	}
+	at, err := time.Parse(time.RFC3339, cursor)
+	return 0, at, err
 }
 
 // getOrder handles GET /orders/:orderId
@@ -138,13 +587,13 @@ func (h *Handler) getOrder(c *gin.Context) {
 	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
 	if err != nil {
 		h.logger.Warn("Invalid order ID", zap.Error(err))
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
 		return
 	}
 
-	order, err := h.service.GetOrder(orderID)
+	order, err := h.service.GetOrder(c.Request.Context(), orderID)
 	if err == models.ErrOrderNotFound {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
 		return
 	} else if err != nil {
 		h.logger.Error("Failed to get order", zap.Error(err))
@@ -154,3 +603,1365 @@ func (h *Handler) getOrder(c *gin.Context) {
 
 	c.JSON(http.StatusOK, order)
 }
+
+// cancelQuantity handles POST /orders/:orderId/cancel-quantity
+func (h *Handler) cancelQuantity(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("orderId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
+		return
+	}
+	var req CancelQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if !h.ownsOrder(c, orderID) {
+		return
+	}
+
+	order, err := h.service.CancelQuantity(c.Request.Context(), orderID, req.Quantity)
+	if err != nil {
+		h.logger.Error("Failed to cancel order quantity", zap.Error(err))
+		switch err {
+		case models.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+		case models.ErrOrderNotOpen:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Order is not open", Code: models.ReasonOrderNotOpen})
+		case models.ErrInvalidOrder:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Quantity must be less than the order's remaining quantity", Code: models.ReasonInvalidOrder, Field: "quantity",
+			})
+		case models.ErrOffLot:
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			c.JSON(storageStatus(err, http.StatusInternalServerError), errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, CancelQuantityResponse{
+		OrderID:           order.OrderID,
+		Status:            order.Status,
+		CanceledQuantity:  req.Quantity,
+		Quantity:          order.InitialQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+	})
+}
+
+// listOrders handles GET /orders?status={status}&symbol={symbol}&side={side}&from={from}&to={to}&cursor={cursor}&limit={limit}
+func (h *Handler) listOrders(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	var req ListOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid order list query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+	filter := models.OrderFilter{
+		OwnerID: account,
+		Symbol:  req.Symbol,
+		Side:    req.Side,
+		Status:  req.Status,
+		From:    req.From,
+		To:      req.To,
+		Limit:   req.Limit,
+	}
+	if req.Cursor != "" {
+		var err error
+		if filter.AfterTime, filter.AfterID, err = parseOrderCursor(req.Cursor); err != nil {
+			h.logger.Warn("Invalid order list cursor", zap.String("cursor", req.Cursor))
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid cursor"})
+			return
+		}
+	}
+
+	orders, err := h.service.GetOrders(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	response := OrdersResponse{Orders: orders}
+	if orders == nil {
+		response.Orders = []*models.Order{}
+	}
+	if len(orders) == req.Limit {
+		last := orders[len(orders)-1]
+		response.NextCursor = strconv.FormatInt(last.CreatedAt.UnixMicro(), 10) + "-" + strconv.FormatUint(last.OrderID, 10)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseOrderCursor parses an order list cursor: the creation time in Unix microseconds and the
+// ID of the last order listed, joined by a dash
+func parseOrderCursor(cursor string) (time.Time, uint64, error) {
+	micros, id, _ := strings.Cut(cursor, "-")
+	at, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	orderID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.UnixMicro(at), orderID, nil
+}
+
+// getClientOrder handles GET /client-orders/:clientOrderId
+func (h *Handler) getClientOrder(c *gin.Context) {
+	order, ok := h.clientOrder(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// cancelClientOrder handles DELETE /client-orders/:clientOrderId
+func (h *Handler) cancelClientOrder(c *gin.Context) {
+	order, ok := h.clientOrder(c)
+	if !ok {
+		return
+	}
+	h.cancel(c, order.OrderID)
+}
+
+// clientOrder looks up the caller's order named by the clientOrderId path parameter, responding
+// with 404 when there is none
+func (h *Handler) clientOrder(c *gin.Context) (*models.Order, bool) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return nil, false
+	}
+	order, err := h.service.GetOrderByClientID(c.Request.Context(), account, c.Param("clientOrderId"))
+	if err == models.ErrOrderNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+		return nil, false
+	} else if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return order, true
+}
+
+// getExecutions handles GET /orders/:orderId/executions
+func (h *Handler) getExecutions(c *gin.Context) {
+	orderIDStr := c.Param("orderId")
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
+		return
+	}
+
+	executions, err := h.service.GetExecutions(c.Request.Context(), orderID)
+	if err == models.ErrOrderNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to get executions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newExecutionResponses(executions))
+}
+
+// getOrderTrades handles GET /orders/:orderId/trades
+func (h *Handler) getOrderTrades(c *gin.Context) {
+	orderIDStr := c.Param("orderId")
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
+		return
+	}
+
+	trades, err := h.service.GetOrderTrades(c.Request.Context(), orderID)
+	if err == models.ErrOrderNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to get order trades", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, append([]*models.Trade{}, trades...))
+}
+
+// getOrderHistory handles GET /orders/:orderId/events
+func (h *Handler) getOrderHistory(c *gin.Context) {
+	orderIDStr := c.Param("orderId")
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID", Code: models.ReasonInvalidRequest, Field: "orderId"})
+		return
+	}
+
+	events, err := h.service.GetOrderHistory(c.Request.Context(), orderID)
+	if err == models.ErrOrderNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found", Code: models.ReasonOrderNotFound})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to get order history", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderHistoryResponses(events))
+}
+
+// getDepthHistory handles GET /depth/history?symbol={symbol}&from={rfc3339}&to={rfc3339}&limit={n}
+func (h *Handler) getDepthHistory(c *gin.Context) {
+	var req DepthHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid depth history query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if req.From.IsZero() {
+		req.From = req.To.Add(-time.Hour)
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	snapshots, err := h.service.GetDepthSnapshots(c.Request.Context(), req.Symbol, req.From, req.To, req.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get depth history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]DepthSnapshotResponse, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		response = append(response, DepthSnapshotResponse{
+			Symbol:     snapshot.Symbol,
+			Bids:       newPriceLevelResponses(snapshot.Bids),
+			Asks:       newPriceLevelResponses(snapshot.Asks),
+			CapturedAt: snapshot.CapturedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getCandles handles GET /candles?symbol={symbol}&interval={interval}&from={rfc3339}&to={rfc3339}&limit={n}
+func (h *Handler) getCandles(c *gin.Context) {
+	var req CandlesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid candles query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 500
+	}
+	length := req.Interval.Duration()
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if req.From.IsZero() {
+		req.From = req.To.Add(-time.Duration(req.Limit) * length)
+	}
+
+	candles, err := h.service.GetCandles(c.Request.Context(), req.Symbol, req.Interval, req.From, req.To, req.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get candles", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := CandlesResponse{Symbol: req.Symbol, Interval: req.Interval, Candles: make([]CandleResponse, 0, len(candles))}
+	for _, candle := range candles {
+		response.Candles = append(response.Candles, CandleResponse{
+			OpenTime:  candle.OpenTime,
+			CloseTime: candle.OpenTime.Add(length),
+			Open:      candle.Open,
+			High:      candle.High,
+			Low:       candle.Low,
+			Close:     candle.Close,
+			Volume:    candle.Volume,
+			Trades:    candle.Trades,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getBookStats handles GET /stats/book
+func (h *Handler) getBookStats(c *gin.Context) {
+	report := h.service.BookMemory()
+
+	response := BookStatsResponse{
+		EstimatedBytes: report.EstimatedBytes,
+		Evictions:      report.Evictions,
+		Symbols:        make([]SymbolBookStats, 0, len(report.Symbols)),
+	}
+	for _, st := range report.Symbols {
+		response.Symbols = append(response.Symbols, SymbolBookStats{
+			Symbol:         st.Symbol,
+			BidLevels:      st.BidLevels,
+			AskLevels:      st.AskLevels,
+			Orders:         st.Orders,
+			EstimatedBytes: st.EstimatedBytes,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getMarketQuality handles GET /stats/market-quality?symbol={symbol}, symbol being optional
+func (h *Handler) getMarketQuality(c *gin.Context) {
+	symbol := c.Query("symbol")
+	quality := h.service.QualityThresholds()
+
+	response := MarketQualitySummaryResponse{
+		Symbols: []MarketQualityResponse{},
+		Thresholds: MarketQualityThresholds{
+			DepthBand:        quality.DepthBand,
+			MaxSpreadBps:     quality.MaxSpreadBps,
+			MinDepthNotional: quality.MinDepthNotional,
+		},
+	}
+	for _, sample := range h.service.MeasureMarketQuality() {
+		if symbol == "" || sample.Symbol == symbol {
+			response.Symbols = append(response.Symbols, newMarketQualityResponse(sample, h.service.QualityAlerts(sample)))
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getMarketQualityHistory handles GET /stats/market-quality/history?symbol={symbol}&from={rfc3339}&to={rfc3339}&limit={n}
+func (h *Handler) getMarketQualityHistory(c *gin.Context) {
+	var req DepthHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid market quality history query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if req.From.IsZero() {
+		req.From = req.To.Add(-time.Hour)
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	samples, err := h.service.GetMarketQuality(c.Request.Context(), req.Symbol, req.From, req.To, req.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get market quality history", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]MarketQualityResponse, 0, len(samples))
+	for _, sample := range samples {
+		response = append(response, newMarketQualityResponse(sample, h.service.QualityAlerts(sample)))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getAuction handles GET /auction?symbol={symbol}
+func (h *Handler) getAuction(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		h.logger.Warn("Missing symbol parameter")
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Symbol is required"})
+		return
+	}
+
+	info := h.service.GetAuctionInfo(c.Request.Context(), symbol)
+	response := AuctionResponse{Symbol: info.Symbol, Phase: info.Phase}
+	if info.Phase == models.PhaseAuction {
+		response.Reason = info.Reason
+		response.EndsAt = &info.EndsAt
+		response.IndicativePrice = info.IndicativePrice
+		response.IndicativeVolume = info.IndicativeVolume
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// placeParentOrder handles POST /algo-orders
+func (h *Handler) placeParentOrder(c *gin.Context) {
+	var req PlaceParentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	parent := &models.ParentOrder{
+		Symbol:            req.Symbol,
+		Side:              req.Side,
+		Strategy:          req.Strategy,
+		TotalQuantity:     req.Quantity,
+		ParticipationRate: req.ParticipationRate,
+		SliceInterval:     time.Duration(req.SliceIntervalSeconds) * time.Second,
+		EndAt:             time.Now().Add(time.Duration(req.DurationSeconds) * time.Second),
+		OwnerID:           accountID(c),
+	}
+	if req.LimitPrice > 0 {
+		parent.LimitPrice = models.NullDecimal{Decimal: req.LimitPrice, Valid: true}
+	}
+
+	if err := h.algos.Submit(c.Request.Context(), parent); err != nil {
+		h.logger.Error("Failed to place parent order", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newParentOrderResponse(parent, nil))
+}
+
+// getParentOrder handles GET /algo-orders/:parentId
+func (h *Handler) getParentOrder(c *gin.Context) {
+	parentID, err := strconv.ParseUint(c.Param("parentId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid parent order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid parent order ID"})
+		return
+	}
+
+	parent, children, err := h.algos.Get(c.Request.Context(), parentID)
+	if err == models.ErrParentNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Parent order not found"})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to get parent order", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newParentOrderResponse(parent, children))
+}
+
+// cancelParentOrder handles DELETE /algo-orders/:parentId
+func (h *Handler) cancelParentOrder(c *gin.Context) {
+	parentID, err := strconv.ParseUint(c.Param("parentId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid parent order ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid parent order ID"})
+		return
+	}
+
+	if !h.ownsParentOrder(c, parentID) {
+		return
+	}
+	if err := h.algos.Cancel(c.Request.Context(), parentID); err != nil {
+		h.logger.Error("Failed to cancel parent order", zap.Error(err))
+		if err == models.ErrParentNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Parent order not found"})
+		} else if err == models.ErrParentNotActive {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Parent order is not active"})
+		} else {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Parent order canceled"})
+}
+
+// placeOrderGroup handles POST /order-groups
+func (h *Handler) placeOrderGroup(c *gin.Context) {
+	var req PlaceOrderGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	// An OCO group is one limit order and one stop order, given in either order
+	limit, stop := newOrder(req.Orders[0], accountID(c)), newOrder(req.Orders[1], accountID(c))
+	if limit.Type != models.TypeLimit {
+		limit, stop = stop, limit
+	}
+	group, result, err := h.service.PlaceOCO(c.Request.Context(), limit, stop)
+	if err != nil {
+		h.logger.Error("Failed to place order group", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusBadRequest), errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, PlaceOrderGroupResponse{
+		GroupID:    group.GroupID,
+		Status:     group.Status,
+		Orders:     []GroupOrderResponse{newGroupOrderResponse(limit), newGroupOrderResponse(stop)},
+		Trades:     result.Trades,
+		Executions: newExecutionResponses(result.Executions),
+	})
+}
+
+// getOrderGroup handles GET /order-groups/:groupId
+func (h *Handler) getOrderGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("groupId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order group ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order group ID"})
+		return
+	}
+
+	group, orders, err := h.service.GetOrderGroup(c.Request.Context(), groupID)
+	if err == models.ErrGroupNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order group not found"})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to get order group", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if orders == nil {
+		orders = []*models.Order{}
+	}
+	c.JSON(http.StatusOK, OrderGroupResponse{
+		GroupID:   group.GroupID,
+		Type:      group.Type,
+		Symbol:    group.Symbol,
+		Status:    group.Status,
+		CreatedAt: group.CreatedAt,
+		Orders:    orders,
+	})
+}
+
+// cancelOrderGroup handles DELETE /order-groups/:groupId
+func (h *Handler) cancelOrderGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("groupId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid order group ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order group ID"})
+		return
+	}
+
+	if !h.ownsOrderGroup(c, groupID) {
+		return
+	}
+	if err := h.service.CancelOrderGroup(c.Request.Context(), groupID); err != nil {
+		h.logger.Error("Failed to cancel order group", zap.Error(err))
+		switch err {
+		case models.ErrGroupNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order group not found"})
+		case models.ErrGroupNotActive:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Order group is not active"})
+		default:
+			c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order group canceled"})
+}
+
+// getBalances handles GET /wallet/balances
+func (h *Handler) getBalances(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+
+	balances, err := h.service.GetBalances(c.Request.Context(), account)
+	if err != nil {
+		h.logger.Error("Failed to get balances", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]BalanceResponse, 0, len(balances))
+	for _, b := range balances {
+		response = append(response, BalanceResponse{
+			Currency:  b.Currency,
+			Settled:   b.Settled,
+			Pending:   b.Pending,
+			Total:     b.Settled + b.Pending,
+			Held:      b.Held,
+			Available: b.Settled + b.Pending - b.Held,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getSettlements handles GET /wallet/settlements?status={pending|settled}
+func (h *Handler) getSettlements(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	status := models.SettlementStatus(c.DefaultQuery("status", string(models.SettlementPending)))
+	if status != models.SettlementPending && status != models.SettlementSettled {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid settlement status"})
+		return
+	}
+
+	settlements, err := h.service.GetSettlements(c.Request.Context(), account, status)
+	if err != nil {
+		h.logger.Error("Failed to get settlements", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]SettlementResponse, 0, len(settlements))
+	for _, st := range settlements {
+		response = append(response, SettlementResponse{
+			SettlementID: st.SettlementID,
+			TradeID:      st.TradeID,
+			Currency:     st.Currency,
+			Amount:       st.Amount,
+			SettleAt:     st.SettleAt,
+			Status:       st.Status,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// reportBlockTrade handles POST /block-trades, recording a block trade that waits for the
+// counterparty to affirm it
+func (h *Handler) reportBlockTrade(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	var req BlockTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	block := &service.BlockTrade{
+		Symbol:   req.Symbol,
+		BuyerID:  account,
+		SellerID: req.CounterpartyID,
+		Price:    req.Price,
+		Quantity: req.Quantity,
+	}
+	if req.Side == models.SideSell {
+		block.BuyerID, block.SellerID = req.CounterpartyID, account
+	}
+	report, err := h.service.ReportBlockTrade(c.Request.Context(), account, block)
+	if err != nil {
+		h.logger.Error("Failed to report block trade", zap.Error(err))
+		h.blockTradeError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, newBlockReportResponse(report, account))
+}
+
+// blockID returns the block trade ID in the path or responds with 400 when it is invalid
+func (h *Handler) blockID(c *gin.Context) (uint64, bool) {
+	blockID, err := strconv.ParseUint(c.Param("blockId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid block trade ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid block trade ID"})
+		return 0, false
+	}
+	return blockID, true
+}
+
+// affirmBlockTrade handles POST /block-trades/:blockId/affirm, printing a block trade reported
+// by the caller's counterparty
+func (h *Handler) affirmBlockTrade(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	blockID, ok := h.blockID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.AffirmBlockTrade(c.Request.Context(), account, blockID)
+	if err != nil {
+		h.logger.Error("Failed to affirm block trade", zap.Error(err))
+		h.blockTradeError(c, err)
+		return
+	}
+
+	order, execution := result.BuyOrder, result.Executions[0]
+	if account == result.SellOrder.OwnerID {
+		order, execution = result.SellOrder, result.Executions[1]
+	}
+	c.JSON(http.StatusOK, BlockTradeResponse{
+		OrderID:   order.OrderID,
+		Trade:     result.Trade,
+		Execution: newExecutionResponses([]*models.Execution{execution})[0],
+	})
+}
+
+// declineBlockTrade handles DELETE /block-trades/:blockId, by which the counterparty refuses a
+// pending block trade or the reporter withdraws it
+func (h *Handler) declineBlockTrade(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	blockID, ok := h.blockID(c)
+	if !ok {
+		return
+	}
+	report, err := h.service.DeclineBlockTrade(c.Request.Context(), account, blockID)
+	if err != nil {
+		h.logger.Error("Failed to decline block trade", zap.Error(err))
+		h.blockTradeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newBlockReportResponse(report, account))
+}
+
+// getBlockTrades handles GET /block-trades, listing the caller's block trades awaiting affirmation
+func (h *Handler) getBlockTrades(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	reports, err := h.service.GetPendingBlockTrades(c.Request.Context(), account)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	response := make([]BlockReportResponse, len(reports))
+	for i, report := range reports {
+		response[i] = newBlockReportResponse(report, account)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// blockTradeError responds with the status of an error reporting, affirming or declining a block trade
+func (h *Handler) blockTradeError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrInvalidBlockTrade, models.ErrNoReferencePrice, models.ErrOutsidePriceBand, models.ErrSymbolNotListed,
+		models.ErrSymbolHalted, models.ErrInsufficientCredit:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case models.ErrBlockNotFound, models.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case models.ErrBlockNotPending:
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	}
+}
+
+// deposit handles POST /admin/accounts/:accountId/deposits, crediting funds received off the
+// exchange, so only back-office staff may post them
+func (h *Handler) deposit(c *gin.Context) {
+	account := c.Param("accountId")
+	if _, err := h.service.GetAccount(c.Request.Context(), account); err != nil {
+		status := storageStatus(err, http.StatusInternalServerError)
+		if err == models.ErrAccountNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	var req DepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	if err := h.service.Deposit(c.Request.Context(), account, req.Currency, req.Amount); err != nil {
+		h.logger.Error("Failed to deposit", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deposit credited"})
+}
+
+// getInvoices handles GET /billing/invoices
+func (h *Handler) getInvoices(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+
+	invoices, err := h.biller.GetInvoices(c.Request.Context(), account)
+	if err != nil {
+		h.logger.Error("Failed to get invoices", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		response = append(response, newInvoiceResponse(invoice))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// invoice resolves the caller's invoice named by the invoiceId path parameter,
+// responding with an error and returning false when it cannot
+func (h *Handler) invoice(c *gin.Context) (*models.Invoice, bool) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return nil, false
+	}
+	invoiceID, err := strconv.ParseUint(c.Param("invoiceId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid invoice ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid invoice ID"})
+		return nil, false
+	}
+
+	invoice, err := h.biller.GetInvoice(c.Request.Context(), account, invoiceID)
+	if err == models.ErrInvoiceNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Invoice not found"})
+		return nil, false
+	} else if err != nil {
+		h.logger.Error("Failed to get invoice", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return invoice, true
+}
+
+// getInvoice handles GET /billing/invoices/:invoiceId
+func (h *Handler) getInvoice(c *gin.Context) {
+	invoice, ok := h.invoice(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newInvoiceResponse(invoice))
+}
+
+// downloadInvoice handles GET /billing/invoices/:invoiceId/download
+func (h *Handler) downloadInvoice(c *gin.Context) {
+	invoice, ok := h.invoice(c)
+	if !ok {
+		return
+	}
+
+	filename := "invoice-" + strconv.FormatUint(invoice.InvoiceID, 10) + "-" + invoice.PeriodStart.Format("2006-01") + ".csv"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+	if err := billing.WriteCSV(c.Writer, invoice); err != nil {
+		h.logger.Error("Failed to write invoice export", zap.Error(err))
+	}
+}
+
+// cachedPublic serves a public market data response from the shared cache, keyed by path and
+// normalized query, computing it at most once per cache TTL
+func (h *Handler) cachedPublic(c *gin.Context, compute func() (int, any)) {
+	key := c.Request.URL.Path + "?" + c.Request.URL.Query().Encode()
+	status, body := h.publicCache.get(key, compute)
+	if status == http.StatusOK && h.publicCache.ttl > 0 {
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(h.publicCache.ttl.Seconds())))
+	}
+	c.JSON(status, body)
+}
+
+// bindMarketData binds the public market data query, responding with 400 when it is invalid
+func (h *Handler) bindMarketData(c *gin.Context) (MarketDataRequest, bool) {
+	var req MarketDataRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid market data query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return req, false
+	}
+	return req, true
+}
+
+// getTicker handles GET /ticker?symbol={symbol}
+func (h *Handler) getTicker(c *gin.Context) {
+	req, ok := h.bindMarketData(c)
+	if !ok {
+		return
+	}
+
+	h.cachedPublic(c, func() (int, any) {
+		ticker, err := h.service.GetTicker(req.Symbol)
+		if err != nil {
+			h.logger.Error("Failed to get ticker", zap.Error(err))
+			return marketDataStatus(err), ErrorResponse{Error: err.Error()}
+		}
+		response := TickerResponse{
+			Symbol:    ticker.Symbol,
+			BestBid:   nullDecimal(ticker.BestBid),
+			BestAsk:   nullDecimal(ticker.BestAsk),
+			LastPrice: nullDecimal(ticker.LastPrice),
+		}
+		if ticker.LastPrice.Valid {
+			response.LastTradeAt = &ticker.LastTradeAt
+		}
+		return http.StatusOK, response
+	})
+}
+
+// getDepth handles GET /depth?symbol={symbol}&levels={levels}
+func (h *Handler) getDepth(c *gin.Context) {
+	req, ok := h.bindMarketData(c)
+	if !ok {
+		return
+	}
+	if req.Levels == 0 {
+		req.Levels = 20
+	}
+
+	h.cachedPublic(c, func() (int, any) {
+		bids, asks, err := h.service.GetDepth(req.Symbol, req.Levels)
+		if err != nil {
+			h.logger.Error("Failed to get depth", zap.Error(err))
+			return marketDataStatus(err), ErrorResponse{Error: err.Error()}
+		}
+		return http.StatusOK, DepthResponse{
+			Symbol: req.Symbol,
+			Bids:   newPriceLevelResponses(bids),
+			Asks:   newPriceLevelResponses(asks),
+		}
+	})
+}
+
+// getBook handles GET /book?symbol={symbol}&levels={levels}
+func (h *Handler) getBook(c *gin.Context) {
+	req, ok := h.bindMarketData(c)
+	if !ok {
+		return
+	}
+	if req.Levels == 0 {
+		req.Levels = 20
+	}
+
+	h.cachedPublic(c, func() (int, any) {
+		bids, asks, err := h.service.GetBookOrders(req.Symbol, req.Levels)
+		if err != nil {
+			h.logger.Error("Failed to get book", zap.Error(err))
+			return marketDataStatus(err), ErrorResponse{Error: err.Error()}
+		}
+		return http.StatusOK, PublicBookResponse{
+			Symbol: req.Symbol,
+			Bids:   h.newPublicOrderResponses(bids),
+			Asks:   h.newPublicOrderResponses(asks),
+		}
+	})
+}
+
+// newPublicOrderResponses converts resting orders into public responses, aliasing their IDs
+func (h *Handler) newPublicOrderResponses(orders []service.RestingOrder) []PublicOrderResponse {
+	response := make([]PublicOrderResponse, len(orders))
+	for i, o := range orders {
+		response[i] = PublicOrderResponse{
+			OrderID:   h.publicAliases.alias(o.OrderID),
+			Price:     o.Price,
+			Quantity:  o.Quantity,
+			CreatedAt: o.CreatedAt,
+		}
+	}
+	return response
+}
+
+// getRecentTrades handles GET /trades/recent?symbol={symbol}&limit={limit}
+func (h *Handler) getRecentTrades(c *gin.Context) {
+	req, ok := h.bindMarketData(c)
+	if !ok {
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	h.cachedPublic(c, func() (int, any) {
+		trades, err := h.service.GetRecentTrades(c.Request.Context(), req.Symbol, req.Limit)
+		if err != nil {
+			h.logger.Error("Failed to get recent trades", zap.Error(err))
+			return http.StatusInternalServerError, ErrorResponse{Error: err.Error()}
+		}
+		return http.StatusOK, h.publicTrades(trades)
+	})
+}
+
+// publicTrades converts trades to their public form, showing each order by its public alias
+func (h *Handler) publicTrades(trades []*models.Trade) []PublicTradeResponse {
+	response := make([]PublicTradeResponse, 0, len(trades))
+	for _, trade := range trades {
+		response = append(response, PublicTradeResponse{
+			TradeID:     trade.TradeID,
+			BuyOrderID:  h.publicAliases.alias(trade.BuyOrderID),
+			SellOrderID: h.publicAliases.alias(trade.SellOrderID),
+			Price:       trade.Price,
+			Quantity:    trade.Quantity,
+			PrintType:   trade.PrintType,
+			CreatedAt:   trade.CreatedAt,
+		})
+	}
+	return response
+}
+
+// getLeaderboard handles GET /leaderboard?symbol={symbol}&date={date}&limit={limit}
+func (h *Handler) getLeaderboard(c *gin.Context) {
+	var req LeaderboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid leaderboard query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	standings := h.board.Top(req.Symbol, req.Date, req.Limit)
+	response := LeaderboardResponse{
+		Symbol:  req.Symbol,
+		Date:    req.Date.UTC().Format("2006-01-02"),
+		Traders: make([]LeaderboardEntryResponse, len(standings)),
+	}
+	for i, standing := range standings {
+		response.Traders[i] = LeaderboardEntryResponse{
+			Rank:      i + 1,
+			AccountID: standing.AccountID,
+			Notional:  standing.Notional,
+			Quantity:  standing.Quantity,
+			Trades:    standing.Trades,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getPortfolio handles GET /risk/portfolio
+func (h *Handler) getPortfolio(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+
+	portfolio, err := h.risk.GetPortfolio(c.Request.Context(), account)
+	if err != nil {
+		h.logger.Error("Failed to get portfolio", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := PortfolioResponse{
+		ReportingCurrency: portfolio.ReportingCurrency,
+		Equity:            portfolio.Equity,
+		OpenOrderNotional: portfolio.OpenOrderNotional,
+		Committed:         portfolio.Committed,
+		Utilization:       portfolio.Utilization,
+		BuyingPower:       portfolio.BuyingPower,
+		Positions:         make([]PositionResponse, 0, len(portfolio.Positions)),
+		Exposures:         make([]SymbolExposureResponse, 0, len(portfolio.Exposures)),
+		Unvalued:          append([]string{}, portfolio.Unvalued...),
+	}
+	for _, p := range portfolio.Positions {
+		response.Positions = append(response.Positions, PositionResponse{
+			Currency: p.Currency,
+			Quantity: p.Quantity,
+			Rate:     p.Rate,
+			Value:    p.Value,
+			Valued:   p.Valued,
+		})
+	}
+	for _, e := range portfolio.Exposures {
+		response.Exposures = append(response.Exposures, SymbolExposureResponse{
+			Symbol:        e.Symbol,
+			OpenOrders:    e.OpenOrders,
+			BuyNotional:   e.BuyNotional,
+			SellNotional:  e.SellNotional,
+			BuyCommitted:  e.BuyCommitted,
+			SellCommitted: e.SellCommitted,
+			Value:         e.Value,
+			Valued:        e.Valued,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getOrderFlow handles GET /admin/flow?symbol={symbol}&bucket={bucket}&limit={n}
+func (h *Handler) getOrderFlow(c *gin.Context) {
+	var req OrderFlowRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid order flow query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.Bucket == "" {
+		req.Bucket = "1m"
+	}
+	if req.Limit == 0 {
+		req.Limit = 60
+	}
+	length, _ := time.ParseDuration(req.Bucket)
+
+	buckets := h.service.OrderFlow(req.Symbol, length, req.Limit)
+	response := OrderFlowResponse{
+		Symbol:    req.Symbol,
+		Bucket:    req.Bucket,
+		Retention: h.service.FlowRetention().String(),
+		Buckets:   make([]FlowBucketResponse, 0, len(buckets)),
+	}
+	for _, b := range buckets {
+		response.Buckets = append(response.Buckets, FlowBucketResponse{
+			Start:     b.Start,
+			End:       b.Start.Add(length),
+			NewOrders: b.NewOrders,
+			Cancels:   b.Cancels,
+			Modifies:  b.Modifies,
+			Rejects:   b.Rejects,
+			Trades:    b.Trades,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getShards handles GET /admin/shards?symbol={symbol}
+func (h *Handler) getShards(c *gin.Context) {
+	topology := h.service.ShardTopology(c.Request.Context())
+
+	response := ShardsResponse{Count: len(topology), Shards: make([]ShardResponse, 0, len(topology))}
+	for _, st := range topology {
+		response.Shards = append(response.Shards, ShardResponse{
+			Index:   st.Index,
+			Healthy: st.Healthy,
+			Error:   st.Error,
+			Symbols: st.Symbols,
+		})
+	}
+	if symbol := c.Query("symbol"); symbol != "" {
+		shard := h.service.ShardFor(symbol)
+		response.SymbolShard = &shard
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getFeeSchedules handles GET /admin/fee-schedules
+func (h *Handler) getFeeSchedules(c *gin.Context) {
+	schedules := h.service.FeeSchedules()
+	response := make([]FeeScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		response = append(response, newFeeScheduleResponse(schedule))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// createFeeSchedule handles POST /admin/fee-schedules
+func (h *Handler) createFeeSchedule(c *gin.Context) {
+	var req FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	schedule := &models.FeeSchedule{
+		Name:       req.Name,
+		MakerRate:  req.MakerRate,
+		TakerRate:  req.TakerRate,
+		Allocation: req.Allocation,
+		Active:     req.Active == nil || *req.Active,
+	}
+	if err := h.service.CreateFeeSchedule(c.Request.Context(), schedule); err != nil {
+		h.logger.Error("Failed to create fee schedule", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, newFeeScheduleResponse(schedule))
+}
+
+// getTickSize handles GET /admin/symbols/:symbol/tick-size
+func (h *Handler) getTickSize(c *gin.Context) {
+	symbol := c.Param("symbol")
+	tick, err := h.service.TickSize(symbol)
+	if err != nil {
+		c.JSON(marketDataStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, TickSizeResponse{Symbol: symbol, TickSize: tick})
+}
+
+// changeTickSize handles PUT /admin/symbols/:symbol/tick-size
+func (h *Handler) changeTickSize(c *gin.Context) {
+	var req TickSizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	change, err := h.service.ChangeTickSize(c.Param("symbol"), req.TickSize, req.Policy)
+	if err != nil {
+		h.logger.Error("Failed to change tick size", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, TickSizeResponse{
+		Symbol:           change.Symbol,
+		TickSize:         change.TickSize,
+		PreviousTickSize: &change.Previous,
+		Repriced:         newTickOrderResponses(change.Repriced),
+		Canceled:         newTickOrderResponses(change.Canceled),
+	})
+}
+
+// updateFeeSchedule handles PUT /admin/fee-schedules/:scheduleId
+func (h *Handler) updateFeeSchedule(c *gin.Context) {
+	scheduleID, err := strconv.ParseUint(c.Param("scheduleId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid fee schedule ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid fee schedule ID"})
+		return
+	}
+	var req UpdateFeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	schedule, err := h.service.UpdateFeeSchedule(c.Request.Context(), &models.FeeSchedule{
+		ScheduleID: scheduleID,
+		MakerRate:  req.MakerRate,
+		TakerRate:  req.TakerRate,
+		Allocation: req.Allocation,
+		Active:     *req.Active,
+	})
+	if err == models.ErrScheduleNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Fee schedule not found"})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to update fee schedule", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newFeeScheduleResponse(schedule))
+}
+
+// getSandbox handles GET /admin/sandbox
+func (h *Handler) getSandbox(c *gin.Context) {
+	settings, _ := h.service.SandboxSettings()
+	c.JSON(http.StatusOK, newSandboxSettings(settings))
+}
+
+// updateSandbox handles PUT /admin/sandbox
+func (h *Handler) updateSandbox(c *gin.Context) {
+	var req SandboxSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	settings, err := h.service.UpdateSandboxSettings(config.SandboxConfig{
+		AckLatency:      time.Duration(req.AckLatencyMs) * time.Millisecond,
+		AckJitter:       time.Duration(req.AckJitterMs) * time.Millisecond,
+		PartialFillRate: req.PartialFillRate,
+		QueueAhead:      req.QueueAhead,
+		QueueHalfLife:   time.Duration(req.QueueHalfLifeMs) * time.Millisecond,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newSandboxSettings(settings))
+}
+
+// getAccountFees handles GET /fees/schedule
+func (h *Handler) getAccountFees(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+
+	schedule, maker, taker := h.service.AccountFees(account)
+	response := AccountFeesResponse{MakerRate: maker, TakerRate: taker}
+	if schedule != nil {
+		scheduleResponse := newFeeScheduleResponse(schedule)
+		response.Schedule = &scheduleResponse
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// subscriberID returns the feed subscriber named in the path, which is the caller's account, or
+// responds with 400 when it is invalid and 403 when it is another's
+func (h *Handler) subscriberID(c *gin.Context) (string, bool) {
+	id := c.Param("subscriberId")
+	if len(id) > 64 {
+		h.logger.Warn("Invalid subscriber ID", zap.String("subscriber_id", id))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid subscriber ID"})
+		return "", false
+	}
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return "", false
+	}
+	if id != account {
+		h.logger.Warn("Subscriber is not the caller", zap.String("subscriber_id", id), zap.String("account_id", account))
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Subscribers read the feed as their own account"})
+		return "", false
+	}
+	return id, true
+}
+
+// getOrderEvents handles GET /compliance/subscribers/:subscriberId/events?cursor={cursor}&limit={n}&wait={duration}
+func (h *Handler) getOrderEvents(c *gin.Context) {
+	subscriber, ok := h.subscriberID(c)
+	if !ok {
+		return
+	}
+	var req OrderEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid order events query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	var from compliance.Cursor
+	var err error
+	if req.Cursor != "" {
+		from, err = h.feed.ParseCursor(req.Cursor)
+	} else {
+		from, err = h.feed.Position(c.Request.Context(), subscriber)
+	}
+	if err == models.ErrInvalidCursor {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	events, next, err := h.feed.Poll(c.Request.Context(), from, req.Limit, req.Wait)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newOrderEventsResponse(events, next))
+}
+
+// ackOrderEvents handles POST /compliance/subscribers/:subscriberId/ack
+func (h *Handler) ackOrderEvents(c *gin.Context) {
+	subscriber, ok := h.subscriberID(c)
+	if !ok {
+		return
+	}
+	var req AckEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	cursor, err := h.feed.ParseCursor(req.Cursor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.feed.Ack(c.Request.Context(), subscriber, cursor); err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Events acknowledged"})
+}
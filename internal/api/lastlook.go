@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// respondLastLook handles POST /last-looks/:lastLookId
+func (h *Handler) respondLastLook(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	lastLookID, err := strconv.ParseUint(c.Param("lastLookId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid last look ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid last look ID"})
+		return
+	}
+	var req LastLookAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid last look answer", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	if err := h.service.RespondLastLook(account, lastLookID, *req.Accept); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Last look answered"})
+}
+
+// getLastLookStats handles GET /admin/last-looks?symbol={symbol}&from={from}&to={to}
+func (h *Handler) getLastLookStats(c *gin.Context) {
+	var req LastLookStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid last look query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if req.From.IsZero() {
+		req.From = req.To.Add(-h.service.LastLookPeriod())
+	}
+
+	stats, err := h.service.GetLastLookStats(c.Request.Context(), req.Symbol, req.From, req.To)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := LastLookReportResponse{
+		Symbol:    req.Symbol,
+		From:      req.From,
+		To:        req.To,
+		Providers: make([]LastLookStatsResponse, 0, len(stats)),
+	}
+	for _, st := range stats {
+		provider := LastLookStatsResponse{
+			ProviderID: st.ProviderID,
+			Requests:   st.Requests,
+			Rejects:    st.Rejects,
+			Timeouts:   st.Timeouts,
+			Flagged:    h.service.LastLookFlagged(st),
+		}
+		if st.Requests > 0 {
+			provider.RejectRatio = float64(st.Rejects) / float64(st.Requests)
+		}
+		response.Providers = append(response.Providers, provider)
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// getLedger handles GET /wallet/ledger?limit={n}
+func (h *Handler) getLedger(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	var req LedgerRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid ledger query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	postings, err := h.service.GetLedger(c.Request.Context(), account, req.Limit)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	response := make([]LedgerPostingResponse, 0, len(postings))
+	for _, p := range postings {
+		posting := LedgerPostingResponse{
+			PostingID: p.PostingID,
+			Kind:      p.Kind,
+			CreatedAt: p.CreatedAt,
+			Entries:   make([]LedgerEntryResponse, 0, len(p.Entries)),
+		}
+		if p.ReferenceID.Valid {
+			posting.ReferenceID = &p.ReferenceID.Int64
+		}
+		for _, e := range p.Entries {
+			posting.Entries = append(posting.Entries, LedgerEntryResponse{
+				EntryID:  e.EntryID,
+				Currency: e.Currency,
+				Bucket:   e.Bucket,
+				Amount:   e.Amount,
+			})
+		}
+		response = append(response, posting)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// reconcileLedger handles GET /admin/ledger/reconciliation
+func (h *Handler) reconcileLedger(c *gin.Context) {
+	reconciliation, err := h.service.Reconcile(c.Request.Context())
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	response := ReconciliationResponse{
+		Balanced:   len(reconciliation.Mismatches) == 0 && len(reconciliation.Unbalanced) == 0,
+		Mismatches: make([]LedgerMismatchResponse, 0, len(reconciliation.Mismatches)),
+		Unbalanced: make([]UnbalancedPostingResponse, 0, len(reconciliation.Unbalanced)),
+	}
+	for _, m := range reconciliation.Mismatches {
+		response.Mismatches = append(response.Mismatches, LedgerMismatchResponse{
+			AccountID:     m.AccountID,
+			Currency:      m.Currency,
+			Settled:       m.Settled,
+			Pending:       m.Pending,
+			Held:          m.Held,
+			LedgerSettled: m.LedgerSettled,
+			LedgerPending: m.LedgerPending,
+			LedgerHeld:    m.LedgerHeld,
+		})
+	}
+	for _, u := range reconciliation.Unbalanced {
+		response.Unbalanced = append(response.Unbalanced, UnbalancedPostingResponse{
+			PostingID: u.PostingID,
+			Currency:  u.Currency,
+			Sum:       u.Sum,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// refuseInMaintenance turns order entry and cancels away while maintenance mode is on, before they
+// take any quota or bulkhead slot
+func (h *Handler) refuseInMaintenance(c *gin.Context) {
+	if h.service.Maintenance() {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, errorResponse(models.ErrMaintenance))
+		return
+	}
+	c.Next()
+}
+
+// getMaintenance handles GET /admin/maintenance
+func (h *Handler) getMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, MaintenanceResponse{Enabled: h.service.Maintenance()})
+}
+
+// updateMaintenance handles PUT /admin/maintenance
+func (h *Handler) updateMaintenance(c *gin.Context) {
+	var req UpdateMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	h.service.SetMaintenance(*req.Enabled)
+	c.JSON(http.StatusOK, MaintenanceResponse{Enabled: h.service.Maintenance()})
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"orderSystem/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// instrument counts and times every request by its route, the pattern it matched rather than
+// its path so IDs in the path do not each get their own series
+func instrument(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	metrics.Since(metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route), start)
+}
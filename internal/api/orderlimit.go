@@ -0,0 +1,211 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"orderSystem/internal/config"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// orderRate is one caller's order entry budget and the outcomes of its requests in the current window
+type orderRate struct {
+	bucket
+	factor      float64 // fraction of the configured rate and burst currently allowed
+	windowStart time.Time
+	orders      int // order entry requests answered in the window
+	rejects     int // of which were refused
+	cancels     int // successful cancels in the window
+}
+
+// orderRateStatus is a caller's effective order rate limit and the ratios it is judged by
+type orderRateStatus struct {
+	Rate        float64
+	Burst       int
+	Factor      float64
+	WindowStart time.Time
+	Orders      int
+	Rejects     int
+	Cancels     int
+}
+
+// orderLimiter rate limits each caller's order entry, adapting the limit to how the caller's
+// orders fare. At the end of every window in which a caller sent at least MinOrders orders and
+// saw more than MaxRejectRatio of them refused, or canceled more than MaxCancelRatio as many
+// orders as it sent, its rate and burst are multiplied by TightenFactor, down to MinFactor of
+// the configured ones; every other window restores RelaxStep of the configured limit.
+type orderLimiter struct {
+	cfg       config.OrderLimitsConfig
+	mutex     sync.Mutex
+	rates     map[string]*orderRate
+	lastSweep time.Time
+}
+
+// newOrderLimiter creates an adaptive order rate limiter, nil when disabled
+func newOrderLimiter(cfg config.OrderLimitsConfig) *orderLimiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &orderLimiter{cfg: cfg, rates: make(map[string]*orderRate), lastSweep: time.Now()}
+}
+
+// rate returns key's state brought up to now, closing any windows that have ended. Callers must
+// hold the mutex.
+func (l *orderLimiter) rate(key string, now time.Time) *orderRate {
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for k, r := range l.rates {
+			if now.Sub(r.last) > limiterIdleTTL && r.factor == 1 {
+				delete(l.rates, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	r, ok := l.rates[key]
+	if !ok {
+		r = &orderRate{bucket: bucket{tokens: float64(l.cfg.Burst), last: now}, factor: 1, windowStart: now}
+		l.rates[key] = r
+	}
+	if windows := int(now.Sub(r.windowStart) / l.cfg.Window); windows > 0 {
+		misbehaved := r.orders >= l.cfg.MinOrders && r.orders > 0 &&
+			(float64(r.rejects)/float64(r.orders) > l.cfg.MaxRejectRatio ||
+				float64(r.cancels)/float64(r.orders) > l.cfg.MaxCancelRatio)
+		relaxed := windows
+		if misbehaved {
+			r.factor = math.Max(l.cfg.MinFactor, r.factor*l.cfg.TightenFactor)
+			relaxed--
+		}
+		r.factor = math.Min(1, r.factor+float64(relaxed)*l.cfg.RelaxStep)
+		r.windowStart = r.windowStart.Add(time.Duration(windows) * l.cfg.Window)
+		r.orders, r.rejects, r.cancels = 0, 0, 0
+	}
+
+	rate, burst := l.cfg.Rate*r.factor, l.burst(r)
+	r.tokens = math.Min(float64(burst), r.tokens+now.Sub(r.last).Seconds()*rate)
+	r.last = now
+	return r
+}
+
+// burst returns a caller's current burst, never below a single request
+func (l *orderLimiter) burst(r *orderRate) int {
+	return max(1, int(float64(l.cfg.Burst)*r.factor))
+}
+
+// allow takes a token from key's bucket, returning how long to wait when none is available
+func (l *orderLimiter) allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	r := l.rate(key, time.Now())
+	if r.tokens < 1 {
+		return false, time.Duration((1 - r.tokens) / (l.cfg.Rate * r.factor) * float64(time.Second))
+	}
+	r.tokens--
+	return true, 0
+}
+
+// recordOrder counts an answered order entry request, refused or not
+func (l *orderLimiter) recordOrder(key string, refused bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	r := l.rate(key, time.Now())
+	r.orders++
+	if refused {
+		r.rejects++
+	}
+}
+
+// recordCancel counts a successful cancel
+func (l *orderLimiter) recordCancel(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.rate(key, time.Now()).cancels++
+}
+
+// status returns key's effective limit and the counts of its current window
+func (l *orderLimiter) status(key string) orderRateStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	r := l.rate(key, time.Now())
+	return orderRateStatus{
+		Rate:        l.cfg.Rate * r.factor,
+		Burst:       l.burst(r),
+		Factor:      r.factor,
+		WindowStart: r.windowStart,
+		Orders:      r.orders,
+		Rejects:     r.rejects,
+		Cancels:     r.cancels,
+	}
+}
+
+// orderKey identifies the caller an order rate applies to: its account, or its IP when anonymous
+func orderKey(c *gin.Context) string {
+	if id := accountID(c); id != "" {
+		return "account:" + id
+	}
+	return "ip:" + clientIP(c)
+}
+
+// limitOrderEntry rejects order entry requests with 429 once the caller exhausts its adaptive
+// budget, and counts every answered request towards its reject ratio; a 4xx other than 429
+// counts as refused. Orders accepted with ack_mode async and rejected later by matching are
+// not seen here.
+func (h *Handler) limitOrderEntry(c *gin.Context) {
+	if h.orderLimits == nil {
+		c.Next()
+		return
+	}
+	key := orderKey(c)
+	ok, wait := h.orderLimits.allow(key)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{Error: "Order rate limit exceeded"})
+		return
+	}
+	c.Next()
+	status := c.Writer.Status()
+	h.orderLimits.recordOrder(key, status >= 400 && status < 500 && status != http.StatusTooManyRequests)
+}
+
+// countCancels counts every successful cancel towards the caller's cancel ratio
+func (h *Handler) countCancels(c *gin.Context) {
+	if h.orderLimits == nil {
+		c.Next()
+		return
+	}
+	c.Next()
+	if status := c.Writer.Status(); status >= 200 && status < 300 {
+		h.orderLimits.recordCancel(orderKey(c))
+	}
+}
+
+// getOrderRateLimit handles GET /account/rate-limit
+func (h *Handler) getOrderRateLimit(c *gin.Context) {
+	if h.orderLimits == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order rate limiting is disabled"})
+		return
+	}
+	st := h.orderLimits.status(orderKey(c))
+	cfg := h.orderLimits.cfg
+	response := OrderRateLimitResponse{
+		Rate:           st.Rate,
+		Burst:          st.Burst,
+		BaseRate:       cfg.Rate,
+		BaseBurst:      cfg.Burst,
+		Factor:         st.Factor,
+		WindowStart:    st.WindowStart,
+		WindowEnd:      st.WindowStart.Add(cfg.Window),
+		Orders:         st.Orders,
+		Rejects:        st.Rejects,
+		Cancels:        st.Cancels,
+		MaxRejectRatio: cfg.MaxRejectRatio,
+		MaxCancelRatio: cfg.MaxCancelRatio,
+	}
+	if st.Orders > 0 {
+		response.RejectRatio = float64(st.Rejects) / float64(st.Orders)
+		response.CancelRatio = float64(st.Cancels) / float64(st.Orders)
+	}
+	c.JSON(http.StatusOK, response)
+}
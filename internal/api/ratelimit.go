@@ -0,0 +1,134 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limiterIdleTTL is how long an untouched bucket is kept before being swept
+const limiterIdleTTL = 10 * time.Minute
+
+// bucket is a token bucket for a single client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per key refilled at rate tokens per second up to burst
+type rateLimiter struct {
+	rate      float64
+	burst     float64
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// newRateLimiter creates a limiter allowing rate requests per second per key with the given burst
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// quota is the state of a bucket once a request has been counted
+type quota struct {
+	limit     int           // the burst
+	remaining int           // whole tokens left
+	reset     time.Duration // until the bucket is full again
+	wait      time.Duration // until the next token, when the request was refused
+}
+
+// take takes a token from key's bucket, reporting whether one was available along with the
+// bucket's quota
+func (l *rateLimiter) take(key string) (bool, quota) {
+	now := time.Now()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > limiterIdleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	q := quota{
+		limit:     int(l.burst),
+		remaining: int(b.tokens),
+		reset:     time.Duration((l.burst - b.tokens) / l.rate * float64(time.Second)),
+	}
+	if !allowed {
+		q.wait = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	return allowed, q
+}
+
+// rateRemainingKey is the gin context key of the lowest remaining quota reported so far
+const rateRemainingKey = "rate_limit_remaining"
+
+// setRateHeaders reports a quota in the X-RateLimit headers unless an earlier limiter on the
+// request already reported a tighter one
+func setRateHeaders(c *gin.Context, q quota) {
+	if remaining, ok := c.Get(rateRemainingKey); ok && remaining.(int) <= q.remaining {
+		return
+	}
+	c.Set(rateRemainingKey, q.remaining)
+	c.Header("X-RateLimit-Limit", strconv.Itoa(q.limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(q.remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(q.reset.Seconds()))))
+}
+
+// rateLimit rejects requests with 429 once the key derived from the request exhausts its bucket,
+// reporting the bucket's quota in the X-RateLimit headers. An empty key is not limited.
+func rateLimit(l *rateLimiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if l == nil || k == "" {
+			c.Next()
+			return
+		}
+		ok, q := l.take(k)
+		setRateHeaders(c, q)
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(q.wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{Error: "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientIP identifies the client a per-IP quota applies to: the address the request came from,
+// or the one a proxy in server.trusted_proxies forwards, never a forwarding header set by anyone
+// else, so rotating the header cannot earn a client a fresh bucket
+func clientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// apiKey identifies the API key a request is made with: the signing key of a signed request,
+// otherwise its account, empty when anonymous
+func apiKey(c *gin.Context) string {
+	if id := c.GetHeader(signingKeyHeader); id != "" {
+		return "key:" + id
+	}
+	if id := accountID(c); id != "" {
+		return "account:" + id
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"orderSystem/internal/config"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seenIP returns the client IP a router built with the given trusted proxies sees for a
+// request from peer carrying forwarded in X-Forwarded-For
+func seenIP(t *testing.T, trustedProxies []string, peer, forwarded string) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router, err := NewRouter(config.ServerConfig{TrustedProxies: trustedProxies})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = peer + ":40000"
+	req.Header.Set("X-Forwarded-For", forwarded)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Body.String()
+}
+
+func TestSpoofedForwardedForIgnored(t *testing.T) {
+	if ip := seenIP(t, nil, "198.51.100.7", "203.0.113.7"); ip != "198.51.100.7" {
+		t.Errorf("client IP without trusted proxies = %s, want the peer 198.51.100.7", ip)
+	}
+	if ip := seenIP(t, []string{"10.0.0.0/8"}, "198.51.100.7", "203.0.113.7"); ip != "198.51.100.7" {
+		t.Errorf("client IP from an untrusted peer = %s, want the peer 198.51.100.7", ip)
+	}
+}
+
+func TestTrustedProxyForwardsClientIP(t *testing.T) {
+	if ip := seenIP(t, []string{"10.0.0.0/8"}, "10.1.2.3", "203.0.113.7"); ip != "203.0.113.7" {
+		t.Errorf("client IP through a trusted proxy = %s, want the forwarded 203.0.113.7", ip)
+	}
+}
+
+// TestIPQuotaIgnoresSpoofedForwardedFor rotates X-Forwarded-For from one untrusted peer, which
+// must not give it a fresh per-IP bucket for each claimed address
+func TestIPQuotaIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router, err := NewRouter(config.ServerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.GET("/limited", rateLimit(newRateLimiter(1, 2), clientIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	var statuses []int
+	for _, forwarded := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = "198.51.100.7:40000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		statuses = append(statuses, rec.Code)
+	}
+	if statuses[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses %v, want the third request limited", statuses)
+	}
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"orderSystem/internal/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Headers of a signed request
+const (
+	signingKeyHeader = "X-Signing-Key"
+	timestampHeader  = "X-Timestamp" // Unix milliseconds
+	nonceHeader      = "X-Nonce"
+	signatureHeader  = "X-Signature"
+)
+
+// signingKeyKey is the context key of the key a request was signed with
+const signingKeyKey = "signing_key"
+
+// Limits on what a signed request may carry
+const (
+	maxNonceLength = 64
+	maxSignedBody  = 1 << 20
+)
+
+// nonceCache remembers the nonces of accepted signed requests until their timestamps leave the
+// window, so a captured request cannot be replayed while it would still pass the clock check
+type nonceCache struct {
+	window    time.Duration
+	mutex     sync.Mutex
+	expiries  map[string]time.Time
+	lastSweep time.Time
+}
+
+// newNonceCache creates a cache for requests whose timestamps may be window away from the server clock
+func newNonceCache(window time.Duration) *nonceCache {
+	return &nonceCache{window: window, expiries: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// claim records a key's nonce for a request signed at timestamp, returning false when it was
+// already used
+func (n *nonceCache) claim(keyID, nonce string, timestamp time.Time) bool {
+	now := time.Now()
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if now.Sub(n.lastSweep) > n.window {
+		for k, expiry := range n.expiries {
+			if now.After(expiry) {
+				delete(n.expiries, k)
+			}
+		}
+		n.lastSweep = now
+	}
+
+	k := keyID + "\n" + nonce
+	if expiry, ok := n.expiries[k]; ok && !now.After(expiry) {
+		return false
+	}
+	n.expiries[k] = timestamp.Add(n.window)
+	return true
+}
+
+// signaturePayload returns the string a request is signed over: its timestamp, nonce, method,
+// path with query and body, separated by newlines
+func signaturePayload(timestamp, nonce string, r *http.Request, body []byte) []byte {
+	payload := []byte(timestamp + "\n" + nonce + "\n" + r.Method + "\n" + r.URL.RequestURI() + "\n")
+	return append(payload, body...)
+}
+
+// sign returns the hex HMAC-SHA256 of payload under secret
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature authenticates HMAC-signed requests, which then act for the signing key's
+// account whatever their account header says. Unsigned requests pass through unless signing is
+// required.
+func (h *Handler) verifySignature(c *gin.Context) {
+	h.checkSignature(c, h.signing.Required)
+}
+
+// acceptSignature authenticates HMAC-signed requests like verifySignature, but lets unsigned
+// requests through even when signing is required
+func (h *Handler) acceptSignature(c *gin.Context) {
+	h.checkSignature(c, false)
+}
+
+// checkSignature authenticates a signed request from one of its key's allowed IPs, rejecting
+// unsigned ones when required
+func (h *Handler) checkSignature(c *gin.Context, required bool) {
+	signature := c.GetHeader(signatureHeader)
+	if signature == "" {
+		if required {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Signed request required"})
+			return
+		}
+		c.Next()
+		return
+	}
+
+	keyID, timestamp, nonce := c.GetHeader(signingKeyHeader), c.GetHeader(timestampHeader), c.GetHeader(nonceHeader)
+	if keyID == "" || nonce == "" || len(nonce) > maxNonceLength {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+			Error: signingKeyHeader + ", " + timestampHeader + " and " + nonceHeader + " headers are required"})
+		return
+	}
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	signedAt := time.UnixMilli(millis)
+	if err != nil || signedAt.Before(time.Now().Add(-h.signing.Window)) || signedAt.After(time.Now().Add(h.signing.Window)) {
+		h.logger.Warn("Signed request outside the time window", zap.String("key_id", keyID), zap.String("timestamp", timestamp))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Request timestamp outside the allowed window"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBody))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Request body too large"})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	key, err := h.service.SigningKey(c.Request.Context(), keyID)
+	if err == models.ErrSigningKeyNotFound {
+		h.logger.Warn("Unknown signing key", zap.String("key_id", keyID))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid signature"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	expected := sign(key.Secret, signaturePayload(timestamp, nonce, c.Request, body))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		h.logger.Warn("Invalid request signature", zap.String("key_id", keyID))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid signature"})
+		return
+	}
+	if !h.nonces.claim(keyID, nonce, signedAt) {
+		h.logger.Warn("Replayed signed request", zap.String("key_id", keyID), zap.String("nonce", nonce))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Nonce already used"})
+		return
+	}
+	if !key.AllowsIP(c.ClientIP()) {
+		h.logger.Warn("Signed request from an IP the key does not allow", zap.String("key_id", keyID),
+			zap.String("ip", c.ClientIP()))
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Client IP not allowed for this signing key"})
+		return
+	}
+
+	c.Set(signingKeyKey, key)
+	c.Set(accountKey, key.AccountID)
+	c.Next()
+}
+
+// signingKey returns the key a request was signed with, nil for an unsigned request
+func signingKey(c *gin.Context) *models.SigningKey {
+	value, _ := c.Get(signingKeyKey)
+	key, _ := value.(*models.SigningKey)
+	return key
+}
+
+// requireScope rejects requests signed with a key that lacks the scope; unsigned requests are
+// left to the route's other checks
+func requireScope(scope models.KeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := signingKey(c); key != nil && !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Signing key lacks the " + string(scope) + " scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// createSigningKey handles POST /signing-keys. Accounts cannot grant their own keys the admin or
+// compliance scope, and a signed request only scopes it holds itself.
+func (h *Handler) createSigningKey(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	var req CreateSigningKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.logger.Warn("Invalid signing key request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+	signer := signingKey(c)
+	for _, scope := range scopes {
+		if scope == models.ScopeAdmin || scope == models.ScopeCompliance || signer != nil && !signer.HasScope(scope) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Cannot grant the " + string(scope) + " scope"})
+			return
+		}
+	}
+	h.issueSigningKey(c, account, scopes, req.AllowedIPs)
+}
+
+// issueSigningKey creates a signing key and responds with it, secret included
+func (h *Handler) issueSigningKey(c *gin.Context, account string, scopes []models.KeyScope, allowedIPs []string) {
+	key, err := h.service.CreateSigningKey(c.Request.Context(), account, scopes, allowedIPs)
+	switch err {
+	case nil:
+		c.JSON(http.StatusCreated, newSigningKeyResponse(key, true))
+	case models.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case models.ErrInvalidSigningKey:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: models.ReasonInvalidRequest})
+	default:
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	}
+}
+
+// revokeSigningKey handles DELETE /signing-keys/:keyId
+func (h *Handler) revokeSigningKey(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	if err := h.service.RevokeSigningKey(c.Request.Context(), account, c.Param("keyId")); err != nil {
+		h.logger.Warn("Failed to revoke signing key", zap.String("account_id", account), zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusNotFound), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Signing key revoked"})
+}
+
+// getAccountSigningKeys handles GET /admin/accounts/:accountId/signing-keys
+func (h *Handler) getAccountSigningKeys(c *gin.Context) {
+	keys, err := h.service.SigningKeys(c.Request.Context(), c.Param("accountId"))
+	if err == models.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	resp := make([]SigningKeyResponse, len(keys))
+	for i, key := range keys {
+		resp[i] = newSigningKeyResponse(key, false)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// createAccountSigningKey handles POST /admin/accounts/:accountId/signing-keys, which may grant
+// any scope
+func (h *Handler) createAccountSigningKey(c *gin.Context) {
+	var req CreateSigningKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.logger.Warn("Invalid signing key request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+	h.issueSigningKey(c, c.Param("accountId"), req.Scopes, req.AllowedIPs)
+}
+
+// revokeAnySigningKey handles DELETE /admin/signing-keys/:keyId
+func (h *Handler) revokeAnySigningKey(c *gin.Context) {
+	if err := h.service.RevokeSigningKey(c.Request.Context(), "", c.Param("keyId")); err != nil {
+		h.logger.Warn("Failed to revoke signing key", zap.String("key_id", c.Param("keyId")), zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusNotFound), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Signing key revoked"})
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/models"
+	"orderSystem/internal/service"
+	"orderSystem/internal/stream"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// streamReplies is how many command replies may queue for a WebSocket connection's writer
+const streamReplies = 64
+
+// Market data stream channels, mapped onto the engine's event kinds
+var streamChannels = map[string]service.MarketEventKind{
+	"trades": service.EventTrade,
+	"book":   service.EventBook,
+}
+
+// streamMarketData handles GET /ws, upgrading to a WebSocket that streams the trades and book
+// changes of the symbol channels the client subscribes to. With ?resume_token={token} it
+// resumes a disconnected session instead of starting a new one.
+func (h *Handler) streamMarketData(c *gin.Context) {
+	var subscriber *stream.Subscriber
+	var err error
+	token := c.Query("resume_token")
+	if token != "" {
+		subscriber, err = h.hub.Resume(token)
+	} else {
+		subscriber, err = h.hub.Connect(clientIP(c))
+	}
+	if err != nil {
+		h.logger.Warn("Rejected market data stream", zap.String("client_ip", c.ClientIP()), zap.Error(err))
+		status := http.StatusNotFound
+		if err == models.ErrTooManyConnections {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer subscriber.Detach()
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serveStream(ws, subscriber, token != "")
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serveStream runs one WebSocket connection: commands are read on their own goroutine while this
+// one writes replies, heartbeats and events, so a client is always written to from a single
+// goroutine. The session's resume token is sent first, along with its subscriptions when it is
+// resumed.
+func (h *Handler) serveStream(ws *websocket.Conn, subscriber *stream.Subscriber, resumed bool) {
+	defer ws.Close()
+	// The server's read and write timeouts still apply to the hijacked connection
+	ws.SetDeadline(time.Time{})
+
+	greeting := StreamMessage{Type: "session", ResumeToken: subscriber.Token()}
+	if resumed {
+		greeting.Type = "resumed"
+		greeting.Subscriptions = newStreamSubscriptions(subscriber.Subscriptions())
+	}
+	if err := websocket.JSON.Send(ws, greeting); err != nil {
+		return
+	}
+	// An event taken from the queue as the previous connection dropped is sent before the rest
+	if event := subscriber.TakeUnsent(); event != nil {
+		if err := websocket.JSON.Send(ws, h.newStreamMessage(event)); err != nil {
+			subscriber.Unsent(event)
+			return
+		}
+		subscriber.Delivered(event)
+	}
+
+	replies := make(chan StreamMessage, streamReplies)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.readStreamCommands(ws, subscriber, replies)
+	}()
+
+	heartbeat := time.NewTicker(h.streaming.PingInterval)
+	defer heartbeat.Stop()
+	for {
+		var message StreamMessage
+		var event *service.MarketEvent
+		select {
+		case <-done:
+			return
+		case message = <-replies:
+		case <-heartbeat.C:
+			message = StreamMessage{Type: "ping"}
+		case queued, ok := <-subscriber.Events():
+			if !ok {
+				websocket.JSON.Send(ws, StreamMessage{Type: "error", Error: "subscriber fell behind the feed"})
+				return
+			}
+			event, message = queued, h.newStreamMessage(queued)
+		}
+		if err := websocket.JSON.Send(ws, message); err != nil {
+			if event != nil {
+				subscriber.Unsent(event)
+			}
+			return
+		}
+		if event != nil {
+			subscriber.Delivered(event)
+		}
+	}
+}
+
+// readStreamCommands applies the client's commands until the connection closes or the client
+// sends nothing, not even a pong, for the idle timeout
+func (h *Handler) readStreamCommands(ws *websocket.Conn, subscriber *stream.Subscriber, replies chan<- StreamMessage) {
+	for {
+		ws.SetReadDeadline(time.Now().Add(h.streaming.IdleTimeout))
+		var cmd StreamCommand
+		if err := websocket.JSON.Receive(ws, &cmd); err != nil {
+			return
+		}
+		switch cmd.Op {
+		case "pong":
+			continue
+		case "ping":
+			replies <- StreamMessage{Type: "pong"}
+			continue
+		}
+		kind, ok := streamChannels[cmd.Channel]
+		if !ok || cmd.Symbol == "" || cmd.Op != "subscribe" && cmd.Op != "unsubscribe" {
+			replies <- StreamMessage{Type: "error", Channel: cmd.Channel, Symbol: cmd.Symbol, Error: "invalid stream command"}
+			continue
+		}
+		topic := stream.Topic{Symbol: cmd.Symbol, Kind: kind}
+
+		if cmd.Op == "unsubscribe" {
+			subscriber.Unwatch(topic)
+			replies <- StreamMessage{Type: "unsubscribed", Channel: cmd.Channel, Symbol: cmd.Symbol}
+			continue
+		}
+		if subscriber.Watching(topic) {
+			continue
+		}
+		if _, ok := h.service.SymbolConfig(ws.Request().Context(), cmd.Symbol); !ok {
+			replies <- StreamMessage{Type: "error", Channel: cmd.Channel, Symbol: cmd.Symbol, Error: models.ErrSymbolNotFound.Error()}
+			continue
+		}
+		var err error
+		if kind == service.EventBook {
+			// The snapshot is queued and the channel watched on the symbol's engine, so the
+			// deltas that follow continue from exactly the snapshot's sequence
+			snapshotErr := h.service.SnapshotBook(cmd.Symbol, func(snapshot *service.MarketEvent) {
+				err = subscriber.Watch(topic, snapshot)
+			})
+			if snapshotErr != nil {
+				h.logger.Error("Failed to snapshot book", zap.String("symbol", cmd.Symbol), zap.Error(snapshotErr))
+				err = snapshotErr
+			}
+		} else {
+			err = subscriber.Watch(topic, nil)
+		}
+		if err != nil {
+			replies <- StreamMessage{Type: "error", Channel: cmd.Channel, Symbol: cmd.Symbol, Error: err.Error()}
+			continue
+		}
+		replies <- StreamMessage{Type: "subscribed", Channel: cmd.Channel, Symbol: cmd.Symbol}
+	}
+}
+
+// newStreamSubscriptions lists a session's subscriptions in channel and symbol order
+func newStreamSubscriptions(subscriptions map[stream.Topic]uint64) []StreamSubscription {
+	response := make([]StreamSubscription, 0, len(subscriptions))
+	for channel, kind := range streamChannels {
+		for topic, sequence := range subscriptions {
+			if topic.Kind == kind {
+				response = append(response, StreamSubscription{Channel: channel, Symbol: topic.Symbol, Sequence: sequence})
+			}
+		}
+	}
+	sort.Slice(response, func(i, j int) bool {
+		if response[i].Channel != response[j].Channel {
+			return response[i].Channel < response[j].Channel
+		}
+		return response[i].Symbol < response[j].Symbol
+	})
+	return response
+}
+
+// newStreamMessage converts a market event into the message sent to clients, aliasing order IDs
+// on the public tape
+func (h *Handler) newStreamMessage(event *service.MarketEvent) StreamMessage {
+	message := StreamMessage{
+		Type:     string(event.Kind),
+		Symbol:   event.Symbol,
+		Sequence: &event.Sequence,
+		Time:     &event.Time,
+	}
+	switch event.Kind {
+	case service.EventTrade:
+		message.Channel = "trades"
+		message.Trades = h.publicTrades(event.Trades)
+	case service.EventBook:
+		message.Channel = "book"
+		message.PrevSequence, message.Checksum = &event.PrevSequence, &event.Checksum
+		message.Changes = make([]BookChangeResponse, len(event.Changes))
+		for i, change := range event.Changes {
+			message.Changes[i] = BookChangeResponse{
+				Side:       change.Side,
+				Price:      change.Level.Price,
+				Quantity:   change.Level.Quantity,
+				OrderCount: change.Level.OrderCount,
+			}
+		}
+	case service.EventSnapshot:
+		message.Channel = "book"
+		bids, asks := newPriceLevelResponses(event.Bids), newPriceLevelResponses(event.Asks)
+		message.Bids, message.Asks = &bids, &asks
+		message.Checksum = &event.Checksum
+	}
+	return message
+}
+
+// listenKeyCheck is how often an open private stream checks that its listen key is still valid
+const listenKeyCheck = 30 * time.Second
+
+// createListenKey handles POST /user-stream
+func (h *Handler) createListenKey(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	key, expiresAt := h.users.CreateListenKey(account)
+	c.JSON(http.StatusOK, ListenKeyResponse{ListenKey: key, ExpiresAt: expiresAt})
+}
+
+// keepAliveListenKey handles PUT /user-stream/:listenKey
+func (h *Handler) keepAliveListenKey(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	key := c.Param("listenKey")
+	expiresAt, err := h.users.KeepAlive(key, account)
+	if err != nil {
+		h.logger.Warn("Failed to keep listen key alive", zap.String("account_id", account), zap.Error(err))
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ListenKeyResponse{ListenKey: key, ExpiresAt: expiresAt})
+}
+
+// closeListenKey handles DELETE /user-stream/:listenKey
+func (h *Handler) closeListenKey(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	if err := h.users.CloseListenKey(c.Param("listenKey"), account); err != nil {
+		h.logger.Warn("Failed to close listen key", zap.String("account_id", account), zap.Error(err))
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Listen key closed"})
+}
+
+// streamOrders handles GET /ws/user?listen_key={listenKey}, upgrading to a WebSocket that pushes
+// the changes to the listen key's account's orders
+func (h *Handler) streamOrders(c *gin.Context) {
+	key := c.Query("listen_key")
+	userStream, err := h.users.Open(key)
+	if err != nil {
+		h.logger.Warn("Rejected private stream", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer h.users.Close(userStream)
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serveUserStream(ws, key, userStream)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serveUserStream writes a private stream's order updates and last looks until the client
+// disconnects, the stream falls behind or its listen key expires
+func (h *Handler) serveUserStream(ws *websocket.Conn, key string, userStream *stream.UserStream) {
+	defer ws.Close()
+	ws.SetDeadline(time.Time{})
+
+	// Clients send nothing; reading only notices when they go away
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	ticker := time.NewTicker(listenKeyCheck)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if !h.users.ValidKey(key) {
+				websocket.JSON.Send(ws, StreamMessage{Type: "listen_key_expired"})
+				return
+			}
+		case update, ok := <-userStream.Updates():
+			if !ok {
+				websocket.JSON.Send(ws, StreamMessage{Type: "error", Error: "stream fell behind the order updates"})
+				return
+			}
+			var message any
+			if update.Kind == stream.UpdateLastLook {
+				message = newLastLookResponse(update.LastLook)
+			} else {
+				message = newOrderUpdateResponse(update)
+			}
+			if err := websocket.JSON.Send(ws, message); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// getSymbols handles GET /admin/symbols
+func (h *Handler) getSymbols(c *gin.Context) {
+	symbols, err := h.service.Symbols(c.Request.Context())
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	response := make([]SymbolResponse, 0, len(symbols))
+	for _, symbol := range symbols {
+		response = append(response, newSymbolResponse(symbol))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// listSymbol handles POST /admin/symbols
+func (h *Handler) listSymbol(c *gin.Context) {
+	var req ListSymbolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	symbol := &models.Symbol{
+		Symbol:        req.Symbol,
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		LotSize:       req.LotSize,
+		MinNotional:   req.MinNotional,
+	}
+	if err := h.service.ListSymbol(c.Request.Context(), symbol, req.TickSize); err != nil {
+		h.logger.Error("Failed to list symbol", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, newSymbolResponse(symbol))
+}
+
+// getSymbol handles GET /admin/symbols/:symbol
+func (h *Handler) getSymbol(c *gin.Context) {
+	symbol, err := h.service.Symbol(c.Request.Context(), c.Param("symbol"))
+	if err == models.ErrSymbolNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Symbol not found"})
+		return
+	} else if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newSymbolResponse(symbol))
+}
+
+// updateSymbol handles PUT /admin/symbols/:symbol
+func (h *Handler) updateSymbol(c *gin.Context) {
+	var req UpdateSymbolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	symbol, err := h.service.UpdateSymbol(c.Request.Context(), &models.Symbol{
+		Symbol:      c.Param("symbol"),
+		LotSize:     req.LotSize,
+		MinNotional: req.MinNotional,
+	})
+	if err == models.ErrSymbolNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Symbol not found"})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to update symbol", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newSymbolResponse(symbol))
+}
+
+// delistSymbol handles DELETE /admin/symbols/:symbol
+func (h *Handler) delistSymbol(c *gin.Context) {
+	symbol := c.Param("symbol")
+	canceled, err := h.service.DelistSymbol(c.Request.Context(), symbol)
+	if err == models.ErrSymbolNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Symbol not found"})
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to delist symbol", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, DelistSymbolResponse{Symbol: symbol, Canceled: canceled})
+}
+
+// haltSymbol handles POST /admin/symbols/:symbol/halt?cancel_orders={bool}
+func (h *Handler) haltSymbol(c *gin.Context) {
+	var req HaltSymbolRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warn("Invalid halt query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	symbol := c.Param("symbol")
+	canceled, err := h.service.HaltSymbol(c.Request.Context(), symbol, req.CancelOrders)
+	switch {
+	case err == models.ErrSymbolNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Symbol not found"})
+	case err == models.ErrSymbolNotListed:
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case err != nil:
+		h.logger.Error("Failed to halt symbol", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusOK, SymbolStatusResponse{Symbol: symbol, Status: models.SymbolHalted, Canceled: canceled})
+	}
+}
+
+// resumeSymbol handles POST /admin/symbols/:symbol/resume
+func (h *Handler) resumeSymbol(c *gin.Context) {
+	symbol := c.Param("symbol")
+	err := h.service.ResumeSymbol(c.Request.Context(), symbol)
+	switch {
+	case err == models.ErrSymbolNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Symbol not found"})
+	case err == models.ErrSymbolNotListed:
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case err != nil:
+		h.logger.Error("Failed to resume symbol", zap.Error(err))
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusOK, SymbolStatusResponse{Symbol: symbol, Status: models.SymbolActive})
+	}
+}
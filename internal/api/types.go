@@ -1,24 +1,1304 @@
 package api
 
-import "orderSystem/internal/models"
+import (
+	"database/sql"
+	"orderSystem/internal/compliance"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/service"
+	"orderSystem/internal/stream"
+	"time"
+)
 
 // PlaceOrderRequest defines the request body for placing an order
 type PlaceOrderRequest struct {
 	Symbol   string           `json:"symbol" binding:"required,alphanum,max=10"`
 	Side     models.OrderSide `json:"side" binding:"required,oneof=buy sell"`
-	Type     models.OrderType `json:"type" binding:"required,oneof=limit market"`
-	Price    float64          `json:"price" binding:"required_if=Type limit"`
-	Quantity float64          `json:"quantity" binding:"required,gt=0"`
+	Type     models.OrderType `json:"type" binding:"required,oneof=limit market stop stop_limit"`
+	Price    models.Decimal   `json:"price" binding:"required_if=Type limit,required_if=Type stop_limit"`
+	Quantity models.Decimal   `json:"quantity" binding:"required_without=QuoteQuantity,excluded_with=QuoteQuantity,omitempty,gt=0"`
+	// QuoteQuantity sizes a market buy by the amount of the quote currency to spend instead
+	QuoteQuantity models.Decimal `json:"quote_quantity" binding:"omitempty,gt=0"`
+	// TriggerPrice is the last trade price at which a stop or stop_limit order activates
+	TriggerPrice models.Decimal `json:"trigger_price" binding:"required_if=Type stop,required_if=Type stop_limit"`
+	// DisplayQuantity makes a limit order an iceberg showing at most this much at a time
+	DisplayQuantity models.Decimal `json:"display_quantity" binding:"omitempty,gt=0,ltefield=Quantity"`
+	// TimeInForce defaults to gtc
+	TimeInForce models.TimeInForce `json:"time_in_force" binding:"omitempty,oneof=gtc ioc fok gtd"`
+	// ExpireAt is when a gtd order expires, required for and only allowed with gtd
+	ExpireAt *time.Time `json:"expire_at" binding:"required_if=TimeInForce gtd"`
+	// ProtectionPrice is the worst price a market or stop order may fill at; MaxSlippage sets
+	// it instead as a percentage under 100 from the best opposite price, or a stop's trigger
+	// price (Decimals bind as their 1e-8 units)
+	ProtectionPrice models.Decimal `json:"protection_price" binding:"omitempty,gt=0,excluded_with=MaxSlippage"`
+	MaxSlippage     models.Decimal `json:"max_slippage" binding:"omitempty,gt=0,lt=10000000000"`
+	// AckMode async returns once the order is queued instead of after matching; only POST /orders
+	// honors it, defaulting to sync
+	AckMode string `json:"ack_mode" binding:"omitempty,oneof=sync async"`
+	// ClientOrderID is the caller's own ID for the order, unique per account; resubmitting it
+	// returns the order already placed under it
+	ClientOrderID string `json:"client_order_id" binding:"omitempty,max=64,printascii"`
+}
+
+// OrderAckResponse defines the response for an order accepted without waiting for matching
+type OrderAckResponse struct {
+	OrderID uint64 `json:"order_id"`
+	Status  string `json:"status"` // always accepted
 }
 
 // PlaceOrderResponse defines the response for placing an order
 type PlaceOrderResponse struct {
+	ExecutionReportResponse
+	Trades      []*models.Trade     `json:"trades"`
+	Executions  []ExecutionResponse `json:"executions"`
+	Resubmitted bool                `json:"resubmitted,omitempty"` // the order was placed by an earlier request
+}
+
+// ExecutionReportResponse defines an order's state after a change, the same envelope in the
+// POST /orders response and private stream messages; fields follow the FIX execution report's
+// ExecType, OrdStatus, CumQty, LeavesQty, AvgPx, LastQty and LastPx, and CashOrderQty for
+// quote_quantity
+type ExecutionReportResponse struct {
+	ExecType       models.ExecType    `json:"exec_type"`
+	OrderID        uint64             `json:"order_id"`
+	ClientOrderID  string             `json:"client_order_id,omitempty"`
+	Symbol         string             `json:"symbol"`
+	Side           models.OrderSide   `json:"side"`
+	OrderType      models.OrderType   `json:"order_type"`
+	Status         models.OrderStatus `json:"status"`
+	Price          models.NullDecimal `json:"price"`
+	Quantity       models.Decimal     `json:"quantity"`
+	CumQuantity    models.Decimal     `json:"cum_quantity"`
+	LeavesQuantity models.Decimal     `json:"leaves_quantity"`
+	AvgPrice       *models.Decimal    `json:"avg_price,omitempty"`
+	QuoteQuantity  *models.Decimal    `json:"quote_quantity,omitempty"`
+	CumQuote       float64            `json:"cum_quote_quantity"`
+	LastQuantity   *models.Decimal    `json:"last_quantity,omitempty"`
+	LastPrice      *models.Decimal    `json:"last_price,omitempty"`
+	ReasonCode     models.ReasonCode  `json:"reason_code,omitempty"`
+	Reason         string             `json:"reason,omitempty"`
+	Time           time.Time          `json:"time"`
+}
+
+// newExecutionReportResponse converts an execution report into its response
+func newExecutionReportResponse(report *models.ExecutionReport) ExecutionReportResponse {
+	optional := func(d models.NullDecimal) *models.Decimal {
+		if !d.Valid {
+			return nil
+		}
+		return &d.Decimal
+	}
+	return ExecutionReportResponse{
+		ExecType:       report.ExecType,
+		OrderID:        report.OrderID,
+		ClientOrderID:  report.ClientOrderID,
+		Symbol:         report.Symbol,
+		Side:           report.Side,
+		OrderType:      report.Type,
+		Status:         report.Status,
+		Price:          report.Price,
+		Quantity:       report.Quantity,
+		CumQuantity:    report.CumQuantity,
+		LeavesQuantity: report.LeavesQuantity,
+		AvgPrice:       optional(report.AvgPrice),
+		QuoteQuantity:  optional(report.QuoteQuantity),
+		CumQuote:       report.CumQuote,
+		LastQuantity:   optional(report.LastQuantity),
+		LastPrice:      optional(report.LastPrice),
+		ReasonCode:     report.Reason,
+		Reason:         report.Text,
+		Time:           report.Time,
+	}
+}
+
+// AmendOrderRequest defines the request body for amending an open limit order; omitted fields stay unchanged
+type AmendOrderRequest struct {
+	Price models.Decimal `json:"price" binding:"required_without=Quantity,omitempty,gt=0"`
+	// Quantity is the new total order quantity, including any part already filled
+	Quantity models.Decimal `json:"quantity" binding:"required_without=Price,omitempty,gt=0"`
+}
+
+// CancelQuantityRequest defines the request body for canceling part of an open order
+type CancelQuantityRequest struct {
+	Quantity models.Decimal `json:"quantity" binding:"required,gt=0"`
+}
+
+// CancelQuantityResponse defines an order after part of it was canceled
+type CancelQuantityResponse struct {
+	OrderID           uint64             `json:"order_id"`
+	Status            models.OrderStatus `json:"status"`
+	CanceledQuantity  models.Decimal     `json:"canceled_quantity"`
+	Quantity          models.Decimal     `json:"quantity"`
+	RemainingQuantity models.Decimal     `json:"remaining_quantity"`
+}
+
+// TickSizeRequest defines the request body for moving a symbol to a new tick size
+type TickSizeRequest struct {
+	TickSize models.Decimal     `json:"tick_size" binding:"required"`
+	Policy   service.TickPolicy `json:"policy" binding:"required,oneof=reprice cancel"`
+}
+
+// TickOrderResponse defines an order repriced or canceled by a tick size change
+type TickOrderResponse struct {
+	OrderID      uint64             `json:"order_id"`
+	Side         models.OrderSide   `json:"side"`
+	Type         models.OrderType   `json:"type"`
+	Status       models.OrderStatus `json:"status"`
+	Price        models.NullDecimal `json:"price"`
+	TriggerPrice models.NullDecimal `json:"trigger_price"`
+}
+
+// TickSizeResponse defines a symbol's tick size, with the orders a change repriced or canceled
+type TickSizeResponse struct {
+	Symbol           string              `json:"symbol"`
+	TickSize         models.Decimal      `json:"tick_size"`
+	PreviousTickSize *models.Decimal     `json:"previous_tick_size,omitempty"`
+	Repriced         []TickOrderResponse `json:"repriced,omitempty"`
+	Canceled         []TickOrderResponse `json:"canceled,omitempty"`
+}
+
+// newTickOrderResponses converts the orders of a tick size change into responses
+func newTickOrderResponses(orders []*models.Order) []TickOrderResponse {
+	responses := make([]TickOrderResponse, 0, len(orders))
+	for _, o := range orders {
+		responses = append(responses, TickOrderResponse{
+			OrderID:      o.OrderID,
+			Side:         o.Side,
+			Type:         o.Type,
+			Status:       o.Status,
+			Price:        o.Price,
+			TriggerPrice: o.TriggerPrice,
+		})
+	}
+	return responses
+}
+
+// ListSymbolRequest defines the request body for listing a symbol in the registry; a tick size
+// of 0 keeps the configured one
+type ListSymbolRequest struct {
+	Symbol        string         `json:"symbol" binding:"required,alphanum,max=10"`
+	BaseCurrency  string         `json:"base_currency" binding:"required,alphanum,max=10"`
+	QuoteCurrency string         `json:"quote_currency" binding:"required,alphanum,max=10"`
+	TickSize      models.Decimal `json:"tick_size" binding:"min=0"`
+	LotSize       models.Decimal `json:"lot_size" binding:"min=0"`
+	MinNotional   float64        `json:"min_notional" binding:"min=0"`
+}
+
+// UpdateSymbolRequest defines the request body for replacing a listed symbol's lot size and
+// minimum notional; tick size changes go through the tick size endpoint
+type UpdateSymbolRequest struct {
+	LotSize     models.Decimal `json:"lot_size" binding:"min=0"`
+	MinNotional float64        `json:"min_notional" binding:"min=0"`
+}
+
+// SymbolResponse defines a symbol of the registry
+type SymbolResponse struct {
+	Symbol        string              `json:"symbol"`
+	BaseCurrency  string              `json:"base_currency"`
+	QuoteCurrency string              `json:"quote_currency"`
+	TickSize      models.Decimal      `json:"tick_size"`
+	LotSize       models.Decimal      `json:"lot_size"`
+	MinNotional   float64             `json:"min_notional"`
+	Status        models.SymbolStatus `json:"status"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// newSymbolResponse converts a registry entry into a response
+func newSymbolResponse(symbol *models.Symbol) SymbolResponse {
+	return SymbolResponse{
+		Symbol:        symbol.Symbol,
+		BaseCurrency:  symbol.BaseCurrency,
+		QuoteCurrency: symbol.QuoteCurrency,
+		TickSize:      symbol.TickSize,
+		LotSize:       symbol.LotSize,
+		MinNotional:   symbol.MinNotional,
+		Status:        symbol.Status,
+		CreatedAt:     symbol.CreatedAt,
+		UpdatedAt:     symbol.UpdatedAt,
+	}
+}
+
+// HaltSymbolRequest defines the query parameters of a trading halt
+type HaltSymbolRequest struct {
+	CancelOrders bool `form:"cancel_orders"`
+}
+
+// SymbolStatusResponse defines a halted or resumed symbol and the orders the halt canceled
+type SymbolStatusResponse struct {
+	Symbol   string              `json:"symbol"`
+	Status   models.SymbolStatus `json:"status"`
+	Canceled []uint64            `json:"canceled,omitempty"`
+}
+
+// DelistSymbolResponse defines a delisted symbol and the orders delisting it canceled
+type DelistSymbolResponse struct {
+	Symbol   string   `json:"symbol"`
+	Canceled []uint64 `json:"canceled"`
+}
+
+// PlaceOrderGroupRequest defines the request body for placing linked orders; an OCO group is
+// one limit order and one stop or stop_limit order for the same symbol and side
+type PlaceOrderGroupRequest struct {
+	Type   models.GroupType    `json:"type" binding:"required,oneof=oco"`
+	Orders []PlaceOrderRequest `json:"orders" binding:"required,len=2,dive"`
+}
+
+// GroupOrderResponse defines the state of one order of a newly placed group
+type GroupOrderResponse struct {
 	OrderID uint64             `json:"order_id"`
+	Type    models.OrderType   `json:"type"`
 	Status  models.OrderStatus `json:"status"`
-	Trades  []*models.Trade    `json:"trades"`
+}
+
+// newGroupOrderResponse converts a group order into a response
+func newGroupOrderResponse(order *models.Order) GroupOrderResponse {
+	return GroupOrderResponse{OrderID: order.OrderID, Type: order.Type, Status: order.Status}
+}
+
+// PlaceOrderGroupResponse defines the response for placing an order group
+type PlaceOrderGroupResponse struct {
+	GroupID    uint64               `json:"group_id"`
+	Status     models.GroupStatus   `json:"status"`
+	Orders     []GroupOrderResponse `json:"orders"`
+	Trades     []*models.Trade      `json:"trades"`
+	Executions []ExecutionResponse  `json:"executions"`
+}
+
+// OrderGroupResponse defines an order group and its linked orders
+type OrderGroupResponse struct {
+	GroupID   uint64             `json:"group_id"`
+	Type      models.GroupType   `json:"type"`
+	Symbol    string             `json:"symbol"`
+	Status    models.GroupStatus `json:"status"`
+	CreatedAt time.Time          `json:"created_at"`
+	Orders    []*models.Order    `json:"orders"`
+}
+
+// ExecutionResponse defines one side's execution of a trade
+type ExecutionResponse struct {
+	ExecID    string           `json:"exec_id"`
+	TradeID   uint64           `json:"trade_id"`
+	OrderID   uint64           `json:"order_id"`
+	Symbol    string           `json:"symbol"`
+	Side      models.OrderSide `json:"side"`
+	Liquidity models.Liquidity `json:"liquidity"`
+	Price     models.Decimal   `json:"price"`
+	Quantity  models.Decimal   `json:"quantity"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// newExecutionResponses converts model executions into response executions
+func newExecutionResponses(executions []*models.Execution) []ExecutionResponse {
+	response := make([]ExecutionResponse, len(executions))
+	for i, e := range executions {
+		response[i] = ExecutionResponse{
+			ExecID:    e.ExecID,
+			TradeID:   e.TradeID,
+			OrderID:   e.OrderID,
+			Symbol:    e.Symbol,
+			Side:      e.Side,
+			Liquidity: e.Liquidity,
+			Price:     e.Price,
+			Quantity:  e.Quantity,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	return response
 }
 
 // ErrorResponse defines an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error string            `json:"error"`
+	Code  models.ReasonCode `json:"reason_code,omitempty"` // why the request was refused
+	Field string            `json:"field,omitempty"`       // the invalid request field, if any
+}
+
+// OrderBookRequest defines the query parameters for the aggregated order book
+type OrderBookRequest struct {
+	Symbol string `form:"symbol" binding:"required"`
+	Depth  int    `form:"depth" binding:"omitempty,min=1,max=1000"`
+}
+
+// DepthHistoryRequest defines the query parameters for depth snapshot history
+type DepthHistoryRequest struct {
+	Symbol string    `form:"symbol" binding:"required"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// TradesRequest defines the query parameters for listing a symbol's trades; Before and After are
+// each a trade ID or an RFC 3339 time
+type TradesRequest struct {
+	Symbol string `form:"symbol" binding:"required"`
+	Before string `form:"before"`
+	After  string `form:"after"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// CandlesRequest defines the query parameters for OHLCV candles
+type CandlesRequest struct {
+	Symbol   string                `form:"symbol" binding:"required"`
+	Interval models.CandleInterval `form:"interval" binding:"required,oneof=1m 5m 1h 1d"`
+	From     time.Time             `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time             `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit    int                   `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// CandleResponse defines an OHLCV bar
+type CandleResponse struct {
+	OpenTime  time.Time      `json:"open_time"`
+	CloseTime time.Time      `json:"close_time"`
+	Open      models.Decimal `json:"open"`
+	High      models.Decimal `json:"high"`
+	Low       models.Decimal `json:"low"`
+	Close     models.Decimal `json:"close"`
+	Volume    models.Decimal `json:"volume"`
+	Trades    int            `json:"trades"`
+}
+
+// CandlesResponse defines a symbol's bars of one interval, oldest first
+type CandlesResponse struct {
+	Symbol   string                `json:"symbol"`
+	Interval models.CandleInterval `json:"interval"`
+	Candles  []CandleResponse      `json:"candles"`
+}
+
+// OrderFlowRequest defines the query parameters for a symbol's bucketed order flow
+type OrderFlowRequest struct {
+	Symbol string `form:"symbol" binding:"required"`
+	Bucket string `form:"bucket" binding:"omitempty,oneof=1m 5m 15m 1h"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1440"`
+}
+
+// FlowBucketResponse defines a symbol's order flow counts within one bucket
+type FlowBucketResponse struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	NewOrders int       `json:"new_orders"`
+	Cancels   int       `json:"cancels"`
+	Modifies  int       `json:"modifies"`
+	Rejects   int       `json:"rejects"`
+	Trades    int       `json:"trades"`
+}
+
+// OrderFlowResponse defines a symbol's order flow in consecutive buckets, oldest first
+type OrderFlowResponse struct {
+	Symbol    string               `json:"symbol"`
+	Bucket    string               `json:"bucket"`
+	Retention string               `json:"retention"`
+	Buckets   []FlowBucketResponse `json:"buckets"`
+}
+
+// LastLookAnswerRequest defines the request body for answering a last look
+type LastLookAnswerRequest struct {
+	Accept *bool `json:"accept" binding:"required"`
+}
+
+// LastLookStatsRequest defines the query parameters for last look surveillance
+type LastLookStatsRequest struct {
+	Symbol string    `form:"symbol" binding:"omitempty,alphanum,max=10"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// LastLookStatsResponse defines a liquidity provider's last look answers within a period
+type LastLookStatsResponse struct {
+	ProviderID  string  `json:"provider_id"`
+	Requests    int     `json:"requests"`
+	Rejects     int     `json:"rejects"`
+	Timeouts    int     `json:"timeouts"`
+	RejectRatio float64 `json:"reject_ratio"`
+	Flagged     bool    `json:"flagged"`
+}
+
+// LastLookReportResponse defines last look surveillance of every provider over a period
+type LastLookReportResponse struct {
+	Symbol    string                  `json:"symbol,omitempty"`
+	From      time.Time               `json:"from"`
+	To        time.Time               `json:"to"`
+	Providers []LastLookStatsResponse `json:"providers"`
+}
+
+// DailySummariesRequest defines the days of the daily summary chain to list or verify, by
+// default the last 7 completed days (UTC)
+type DailySummariesRequest struct {
+	From time.Time `form:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// DailySummaryResponse defines a day's hash-chained trading summary
+type DailySummaryResponse struct {
+	Day           string    `json:"day"`
+	Trades        int       `json:"trades"`
+	TradeDigest   string    `json:"trade_digest"`
+	LedgerEntries int       `json:"ledger_entries"`
+	LedgerDigest  string    `json:"ledger_digest"`
+	PreviousHash  string    `json:"previous_hash"`
+	Hash          string    `json:"hash"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DiscrepancyResponse defines a way a day's stored summary no longer matches
+type DiscrepancyResponse struct {
+	Day      string `json:"day"`
+	Field    string `json:"field"`
+	Stored   string `json:"stored"`
+	Computed string `json:"computed"`
+}
+
+// VerifySummariesResponse defines the outcome of verifying the daily summary chain
+type VerifySummariesResponse struct {
+	From          string                `json:"from"`
+	To            string                `json:"to"`
+	Checked       int                   `json:"checked"`
+	Valid         bool                  `json:"valid"`
+	Discrepancies []DiscrepancyResponse `json:"discrepancies"`
+}
+
+// ListOrdersRequest defines the query parameters for listing the caller's orders
+type ListOrdersRequest struct {
+	Symbol string             `form:"symbol" binding:"omitempty,alphanum,max=10"`
+	Side   models.OrderSide   `form:"side" binding:"omitempty,oneof=buy sell"`
+	Status models.OrderStatus `form:"status" binding:"omitempty,oneof=open partially_filled pending filled canceled expired"`
+	From   time.Time          `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time          `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor string             `form:"cursor"`
+	Limit  int                `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// OrdersResponse defines a page of the caller's orders, newest first; NextCursor is empty on the
+// last page
+type OrdersResponse struct {
+	Orders     []*models.Order `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// PriceLevelResponse defines an aggregated price level
+type PriceLevelResponse struct {
+	Price      models.Decimal `json:"price"`
+	Quantity   models.Decimal `json:"quantity"`
+	OrderCount int            `json:"order_count"`
+}
+
+// DepthSnapshotResponse defines a persisted depth snapshot
+type DepthSnapshotResponse struct {
+	Symbol     string               `json:"symbol"`
+	Bids       []PriceLevelResponse `json:"bids"`
+	Asks       []PriceLevelResponse `json:"asks"`
+	CapturedAt time.Time            `json:"captured_at"`
+}
+
+// newPriceLevelResponses converts model price levels into response levels
+func newPriceLevelResponses(levels []models.PriceLevel) []PriceLevelResponse {
+	response := make([]PriceLevelResponse, len(levels))
+	for i, level := range levels {
+		response[i] = PriceLevelResponse{Price: level.Price, Quantity: level.Quantity, OrderCount: level.OrderCount}
+	}
+	return response
+}
+
+// LeaderboardRequest defines the query parameters for the volume leaderboard; the date defaults to today (UTC)
+type LeaderboardRequest struct {
+	Symbol string    `form:"symbol" binding:"required"`
+	Date   time.Time `form:"date" time_format:"2006-01-02"`
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// LeaderboardResponse defines the accounts that traded the most notional in a symbol on a day
+type LeaderboardResponse struct {
+	Symbol  string                     `json:"symbol"`
+	Date    string                     `json:"date"`
+	Traders []LeaderboardEntryResponse `json:"traders"`
+}
+
+// LeaderboardEntryResponse defines one account's place on the leaderboard
+type LeaderboardEntryResponse struct {
+	Rank      int            `json:"rank"`
+	AccountID string         `json:"account_id"`
+	Notional  float64        `json:"notional"`
+	Quantity  models.Decimal `json:"quantity"`
+	Trades    int            `json:"trades"`
+}
+
+// SymbolBookStats defines the in-memory book accounting for one symbol
+type SymbolBookStats struct {
+	Symbol         string `json:"symbol"`
+	BidLevels      int    `json:"bid_levels"`
+	AskLevels      int    `json:"ask_levels"`
+	Orders         int    `json:"orders"`
+	EstimatedBytes int    `json:"estimated_bytes"`
+}
+
+// BookStatsResponse defines the in-memory book accounting across all symbols
+type BookStatsResponse struct {
+	Symbols        []SymbolBookStats `json:"symbols"`
+	EstimatedBytes int               `json:"estimated_bytes"`
+	Evictions      uint64            `json:"evictions"`
+}
+
+// MarketQualityResponse defines a symbol's spread and near-touch depth along with the alert
+// thresholds it breaches
+type MarketQualityResponse struct {
+	Symbol     string                 `json:"symbol"`
+	BestBid    *models.Decimal        `json:"best_bid"`
+	BestAsk    *models.Decimal        `json:"best_ask"`
+	SpreadBps  *float64               `json:"spread_bps"`
+	BidDepth   float64                `json:"bid_depth"`
+	AskDepth   float64                `json:"ask_depth"`
+	CapturedAt time.Time              `json:"captured_at"`
+	Alerts     []service.QualityAlert `json:"alerts"`
+}
+
+// newMarketQualityResponse converts a market quality sample and its alerts into a response
+func newMarketQualityResponse(sample *models.MarketQuality, alerts []service.QualityAlert) MarketQualityResponse {
+	return MarketQualityResponse{
+		Symbol:     sample.Symbol,
+		BestBid:    nullDecimal(sample.BestBid),
+		BestAsk:    nullDecimal(sample.BestAsk),
+		SpreadBps:  nullFloat(sample.SpreadBps),
+		BidDepth:   sample.BidDepth,
+		AskDepth:   sample.AskDepth,
+		CapturedAt: sample.CapturedAt,
+		Alerts:     alerts,
+	}
+}
+
+// MarketQualityThresholds defines the configured market quality alert thresholds, 0 meaning disabled
+type MarketQualityThresholds struct {
+	DepthBand        float64 `json:"depth_band"`
+	MaxSpreadBps     float64 `json:"max_spread_bps"`
+	MinDepthNotional float64 `json:"min_depth_notional"`
+}
+
+// MarketQualitySummaryResponse defines the live market quality of the measured symbols
+type MarketQualitySummaryResponse struct {
+	Symbols    []MarketQualityResponse `json:"symbols"`
+	Thresholds MarketQualityThresholds `json:"thresholds"`
+}
+
+// AuctionResponse defines a symbol's trading phase and indicative auction uncrossing
+type AuctionResponse struct {
+	Symbol           string              `json:"symbol"`
+	Phase            models.TradingPhase `json:"phase"`
+	Reason           string              `json:"reason,omitempty"`
+	EndsAt           *time.Time          `json:"ends_at,omitempty"`
+	IndicativePrice  models.Decimal      `json:"indicative_price,omitempty"`
+	IndicativeVolume models.Decimal      `json:"indicative_volume,omitempty"`
+}
+
+// PlaceParentOrderRequest defines the request body for a TWAP or POV parent order
+type PlaceParentOrderRequest struct {
+	Symbol               string              `json:"symbol" binding:"required,alphanum,max=10"`
+	Side                 models.OrderSide    `json:"side" binding:"required,oneof=buy sell"`
+	Strategy             models.AlgoStrategy `json:"strategy" binding:"required,oneof=twap pov"`
+	Quantity             models.Decimal      `json:"quantity" binding:"required,gt=0"`
+	LimitPrice           models.Decimal      `json:"limit_price" binding:"omitempty,gt=0"`
+	DurationSeconds      int                 `json:"duration_seconds" binding:"required,gt=0"`
+	SliceIntervalSeconds int                 `json:"slice_interval_seconds" binding:"required,gt=0"`
+	ParticipationRate    float64             `json:"participation_rate" binding:"omitempty,gt=0,lt=1"`
+}
+
+// ParentOrderResponse defines a parent order and the child orders placed for it
+type ParentOrderResponse struct {
+	ParentID          uint64              `json:"parent_id"`
+	Symbol            string              `json:"symbol"`
+	Side              models.OrderSide    `json:"side"`
+	Strategy          models.AlgoStrategy `json:"strategy"`
+	Quantity          models.Decimal      `json:"quantity"`
+	FilledQuantity    models.Decimal      `json:"filled_quantity"`
+	LimitPrice        *models.Decimal     `json:"limit_price,omitempty"`
+	ParticipationRate float64             `json:"participation_rate,omitempty"`
+	NextSliceAt       time.Time           `json:"next_slice_at"`
+	EndAt             time.Time           `json:"end_at"`
+	Status            models.ParentStatus `json:"status"`
+	CreatedAt         time.Time           `json:"created_at"`
+	Children          []*models.Order     `json:"children"`
+}
+
+// newParentOrderResponse converts a parent order and its children into a response
+func newParentOrderResponse(parent *models.ParentOrder, children []*models.Order) ParentOrderResponse {
+	response := ParentOrderResponse{
+		ParentID:          parent.ParentID,
+		Symbol:            parent.Symbol,
+		Side:              parent.Side,
+		Strategy:          parent.Strategy,
+		Quantity:          parent.TotalQuantity,
+		FilledQuantity:    parent.FilledQuantity,
+		ParticipationRate: parent.ParticipationRate,
+		NextSliceAt:       parent.NextSliceAt,
+		EndAt:             parent.EndAt,
+		Status:            parent.Status,
+		CreatedAt:         parent.CreatedAt,
+		Children:          children,
+	}
+	if parent.LimitPrice.Valid {
+		response.LimitPrice = &parent.LimitPrice.Decimal
+	}
+	if response.Children == nil {
+		response.Children = []*models.Order{}
+	}
+	return response
+}
+
+// BlockTradeRequest defines the request body for reporting a pre-negotiated block trade; side
+// is the reporting account's side and the counterparty takes the other
+type BlockTradeRequest struct {
+	Symbol         string           `json:"symbol" binding:"required"`
+	Side           models.OrderSide `json:"side" binding:"required,oneof=buy sell"`
+	Price          models.Decimal   `json:"price" binding:"required,gt=0"`
+	Quantity       models.Decimal   `json:"quantity" binding:"required,gt=0"`
+	CounterpartyID string           `json:"counterparty_id" binding:"required,max=64"`
+}
+
+// BlockReportResponse defines a reported block trade from one side's view, with the side it
+// takes and its counterparty
+type BlockReportResponse struct {
+	BlockID        uint64             `json:"block_id"`
+	Symbol         string             `json:"symbol"`
+	Side           models.OrderSide   `json:"side"`
+	Price          models.Decimal     `json:"price"`
+	Quantity       models.Decimal     `json:"quantity"`
+	CounterpartyID string             `json:"counterparty_id"`
+	Reported       bool               `json:"reported"` // whether this side reported it, rather than must affirm it
+	Status         models.BlockStatus `json:"status"`
+	TradeID        uint64             `json:"trade_id,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+}
+
+// newBlockReportResponse builds the view of a block trade of one of its sides
+func newBlockReportResponse(report *models.BlockReport, account string) BlockReportResponse {
+	side, counterparty := models.SideBuy, report.SellerID
+	if account == report.SellerID {
+		side, counterparty = models.SideSell, report.BuyerID
+	}
+	return BlockReportResponse{
+		BlockID:        report.BlockID,
+		Symbol:         report.Symbol,
+		Side:           side,
+		Price:          report.Price,
+		Quantity:       report.Quantity,
+		CounterpartyID: counterparty,
+		Reported:       account == report.ReporterID,
+		Status:         report.Status,
+		TradeID:        report.TradeID,
+		CreatedAt:      report.CreatedAt,
+		ExpiresAt:      report.ExpiresAt,
+	}
+}
+
+// BlockTradeResponse defines the response for an affirmed block trade, with the affirming
+// account's own order and execution
+type BlockTradeResponse struct {
+	OrderID   uint64            `json:"order_id"`
+	Trade     *models.Trade     `json:"trade"`
+	Execution ExecutionResponse `json:"execution"`
+}
+
+// DepositRequest defines the request body for crediting a balance
+type DepositRequest struct {
+	Currency string  `json:"currency" binding:"required,alphanum,max=10"`
+	Amount   float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// BalanceResponse defines an account's settled and pending holdings of one currency, and how
+// much of them its open orders hold
+type BalanceResponse struct {
+	Currency  string  `json:"currency"`
+	Settled   float64 `json:"settled"`
+	Pending   float64 `json:"pending"`
+	Total     float64 `json:"total"`
+	Held      float64 `json:"held"`
+	Available float64 `json:"available"`
+}
+
+// SettlementResponse defines a balance delta awaiting or past settlement
+type SettlementResponse struct {
+	SettlementID uint64                  `json:"settlement_id"`
+	TradeID      uint64                  `json:"trade_id"`
+	Currency     string                  `json:"currency"`
+	Amount       float64                 `json:"amount"`
+	SettleAt     time.Time               `json:"settle_at"`
+	Status       models.SettlementStatus `json:"status"`
+}
+
+// LedgerRequest defines the query parameters for listing an account's ledger postings
+type LedgerRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// LedgerPostingResponse defines a double-entry ledger posting with the account's own entries
+type LedgerPostingResponse struct {
+	PostingID   uint64                `json:"posting_id"`
+	Kind        models.LedgerKind     `json:"kind"`
+	ReferenceID *int64                `json:"reference_id,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	Entries     []LedgerEntryResponse `json:"entries"`
+}
+
+// LedgerEntryResponse defines one line of a ledger posting
+type LedgerEntryResponse struct {
+	EntryID  uint64              `json:"entry_id"`
+	Currency string              `json:"currency"`
+	Bucket   models.LedgerBucket `json:"bucket"`
+	Amount   float64             `json:"amount"`
+}
+
+// ReconciliationResponse defines where the ledger and the balances disagree
+type ReconciliationResponse struct {
+	Balanced   bool                        `json:"balanced"`
+	Mismatches []LedgerMismatchResponse    `json:"mismatches"`
+	Unbalanced []UnbalancedPostingResponse `json:"unbalanced_postings"`
+}
+
+// LedgerMismatchResponse defines a balance that differs from the sum of its ledger entries
+type LedgerMismatchResponse struct {
+	AccountID     string  `json:"account_id"`
+	Currency      string  `json:"currency"`
+	Settled       float64 `json:"settled"`
+	Pending       float64 `json:"pending"`
+	Held          float64 `json:"held"`
+	LedgerSettled float64 `json:"ledger_settled"`
+	LedgerPending float64 `json:"ledger_pending"`
+	LedgerHeld    float64 `json:"ledger_held"`
+}
+
+// UnbalancedPostingResponse defines a ledger posting whose entries do not sum to zero
+type UnbalancedPostingResponse struct {
+	PostingID uint64  `json:"posting_id"`
+	Currency  string  `json:"currency"`
+	Sum       float64 `json:"sum"`
+}
+
+// InvoiceLineResponse defines an invoice's fees for one day, symbol and currency
+type InvoiceLineResponse struct {
+	Day      string  `json:"day"`
+	Symbol   string  `json:"symbol"`
+	Currency string  `json:"currency"`
+	Fills    int     `json:"fills"`
+	Notional float64 `json:"notional"`
+	Fee      float64 `json:"fee"`
+}
+
+// InvoiceTotalResponse defines an invoice's total fees in one currency
+type InvoiceTotalResponse struct {
+	Currency string  `json:"currency"`
+	Fee      float64 `json:"fee"`
+}
+
+// InvoiceResponse defines a monthly fee invoice; lines and totals are omitted when listing
+type InvoiceResponse struct {
+	InvoiceID uint64                 `json:"invoice_id"`
+	Period    string                 `json:"period"`
+	CreatedAt time.Time              `json:"created_at"`
+	Lines     []InvoiceLineResponse  `json:"lines,omitempty"`
+	Totals    []InvoiceTotalResponse `json:"totals,omitempty"`
+}
+
+// newInvoiceResponse converts an invoice and whatever line items and totals it carries
+func newInvoiceResponse(invoice *models.Invoice) InvoiceResponse {
+	response := InvoiceResponse{
+		InvoiceID: invoice.InvoiceID,
+		Period:    invoice.PeriodStart.Format("2006-01"),
+		CreatedAt: invoice.CreatedAt,
+	}
+	for _, line := range invoice.Lines {
+		response.Lines = append(response.Lines, InvoiceLineResponse{
+			Day:      line.Day.Format("2006-01-02"),
+			Symbol:   line.Symbol,
+			Currency: line.Currency,
+			Fills:    line.Fills,
+			Notional: line.Notional,
+			Fee:      line.Amount,
+		})
+	}
+	for _, total := range invoice.Totals {
+		response.Totals = append(response.Totals, InvoiceTotalResponse{Currency: total.Currency, Fee: total.Amount})
+	}
+	return response
+}
+
+// MarketDataRequest defines the query parameters shared by the public market data endpoints
+type MarketDataRequest struct {
+	Symbol string `form:"symbol" binding:"required"`
+	Levels int    `form:"levels" binding:"omitempty,min=1,max=100"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// TickerResponse defines a symbol's top of book and last trade; absent values are omitted
+type TickerResponse struct {
+	Symbol      string          `json:"symbol"`
+	BestBid     *models.Decimal `json:"best_bid,omitempty"`
+	BestAsk     *models.Decimal `json:"best_ask,omitempty"`
+	LastPrice   *models.Decimal `json:"last_price,omitempty"`
+	LastTradeAt *time.Time      `json:"last_trade_at,omitempty"`
+}
+
+// DepthResponse defines the aggregated best price levels of a symbol's book
+type DepthResponse struct {
+	Symbol string               `json:"symbol"`
+	Bids   []PriceLevelResponse `json:"bids"`
+	Asks   []PriceLevelResponse `json:"asks"`
+}
+
+// PublicTradeResponse defines a trade as shown on the public tape, with public order identifiers
+type PublicTradeResponse struct {
+	TradeID     uint64           `json:"trade_id"`
+	BuyOrderID  string           `json:"buy_order_id"`
+	SellOrderID string           `json:"sell_order_id"`
+	Price       models.Decimal   `json:"price"`
+	Quantity    models.Decimal   `json:"quantity"`
+	PrintType   models.PrintType `json:"print_type"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// StreamCommand defines a message sent by a WebSocket market data client
+type StreamCommand struct {
+	Op      string `json:"op"`      // subscribe, unsubscribe, ping or pong
+	Channel string `json:"channel"` // trades or book
+	Symbol  string `json:"symbol"`
+}
+
+// StreamMessage defines a message sent to a WebSocket client: a session greeting, a subscription
+// reply, a heartbeat, an error, or a trades, snapshot or book event; fields a message type does
+// not use are omitted
+type StreamMessage struct {
+	Type          string                `json:"type"`
+	ResumeToken   string                `json:"resume_token,omitempty"`
+	Subscriptions []StreamSubscription  `json:"subscriptions,omitempty"`
+	Channel       string                `json:"channel,omitempty"`
+	Symbol        string                `json:"symbol,omitempty"`
+	Sequence      *uint64               `json:"sequence,omitempty"`
+	PrevSequence  *uint64               `json:"prev_sequence,omitempty"`
+	Checksum      *uint32               `json:"checksum,omitempty"`
+	Trades        []PublicTradeResponse `json:"trades,omitempty"`
+	Bids          *[]PriceLevelResponse `json:"bids,omitempty"`
+	Asks          *[]PriceLevelResponse `json:"asks,omitempty"`
+	Changes       []BookChangeResponse  `json:"changes,omitempty"`
+	Time          *time.Time            `json:"time,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+// StreamSubscription defines a subscription restored by resuming a session, with the sequence
+// of the last event the client was sent on it, zero if none was
+type StreamSubscription struct {
+	Channel  string `json:"channel"`
+	Symbol   string `json:"symbol"`
+	Sequence uint64 `json:"sequence"`
+}
+
+// BookChangeResponse defines the new state of a changed price level; zero quantity removes it
+type BookChangeResponse struct {
+	Side       models.OrderSide `json:"side"`
+	Price      models.Decimal   `json:"price"`
+	Quantity   models.Decimal   `json:"quantity"`
+	OrderCount int              `json:"order_count"`
+}
+
+// ListenKeyResponse defines a private stream listen key and when it expires without a keepalive
+type ListenKeyResponse struct {
+	ListenKey string    `json:"listen_key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateAccountRequest defines the request body for registering an account
+type CreateAccountRequest struct {
+	Name string `json:"name" binding:"max=128"`
+}
+
+// AccountResponse defines a registered account, along with its first signing key when it was
+// just created
+type AccountResponse struct {
+	AccountID  string              `json:"account_id"`
+	Name       string              `json:"name"`
+	CreatedAt  time.Time           `json:"created_at"`
+	SigningKey *SigningKeyResponse `json:"signing_key,omitempty"`
+}
+
+// CreateSigningKeyRequest defines the optional request body for issuing a signing key; keys get
+// the default scopes without any and may sign from any IP without allowed ones
+type CreateSigningKeyRequest struct {
+	Scopes     []models.KeyScope `json:"scopes" binding:"omitempty,max=5,dive,oneof=read trade cancel admin compliance"`
+	AllowedIPs []string          `json:"allowed_ips" binding:"omitempty,max=16,dive,ip|cidr"`
+}
+
+// SigningKeyResponse defines a signing key; the secret is only shown when the key is issued
+type SigningKeyResponse struct {
+	KeyID      string            `json:"key_id"`
+	Secret     string            `json:"secret,omitempty"`
+	Scopes     []models.KeyScope `json:"scopes"`
+	AllowedIPs []string          `json:"allowed_ips"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// newSigningKeyResponse converts a signing key, with its secret when just issued
+func newSigningKeyResponse(key *models.SigningKey, withSecret bool) SigningKeyResponse {
+	resp := SigningKeyResponse{
+		KeyID:      key.KeyID,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedIPs,
+		CreatedAt:  key.CreatedAt,
+	}
+	if resp.AllowedIPs == nil {
+		resp.AllowedIPs = []string{}
+	}
+	if withSecret {
+		resp.Secret = key.Secret
+	}
+	return resp
+}
+
+// RegisterWebhookRequest defines the request body for registering a webhook
+type RegisterWebhookRequest struct {
+	URL string `json:"url" binding:"required,url,max=512"`
+}
+
+// WebhookResponse defines a registered webhook; the secret is only shown on registration
+type WebhookResponse struct {
+	WebhookID uint64    `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderUpdateResponse defines a change to one of the caller's orders pushed on the private stream
+type OrderUpdateResponse struct {
+	Type   string            `json:"type"` // always order
+	Update stream.UpdateKind `json:"update"`
+	ExecutionReportResponse
+}
+
+// newOrderUpdateResponse converts a routed order update into its response
+func newOrderUpdateResponse(update *stream.OrderUpdate) OrderUpdateResponse {
+	return OrderUpdateResponse{Type: "order", Update: update.Kind, ExecutionReportResponse: newExecutionReportResponse(update.Report())}
+}
+
+// LastLookResponse defines a match of the caller's resting order pushed on the private stream
+// for a last look, to be answered before the deadline
+type LastLookResponse struct {
+	Type         string           `json:"type"` // always last_look
+	LastLookID   uint64           `json:"last_look_id"`
+	Symbol       string           `json:"symbol"`
+	OrderID      uint64           `json:"order_id"`
+	TakerOrderID uint64           `json:"taker_order_id"`
+	Side         models.OrderSide `json:"side"`
+	Price        models.Decimal   `json:"price"`
+	Quantity     models.Decimal   `json:"quantity"`
+	RequestedAt  time.Time        `json:"requested_at"`
+	Deadline     time.Time        `json:"deadline"`
+}
+
+// newLastLookResponse converts a last look into its response
+func newLastLookResponse(look *models.LastLook) LastLookResponse {
+	return LastLookResponse{
+		Type:         "last_look",
+		LastLookID:   look.LastLookID,
+		Symbol:       look.Symbol,
+		OrderID:      look.OrderID,
+		TakerOrderID: look.TakerOrderID,
+		Side:         look.Side,
+		Price:        look.Price,
+		Quantity:     look.Quantity,
+		RequestedAt:  look.RequestedAt,
+		Deadline:     look.Deadline,
+	}
+}
+
+// PublicOrderResponse defines a resting order as shown in the public order-level book
+type PublicOrderResponse struct {
+	OrderID   string         `json:"order_id"`
+	Price     models.Decimal `json:"price"`
+	Quantity  models.Decimal `json:"quantity"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// PublicBookResponse defines the individual resting orders of a symbol's best price levels
+type PublicBookResponse struct {
+	Symbol string                `json:"symbol"`
+	Bids   []PublicOrderResponse `json:"bids"`
+	Asks   []PublicOrderResponse `json:"asks"`
+}
+
+// nullFloat converts a nullable metric into an optional response field
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// nullDecimal converts a nullable price into an optional response field
+func nullDecimal(v models.NullDecimal) *models.Decimal {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Decimal
+}
+
+// PositionResponse defines an account's holding of one currency
+type PositionResponse struct {
+	Currency string  `json:"currency"`
+	Quantity float64 `json:"quantity"`
+	Rate     float64 `json:"rate"`
+	Value    float64 `json:"value"`
+	Valued   bool    `json:"valued"`
+}
+
+// SymbolExposureResponse defines an account's open order exposure in one symbol
+type SymbolExposureResponse struct {
+	Symbol        string  `json:"symbol"`
+	OpenOrders    int     `json:"open_orders"`
+	BuyNotional   float64 `json:"buy_notional"`
+	SellNotional  float64 `json:"sell_notional"`
+	BuyCommitted  float64 `json:"buy_committed"`
+	SellCommitted float64 `json:"sell_committed"`
+	Value         float64 `json:"value"`
+	Valued        bool    `json:"valued"`
+}
+
+// PortfolioResponse defines an account's cross-symbol risk summary
+type PortfolioResponse struct {
+	ReportingCurrency string                   `json:"reporting_currency"`
+	Equity            float64                  `json:"equity"`
+	OpenOrderNotional float64                  `json:"open_order_notional"`
+	Committed         float64                  `json:"committed"`
+	Utilization       float64                  `json:"utilization"`
+	BuyingPower       float64                  `json:"buying_power"`
+	Positions         []PositionResponse       `json:"positions"`
+	Exposures         []SymbolExposureResponse `json:"exposures"`
+	Unvalued          []string                 `json:"unvalued"`
+}
+
+// ShardResponse defines one database shard's health and the symbols routed to it
+type ShardResponse struct {
+	Index   int      `json:"index"`
+	Healthy bool     `json:"healthy"`
+	Error   string   `json:"error,omitempty"`
+	Symbols []string `json:"symbols"`
+}
+
+// ShardsResponse defines the database shard topology, and the queried symbol's shard if any
+type ShardsResponse struct {
+	Count       int             `json:"count"`
+	Shards      []ShardResponse `json:"shards"`
+	SymbolShard *int            `json:"symbol_shard,omitempty"`
+}
+
+// OrderEventsRequest defines the query parameters for reading the order event feed; without a
+// cursor the subscriber's last acknowledged position is used
+type OrderEventsRequest struct {
+	Cursor string        `form:"cursor"`
+	Limit  int           `form:"limit" binding:"omitempty,min=1"`
+	Wait   time.Duration `form:"wait"`
+}
+
+// OrderEventResponse defines one order lifecycle event
+type OrderEventResponse struct {
+	Shard             int                   `json:"shard"`
+	EventID           uint64                `json:"event_id"`
+	Kind              models.OrderEventKind `json:"kind"`
+	OrderID           uint64                `json:"order_id"`
+	Symbol            string                `json:"symbol"`
+	Side              models.OrderSide      `json:"side"`
+	Type              models.OrderType      `json:"type"`
+	Status            models.OrderStatus    `json:"status"`
+	Price             *models.Decimal       `json:"price,omitempty"`
+	InitialQuantity   models.Decimal        `json:"initial_quantity"`
+	RemainingQuantity models.Decimal        `json:"remaining_quantity"`
+	OwnerID           string                `json:"owner_id"`
+	Actor             string                `json:"actor"`
+	Reason            models.ReasonCode     `json:"reason,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+}
+
+// OrderEventsResponse defines a batch of order events and the cursor to acknowledge or resume from
+type OrderEventsResponse struct {
+	Events     []OrderEventResponse `json:"events"`
+	NextCursor string               `json:"next_cursor"`
+}
+
+// newOrderEventsResponse converts a batch of feed events into a response
+func newOrderEventsResponse(events []compliance.ShardEvent, next compliance.Cursor) OrderEventsResponse {
+	response := OrderEventsResponse{Events: make([]OrderEventResponse, len(events)), NextCursor: next.String()}
+	for i, se := range events {
+		e := se.Event
+		response.Events[i] = OrderEventResponse{
+			Shard:             se.Shard,
+			EventID:           e.EventID,
+			Kind:              e.Kind,
+			OrderID:           e.OrderID,
+			Symbol:            e.Symbol,
+			Side:              e.Side,
+			Type:              e.Type,
+			Status:            e.Status,
+			Price:             nullDecimal(e.Price),
+			InitialQuantity:   e.InitialQuantity,
+			RemainingQuantity: e.RemainingQuantity,
+			OwnerID:           e.OwnerID,
+			Actor:             e.Actor,
+			Reason:            e.Reason,
+			CreatedAt:         e.CreatedAt,
+		}
+	}
+	return response
+}
+
+// OrderHistoryEventResponse defines one recorded change to an order: the transition it made,
+// who made it, why, and the order's state after it
+type OrderHistoryEventResponse struct {
+	EventID           uint64                `json:"event_id"`
+	Kind              models.OrderEventKind `json:"kind"`
+	Transition        stream.UpdateKind     `json:"transition"`
+	Status            models.OrderStatus    `json:"status"`
+	Price             *models.Decimal       `json:"price,omitempty"`
+	InitialQuantity   models.Decimal        `json:"initial_quantity"`
+	RemainingQuantity models.Decimal        `json:"remaining_quantity"`
+	Actor             string                `json:"actor"`
+	Reason            models.ReasonCode     `json:"reason,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+}
+
+// newOrderHistoryResponses converts an order's events, oldest first, into responses
+func newOrderHistoryResponses(events []*models.OrderEvent) []OrderHistoryEventResponse {
+	classifier := stream.NewClassifier()
+	responses := make([]OrderHistoryEventResponse, len(events))
+	for i, e := range events {
+		responses[i] = OrderHistoryEventResponse{
+			EventID:           e.EventID,
+			Kind:              e.Kind,
+			Transition:        classifier.Classify(e).Kind,
+			Status:            e.Status,
+			Price:             nullDecimal(e.Price),
+			InitialQuantity:   e.InitialQuantity,
+			RemainingQuantity: e.RemainingQuantity,
+			Actor:             e.Actor,
+			Reason:            e.Reason,
+			CreatedAt:         e.CreatedAt,
+		}
+	}
+	return responses
+}
+
+// AckEventsRequest defines the request body for acknowledging processed order events
+type AckEventsRequest struct {
+	Cursor string `json:"cursor" binding:"required"`
+}
+
+// FeeScheduleRequest defines the request body for creating a fee schedule; it is active unless
+// active is false
+type FeeScheduleRequest struct {
+	Name       string  `json:"name" binding:"required,max=64"`
+	MakerRate  float64 `json:"maker_rate"`
+	TakerRate  float64 `json:"taker_rate"`
+	Allocation int     `json:"allocation" binding:"min=0,max=100"`
+	Active     *bool   `json:"active"`
+}
+
+// UpdateFeeScheduleRequest defines the request body for replacing a fee schedule's rates,
+// allocation and active flag
+type UpdateFeeScheduleRequest struct {
+	MakerRate  float64 `json:"maker_rate"`
+	TakerRate  float64 `json:"taker_rate"`
+	Allocation int     `json:"allocation" binding:"min=0,max=100"`
+	Active     *bool   `json:"active" binding:"required"`
+}
+
+// OrderRateLimitResponse defines the caller's effective order rate limit and the counts of the
+// current window it will be adjusted by
+type OrderRateLimitResponse struct {
+	Rate           float64   `json:"rate"` // order entry requests per second
+	Burst          int       `json:"burst"`
+	BaseRate       float64   `json:"base_rate"`
+	BaseBurst      int       `json:"base_burst"`
+	Factor         float64   `json:"factor"` // rate / base_rate
+	WindowStart    time.Time `json:"window_start"`
+	WindowEnd      time.Time `json:"window_end"`
+	Orders         int       `json:"orders"`
+	Rejects        int       `json:"rejects"`
+	Cancels        int       `json:"cancels"`
+	RejectRatio    float64   `json:"reject_ratio"`
+	CancelRatio    float64   `json:"cancel_ratio"`
+	MaxRejectRatio float64   `json:"max_reject_ratio"`
+	MaxCancelRatio float64   `json:"max_cancel_ratio"`
+}
+
+// SandboxSettings defines the simulated frictions of sandbox mode, both as the request body
+// replacing them and as the response
+type SandboxSettings struct {
+	AckLatencyMs    int64   `json:"ack_latency_ms" binding:"min=0"`
+	AckJitterMs     int64   `json:"ack_jitter_ms" binding:"min=0"`
+	PartialFillRate float64 `json:"partial_fill_rate" binding:"min=0,max=1"`
+	QueueAhead      float64 `json:"queue_ahead" binding:"min=0"`
+	QueueHalfLifeMs int64   `json:"queue_half_life_ms" binding:"min=0"`
+}
+
+// newSandboxSettings converts sandbox settings into their response
+func newSandboxSettings(settings config.SandboxConfig) SandboxSettings {
+	return SandboxSettings{
+		AckLatencyMs:    settings.AckLatency.Milliseconds(),
+		AckJitterMs:     settings.AckJitter.Milliseconds(),
+		PartialFillRate: settings.PartialFillRate,
+		QueueAhead:      settings.QueueAhead,
+		QueueHalfLifeMs: settings.QueueHalfLife.Milliseconds(),
+	}
+}
+
+// RuntimeStatsResponse defines the process's goroutine count, memory use and garbage collector
+// stats; the pause quantiles are the minimum, quartiles and maximum of recent pauses
+type RuntimeStatsResponse struct {
+	Goroutines       int        `json:"goroutines"`
+	GOMAXPROCS       int        `json:"gomaxprocs"`
+	HeapAlloc        uint64     `json:"heap_alloc_bytes"`
+	HeapInuse        uint64     `json:"heap_inuse_bytes"`
+	HeapObjects      uint64     `json:"heap_objects"`
+	Sys              uint64     `json:"sys_bytes"`
+	NumGC            uint32     `json:"num_gc"`
+	LastGC           *time.Time `json:"last_gc,omitempty"`
+	PauseTotalMs     float64    `json:"gc_pause_total_ms"`
+	PauseQuantilesMs []float64  `json:"gc_pause_quantiles_ms,omitempty"`
+	GCCPUFraction    float64    `json:"gc_cpu_fraction"`
+}
+
+// UpdateMaintenanceRequest defines the request body turning maintenance mode on or off
+type UpdateMaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceResponse reports whether maintenance mode is on
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// FeeScheduleResponse defines a fee schedule and the percentage of accounts assigned to it
+type FeeScheduleResponse struct {
+	ScheduleID uint64    `json:"schedule_id"`
+	Name       string    `json:"name"`
+	MakerRate  float64   `json:"maker_rate"`
+	TakerRate  float64   `json:"taker_rate"`
+	Allocation int       `json:"allocation"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// newFeeScheduleResponse converts a fee schedule into a response
+func newFeeScheduleResponse(schedule *models.FeeSchedule) FeeScheduleResponse {
+	return FeeScheduleResponse{
+		ScheduleID: schedule.ScheduleID,
+		Name:       schedule.Name,
+		MakerRate:  schedule.MakerRate,
+		TakerRate:  schedule.TakerRate,
+		Allocation: schedule.Allocation,
+		Active:     schedule.Active,
+		CreatedAt:  schedule.CreatedAt,
+	}
+}
+
+// AccountFeesResponse defines the fee rates an account is charged and the schedule assigning
+// them, absent for the default rates
+type AccountFeesResponse struct {
+	MakerRate float64              `json:"maker_rate"`
+	TakerRate float64              `json:"taker_rate"`
+	Schedule  *FeeScheduleResponse `json:"schedule,omitempty"`
 }
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionHeader names the API version a request asks for on unversioned paths and the version
+// every response was served as
+const versionHeader = "API-Version"
+
+// latestVersion is the newest API version; response shapes only change in a new version, served
+// under its own path prefix, so clients of an older version keep getting the responses they know
+const latestVersion = 1
+
+// servedVersion serves a versioned group's requests as the given version
+func servedVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(versionHeader, strconv.Itoa(version))
+	}
+}
+
+// negotiateVersion serves an unversioned request as the version its API-Version header asks for,
+// version 1 without one, and refuses versions that do not exist. Unversioned paths are kept for
+// clients that predate /v1 and are marked deprecated.
+func negotiateVersion(c *gin.Context) {
+	version := 1
+	if requested := c.GetHeader(versionHeader); requested != "" {
+		v, err := strconv.Atoi(requested)
+		if err != nil || v < 1 || v > latestVersion {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+				Error: "unsupported API version " + requested, Code: models.ReasonInvalidRequest, Field: versionHeader,
+			})
+			return
+		}
+		version = v
+	}
+	c.Header("Deprecation", "true")
+	servedVersion(version)(c)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"orderSystem/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// registerWebhook handles POST /webhooks
+func (h *Handler) registerWebhook(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	var req RegisterWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid webhook request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	webhook, err := h.webhooks.Register(c.Request.Context(), account, req.URL)
+	if err == models.ErrInvalidWebhook {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, WebhookResponse{
+		WebhookID: webhook.WebhookID,
+		URL:       webhook.URL,
+		Secret:    webhook.Secret,
+		CreatedAt: webhook.CreatedAt,
+	})
+}
+
+// getWebhooks handles GET /webhooks
+func (h *Handler) getWebhooks(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	webhooks, err := h.webhooks.Webhooks(c.Request.Context(), account)
+	if err != nil {
+		c.JSON(storageStatus(err, http.StatusInternalServerError), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, WebhookResponse{WebhookID: webhook.WebhookID, URL: webhook.URL, CreatedAt: webhook.CreatedAt})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// deleteWebhook handles DELETE /webhooks/:webhookId
+func (h *Handler) deleteWebhook(c *gin.Context) {
+	account, ok := h.requireAccount(c)
+	if !ok {
+		return
+	}
+	webhookID, err := strconv.ParseUint(c.Param("webhookId"), 10, 64)
+	if err != nil {
+		h.logger.Warn("Invalid webhook ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.webhooks.Delete(c.Request.Context(), account, webhookID); err != nil {
+		c.JSON(storageStatus(err, http.StatusNotFound), ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
@@ -0,0 +1,361 @@
+// Package archive writes the trades and order events of each completed UTC day to compact,
+// schema'd files for long-term storage, and reads them back for replays and backtests.
+//
+// A file is a gzip stream of varint length-prefixed protobuf messages, described by
+// archive.proto: a Header, then one Trade or OrderEvent per row, each shard's rows in ID order.
+// Messages are encoded by hand with protowire, so adding a field means adding it to the schema
+// and to the append and decode functions here; readers skip fields they do not know.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"orderSystem/internal/models"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Kind is what an archive file holds
+type Kind string
+
+// Archive file kinds
+const (
+	KindTrades      Kind = "trades"
+	KindOrderEvents Kind = "order_events"
+)
+
+// formatVersion is the Header version this package writes
+const formatVersion = 1
+
+// maxRecordSize bounds a single message, so a corrupt length cannot make a reader allocate
+// without limit
+const maxRecordSize = 1 << 20
+
+// dayLayout formats the day of a file in its Header and name
+const dayLayout = "2006-01-02"
+
+// FileName returns the name of the file of a kind and day, such as trades/2024-05-01.binpb.gz
+func FileName(kind Kind, day time.Time) string {
+	return fmt.Sprintf("%s/%s.binpb.gz", kind, day.UTC().Format(dayLayout))
+}
+
+// Header describes an archive file
+type Header struct {
+	Version   int
+	Kind      Kind
+	Day       time.Time
+	CreatedAt time.Time
+}
+
+// Record is one row of an archive file: a trade or an order event, with the shard it came from
+type Record struct {
+	Shard int
+	Trade *models.Trade
+	Event *models.OrderEvent
+}
+
+// Writer encodes an archive file
+type Writer struct {
+	gz  *gzip.Writer
+	buf []byte
+}
+
+// NewWriter starts an archive file of a kind and day on w
+func NewWriter(w io.Writer, kind Kind, day time.Time) (*Writer, error) {
+	aw := &Writer{gz: gzip.NewWriter(w)}
+	var b []byte
+	b = appendUint(b, 1, formatVersion)
+	b = appendString(b, 2, string(kind))
+	b = appendString(b, 3, day.UTC().Format(dayLayout))
+	b = appendUint(b, 4, models.DecimalPlaces)
+	b = appendInt(b, 5, time.Now().UnixMicro())
+	return aw, aw.write(b)
+}
+
+// WriteTrade appends a trade read from a shard
+func (w *Writer) WriteTrade(shard int, trade *models.Trade) error {
+	b := w.buf[:0]
+	b = appendUint(b, 1, uint64(shard))
+	b = appendUint(b, 2, trade.TradeID)
+	b = appendString(b, 3, trade.Symbol)
+	b = appendUint(b, 4, trade.BuyOrderID)
+	b = appendUint(b, 5, trade.SellOrderID)
+	b = appendString(b, 6, trade.BuyOwnerID)
+	b = appendString(b, 7, trade.SellOwnerID)
+	b = appendSint(b, 8, int64(trade.Price))
+	b = appendSint(b, 9, int64(trade.Quantity))
+	b = appendString(b, 10, string(trade.PrintType))
+	b = appendInt(b, 11, trade.CreatedAt.UnixMicro())
+	w.buf = b
+	return w.write(b)
+}
+
+// WriteEvent appends an order event read from a shard
+func (w *Writer) WriteEvent(shard int, event *models.OrderEvent) error {
+	b := w.buf[:0]
+	b = appendUint(b, 1, uint64(shard))
+	b = appendUint(b, 2, event.EventID)
+	b = appendString(b, 3, string(event.Kind))
+	b = appendUint(b, 4, event.OrderID)
+	b = appendString(b, 5, event.Symbol)
+	b = appendString(b, 6, string(event.Side))
+	b = appendString(b, 7, string(event.Type))
+	b = appendString(b, 8, string(event.Status))
+	if event.Price.Valid {
+		// An optional field is written even when zero, so presence survives the round trip
+		b = protowire.AppendTag(b, 9, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeZigZag(int64(event.Price.Decimal)))
+	}
+	b = appendSint(b, 10, int64(event.InitialQuantity))
+	b = appendSint(b, 11, int64(event.RemainingQuantity))
+	b = appendString(b, 12, event.OwnerID)
+	b = appendInt(b, 13, event.CreatedAt.UnixMicro())
+	b = appendString(b, 14, event.Actor)
+	b = appendString(b, 15, string(event.Reason))
+	w.buf = b
+	return w.write(b)
+}
+
+// Close finishes the file; it does not close the underlying writer
+func (w *Writer) Close() error {
+	return w.gz.Close()
+}
+
+// write appends one length-prefixed message
+func (w *Writer) write(message []byte) error {
+	if _, err := w.gz.Write(protowire.AppendVarint(nil, uint64(len(message)))); err != nil {
+		return err
+	}
+	_, err := w.gz.Write(message)
+	return err
+}
+
+// Reader decodes an archive file
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	buf    []byte
+}
+
+// NewReader opens an archive file, reading its Header
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	ar := &Reader{r: bufio.NewReader(gz)}
+	message, err := ar.read()
+	if err == io.EOF {
+		return nil, errors.New("archive file has no header")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decimalPlaces := uint64(0)
+	err = fields(message, func(num protowire.Number, v uint64, s []byte) {
+		switch num {
+		case 1:
+			ar.header.Version = int(v)
+		case 2:
+			ar.header.Kind = Kind(s)
+		case 3:
+			ar.header.Day, _ = time.Parse(dayLayout, string(s))
+		case 4:
+			decimalPlaces = v
+		case 5:
+			ar.header.CreatedAt = time.UnixMicro(int64(v))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ar.header.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", ar.header.Version)
+	}
+	if ar.header.Kind != KindTrades && ar.header.Kind != KindOrderEvents {
+		return nil, fmt.Errorf("unknown archive kind %q", ar.header.Kind)
+	}
+	if decimalPlaces != models.DecimalPlaces {
+		return nil, fmt.Errorf("archive written with %d decimal places, expected %d", decimalPlaces, models.DecimalPlaces)
+	}
+	return ar, nil
+}
+
+// Header returns the file's Header
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *Reader) Next() (*Record, error) {
+	message, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	if r.header.Kind == KindTrades {
+		return decodeTrade(message)
+	}
+	return decodeEvent(message)
+}
+
+// read reads one length-prefixed message, valid until the next call
+func (r *Reader) read() ([]byte, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("archive record of %d bytes exceeds the limit", size)
+	}
+	if uint64(cap(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return r.buf, nil
+}
+
+// decodeTrade decodes a Trade message
+func decodeTrade(message []byte) (*Record, error) {
+	record := &Record{Trade: &models.Trade{}}
+	t := record.Trade
+	err := fields(message, func(num protowire.Number, v uint64, s []byte) {
+		switch num {
+		case 1:
+			record.Shard = int(v)
+		case 2:
+			t.TradeID = v
+		case 3:
+			t.Symbol = string(s)
+		case 4:
+			t.BuyOrderID = v
+		case 5:
+			t.SellOrderID = v
+		case 6:
+			t.BuyOwnerID = string(s)
+		case 7:
+			t.SellOwnerID = string(s)
+		case 8:
+			t.Price = models.Decimal(protowire.DecodeZigZag(v))
+		case 9:
+			t.Quantity = models.Decimal(protowire.DecodeZigZag(v))
+		case 10:
+			t.PrintType = models.PrintType(s)
+		case 11:
+			t.CreatedAt = time.UnixMicro(int64(v))
+		}
+	})
+	return record, err
+}
+
+// decodeEvent decodes an OrderEvent message
+func decodeEvent(message []byte) (*Record, error) {
+	record := &Record{Event: &models.OrderEvent{}}
+	e := record.Event
+	err := fields(message, func(num protowire.Number, v uint64, s []byte) {
+		switch num {
+		case 1:
+			record.Shard = int(v)
+		case 2:
+			e.EventID = v
+		case 3:
+			e.Kind = models.OrderEventKind(s)
+		case 4:
+			e.OrderID = v
+		case 5:
+			e.Symbol = string(s)
+		case 6:
+			e.Side = models.OrderSide(s)
+		case 7:
+			e.Type = models.OrderType(s)
+		case 8:
+			e.Status = models.OrderStatus(s)
+		case 9:
+			e.Price = models.NullDecimal{Decimal: models.Decimal(protowire.DecodeZigZag(v)), Valid: true}
+		case 10:
+			e.InitialQuantity = models.Decimal(protowire.DecodeZigZag(v))
+		case 11:
+			e.RemainingQuantity = models.Decimal(protowire.DecodeZigZag(v))
+		case 12:
+			e.OwnerID = string(s)
+		case 13:
+			e.CreatedAt = time.UnixMicro(int64(v))
+		case 14:
+			e.Actor = string(s)
+		case 15:
+			e.Reason = models.ReasonCode(s)
+		}
+	})
+	return record, err
+}
+
+// fields walks a message, calling fn with the value of each varint field or the bytes of each
+// length-delimited one; fields of other wire types are skipped
+func fields(message []byte, fn func(num protowire.Number, v uint64, s []byte)) error {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		message = message[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(message)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, v, nil)
+			message = message[n:]
+		case protowire.BytesType:
+			s, n := protowire.ConsumeBytes(message)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, 0, s)
+			message = message[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, message)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			message = message[n:]
+		}
+	}
+	return nil
+}
+
+// appendUint appends a uint32 or uint64 field, omitting the zero value as proto3 does
+func appendUint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendInt appends an int64 field
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	return appendUint(b, num, uint64(v))
+}
+
+// appendSint appends a zigzag-encoded sint64 field
+func appendSint(b []byte, num protowire.Number, v int64) []byte {
+	return appendUint(b, num, protowire.EncodeZigZag(v))
+}
+
+// appendString appends a string field, omitting the empty string
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
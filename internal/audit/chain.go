@@ -0,0 +1,269 @@
+// Package audit keeps an immutable, hash-chained summary of each trading day, so that altering
+// historical trades or ledger entries is detectable
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// dayLayout formats the day a summary covers
+const dayLayout = "2006-01-02"
+
+// Chain writes a summary of every completed UTC day, from the day of the first trade on. Each
+// summary holds a SHA-256 digest of the day's trades and one of its ledger entries, the
+// settlements and fees of those trades, and its hash covers them along with the previous day's
+// hash, so changing any summarized row or summary breaks the chain from that day on.
+type Chain struct {
+	repo   repository.Repository
+	cfg    config.AuditConfig
+	logger *zap.Logger
+}
+
+// Discrepancy is a way a stored summary no longer matches the rows it covers or the chain
+type Discrepancy struct {
+	Day      time.Time
+	Field    string // trades, trade_digest, ledger_entries, ledger_digest, previous_hash, hash or day
+	Stored   string
+	Computed string
+}
+
+// NewChain creates a daily summary chain over all shards
+func NewChain(repo repository.Repository, cfg config.AuditConfig, logger *zap.Logger) *Chain {
+	return &Chain{repo: repo, cfg: cfg, logger: logger}
+}
+
+// Run summarizes the completed days not yet summarized now and then every interval until ctx
+// is canceled
+func (c *Chain) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := c.extend(ctx); err != nil {
+			c.logger.Error("Failed to write daily summary", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// extend summarizes every completed day after the last summary, oldest first. Without a summary
+// yet the chain starts on the day of the first trade.
+func (c *Chain) extend(ctx context.Context) error {
+	last, err := c.repo.GetLastDailySummary(ctx)
+	if err != nil {
+		return err
+	}
+	var day time.Time
+	var previous string
+	if last != nil {
+		day, previous = utcDay(last.Day).AddDate(0, 0, 1), last.Hash
+	} else if day, err = c.firstTradeDay(ctx); err != nil || day.IsZero() {
+		return err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, err := c.summarize(ctx, day, previous)
+		if err != nil {
+			return err
+		}
+		summary.CreatedAt = time.Now()
+		if err := c.repo.SaveDailySummary(ctx, summary); err != nil {
+			return err
+		}
+		c.logger.Info("Daily summary written", zap.String("day", day.Format(dayLayout)), zap.Int("trades", summary.Trades),
+			zap.Int("ledger_entries", summary.LedgerEntries), zap.String("hash", summary.Hash))
+		previous = summary.Hash
+	}
+	return nil
+}
+
+// firstTradeDay returns the UTC day of the earliest trade on any shard, the zero time when there
+// are none
+func (c *Chain) firstTradeDay(ctx context.Context) (time.Time, error) {
+	var first time.Time
+	for _, shard := range c.repo.Shards() {
+		t, err := shard.GetFirstTradeTime(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	if first.IsZero() {
+		return first, nil
+	}
+	return first.UTC().Truncate(24 * time.Hour), nil
+}
+
+// summarize digests a day's trades and ledger entries from every shard in turn and chains them
+// to the previous day's hash
+func (c *Chain) summarize(ctx context.Context, day time.Time, previous string) (*models.DailySummary, error) {
+	end := day.AddDate(0, 0, 1)
+	trades, ledger := sha256.New(), sha256.New()
+	summary := &models.DailySummary{Day: day, PreviousHash: previous}
+	for shard, repo := range c.repo.Shards() {
+		n, err := page(c.cfg.BatchSize, func(afterID uint64) ([]*models.Trade, error) {
+			return repo.GetTradesBetween(ctx, day, end, afterID, c.cfg.BatchSize)
+		}, func(t *models.Trade) uint64 {
+			fmt.Fprintf(trades, "%d|%d|%s|%d|%d|%s|%s|%s|%s|%d|%s\n", shard, t.TradeID, t.Symbol, t.BuyOrderID, t.SellOrderID,
+				t.BuyOwnerID, t.SellOwnerID, t.Price, t.Quantity, t.CreatedAt.UnixMicro(), t.PrintType)
+			return t.TradeID
+		})
+		if err != nil {
+			return nil, err
+		}
+		summary.Trades += n
+
+		// A settlement's status changes once it is paid out, so only what it pays is covered
+		n, err = page(c.cfg.BatchSize, func(afterID uint64) ([]*models.Settlement, error) {
+			return repo.GetSettlementsBetween(ctx, day, end, afterID, c.cfg.BatchSize)
+		}, func(s *models.Settlement) uint64 {
+			fmt.Fprintf(ledger, "settlement|%d|%d|%d|%s|%s|%s|%d\n", shard, s.SettlementID, s.TradeID, s.AccountID,
+				s.Currency, amount(s.Amount), s.SettleAt.UnixMicro())
+			return s.SettlementID
+		})
+		if err != nil {
+			return nil, err
+		}
+		summary.LedgerEntries += n
+
+		n, err = page(c.cfg.BatchSize, func(afterID uint64) ([]*models.FeeEntry, error) {
+			return repo.GetFeeEntriesBetween(ctx, day, end, afterID, c.cfg.BatchSize)
+		}, func(e *models.FeeEntry) uint64 {
+			fmt.Fprintf(ledger, "fee|%d|%d|%s|%d|%s|%s|%s|%s|%s|%s|%s|%d\n", shard, e.EntryID, e.ExecID, e.TradeID, e.AccountID,
+				e.Symbol, e.Currency, e.Liquidity, strconv.FormatFloat(e.Rate, 'f', 6, 64), amount(e.Notional), amount(e.Amount),
+				e.CreatedAt.UnixMicro())
+			return e.EntryID
+		})
+		if err != nil {
+			return nil, err
+		}
+		summary.LedgerEntries += n
+	}
+	summary.TradeDigest = digest(trades)
+	summary.LedgerDigest = digest(ledger)
+	summary.Hash = chainHash(summary)
+	return summary, nil
+}
+
+// page reads rows in batches of size after the last ID seen, passing each to write, which
+// returns its ID, and returns how many there were
+func page[T any](size int, read func(afterID uint64) ([]T, error), write func(T) uint64) (int, error) {
+	var afterID uint64
+	total := 0
+	for {
+		rows, err := read(afterID)
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range rows {
+			afterID = write(row)
+		}
+		total += len(rows)
+		if len(rows) < size {
+			return total, nil
+		}
+	}
+}
+
+// amount formats a ledger amount with the 8 decimals it is stored with
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 8, 64)
+}
+
+func digest(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// chainHash hashes a summary's day, counts and digests together with the previous day's hash
+func chainHash(s *models.DailySummary) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%d|%s|%d|%s", s.PreviousHash, s.Day.Format(dayLayout), s.Trades, s.TradeDigest,
+		s.LedgerEntries, s.LedgerDigest)
+	return digest(h)
+}
+
+// utcDay returns a stored day as midnight UTC, whatever location the driver read it in
+func utcDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Summaries returns the summaries of the days in [from, to], oldest first
+func (c *Chain) Summaries(ctx context.Context, from, to time.Time) ([]*models.DailySummary, error) {
+	summaries, err := c.repo.GetDailySummaries(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		s.Day = utcDay(s.Day)
+	}
+	return summaries, nil
+}
+
+// Verify recomputes the summaries of the days in [from, to] from the rows they cover and checks
+// that each is chained to the summary of the day before, returning how many summaries were
+// checked and every way they no longer match. The chain only holds from the first summary on.
+func (c *Chain) Verify(ctx context.Context, from, to time.Time) (int, []Discrepancy, error) {
+	summaries, err := c.Summaries(ctx, from.AddDate(0, 0, -1), to)
+	if err != nil {
+		return 0, nil, err
+	}
+	var discrepancies []Discrepancy
+	checked := 0
+	for i, stored := range summaries {
+		if stored.Day.Before(from) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		checked++
+		if i > 0 {
+			prior := summaries[i-1]
+			if !prior.Day.AddDate(0, 0, 1).Equal(stored.Day) {
+				discrepancies = append(discrepancies, Discrepancy{Day: stored.Day, Field: "day",
+					Stored: prior.Day.Format(dayLayout), Computed: stored.Day.AddDate(0, 0, -1).Format(dayLayout)})
+			}
+			if stored.PreviousHash != prior.Hash {
+				discrepancies = append(discrepancies, Discrepancy{Day: stored.Day, Field: "previous_hash",
+					Stored: stored.PreviousHash, Computed: prior.Hash})
+			}
+		}
+
+		computed, err := c.summarize(ctx, stored.Day, stored.PreviousHash)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, f := range []struct{ name, stored, computed string }{
+			{"trades", strconv.Itoa(stored.Trades), strconv.Itoa(computed.Trades)},
+			{"trade_digest", stored.TradeDigest, computed.TradeDigest},
+			{"ledger_entries", strconv.Itoa(stored.LedgerEntries), strconv.Itoa(computed.LedgerEntries)},
+			{"ledger_digest", stored.LedgerDigest, computed.LedgerDigest},
+			{"hash", stored.Hash, chainHash(stored)},
+		} {
+			if f.stored != f.computed {
+				discrepancies = append(discrepancies, Discrepancy{Day: stored.Day, Field: f.name, Stored: f.stored, Computed: f.computed})
+			}
+		}
+	}
+	return checked, discrepancies, nil
+}
@@ -0,0 +1,136 @@
+package billing
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Biller turns the fee ledger into monthly per-account invoices
+type Biller struct {
+	repo   repository.Repository
+	logger *zap.Logger
+}
+
+// NewBiller creates a biller
+func NewBiller(repo repository.Repository, logger *zap.Logger) *Biller {
+	return &Biller{repo: repo, logger: logger}
+}
+
+// monthStart returns the first instant of t's calendar month in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Run invoices the previous calendar month at startup and then on each interval until ctx is done.
+// Months are only invoiced once closed, so late re-runs are no-ops.
+func (b *Biller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	now := time.Now()
+	for {
+		if _, err := b.GenerateInvoices(ctx, monthStart(now).AddDate(0, -1, 0)); err != nil {
+			b.logger.Error("Failed to generate invoices", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
+
+// GenerateInvoices creates an invoice for every account with fees in the month starting at
+// periodStart that has not been invoiced yet, returning how many were created
+func (b *Biller) GenerateInvoices(ctx context.Context, periodStart time.Time) (int, error) {
+	from := monthStart(periodStart)
+	to := from.AddDate(0, 1, 0)
+	accounts, err := b.repo.GetUninvoicedAccounts(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, accountID := range accounts {
+		if err := b.generate(ctx, accountID, from, to); err != nil {
+			b.logger.Error("Failed to generate invoice", zap.String("account_id", accountID),
+				zap.Time("period_start", from), zap.Error(err))
+			continue
+		}
+		created++
+	}
+	if created > 0 {
+		b.logger.Info("Invoices generated", zap.Time("period_start", from), zap.Int("count", created))
+	}
+	return created, nil
+}
+
+// generate aggregates an account's ledger entries for [from, to) into a persisted invoice
+func (b *Biller) generate(ctx context.Context, accountID string, from, to time.Time) error {
+	tx, err := b.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lines, err := b.repo.GetFeeLinesTx(ctx, tx, accountID, from, to)
+	if err != nil {
+		return err
+	}
+	invoice := &models.Invoice{
+		AccountID:   accountID,
+		PeriodStart: from,
+		Lines:       lines,
+		CreatedAt:   time.Now(),
+	}
+	if err := b.repo.SaveInvoiceTx(ctx, tx, invoice); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// invoiceTotals sums an invoice's line items per currency, ordered by currency
+func invoiceTotals(lines []models.InvoiceLine) []models.InvoiceTotal {
+	sums := make(map[string]float64)
+	for _, line := range lines {
+		sums[line.Currency] += line.Amount
+	}
+	totals := make([]models.InvoiceTotal, 0, len(sums))
+	for currency, amount := range sums {
+		totals = append(totals, models.InvoiceTotal{Currency: currency, Amount: amount})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals
+}
+
+// GetInvoices retrieves an account's invoices without line items, newest first
+func (b *Biller) GetInvoices(ctx context.Context, accountID string) ([]*models.Invoice, error) {
+	invoices, err := b.repo.GetInvoices(ctx, accountID)
+	if err != nil {
+		b.logger.Error("Failed to get invoices", zap.Error(err))
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// GetInvoice retrieves one of an account's invoices with its line items and currency totals
+func (b *Biller) GetInvoice(ctx context.Context, accountID string, invoiceID uint64) (*models.Invoice, error) {
+	invoice, err := b.repo.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		if err != models.ErrInvoiceNotFound {
+			b.logger.Error("Failed to get invoice", zap.Error(err))
+		}
+		return nil, err
+	}
+	if invoice.AccountID != accountID {
+		return nil, models.ErrInvoiceNotFound
+	}
+	invoice.Totals = invoiceTotals(invoice.Lines)
+	return invoice, nil
+}
@@ -0,0 +1,34 @@
+package billing
+
+import (
+	"encoding/csv"
+	"io"
+	"orderSystem/internal/models"
+	"strconv"
+)
+
+// WriteCSV exports an invoice as CSV: one row per line item followed by one total row per currency
+func WriteCSV(w io.Writer, invoice *models.Invoice) error {
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', 8, 64) }
+
+	cw := csv.NewWriter(w)
+	period := invoice.PeriodStart.Format("2006-01")
+	if err := cw.Write([]string{"invoice_id", "account_id", "period", "day", "symbol", "currency", "fills", "notional", "fee"}); err != nil {
+		return err
+	}
+	id := strconv.FormatUint(invoice.InvoiceID, 10)
+	for _, line := range invoice.Lines {
+		if err := cw.Write([]string{id, invoice.AccountID, period, line.Day.Format("2006-01-02"), line.Symbol,
+			line.Currency, strconv.Itoa(line.Fills), amount(line.Notional), amount(line.Amount)}); err != nil {
+			return err
+		}
+	}
+	for _, total := range invoice.Totals {
+		if err := cw.Write([]string{id, invoice.AccountID, period, "total", "", total.Currency, "", "",
+			amount(total.Amount)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,138 @@
+package candles
+
+import (
+	"context"
+	"orderSystem/internal/compliance"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// subscriber is the event cursor subscriber ID under which the aggregator records its progress
+const subscriber = "candles"
+
+// candleKey identifies a bar
+type candleKey struct {
+	symbol   string
+	interval models.CandleInterval
+	openTime time.Time
+}
+
+// Aggregator builds the OHLCV bars of every symbol from the trades of every shard, in commit
+// order, at each of models.CandleIntervals. It starts from the first stored trade, so history is
+// backfilled on first run, and saves its progress as event cursors after each batch; a batch
+// replayed after a crash is ignored by SaveCandles, so every trade counts exactly once.
+//
+// Only book prints count: block trades are negotiated off-book and, as for the last trade
+// price, neither move the bars nor add to their volume.
+type Aggregator struct {
+	feed   *compliance.Feed
+	repo   repository.Repository
+	cfg    config.CandlesConfig
+	logger *zap.Logger
+
+	cursor compliance.Cursor // nil until the saved cursors are loaded
+}
+
+// NewAggregator creates a candle aggregator
+func NewAggregator(feed *compliance.Feed, repo repository.Repository, cfg config.CandlesConfig, logger *zap.Logger) *Aggregator {
+	return &Aggregator{feed: feed, repo: repo, cfg: cfg, logger: logger}
+}
+
+// Run aggregates new trades until ctx is canceled, draining any backlog without pausing
+func (a *Aggregator) Run(ctx context.Context) {
+	for {
+		aggregated, err := a.aggregate(ctx)
+		wait := a.cfg.PollInterval
+		if err != nil {
+			a.logger.Warn("Failed to aggregate candles", zap.Error(err))
+		} else if aggregated > 0 {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// aggregate merges one batch of trades from every shard into the stored bars, returning how
+// many trades were read
+func (a *Aggregator) aggregate(ctx context.Context) (int, error) {
+	if a.cursor == nil {
+		cursor, err := a.feed.Position(ctx, subscriber)
+		if err != nil {
+			return 0, err
+		}
+		a.cursor = cursor
+	}
+
+	next := append(compliance.Cursor(nil), a.cursor...)
+	read := 0
+	for i, shard := range a.repo.Shards() {
+		if err := shard.SequenceOutbox(ctx, repository.OutboxTrades, a.cfg.BatchSize); err != nil {
+			a.logger.Error("Failed to sequence trades", zap.Int("shard", i), zap.Error(err))
+			return 0, repository.Classify(err)
+		}
+		trades, err := shard.GetTradesAfter(ctx, a.cursor[i], a.cfg.BatchSize)
+		if err != nil {
+			a.logger.Error("Failed to get trades", zap.Int("shard", i), zap.Error(err))
+			return 0, repository.Classify(err)
+		}
+		if len(trades) == 0 {
+			continue
+		}
+		if candles := build(trades); len(candles) > 0 {
+			if err := shard.SaveCandles(ctx, candles); err != nil {
+				a.logger.Error("Failed to save candles", zap.Int("shard", i), zap.Error(err))
+				return 0, repository.Classify(err)
+			}
+		}
+		next[i] = trades[len(trades)-1].CommitSequence
+		read += len(trades)
+	}
+	if read == 0 {
+		return 0, nil
+	}
+	a.cursor = next
+	return read, a.feed.Ack(ctx, subscriber, next)
+}
+
+// build returns the bars of a shard's trades, given in commit order, at every interval
+func build(trades []*models.Trade) []*models.Candle {
+	var candles []*models.Candle
+	bars := make(map[candleKey]*models.Candle)
+	for _, trade := range trades {
+		if trade.PrintType == models.PrintBlock {
+			continue
+		}
+		for _, interval := range models.CandleIntervals {
+			key := candleKey{trade.Symbol, interval, trade.CreatedAt.UTC().Truncate(interval.Duration())}
+			bar, ok := bars[key]
+			if !ok {
+				bar = &models.Candle{
+					Symbol:        trade.Symbol,
+					Interval:      interval,
+					OpenTime:      key.openTime,
+					Open:          trade.Price,
+					High:          trade.Price,
+					Low:           trade.Price,
+					FirstSequence: trade.CommitSequence,
+				}
+				bars[key] = bar
+				candles = append(candles, bar)
+			}
+			bar.High = max(bar.High, trade.Price)
+			bar.Low = min(bar.Low, trade.Price)
+			bar.Close = trade.Price
+			bar.Volume += trade.Quantity
+			bar.Trades++
+			bar.LastSequence = trade.CommitSequence
+		}
+	}
+	return candles
+}
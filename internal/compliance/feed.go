@@ -0,0 +1,179 @@
+package compliance
+
+import (
+	"context"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ShardEvent is an order event along with the shard whose outbox recorded it
+type ShardEvent struct {
+	Shard int
+	Event *models.OrderEvent
+}
+
+// Cursor is a position in the feed: the last commit sequence consumed from each shard's outbox
+type Cursor []uint64
+
+// String encodes a cursor as its per-shard commit sequences joined by dots, e.g. "1042.0.77"
+func (c Cursor) String() string {
+	parts := make([]string, len(c))
+	for i, id := range c {
+		parts[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(parts, ".")
+}
+
+// ParseCursor decodes a cursor produced by Cursor.String for a repository with the given number of shards
+func ParseCursor(s string, shards int) (Cursor, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != shards {
+		return nil, models.ErrInvalidCursor
+	}
+	cursor := make(Cursor, shards)
+	for i, part := range parts {
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, models.ErrInvalidCursor
+		}
+		cursor[i] = id
+	}
+	return cursor, nil
+}
+
+// Feed serves the order lifecycle events recorded in each shard's outbox to external
+// subscribers, who acknowledge what they have processed and resume from there
+type Feed struct {
+	repo   repository.Repository
+	cfg    config.ComplianceConfig
+	logger *zap.Logger
+}
+
+// NewFeed creates an order event feed
+func NewFeed(repo repository.Repository, cfg config.ComplianceConfig, logger *zap.Logger) *Feed {
+	return &Feed{repo: repo, cfg: cfg, logger: logger}
+}
+
+// ParseCursor decodes a cursor for this feed's repository
+func (f *Feed) ParseCursor(s string) (Cursor, error) {
+	return ParseCursor(s, len(f.repo.Shards()))
+}
+
+// Position returns a subscriber's last acknowledged cursor, the start of the feed for a new subscriber
+func (f *Feed) Position(ctx context.Context, subscriberID string) (Cursor, error) {
+	saved, err := f.repo.GetEventCursors(ctx, subscriberID)
+	if err != nil {
+		f.logger.Error("Failed to get event cursors", zap.String("subscriber_id", subscriberID), zap.Error(err))
+		return nil, err
+	}
+	cursor := make(Cursor, len(f.repo.Shards()))
+	for shard, id := range saved {
+		if shard < len(cursor) {
+			cursor[shard] = id
+		}
+	}
+	return cursor, nil
+}
+
+// Head returns the cursor just past the latest event of every shard, for consumers that only
+// want events from now on
+func (f *Feed) Head(ctx context.Context) (Cursor, error) {
+	shards := f.repo.Shards()
+	cursor := make(Cursor, len(shards))
+	for i, shard := range shards {
+		if err := shard.SequenceOutbox(ctx, repository.OutboxOrderEvents, f.cfg.BatchSize); err != nil {
+			f.logger.Error("Failed to sequence order events", zap.Int("shard", i), zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+		id, err := shard.GetOutboxSequence(ctx, repository.OutboxOrderEvents)
+		if err != nil {
+			f.logger.Error("Failed to get order event sequence", zap.Int("shard", i), zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+		cursor[i] = id
+	}
+	return cursor, nil
+}
+
+// Poll returns up to limit events after from, in order within each shard, and the cursor
+// following the last one. When there are none it waits up to wait, or the configured maximum,
+// for new events before returning an empty batch with from unchanged.
+func (f *Feed) Poll(ctx context.Context, from Cursor, limit int, wait time.Duration) ([]ShardEvent, Cursor, error) {
+	if limit <= 0 || limit > f.cfg.BatchSize {
+		limit = f.cfg.BatchSize
+	}
+	if wait > f.cfg.MaxWait {
+		wait = f.cfg.MaxWait
+	}
+	deadline := time.Now().Add(wait)
+
+	ticker := time.NewTicker(f.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		events, next, err := f.fetch(ctx, from, limit)
+		if err != nil || len(events) > 0 || !time.Now().Before(deadline) {
+			return events, next, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, from, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch sequences every shard's newly committed events, reads the events after from on every
+// shard once and merges them by time, keeping each shard's events in commit order
+func (f *Feed) fetch(ctx context.Context, from Cursor, limit int) ([]ShardEvent, Cursor, error) {
+	shards := f.repo.Shards()
+	pending := make([][]*models.OrderEvent, len(shards))
+	for i, shard := range shards {
+		if err := shard.SequenceOutbox(ctx, repository.OutboxOrderEvents, limit); err != nil {
+			f.logger.Error("Failed to sequence order events", zap.Int("shard", i), zap.Error(err))
+			return nil, from, repository.Classify(err)
+		}
+		events, err := shard.GetOrderEvents(ctx, from[i], limit)
+		if err != nil {
+			f.logger.Error("Failed to get order events", zap.Int("shard", i), zap.Error(err))
+			return nil, from, repository.Classify(err)
+		}
+		pending[i] = events
+	}
+
+	next := append(Cursor(nil), from...)
+	var merged []ShardEvent
+	for len(merged) < limit {
+		pick := -1
+		for i, events := range pending {
+			if len(events) > 0 && (pick < 0 || events[0].CreatedAt.Before(pending[pick][0].CreatedAt)) {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			break
+		}
+		event := pending[pick][0]
+		pending[pick] = pending[pick][1:]
+		merged = append(merged, ShardEvent{Shard: pick, Event: event})
+		next[pick] = event.CommitSequence
+	}
+	return merged, next, nil
+}
+
+// Ack records that a subscriber has processed every event up to cursor. Acknowledging a cursor
+// behind the saved one leaves the saved one in place.
+func (f *Feed) Ack(ctx context.Context, subscriberID string, cursor Cursor) error {
+	for shard, id := range cursor {
+		if err := f.repo.SaveEventCursor(ctx, subscriberID, shard, id); err != nil {
+			f.logger.Error("Failed to save event cursor", zap.String("subscriber_id", subscriberID), zap.Error(err))
+			return repository.Classify(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package compliance
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"orderSystem/internal/config"
+	"orderSystem/internal/migration"
+	"orderSystem/internal/repository"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestMain silences the standard logger, which migrations report to
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestFeed creates a feed on a fresh SQLite database, returning the database for writing events
+func newTestFeed(t *testing.T) (*Feed, *sql.DB) {
+	t.Helper()
+	db, err := repository.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migration.RunSQLiteMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.ComplianceConfig{PollInterval: time.Millisecond, BatchSize: 100}
+	return NewFeed(repository.NewSQLiteRepository(db), cfg, zap.NewNop()), db
+}
+
+// commitEvent commits an order event under an event ID, as an engine whose transaction was
+// given that ID on insert does when it commits
+func commitEvent(t *testing.T, db *sql.DB, eventID, orderID uint64) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO order_events (event_id, kind, order_id, symbol, side, type, status, initial_quantity,
+			remaining_quantity, created_at)
+		VALUES (?, 'created', ?, 'BTCUSD', 'buy', 'limit', 'open', 1, 1, ?)`, eventID, orderID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOutOfOrderCommitsDelivered commits the event with the higher ID first, as two engines
+// committing concurrently may, and checks the feed delivers the lower one after it rather than
+// passing over it
+func TestOutOfOrderCommitsDelivered(t *testing.T) {
+	feed, db := newTestFeed(t)
+	ctx := context.Background()
+
+	commitEvent(t, db, 2, 200)
+	events, cursor, err := feed.Poll(ctx, Cursor{0}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event.EventID != 2 {
+		t.Fatalf("first poll returned %d events, want only event 2", len(events))
+	}
+
+	commitEvent(t, db, 1, 100)
+	events, cursor, err = feed.Poll(ctx, cursor, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event.EventID != 1 {
+		t.Fatalf("second poll returned %d events, want only event 1", len(events))
+	}
+
+	if events, _, err = feed.Poll(ctx, cursor, 0, 0); err != nil || len(events) != 0 {
+		t.Errorf("third poll returned %d events, %v, want none", len(events), err)
+	}
+}
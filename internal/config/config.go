@@ -1,32 +1,1128 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"orderSystem/internal/crypto"
+	"orderSystem/internal/ids"
+	"orderSystem/internal/models"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
+// Config holds all runtime settings of the server
 type Config struct {
-	DatabaseDSN string
-	ServerAddr  string
+	Database    DatabaseConfig    `yaml:"database"`
+	Server      ServerConfig      `yaml:"server"`
+	Engine      EngineConfig      `yaml:"engine"`
+	Fees        FeeConfig         `yaml:"fees"`
+	Risk        RiskConfig        `yaml:"risk"`
+	Streaming   StreamingConfig   `yaml:"streaming"`
+	Public      PublicConfig      `yaml:"public"`
+	Compliance  ComplianceConfig  `yaml:"compliance"`
+	Quality     QualityConfig     `yaml:"quality"`
+	Bulkheads   BulkheadConfig    `yaml:"bulkheads"`
+	OrderLimits OrderLimitsConfig `yaml:"order_limits"`
+	RateLimits  RateLimitsConfig  `yaml:"rate_limits"`
+	Leaderboard LeaderboardConfig `yaml:"leaderboard"`
+	Kafka       KafkaConfig       `yaml:"kafka"`
+	Signing     SigningConfig     `yaml:"signing"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Encryption  EncryptionConfig  `yaml:"encryption"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+	Archive     ArchiveConfig     `yaml:"archive"`
+	Audit       AuditConfig       `yaml:"audit"`
+	Candles     CandlesConfig     `yaml:"candles"`
+	Sandbox     SandboxConfig     `yaml:"sandbox"`
+	LastLook    LastLookConfig    `yaml:"last_look"`
+	Seed        SeedConfig        `yaml:"seed"`
+
+	// Symbols holds per-instrument settings; only configurable from the YAML file
+	Symbols map[string]SymbolConfig `yaml:"symbols"`
+}
+
+// DatabaseConfig holds the database connection and pool settings
+type DatabaseConfig struct {
+	// Storage selects MySQL, or SQLite for development and embedded use, which keeps everything
+	// in the single file at SQLitePath
+	Storage    string `yaml:"storage"`
+	SQLitePath string `yaml:"sqlite_path"`
+
+	DSN             string        `yaml:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"` // how long a connection may sit idle before it is closed
+	DialTimeout     time.Duration `yaml:"dial_timeout"`       // how long connecting to the database may take
+
+	// QueryTimeout bounds how long the server waits on the database for any one read or write,
+	// so a stalled query fails instead of holding up a symbol's engine; 0 waits indefinitely
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+
+	// SlowQueryThreshold is how long a repository call may take before it is logged as slow;
+	// 0 logs none, though every call's latency is still recorded
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+
+	// ShardDSNs are additional databases; with any set, orders, trades and the rows derived from
+	// them are partitioned across DSN (shard 0) and these by symbol hash
+	ShardDSNs stringList `yaml:"shard_dsns"`
+}
+
+// stringList is a list setting given as comma-separated values in flags and env vars
+type stringList []string
+
+// String implements flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value, replacing any earlier value
+func (l *stringList) Set(v string) error {
+	*l = nil
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// ServerConfig holds the HTTP listener settings
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// Order entry and cancel requests still waiting for their symbol's engine after
+	// RequestTimeout are answered with 503 without running; 0 waits indefinitely
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+
+	// Start in maintenance mode, refusing order entry and cancels until an admin turns it off
+	Maintenance bool `yaml:"maintenance"`
+
+	// Serve pprof profiles and runtime stats under /admin/debug
+	Debug bool `yaml:"debug"`
+
+	// Addresses or CIDR prefixes of the reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers give the client IP; requests from any other peer are taken to come from the peer
+	// itself, so clients cannot choose the IP that allowlists and rate limits see
+	TrustedProxies stringList `yaml:"trusted_proxies"`
+}
+
+// EngineConfig holds matching engine settings
+type EngineConfig struct {
+	DepthSnapshotInterval time.Duration `yaml:"depth_snapshot_interval"`
+	DepthSnapshotLevels   int           `yaml:"depth_snapshot_levels"`
+
+	// Imbalance-triggered volatility auctions, disabled while ImbalanceRatio is 0
+	ImbalanceRatio            float64       `yaml:"imbalance_ratio"`
+	ImbalanceLevels           int           `yaml:"imbalance_levels"`
+	ImbalanceWindow           time.Duration `yaml:"imbalance_window"`
+	VolatilityAuctionDuration time.Duration `yaml:"volatility_auction_duration"`
+
+	SettlementInterval time.Duration `yaml:"settlement_interval"`
+
+	// Bounds on how much of the book a single incoming order may consume, 0 is unlimited
+	MaxWalkLevels int    `yaml:"max_walk_levels"`
+	MaxWalkFills  int    `yaml:"max_walk_fills"`
+	WalkCapAction string `yaml:"walk_cap_action"` // what happens to a capped limit order's remainder
+
+	// Retries of a matching transaction that lost a deadlock or timed out waiting for a lock, with
+	// jittered exponential backoff
+	DeadlockRetries int           `yaml:"deadlock_retries"`
+	DeadlockBackoff time.Duration `yaml:"deadlock_backoff"`
+
+	// How often symbols with a stale_order_ttl are swept for stale resting orders, 0 disables
+	StaleOrderSweepInterval time.Duration `yaml:"stale_order_sweep_interval"`
+
+	// How often good-till-date orders past their expiry time are expired, 0 disables
+	ExpirySweepInterval time.Duration `yaml:"expiry_sweep_interval"`
+
+	// How long per-minute order flow counts are kept for operators
+	FlowRetention time.Duration `yaml:"flow_retention"`
+
+	// How long an engine holding nothing but what it can load again may sit idle before it is
+	// stopped, 0 keeps every engine running
+	IdleEngineTTL time.Duration `yaml:"idle_engine_ttl"`
+
+	// Whether every order command an engine applies is appended to the engine journal
+	Journal bool `yaml:"journal"`
+
+	// How often each loaded symbol's book is snapshotted for warm start, 0 disables
+	BookSnapshotInterval time.Duration `yaml:"book_snapshot_interval"`
+
+	// Node number in the IDs this server generates, unique among servers sharing the databases
+	NodeID int `yaml:"node_id"`
+
+	// Whether engines commit their transactions to a write-ahead log in WriteBehindDir and go on
+	// matching while the transactions are written to the database behind them
+	WriteBehind    bool   `yaml:"write_behind"`
+	WriteBehindDir string `yaml:"write_behind_dir"`
 }
 
-func Load(logger *zap.Logger) (*Config, error) {
+// Walk cap actions for the remainder of a limit order that hit the book walk cap
+const (
+	WalkCapCancel = "cancel"
+	WalkCapRest   = "rest"
+)
+
+// SymbolConfig holds the settings of a single instrument
+type SymbolConfig struct {
+	BaseCurrency  string        `yaml:"base_currency"`
+	QuoteCurrency string        `yaml:"quote_currency"`
+	SettlementLag time.Duration `yaml:"settlement_lag"` // 0 settles trades immediately (T+0)
+
+	// Order prices must be multiples of TickSize, 0 accepts any price; a tick size changed
+	// through the admin API is stored and overrides it
+	TickSize float64 `yaml:"tick_size"`
+
+	// Order quantities must be multiples of LotSize, 0 accepts any quantity; it is copied into
+	// the symbol registry when the symbol is first listed, and changed there from then on
+	LotSize float64 `yaml:"lot_size"`
+
+	// Once the symbol has not traded for StaleOrderTTL, resting orders unchanged for as long
+	// expire; 0 keeps them indefinitely
+	StaleOrderTTL time.Duration `yaml:"stale_order_ttl"`
+
+	// Resting orders of the LastLookProviders accounts may reject a match within LastLookWindow
+	// before it prints; 0 disables last look
+	LastLookWindow    time.Duration `yaml:"last_look_window"`
+	LastLookProviders []string      `yaml:"last_look_providers"`
+
+	// Limit orders priced more than PriceBand (a fraction, 0 disables the check) away from the
+	// symbol's reference price are rejected
+	PriceBand float64 `yaml:"price_band"`
+
+	// Trading halts once trade prices move more than CircuitBreakerMove (a fraction, 0 disables
+	// the breaker) within CircuitBreakerWindow
+	CircuitBreakerMove   float64       `yaml:"circuit_breaker_move"`
+	CircuitBreakerWindow time.Duration `yaml:"circuit_breaker_window"`
+
+	// A call auction starts at each of CallAuctions, times of day in UTC given as offsets from
+	// midnight (e.g. 9h30m to open, 16h to close), and collects orders for CallAuctionDuration
+	// before it uncrosses
+	CallAuctions        []time.Duration `yaml:"call_auctions"`
+	CallAuctionDuration time.Duration   `yaml:"call_auction_duration"`
+}
+
+// FeeConfig holds trading fee rates expressed as fractions of notional
+type FeeConfig struct {
+	MakerRate float64 `yaml:"maker_rate"`
+	TakerRate float64 `yaml:"taker_rate"`
+
+	BillingInterval time.Duration `yaml:"billing_interval"` // how often closed months are invoiced
+}
+
+// RiskConfig holds pre-trade risk limits, zero meaning unlimited
+type RiskConfig struct {
+	MaxOrderQuantity float64 `yaml:"max_order_quantity"`
+	MaxOrderNotional float64 `yaml:"max_order_notional"`
+
+	ReportingCurrency string `yaml:"reporting_currency"` // currency portfolio summaries are valued in
+
+	// Block trades must be priced within BlockPriceBand (a fraction, 0 disables the check) of the
+	// symbol's reference price and be at least BlockMinQuantity in size. A reported block waits
+	// up to BlockAffirmTTL for its counterparty to affirm it.
+	BlockPriceBand   float64       `yaml:"block_price_band"`
+	BlockMinQuantity float64       `yaml:"block_min_quantity"`
+	BlockAffirmTTL   time.Duration `yaml:"block_affirm_ttl"`
+
+	// Pre-trade credit check of account orders, and how long a check may take before the
+	// order is rejected
+	CreditCheck   string        `yaml:"credit_check"`
+	CreditTimeout time.Duration `yaml:"credit_timeout"`
+
+	// Collateral credit checks value each currency at its mark price less its haircut, a
+	// fraction; currencies without one take DefaultHaircut
+	Haircuts       map[string]float64 `yaml:"haircuts"`
+	DefaultHaircut float64            `yaml:"default_haircut"`
+}
+
+// Storage backends
+const (
+	StorageMySQL  = "mysql"
+	StorageSQLite = "sqlite"
+)
+
+// Credit checks run before account orders are matched
+const (
+	CreditNone       = "none"
+	CreditBalances   = "balances"   // the account's balances must cover the order and its other open orders
+	CreditCollateral = "collateral" // the haircut value of all the account's balances must cover it
+	CreditHolds      = "holds"      // open orders hold the balances they consume, checked as they are matched
+)
+
+// StreamingConfig holds market data streaming settings
+type StreamingConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	BufferSize   int           `yaml:"buffer_size"`
+	ListenKeyTTL time.Duration `yaml:"listen_key_ttl"` // how long a private stream listen key lasts without a keepalive
+
+	// Market data connections are pinged every PingInterval and dropped after IdleTimeout
+	// without a message from the client
+	PingInterval time.Duration `yaml:"ping_interval"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+
+	// Per client IP limits on open market data connections and on the subscriptions of all its
+	// sessions, 0 for no limit
+	MaxConnections   int `yaml:"max_connections"`
+	MaxSubscriptions int `yaml:"max_subscriptions"`
+
+	// ResumeTTL is how long a disconnected session keeps its subscriptions for a client to
+	// resume, 0 disables resuming
+	ResumeTTL time.Duration `yaml:"resume_ttl"`
+}
+
+// PublicConfig holds the settings of the unauthenticated market data endpoints
+type PublicConfig struct {
+	CacheTTL  time.Duration `yaml:"cache_ttl"`  // how long responses are shared between callers, 0 disables
+	RateLimit float64       `yaml:"rate_limit"` // requests per second per client IP
+	RateBurst int           `yaml:"rate_burst"`
+
+	// AnonymizeOrders replaces order IDs in public feeds with aliases that change every AliasRotation
+	AnonymizeOrders bool          `yaml:"anonymize_orders"`
+	AliasRotation   time.Duration `yaml:"alias_rotation"`
+}
+
+// ComplianceConfig holds the settings of the order lifecycle event feed
+type ComplianceConfig struct {
+	MaxWait      time.Duration `yaml:"max_wait"`      // longest a long-poll request waits for new events
+	PollInterval time.Duration `yaml:"poll_interval"` // how often a waiting request checks the outbox
+	BatchSize    int           `yaml:"batch_size"`    // maximum events returned per request
+}
+
+// QualityConfig holds the settings of market quality monitoring
+type QualityConfig struct {
+	SampleInterval time.Duration `yaml:"sample_interval"` // how often metrics are persisted, 0 disables
+	DepthBand      float64       `yaml:"depth_band"`      // fraction of the mid price depth is measured within
+
+	// Alert thresholds, 0 disables each: a market is too thin when its spread is wider than
+	// MaxSpreadBps or either side holds less than MinDepthNotional within the band
+	MaxSpreadBps     float64 `yaml:"max_spread_bps"`
+	MinDepthNotional float64 `yaml:"min_depth_notional"`
+}
+
+// BulkheadConfig holds the concurrency limits isolating classes of API requests from each
+// other, 0 meaning unlimited
+type BulkheadConfig struct {
+	OrderEntry int           `yaml:"order_entry"` // placing and amending orders, groups, algo orders and block trades
+	Cancels    int           `yaml:"cancels"`
+	MarketData int           `yaml:"market_data"` // book, trade, depth and statistics reads
+	Wait       time.Duration `yaml:"wait"`        // how long a request may queue for a free slot
+}
+
+// OrderLimitsConfig holds the settings of the adaptive per-account order entry rate limit
+// (per client IP for anonymous callers). A caller whose orders are refused or canceled too often
+// within a window has its limit tightened by TightenFactor, down to MinFactor of Rate and Burst,
+// and regains RelaxStep of them with every window it behaves.
+type OrderLimitsConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Rate           float64       `yaml:"rate"` // order entry requests per second
+	Burst          int           `yaml:"burst"`
+	Window         time.Duration `yaml:"window"`           // how often a caller's ratios are judged
+	MinOrders      int           `yaml:"min_orders"`       // orders a window needs before its ratios count
+	MaxRejectRatio float64       `yaml:"max_reject_ratio"` // refused orders per order sent
+	MaxCancelRatio float64       `yaml:"max_cancel_ratio"` // cancels per order sent
+	TightenFactor  float64       `yaml:"tighten_factor"`
+	MinFactor      float64       `yaml:"min_factor"`
+	RelaxStep      float64       `yaml:"relax_step"`
+}
+
+// RateLimitsConfig holds the token bucket quotas of order entry and cancel requests, kept both
+// per API key (the signing key of a signed request, else the account) and per client IP
+type RateLimitsConfig struct {
+	Enabled  bool    `yaml:"enabled"`
+	KeyRate  float64 `yaml:"key_rate"` // requests per second per API key
+	KeyBurst int     `yaml:"key_burst"`
+	IPRate   float64 `yaml:"ip_rate"` // requests per second per client IP
+	IPBurst  int     `yaml:"ip_burst"`
+}
+
+// LeaderboardConfig holds the settings of the traded volume leaderboard
+type LeaderboardConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Days    int  `yaml:"days"` // UTC days of standings kept, today included
+}
+
+// KafkaConfig holds the settings of the order event and trade publisher
+type KafkaConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Brokers      stringList    `yaml:"brokers"` // bootstrap brokers as host:port
+	ClientID     string        `yaml:"client_id"`
+	OrderTopic   string        `yaml:"order_topic"`   // topic of order lifecycle events
+	TradeTopic   string        `yaml:"trade_topic"`   // topic of executed trades
+	BatchSize    int           `yaml:"batch_size"`    // maximum rows read from a shard per publish
+	PollInterval time.Duration `yaml:"poll_interval"` // how often the outboxes are checked once drained
+	Timeout      time.Duration `yaml:"timeout"`       // broker dial, write and acknowledgement timeout
+}
+
+// SigningConfig holds the settings of HMAC-signed order entry requests
+type SigningConfig struct {
+	Required bool          `yaml:"required"` // reject unsigned order entry and cancel requests
+	Window   time.Duration `yaml:"window"`   // how far a request's timestamp may be from the server clock
+}
+
+// AuthConfig holds the settings of JWT bearer tokens, the sessions of dashboards and other human
+// clients; bots keep using account headers and signing keys
+type AuthConfig struct {
+	JWTSecret string `yaml:"jwt_secret"` // HS256 key tokens are signed with; empty disables bearer tokens
+	Issuer    string `yaml:"issuer"`     // when set, tokens must carry it as their iss claim
+}
+
+// EncryptionConfig holds the master keys sensitive fields are sealed under at rest, each given as
+// ID:BASE64 with 32 bytes of key. Values are sealed under the key with KeyID; the other keys
+// only open values sealed before a rotation. Without keys, values are stored as they are.
+type EncryptionConfig struct {
+	KeyID string     `yaml:"key_id"`
+	Keys  stringList `yaml:"keys"`
+}
+
+// WebhooksConfig holds the settings of webhook order notifications
+type WebhooksConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"` // how often new order events and due deliveries are checked
+	BatchSize    int           `yaml:"batch_size"`    // maximum events or deliveries handled per pass
+	Timeout      time.Duration `yaml:"timeout"`       // per delivery attempt
+	MaxAttempts  int           `yaml:"max_attempts"`  // attempts before a delivery is given up
+	RetryBackoff time.Duration `yaml:"retry_backoff"` // wait after the first failure, doubled after each further one
+	MaxBackoff   time.Duration `yaml:"max_backoff"`
+}
+
+// ArchiveConfig holds the settings of the daily trade and order event archive. Files go to the
+// S3-compatible bucket when one is set and to Dir otherwise.
+type ArchiveConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Dir          string        `yaml:"dir"`
+	Interval     time.Duration `yaml:"interval"`      // how often completed days are checked for missing files
+	BackfillDays int           `yaml:"backfill_days"` // how many completed days back missing files are written
+	BatchSize    int           `yaml:"batch_size"`    // maximum rows read from a shard per query
+	S3           S3Config      `yaml:"s3"`
+}
+
+// S3Config holds the location and credentials of an S3-compatible bucket, addressed path-style
+// so other S3-compatible stores work too
+type S3Config struct {
+	Endpoint  string        `yaml:"endpoint"` // such as https://s3.us-east-1.amazonaws.com
+	Region    string        `yaml:"region"`
+	Bucket    string        `yaml:"bucket"`
+	Prefix    string        `yaml:"prefix"` // prepended to every object name
+	AccessKey string        `yaml:"access_key"`
+	SecretKey string        `yaml:"secret_key"`
+	Timeout   time.Duration `yaml:"timeout"` // per request
+}
+
+// AuditConfig holds the settings of the daily trading summaries, each hashing a completed UTC
+// day's trades and ledger entries together with the previous day's summary
+type AuditConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval"`   // how often completed days are checked for a missing summary
+	BatchSize int           `yaml:"batch_size"` // maximum rows read from a shard per query
+}
+
+// CandlesConfig holds the settings of the OHLCV candle aggregator
+type CandlesConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"` // how often new trades are checked once caught up
+	BatchSize    int           `yaml:"batch_size"`    // maximum trades read from a shard per pass
+}
+
+// SandboxConfig holds the settings of sandbox mode, in which the server runs as a paper-trading
+// venue that imitates the frictions of a real one. Every setting but Enabled can be changed at
+// runtime through /admin/sandbox.
+type SandboxConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	AckLatency time.Duration `yaml:"ack_latency"` // delay before each new order reaches matching
+	AckJitter  time.Duration `yaml:"ack_jitter"`  // random extra delay, up to this
+
+	// Probability that a fill of an incoming order is cut to a random part of its size, the
+	// rest of the order then missing the liquidity as if another participant took it first
+	PartialFillRate float64 `yaml:"partial_fill_rate"`
+
+	// Simulated interest queued ahead of a newly resting order, as a multiple of the quantity
+	// already resting at its price, which incoming orders must trade through before reaching it;
+	// it cancels away with a half-life of QueueHalfLife, 0 keeping it until traded through
+	QueueAhead    float64       `yaml:"queue_ahead"`
+	QueueHalfLife time.Duration `yaml:"queue_half_life"`
+}
+
+// LastLookConfig holds the surveillance of last look rejects: a provider that answered at least
+// MinRequests last looks within a period and rejected more than MaxRejectRatio of them is
+// flagged. Period is the default period reported.
+type LastLookConfig struct {
+	MinRequests    int           `yaml:"min_requests"`
+	MaxRejectRatio float64       `yaml:"max_reject_ratio"`
+	Period         time.Duration `yaml:"period"`
+}
+
+// ValidTickSize reports whether a tick or lot size is positive and held exactly by a Decimal, so
+// it is a multiple of the 1e-8 grid prices and quantities are kept on
+func ValidTickSize(tick float64) bool {
+	d := models.NewDecimal(tick)
+	return d > 0 && d.Float64() == tick
+}
+
+// ValidSandbox reports whether sandbox settings are in range
+func ValidSandbox(c SandboxConfig) bool {
+	return c.AckLatency >= 0 && c.AckJitter >= 0 && c.PartialFillRate >= 0 && c.PartialFillRate <= 1 &&
+		c.QueueAhead >= 0 && c.QueueHalfLife >= 0
+}
+
+// SeedConfig holds the settings of the demo market generator, cmd/seed. Each seeded symbol gets
+// Levels price levels a side around its mid price, the best bid and ask Spread apart and further
+// levels LevelSpacing apart, both fractions of the mid. Order sizes are drawn around a mean
+// notional that grows by DepthGrowth per level away from the touch.
+type SeedConfig struct {
+	// Prices holds the mid price of every symbol to seed, a demo set when empty; only
+	// configurable from the YAML file
+	Prices map[string]float64 `yaml:"prices"`
+
+	// SymbolsFile is a JSON symbol registry listed before seeding, in the shape GET
+	// /admin/symbols returns; entries with a mid_price are seeded at it
+	SymbolsFile string `yaml:"symbols_file"`
+
+	Accounts       int     `yaml:"accounts"`         // demo accounts, named seed-001 and up; 0 seeds anonymous orders
+	Deposit        float64 `yaml:"deposit"`          // quote currency value each account holds in every currency
+	Levels         int     `yaml:"levels"`           // price levels per side
+	OrdersPerLevel int     `yaml:"orders_per_level"` // most resting orders at a level, at least one
+	Spread         float64 `yaml:"spread"`
+	LevelSpacing   float64 `yaml:"level_spacing"`
+	OrderNotional  float64 `yaml:"order_notional"` // mean order notional at the touch, in quote currency
+	DepthGrowth    float64 `yaml:"depth_growth"`
+	Jitter         float64 `yaml:"jitter"`      // fraction order sizes vary by around the mean
+	TickSize       float64 `yaml:"tick_size"`   // prices are rounded to multiples of this
+	LotSize        float64 `yaml:"lot_size"`    // quantities are rounded to multiples of this
+	RandomSeed     int64   `yaml:"random_seed"` // 0 draws a different market every run
+}
+
+// defaults returns the configuration used when no source overrides a setting
+func defaults() Config {
+	return Config{
+		Database: DatabaseConfig{
+			Storage:         StorageMySQL,
+			SQLitePath:      "order_matching.db",
+			DSN:             "user:password@tcp(localhost:3306)/order_matching?parseTime=true",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: time.Minute,
+			DialTimeout:     5 * time.Second,
+
+			SlowQueryThreshold: 100 * time.Millisecond,
+		},
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			RequestTimeout:  5 * time.Second,
+		},
+		Engine: EngineConfig{
+			DepthSnapshotLevels:       10,
+			ImbalanceLevels:           5,
+			ImbalanceWindow:           10 * time.Second,
+			VolatilityAuctionDuration: 30 * time.Second,
+			SettlementInterval:        time.Second,
+			WalkCapAction:             WalkCapCancel,
+			DeadlockRetries:           3,
+			DeadlockBackoff:           5 * time.Millisecond,
+			StaleOrderSweepInterval:   time.Hour,
+			ExpirySweepInterval:       time.Second,
+			FlowRetention:             24 * time.Hour,
+			IdleEngineTTL:             10 * time.Minute,
+			WriteBehindDir:            "write_behind",
+		},
+		Fees: FeeConfig{
+			BillingInterval: time.Hour,
+		},
+		Streaming: StreamingConfig{
+			BufferSize:       256,
+			ListenKeyTTL:     time.Hour,
+			PingInterval:     30 * time.Second,
+			IdleTimeout:      90 * time.Second,
+			MaxConnections:   5,
+			MaxSubscriptions: 64,
+			ResumeTTL:        time.Minute,
+		},
+		Risk: RiskConfig{
+			ReportingCurrency: "USD",
+			CreditCheck:       CreditNone,
+			CreditTimeout:     500 * time.Millisecond,
+			BlockPriceBand:    0.05,
+			BlockAffirmTTL:    time.Hour,
+		},
+		Public: PublicConfig{
+			CacheTTL:  time.Second,
+			RateLimit: 5,
+			RateBurst: 20,
+
+			AnonymizeOrders: true,
+			AliasRotation:   time.Hour,
+		},
+		Compliance: ComplianceConfig{
+			MaxWait:      5 * time.Second,
+			PollInterval: 250 * time.Millisecond,
+			BatchSize:    500,
+		},
+		Quality: QualityConfig{
+			SampleInterval: time.Minute,
+			DepthBand:      0.01,
+		},
+		Bulkheads: BulkheadConfig{
+			OrderEntry: 64,
+			Cancels:    64,
+			MarketData: 32,
+			Wait:       100 * time.Millisecond,
+		},
+		OrderLimits: OrderLimitsConfig{
+			Rate:           50,
+			Burst:          100,
+			Window:         time.Minute,
+			MinOrders:      20,
+			MaxRejectRatio: 0.5,
+			MaxCancelRatio: 0.95,
+			TightenFactor:  0.5,
+			MinFactor:      0.1,
+			RelaxStep:      0.1,
+		},
+		RateLimits: RateLimitsConfig{
+			KeyRate:  20,
+			KeyBurst: 40,
+			IPRate:   50,
+			IPBurst:  100,
+		},
+		Leaderboard: LeaderboardConfig{
+			Days: 7,
+		},
+		Kafka: KafkaConfig{
+			Brokers:      stringList{"localhost:9092"},
+			ClientID:     "order-matching",
+			OrderTopic:   "order-events",
+			TradeTopic:   "trades",
+			BatchSize:    500,
+			PollInterval: 250 * time.Millisecond,
+			Timeout:      10 * time.Second,
+		},
+		Signing: SigningConfig{
+			Window: 5 * time.Second,
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval: time.Second,
+			BatchSize:    100,
+			Timeout:      5 * time.Second,
+			MaxAttempts:  8,
+			RetryBackoff: 10 * time.Second,
+			MaxBackoff:   time.Hour,
+		},
+		Archive: ArchiveConfig{
+			Dir:          "archive",
+			Interval:     time.Hour,
+			BackfillDays: 7,
+			BatchSize:    1000,
+			S3: S3Config{
+				Region:  "us-east-1",
+				Timeout: time.Minute,
+			},
+		},
+		Audit: AuditConfig{
+			Interval:  time.Hour,
+			BatchSize: 1000,
+		},
+		Candles: CandlesConfig{
+			PollInterval: time.Second,
+			BatchSize:    1000,
+		},
+		LastLook: LastLookConfig{
+			MinRequests:    20,
+			MaxRejectRatio: 0.2,
+			Period:         24 * time.Hour,
+		},
+		Seed: SeedConfig{
+			Accounts:       10,
+			Deposit:        1000000,
+			Levels:         20,
+			OrdersPerLevel: 3,
+			Spread:         0.001,
+			LevelSpacing:   0.0005,
+			OrderNotional:  5000,
+			DepthGrowth:    1.1,
+			Jitter:         0.5,
+			TickSize:       0.01,
+			LotSize:        0.0001,
+		},
+	}
+}
+
+// newFlagSet binds every setting to a command line flag, the flag name also
+// determining the environment variable (db-dsn -> DB_DSN)
+func newFlagSet(cfg *Config, configFile *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(configFile, "config", "", "path to a YAML config file")
+
+	fs.StringVar(&cfg.Database.Storage, "storage", cfg.Database.Storage, "storage backend, mysql or sqlite")
+	fs.StringVar(&cfg.Database.SQLitePath, "db-sqlite-path", cfg.Database.SQLitePath, "SQLite database file, with storage sqlite")
+	fs.StringVar(&cfg.Database.DSN, "db-dsn", cfg.Database.DSN, "MySQL data source name")
+	fs.Var(&cfg.Database.ShardDSNs, "db-shard-dsns", "comma-separated data source names of additional symbol shards")
+	fs.IntVar(&cfg.Database.MaxOpenConns, "db-max-open-conns", cfg.Database.MaxOpenConns, "maximum open database connections")
+	fs.IntVar(&cfg.Database.MaxIdleConns, "db-max-idle-conns", cfg.Database.MaxIdleConns, "maximum idle database connections")
+	fs.DurationVar(&cfg.Database.ConnMaxLifetime, "db-conn-max-lifetime", cfg.Database.ConnMaxLifetime, "maximum lifetime of a database connection")
+	fs.DurationVar(&cfg.Database.ConnMaxIdleTime, "db-conn-max-idle-time", cfg.Database.ConnMaxIdleTime, "how long a database connection may sit idle before it is closed, 0 keeps it")
+	fs.DurationVar(&cfg.Database.DialTimeout, "db-dial-timeout", cfg.Database.DialTimeout, "how long connecting to the database may take, 0 for the system default")
+	fs.DurationVar(&cfg.Database.QueryTimeout, "db-query-timeout", cfg.Database.QueryTimeout, "how long a database read or write may stall before it fails, 0 waits indefinitely")
+	fs.DurationVar(&cfg.Database.SlowQueryThreshold, "db-slow-query-threshold", cfg.Database.SlowQueryThreshold, "how long a repository call may take before it is logged, 0 logs none")
+
+	fs.StringVar(&cfg.Server.Addr, "server-addr", cfg.Server.Addr, "HTTP listen address")
+	fs.DurationVar(&cfg.Server.ReadTimeout, "server-read-timeout", cfg.Server.ReadTimeout, "HTTP read timeout")
+	fs.DurationVar(&cfg.Server.WriteTimeout, "server-write-timeout", cfg.Server.WriteTimeout, "HTTP write timeout")
+	fs.DurationVar(&cfg.Server.IdleTimeout, "server-idle-timeout", cfg.Server.IdleTimeout, "HTTP keep-alive idle timeout")
+	fs.DurationVar(&cfg.Server.ShutdownTimeout, "server-shutdown-timeout", cfg.Server.ShutdownTimeout, "graceful shutdown timeout")
+	fs.DurationVar(&cfg.Server.RequestTimeout, "server-request-timeout", cfg.Server.RequestTimeout, "how long order entry and cancel requests may wait for their symbol's engine, 0 waits indefinitely")
+	fs.BoolVar(&cfg.Server.Maintenance, "server-maintenance", cfg.Server.Maintenance, "start in maintenance mode, refusing order entry and cancels")
+	fs.BoolVar(&cfg.Server.Debug, "server-debug", cfg.Server.Debug, "serve pprof profiles and runtime stats under /admin/debug")
+	fs.Var(&cfg.Server.TrustedProxies, "server-trusted-proxies", "comma-separated addresses or CIDR prefixes of reverse proxies trusted to forward the client IP")
+
+	fs.DurationVar(&cfg.Engine.DepthSnapshotInterval, "depth-snapshot-interval", cfg.Engine.DepthSnapshotInterval, "interval between persisted depth snapshots, 0 disables")
+	fs.IntVar(&cfg.Engine.DepthSnapshotLevels, "depth-snapshot-levels", cfg.Engine.DepthSnapshotLevels, "price levels per side in each depth snapshot")
+	fs.Float64Var(&cfg.Engine.ImbalanceRatio, "imbalance-ratio", cfg.Engine.ImbalanceRatio, "one-sided depth ratio that triggers a volatility auction, 0 disables")
+	fs.IntVar(&cfg.Engine.ImbalanceLevels, "imbalance-levels", cfg.Engine.ImbalanceLevels, "price levels per side used to measure imbalance")
+	fs.DurationVar(&cfg.Engine.ImbalanceWindow, "imbalance-window", cfg.Engine.ImbalanceWindow, "how long the imbalance must persist before an auction")
+	fs.DurationVar(&cfg.Engine.VolatilityAuctionDuration, "volatility-auction-duration", cfg.Engine.VolatilityAuctionDuration, "length of an imbalance-triggered volatility auction")
+	fs.DurationVar(&cfg.Engine.SettlementInterval, "settlement-interval", cfg.Engine.SettlementInterval, "how often due pending settlements are finalized")
+	fs.IntVar(&cfg.Engine.MaxWalkLevels, "max-walk-levels", cfg.Engine.MaxWalkLevels, "maximum price levels a single order may consume, 0 is unlimited")
+	fs.IntVar(&cfg.Engine.MaxWalkFills, "max-walk-fills", cfg.Engine.MaxWalkFills, "maximum fills a single order may generate, 0 is unlimited")
+	fs.StringVar(&cfg.Engine.WalkCapAction, "walk-cap-action", cfg.Engine.WalkCapAction, "remainder of a capped limit order: cancel or rest")
+	fs.IntVar(&cfg.Engine.DeadlockRetries, "deadlock-retries", cfg.Engine.DeadlockRetries, "times a deadlocked or lock-timed-out matching transaction is retried, 0 disables")
+	fs.DurationVar(&cfg.Engine.DeadlockBackoff, "deadlock-backoff", cfg.Engine.DeadlockBackoff, "base delay before retrying a deadlocked or lock-timed-out matching transaction")
+	fs.DurationVar(&cfg.Engine.StaleOrderSweepInterval, "stale-order-sweep-interval", cfg.Engine.StaleOrderSweepInterval, "how often inactive symbols are swept for stale resting orders, 0 disables")
+	fs.DurationVar(&cfg.Engine.ExpirySweepInterval, "expiry-sweep-interval", cfg.Engine.ExpirySweepInterval, "how often good-till-date orders past their expiry time are expired, 0 disables")
+	fs.DurationVar(&cfg.Engine.FlowRetention, "flow-retention", cfg.Engine.FlowRetention, "how long per-minute order flow counts are kept")
+	fs.DurationVar(&cfg.Engine.IdleEngineTTL, "idle-engine-ttl", cfg.Engine.IdleEngineTTL, "how long an empty symbol engine may sit idle before it is stopped, 0 disables")
+	fs.BoolVar(&cfg.Engine.Journal, "journal", cfg.Engine.Journal, "append every order command applied by an engine to the engine journal")
+	fs.DurationVar(&cfg.Engine.BookSnapshotInterval, "book-snapshot-interval", cfg.Engine.BookSnapshotInterval, "interval between persisted order book snapshots used at warm start, 0 disables")
+	fs.IntVar(&cfg.Engine.NodeID, "node-id", cfg.Engine.NodeID, "node number in generated order and trade IDs, unique among servers sharing the databases")
+	fs.BoolVar(&cfg.Engine.WriteBehind, "write-behind", cfg.Engine.WriteBehind, "commit matching transactions to a write-ahead log and write them to the database behind the engines")
+	fs.StringVar(&cfg.Engine.WriteBehindDir, "write-behind-dir", cfg.Engine.WriteBehindDir, "directory of the write-behind write-ahead log")
+
+	fs.Float64Var(&cfg.Fees.MakerRate, "fee-maker-rate", cfg.Fees.MakerRate, "maker fee rate, negative for a rebate")
+	fs.Float64Var(&cfg.Fees.TakerRate, "fee-taker-rate", cfg.Fees.TakerRate, "taker fee rate")
+	fs.DurationVar(&cfg.Fees.BillingInterval, "fee-billing-interval", cfg.Fees.BillingInterval, "how often monthly fee invoices are generated for closed months")
+
+	fs.Float64Var(&cfg.Risk.MaxOrderQuantity, "risk-max-order-quantity", cfg.Risk.MaxOrderQuantity, "maximum quantity of a single order, 0 is unlimited")
+	fs.Float64Var(&cfg.Risk.MaxOrderNotional, "risk-max-order-notional", cfg.Risk.MaxOrderNotional, "maximum notional of a single limit order, 0 is unlimited")
+	fs.StringVar(&cfg.Risk.CreditCheck, "risk-credit-check", cfg.Risk.CreditCheck, "pre-trade credit check of account orders: none, balances, collateral or holds")
+	fs.DurationVar(&cfg.Risk.CreditTimeout, "risk-credit-timeout", cfg.Risk.CreditTimeout, "how long a credit check may take before the order is rejected")
+	fs.Float64Var(&cfg.Risk.DefaultHaircut, "risk-default-haircut", cfg.Risk.DefaultHaircut, "haircut of currencies without their own in collateral credit checks, a fraction")
+	fs.StringVar(&cfg.Risk.ReportingCurrency, "risk-reporting-currency", cfg.Risk.ReportingCurrency, "currency portfolio risk summaries are valued in")
+	fs.Float64Var(&cfg.Risk.BlockPriceBand, "risk-block-price-band", cfg.Risk.BlockPriceBand, "maximum deviation of a block trade price from the reference price as a fraction, 0 disables the check")
+	fs.Float64Var(&cfg.Risk.BlockMinQuantity, "risk-block-min-quantity", cfg.Risk.BlockMinQuantity, "minimum quantity of a block trade")
+	fs.DurationVar(&cfg.Risk.BlockAffirmTTL, "risk-block-affirm-ttl", cfg.Risk.BlockAffirmTTL, "how long a reported block trade waits for its counterparty to affirm it")
+
+	fs.BoolVar(&cfg.Streaming.Enabled, "streaming-enabled", cfg.Streaming.Enabled, "enable market data streaming")
+	fs.IntVar(&cfg.Streaming.BufferSize, "streaming-buffer-size", cfg.Streaming.BufferSize, "per-subscriber message buffer size")
+	fs.DurationVar(&cfg.Streaming.ListenKeyTTL, "streaming-listen-key-ttl", cfg.Streaming.ListenKeyTTL, "how long a private stream listen key lasts without a keepalive")
+	fs.DurationVar(&cfg.Streaming.PingInterval, "streaming-ping-interval", cfg.Streaming.PingInterval, "how often market data connections are pinged")
+	fs.DurationVar(&cfg.Streaming.IdleTimeout, "streaming-idle-timeout", cfg.Streaming.IdleTimeout, "drop market data connections silent for this long")
+	fs.IntVar(&cfg.Streaming.MaxConnections, "streaming-max-connections", cfg.Streaming.MaxConnections, "market data connections per client IP, 0 for no limit")
+	fs.IntVar(&cfg.Streaming.MaxSubscriptions, "streaming-max-subscriptions", cfg.Streaming.MaxSubscriptions, "market data subscriptions per client IP, 0 for no limit")
+	fs.DurationVar(&cfg.Streaming.ResumeTTL, "streaming-resume-ttl", cfg.Streaming.ResumeTTL, "how long a disconnected market data session can be resumed, 0 disables")
+
+	fs.DurationVar(&cfg.Public.CacheTTL, "public-cache-ttl", cfg.Public.CacheTTL, "how long public market data responses are cached, 0 disables")
+	fs.Float64Var(&cfg.Public.RateLimit, "public-rate-limit", cfg.Public.RateLimit, "public market data requests per second per client IP")
+	fs.IntVar(&cfg.Public.RateBurst, "public-rate-burst", cfg.Public.RateBurst, "public market data request burst per client IP")
+	fs.BoolVar(&cfg.Public.AnonymizeOrders, "public-anonymize-orders", cfg.Public.AnonymizeOrders, "show rotating aliases instead of order IDs in public market data")
+	fs.DurationVar(&cfg.Public.AliasRotation, "public-alias-rotation", cfg.Public.AliasRotation, "how often public order aliases change")
+
+	fs.DurationVar(&cfg.Compliance.MaxWait, "compliance-max-wait", cfg.Compliance.MaxWait, "longest an order event long-poll waits for new events")
+	fs.DurationVar(&cfg.Compliance.PollInterval, "compliance-poll-interval", cfg.Compliance.PollInterval, "how often a waiting order event long-poll checks for new events")
+	fs.IntVar(&cfg.Compliance.BatchSize, "compliance-batch-size", cfg.Compliance.BatchSize, "maximum order events returned per request")
+
+	fs.DurationVar(&cfg.Quality.SampleInterval, "quality-sample-interval", cfg.Quality.SampleInterval, "interval between persisted market quality samples, 0 disables")
+	fs.Float64Var(&cfg.Quality.DepthBand, "quality-depth-band", cfg.Quality.DepthBand, "fraction of the mid price market quality depth is measured within")
+	fs.Float64Var(&cfg.Quality.MaxSpreadBps, "quality-max-spread-bps", cfg.Quality.MaxSpreadBps, "spread in basis points above which a market is flagged as thin, 0 disables")
+	fs.Float64Var(&cfg.Quality.MinDepthNotional, "quality-min-depth-notional", cfg.Quality.MinDepthNotional, "notional per side within the depth band below which a market is flagged as thin, 0 disables")
+
+	fs.IntVar(&cfg.Bulkheads.OrderEntry, "bulkhead-order-entry", cfg.Bulkheads.OrderEntry, "concurrent order entry requests, 0 is unlimited")
+	fs.IntVar(&cfg.Bulkheads.Cancels, "bulkhead-cancels", cfg.Bulkheads.Cancels, "concurrent cancel requests, 0 is unlimited")
+	fs.IntVar(&cfg.Bulkheads.MarketData, "bulkhead-market-data", cfg.Bulkheads.MarketData, "concurrent market data read requests, 0 is unlimited")
+	fs.DurationVar(&cfg.Bulkheads.Wait, "bulkhead-wait", cfg.Bulkheads.Wait, "how long a request may wait for a free bulkhead slot")
+
+	fs.BoolVar(&cfg.OrderLimits.Enabled, "order-limits-enabled", cfg.OrderLimits.Enabled, "rate limit order entry per account, adapting to reject and cancel ratios")
+	fs.Float64Var(&cfg.OrderLimits.Rate, "order-limits-rate", cfg.OrderLimits.Rate, "order entry requests per second per account before any tightening")
+	fs.IntVar(&cfg.OrderLimits.Burst, "order-limits-burst", cfg.OrderLimits.Burst, "order entry request burst per account before any tightening")
+	fs.DurationVar(&cfg.OrderLimits.Window, "order-limits-window", cfg.OrderLimits.Window, "how often an account's reject and cancel ratios are judged")
+	fs.IntVar(&cfg.OrderLimits.MinOrders, "order-limits-min-orders", cfg.OrderLimits.MinOrders, "orders a window needs before its ratios can tighten the limit")
+	fs.Float64Var(&cfg.OrderLimits.MaxRejectRatio, "order-limits-max-reject-ratio", cfg.OrderLimits.MaxRejectRatio, "refused orders per order sent above which the limit is tightened")
+	fs.Float64Var(&cfg.OrderLimits.MaxCancelRatio, "order-limits-max-cancel-ratio", cfg.OrderLimits.MaxCancelRatio, "cancels per order sent above which the limit is tightened")
+	fs.Float64Var(&cfg.OrderLimits.TightenFactor, "order-limits-tighten-factor", cfg.OrderLimits.TightenFactor, "factor the limit is multiplied by after a misbehaving window")
+	fs.Float64Var(&cfg.OrderLimits.MinFactor, "order-limits-min-factor", cfg.OrderLimits.MinFactor, "smallest fraction of the configured limit an account can be tightened to")
+	fs.Float64Var(&cfg.OrderLimits.RelaxStep, "order-limits-relax-step", cfg.OrderLimits.RelaxStep, "fraction of the configured limit restored after each well-behaved window")
+
+	fs.BoolVar(&cfg.RateLimits.Enabled, "rate-limits-enabled", cfg.RateLimits.Enabled, "rate limit order entry and cancels per API key and per client IP")
+	fs.Float64Var(&cfg.RateLimits.KeyRate, "rate-limits-key-rate", cfg.RateLimits.KeyRate, "order entry and cancel requests per second per API key")
+	fs.IntVar(&cfg.RateLimits.KeyBurst, "rate-limits-key-burst", cfg.RateLimits.KeyBurst, "order entry and cancel request burst per API key")
+	fs.Float64Var(&cfg.RateLimits.IPRate, "rate-limits-ip-rate", cfg.RateLimits.IPRate, "order entry and cancel requests per second per client IP")
+	fs.IntVar(&cfg.RateLimits.IPBurst, "rate-limits-ip-burst", cfg.RateLimits.IPBurst, "order entry and cancel request burst per client IP")
+
+	fs.BoolVar(&cfg.Leaderboard.Enabled, "leaderboard-enabled", cfg.Leaderboard.Enabled, "rank accounts by traded notional per symbol and day")
+	fs.IntVar(&cfg.Leaderboard.Days, "leaderboard-days", cfg.Leaderboard.Days, "days of leaderboard standings kept")
+
+	fs.BoolVar(&cfg.Kafka.Enabled, "kafka-enabled", cfg.Kafka.Enabled, "publish order events and trades to Kafka")
+	fs.Var(&cfg.Kafka.Brokers, "kafka-brokers", "comma-separated Kafka bootstrap brokers")
+	fs.StringVar(&cfg.Kafka.ClientID, "kafka-client-id", cfg.Kafka.ClientID, "client ID sent to Kafka brokers")
+	fs.StringVar(&cfg.Kafka.OrderTopic, "kafka-order-topic", cfg.Kafka.OrderTopic, "Kafka topic of order lifecycle events")
+	fs.StringVar(&cfg.Kafka.TradeTopic, "kafka-trade-topic", cfg.Kafka.TradeTopic, "Kafka topic of executed trades")
+	fs.IntVar(&cfg.Kafka.BatchSize, "kafka-batch-size", cfg.Kafka.BatchSize, "maximum order events or trades read from a shard per publish")
+	fs.DurationVar(&cfg.Kafka.PollInterval, "kafka-poll-interval", cfg.Kafka.PollInterval, "how often the Kafka publisher checks for new order events and trades")
+	fs.DurationVar(&cfg.Kafka.Timeout, "kafka-timeout", cfg.Kafka.Timeout, "Kafka broker dial, write and acknowledgement timeout")
+
+	fs.BoolVar(&cfg.Signing.Required, "signing-required", cfg.Signing.Required, "reject order entry and cancel requests without an HMAC signature")
+	fs.DurationVar(&cfg.Signing.Window, "signing-window", cfg.Signing.Window, "how far a signed request's timestamp may be from the server clock")
+
+	fs.StringVar(&cfg.Auth.JWTSecret, "auth-jwt-secret", cfg.Auth.JWTSecret, "HS256 key of JWT bearer tokens, empty disables them and leaves admin routes to admin signing keys")
+	fs.StringVar(&cfg.Auth.Issuer, "auth-issuer", cfg.Auth.Issuer, "iss claim JWT bearer tokens must carry, empty accepts any")
+
+	fs.StringVar(&cfg.Encryption.KeyID, "encryption-key-id", cfg.Encryption.KeyID, "ID of the master key sensitive fields are sealed under, empty stores them unencrypted")
+	fs.Var(&cfg.Encryption.Keys, "encryption-keys", "comma-separated ID:BASE64 master keys, the current one and any still opening older values")
+
+	fs.BoolVar(&cfg.Webhooks.Enabled, "webhooks-enabled", cfg.Webhooks.Enabled, "post order fill and cancel notifications to registered webhooks")
+	fs.DurationVar(&cfg.Webhooks.PollInterval, "webhooks-poll-interval", cfg.Webhooks.PollInterval, "how often new order events and due webhook deliveries are checked")
+	fs.IntVar(&cfg.Webhooks.BatchSize, "webhooks-batch-size", cfg.Webhooks.BatchSize, "maximum order events or webhook deliveries handled per pass")
+	fs.DurationVar(&cfg.Webhooks.Timeout, "webhooks-timeout", cfg.Webhooks.Timeout, "timeout of a webhook delivery attempt")
+	fs.IntVar(&cfg.Webhooks.MaxAttempts, "webhooks-max-attempts", cfg.Webhooks.MaxAttempts, "attempts before a webhook delivery is given up")
+	fs.DurationVar(&cfg.Webhooks.RetryBackoff, "webhooks-retry-backoff", cfg.Webhooks.RetryBackoff, "wait after a failed webhook delivery, doubled after each further failure")
+	fs.DurationVar(&cfg.Webhooks.MaxBackoff, "webhooks-max-backoff", cfg.Webhooks.MaxBackoff, "longest wait between webhook delivery attempts")
+	fs.BoolVar(&cfg.Archive.Enabled, "archive-enabled", cfg.Archive.Enabled, "write daily trade and order event archive files")
+	fs.StringVar(&cfg.Archive.Dir, "archive-dir", cfg.Archive.Dir, "directory archive files are written to when no S3 bucket is set")
+	fs.DurationVar(&cfg.Archive.Interval, "archive-interval", cfg.Archive.Interval, "how often completed days are checked for missing archive files")
+	fs.IntVar(&cfg.Archive.BackfillDays, "archive-backfill-days", cfg.Archive.BackfillDays, "how many completed days back missing archive files are written")
+	fs.IntVar(&cfg.Archive.BatchSize, "archive-batch-size", cfg.Archive.BatchSize, "maximum trades or order events read from a shard per archive query")
+	fs.StringVar(&cfg.Archive.S3.Endpoint, "archive-s3-endpoint", cfg.Archive.S3.Endpoint, "S3-compatible endpoint URL of the archive bucket")
+	fs.StringVar(&cfg.Archive.S3.Region, "archive-s3-region", cfg.Archive.S3.Region, "region requests to the archive bucket are signed for")
+	fs.StringVar(&cfg.Archive.S3.Bucket, "archive-s3-bucket", cfg.Archive.S3.Bucket, "archive bucket, empty to write to archive-dir")
+	fs.StringVar(&cfg.Archive.S3.Prefix, "archive-s3-prefix", cfg.Archive.S3.Prefix, "prefix of archive object names")
+	fs.StringVar(&cfg.Archive.S3.AccessKey, "archive-s3-access-key", cfg.Archive.S3.AccessKey, "access key ID of the archive bucket")
+	fs.StringVar(&cfg.Archive.S3.SecretKey, "archive-s3-secret-key", cfg.Archive.S3.SecretKey, "secret access key of the archive bucket")
+	fs.DurationVar(&cfg.Archive.S3.Timeout, "archive-s3-timeout", cfg.Archive.S3.Timeout, "timeout of an archive bucket request")
+	fs.BoolVar(&cfg.Audit.Enabled, "audit-enabled", cfg.Audit.Enabled, "write a hash-chained summary of every completed trading day")
+	fs.DurationVar(&cfg.Audit.Interval, "audit-interval", cfg.Audit.Interval, "how often completed days are checked for a missing daily summary")
+	fs.IntVar(&cfg.Audit.BatchSize, "audit-batch-size", cfg.Audit.BatchSize, "maximum trades or ledger entries read from a shard per daily summary query")
+	fs.BoolVar(&cfg.Candles.Enabled, "candles-enabled", cfg.Candles.Enabled, "aggregate trades into OHLCV candles")
+	fs.DurationVar(&cfg.Candles.PollInterval, "candles-poll-interval", cfg.Candles.PollInterval, "how often the candle aggregator checks for new trades")
+	fs.IntVar(&cfg.Candles.BatchSize, "candles-batch-size", cfg.Candles.BatchSize, "maximum trades read from a shard per candle aggregation pass")
+	fs.BoolVar(&cfg.Sandbox.Enabled, "sandbox-enabled", cfg.Sandbox.Enabled, "run as a paper-trading venue with simulated latency, partial fills and queue position")
+	fs.DurationVar(&cfg.Sandbox.AckLatency, "sandbox-ack-latency", cfg.Sandbox.AckLatency, "delay before each new sandbox order reaches matching")
+	fs.DurationVar(&cfg.Sandbox.AckJitter, "sandbox-ack-jitter", cfg.Sandbox.AckJitter, "most random delay added to sandbox-ack-latency")
+	fs.Float64Var(&cfg.Sandbox.PartialFillRate, "sandbox-partial-fill-rate", cfg.Sandbox.PartialFillRate, "probability a sandbox fill is cut short, between 0 and 1")
+	fs.Float64Var(&cfg.Sandbox.QueueAhead, "sandbox-queue-ahead", cfg.Sandbox.QueueAhead, "simulated interest ahead of a resting sandbox order, as a multiple of the quantity resting before it")
+	fs.DurationVar(&cfg.Sandbox.QueueHalfLife, "sandbox-queue-half-life", cfg.Sandbox.QueueHalfLife, "half-life of the simulated queue ahead of resting sandbox orders, 0 keeps it until traded through")
+	fs.IntVar(&cfg.LastLook.MinRequests, "last-look-min-requests", cfg.LastLook.MinRequests, "last looks a provider must answer in a period before its reject ratio is judged")
+	fs.Float64Var(&cfg.LastLook.MaxRejectRatio, "last-look-max-reject-ratio", cfg.LastLook.MaxRejectRatio, "last look reject ratio above which a provider is flagged")
+	fs.DurationVar(&cfg.LastLook.Period, "last-look-period", cfg.LastLook.Period, "default period last look surveillance reports on")
+
+	fs.StringVar(&cfg.Seed.SymbolsFile, "seed-symbols-file", cfg.Seed.SymbolsFile, "JSON symbol registry listed by cmd/seed before seeding")
+	fs.IntVar(&cfg.Seed.Accounts, "seed-accounts", cfg.Seed.Accounts, "demo accounts created by cmd/seed, 0 for anonymous orders")
+	fs.Float64Var(&cfg.Seed.Deposit, "seed-deposit", cfg.Seed.Deposit, "quote currency value each demo account holds in every currency")
+	fs.IntVar(&cfg.Seed.Levels, "seed-levels", cfg.Seed.Levels, "price levels seeded on each side of a book")
+	fs.IntVar(&cfg.Seed.OrdersPerLevel, "seed-orders-per-level", cfg.Seed.OrdersPerLevel, "most resting orders seeded at a price level")
+	fs.Float64Var(&cfg.Seed.Spread, "seed-spread", cfg.Seed.Spread, "seeded best bid to best ask distance as a fraction of the mid price")
+	fs.Float64Var(&cfg.Seed.LevelSpacing, "seed-level-spacing", cfg.Seed.LevelSpacing, "distance between seeded price levels as a fraction of the mid price")
+	fs.Float64Var(&cfg.Seed.OrderNotional, "seed-order-notional", cfg.Seed.OrderNotional, "mean notional of a seeded order at the touch")
+	fs.Float64Var(&cfg.Seed.DepthGrowth, "seed-depth-growth", cfg.Seed.DepthGrowth, "factor the mean seeded order notional grows by per level away from the touch")
+	fs.Float64Var(&cfg.Seed.Jitter, "seed-jitter", cfg.Seed.Jitter, "fraction seeded order sizes vary by around the mean")
+	fs.Float64Var(&cfg.Seed.TickSize, "seed-tick-size", cfg.Seed.TickSize, "price increment of seeded orders")
+	fs.Float64Var(&cfg.Seed.LotSize, "seed-lot-size", cfg.Seed.LotSize, "quantity increment of seeded orders")
+	fs.Int64Var(&cfg.Seed.RandomSeed, "seed-random-seed", cfg.Seed.RandomSeed, "random seed of the seeded market, 0 for a different one every run")
+	return fs
+}
+
+// envName returns the environment variable bound to a flag
+func envName(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Load builds the configuration from defaults, an optional YAML file, environment
+// variables and command line flags, later sources taking precedence
+func Load(logger *zap.Logger, args []string) (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		logger.Warn("Failed to load .env file, using default env variable")
-	}	
+	}
+
+	cfg := defaults()
+	var configFile string
+	fs := newFlagSet(&cfg, &configFile)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if configFile == "" {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
 
-	cfg := &Config{
-		DatabaseDSN: os.Getenv("DB_DSN"),
-		ServerAddr:  os.Getenv("SERVER_ADDR"),
+	// Reset to defaults so the file, env and flags are applied in precedence order
+	cfg = defaults()
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", configFile, err)
+		}
 	}
-	if cfg.DatabaseDSN == "" {
-		cfg.DatabaseDSN="user:password@tcp(localhost:3306)/order_matching?parseTime=true"
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		if v, ok := os.LookupEnv(envName(f.Name)); ok {
+			if err := f.Value.Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %v", envName(f.Name), err))
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
-	if cfg.ServerAddr == "" {
-		cfg.ServerAddr = ":8080"
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	return cfg, nil
+	return &cfg, nil
 }
 
+// Validate reports every invalid setting or combination of settings
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Database.Storage == StorageMySQL || c.Database.Storage == StorageSQLite,
+		"database.storage must be %q or %q", StorageMySQL, StorageSQLite)
+	check(c.Database.Storage != StorageSQLite || c.Database.SQLitePath != "", "database.sqlite_path is required with storage %q", StorageSQLite)
+	check(c.Database.Storage != StorageSQLite || len(c.Database.ShardDSNs) == 0,
+		"database.shard_dsns need storage %q, as SQLite keeps one database file", StorageMySQL)
+	check(c.Database.DSN != "", "database.dsn is required")
+	for i, dsn := range c.Database.ShardDSNs {
+		check(dsn != c.Database.DSN, "database.shard_dsns[%d] duplicates database.dsn", i)
+	}
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+	check(c.Database.DialTimeout >= 0, "database.dial_timeout must not be negative")
+	check(c.Database.QueryTimeout >= 0, "database.query_timeout must not be negative")
+	check(c.Database.SlowQueryThreshold >= 0, "database.slow_query_threshold must not be negative")
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies entries must be IP addresses or CIDR prefixes")
+	}
+	check(c.Server.RequestTimeout >= 0 && c.Server.RequestTimeout < c.Server.WriteTimeout,
+		"server.request_timeout must not be negative and shorter than server.write_timeout")
+
+	check(c.Engine.DepthSnapshotInterval >= 0, "engine.depth_snapshot_interval must not be negative")
+	check(c.Engine.BookSnapshotInterval >= 0, "engine.book_snapshot_interval must not be negative")
+	check(c.Engine.DepthSnapshotInterval == 0 || c.Engine.DepthSnapshotLevels > 0,
+		"engine.depth_snapshot_levels must be positive when depth snapshots are enabled")
+	check(c.Engine.ImbalanceRatio == 0 || c.Engine.ImbalanceRatio > 1, "engine.imbalance_ratio must be greater than 1 or 0 to disable")
+	if c.Engine.ImbalanceRatio > 0 {
+		check(c.Engine.ImbalanceLevels > 0, "engine.imbalance_levels must be positive when imbalance auctions are enabled")
+		check(c.Engine.ImbalanceWindow >= 0, "engine.imbalance_window must not be negative")
+		check(c.Engine.VolatilityAuctionDuration > 0, "engine.volatility_auction_duration must be positive when imbalance auctions are enabled")
+	}
+
+	check(c.Engine.SettlementInterval > 0, "engine.settlement_interval must be positive")
+	check(c.Engine.MaxWalkLevels >= 0, "engine.max_walk_levels must not be negative")
+	check(c.Engine.MaxWalkFills >= 0, "engine.max_walk_fills must not be negative")
+	check(c.Engine.WalkCapAction == WalkCapCancel || c.Engine.WalkCapAction == WalkCapRest,
+		"engine.walk_cap_action must be %q or %q", WalkCapCancel, WalkCapRest)
+	check(c.Engine.DeadlockRetries >= 0, "engine.deadlock_retries must not be negative")
+	check(c.Engine.DeadlockBackoff >= 0, "engine.deadlock_backoff must not be negative")
+	check(c.Engine.StaleOrderSweepInterval >= 0, "engine.stale_order_sweep_interval must not be negative")
+	check(c.Engine.ExpirySweepInterval >= 0, "engine.expiry_sweep_interval must not be negative")
+	check(c.Engine.FlowRetention >= time.Minute, "engine.flow_retention must be at least 1m")
+	check(c.Engine.IdleEngineTTL >= 0, "engine.idle_engine_ttl must not be negative")
+	check(c.Engine.NodeID >= 0 && c.Engine.NodeID <= ids.MaxNode, "engine.node_id must be between 0 and %d", ids.MaxNode)
+	if c.Engine.WriteBehind {
+		check(c.Engine.WriteBehindDir != "", "engine.write_behind_dir must be set when write-behind is on")
+		check(c.Risk.CreditCheck == CreditNone,
+			"engine.write_behind cannot be used with risk.credit_check %q, which reads balances the queued writes have not reached yet", c.Risk.CreditCheck)
+	}
+	for symbol, sc := range c.Symbols {
+		check(sc.BaseCurrency != "" && sc.QuoteCurrency != "", "symbols.%s requires base_currency and quote_currency", symbol)
+		check(sc.BaseCurrency != sc.QuoteCurrency, "symbols.%s base_currency and quote_currency must differ", symbol)
+		check(sc.SettlementLag >= 0, "symbols.%s.settlement_lag must not be negative", symbol)
+		check(sc.TickSize == 0 || ValidTickSize(sc.TickSize), "symbols.%s.tick_size must be 0 or a positive multiple of 0.00000001", symbol)
+		check(sc.LotSize == 0 || ValidTickSize(sc.LotSize), "symbols.%s.lot_size must be 0 or a positive multiple of 0.00000001", symbol)
+		check(sc.StaleOrderTTL >= 0, "symbols.%s.stale_order_ttl must not be negative", symbol)
+		check(sc.LastLookWindow >= 0 && sc.LastLookWindow < c.Server.WriteTimeout,
+			"symbols.%s.last_look_window must not be negative and shorter than server.write_timeout", symbol)
+		check(sc.LastLookWindow == 0 || len(sc.LastLookProviders) > 0,
+			"symbols.%s.last_look_window requires last_look_providers", symbol)
+		check(sc.PriceBand >= 0, "symbols.%s.price_band must not be negative", symbol)
+		check(sc.CircuitBreakerMove >= 0, "symbols.%s.circuit_breaker_move must not be negative", symbol)
+		check(sc.CircuitBreakerMove == 0 || sc.CircuitBreakerWindow > 0,
+			"symbols.%s.circuit_breaker_move requires a positive circuit_breaker_window", symbol)
+		for _, start := range sc.CallAuctions {
+			check(start >= 0 && start < 24*time.Hour, "symbols.%s.call_auctions must be times of day between 0s and 24h", symbol)
+		}
+		check(len(sc.CallAuctions) == 0 || sc.CallAuctionDuration > 0 && sc.CallAuctionDuration < 24*time.Hour,
+			"symbols.%s.call_auctions requires a call_auction_duration between 0s and 24h", symbol)
+	}
+
+	check(c.Fees.BillingInterval > 0, "fees.billing_interval must be positive")
+	check(c.Fees.TakerRate >= 0 && c.Fees.TakerRate < 1, "fees.taker_rate must be in [0, 1)")
+	check(c.Fees.MakerRate > -1 && c.Fees.MakerRate < 1, "fees.maker_rate must be in (-1, 1)")
+	check(c.Fees.MakerRate+c.Fees.TakerRate >= 0,
+		"fees.maker_rate rebate (%g) must not exceed fees.taker_rate (%g)", c.Fees.MakerRate, c.Fees.TakerRate)
+
+	check(c.Risk.MaxOrderQuantity >= 0, "risk.max_order_quantity must not be negative")
+	check(c.Risk.MaxOrderNotional >= 0, "risk.max_order_notional must not be negative")
+	check(c.Risk.CreditCheck == CreditNone || c.Risk.CreditCheck == CreditBalances || c.Risk.CreditCheck == CreditCollateral ||
+		c.Risk.CreditCheck == CreditHolds,
+		"risk.credit_check must be %q, %q, %q or %q", CreditNone, CreditBalances, CreditCollateral, CreditHolds)
+	check(c.Risk.CreditCheck != CreditHolds || len(c.Database.ShardDSNs) == 0,
+		"risk.credit_check %q needs a single database, as holds are checked in one transaction", CreditHolds)
+	check(c.Risk.CreditTimeout > 0 && c.Risk.CreditTimeout < c.Server.WriteTimeout,
+		"risk.credit_timeout must be positive and shorter than server.write_timeout")
+	check(c.Risk.ReportingCurrency != "", "risk.reporting_currency is required")
+	check(c.Risk.DefaultHaircut >= 0 && c.Risk.DefaultHaircut <= 1, "risk.default_haircut must be between 0 and 1")
+	for currency, haircut := range c.Risk.Haircuts {
+		check(haircut >= 0 && haircut <= 1, "risk.haircuts.%s must be between 0 and 1", currency)
+	}
+	check(c.Risk.BlockPriceBand >= 0 && c.Risk.BlockPriceBand < 1, "risk.block_price_band must be between 0 and 1")
+	check(c.Risk.BlockMinQuantity >= 0, "risk.block_min_quantity must not be negative")
+	check(c.Risk.BlockAffirmTTL > 0, "risk.block_affirm_ttl must be positive")
+
+	check(!c.Streaming.Enabled || c.Streaming.BufferSize > 0,
+		"streaming.buffer_size must be positive when streaming is enabled")
+	check(!c.Streaming.Enabled || c.Streaming.ListenKeyTTL > 0,
+		"streaming.listen_key_ttl must be positive when streaming is enabled")
+	check(!c.Streaming.Enabled || c.Streaming.PingInterval > 0,
+		"streaming.ping_interval must be positive when streaming is enabled")
+	check(!c.Streaming.Enabled || c.Streaming.IdleTimeout > c.Streaming.PingInterval,
+		"streaming.idle_timeout must be longer than streaming.ping_interval")
+	check(c.Streaming.MaxConnections >= 0, "streaming.max_connections must not be negative")
+	check(c.Streaming.MaxSubscriptions >= 0, "streaming.max_subscriptions must not be negative")
+	check(c.Streaming.ResumeTTL >= 0, "streaming.resume_ttl must not be negative")
+
+	check(c.Public.CacheTTL >= 0, "public.cache_ttl must not be negative")
+	check(c.Public.RateLimit > 0, "public.rate_limit must be positive")
+	check(c.Public.RateBurst > 0, "public.rate_burst must be positive")
+	check(!c.Public.AnonymizeOrders || c.Public.AliasRotation > 0,
+		"public.alias_rotation must be positive when public.anonymize_orders is set")
+
+	check(c.Compliance.MaxWait >= 0 && c.Compliance.MaxWait < c.Server.WriteTimeout,
+		"compliance.max_wait must not be negative and must be shorter than server.write_timeout")
+	check(c.Compliance.PollInterval > 0, "compliance.poll_interval must be positive")
+	check(c.Compliance.BatchSize > 0, "compliance.batch_size must be positive")
+
+	check(c.Quality.SampleInterval >= 0, "quality.sample_interval must not be negative")
+	check(c.Quality.DepthBand > 0 && c.Quality.DepthBand < 1, "quality.depth_band must be between 0 and 1")
+	check(c.Quality.MaxSpreadBps >= 0, "quality.max_spread_bps must not be negative")
+	check(c.Quality.MinDepthNotional >= 0, "quality.min_depth_notional must not be negative")
+
+	check(c.OrderLimits.Rate > 0 && c.OrderLimits.Burst > 0, "order_limits.rate and order_limits.burst must be positive")
+	check(c.OrderLimits.Window > 0, "order_limits.window must be positive")
+	check(c.OrderLimits.MinOrders >= 0, "order_limits.min_orders must not be negative")
+	check(c.OrderLimits.MaxRejectRatio >= 0 && c.OrderLimits.MaxCancelRatio >= 0,
+		"order_limits.max_reject_ratio and order_limits.max_cancel_ratio must not be negative")
+	check(c.OrderLimits.MinFactor > 0 && c.OrderLimits.MinFactor <= 1, "order_limits.min_factor must be in (0, 1]")
+	check(c.OrderLimits.TightenFactor > 0 && c.OrderLimits.TightenFactor < 1, "order_limits.tighten_factor must be in (0, 1)")
+	check(c.OrderLimits.RelaxStep > 0, "order_limits.relax_step must be positive")
+
+	check(c.RateLimits.KeyRate > 0 && c.RateLimits.KeyBurst > 0, "rate_limits.key_rate and rate_limits.key_burst must be positive")
+	check(c.RateLimits.IPRate > 0 && c.RateLimits.IPBurst > 0, "rate_limits.ip_rate and rate_limits.ip_burst must be positive")
+
+	check(c.Bulkheads.OrderEntry >= 0, "bulkheads.order_entry must not be negative")
+	check(c.Bulkheads.Cancels >= 0, "bulkheads.cancels must not be negative")
+	check(c.Bulkheads.MarketData >= 0, "bulkheads.market_data must not be negative")
+	check(c.Bulkheads.Wait >= 0 && c.Bulkheads.Wait < c.Server.WriteTimeout,
+		"bulkheads.wait must not be negative and must be shorter than server.write_timeout")
+
+	check(!c.Leaderboard.Enabled || c.Leaderboard.Days > 0, "leaderboard.days must be positive when the leaderboard is enabled")
+
+	check(!c.Kafka.Enabled || len(c.Kafka.Brokers) > 0, "kafka.brokers must not be empty when kafka is enabled")
+	check(!c.Kafka.Enabled || c.Kafka.OrderTopic != "" && c.Kafka.TradeTopic != "",
+		"kafka.order_topic and kafka.trade_topic must be set when kafka is enabled")
+	check(c.Kafka.BatchSize > 0, "kafka.batch_size must be positive")
+	check(c.Kafka.PollInterval > 0, "kafka.poll_interval must be positive")
+	check(c.Kafka.Timeout > 0, "kafka.timeout must be positive")
+
+	check(c.Signing.Window > 0, "signing.window must be positive")
+
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret must be at least 32 bytes")
+
+	if _, err := crypto.NewKeyring(c.Encryption.KeyID, c.Encryption.Keys); err != nil {
+		check(false, "encryption: %v", err)
+	}
+
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval must be positive")
+	check(c.Webhooks.BatchSize > 0, "webhooks.batch_size must be positive")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive")
+	check(c.Webhooks.RetryBackoff > 0 && c.Webhooks.MaxBackoff >= c.Webhooks.RetryBackoff,
+		"webhooks.retry_backoff must be positive and no longer than webhooks.max_backoff")
+
+	check(c.Archive.S3.Bucket != "" || c.Archive.Dir != "", "archive.dir must be set when archive.s3.bucket is not")
+	check(c.Archive.S3.Bucket == "" || c.Archive.S3.Endpoint != "" && c.Archive.S3.Region != "",
+		"archive.s3.endpoint and archive.s3.region must be set when archive.s3.bucket is")
+	check(c.Archive.Interval > 0, "archive.interval must be positive")
+	check(c.Archive.BackfillDays > 0, "archive.backfill_days must be positive")
+	check(c.Archive.BatchSize > 0, "archive.batch_size must be positive")
+	check(c.Archive.S3.Timeout > 0, "archive.s3.timeout must be positive")
+	check(c.Audit.Interval > 0, "audit.interval must be positive")
+	check(c.Audit.BatchSize > 0, "audit.batch_size must be positive")
+
+	check(c.Candles.PollInterval > 0, "candles.poll_interval must be positive")
+	check(c.Candles.BatchSize > 0, "candles.batch_size must be positive")
+
+	check(ValidSandbox(c.Sandbox), "sandbox latencies, queue_ahead and queue_half_life must not be negative and partial_fill_rate must be between 0 and 1")
+
+	check(c.LastLook.MinRequests >= 0, "last_look.min_requests must not be negative")
+	check(c.LastLook.MaxRejectRatio >= 0 && c.LastLook.MaxRejectRatio <= 1, "last_look.max_reject_ratio must be between 0 and 1")
+	check(c.LastLook.Period > 0, "last_look.period must be positive")
+
+	for symbol, mid := range c.Seed.Prices {
+		check(mid > 0, "seed.prices.%s must be positive", symbol)
+	}
+	check(c.Seed.Accounts >= 0, "seed.accounts must not be negative")
+	check(c.Seed.Deposit >= 0, "seed.deposit must not be negative")
+	check(c.Seed.Levels > 0, "seed.levels must be positive")
+	check(c.Seed.OrdersPerLevel > 0, "seed.orders_per_level must be positive")
+	check(c.Seed.Spread > 0 && c.Seed.Spread < 1, "seed.spread must be between 0 and 1")
+	check(c.Seed.LevelSpacing > 0 && c.Seed.Spread/2+c.Seed.LevelSpacing*float64(c.Seed.Levels-1) < 1,
+		"seed.level_spacing must be positive and keep every seeded bid above zero")
+	check(c.Seed.OrderNotional > 0, "seed.order_notional must be positive")
+	check(c.Seed.DepthGrowth > 0, "seed.depth_growth must be positive")
+	check(c.Seed.Jitter >= 0 && c.Seed.Jitter < 1, "seed.jitter must be in [0, 1)")
+	check(c.Seed.TickSize >= 1e-8, "seed.tick_size must be at least 0.00000001")
+	check(c.Seed.LotSize >= 1e-8, "seed.lot_size must be at least 0.00000001")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
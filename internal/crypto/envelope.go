@@ -0,0 +1,212 @@
+// Package crypto seals sensitive fields for storage with envelope encryption: each value is
+// encrypted with AES-256-GCM under a data key of its own, and the data key is stored alongside it
+// wrapped by a master key. Master keys are configured by ID, from the environment or a KMS's
+// output, so rotating to a new one only rewraps the data keys.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of master and data keys in bytes
+const KeySize = 32
+
+// prefix starts every sealed value, telling it from a plain one stored before encryption was on
+const prefix = "enc:v1:"
+
+// Errors opening sealed values
+var (
+	ErrUnknownKey = errors.New("sealed with a master key that is not configured")
+	ErrCorrupt    = errors.New("sealed value is corrupt or belongs to another field")
+)
+
+// Keyring seals values under its current master key and opens values sealed under any of its
+// keys. A nil keyring has no keys: it stores values as they are and cannot open sealed ones.
+// It is safe for concurrent use.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring from master keys given as ID:BASE64 entries, sealing under the
+// key with the current ID. No current ID and no keys give a nil keyring.
+func NewKeyring(current string, entries []string) (*Keyring, error) {
+	if current == "" && len(entries) == 0 {
+		return nil, nil
+	}
+	k := &Keyring{current: current, keys: make(map[string]cipher.AEAD)}
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || strings.ContainsRune(id, ':') {
+			return nil, fmt.Errorf("master key entry must be ID:BASE64")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != KeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes in base64", id, KeySize)
+		}
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("master key %q is given twice", id)
+		}
+		if k.keys[id], err = newAEAD(key); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := k.keys[current]; !ok {
+		return nil, fmt.Errorf("current master key %q is not among the keys", current)
+	}
+	return k, nil
+}
+
+// KeyID returns the ID of the master key values are sealed under, empty for a nil keyring
+func (k *Keyring) KeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.current
+}
+
+// Seal encrypts a value under a new data key wrapped by the current master key. The field names
+// what the value is, such as a table, column and row, and must be given again to open it, so a
+// sealed value copied into another field does not open. A nil keyring returns the value as is.
+func (k *Keyring) Seal(value, field string) (string, error) {
+	if k == nil {
+		return value, nil
+	}
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(data, []byte(value), field)
+	if err != nil {
+		return "", err
+	}
+	return k.wrap(dataKey, field, ciphertext)
+}
+
+// Open decrypts a sealed value of a field. A value that was never sealed is returned as is, so
+// rows written before encryption was turned on stay readable until they are sealed.
+func (k *Keyring) Open(value, field string) (string, error) {
+	if !Sealed(value) {
+		return value, nil
+	}
+	dataKey, ciphertext, err := k.unwrap(value, field)
+	if err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, ciphertext, field)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap returns a field's value sealed under the current master key, reporting whether it
+// changed. A value sealed under an older key keeps its data key and ciphertext, only the data
+// key being wrapped again; a value never sealed is sealed. A nil keyring changes nothing.
+func (k *Keyring) Rewrap(value, field string) (string, bool, error) {
+	if k == nil {
+		return value, false, nil
+	}
+	if !Sealed(value) {
+		sealed, err := k.Seal(value, field)
+		return sealed, err == nil, err
+	}
+	if keyID(value) == k.current {
+		return value, false, nil
+	}
+	dataKey, ciphertext, err := k.unwrap(value, field)
+	if err != nil {
+		return "", false, err
+	}
+	rewrapped, err := k.wrap(dataKey, field, ciphertext)
+	return rewrapped, err == nil, err
+}
+
+// Sealed reports whether a stored value was sealed by a keyring
+func Sealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// wrap encodes a sealed value: the current key's ID, the data key wrapped by it and the value's
+// ciphertext under the data key
+func (k *Keyring) wrap(dataKey []byte, field string, ciphertext []byte) (string, error) {
+	wrapped, err := seal(k.keys[k.current], dataKey, field)
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.current + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// unwrap decodes a sealed value, returning its data key and ciphertext
+func (k *Keyring) unwrap(value, field string) ([]byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return nil, nil, ErrCorrupt
+	}
+	if k == nil || k.keys[parts[0]] == nil {
+		return nil, nil, ErrUnknownKey
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, ErrCorrupt
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, ErrCorrupt
+	}
+	dataKey, err := open(k.keys[parts[0]], wrapped, field)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, ciphertext, nil
+}
+
+// keyID returns the ID of the master key a sealed value's data key is wrapped by
+func keyID(value string) string {
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under a random nonce, which it prepends, authenticating the field
+func seal(aead cipher.AEAD, plaintext []byte, field string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(field)), nil
+}
+
+// open decrypts what seal returned for the same field
+func open(aead cipher.AEAD, sealed []byte, field string) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrCorrupt
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(field))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,52 @@
+// Package ids generates the exchange's order and trade IDs as 64-bit snowflake IDs: a
+// millisecond timestamp, the node that generated the ID and a sequence within the millisecond.
+// IDs of one node only ever increase and IDs of different nodes never collide.
+package ids
+
+import (
+	"sync"
+	"time"
+)
+
+// Bit widths of an ID's node and sequence; the timestamp takes the 41 bits above them
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// epoch is the time ID timestamps count milliseconds from
+var epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Generator generates the IDs of one node. It is safe for concurrent use.
+type Generator struct {
+	node uint64
+
+	// Timestamp and sequence of the last ID, guarded by mutex
+	last     int64
+	sequence uint64
+	mutex    sync.Mutex
+}
+
+// NewGenerator creates a generator for a node between 0 and MaxNode
+func NewGenerator(node int) *Generator {
+	return &Generator{node: uint64(node) & MaxNode}
+}
+
+// Next returns a new ID, greater than every ID the generator returned before. Once a
+// millisecond's sequence runs out, and while the clock is behind the last ID, IDs carry on
+// from the last one rather than wait for the clock.
+func (g *Generator) Next() uint64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := time.Since(epoch).Milliseconds()
+	if now > g.last {
+		g.last, g.sequence = now, 0
+	} else if g.sequence++; g.sequence > maxSequence {
+		g.last, g.sequence = g.last+1, 0
+	}
+	return uint64(g.last)<<(nodeBits+sequenceBits) | g.node<<sequenceBits | g.sequence
+}
@@ -0,0 +1,435 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"orderSystem/internal/config"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Kafka API keys and the versions used, supported by brokers from 1.0 on
+const (
+	apiProduce      int16 = 0
+	apiMetadata     int16 = 3
+	produceVersion  int16 = 3
+	metadataVersion int16 = 4
+
+	maxResponseSize = 64 << 20
+)
+
+var (
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+	errShortResponse = errors.New("kafka: truncated response")
+	errCorrelation   = errors.New("kafka: response does not match request")
+)
+
+// BrokerError is an error code returned by a broker, e.g. 6 when a partition's leader moved
+type BrokerError int16
+
+// Error implements error
+func (e BrokerError) Error() string {
+	return "kafka: broker error code " + strconv.Itoa(int(e))
+}
+
+// Message is a record to produce; messages with the same key go to the same partition
+type Message struct {
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// partition is a topic partition and the node ID of its leader, -1 while it has none
+type partition struct {
+	id     int32
+	leader int32
+}
+
+// Producer writes messages to Kafka topics over the broker wire protocol, waiting for all
+// in-sync replicas to acknowledge each request. It is not safe for concurrent use.
+type Producer struct {
+	cfg           config.KafkaConfig
+	brokers       map[int32]string // node ID -> host:port
+	topics        map[string][]partition
+	conns         map[int32]net.Conn
+	correlationID int32
+}
+
+// NewProducer creates a producer; brokers are contacted on the first Produce
+func NewProducer(cfg config.KafkaConfig) *Producer {
+	return &Producer{
+		cfg:     cfg,
+		brokers: make(map[int32]string),
+		topics:  make(map[string][]partition),
+		conns:   make(map[int32]net.Conn),
+	}
+}
+
+// Produce writes messages to a topic, partitioned by key, and returns once every partition
+// leader has acknowledged them. On an error some partitions may have been written, so a caller
+// retrying the same messages delivers them at least once.
+func (p *Producer) Produce(topic string, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	partitions, ok := p.topics[topic]
+	if !ok {
+		if err := p.refresh(topic); err != nil {
+			return err
+		}
+		partitions = p.topics[topic]
+	}
+
+	batches := make(map[int32]map[int32][]Message) // leader -> partition -> messages
+	for _, message := range messages {
+		part := partitions[partitionIndex(message.Key, len(partitions))]
+		if part.leader < 0 {
+			delete(p.topics, topic)
+			return fmt.Errorf("kafka: partition %s/%d has no leader", topic, part.id)
+		}
+		if batches[part.leader] == nil {
+			batches[part.leader] = make(map[int32][]Message)
+		}
+		batches[part.leader][part.id] = append(batches[part.leader][part.id], message)
+	}
+	for leader, batch := range batches {
+		if err := p.produce(leader, topic, batch); err != nil {
+			delete(p.topics, topic)
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes every broker connection
+func (p *Producer) Close() {
+	for id, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, id)
+	}
+}
+
+// partitionIndex maps a message key onto one of n partitions
+func partitionIndex(key []byte, n int) int {
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(n))
+}
+
+// produce sends one topic's batches for the partitions led by a broker
+func (p *Producer) produce(leader int32, topic string, batches map[int32][]Message) error {
+	conn, err := p.conn(leader)
+	if err != nil {
+		return err
+	}
+
+	var body encoder
+	body.int16(-1) // no transactional ID
+	body.int16(-1) // acks from all in-sync replicas
+	body.int32(int32(p.cfg.Timeout.Milliseconds()))
+	body.int32(1)
+	body.string(topic)
+	body.int32(int32(len(batches)))
+	for id, messages := range batches {
+		body.int32(id)
+		body.bytes(encodeBatch(messages))
+	}
+	response, err := p.roundTrip(conn, apiProduce, produceVersion, body.buf)
+	if err != nil {
+		p.disconnect(leader)
+		return err
+	}
+
+	d := decoder{buf: response}
+	for topics := d.int32(); topics > 0; topics-- {
+		d.string()
+		for parts := d.int32(); parts > 0; parts-- {
+			id, code := d.int32(), d.int16()
+			d.int64() // base offset
+			d.int64() // log append time
+			if code != 0 && d.err == nil {
+				return fmt.Errorf("kafka: produce to %s/%d: %w", topic, id, BrokerError(code))
+			}
+		}
+	}
+	return d.err
+}
+
+// refresh fetches a topic's partition leaders from the first reachable broker, asking it to
+// create the topic when the cluster allows automatic creation
+func (p *Producer) refresh(topic string) error {
+	var body encoder
+	body.int32(1)
+	body.string(topic)
+	body.int8(1) // allow auto topic creation
+
+	addrs := append([]string(nil), p.cfg.Brokers...)
+	for _, addr := range p.brokers {
+		addrs = append(addrs, addr)
+	}
+	err := errors.New("kafka: no brokers configured")
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = net.DialTimeout("tcp", addr, p.cfg.Timeout)
+		if err != nil {
+			continue
+		}
+		var response []byte
+		response, err = p.roundTrip(conn, apiMetadata, metadataVersion, body.buf)
+		conn.Close()
+		if err == nil {
+			return p.parseMetadata(topic, response)
+		}
+	}
+	return err
+}
+
+// parseMetadata records the brokers and the topic's partitions of a metadata response
+func (p *Producer) parseMetadata(topic string, response []byte) error {
+	d := decoder{buf: response}
+	d.int32() // throttle time
+	for brokers := d.int32(); brokers > 0; brokers-- {
+		id, host, port := d.int32(), d.string(), d.int32()
+		d.string() // rack
+		if d.err == nil {
+			p.brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+		}
+	}
+	d.string() // cluster ID
+	d.int32()  // controller ID
+
+	var partitions []partition
+	var code int16
+	for topics := d.int32(); topics > 0; topics-- {
+		topicCode, name := d.int16(), d.string()
+		d.int8() // internal
+		var parts []partition
+		for n := d.int32(); n > 0; n-- {
+			d.int16() // partition error; a leader, if any, still takes writes
+			id, leader := d.int32(), d.int32()
+			d.int32s() // replicas
+			d.int32s() // in-sync replicas
+			parts = append(parts, partition{id: id, leader: leader})
+		}
+		if name == topic {
+			partitions, code = parts, topicCode
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if code != 0 {
+		return fmt.Errorf("kafka: metadata of %s: %w", topic, BrokerError(code))
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("kafka: topic %s has no partitions", topic)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].id < partitions[j].id })
+	p.topics[topic] = partitions
+	return nil
+}
+
+// conn returns the connection to a broker, dialing it when there is none
+func (p *Producer) conn(id int32) (net.Conn, error) {
+	if conn, ok := p.conns[id]; ok {
+		return conn, nil
+	}
+	addr, ok := p.brokers[id]
+	if !ok {
+		return nil, fmt.Errorf("kafka: unknown broker %d", id)
+	}
+	conn, err := net.DialTimeout("tcp", addr, p.cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[id] = conn
+	return conn, nil
+}
+
+// disconnect drops a broker connection after a failed request
+func (p *Producer) disconnect(id int32) {
+	if conn, ok := p.conns[id]; ok {
+		conn.Close()
+		delete(p.conns, id)
+	}
+}
+
+// roundTrip sends a request and reads its response, returning the body after the header.
+// The I/O deadline leaves the broker the full timeout to gather acknowledgements.
+func (p *Producer) roundTrip(conn net.Conn, apiKey, version int16, body []byte) ([]byte, error) {
+	p.correlationID++
+	var request encoder
+	request.int32(int32(10 + len(p.cfg.ClientID) + len(body)))
+	request.int16(apiKey)
+	request.int16(version)
+	request.int32(p.correlationID)
+	request.string(p.cfg.ClientID)
+	request.buf = append(request.buf, body...)
+
+	if err := conn.SetDeadline(time.Now().Add(2 * p.cfg.Timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(request.buf); err != nil {
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > maxResponseSize {
+		return nil, errShortResponse
+	}
+	response := make([]byte, n)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	if int32(binary.BigEndian.Uint32(response)) != p.correlationID {
+		return nil, errCorrelation
+	}
+	return response[4:], nil
+}
+
+// encodeBatch encodes messages as an uncompressed v2 record batch
+func encodeBatch(messages []Message) []byte {
+	first := messages[0].Time.UnixMilli()
+	last := first
+	var records encoder
+	for i, message := range messages {
+		timestamp := message.Time.UnixMilli()
+		if timestamp > last {
+			last = timestamp
+		}
+		var record encoder
+		record.int8(0) // attributes
+		record.varint(timestamp - first)
+		record.varint(int64(i))
+		record.varbytes(message.Key)
+		record.varbytes(message.Value)
+		record.varint(0) // headers
+		records.varint(int64(len(record.buf)))
+		records.buf = append(records.buf, record.buf...)
+	}
+
+	// The checksum covers everything from the attributes on
+	var body encoder
+	body.int16(0) // attributes: no compression, create time
+	body.int32(int32(len(messages) - 1))
+	body.int64(first)
+	body.int64(last)
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(messages)))
+	body.buf = append(body.buf, records.buf...)
+
+	var batch encoder
+	batch.int64(0)                        // base offset, assigned by the broker
+	batch.int32(int32(9 + len(body.buf))) // length after this field
+	batch.int32(-1)                       // partition leader epoch
+	batch.int8(2)                         // magic
+	batch.int32(int32(crc32.Checksum(body.buf, castagnoli)))
+	batch.buf = append(batch.buf, body.buf...)
+	return batch.buf
+}
+
+// encoder appends values in the Kafka protocol encoding
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) int8(v int8)    { e.buf = append(e.buf, byte(v)) }
+func (e *encoder) int16(v int16)  { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *encoder) int32(v int32)  { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *encoder) int64(v int64)  { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+func (e *encoder) varint(v int64) { e.buf = binary.AppendVarint(e.buf, v) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// varbytes appends a record key or value, nil encoding as null
+func (e *encoder) varbytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// decoder reads values in the Kafka protocol encoding; after the first error every read
+// returns zero and err keeps that error
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err == nil && (n < 0 || n > len(d.buf)) {
+		d.err = errShortResponse
+	}
+	if d.err != nil {
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a string, a null one as empty
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// int32s skips an array of int32
+func (d *decoder) int32s() {
+	if n := d.int32(); n > 0 {
+		d.take(4 * int(n))
+	}
+}
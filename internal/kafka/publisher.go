@@ -0,0 +1,208 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"orderSystem/internal/compliance"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event cursor subscriber IDs under which the publisher records its progress
+const (
+	orderSubscriber = "kafka-orders"
+	tradeSubscriber = "kafka-trades"
+)
+
+// OrderEventMessage is the value of an order lifecycle event record
+type OrderEventMessage struct {
+	Shard             int                   `json:"shard"`
+	EventID           uint64                `json:"event_id"`
+	Kind              models.OrderEventKind `json:"kind"`
+	OrderID           uint64                `json:"order_id"`
+	Symbol            string                `json:"symbol"`
+	Side              models.OrderSide      `json:"side"`
+	Type              models.OrderType      `json:"type"`
+	Status            models.OrderStatus    `json:"status"`
+	Price             *models.Decimal       `json:"price,omitempty"`
+	InitialQuantity   models.Decimal        `json:"initial_quantity"`
+	RemainingQuantity models.Decimal        `json:"remaining_quantity"`
+	OwnerID           string                `json:"owner_id"`
+	Actor             string                `json:"actor"`
+	Reason            models.ReasonCode     `json:"reason,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+}
+
+// TradeMessage is the value of an executed trade record
+type TradeMessage struct {
+	Shard       int              `json:"shard"`
+	TradeID     uint64           `json:"trade_id"`
+	Symbol      string           `json:"symbol"`
+	BuyOrderID  uint64           `json:"buy_order_id"`
+	SellOrderID uint64           `json:"sell_order_id"`
+	BuyOwnerID  string           `json:"buy_owner_id"`
+	SellOwnerID string           `json:"sell_owner_id"`
+	Price       models.Decimal   `json:"price"`
+	Quantity    models.Decimal   `json:"quantity"`
+	PrintType   models.PrintType `json:"print_type"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// Publisher relays the order event outbox and the trades of every shard to Kafka, keyed by
+// symbol so each symbol's records stay in order within one partition. Progress is saved as
+// event cursors after each acknowledged batch, so records are delivered at least once, also
+// across restarts; consumers deduplicate by shard and event or trade ID.
+type Publisher struct {
+	producer *Producer
+	feed     *compliance.Feed
+	repo     repository.Repository
+	cfg      config.KafkaConfig
+	logger   *zap.Logger
+
+	orders compliance.Cursor // nil until the saved cursors are loaded
+	trades compliance.Cursor
+}
+
+// NewPublisher creates a Kafka publisher
+func NewPublisher(feed *compliance.Feed, repo repository.Repository, cfg config.KafkaConfig, logger *zap.Logger) *Publisher {
+	return &Publisher{producer: NewProducer(cfg), feed: feed, repo: repo, cfg: cfg, logger: logger}
+}
+
+// Run publishes new order events and trades until ctx is canceled, draining any backlog
+// without pausing and backing off for the timeout after a failure
+func (p *Publisher) Run(ctx context.Context) {
+	defer p.producer.Close()
+	for {
+		published, err := p.publish(ctx)
+		wait := p.cfg.PollInterval
+		if err != nil {
+			p.logger.Warn("Failed to publish to Kafka", zap.Error(err))
+			wait = p.cfg.Timeout
+		} else if published > 0 {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// publish sends one batch of order events and one of trades, returning how many records were sent
+func (p *Publisher) publish(ctx context.Context) (int, error) {
+	if p.orders == nil {
+		orders, err := p.feed.Position(ctx, orderSubscriber)
+		if err != nil {
+			return 0, err
+		}
+		trades, err := p.feed.Position(ctx, tradeSubscriber)
+		if err != nil {
+			return 0, err
+		}
+		p.orders, p.trades = orders, trades
+	}
+	orders, err := p.publishOrders(ctx)
+	if err != nil {
+		return orders, err
+	}
+	trades, err := p.publishTrades(ctx)
+	return orders + trades, err
+}
+
+// publishOrders sends the order events after the order cursor
+func (p *Publisher) publishOrders(ctx context.Context) (int, error) {
+	events, next, err := p.feed.Poll(ctx, p.orders, p.cfg.BatchSize, 0)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	messages := make([]Message, len(events))
+	for i, se := range events {
+		e := se.Event
+		value, err := json.Marshal(OrderEventMessage{
+			Shard:             se.Shard,
+			EventID:           e.EventID,
+			Kind:              e.Kind,
+			OrderID:           e.OrderID,
+			Symbol:            e.Symbol,
+			Side:              e.Side,
+			Type:              e.Type,
+			Status:            e.Status,
+			Price:             nullDecimal(e.Price),
+			InitialQuantity:   e.InitialQuantity,
+			RemainingQuantity: e.RemainingQuantity,
+			OwnerID:           e.OwnerID,
+			Actor:             e.Actor,
+			Reason:            e.Reason,
+			CreatedAt:         e.CreatedAt,
+		})
+		if err != nil {
+			return 0, err
+		}
+		messages[i] = Message{Key: []byte(e.Symbol), Value: value, Time: e.CreatedAt}
+	}
+	if err := p.producer.Produce(p.cfg.OrderTopic, messages); err != nil {
+		return 0, err
+	}
+	p.orders = next
+	return len(events), p.feed.Ack(ctx, orderSubscriber, next)
+}
+
+// publishTrades sends the trades after the trade cursor of every shard, which holds commit
+// sequences rather than trade IDs: trade IDs are assigned when a trade is printed, before it
+// commits, so engines committing concurrently commit them out of order
+func (p *Publisher) publishTrades(ctx context.Context) (int, error) {
+	next := append(compliance.Cursor(nil), p.trades...)
+	var messages []Message
+	for i, shard := range p.repo.Shards() {
+		if err := shard.SequenceOutbox(ctx, repository.OutboxTrades, p.cfg.BatchSize); err != nil {
+			p.logger.Error("Failed to sequence trades", zap.Int("shard", i), zap.Error(err))
+			return 0, repository.Classify(err)
+		}
+		trades, err := shard.GetTradesAfter(ctx, p.trades[i], p.cfg.BatchSize)
+		if err != nil {
+			p.logger.Error("Failed to get trades", zap.Int("shard", i), zap.Error(err))
+			return 0, repository.Classify(err)
+		}
+		for _, trade := range trades {
+			value, err := json.Marshal(TradeMessage{
+				Shard:       i,
+				TradeID:     trade.TradeID,
+				Symbol:      trade.Symbol,
+				BuyOrderID:  trade.BuyOrderID,
+				SellOrderID: trade.SellOrderID,
+				BuyOwnerID:  trade.BuyOwnerID,
+				SellOwnerID: trade.SellOwnerID,
+				Price:       trade.Price,
+				Quantity:    trade.Quantity,
+				PrintType:   trade.PrintType,
+				CreatedAt:   trade.CreatedAt,
+			})
+			if err != nil {
+				return 0, err
+			}
+			messages = append(messages, Message{Key: []byte(trade.Symbol), Value: value, Time: trade.CreatedAt})
+			next[i] = trade.CommitSequence
+		}
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	if err := p.producer.Produce(p.cfg.TradeTopic, messages); err != nil {
+		return 0, err
+	}
+	p.trades = next
+	return len(messages), p.feed.Ack(ctx, tradeSubscriber, next)
+}
+
+// nullDecimal returns a pointer to a set decimal, nil for an unset one
+func nullDecimal(d models.NullDecimal) *models.Decimal {
+	if !d.Valid {
+		return nil
+	}
+	return &d.Decimal
+}
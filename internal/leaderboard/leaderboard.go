@@ -0,0 +1,122 @@
+package leaderboard
+
+import (
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/service"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dayLayout formats the UTC day a trade counts towards
+const dayLayout = "2006-01-02"
+
+// Standing is an account's traded volume in one symbol on one day
+type Standing struct {
+	AccountID string
+	Notional  float64
+	Quantity  models.Decimal
+	Trades    int
+}
+
+// tallyKey identifies the standings of a symbol on a UTC day
+type tallyKey struct {
+	symbol string
+	day    string
+}
+
+// Board ranks accounts by the notional they traded per symbol and UTC day. It tallies the trades
+// of the matching engine's event stream as they print, keeping the configured number of days;
+// standings are held in memory only and start over on restart.
+//
+// Only book prints count: block trades are negotiated off-book and trades between two orders
+// of the same account are skipped, so neither can buy a place on the board.
+type Board struct {
+	days int
+
+	// Standings by symbol and day, then by account, guarded by mutex
+	tallies map[tallyKey]map[string]*Standing
+	latest  string
+	mutex   sync.RWMutex
+}
+
+// NewBoard creates an empty leaderboard
+func NewBoard(cfg config.LeaderboardConfig) *Board {
+	return &Board{days: cfg.Days, tallies: make(map[tallyKey]map[string]*Standing)}
+}
+
+// Publish tallies the trades of a market event; it implements service.MarketEventSink
+func (b *Board) Publish(event *service.MarketEvent) {
+	if event.Kind != service.EventTrade {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, trade := range event.Trades {
+		if trade.PrintType == models.PrintBlock || trade.BuyOwnerID == trade.SellOwnerID {
+			continue
+		}
+		day := trade.CreatedAt.UTC().Format(dayLayout)
+		if day > b.latest {
+			b.latest = day
+			b.prune()
+		}
+		b.credit(tallyKey{trade.Symbol, day}, trade.BuyOwnerID, trade)
+		b.credit(tallyKey{trade.Symbol, day}, trade.SellOwnerID, trade)
+	}
+}
+
+// credit adds a trade to one side's standing; anonymous orders are not ranked
+func (b *Board) credit(key tallyKey, accountID string, trade *models.Trade) {
+	if accountID == "" {
+		return
+	}
+	standings, ok := b.tallies[key]
+	if !ok {
+		standings = make(map[string]*Standing)
+		b.tallies[key] = standings
+	}
+	standing, ok := standings[accountID]
+	if !ok {
+		standing = &Standing{AccountID: accountID}
+		standings[accountID] = standing
+	}
+	standing.Notional += models.Notional(trade.Price, trade.Quantity)
+	standing.Quantity += trade.Quantity
+	standing.Trades++
+}
+
+// prune drops the days that have fallen out of the retention window
+func (b *Board) prune() {
+	latest, _ := time.Parse(dayLayout, b.latest)
+	oldest := latest.AddDate(0, 0, 1-b.days).Format(dayLayout)
+	for key := range b.tallies {
+		if key.day < oldest {
+			delete(b.tallies, key)
+		}
+	}
+}
+
+// Top returns up to limit accounts with the highest notional traded in a symbol on the UTC day
+// of day, highest first, ties broken by account ID
+func (b *Board) Top(symbol string, day time.Time, limit int) []Standing {
+	b.mutex.RLock()
+	standings := b.tallies[tallyKey{symbol, day.UTC().Format(dayLayout)}]
+	top := make([]Standing, 0, len(standings))
+	for _, standing := range standings {
+		top = append(top, *standing)
+	}
+	b.mutex.RUnlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Notional != top[j].Notional {
+			return top[i].Notional > top[j].Notional
+		}
+		return top[i].AccountID < top[j].AccountID
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
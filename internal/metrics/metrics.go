@@ -0,0 +1,125 @@
+// Package metrics exposes the engine and API metrics operators scrape from /metrics in the
+// Prometheus format.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ordersystem"
+
+// Engine and API metrics, all registered with registry
+var (
+	OrdersPlaced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_placed_total",
+		Help:      "New order requests accepted by the matching engine, an OCO group counting once.",
+	}, []string{"symbol"})
+	OrdersRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_rejected_total",
+		Help:      "Order requests refused for their parameters, risk, credit or the order's state, by reason code.",
+	}, []string{"symbol", "reason"})
+	TradesExecuted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trades_executed_total",
+		Help:      "Trades printed, book and block alike.",
+	}, []string{"symbol"})
+	MatchLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "match_latency_seconds",
+		Help:      "Time taken to match an order against the book and commit the result, deadlock retries included.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"symbol"})
+	DBTransactionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_transaction_duration_seconds",
+		Help:      "Duration of the matching and balance database transactions from begin to commit or rollback.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+	DBCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_call_duration_seconds",
+		Help:      "Duration of each repository call, by repository method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method"})
+	WriteBehindLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "write_behind_lag",
+		Help:      "Transactions committed to the write-behind log and not yet written to the shard, by shard.",
+	}, []string{"shard"})
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by route and status code.",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle an HTTP request, by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	bookLevels = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "book_levels"),
+		"Price levels resting on each side of a symbol's book.", []string{"symbol", "side"}, nil)
+	bookQuantity = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "book_quantity"),
+		"Visible quantity resting on each side of a symbol's book.", []string{"symbol", "side"}, nil)
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		OrdersPlaced, OrdersRejected, TradesExecuted, MatchLatency, DBTransactionDuration,
+		DBCallDuration, WriteBehindLag, HTTPRequests, HTTPRequestDuration,
+	)
+}
+
+// Handler serves every registered metric in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Since observes the seconds elapsed since start
+func Since(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
+
+// BookDepth is the resting size on one side of a symbol's book
+type BookDepth struct {
+	Symbol   string
+	Side     string
+	Levels   int
+	Quantity float64
+}
+
+// depthCollector reports book depth read when scraped
+type depthCollector struct {
+	read func() []BookDepth
+}
+
+// Describe sends the book depth descriptors
+func (c depthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bookLevels
+	ch <- bookQuantity
+}
+
+// Collect reads the book depth and sends a gauge of each side's levels and quantity
+func (c depthCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, d := range c.read() {
+		ch <- prometheus.MustNewConstMetric(bookLevels, prometheus.GaugeValue, float64(d.Levels), d.Symbol, d.Side)
+		ch <- prometheus.MustNewConstMetric(bookQuantity, prometheus.GaugeValue, d.Quantity, d.Symbol, d.Side)
+	}
+}
+
+// RegisterBookDepth reports the book depth read returns on every scrape
+func RegisterBookDepth(read func() []BookDepth) {
+	registry.MustRegister(depthCollector{read: read})
+}
@@ -2,107 +2,184 @@ package migration
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
+	"orderSystem/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-// RunMigrations runs all pending database migrations
-func RunMigrations(db *sql.DB) error {
-	projectRoot, err := getProjectRoot()
+// Migrator runs and inspects one database's migrations
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// Migration is one migration of a migration set
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// NewMigrator prepares the migrations of a MySQL database
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	driver, err := mysql.WithInstance(db, &mysql.Config{})
 	if err != nil {
-		return fmt.Errorf("failed to get project root: %v", err)
+		return nil, fmt.Errorf("could not create migration driver: %v", err)
 	}
+	return newMigrator(migrations.MySQL, ".", "mysql", driver)
+}
 
-	migrationsPath := filepath.Join(projectRoot, "migrations")
-	log.Printf("Looking for migrations in: %s", migrationsPath)
+// NewSQLiteMigrator prepares the migrations of a SQLite database, which has its own migration
+// set under migrations/sqlite
+func NewSQLiteMigrator(db *sql.DB) (*Migrator, error) {
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("could not create migration driver: %v", err)
+	}
+	return newMigrator(migrations.SQLite, "sqlite", "sqlite", driver)
+}
 
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
+// newMigrator prepares the migrations embedded in dir of fsys, which are compiled into the
+// binary so they are found whatever directory it runs from
+func newMigrator(fsys fs.FS, dir, databaseName string, driver database.Driver) (*Migrator, error) {
+	src, err := iofs.New(fsys, dir)
 	if err != nil {
-		return fmt.Errorf("could not create migration driver: %v", err)
+		return nil, fmt.Errorf("could not read embedded migrations: %v", err)
 	}
+	m, err := migrate.NewWithInstance("iofs", src, databaseName, driver)
+	if err != nil {
+		return nil, fmt.Errorf("could not create migration instance: %v", err)
+	}
+	return &Migrator{m: m, source: src}, nil
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"mysql",
-		driver,
-	)
+// RunMigrations runs all pending database migrations
+func RunMigrations(db *sql.DB) error {
+	migrator, err := NewMigrator(db)
 	if err != nil {
-		return fmt.Errorf("could not create migration instance: %v", err)
+		return err
 	}
+	return migrator.Run()
+}
 
-	// Check if we need to force a version
-	version, dirty, err := m.Version()
-	if err != nil && err != migrate.ErrNilVersion {
-		return fmt.Errorf("could not get migration version: %v", err)
+// RunSQLiteMigrations runs all pending migrations of a SQLite database
+func RunSQLiteMigrations(db *sql.DB) error {
+	migrator, err := NewSQLiteMigrator(db)
+	if err != nil {
+		return err
 	}
+	return migrator.Run()
+}
 
+// Run applies every pending migration, as the server does at startup. A database left dirty by
+// a failed migration is refused rather than forced, since only an operator can tell whether the
+// migration's changes were made; see Force.
+func (mg *Migrator) Run() error {
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return err
+	}
 	if dirty {
-		log.Printf("Found dirty database at version %d, forcing version", version)
-		if err := m.Force(int(version)); err != nil {
-			return fmt.Errorf("could not force version: %v", err)
-		}
+		return fmt.Errorf("database is dirty at version %d after a failed migration; repair it and mark the version it is at with the migrate command's force", version)
 	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("could not run migrations: %v", err)
+	if err := mg.Up(0); err != nil {
+		return err
 	}
 
 	log.Println("Migrations completed successfully")
 	return nil
 }
 
-// RollbackLastMigration rolls back the last applied migration
-func RollbackLastMigration(db *sql.DB) error {
-	projectRoot, err := getProjectRoot()
-	if err != nil {
-		return fmt.Errorf("failed to get project root: %v", err)
+// Version returns the version last migrated to, 0 when none has been, and whether that
+// migration failed part way
+func (mg *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, nil
 	}
-
-	migrationsPath := filepath.Join(projectRoot, "migrations")
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
 	if err != nil {
-		return fmt.Errorf("could not create migration driver: %v", err)
+		return 0, false, fmt.Errorf("could not get migration version: %v", err)
 	}
+	return version, dirty, nil
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"mysql",
-		driver,
-	)
-	if err != nil {
-		return fmt.Errorf("could not create migration instance: %v", err)
+// Up applies up to the next steps pending migrations, all of them when steps is 0
+func (mg *Migrator) Up(steps int) error {
+	var err error
+	if steps > 0 {
+		err = mg.m.Steps(steps)
+	} else {
+		err = mg.m.Up()
+	}
+	if err != nil && err != migrate.ErrNoChange && !errors.As(err, &migrate.ErrShortLimit{}) {
+		return fmt.Errorf("could not run migrations: %v", err)
 	}
+	return nil
+}
 
-	if err := m.Steps(-1); err != nil {
+// Down rolls back up to the last steps applied migrations
+func (mg *Migrator) Down(steps int) error {
+	err := mg.m.Steps(-steps)
+	if err != nil && err != migrate.ErrNoChange && !errors.As(err, &migrate.ErrShortLimit{}) {
 		return fmt.Errorf("could not rollback migration: %v", err)
 	}
+	return nil
+}
 
-	log.Println("Rollback completed successfully")
+// Force records the database as migrated to version and clean, running no migration; the
+// operator repairs a failed migration's partial changes first
+func (mg *Migrator) Force(version int) error {
+	if err := mg.m.Force(version); err != nil {
+		return fmt.Errorf("could not force version: %v", err)
+	}
 	return nil
 }
 
-// getProjectRoot returns the absolute path to the project root directory
-func getProjectRoot() (string, error) {
-	// Get current working directory
-	wd, err := os.Getwd()
+// Status lists every migration of the set, oldest first, marking those applied
+func (mg *Migrator) Status() ([]Migration, error) {
+	current, _, err := mg.Version()
 	if err != nil {
-		return "", fmt.Errorf("failed to get working directory: %v", err)
+		return nil, err
+	}
+	var list []Migration
+	version, err := mg.source.First()
+	for err == nil {
+		var r io.ReadCloser
+		var name string
+		r, name, err = mg.source.ReadUp(version)
+		if err != nil {
+			return nil, fmt.Errorf("could not read migration %d: %v", version, err)
+		}
+		r.Close()
+		list = append(list, Migration{Version: version, Name: name, Applied: version <= current})
+		version, err = mg.source.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("could not list migrations: %v", err)
 	}
+	return list, nil
+}
 
-	// If we're in cmd/server, go up one level
-	if filepath.Base(wd) == "server" {
-		wd = filepath.Dir(wd)
+// RollbackLastMigration rolls back the last applied migration
+func RollbackLastMigration(db *sql.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
 	}
-	// If we're in cmd, go up one more level
-	if filepath.Base(wd) == "cmd" {
-		wd = filepath.Dir(wd)
+	if err := migrator.Down(1); err != nil {
+		return err
 	}
 
-	return wd, nil
+	log.Println("Rollback completed successfully")
+	return nil
 }
@@ -0,0 +1,246 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// DecimalPlaces is the number of fractional digits a Decimal holds
+const DecimalPlaces = 8
+
+// decimalScale is the number of Decimal units in one whole unit
+const decimalScale = 100_000_000
+
+// Decimal is a fixed-point number with DecimalPlaces fractional digits, stored as an integer
+// count of 1e-8 units. Prices and quantities are Decimals so that comparing, adding and
+// subtracting them is exact; the arithmetic operators and comparisons work on them directly,
+// while products and quotients go through Mul and Div. Price and quantity columns are
+// DECIMAL(20,8), which hold every Decimal exactly, so none is rounded on its way to storage.
+type Decimal int64
+
+// NewDecimal returns the Decimal nearest to f
+func NewDecimal(f float64) Decimal {
+	return Decimal(math.Round(f * decimalScale))
+}
+
+// DecimalFromInt returns the Decimal of a whole number
+func DecimalFromInt(n int64) Decimal {
+	return Decimal(n * decimalScale)
+}
+
+// ParseDecimal parses a plain decimal number such as "-12.345", rejecting more than
+// DecimalPlaces fractional digits
+func ParseDecimal(s string) (Decimal, error) {
+	text := s
+	negative := strings.HasPrefix(text, "-")
+	if negative || strings.HasPrefix(text, "+") {
+		text = text[1:]
+	}
+	whole, frac, _ := strings.Cut(text, ".")
+	if whole == "" && frac == "" || len(frac) > DecimalPlaces || strings.ContainsAny(whole+frac, "+-") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	var units uint64
+	if whole != "" {
+		n, err := strconv.ParseUint(whole, 10, 64)
+		if err != nil || n > math.MaxInt64/decimalScale {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		units = n * decimalScale
+	}
+	if frac != "" {
+		n, err := strconv.ParseUint(frac+strings.Repeat("0", DecimalPlaces-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		units += n
+	}
+	if units > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if negative {
+		return -Decimal(units), nil
+	}
+	return Decimal(units), nil
+}
+
+// Float64 returns the nearest float64, for ratios and display
+func (d Decimal) Float64() float64 {
+	return float64(d) / decimalScale
+}
+
+// Mul returns d*o rounded half away from zero to DecimalPlaces
+func (d Decimal) Mul(o Decimal) Decimal {
+	negative := (d < 0) != (o < 0)
+	hi, lo := bits.Mul64(abs(d), abs(o))
+	if hi >= decimalScale {
+		panic("decimal: multiplication overflow")
+	}
+	q, r := bits.Div64(hi, lo, decimalScale)
+	if r >= decimalScale/2 {
+		q++
+	}
+	return signed(q, negative)
+}
+
+// Notional returns the value of quantity at price as a float64. Unlike Mul it cannot overflow,
+// so it suits amounts that leave the Decimal domain such as balances and fees.
+func Notional(price, quantity Decimal) float64 {
+	product := new(big.Int).Mul(big.NewInt(int64(price)), big.NewInt(int64(quantity)))
+	value, _ := new(big.Rat).SetFrac(product, big.NewInt(decimalScale*decimalScale)).Float64()
+	return value
+}
+
+// Div returns d/o rounded half away from zero to DecimalPlaces. It panics when o is zero.
+func (d Decimal) Div(o Decimal) Decimal {
+	if o == 0 {
+		panic("decimal: division by zero")
+	}
+	negative := (d < 0) != (o < 0)
+	hi, lo := bits.Mul64(abs(d), decimalScale)
+	divisor := abs(o)
+	if hi >= divisor {
+		panic("decimal: division overflow")
+	}
+	q, r := bits.Div64(hi, lo, divisor)
+	if r >= divisor-r {
+		q++
+	}
+	return signed(q, negative)
+}
+
+// abs returns the magnitude of d
+func abs(d Decimal) uint64 {
+	if d < 0 {
+		return uint64(-d)
+	}
+	return uint64(d)
+}
+
+// signed turns a magnitude back into a Decimal
+func signed(units uint64, negative bool) Decimal {
+	if units > math.MaxInt64 {
+		panic("decimal: overflow")
+	}
+	if negative {
+		return -Decimal(units)
+	}
+	return Decimal(units)
+}
+
+// String formats d without trailing fractional zeros, e.g. "101.5" or "-0.25"
+func (d Decimal) String() string {
+	units := abs(d)
+	text := strconv.FormatUint(units/decimalScale, 10)
+	if frac := units % decimalScale; frac != 0 {
+		digits := strconv.FormatUint(frac+decimalScale, 10)[1:]
+		text += "." + strings.TrimRight(digits, "0")
+	}
+	if d < 0 {
+		return "-" + text
+	}
+	return text
+}
+
+// MarshalJSON encodes d as an exact JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string without passing through float64.
+// Exponent notation is accepted as long as the value fits DecimalPlaces.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		return nil
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+// UnmarshalText decodes a decimal from text, such as a query parameter
+func (d *Decimal) UnmarshalText(text []byte) error {
+	s := string(text)
+	if strings.ContainsAny(s, "eE") {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		s = r.FloatString(DecimalPlaces)
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for DECIMAL columns
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return d.UnmarshalText(v)
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case int64:
+		*d = DecimalFromInt(v)
+		return nil
+	case float64:
+		*d = NewDecimal(v)
+		return nil
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidDecimal, src)
+}
+
+// Value implements driver.Valuer, writing the exact decimal text
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// NullDecimal is a Decimal that may be null, such as the price of a market order
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool
+}
+
+// Scan implements sql.Scanner
+func (n *NullDecimal) Scan(src any) error {
+	if src == nil {
+		n.Decimal, n.Valid = 0, false
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.Scan(src)
+}
+
+// Value implements driver.Valuer
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+// MarshalJSON encodes n as a JSON number, or null when unset
+func (n NullDecimal) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.Decimal.MarshalJSON()
+}
+
+// UnmarshalJSON decodes a JSON number, numeric string or null
+func (n *NullDecimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		n.Decimal, n.Valid = 0, false
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.UnmarshalJSON(data)
+}
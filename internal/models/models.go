@@ -3,62 +3,961 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"math/big"
+	"net/netip"
+	"slices"
 	"time"
 )
 
 // OrderSide represents the side of an order (buy or sell)
 type OrderSide string
 
-// OrderType represents the type of an order (limit or market)
+// OrderType represents the type of an order (limit, market, stop or stop_limit)
 type OrderType string
 
 // OrderStatus represents the status of an order
 type OrderStatus string
 
+// TimeInForce represents how long an order remains active
+type TimeInForce string
+
+// Liquidity indicates whether an execution added or removed liquidity
+type Liquidity string
+
+// TradingPhase represents how a symbol currently processes orders
+type TradingPhase string
+
+// AlgoStrategy represents the slicing strategy of a parent order
+type AlgoStrategy string
+
+// ParentStatus represents the status of a parent order
+type ParentStatus string
+
+// GroupType represents how the orders of an order group are linked
+type GroupType string
+
+// GroupStatus represents the status of an order group
+type GroupStatus string
+
+// BlockStatus represents how far a reported block trade has got
+type BlockStatus string
+
+// SettlementStatus represents whether a balance delta has been finalized
+type SettlementStatus string
+
+// LedgerKind represents the balance mutation a ledger posting records
+type LedgerKind string
+
+// LedgerBucket represents what a ledger entry moves
+type LedgerBucket string
+
+// PrintType represents how a trade came about, as flagged on the tape
+type PrintType string
+
+// OrderEventKind represents what happened to an order in an order lifecycle event
+type OrderEventKind string
+
+// DeliveryStatus represents how far a webhook delivery has got
+type DeliveryStatus string
+
+// ExecType represents what an execution report announces
+type ExecType string
+
+// ReasonCode represents why an order was rejected, the same in every channel reporting it
+type ReasonCode string
+
 // Constants for order attributes
 const (
-	SideBuy        OrderSide   = "buy"
-	SideSell       OrderSide   = "sell"
-	TypeLimit      OrderType   = "limit"
-	TypeMarket     OrderType   = "market"
+	SideBuy    OrderSide = "buy"
+	SideSell   OrderSide = "sell"
+	TypeLimit  OrderType = "limit"
+	TypeMarket OrderType = "market"
+	// TypeStop and TypeStopLimit wait off-book until the last trade price reaches their
+	// trigger price and then execute as a market or limit order respectively
+	TypeStop       OrderType   = "stop"
+	TypeStopLimit  OrderType   = "stop_limit"
 	StatusOpen     OrderStatus = "open"
 	StatusFilled   OrderStatus = "filled"
 	StatusCanceled OrderStatus = "canceled"
+	// StatusPartiallyFilled marks an order resting on the book after part of it traded
+	StatusPartiallyFilled OrderStatus = "partially_filled"
+	// StatusPending marks a stop order that has not been triggered yet
+	StatusPending OrderStatus = "pending"
+	// StatusExpired marks a resting order canceled by the stale order sweep or at its expiry time
+	StatusExpired OrderStatus = "expired"
+	// TIFGTC rests until filled or canceled, TIFIOC cancels any unfilled remainder
+	// immediately and TIFFOK executes in full immediately or not at all
+	TIFGTC TimeInForce = "gtc"
+	TIFIOC TimeInForce = "ioc"
+	TIFFOK TimeInForce = "fok"
+	// TIFGTD rests like TIFGTC until the order's expiry time
+	TIFGTD         TimeInForce = "gtd"
+	LiquidityMaker Liquidity   = "maker"
+	LiquidityTaker Liquidity   = "taker"
+	// LiquidityAuction marks executions produced by an auction uncrossing
+	LiquidityAuction Liquidity = "auction"
+	// LiquidityBlock marks executions of an off-book block trade
+	LiquidityBlock    Liquidity        = "block"
+	PhaseContinuous   TradingPhase     = "continuous"
+	PhaseAuction      TradingPhase     = "auction"
+	PhaseHalted       TradingPhase     = "halted"
+	AlgoTWAP          AlgoStrategy     = "twap"
+	AlgoPOV           AlgoStrategy     = "pov"
+	ParentActive      ParentStatus     = "active"
+	ParentCompleted   ParentStatus     = "completed"
+	ParentCanceled    ParentStatus     = "canceled"
+	SettlementPending SettlementStatus = "pending"
+	SettlementSettled SettlementStatus = "settled"
+	LedgerOpening     LedgerKind       = "opening" // balances from before the ledger
+	LedgerDeposit     LedgerKind       = "deposit"
+	LedgerTrade       LedgerKind       = "trade"
+	LedgerSettlement  LedgerKind       = "settlement" // a pending delta becoming settled
+	LedgerFee         LedgerKind       = "fee"
+	LedgerReserve     LedgerKind       = "reserve" // balance becoming held by open orders
+	LedgerRelease     LedgerKind       = "release" // held balance no longer held
+	// Account entries move the account's settled, pending or held balance, an unheld entry
+	// standing against each held one; the others have no account and stand for funds outside the
+	// exchange, for the anonymous side of trades and for the fees the exchange has collected
+	BucketSettled   LedgerBucket = "settled"
+	BucketPending   LedgerBucket = "pending"
+	BucketHeld      LedgerBucket = "held"
+	BucketUnheld    LedgerBucket = "unheld"
+	BucketExternal  LedgerBucket = "external"
+	BucketAnonymous LedgerBucket = "anonymous"
+	BucketFees      LedgerBucket = "fees"
+	// GroupOCO links a limit order and a stop order so that either filling or triggering cancels the other
+	GroupOCO       GroupType   = "oco"
+	GroupActive    GroupStatus = "active"
+	GroupCompleted GroupStatus = "completed"
+	GroupCanceled  GroupStatus = "canceled"
+	// PrintBook trades were matched in the order book, PrintBlock trades were pre-negotiated
+	// and reported off-book
+	PrintBook  PrintType = "book"
+	PrintBlock PrintType = "block"
+	// A reported block trade is pending until its counterparty affirms it, which prints and
+	// settles it, or either side declines it
+	BlockPending  BlockStatus = "pending"
+	BlockAffirmed BlockStatus = "affirmed"
+	BlockDeclined BlockStatus = "declined"
+	// EventCreated is recorded when an order is first stored, EventUpdated on every later change
+	// and EventRejected for an order refused before it was stored
+	EventCreated  OrderEventKind = "created"
+	EventUpdated  OrderEventKind = "updated"
+	EventRejected OrderEventKind = "rejected"
+	// Actors of order changes other than the accounts that asked for them: the matching engine
+	// filling resting orders, triggering stops and completing groups, the expiry sweeps, and
+	// operators halting, delisting or changing the tick size of a symbol
+	ActorEngine   = "engine"
+	ActorSystem   = "system"
+	ActorOperator = "operator"
+	// DeliveryPending webhook deliveries await their next attempt, DeliveryFailed ones were
+	// given up after the last
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+	// ExecNew reports an accepted order, ExecTrade a fill, ExecReplaced an amendment or a
+	// triggered stop and ExecExpired a sweep by the stale order expiry
+	ExecNew      ExecType = "new"
+	ExecTrade    ExecType = "trade"
+	ExecCanceled ExecType = "canceled"
+	ExecExpired  ExecType = "expired"
+	ExecReplaced ExecType = "replaced"
+	ExecRejected ExecType = "rejected"
+	// ReasonContention covers an order whose transaction kept deadlocking or waiting out row locks,
+	// ReasonInternal other failures that are not the order's fault, such as storage errors
+	ReasonInvalidOrder       ReasonCode = "invalid_order"
+	ReasonTickSize           ReasonCode = "tick_size"
+	ReasonLotSize            ReasonCode = "lot_size"
+	ReasonMinNotional        ReasonCode = "min_notional"
+	ReasonRiskLimit          ReasonCode = "risk_limit"
+	ReasonInsufficientCredit ReasonCode = "insufficient_credit"
+	ReasonCreditUnavailable  ReasonCode = "credit_unavailable"
+	ReasonAuction            ReasonCode = "auction"
+	ReasonHalted             ReasonCode = "halted"
+	ReasonPriceBand          ReasonCode = "price_band"
+	ReasonNoLiquidity        ReasonCode = "no_liquidity"
+	ReasonDuplicateClientID  ReasonCode = "duplicate_client_order_id"
+	ReasonContention         ReasonCode = "contention"
+	ReasonInternal           ReasonCode = "internal"
+	// Reasons an order was canceled or expired: at its owner's request, an unfilled remainder
+	// that could not rest, the book walk cap, its protection price, along with its order group,
+	// at its expire_at, by the stale order sweep or when its symbol was delisted; halts, tick
+	// size changes and insufficient credit use the codes above
+	ReasonRequested  ReasonCode = "requested"
+	ReasonUnfilled   ReasonCode = "unfilled"
+	ReasonWalkCap    ReasonCode = "walk_cap"
+	ReasonProtection ReasonCode = "price_protection"
+	ReasonGroup      ReasonCode = "order_group"
+	ReasonExpireAt   ReasonCode = "expire_at"
+	ReasonStale      ReasonCode = "stale"
+	ReasonDelisted   ReasonCode = "delisted"
+	// Codes of refused requests that are not order rejections: a request that failed validation,
+	// one naming an order that does not exist or is no longer open, and order entry or a cancel
+	// during maintenance mode
+	ReasonInvalidRequest ReasonCode = "invalid_request"
+	ReasonOrderNotFound  ReasonCode = "order_not_found"
+	ReasonOrderNotOpen   ReasonCode = "order_not_open"
+	ReasonMaintenance    ReasonCode = "maintenance"
 )
 
 // Custom errors for order operations
 var (
-	ErrInvalidOrder   = errors.New("invalid order parameters")
-	ErrOrderNotFound  = errors.New("order not found")
-	ErrOrderNotOpen   = errors.New("order is not open")
+	ErrInvalidOrder       = errors.New("invalid order parameters")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderNotOpen       = errors.New("order is not open")
+	ErrRiskLimitExceeded  = errors.New("order exceeds risk limits")
+	ErrSymbolInAuction    = errors.New("only resting limit orders are accepted during an auction")
+	ErrParentNotFound     = errors.New("parent order not found")
+	ErrParentNotActive    = errors.New("parent order is not active")
+	ErrInvalidDeposit     = errors.New("invalid deposit parameters")
+	ErrUnbalancedPosting  = errors.New("ledger posting does not balance")
+	ErrInvoiceNotFound    = errors.New("invoice not found")
+	ErrGroupNotFound      = errors.New("order group not found")
+	ErrGroupNotActive     = errors.New("order group is not active")
+	ErrInvalidBlockTrade  = errors.New("invalid block trade parameters")
+	ErrNoReferencePrice   = errors.New("no reference price to validate the block trade against")
+	ErrOutsidePriceBand   = errors.New("block trade price is outside the allowed band")
+	ErrBlockNotFound      = errors.New("block trade not found")
+	ErrBlockNotPending    = errors.New("block trade was already affirmed, declined or expired")
+	ErrInvalidCursor      = errors.New("invalid event cursor")
+	ErrInvalidSchedule    = errors.New("invalid fee schedule parameters")
+	ErrScheduleNotFound   = errors.New("fee schedule not found")
+	ErrOverAllocated      = errors.New("active fee schedules would be assigned more than 100% of accounts")
+	ErrInvalidDecimal     = errors.New("invalid decimal")
+	ErrListenKeyNotFound  = errors.New("listen key not found or expired")
+	ErrInsufficientCredit = errors.New("order exceeds available credit")
+	ErrCreditUnavailable  = errors.New("credit check unavailable")
+	ErrSigningKeyNotFound = errors.New("signing key not found or revoked")
+	ErrInvalidSigningKey  = errors.New("invalid signing key scopes or allowed IPs")
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrInvalidWebhook     = errors.New("webhook URL must be an absolute http or https URL")
+	ErrAccountNotFound    = errors.New("account not found")
+	ErrDuplicateClientID  = errors.New("client order ID already used for a different order")
+	ErrTooManyConnections = errors.New("too many open streams")
+	ErrSubscriptionLimit  = errors.New("too many subscriptions")
+	ErrSessionNotFound    = errors.New("stream session not found or expired")
+	ErrArchiveNotFound    = errors.New("archive file not found")
+	ErrInvalidSandbox     = errors.New("invalid sandbox settings")
+	ErrLastLookNotFound   = errors.New("last look not found or expired")
+	ErrOffTick            = errors.New("price is not a multiple of the tick size")
+	ErrInvalidTickSize    = errors.New("tick size must be a positive multiple of 0.00000001")
+	ErrSymbolNotListed    = errors.New("symbol is not listed or was delisted")
+	ErrOffLot             = errors.New("quantity is not a multiple of the lot size")
+	ErrBelowMinNotional   = errors.New("order value is below the symbol's minimum notional")
+	ErrSymbolNotFound     = errors.New("symbol not found")
+	ErrInvalidSymbol      = errors.New("invalid symbol parameters")
+	ErrSymbolHalted       = errors.New("trading in the symbol is halted")
+	ErrPriceBand          = errors.New("limit price is outside the symbol's price band")
+	ErrNoLiquidity        = errors.New("the book cannot fill any of the quote quantity")
+	ErrRequestTimeout     = errors.New("request timed out before it was carried out")
+	ErrContention         = errors.New("order kept conflicting with concurrent database writes, retry later")
+	ErrMaintenance        = errors.New("order entry and cancels are paused for maintenance")
 )
 
+// RejectReason returns the reason code of an error that refused an order
+func RejectReason(err error) ReasonCode {
+	switch err {
+	case ErrInvalidOrder, ErrSymbolNotListed:
+		return ReasonInvalidOrder
+	case ErrOffTick:
+		return ReasonTickSize
+	case ErrOffLot:
+		return ReasonLotSize
+	case ErrBelowMinNotional:
+		return ReasonMinNotional
+	case ErrRiskLimitExceeded:
+		return ReasonRiskLimit
+	case ErrInsufficientCredit:
+		return ReasonInsufficientCredit
+	case ErrCreditUnavailable:
+		return ReasonCreditUnavailable
+	case ErrSymbolInAuction:
+		return ReasonAuction
+	case ErrSymbolHalted:
+		return ReasonHalted
+	case ErrPriceBand:
+		return ReasonPriceBand
+	case ErrNoLiquidity:
+		return ReasonNoLiquidity
+	case ErrDuplicateClientID:
+		return ReasonDuplicateClientID
+	case ErrContention:
+		return ReasonContention
+	}
+	return ReasonInternal
+}
+
+// ErrorCode returns the code of an error refusing a request: its rejection reason, or the code of
+// a request on an order that does not exist or is no longer open or made during maintenance
+func ErrorCode(err error) ReasonCode {
+	switch err {
+	case ErrOrderNotFound:
+		return ReasonOrderNotFound
+	case ErrOrderNotOpen:
+		return ReasonOrderNotOpen
+	case ErrMaintenance:
+		return ReasonMaintenance
+	}
+	return RejectReason(err)
+}
+
 // Order represents a trading order
 type Order struct {
 	OrderID           uint64
 	Symbol            string
 	Side              OrderSide
 	Type              OrderType
-	Price             sql.NullFloat64 // Changed to sql.NullFloat64
-	InitialQuantity   float64
-	RemainingQuantity float64
+	Price             NullDecimal // unset for market orders
+	InitialQuantity   Decimal
+	RemainingQuantity Decimal
 	Status            OrderStatus
 	CreatedAt         time.Time
+	ParentID          sql.NullInt64 // set for child orders of an algo parent order
+	OwnerID           string        // account that placed the order, empty when anonymous
+	TimeInForce       TimeInForce
+	TriggerPrice      NullDecimal    // set for stop and stop-limit orders
+	DisplayQuantity   NullDecimal    // set for iceberg orders, the size of each visible slice
+	VisibleQuantity   Decimal        `json:"-"` // not persisted, remaining size of an iceberg's current slice
+	GroupID           sql.NullInt64  // set for orders linked in an order group
+	Sequence          uint64         // time priority within a price level, increasing with each (re-)entry into the book
+	ClientOrderID     sql.NullString // set when the owner gave the order its own ID, unique per owner
+	ExpireAt          sql.NullTime   // set for good-till-date orders, when the order expires
+	ProtectionPrice   NullDecimal    // set for protected market and stop orders, the worst price they may fill at
+	QuoteQuantity     NullDecimal    // set for market buys sized by the quote amount to spend
+	Version           uint32         // bumped by every update, which must match the stored row's version
+	// Not persisted, a percentage from the best opposite price, or a stop's trigger price, the
+	// engine turns into the order's protection price
+	MaxSlippage NullDecimal `json:"-"`
+
+	// Not persisted, set on orders read through the service: the quantity filled so far and
+	// the average price of those fills, unset before the first
+	FilledQuantity Decimal
+	AvgPrice       NullDecimal
+
+	// Sandbox mode only, not persisted: simulated interest queued ahead of the resting order and
+	// when it last decayed
+	QueueAhead     Decimal   `json:"-"`
+	QueueDecayedAt time.Time `json:"-"`
+}
+
+// Resting reports whether an order with the status rests on the book, untouched or partially filled
+func (s OrderStatus) Resting() bool {
+	return s == StatusOpen || s == StatusPartiallyFilled
+}
+
+// RestingStatus returns the status of an order resting on the book: partially filled once any
+// of it traded
+func RestingStatus(order *Order) OrderStatus {
+	if order.RemainingQuantity < order.InitialQuantity {
+		return StatusPartiallyFilled
+	}
+	return StatusOpen
+}
+
+// OrderFilter selects one account's orders, newest first; zero fields match every order
+type OrderFilter struct {
+	OwnerID string
+	Symbol  string
+	Side    OrderSide
+	Status  OrderStatus
+	From    time.Time // created at or after
+	To      time.Time // created before
+
+	// A continued listing starts after the order created at AfterTime with ID AfterID, the last
+	// one of the previous page
+	AfterTime time.Time
+	AfterID   uint64
+
+	Limit int
+}
+
+// TradeFilter selects a page of one symbol's trades; zero bounds match every trade. Trade IDs
+// increase in execution order within a symbol, so either bound may be an ID or a time.
+type TradeFilter struct {
+	Symbol   string
+	BeforeID uint64    // IDs below
+	AfterID  uint64    // IDs above
+	Before   time.Time // executed before
+	After    time.Time // executed after
+	Limit    int
+}
+
+// Forward reports whether a filter pages forward from an after bound, listing trades oldest
+// first; every other filter lists them newest first
+func (f TradeFilter) Forward() bool {
+	return (f.AfterID != 0 || !f.After.IsZero()) && f.BeforeID == 0 && f.Before.IsZero()
+}
+
+// ParentOrder represents a server-side algo order sliced into child orders over time
+type ParentOrder struct {
+	ParentID          uint64
+	Symbol            string
+	Side              OrderSide
+	Strategy          AlgoStrategy
+	TotalQuantity     Decimal
+	FilledQuantity    Decimal
+	LimitPrice        NullDecimal // children are market orders when unset
+	ParticipationRate float64     // POV only: fraction of market volume to target
+	SliceInterval     time.Duration
+	NextSliceAt       time.Time
+	EndAt             time.Time
+	Status            ParentStatus
+	CreatedAt         time.Time
+	OwnerID           string
+}
+
+// OrderGroup represents orders linked so that the execution of one affects the others
+type OrderGroup struct {
+	GroupID   uint64
+	Type      GroupType
+	Symbol    string
+	Status    GroupStatus
+	OwnerID   string
+	CreatedAt time.Time
+}
+
+// BlockReport is a pre-negotiated block trade reported by one side, which is only printed and
+// settled once the other side affirms it
+type BlockReport struct {
+	BlockID    uint64
+	Symbol     string
+	BuyerID    string
+	SellerID   string
+	ReporterID string // the side that reported it, either BuyerID or SellerID
+	Price      Decimal
+	Quantity   Decimal
+	Status     BlockStatus
+	TradeID    uint64 // the printed trade, once affirmed
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // when a block still pending can no longer be affirmed
+}
+
+// Counterparty returns the side of a block trade that must affirm it
+func (b *BlockReport) Counterparty() string {
+	if b.ReporterID == b.BuyerID {
+		return b.SellerID
+	}
+	return b.BuyerID
 }
 
 // Trade represents an executed trade
 type Trade struct {
-	TradeID     uint64
-	Symbol      string
-	BuyOrderID  uint64
-	SellOrderID uint64
-	Price       float64
-	Quantity    float64
+	TradeID        uint64
+	CommitSequence uint64 // increases in commit order within a shard; only read by GetTradesAfter
+	Symbol         string
+	BuyOrderID     uint64
+	SellOrderID    uint64
+	Price          Decimal
+	Quantity       Decimal
+	CreatedAt      time.Time
+	PrintType      PrintType
+	BuyOwnerID     string `json:"-"` // not persisted, used for settlement
+	SellOwnerID    string `json:"-"`
+}
+
+// Execution represents one side's view of a trade, identified by its own execution ID
+type Execution struct {
+	ExecID    string
+	TradeID   uint64
+	OrderID   uint64
+	Symbol    string
+	Side      OrderSide
+	Liquidity Liquidity
+	Price     Decimal
+	Quantity  Decimal
+	CreatedAt time.Time
+}
+
+// AveragePrice returns the quantity-weighted average price of executions, invalid when there
+// are none. The notional is summed exactly, so large fills cannot overflow it.
+func AveragePrice(executions []*Execution) NullDecimal {
+	notional, quantity := new(big.Int), new(big.Int)
+	for _, e := range executions {
+		notional.Add(notional, new(big.Int).Mul(big.NewInt(int64(e.Price)), big.NewInt(int64(e.Quantity))))
+		quantity.Add(quantity, big.NewInt(int64(e.Quantity)))
+	}
+	if quantity.Sign() == 0 {
+		return NullDecimal{}
+	}
+	// Both sums are in Decimal units, so their quotient is the price in units; the average of
+	// representable prices is itself representable
+	average, remainder := new(big.Int).QuoRem(notional, quantity, new(big.Int))
+	if remainder.Lsh(remainder.Abs(remainder), 1).Cmp(quantity) >= 0 {
+		average.Add(average, big.NewInt(int64(notional.Sign())))
+	}
+	return NullDecimal{Decimal: Decimal(average.Int64()), Valid: true}
+}
+
+// FilledNotional returns the total quote amount of executions. Like Notional it is a float64,
+// summed exactly and converted once, since the total of large fills can exceed a Decimal.
+func FilledNotional(executions []*Execution) float64 {
+	product := new(big.Int)
+	for _, e := range executions {
+		product.Add(product, new(big.Int).Mul(big.NewInt(int64(e.Price)), big.NewInt(int64(e.Quantity))))
+	}
+	value, _ := new(big.Rat).SetFrac(product, big.NewInt(decimalScale*decimalScale)).Float64()
+	return value
+}
+
+// ExecutionReport is an order's state after a change, in the one shape every channel reports it
+// in: what happened, the cumulative fill and what remains, the average price of every fill and
+// the quantity and price of the fills that caused the report
+type ExecutionReport struct {
+	ExecType       ExecType
+	OrderID        uint64
+	ClientOrderID  string
+	Symbol         string
+	Side           OrderSide
+	Type           OrderType
+	Status         OrderStatus
+	Price          NullDecimal
+	Quantity       Decimal
+	CumQuantity    Decimal
+	LeavesQuantity Decimal
+	AvgPrice       NullDecimal
+	QuoteQuantity  NullDecimal // quote-sized orders, the amount to spend
+	CumQuote       float64     // the quote amount of every fill
+	LastQuantity   NullDecimal
+	LastPrice      NullDecimal
+	Reason         ReasonCode // rejections only, with Text describing the error
+	Text           string
+	Time           time.Time
+}
+
+// Balance represents an account's holdings of one currency
+type Balance struct {
+	AccountID string
+	Currency  string
+	Settled   float64
+	Pending   float64 // trade proceeds awaiting settlement, negative for pending deliveries
+	Held      float64 // what the account's open orders consume if they fill, derived from them
+}
+
+// LedgerPosting is an immutable double-entry record of one balance mutation; its entries sum to
+// zero in each currency
+type LedgerPosting struct {
+	PostingID   uint64
+	Kind        LedgerKind
+	ReferenceID sql.NullInt64 // the trade or settlement posted
+	CreatedAt   time.Time
+	Entries     []*LedgerEntry
+}
+
+// LedgerEntry is one line of a ledger posting
+type LedgerEntry struct {
+	EntryID   uint64
+	PostingID uint64
+	AccountID string // empty for external, anonymous and fees entries
+	Currency  string
+	Bucket    LedgerBucket
+	Amount    float64
+}
+
+// LedgerMismatch is a balance that differs from the sum of its account's ledger entries
+type LedgerMismatch struct {
+	AccountID     string
+	Currency      string
+	Settled       float64
+	Pending       float64
+	Held          float64
+	LedgerSettled float64
+	LedgerPending float64
+	LedgerHeld    float64
+}
+
+// UnbalancedPosting is a ledger posting whose entries do not sum to zero in a currency
+type UnbalancedPosting struct {
+	PostingID uint64
+	Currency  string
+	Sum       float64
+}
+
+// Reconciliation reports where the ledger and the balances disagree
+type Reconciliation struct {
+	Mismatches []*LedgerMismatch
+	Unbalanced []*UnbalancedPosting
+}
+
+// Settlement represents a pending balance delta created by a trade
+type Settlement struct {
+	SettlementID uint64
+	TradeID      uint64
+	AccountID    string
+	Currency     string
+	Amount       float64
+	SettleAt     time.Time
+	Status       SettlementStatus
+}
+
+// FeeEntry represents the fee charged, or rebate paid, to an account for one execution
+type FeeEntry struct {
+	EntryID    uint64
+	ExecID     string
+	TradeID    uint64
+	AccountID  string
+	Symbol     string
+	Currency   string
+	Liquidity  Liquidity
+	Rate       float64
+	Notional   float64
+	Amount     float64 // negative for a rebate
+	CreatedAt  time.Time
+	ScheduleID sql.NullInt64 // fee schedule the rate came from, unset for the default rates
+}
+
+// FeeSchedule is an alternative set of fee rates assigned to a stable percentage of accounts
+type FeeSchedule struct {
+	ScheduleID uint64
+	Name       string
+	MakerRate  float64
+	TakerRate  float64
+	Allocation int // percentage of accounts assigned to the schedule while it is active
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// Account is a registered trading account, the owner of orders, balances and signing keys
+type Account struct {
+	AccountID string
+	Name      string
+	CreatedAt time.Time
+}
+
+// KeyScope is a class of requests a signing key may sign
+type KeyScope string
+
+// Signing key scopes: reading the account's data, order entry, cancels, the admin API and the
+// compliance event feed of every account's orders
+const (
+	ScopeRead       KeyScope = "read"
+	ScopeTrade      KeyScope = "trade"
+	ScopeCancel     KeyScope = "cancel"
+	ScopeAdmin      KeyScope = "admin"
+	ScopeCompliance KeyScope = "compliance"
+)
+
+// DefaultScopes are the scopes of a key created without any, everything but the admin API and
+// the compliance feed
+var DefaultScopes = []KeyScope{ScopeRead, ScopeTrade, ScopeCancel}
+
+// SigningKey is an HMAC secret an account signs its requests with
+type SigningKey struct {
+	KeyID      string
+	AccountID  string
+	Secret     string `json:"-"`
+	Scopes     []KeyScope
+	AllowedIPs []string // addresses or CIDR prefixes the key may sign from, any when empty
+	CreatedAt  time.Time
+	RevokedAt  sql.NullTime
+}
+
+// HasScope reports whether the key may sign requests of the scope
+func (k *SigningKey) HasScope(scope KeyScope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// AllowsIP reports whether the key may sign requests from a client IP
+func (k *SigningKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range k.AllowedIPs {
+		if prefix, err := ParseAllowedIP(allowed); err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAllowedIP parses an allowed IP entry, an address or a CIDR prefix
+func ParseAllowedIP(allowed string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(allowed); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(allowed)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// Webhook is a URL an account's order notifications are posted to, signed with its secret
+type Webhook struct {
+	WebhookID uint64
+	AccountID string
+	URL       string
+	Secret    string `json:"-"`
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is one notification queued for a webhook and its delivery attempts so far
+type WebhookDelivery struct {
+	DeliveryID    uint64
+	WebhookID     uint64
+	Event         string
+	Payload       string
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+// OrderEvent is a snapshot of an order taken in the same transaction as the change it records
+type OrderEvent struct {
+	EventID           uint64 // assigned on insert, so not in commit order when engines commit concurrently
+	CommitSequence    uint64 // increases in commit order within a shard; 0 until the outbox is sequenced
+	Kind              OrderEventKind
+	OrderID           uint64
+	Symbol            string
+	Side              OrderSide
+	Type              OrderType
+	Status            OrderStatus
+	Price             NullDecimal
+	InitialQuantity   Decimal
+	RemainingQuantity Decimal
+	OwnerID           string
+	Actor             string     // the account or actor that made the change
+	Reason            ReasonCode // set for cancels, expiries and rejections
+	CreatedAt         time.Time
+}
+
+// OrderChange is who made a change to an order and why, as its order event records them
+type OrderChange struct {
+	Actor  string     // the account that asked for the change, or ActorEngine, ActorSystem or ActorOperator
+	Reason ReasonCode // why the order was canceled or expired, when it was
+}
+
+// Invoice represents an account's fees for one calendar month
+type Invoice struct {
+	InvoiceID   uint64
+	AccountID   string
+	PeriodStart time.Time // first day of the month, UTC
+	Lines       []InvoiceLine
+	Totals      []InvoiceTotal
 	CreatedAt   time.Time
 }
 
+// InvoiceLine aggregates an invoice's fees for one day, symbol and currency
+type InvoiceLine struct {
+	Day      time.Time
+	Symbol   string
+	Currency string
+	Fills    int
+	Notional float64
+	Amount   float64
+}
+
+// InvoiceTotal is an invoice's total fees in one currency
+type InvoiceTotal struct {
+	Currency string
+	Amount   float64
+}
+
 // OrderBookEntry represents orders at a specific price level
 type OrderBookEntry struct {
-	Price  float64
+	Price  Decimal
 	Orders []*Order
-}
\ No newline at end of file
+}
+
+// PriceLevel represents aggregated quantity resting at a single price
+type PriceLevel struct {
+	Price      Decimal
+	Quantity   Decimal
+	OrderCount int
+}
+
+// DepthSnapshot represents the top levels of a symbol's book at a point in time
+type DepthSnapshot struct {
+	SnapshotID uint64
+	Symbol     string
+	Bids       []PriceLevel
+	Asks       []PriceLevel
+	CapturedAt time.Time
+}
+
+// MarketQuality represents the spread and near-touch depth of a symbol's book at a point in
+// time. The best prices and spread are null while a side is empty; depth is the notional
+// resting within the configured band of the mid, or of the only populated side's best price.
+type MarketQuality struct {
+	SampleID   uint64
+	Symbol     string
+	BestBid    NullDecimal
+	BestAsk    NullDecimal
+	SpreadBps  sql.NullFloat64
+	BidDepth   float64
+	AskDepth   float64
+	CapturedAt time.Time
+}
+
+// CandleInterval is the length of a candle's bar
+type CandleInterval string
+
+// Candle intervals
+const (
+	Interval1m CandleInterval = "1m"
+	Interval5m CandleInterval = "5m"
+	Interval1h CandleInterval = "1h"
+	Interval1d CandleInterval = "1d"
+)
+
+// CandleIntervals lists every interval candles are kept at
+var CandleIntervals = []CandleInterval{Interval1m, Interval5m, Interval1h, Interval1d}
+
+// Duration returns the length of the interval's bars
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case Interval1m:
+		return time.Minute
+	case Interval5m:
+		return 5 * time.Minute
+	case Interval1h:
+		return time.Hour
+	case Interval1d:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// Candle represents the OHLCV bar of a symbol's book trades over one interval starting at OpenTime,
+// UTC-aligned. FirstSequence and LastSequence bound the commit sequences of the trades it covers,
+// which lets a bar be extended by a batch of later trades exactly once.
+type Candle struct {
+	Symbol        string
+	Interval      CandleInterval
+	OpenTime      time.Time
+	Open          Decimal
+	High          Decimal
+	Low           Decimal
+	Close         Decimal
+	Volume        Decimal
+	Trades        int
+	FirstSequence uint64
+	LastSequence  uint64
+}
+
+// LastLookOutcome is how a liquidity provider answered a last look
+type LastLookOutcome string
+
+// Last look outcomes
+const (
+	LastLookAccepted LastLookOutcome = "accepted"
+	LastLookRejected LastLookOutcome = "rejected"
+	LastLookTimedOut LastLookOutcome = "timed_out" // no answer within the window, so the match went ahead
+)
+
+// LastLook is a match of a designated liquidity provider's resting order offered to the
+// provider to reject before it prints
+type LastLook struct {
+	LastLookID   uint64
+	Symbol       string
+	OrderID      uint64 // the provider's resting order
+	ProviderID   string
+	TakerOrderID uint64
+	Side         OrderSide // of the resting order
+	Price        Decimal
+	Quantity     Decimal // the most the match may fill
+	Outcome      LastLookOutcome
+	RequestedAt  time.Time
+	Deadline     time.Time `json:"-"` // not persisted
+	DecidedAt    time.Time
+}
+
+// LastLookStats counts a liquidity provider's last look answers
+type LastLookStats struct {
+	ProviderID string
+	Requests   int
+	Rejects    int
+	Timeouts   int
+}
+
+// JournalKind is the kind of order command recorded in the engine journal
+type JournalKind string
+
+// Engine journal command kinds
+const (
+	JournalPlace          JournalKind = "place"
+	JournalOCO            JournalKind = "oco"
+	JournalAmend          JournalKind = "amend"
+	JournalCancel         JournalKind = "cancel"
+	JournalCancelQuantity JournalKind = "cancel_quantity"
+	JournalCancelGroup    JournalKind = "cancel_group"
+	JournalExpire         JournalKind = "expire"
+	JournalStale          JournalKind = "stale"
+	JournalTickSize       JournalKind = "tick_size"
+	JournalAuction        JournalKind = "auction"
+	JournalUncross        JournalKind = "uncross"
+)
+
+// JournalEntry is an order command a symbol's engine applied, numbered in the order the engine
+// applied it, along with the trades it printed. A command is stored in a part per transaction it
+// committed, each with that transaction's trades and last look declines; an entry read back
+// holds every part.
+type JournalEntry struct {
+	Symbol    string
+	Sequence  uint64
+	Part      int // the part being appended, counting the command's transactions from 0
+	Kind      JournalKind
+	Orders    []*Order    // place: the order as submitted; oco: its limit and stop orders
+	OrderID   uint64      // amend, cancel, cancel_quantity, expire and stale
+	GroupID   uint64      // oco: the group placed; cancel_group: the group canceled
+	Price     NullDecimal // amend: the new price, unset to keep it
+	Quantity  Decimal     // amend: the new total quantity, 0 to keep it; cancel_quantity: the quantity canceled
+	Change    OrderChange // cancel: who canceled the order and why
+	TickSize  Decimal     // tick_size: the new tick size
+	Policy    string      // tick_size: what became of orders off the new grid
+	Auction   string      // auction: why the auction started
+	Trades    []*Trade
+	Declined  map[uint64][]uint64 // resting orders whose providers rejected their match on last look, by executing order
+	CreatedAt time.Time
+}
+
+// DailySummary is the tamper-evident record of one UTC day's trades and ledger entries: a digest
+// of each, chained to the previous day's summary through its hash
+type DailySummary struct {
+	Day           time.Time
+	Trades        int
+	TradeDigest   string
+	LedgerEntries int // settlements and fee entries of the day's trades
+	LedgerDigest  string
+	PreviousHash  string // empty for the first summary
+	Hash          string
+	CreatedAt     time.Time
+}
+
+// BookSnapshot is a symbol's book of open orders as it stood once the order event with
+// LastEventID was committed, price level by price level in time priority
+type BookSnapshot struct {
+	Symbol      string
+	LastEventID uint64
+	Orders      []*Order
+	CreatedAt   time.Time
+}
+
+// SymbolStatus is whether a listed symbol takes orders
+type SymbolStatus string
+
+// Symbol listing statuses
+const (
+	SymbolActive   SymbolStatus = "active"
+	SymbolHalted   SymbolStatus = "halted" // takes cancels but no orders until resumed
+	SymbolDelisted SymbolStatus = "delisted"
+)
+
+// Symbol is an instrument listed in the symbol registry, the only symbols orders are taken for
+type Symbol struct {
+	Symbol        string
+	BaseCurrency  string
+	QuoteCurrency string
+	LotSize       Decimal // order quantities must be multiples of it, 0 accepts any quantity
+	MinNotional   float64 // smallest value in quote currency of a limit-priced order, 0 for none
+	TickSize      Decimal // current tick size, kept with tick size changes rather than in the registry
+	Status        SymbolStatus
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+)
+
+// SaveAccount persists a new account, its name sealed
+func (r *MySQLRepository) SaveAccount(ctx context.Context, account *models.Account) error {
+	name, err := r.seal(accountName, account.AccountID, account.Name)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO accounts (account_id, name, created_at)
+		VALUES (?, ?, ?)`
+	_, err = r.db.ExecContext(ctx, query, account.AccountID, name, account.CreatedAt)
+	return err
+}
+
+// GetAccount retrieves an account by its ID
+func (r *MySQLRepository) GetAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	query := `
+		SELECT account_id, name, created_at
+		FROM accounts
+		WHERE account_id = ?`
+	account := &models.Account{}
+	err := r.db.QueryRowContext(ctx, query, accountID).Scan(&account.AccountID, &account.Name, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if account.Name, err = r.open(accountName, account.AccountID, account.Name); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
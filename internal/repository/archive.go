@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// GetTradesBetween retrieves up to limit trades created in [from, to) after the given trade ID,
+// in trade ID order
+func (r *MySQLRepository) GetTradesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Trade, error) {
+	query := `
+		SELECT t.trade_id, t.symbol, t.buy_order_id, t.sell_order_id, t.price, t.quantity, t.created_at,
+			t.print_type, COALESCE(b.owner_id, ''), COALESCE(s.owner_id, '')
+		FROM trades t
+		LEFT JOIN orders b ON b.order_id = t.buy_order_id
+		LEFT JOIN orders s ON s.order_id = t.sell_order_id
+		WHERE t.created_at >= ? AND t.created_at < ? AND t.trade_id > ?
+		ORDER BY t.trade_id
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, from, to, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []*models.Trade
+	for rows.Next() {
+		trade := &models.Trade{}
+		if err := rows.Scan(&trade.TradeID, &trade.Symbol, &trade.BuyOrderID, &trade.SellOrderID, &trade.Price,
+			&trade.Quantity, &trade.CreatedAt, &trade.PrintType, &trade.BuyOwnerID, &trade.SellOwnerID); err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	return trades, rows.Err()
+}
+
+// GetOrderEventsBetween retrieves up to limit order events created in [from, to) after the given
+// event ID, in event ID order
+func (r *MySQLRepository) GetOrderEventsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.OrderEvent, error) {
+	query := `
+		SELECT ` + orderEventColumns + `
+		FROM order_events
+		WHERE created_at >= ? AND created_at < ? AND event_id > ?
+		ORDER BY event_id
+		LIMIT ?`
+	return queryOrderEvents(ctx, r.db, query, from, to, afterID, limit)
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// GetFirstTradeTime returns when the first trade was made, the zero time when there are none
+func (r *MySQLRepository) GetFirstTradeTime(ctx context.Context) (time.Time, error) {
+	var first time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT created_at FROM trades ORDER BY created_at LIMIT 1`).Scan(&first)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return first, err
+}
+
+// GetSettlementsBetween retrieves up to limit settlements of trades created in [from, to) after
+// the given settlement ID, in settlement ID order
+func (r *MySQLRepository) GetSettlementsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Settlement, error) {
+	query := `
+		SELECT s.settlement_id, s.trade_id, s.account_id, s.currency, s.amount, s.settle_at, s.status
+		FROM settlements s
+		JOIN trades t ON t.trade_id = s.trade_id
+		WHERE t.created_at >= ? AND t.created_at < ? AND s.settlement_id > ?
+		ORDER BY s.settlement_id
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, from, to, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settlements []*models.Settlement
+	for rows.Next() {
+		s := &models.Settlement{}
+		if err := rows.Scan(&s.SettlementID, &s.TradeID, &s.AccountID, &s.Currency, &s.Amount, &s.SettleAt, &s.Status); err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, s)
+	}
+	return settlements, rows.Err()
+}
+
+// GetFeeEntriesBetween retrieves up to limit fee ledger entries created in [from, to) after the
+// given entry ID, in entry ID order
+func (r *MySQLRepository) GetFeeEntriesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.FeeEntry, error) {
+	query := `
+		SELECT entry_id, exec_id, trade_id, account_id, symbol, currency, liquidity, rate, notional, amount,
+			created_at, schedule_id
+		FROM fee_ledger
+		WHERE created_at >= ? AND created_at < ? AND entry_id > ?
+		ORDER BY entry_id
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, from, to, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.FeeEntry
+	for rows.Next() {
+		e := &models.FeeEntry{}
+		if err := rows.Scan(&e.EntryID, &e.ExecID, &e.TradeID, &e.AccountID, &e.Symbol, &e.Currency, &e.Liquidity,
+			&e.Rate, &e.Notional, &e.Amount, &e.CreatedAt, &e.ScheduleID); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SaveDailySummary stores a day's summary; a day is summarized once
+func (r *MySQLRepository) SaveDailySummary(ctx context.Context, s *models.DailySummary) error {
+	query := `
+		INSERT INTO daily_summaries (day, trades, trade_digest, ledger_entries, ledger_digest, previous_hash, hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, s.Day, s.Trades, s.TradeDigest, s.LedgerEntries, s.LedgerDigest, s.PreviousHash, s.Hash, s.CreatedAt)
+	return err
+}
+
+// dailySummaryColumns lists the daily_summaries table columns in the order used by scanDailySummary
+const dailySummaryColumns = `day, trades, trade_digest, ledger_entries, ledger_digest, previous_hash, hash, created_at`
+
+// scanDailySummary reads a daily summary selected with dailySummaryColumns
+func scanDailySummary(row rowScanner) (*models.DailySummary, error) {
+	s := &models.DailySummary{}
+	err := row.Scan(&s.Day, &s.Trades, &s.TradeDigest, &s.LedgerEntries, &s.LedgerDigest, &s.PreviousHash, &s.Hash, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetLastDailySummary retrieves the latest day's summary, nil when no day was summarized
+func (r *MySQLRepository) GetLastDailySummary(ctx context.Context) (*models.DailySummary, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+dailySummaryColumns+` FROM daily_summaries ORDER BY day DESC LIMIT 1`)
+	s, err := scanDailySummary(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// GetDailySummaries retrieves the summaries of the days in [from, to], oldest first
+func (r *MySQLRepository) GetDailySummaries(ctx context.Context, from, to time.Time) ([]*models.DailySummary, error) {
+	query := `SELECT ` + dailySummaryColumns + ` FROM daily_summaries WHERE day >= ? AND day <= ? ORDER BY day`
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*models.DailySummary
+	for rows.Next() {
+		s, err := scanDailySummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
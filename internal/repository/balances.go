@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// GetBalances retrieves all balances of an account
+func (r *MySQLRepository) GetBalances(ctx context.Context, accountID string) ([]*models.Balance, error) {
+	query := `
+		SELECT account_id, currency, settled, pending
+		FROM balances
+		WHERE account_id = ?
+		ORDER BY currency`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*models.Balance
+	for rows.Next() {
+		balance := &models.Balance{}
+		if err := rows.Scan(&balance.AccountID, &balance.Currency, &balance.Settled, &balance.Pending); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// GetBalanceTx locks and retrieves an account's balance of a currency within a transaction, a
+// zero balance when the account holds none
+func (r *MySQLRepository) GetBalanceTx(ctx context.Context, tx Tx, accountID, currency string) (*models.Balance, error) {
+	query := `
+		SELECT account_id, currency, settled, pending
+		FROM balances
+		WHERE account_id = ? AND currency = ?` + r.forUpdate()
+	rows, err := tx.QueryContext(ctx, query, accountID, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balance := &models.Balance{AccountID: accountID, Currency: currency}
+	if rows.Next() {
+		if err := rows.Scan(&balance.AccountID, &balance.Currency, &balance.Settled, &balance.Pending); err != nil {
+			return nil, err
+		}
+	}
+	return balance, rows.Err()
+}
+
+// GetHeldTx locks an account's balances within a transaction and returns what their held balances
+// record by currency, leaving out currencies with nothing held
+func (r *MySQLRepository) GetHeldTx(ctx context.Context, tx Tx, accountID string) (map[string]float64, error) {
+	query := `
+		SELECT currency, held
+		FROM balances
+		WHERE account_id = ?` + r.forUpdate()
+	rows, err := tx.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	held := make(map[string]float64)
+	for rows.Next() {
+		var currency string
+		var amount float64
+		if err := rows.Scan(&currency, &amount); err != nil {
+			return nil, err
+		}
+		if amount != 0 {
+			held[currency] = amount
+		}
+	}
+	return held, rows.Err()
+}
+
+// settlementColumns lists the settlements table columns in the order used by scanSettlement
+const settlementColumns = `settlement_id, trade_id, account_id, currency, amount, settle_at, status`
+
+// querySettlements runs a query selecting settlementColumns and collects the results
+func querySettlements(ctx context.Context, q querier, query string, args ...any) ([]*models.Settlement, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settlements []*models.Settlement
+	for rows.Next() {
+		st := &models.Settlement{}
+		if err := rows.Scan(&st.SettlementID, &st.TradeID, &st.AccountID, &st.Currency, &st.Amount,
+			&st.SettleAt, &st.Status); err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, st)
+	}
+	return settlements, rows.Err()
+}
+
+// SaveSettlementTx persists a pending settlement within a transaction
+func (r *MySQLRepository) SaveSettlementTx(ctx context.Context, tx Tx, settlement *models.Settlement) error {
+	query := `
+		INSERT INTO settlements (trade_id, account_id, currency, amount, settle_at, status)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, settlement.TradeID, settlement.AccountID, settlement.Currency,
+		settlement.Amount, settlement.SettleAt, settlement.Status)
+	if err != nil {
+		return err
+	}
+	settlement.SettlementID, err = insertID(result)
+	return err
+}
+
+// GetDueSettlementsTx locks and retrieves pending settlements due at or before the given time
+func (r *MySQLRepository) GetDueSettlementsTx(ctx context.Context, tx Tx, before time.Time, limit int) ([]*models.Settlement, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlements
+		WHERE status = 'pending' AND settle_at <= ?
+		ORDER BY settle_at, settlement_id
+		LIMIT ?` + r.forUpdate()
+	return querySettlements(ctx, tx, query, before, limit)
+}
+
+// MarkSettlementSettledTx marks a settlement as finalized within a transaction
+func (r *MySQLRepository) MarkSettlementSettledTx(ctx context.Context, tx Tx, settlementID uint64) error {
+	query := `
+		UPDATE settlements
+		SET status = 'settled'
+		WHERE settlement_id = ?`
+	_, err := tx.ExecContext(ctx, query, settlementID)
+	return err
+}
+
+// GetSettlements retrieves an account's settlements with the given status, oldest first
+func (r *MySQLRepository) GetSettlements(ctx context.Context, accountID string, status models.SettlementStatus) ([]*models.Settlement, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlements
+		WHERE account_id = ? AND status = ?
+		ORDER BY settle_at, settlement_id`
+	return querySettlements(ctx, r.db, query, accountID, status)
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// SaveFeeEntryTx records an execution's fee in the fee ledger within a transaction
+func (r *MySQLRepository) SaveFeeEntryTx(ctx context.Context, tx Tx, entry *models.FeeEntry) error {
+	query := `
+		INSERT INTO fee_ledger (exec_id, trade_id, account_id, symbol, currency, liquidity, rate, notional, amount, created_at,
+			schedule_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, entry.ExecID, entry.TradeID, entry.AccountID, entry.Symbol, entry.Currency,
+		entry.Liquidity, entry.Rate, entry.Notional, entry.Amount, entry.CreatedAt, entry.ScheduleID)
+	if err != nil {
+		return err
+	}
+	entry.EntryID, err = insertID(result)
+	return err
+}
+
+// GetUninvoicedAccounts returns accounts with fee ledger entries in [from, to) and no invoice for the period starting at from
+func (r *MySQLRepository) GetUninvoicedAccounts(ctx context.Context, from, to time.Time) ([]string, error) {
+	query := `
+		SELECT DISTINCT f.account_id
+		FROM fee_ledger f
+		LEFT JOIN invoices i ON i.account_id = f.account_id AND i.period_start = ?
+		WHERE f.created_at >= ? AND f.created_at < ? AND i.invoice_id IS NULL
+		ORDER BY f.account_id`
+	rows, err := r.db.QueryContext(ctx, query, from, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []string
+	for rows.Next() {
+		var accountID string
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, accountID)
+	}
+	return accounts, rows.Err()
+}
+
+// invoicedAccounts returns the accounts already invoiced for the period starting at periodStart
+func (r *MySQLRepository) invoicedAccounts(ctx context.Context, periodStart time.Time) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT account_id FROM invoices WHERE period_start = ?`, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]bool)
+	for rows.Next() {
+		var accountID string
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		accounts[accountID] = true
+	}
+	return accounts, rows.Err()
+}
+
+// feeLinesQuery aggregates an account's fee ledger entries in a time range per day, symbol and currency
+const feeLinesQuery = `
+	SELECT DATE(created_at) AS day, symbol, currency, COUNT(*), SUM(notional), SUM(amount)
+	FROM fee_ledger
+	WHERE account_id = ? AND created_at >= ? AND created_at < ?
+	GROUP BY day, symbol, currency
+	ORDER BY day, symbol, currency`
+
+// GetFeeLinesTx aggregates an account's fee ledger entries in [from, to) per day, symbol and currency within a transaction
+func (r *MySQLRepository) GetFeeLinesTx(ctx context.Context, tx Tx, accountID string, from, to time.Time) ([]models.InvoiceLine, error) {
+	return queryInvoiceLines(ctx, tx, feeLinesQuery, accountID, from, to)
+}
+
+// queryInvoiceLines runs a query selecting day, symbol, currency, fills, notional and amount
+func queryInvoiceLines(ctx context.Context, q querier, query string, args ...any) ([]models.InvoiceLine, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.InvoiceLine
+	for rows.Next() {
+		var line models.InvoiceLine
+		if err := rows.Scan((*calendarDay)(&line.Day), &line.Symbol, &line.Currency, &line.Fills, &line.Notional, &line.Amount); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// calendarDay scans a computed DATE, which MySQL returns as a time and SQLite as YYYY-MM-DD text
+type calendarDay time.Time
+
+// Scan implements sql.Scanner
+func (d *calendarDay) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = calendarDay(v)
+		return nil
+	case []byte:
+		return d.Scan(string(v))
+	case string:
+		day, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return err
+		}
+		*d = calendarDay(day)
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T as a day", src)
+}
+
+// SaveInvoiceTx persists an invoice and its line items within a transaction
+func (r *MySQLRepository) SaveInvoiceTx(ctx context.Context, tx Tx, invoice *models.Invoice) error {
+	query := `
+		INSERT INTO invoices (account_id, period_start, created_at)
+		VALUES (?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, invoice.AccountID, invoice.PeriodStart, invoice.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	invoice.InvoiceID = uint64(id)
+
+	lineQuery := `
+		INSERT INTO invoice_lines (invoice_id, day, symbol, currency, fills, notional, amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	for _, line := range invoice.Lines {
+		if _, err := tx.ExecContext(ctx, lineQuery, invoice.InvoiceID, line.Day, line.Symbol, line.Currency,
+			line.Fills, line.Notional, line.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInvoices retrieves an account's invoices without line items, newest period first
+func (r *MySQLRepository) GetInvoices(ctx context.Context, accountID string) ([]*models.Invoice, error) {
+	query := `
+		SELECT invoice_id, account_id, period_start, created_at
+		FROM invoices
+		WHERE account_id = ?
+		ORDER BY period_start DESC`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []*models.Invoice
+	for rows.Next() {
+		invoice := &models.Invoice{}
+		if err := rows.Scan(&invoice.InvoiceID, &invoice.AccountID, &invoice.PeriodStart, &invoice.CreatedAt); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+	return invoices, rows.Err()
+}
+
+// GetInvoice retrieves an invoice with its line items
+func (r *MySQLRepository) GetInvoice(ctx context.Context, invoiceID uint64) (*models.Invoice, error) {
+	query := `
+		SELECT invoice_id, account_id, period_start, created_at
+		FROM invoices
+		WHERE invoice_id = ?`
+	invoice := &models.Invoice{}
+	err := r.db.QueryRowContext(ctx, query, invoiceID).Scan(&invoice.InvoiceID, &invoice.AccountID, &invoice.PeriodStart, &invoice.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lineQuery := `
+		SELECT day, symbol, currency, fills, notional, amount
+		FROM invoice_lines
+		WHERE invoice_id = ?
+		ORDER BY day, symbol, currency`
+	invoice.Lines, err = queryInvoiceLines(ctx, r.db, lineQuery, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+)
+
+// blockReportColumns lists the block_trades table columns in the order used by scanBlockReport
+const blockReportColumns = `block_id, symbol, buyer_id, seller_id, reporter_id, price, quantity, status,
+	COALESCE(trade_id, 0), created_at, expires_at`
+
+// scanBlockReport reads a block trade report selected with blockReportColumns
+func scanBlockReport(row rowScanner) (*models.BlockReport, error) {
+	report := &models.BlockReport{}
+	err := row.Scan(&report.BlockID, &report.Symbol, &report.BuyerID, &report.SellerID, &report.ReporterID,
+		&report.Price, &report.Quantity, &report.Status, &report.TradeID, &report.CreatedAt, &report.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// SaveBlockReportTx persists a newly reported block trade within a transaction
+func (r *MySQLRepository) SaveBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error {
+	query := `
+		INSERT INTO block_trades (block_id, symbol, buyer_id, seller_id, reporter_id, price, quantity, status,
+			created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, query, report.BlockID, report.Symbol, report.BuyerID, report.SellerID,
+		report.ReporterID, report.Price, report.Quantity, report.Status, report.CreatedAt, report.ExpiresAt)
+	return err
+}
+
+// UpdateBlockReportTx updates a block trade report's status, and the trade printed once it is
+// affirmed, within a transaction
+func (r *MySQLRepository) UpdateBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error {
+	var tradeID any
+	if report.TradeID != 0 {
+		tradeID = report.TradeID
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE block_trades SET status = ?, trade_id = ? WHERE block_id = ?`,
+		report.Status, tradeID, report.BlockID)
+	return err
+}
+
+// GetBlockReport retrieves a block trade report by its ID
+func (r *MySQLRepository) GetBlockReport(ctx context.Context, blockID uint64) (*models.BlockReport, error) {
+	query := `
+		SELECT ` + blockReportColumns + `
+		FROM block_trades
+		WHERE block_id = ?`
+	report, err := scanBlockReport(r.db.QueryRowContext(ctx, query, blockID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetPendingBlockReports retrieves the pending block trade reports either side of which is the
+// account, oldest first
+func (r *MySQLRepository) GetPendingBlockReports(ctx context.Context, accountID string) ([]*models.BlockReport, error) {
+	query := `
+		SELECT ` + blockReportColumns + `
+		FROM block_trades
+		WHERE status = 'pending' AND (buyer_id = ? OR seller_id = ?)
+		ORDER BY created_at, block_id`
+	rows, err := r.db.QueryContext(ctx, query, accountID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*models.BlockReport
+	for rows.Next() {
+		report, err := scanBlockReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// snapshotOrder is the stored form of an open order in a book snapshot
+type snapshotOrder struct {
+	OrderID           uint64             `json:"order_id"`
+	Side              models.OrderSide   `json:"side"`
+	Type              models.OrderType   `json:"type"`
+	Price             models.NullDecimal `json:"price"`
+	InitialQuantity   models.Decimal     `json:"initial_quantity"`
+	RemainingQuantity models.Decimal     `json:"remaining_quantity"`
+	CreatedAt         time.Time          `json:"created_at"`
+	ParentID          *int64             `json:"parent_id,omitempty"`
+	OwnerID           string             `json:"owner_id,omitempty"`
+	TimeInForce       models.TimeInForce `json:"time_in_force"`
+	TriggerPrice      models.NullDecimal `json:"trigger_price"`
+	DisplayQuantity   models.NullDecimal `json:"display_quantity"`
+	GroupID           *int64             `json:"group_id,omitempty"`
+	Sequence          uint64             `json:"sequence"`
+	ClientOrderID     *string            `json:"client_order_id,omitempty"`
+	ExpireAt          *time.Time         `json:"expire_at,omitempty"`
+	Version           uint32             `json:"version"`
+}
+
+// encodeBook serializes a snapshot's orders as gzipped JSON
+func encodeBook(orders []*models.Order) ([]byte, error) {
+	stored := make([]snapshotOrder, len(orders))
+	for i, o := range orders {
+		stored[i] = snapshotOrder{
+			OrderID:           o.OrderID,
+			Side:              o.Side,
+			Type:              o.Type,
+			Price:             o.Price,
+			InitialQuantity:   o.InitialQuantity,
+			RemainingQuantity: o.RemainingQuantity,
+			CreatedAt:         o.CreatedAt,
+			OwnerID:           o.OwnerID,
+			TimeInForce:       o.TimeInForce,
+			TriggerPrice:      o.TriggerPrice,
+			DisplayQuantity:   o.DisplayQuantity,
+			Sequence:          o.Sequence,
+			Version:           o.Version,
+		}
+		if o.ParentID.Valid {
+			stored[i].ParentID = &o.ParentID.Int64
+		}
+		if o.GroupID.Valid {
+			stored[i].GroupID = &o.GroupID.Int64
+		}
+		if o.ClientOrderID.Valid {
+			stored[i].ClientOrderID = &o.ClientOrderID.String
+		}
+		if o.ExpireAt.Valid {
+			stored[i].ExpireAt = &o.ExpireAt.Time
+		}
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(w).Encode(stored); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBook parses the orders of a symbol's snapshot stored by encodeBook
+func decodeBook(symbol string, book []byte) ([]*models.Order, error) {
+	r, err := gzip.NewReader(bytes.NewReader(book))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var stored []snapshotOrder
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+
+	orders := make([]*models.Order, len(stored))
+	for i, o := range stored {
+		orders[i] = &models.Order{
+			OrderID:           o.OrderID,
+			Symbol:            symbol,
+			Side:              o.Side,
+			Type:              o.Type,
+			Price:             o.Price,
+			InitialQuantity:   o.InitialQuantity,
+			RemainingQuantity: o.RemainingQuantity,
+			CreatedAt:         o.CreatedAt,
+			OwnerID:           o.OwnerID,
+			TimeInForce:       o.TimeInForce,
+			TriggerPrice:      o.TriggerPrice,
+			DisplayQuantity:   o.DisplayQuantity,
+			Sequence:          o.Sequence,
+			Version:           o.Version,
+		}
+		orders[i].Status = models.RestingStatus(orders[i])
+		if o.ParentID != nil {
+			orders[i].ParentID = sql.NullInt64{Int64: *o.ParentID, Valid: true}
+		}
+		if o.GroupID != nil {
+			orders[i].GroupID = sql.NullInt64{Int64: *o.GroupID, Valid: true}
+		}
+		if o.ClientOrderID != nil {
+			orders[i].ClientOrderID = sql.NullString{String: *o.ClientOrderID, Valid: true}
+		}
+		if o.ExpireAt != nil {
+			orders[i].ExpireAt = sql.NullTime{Time: *o.ExpireAt, Valid: true}
+		}
+	}
+	return orders, nil
+}
+
+// SaveBookSnapshot stores a symbol's book snapshot, replacing its previous one
+func (r *MySQLRepository) SaveBookSnapshot(ctx context.Context, snapshot *models.BookSnapshot) error {
+	book, err := encodeBook(snapshot.Orders)
+	if err != nil {
+		return err
+	}
+	upsert := `
+		ON DUPLICATE KEY UPDATE last_event_id = VALUES(last_event_id), orders = VALUES(orders),
+			book = VALUES(book), created_at = VALUES(created_at)`
+	if r.sqlite {
+		upsert = `
+		ON CONFLICT (symbol) DO UPDATE SET last_event_id = excluded.last_event_id, orders = excluded.orders,
+			book = excluded.book, created_at = excluded.created_at`
+	}
+	query := `
+		INSERT INTO book_snapshots (symbol, last_event_id, orders, book, created_at)
+		VALUES (?, ?, ?, ?, ?)` + upsert
+	_, err = r.db.ExecContext(ctx, query, snapshot.Symbol, snapshot.LastEventID, len(snapshot.Orders), book, snapshot.CreatedAt)
+	return err
+}
+
+// GetBookSnapshot retrieves a symbol's latest book snapshot, nil when it has none
+func (r *MySQLRepository) GetBookSnapshot(ctx context.Context, symbol string) (*models.BookSnapshot, error) {
+	snapshot := &models.BookSnapshot{Symbol: symbol}
+	var book []byte
+	err := r.db.QueryRowContext(ctx, `SELECT last_event_id, book, created_at FROM book_snapshots WHERE symbol = ?`, symbol).
+		Scan(&snapshot.LastEventID, &book, &snapshot.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Orders, err = decodeBook(symbol, book); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// GetChangedOrders retrieves the current state of a symbol's orders with order events after the
+// given event ID
+func (r *MySQLRepository) GetChangedOrders(ctx context.Context, symbol string, afterEventID uint64) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE order_id IN (SELECT order_id FROM order_events WHERE symbol = ? AND event_id > ?)`
+	return queryOrders(ctx, r.db, query, symbol, afterEventID)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// SaveCandles merges bars built from a batch of trades into the stored ones in one transaction.
+// A stored bar is only extended when it ends before the new bar's first trade, so saving the
+// same batch again, after a crash before its cursor was saved, changes nothing.
+func (r *MySQLRepository) SaveCandles(ctx context.Context, candles []*models.Candle) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Assignments apply left to right, so last_sequence is updated after the columns testing it
+	upsert := `
+		ON DUPLICATE KEY UPDATE
+			high = IF(last_sequence < VALUES(first_sequence), GREATEST(high, VALUES(high)), high),
+			low = IF(last_sequence < VALUES(first_sequence), LEAST(low, VALUES(low)), low),
+			close = IF(last_sequence < VALUES(first_sequence), VALUES(close), close),
+			volume = IF(last_sequence < VALUES(first_sequence), volume + VALUES(volume), volume),
+			trade_count = IF(last_sequence < VALUES(first_sequence), trade_count + VALUES(trade_count), trade_count),
+			last_sequence = IF(last_sequence < VALUES(first_sequence), VALUES(last_sequence), last_sequence)`
+	if r.sqlite {
+		// SQLite's assignments all see the row as it was
+		upsert = `
+		ON CONFLICT (symbol, period, open_time) DO UPDATE SET
+			high = iif(last_sequence < excluded.first_sequence, max(high, excluded.high), high),
+			low = iif(last_sequence < excluded.first_sequence, min(low, excluded.low), low),
+			close = iif(last_sequence < excluded.first_sequence, excluded.close, close),
+			volume = iif(last_sequence < excluded.first_sequence, volume + excluded.volume, volume),
+			trade_count = iif(last_sequence < excluded.first_sequence, trade_count + excluded.trade_count, trade_count),
+			last_sequence = iif(last_sequence < excluded.first_sequence, excluded.last_sequence, last_sequence)`
+	}
+	query := `
+		INSERT INTO candles (symbol, period, open_time, open, high, low, close, volume, trade_count, first_sequence, last_sequence)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)` + upsert
+	for _, c := range candles {
+		if _, err := tx.ExecContext(ctx, query, c.Symbol, c.Interval, c.OpenTime, c.Open, c.High, c.Low, c.Close,
+			c.Volume, c.Trades, c.FirstSequence, c.LastSequence); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCandles retrieves a symbol's bars of an interval opening within [from, to), oldest first
+func (r *MySQLRepository) GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]*models.Candle, error) {
+	query := `
+		SELECT symbol, period, open_time, open, high, low, close, volume, trade_count, first_sequence, last_sequence
+		FROM candles
+		WHERE symbol = ? AND period = ? AND open_time >= ? AND open_time < ?
+		ORDER BY open_time
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, symbol, interval, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []*models.Candle
+	for rows.Next() {
+		c := &models.Candle{}
+		if err := rows.Scan(&c.Symbol, &c.Interval, &c.OpenTime, &c.Open, &c.High, &c.Low, &c.Close,
+			&c.Volume, &c.Trades, &c.FirstSequence, &c.LastSequence); err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+	return candles, rows.Err()
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"orderSystem/internal/models"
+	"strconv"
+	"time"
+)
+
+// compactLevel is the stored form of a price level: [price, quantity, order count]
+type compactLevel [3]json.Number
+
+// encodeLevels serializes price levels into a compact JSON array of arrays
+func encodeLevels(levels []models.PriceLevel) ([]byte, error) {
+	compact := make([]compactLevel, len(levels))
+	for i, level := range levels {
+		compact[i] = compactLevel{json.Number(level.Price.String()), json.Number(level.Quantity.String()),
+			json.Number(strconv.Itoa(level.OrderCount))}
+	}
+	return json.Marshal(compact)
+}
+
+// decodeLevels parses price levels stored by encodeLevels
+func decodeLevels(data []byte) ([]models.PriceLevel, error) {
+	var compact []compactLevel
+	if err := json.Unmarshal(data, &compact); err != nil {
+		return nil, err
+	}
+	levels := make([]models.PriceLevel, len(compact))
+	for i, c := range compact {
+		level := &levels[i]
+		if err := level.Price.UnmarshalText([]byte(c[0])); err != nil {
+			return nil, err
+		}
+		if err := level.Quantity.UnmarshalText([]byte(c[1])); err != nil {
+			return nil, err
+		}
+		count, err := c[2].Int64()
+		if err != nil {
+			return nil, err
+		}
+		level.OrderCount = int(count)
+	}
+	return levels, nil
+}
+
+// SaveDepthSnapshot persists a depth snapshot to the database
+func (r *MySQLRepository) SaveDepthSnapshot(ctx context.Context, snapshot *models.DepthSnapshot) error {
+	bids, err := encodeLevels(snapshot.Bids)
+	if err != nil {
+		return err
+	}
+	asks, err := encodeLevels(snapshot.Asks)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO depth_snapshots (symbol, bids, asks, captured_at)
+		VALUES (?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, snapshot.Symbol, bids, asks, snapshot.CapturedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	snapshot.SnapshotID = uint64(id)
+	return nil
+}
+
+// GetDepthSnapshots retrieves depth snapshots for a symbol captured within [from, to], oldest first
+func (r *MySQLRepository) GetDepthSnapshots(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.DepthSnapshot, error) {
+	query := `
+		SELECT snapshot_id, symbol, bids, asks, captured_at
+		FROM depth_snapshots
+		WHERE symbol = ? AND captured_at >= ? AND captured_at <= ?
+		ORDER BY captured_at, snapshot_id
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, symbol, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*models.DepthSnapshot
+	for rows.Next() {
+		snapshot := &models.DepthSnapshot{}
+		var bids, asks []byte
+		if err := rows.Scan(&snapshot.SnapshotID, &snapshot.Symbol, &bids, &asks, &snapshot.CapturedAt); err != nil {
+			return nil, err
+		}
+		if snapshot.Bids, err = decodeLevels(bids); err != nil {
+			return nil, err
+		}
+		if snapshot.Asks, err = decodeLevels(asks); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
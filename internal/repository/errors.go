@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"orderSystem/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Database failures callers can react to, as classified by Classify
+var (
+	ErrDeadlock        = errors.New("database deadlock, transaction rolled back")
+	ErrLockWaitTimeout = errors.New("database lock wait timeout")
+	ErrDuplicateKey    = errors.New("duplicate key")
+	ErrConnectionLost  = errors.New("database connection lost")
+	ErrVersionConflict = errors.New("order changed by another writer since it was read")
+)
+
+// ErrInsertIDQueued is what a write queued to be written behind returns for its insert ID, which
+// the database only assigns once the write is applied
+var ErrInsertIDQueued = errors.New("insert queued to be written behind has no ID yet")
+
+// insertID returns the ID an insert assigned, 0 for one queued to be written behind
+func insertID(result sql.Result) (uint64, error) {
+	id, err := result.LastInsertId()
+	if err == ErrInsertIDQueued {
+		return 0, nil
+	}
+	return uint64(id), err
+}
+
+// MySQL server error numbers
+const (
+	erDupEntry         = 1062
+	erLockWaitTimeout  = 1205
+	erLockDeadlock     = 1213
+	erServerShutdown   = 1053
+	erConnectionKilled = 1927
+	crServerGone       = 2006
+	crServerLost       = 2013
+)
+
+// Classify maps a MySQL or SQLite error onto one of the typed database errors, returning any other error unchanged
+func Classify(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case erLockDeadlock:
+			return ErrDeadlock
+		case erLockWaitTimeout:
+			return ErrLockWaitTimeout
+		case erDupEntry:
+			return ErrDuplicateKey
+		case erServerShutdown, erConnectionKilled, crServerGone, crServerLost:
+			return ErrConnectionLost
+		}
+		return err
+	}
+
+	// SQLite has no deadlocks: a writer waits out busy_timeout for the database lock instead
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch code := sqliteErr.Code(); {
+		case code&0xff == sqlite3.SQLITE_BUSY, code&0xff == sqlite3.SQLITE_LOCKED:
+			return ErrLockWaitTimeout
+		case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE, code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return ErrDuplicateKey
+		}
+		return err
+	}
+
+	// A call whose context ended, as when its client gave up or its deadline passed, is not
+	// retried; context.DeadlineExceeded would otherwise pass for a network timeout
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrRequestTimeout
+	}
+
+	var netErr net.Error
+	if errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return ErrConnectionLost
+	}
+	return err
+}
+
+// IsTransient reports whether a classified error may succeed if the operation is retried
+func IsTransient(err error) bool {
+	return err == ErrDeadlock || err == ErrLockWaitTimeout || err == ErrConnectionLost
+}
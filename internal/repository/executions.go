@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"strings"
+)
+
+// SaveExecutionTx persists one side's execution of a trade within a transaction
+func (r *MySQLRepository) SaveExecutionTx(ctx context.Context, tx Tx, execution *models.Execution) error {
+	query := `
+		INSERT INTO executions (exec_id, trade_id, order_id, symbol, side, liquidity, price, quantity, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, query, execution.ExecID, execution.TradeID, execution.OrderID, execution.Symbol,
+		execution.Side, execution.Liquidity, execution.Price, execution.Quantity, execution.CreatedAt)
+	return err
+}
+
+// GetExecutions retrieves all executions of an order, oldest first
+func (r *MySQLRepository) GetExecutions(ctx context.Context, orderID uint64) ([]*models.Execution, error) {
+	query := `
+		SELECT exec_id, trade_id, order_id, symbol, side, liquidity, price, quantity, created_at
+		FROM executions
+		WHERE order_id = ?
+		ORDER BY created_at, trade_id`
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []*models.Execution
+	for rows.Next() {
+		execution := &models.Execution{}
+		if err := rows.Scan(&execution.ExecID, &execution.TradeID, &execution.OrderID, &execution.Symbol,
+			&execution.Side, &execution.Liquidity, &execution.Price, &execution.Quantity, &execution.CreatedAt); err != nil {
+			return nil, err
+		}
+		executions = append(executions, execution)
+	}
+	return executions, rows.Err()
+}
+
+// GetAveragePrices retrieves the quantity-weighted average fill price of each of the orders
+// that has executions
+func (r *MySQLRepository) GetAveragePrices(ctx context.Context, orderIDs []uint64) (map[uint64]models.Decimal, error) {
+	prices := make(map[uint64]models.Decimal)
+	if len(orderIDs) == 0 {
+		return prices, nil
+	}
+	args := make([]any, len(orderIDs))
+	for i, id := range orderIDs {
+		args[i] = id
+	}
+	query := `
+		SELECT order_id, CAST(SUM(price * quantity) / SUM(quantity) AS DECIMAL(18,8))
+		FROM executions
+		WHERE order_id IN (?` + strings.Repeat(", ?", len(orderIDs)-1) + `)
+		GROUP BY order_id`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID uint64
+		var price models.Decimal
+		if err := rows.Scan(&orderID, &price); err != nil {
+			return nil, err
+		}
+		prices[orderID] = price
+	}
+	return prices, rows.Err()
+}
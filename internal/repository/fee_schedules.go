@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+)
+
+// feeScheduleColumns lists the fee_schedules table columns in the order used by scanFeeSchedule
+const feeScheduleColumns = `schedule_id, name, maker_rate, taker_rate, allocation, active, created_at`
+
+// scanFeeSchedule reads a fee schedule selected with feeScheduleColumns
+func scanFeeSchedule(row rowScanner) (*models.FeeSchedule, error) {
+	schedule := &models.FeeSchedule{}
+	err := row.Scan(&schedule.ScheduleID, &schedule.Name, &schedule.MakerRate, &schedule.TakerRate,
+		&schedule.Allocation, &schedule.Active, &schedule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// SaveFeeSchedule persists a new fee schedule, setting its ID
+func (r *MySQLRepository) SaveFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	query := `
+		INSERT INTO fee_schedules (name, maker_rate, taker_rate, allocation, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, schedule.Name, schedule.MakerRate, schedule.TakerRate, schedule.Allocation,
+		schedule.Active, schedule.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	schedule.ScheduleID = uint64(id)
+	return nil
+}
+
+// UpdateFeeSchedule updates a fee schedule's rates, allocation and active flag
+func (r *MySQLRepository) UpdateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	query := `
+		UPDATE fee_schedules
+		SET maker_rate = ?, taker_rate = ?, allocation = ?, active = ?
+		WHERE schedule_id = ?`
+	_, err := r.db.ExecContext(ctx, query, schedule.MakerRate, schedule.TakerRate, schedule.Allocation, schedule.Active,
+		schedule.ScheduleID)
+	return err
+}
+
+// GetFeeSchedule retrieves a fee schedule by its ID
+func (r *MySQLRepository) GetFeeSchedule(ctx context.Context, scheduleID uint64) (*models.FeeSchedule, error) {
+	query := `
+		SELECT ` + feeScheduleColumns + `
+		FROM fee_schedules
+		WHERE schedule_id = ?`
+	schedule, err := scanFeeSchedule(r.db.QueryRowContext(ctx, query, scheduleID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetFeeSchedules retrieves every fee schedule in creation order
+func (r *MySQLRepository) GetFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error) {
+	query := `
+		SELECT ` + feeScheduleColumns + `
+		FROM fee_schedules
+		ORDER BY schedule_id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*models.FeeSchedule
+	for rows.Next() {
+		schedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
@@ -0,0 +1,657 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"orderSystem/internal/crypto"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// InstrumentedRepository wraps a repository to record every call's latency in the
+// db_call_duration_seconds histogram, by method, and to log calls slower than a threshold with
+// their arguments redacted. Repositories it hands out for a symbol or shard are wrapped alike.
+type InstrumentedRepository struct {
+	repo      Repository
+	threshold time.Duration
+	logger    *zap.Logger
+}
+
+// NewInstrumentedRepository wraps repo, logging calls that take threshold or longer; a zero
+// threshold logs none
+func NewInstrumentedRepository(repo Repository, threshold time.Duration, logger *zap.Logger) *InstrumentedRepository {
+	return &InstrumentedRepository{repo: repo, threshold: threshold, logger: logger}
+}
+
+// observe records a call to method that began at start
+func (r *InstrumentedRepository) observe(method string, start time.Time, args ...any) {
+	elapsed := time.Since(start)
+	metrics.DBCallDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+	if r.threshold > 0 && elapsed >= r.threshold {
+		r.logger.Warn("Slow repository call", zap.String("method", method), zap.Duration("duration", elapsed),
+			zap.Strings("args", redact(args)))
+	}
+}
+
+// redact describes call arguments for the log. Numbers, times and identifiers such as symbols
+// and account IDs are shown as they are; structs, slices and maps, which carry order contents,
+// key secrets and webhook URLs, are shown only by their type and length.
+func redact(args []any) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			redacted[i] = "nil"
+		case *sql.Tx:
+			redacted[i] = "tx"
+		case time.Time:
+			redacted[i] = v.Format(time.RFC3339Nano)
+		default:
+			switch value := reflect.ValueOf(arg); value.Kind() {
+			case reflect.Pointer, reflect.Struct:
+				redacted[i] = fmt.Sprintf("%T", arg)
+			case reflect.Slice, reflect.Map:
+				redacted[i] = fmt.Sprintf("%T(len %d)", arg, value.Len())
+			default:
+				redacted[i] = fmt.Sprint(arg)
+			}
+		}
+	}
+	return redacted
+}
+
+// ForSymbol returns the instrumented repository holding a symbol's rows
+func (r *InstrumentedRepository) ForSymbol(symbol string) Repository {
+	return NewInstrumentedRepository(r.repo.ForSymbol(symbol), r.threshold, r.logger)
+}
+
+// ShardIndex returns the shard holding a symbol's rows
+func (r *InstrumentedRepository) ShardIndex(symbol string) int {
+	return r.repo.ShardIndex(symbol)
+}
+
+// Shards returns every shard of the repository, instrumented
+func (r *InstrumentedRepository) Shards() []Repository {
+	shards := r.repo.Shards()
+	for i, shard := range shards {
+		shards[i] = NewInstrumentedRepository(shard, r.threshold, r.logger)
+	}
+	return shards
+}
+
+// SealWith has the wrapped repository seal sensitive fields under a keyring
+func (r *InstrumentedRepository) SealWith(keys *crypto.Keyring) {
+	r.repo.SealWith(keys)
+}
+
+// The Repository methods, each timing the wrapped call
+
+func (r *InstrumentedRepository) SaveOrder(ctx context.Context, order *models.Order) error {
+	defer r.observe("SaveOrder", time.Now(), order)
+	return r.repo.SaveOrder(ctx, order)
+}
+
+func (r *InstrumentedRepository) UpdateOrder(ctx context.Context, order *models.Order, change models.OrderChange) error {
+	defer r.observe("UpdateOrder", time.Now(), order, change)
+	return r.repo.UpdateOrder(ctx, order, change)
+}
+
+func (r *InstrumentedRepository) GetOrder(ctx context.Context, orderID uint64) (*models.Order, error) {
+	defer r.observe("GetOrder", time.Now(), orderID)
+	return r.repo.GetOrder(ctx, orderID)
+}
+
+func (r *InstrumentedRepository) GetOrderByClientID(ctx context.Context, ownerID, clientOrderID string) (*models.Order, error) {
+	defer r.observe("GetOrderByClientID", time.Now(), ownerID, clientOrderID)
+	return r.repo.GetOrderByClientID(ctx, ownerID, clientOrderID)
+}
+
+func (r *InstrumentedRepository) SaveTrade(ctx context.Context, trade *models.Trade) error {
+	defer r.observe("SaveTrade", time.Now(), trade)
+	return r.repo.SaveTrade(ctx, trade)
+}
+
+func (r *InstrumentedRepository) GetOrderBook(ctx context.Context, symbol string) ([]*models.Order, error) {
+	defer r.observe("GetOrderBook", time.Now(), symbol)
+	return r.repo.GetOrderBook(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) SaveBookSnapshot(ctx context.Context, snapshot *models.BookSnapshot) error {
+	defer r.observe("SaveBookSnapshot", time.Now(), snapshot)
+	return r.repo.SaveBookSnapshot(ctx, snapshot)
+}
+
+func (r *InstrumentedRepository) GetBookSnapshot(ctx context.Context, symbol string) (*models.BookSnapshot, error) {
+	defer r.observe("GetBookSnapshot", time.Now(), symbol)
+	return r.repo.GetBookSnapshot(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) GetChangedOrders(ctx context.Context, symbol string, afterEventID uint64) ([]*models.Order, error) {
+	defer r.observe("GetChangedOrders", time.Now(), symbol, afterEventID)
+	return r.repo.GetChangedOrders(ctx, symbol, afterEventID)
+}
+
+func (r *InstrumentedRepository) GetStaleOrders(ctx context.Context, symbol string, before time.Time) ([]*models.Order, error) {
+	defer r.observe("GetStaleOrders", time.Now(), symbol, before)
+	return r.repo.GetStaleOrders(ctx, symbol, before)
+}
+
+func (r *InstrumentedRepository) GetExpiredOrders(ctx context.Context, before time.Time, limit int) ([]*models.Order, error) {
+	defer r.observe("GetExpiredOrders", time.Now(), before, limit)
+	return r.repo.GetExpiredOrders(ctx, before, limit)
+}
+
+func (r *InstrumentedRepository) GetMaxOrderSequence(ctx context.Context) (uint64, error) {
+	defer r.observe("GetMaxOrderSequence", time.Now())
+	return r.repo.GetMaxOrderSequence(ctx)
+}
+
+func (r *InstrumentedRepository) GetPendingStops(ctx context.Context) ([]*models.Order, error) {
+	defer r.observe("GetPendingStops", time.Now())
+	return r.repo.GetPendingStops(ctx)
+}
+
+func (r *InstrumentedRepository) GetOpenOrders(ctx context.Context, ownerID string) ([]*models.Order, error) {
+	defer r.observe("GetOpenOrders", time.Now(), ownerID)
+	return r.repo.GetOpenOrders(ctx, ownerID)
+}
+
+func (r *InstrumentedRepository) GetOpenOrdersTx(ctx context.Context, tx Tx, ownerID string) ([]*models.Order, error) {
+	defer r.observe("GetOpenOrdersTx", time.Now(), tx, ownerID)
+	return r.repo.GetOpenOrdersTx(ctx, tx, ownerID)
+}
+
+func (r *InstrumentedRepository) GetOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	defer r.observe("GetOrders", time.Now(), filter)
+	return r.repo.GetOrders(ctx, filter)
+}
+
+func (r *InstrumentedRepository) GetTrades(ctx context.Context, filter models.TradeFilter) ([]*models.Trade, error) {
+	defer r.observe("GetTrades", time.Now(), filter)
+	return r.repo.GetTrades(ctx, filter)
+}
+
+func (r *InstrumentedRepository) GetRecentTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error) {
+	defer r.observe("GetRecentTrades", time.Now(), symbol, limit)
+	return r.repo.GetRecentTrades(ctx, symbol, limit)
+}
+
+func (r *InstrumentedRepository) GetOrderTrades(ctx context.Context, orderID uint64) ([]*models.Trade, error) {
+	defer r.observe("GetOrderTrades", time.Now(), orderID)
+	return r.repo.GetOrderTrades(ctx, orderID)
+}
+
+func (r *InstrumentedRepository) GetLastBookTrade(ctx context.Context, symbol string) (*models.Trade, error) {
+	defer r.observe("GetLastBookTrade", time.Now(), symbol)
+	return r.repo.GetLastBookTrade(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) GetTradesAfter(ctx context.Context, afterSequence uint64, limit int) ([]*models.Trade, error) {
+	defer r.observe("GetTradesAfter", time.Now(), afterSequence, limit)
+	return r.repo.GetTradesAfter(ctx, afterSequence, limit)
+}
+
+func (r *InstrumentedRepository) GetTradesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Trade, error) {
+	defer r.observe("GetTradesBetween", time.Now(), from, to, afterID, limit)
+	return r.repo.GetTradesBetween(ctx, from, to, afterID, limit)
+}
+
+func (r *InstrumentedRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	defer r.observe("BeginTx", time.Now())
+	return r.repo.BeginTx(ctx)
+}
+
+func (r *InstrumentedRepository) SaveOrderTx(ctx context.Context, tx Tx, order *models.Order) error {
+	defer r.observe("SaveOrderTx", time.Now(), tx, order)
+	return r.repo.SaveOrderTx(ctx, tx, order)
+}
+
+func (r *InstrumentedRepository) UpdateOrderTx(ctx context.Context, tx Tx, order *models.Order, change models.OrderChange) error {
+	defer r.observe("UpdateOrderTx", time.Now(), tx, order, change)
+	return r.repo.UpdateOrderTx(ctx, tx, order, change)
+}
+
+func (r *InstrumentedRepository) SaveTradeTx(ctx context.Context, tx Tx, trade *models.Trade) error {
+	defer r.observe("SaveTradeTx", time.Now(), tx, trade)
+	return r.repo.SaveTradeTx(ctx, tx, trade)
+}
+
+func (r *InstrumentedRepository) SaveDepthSnapshot(ctx context.Context, snapshot *models.DepthSnapshot) error {
+	defer r.observe("SaveDepthSnapshot", time.Now(), snapshot)
+	return r.repo.SaveDepthSnapshot(ctx, snapshot)
+}
+
+func (r *InstrumentedRepository) GetDepthSnapshots(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.DepthSnapshot, error) {
+	defer r.observe("GetDepthSnapshots", time.Now(), symbol, from, to, limit)
+	return r.repo.GetDepthSnapshots(ctx, symbol, from, to, limit)
+}
+
+func (r *InstrumentedRepository) SaveMarketQuality(ctx context.Context, sample *models.MarketQuality) error {
+	defer r.observe("SaveMarketQuality", time.Now(), sample)
+	return r.repo.SaveMarketQuality(ctx, sample)
+}
+
+func (r *InstrumentedRepository) GetMarketQuality(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.MarketQuality, error) {
+	defer r.observe("GetMarketQuality", time.Now(), symbol, from, to, limit)
+	return r.repo.GetMarketQuality(ctx, symbol, from, to, limit)
+}
+
+func (r *InstrumentedRepository) SaveCandles(ctx context.Context, candles []*models.Candle) error {
+	defer r.observe("SaveCandles", time.Now(), candles)
+	return r.repo.SaveCandles(ctx, candles)
+}
+
+func (r *InstrumentedRepository) GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]*models.Candle, error) {
+	defer r.observe("GetCandles", time.Now(), symbol, interval, from, to, limit)
+	return r.repo.GetCandles(ctx, symbol, interval, from, to, limit)
+}
+
+func (r *InstrumentedRepository) SaveLastLooks(ctx context.Context, looks []*models.LastLook) error {
+	defer r.observe("SaveLastLooks", time.Now(), looks)
+	return r.repo.SaveLastLooks(ctx, looks)
+}
+
+func (r *InstrumentedRepository) GetLastLookStats(ctx context.Context, symbol string, from, to time.Time) ([]*models.LastLookStats, error) {
+	defer r.observe("GetLastLookStats", time.Now(), symbol, from, to)
+	return r.repo.GetLastLookStats(ctx, symbol, from, to)
+}
+
+func (r *InstrumentedRepository) GetTickSize(ctx context.Context, symbol string) (models.Decimal, error) {
+	defer r.observe("GetTickSize", time.Now(), symbol)
+	return r.repo.GetTickSize(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) AppendJournalTx(ctx context.Context, tx Tx, entry *models.JournalEntry) error {
+	defer r.observe("AppendJournalTx", time.Now(), tx, entry)
+	return r.repo.AppendJournalTx(ctx, tx, entry)
+}
+
+func (r *InstrumentedRepository) GetJournalSequence(ctx context.Context, symbol string) (uint64, error) {
+	defer r.observe("GetJournalSequence", time.Now(), symbol)
+	return r.repo.GetJournalSequence(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) GetWriteBehindSequence(ctx context.Context, nodeID int) (uint64, error) {
+	defer r.observe("GetWriteBehindSequence", time.Now(), nodeID)
+	return r.repo.GetWriteBehindSequence(ctx, nodeID)
+}
+
+func (r *InstrumentedRepository) SaveWriteBehindSequenceTx(ctx context.Context, tx Tx, nodeID int, sequence uint64) error {
+	defer r.observe("SaveWriteBehindSequenceTx", time.Now(), tx, nodeID, sequence)
+	return r.repo.SaveWriteBehindSequenceTx(ctx, tx, nodeID, sequence)
+}
+
+func (r *InstrumentedRepository) GetJournal(ctx context.Context, symbol string, afterSequence uint64, limit int) ([]*models.JournalEntry, error) {
+	defer r.observe("GetJournal", time.Now(), symbol, afterSequence, limit)
+	return r.repo.GetJournal(ctx, symbol, afterSequence, limit)
+}
+
+func (r *InstrumentedRepository) GetJournalSymbols(ctx context.Context) ([]string, error) {
+	defer r.observe("GetJournalSymbols", time.Now())
+	return r.repo.GetJournalSymbols(ctx)
+}
+
+func (r *InstrumentedRepository) SaveTickSizeTx(ctx context.Context, tx Tx, symbol string, tick models.Decimal) error {
+	defer r.observe("SaveTickSizeTx", time.Now(), tx, symbol, tick)
+	return r.repo.SaveTickSizeTx(ctx, tx, symbol, tick)
+}
+
+func (r *InstrumentedRepository) SaveExecutionTx(ctx context.Context, tx Tx, execution *models.Execution) error {
+	defer r.observe("SaveExecutionTx", time.Now(), tx, execution)
+	return r.repo.SaveExecutionTx(ctx, tx, execution)
+}
+
+func (r *InstrumentedRepository) GetExecutions(ctx context.Context, orderID uint64) ([]*models.Execution, error) {
+	defer r.observe("GetExecutions", time.Now(), orderID)
+	return r.repo.GetExecutions(ctx, orderID)
+}
+
+func (r *InstrumentedRepository) GetAveragePrices(ctx context.Context, orderIDs []uint64) (map[uint64]models.Decimal, error) {
+	defer r.observe("GetAveragePrices", time.Now(), orderIDs)
+	return r.repo.GetAveragePrices(ctx, orderIDs)
+}
+
+func (r *InstrumentedRepository) SaveParentOrder(ctx context.Context, parent *models.ParentOrder) error {
+	defer r.observe("SaveParentOrder", time.Now(), parent)
+	return r.repo.SaveParentOrder(ctx, parent)
+}
+
+func (r *InstrumentedRepository) UpdateParentOrder(ctx context.Context, parent *models.ParentOrder) error {
+	defer r.observe("UpdateParentOrder", time.Now(), parent)
+	return r.repo.UpdateParentOrder(ctx, parent)
+}
+
+func (r *InstrumentedRepository) GetParentOrder(ctx context.Context, parentID uint64) (*models.ParentOrder, error) {
+	defer r.observe("GetParentOrder", time.Now(), parentID)
+	return r.repo.GetParentOrder(ctx, parentID)
+}
+
+func (r *InstrumentedRepository) GetActiveParentOrders(ctx context.Context) ([]*models.ParentOrder, error) {
+	defer r.observe("GetActiveParentOrders", time.Now())
+	return r.repo.GetActiveParentOrders(ctx)
+}
+
+func (r *InstrumentedRepository) GetChildOrders(ctx context.Context, parentID uint64) ([]*models.Order, error) {
+	defer r.observe("GetChildOrders", time.Now(), parentID)
+	return r.repo.GetChildOrders(ctx, parentID)
+}
+
+func (r *InstrumentedRepository) SaveOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error {
+	defer r.observe("SaveOrderGroupTx", time.Now(), tx, group)
+	return r.repo.SaveOrderGroupTx(ctx, tx, group)
+}
+
+func (r *InstrumentedRepository) UpdateOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error {
+	defer r.observe("UpdateOrderGroupTx", time.Now(), tx, group)
+	return r.repo.UpdateOrderGroupTx(ctx, tx, group)
+}
+
+func (r *InstrumentedRepository) GetOrderGroup(ctx context.Context, groupID uint64) (*models.OrderGroup, error) {
+	defer r.observe("GetOrderGroup", time.Now(), groupID)
+	return r.repo.GetOrderGroup(ctx, groupID)
+}
+
+func (r *InstrumentedRepository) GetActiveOrderGroups(ctx context.Context) ([]*models.OrderGroup, error) {
+	defer r.observe("GetActiveOrderGroups", time.Now())
+	return r.repo.GetActiveOrderGroups(ctx)
+}
+
+func (r *InstrumentedRepository) SaveBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error {
+	defer r.observe("SaveBlockReportTx", time.Now(), tx, report)
+	return r.repo.SaveBlockReportTx(ctx, tx, report)
+}
+
+func (r *InstrumentedRepository) UpdateBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error {
+	defer r.observe("UpdateBlockReportTx", time.Now(), tx, report)
+	return r.repo.UpdateBlockReportTx(ctx, tx, report)
+}
+
+func (r *InstrumentedRepository) GetBlockReport(ctx context.Context, blockID uint64) (*models.BlockReport, error) {
+	defer r.observe("GetBlockReport", time.Now(), blockID)
+	return r.repo.GetBlockReport(ctx, blockID)
+}
+
+func (r *InstrumentedRepository) GetPendingBlockReports(ctx context.Context, accountID string) ([]*models.BlockReport, error) {
+	defer r.observe("GetPendingBlockReports", time.Now(), accountID)
+	return r.repo.GetPendingBlockReports(ctx, accountID)
+}
+
+func (r *InstrumentedRepository) GetGroupOrders(ctx context.Context, groupID uint64) ([]*models.Order, error) {
+	defer r.observe("GetGroupOrders", time.Now(), groupID)
+	return r.repo.GetGroupOrders(ctx, groupID)
+}
+
+func (r *InstrumentedRepository) PostLedgerTx(ctx context.Context, tx Tx, posting *models.LedgerPosting) error {
+	defer r.observe("PostLedgerTx", time.Now(), tx, posting)
+	return r.repo.PostLedgerTx(ctx, tx, posting)
+}
+
+func (r *InstrumentedRepository) GetLedger(ctx context.Context, accountID string, limit int) ([]*models.LedgerPosting, error) {
+	defer r.observe("GetLedger", time.Now(), accountID, limit)
+	return r.repo.GetLedger(ctx, accountID, limit)
+}
+
+func (r *InstrumentedRepository) Reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	defer r.observe("Reconcile", time.Now())
+	return r.repo.Reconcile(ctx)
+}
+
+func (r *InstrumentedRepository) GetBalances(ctx context.Context, accountID string) ([]*models.Balance, error) {
+	defer r.observe("GetBalances", time.Now(), accountID)
+	return r.repo.GetBalances(ctx, accountID)
+}
+
+func (r *InstrumentedRepository) GetBalanceTx(ctx context.Context, tx Tx, accountID, currency string) (*models.Balance, error) {
+	defer r.observe("GetBalanceTx", time.Now(), tx, accountID, currency)
+	return r.repo.GetBalanceTx(ctx, tx, accountID, currency)
+}
+
+func (r *InstrumentedRepository) GetHeldTx(ctx context.Context, tx Tx, accountID string) (map[string]float64, error) {
+	defer r.observe("GetHeldTx", time.Now(), tx, accountID)
+	return r.repo.GetHeldTx(ctx, tx, accountID)
+}
+
+func (r *InstrumentedRepository) SaveSettlementTx(ctx context.Context, tx Tx, settlement *models.Settlement) error {
+	defer r.observe("SaveSettlementTx", time.Now(), tx, settlement)
+	return r.repo.SaveSettlementTx(ctx, tx, settlement)
+}
+
+func (r *InstrumentedRepository) GetDueSettlementsTx(ctx context.Context, tx Tx, before time.Time, limit int) ([]*models.Settlement, error) {
+	defer r.observe("GetDueSettlementsTx", time.Now(), tx, before, limit)
+	return r.repo.GetDueSettlementsTx(ctx, tx, before, limit)
+}
+
+func (r *InstrumentedRepository) MarkSettlementSettledTx(ctx context.Context, tx Tx, settlementID uint64) error {
+	defer r.observe("MarkSettlementSettledTx", time.Now(), tx, settlementID)
+	return r.repo.MarkSettlementSettledTx(ctx, tx, settlementID)
+}
+
+func (r *InstrumentedRepository) GetSettlements(ctx context.Context, accountID string, status models.SettlementStatus) ([]*models.Settlement, error) {
+	defer r.observe("GetSettlements", time.Now(), accountID, status)
+	return r.repo.GetSettlements(ctx, accountID, status)
+}
+
+func (r *InstrumentedRepository) SaveFeeEntryTx(ctx context.Context, tx Tx, entry *models.FeeEntry) error {
+	defer r.observe("SaveFeeEntryTx", time.Now(), tx, entry)
+	return r.repo.SaveFeeEntryTx(ctx, tx, entry)
+}
+
+func (r *InstrumentedRepository) GetUninvoicedAccounts(ctx context.Context, from, to time.Time) ([]string, error) {
+	defer r.observe("GetUninvoicedAccounts", time.Now(), from, to)
+	return r.repo.GetUninvoicedAccounts(ctx, from, to)
+}
+
+func (r *InstrumentedRepository) GetFeeLinesTx(ctx context.Context, tx Tx, accountID string, from, to time.Time) ([]models.InvoiceLine, error) {
+	defer r.observe("GetFeeLinesTx", time.Now(), tx, accountID, from, to)
+	return r.repo.GetFeeLinesTx(ctx, tx, accountID, from, to)
+}
+
+func (r *InstrumentedRepository) SaveInvoiceTx(ctx context.Context, tx Tx, invoice *models.Invoice) error {
+	defer r.observe("SaveInvoiceTx", time.Now(), tx, invoice)
+	return r.repo.SaveInvoiceTx(ctx, tx, invoice)
+}
+
+func (r *InstrumentedRepository) GetInvoices(ctx context.Context, accountID string) ([]*models.Invoice, error) {
+	defer r.observe("GetInvoices", time.Now(), accountID)
+	return r.repo.GetInvoices(ctx, accountID)
+}
+
+func (r *InstrumentedRepository) GetInvoice(ctx context.Context, invoiceID uint64) (*models.Invoice, error) {
+	defer r.observe("GetInvoice", time.Now(), invoiceID)
+	return r.repo.GetInvoice(ctx, invoiceID)
+}
+
+func (r *InstrumentedRepository) GetOrderEvents(ctx context.Context, afterSequence uint64, limit int) ([]*models.OrderEvent, error) {
+	defer r.observe("GetOrderEvents", time.Now(), afterSequence, limit)
+	return r.repo.GetOrderEvents(ctx, afterSequence, limit)
+}
+
+func (r *InstrumentedRepository) GetOrderEventsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.OrderEvent, error) {
+	defer r.observe("GetOrderEventsBetween", time.Now(), from, to, afterID, limit)
+	return r.repo.GetOrderEventsBetween(ctx, from, to, afterID, limit)
+}
+
+func (r *InstrumentedRepository) GetOrderHistory(ctx context.Context, orderID uint64) ([]*models.OrderEvent, error) {
+	defer r.observe("GetOrderHistory", time.Now(), orderID)
+	return r.repo.GetOrderHistory(ctx, orderID)
+}
+
+func (r *InstrumentedRepository) SaveRejection(ctx context.Context, order *models.Order, reason models.ReasonCode) error {
+	defer r.observe("SaveRejection", time.Now(), order, reason)
+	return r.repo.SaveRejection(ctx, order, reason)
+}
+
+func (r *InstrumentedRepository) GetFirstTradeTime(ctx context.Context) (time.Time, error) {
+	defer r.observe("GetFirstTradeTime", time.Now())
+	return r.repo.GetFirstTradeTime(ctx)
+}
+
+func (r *InstrumentedRepository) GetSettlementsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Settlement, error) {
+	defer r.observe("GetSettlementsBetween", time.Now(), from, to, afterID, limit)
+	return r.repo.GetSettlementsBetween(ctx, from, to, afterID, limit)
+}
+
+func (r *InstrumentedRepository) GetFeeEntriesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.FeeEntry, error) {
+	defer r.observe("GetFeeEntriesBetween", time.Now(), from, to, afterID, limit)
+	return r.repo.GetFeeEntriesBetween(ctx, from, to, afterID, limit)
+}
+
+func (r *InstrumentedRepository) SaveDailySummary(ctx context.Context, s *models.DailySummary) error {
+	defer r.observe("SaveDailySummary", time.Now(), s)
+	return r.repo.SaveDailySummary(ctx, s)
+}
+
+func (r *InstrumentedRepository) GetLastDailySummary(ctx context.Context) (*models.DailySummary, error) {
+	defer r.observe("GetLastDailySummary", time.Now())
+	return r.repo.GetLastDailySummary(ctx)
+}
+
+func (r *InstrumentedRepository) GetDailySummaries(ctx context.Context, from, to time.Time) ([]*models.DailySummary, error) {
+	defer r.observe("GetDailySummaries", time.Now(), from, to)
+	return r.repo.GetDailySummaries(ctx, from, to)
+}
+
+func (r *InstrumentedRepository) GetLastOrderEventID(ctx context.Context) (uint64, error) {
+	defer r.observe("GetLastOrderEventID", time.Now())
+	return r.repo.GetLastOrderEventID(ctx)
+}
+
+func (r *InstrumentedRepository) SequenceOutbox(ctx context.Context, outbox string, limit int) error {
+	defer r.observe("SequenceOutbox", time.Now(), outbox, limit)
+	return r.repo.SequenceOutbox(ctx, outbox, limit)
+}
+
+func (r *InstrumentedRepository) GetOutboxSequence(ctx context.Context, outbox string) (uint64, error) {
+	defer r.observe("GetOutboxSequence", time.Now(), outbox)
+	return r.repo.GetOutboxSequence(ctx, outbox)
+}
+
+func (r *InstrumentedRepository) GetEventCursors(ctx context.Context, subscriberID string) (map[int]uint64, error) {
+	defer r.observe("GetEventCursors", time.Now(), subscriberID)
+	return r.repo.GetEventCursors(ctx, subscriberID)
+}
+
+func (r *InstrumentedRepository) SaveEventCursor(ctx context.Context, subscriberID string, shard int, lastEventID uint64) error {
+	defer r.observe("SaveEventCursor", time.Now(), subscriberID, shard, lastEventID)
+	return r.repo.SaveEventCursor(ctx, subscriberID, shard, lastEventID)
+}
+
+func (r *InstrumentedRepository) SaveFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	defer r.observe("SaveFeeSchedule", time.Now(), schedule)
+	return r.repo.SaveFeeSchedule(ctx, schedule)
+}
+
+func (r *InstrumentedRepository) UpdateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	defer r.observe("UpdateFeeSchedule", time.Now(), schedule)
+	return r.repo.UpdateFeeSchedule(ctx, schedule)
+}
+
+func (r *InstrumentedRepository) GetFeeSchedule(ctx context.Context, scheduleID uint64) (*models.FeeSchedule, error) {
+	defer r.observe("GetFeeSchedule", time.Now(), scheduleID)
+	return r.repo.GetFeeSchedule(ctx, scheduleID)
+}
+
+func (r *InstrumentedRepository) GetFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error) {
+	defer r.observe("GetFeeSchedules", time.Now())
+	return r.repo.GetFeeSchedules(ctx)
+}
+
+func (r *InstrumentedRepository) SaveSymbol(ctx context.Context, symbol *models.Symbol) error {
+	defer r.observe("SaveSymbol", time.Now(), symbol)
+	return r.repo.SaveSymbol(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) UpdateSymbol(ctx context.Context, symbol *models.Symbol) error {
+	defer r.observe("UpdateSymbol", time.Now(), symbol)
+	return r.repo.UpdateSymbol(ctx, symbol)
+}
+
+func (r *InstrumentedRepository) GetSymbols(ctx context.Context) ([]*models.Symbol, error) {
+	defer r.observe("GetSymbols", time.Now())
+	return r.repo.GetSymbols(ctx)
+}
+
+func (r *InstrumentedRepository) SaveAccount(ctx context.Context, account *models.Account) error {
+	defer r.observe("SaveAccount", time.Now(), account)
+	return r.repo.SaveAccount(ctx, account)
+}
+
+func (r *InstrumentedRepository) GetAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	defer r.observe("GetAccount", time.Now(), accountID)
+	return r.repo.GetAccount(ctx, accountID)
+}
+
+func (r *InstrumentedRepository) RewrapSealed(ctx context.Context) (map[string]int, error) {
+	defer r.observe("RewrapSealed", time.Now())
+	return r.repo.RewrapSealed(ctx)
+}
+
+func (r *InstrumentedRepository) SaveSigningKey(ctx context.Context, key *models.SigningKey) error {
+	defer r.observe("SaveSigningKey", time.Now(), key)
+	return r.repo.SaveSigningKey(ctx, key)
+}
+
+func (r *InstrumentedRepository) GetSigningKey(ctx context.Context, keyID string) (*models.SigningKey, error) {
+	defer r.observe("GetSigningKey", time.Now(), keyID)
+	return r.repo.GetSigningKey(ctx, keyID)
+}
+
+func (r *InstrumentedRepository) GetSigningKeys(ctx context.Context, accountID string) ([]*models.SigningKey, error) {
+	defer r.observe("GetSigningKeys", time.Now(), accountID)
+	return r.repo.GetSigningKeys(ctx, accountID)
+}
+
+func (r *InstrumentedRepository) RevokeSigningKey(ctx context.Context, keyID string, at time.Time) error {
+	defer r.observe("RevokeSigningKey", time.Now(), keyID, at)
+	return r.repo.RevokeSigningKey(ctx, keyID, at)
+}
+
+func (r *InstrumentedRepository) HasSigningKeyWithScope(ctx context.Context, scope models.KeyScope) (bool, error) {
+	defer r.observe("HasSigningKeyWithScope", time.Now(), scope)
+	return r.repo.HasSigningKeyWithScope(ctx, scope)
+}
+
+func (r *InstrumentedRepository) SaveWebhook(ctx context.Context, webhook *models.Webhook) error {
+	defer r.observe("SaveWebhook", time.Now(), webhook)
+	return r.repo.SaveWebhook(ctx, webhook)
+}
+
+func (r *InstrumentedRepository) GetWebhook(ctx context.Context, webhookID uint64) (*models.Webhook, error) {
+	defer r.observe("GetWebhook", time.Now(), webhookID)
+	return r.repo.GetWebhook(ctx, webhookID)
+}
+
+func (r *InstrumentedRepository) GetWebhooks(ctx context.Context, accountID string) ([]*models.Webhook, error) {
+	defer r.observe("GetWebhooks", time.Now(), accountID)
+	return r.repo.GetWebhooks(ctx, accountID)
+}
+
+func (r *InstrumentedRepository) DeleteWebhook(ctx context.Context, webhookID uint64) error {
+	defer r.observe("DeleteWebhook", time.Now(), webhookID)
+	return r.repo.DeleteWebhook(ctx, webhookID)
+}
+
+func (r *InstrumentedRepository) SaveWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	defer r.observe("SaveWebhookDeliveries", time.Now(), deliveries)
+	return r.repo.SaveWebhookDeliveries(ctx, deliveries)
+}
+
+func (r *InstrumentedRepository) GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	defer r.observe("GetDueWebhookDeliveries", time.Now(), before, limit)
+	return r.repo.GetDueWebhookDeliveries(ctx, before, limit)
+}
+
+func (r *InstrumentedRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	defer r.observe("UpdateWebhookDelivery", time.Now(), delivery)
+	return r.repo.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (r *InstrumentedRepository) Ping(ctx context.Context) error {
+	defer r.observe("Ping", time.Now())
+	return r.repo.Ping(ctx)
+}
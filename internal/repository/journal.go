@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// journalOrder is the stored form of an order submitted by a journaled command
+type journalOrder struct {
+	OrderID         uint64             `json:"order_id"`
+	Side            models.OrderSide   `json:"side"`
+	Type            models.OrderType   `json:"type"`
+	Price           models.NullDecimal `json:"price"`
+	Quantity        models.Decimal     `json:"quantity"`
+	OwnerID         string             `json:"owner_id,omitempty"`
+	TimeInForce     models.TimeInForce `json:"time_in_force"`
+	TriggerPrice    models.NullDecimal `json:"trigger_price"`
+	DisplayQuantity models.NullDecimal `json:"display_quantity"`
+	ParentID        *int64             `json:"parent_id,omitempty"`
+	ClientOrderID   *string            `json:"client_order_id,omitempty"`
+	ExpireAt        *time.Time         `json:"expire_at,omitempty"`
+	ProtectionPrice models.NullDecimal `json:"protection_price"`
+	QuoteQuantity   models.NullDecimal `json:"quote_quantity"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+// journalCommand is the stored form of a journaled command's parameters
+type journalCommand struct {
+	Orders   []journalOrder     `json:"orders,omitempty"`
+	OrderID  uint64             `json:"order_id,omitempty"`
+	GroupID  uint64             `json:"group_id,omitempty"`
+	Price    models.NullDecimal `json:"price"`
+	Quantity models.Decimal     `json:"quantity"`
+	Actor    string             `json:"actor,omitempty"`
+	Reason   models.ReasonCode  `json:"reason,omitempty"`
+	TickSize models.Decimal     `json:"tick_size,omitempty"`
+	Policy   string             `json:"policy,omitempty"`
+	Auction  string             `json:"auction,omitempty"`
+
+	// The last look declines of the part's transaction, by executing order
+	Declined map[uint64][]uint64 `json:"declined,omitempty"`
+}
+
+// journalTrade is the stored form of a trade printed by a journaled command
+type journalTrade struct {
+	TradeID     uint64           `json:"trade_id"`
+	BuyOrderID  uint64           `json:"buy_order_id"`
+	SellOrderID uint64           `json:"sell_order_id"`
+	Price       models.Decimal   `json:"price"`
+	Quantity    models.Decimal   `json:"quantity"`
+	PrintType   models.PrintType `json:"print_type"`
+}
+
+// encodeJournal serializes a journal entry part's command and trades
+func encodeJournal(entry *models.JournalEntry) ([]byte, []byte, error) {
+	command := journalCommand{OrderID: entry.OrderID, GroupID: entry.GroupID, Price: entry.Price, Quantity: entry.Quantity,
+		Actor: entry.Change.Actor, Reason: entry.Change.Reason, TickSize: entry.TickSize, Policy: entry.Policy,
+		Auction: entry.Auction, Declined: entry.Declined}
+	for _, o := range entry.Orders {
+		stored := journalOrder{
+			OrderID:         o.OrderID,
+			Side:            o.Side,
+			Type:            o.Type,
+			Price:           o.Price,
+			Quantity:        o.InitialQuantity,
+			OwnerID:         o.OwnerID,
+			TimeInForce:     o.TimeInForce,
+			TriggerPrice:    o.TriggerPrice,
+			DisplayQuantity: o.DisplayQuantity,
+			ProtectionPrice: o.ProtectionPrice,
+			QuoteQuantity:   o.QuoteQuantity,
+			CreatedAt:       o.CreatedAt,
+		}
+		if o.ParentID.Valid {
+			stored.ParentID = &o.ParentID.Int64
+		}
+		if o.ClientOrderID.Valid {
+			stored.ClientOrderID = &o.ClientOrderID.String
+		}
+		if o.ExpireAt.Valid {
+			stored.ExpireAt = &o.ExpireAt.Time
+		}
+		command.Orders = append(command.Orders, stored)
+	}
+	trades := make([]journalTrade, 0, len(entry.Trades))
+	for _, t := range entry.Trades {
+		trades = append(trades, journalTrade{t.TradeID, t.BuyOrderID, t.SellOrderID, t.Price, t.Quantity, t.PrintType})
+	}
+
+	commandJSON, err := json.Marshal(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	tradesJSON, err := json.Marshal(trades)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commandJSON, tradesJSON, nil
+}
+
+// decodeJournal parses a journal entry's command and trades stored by encodeJournal. A later
+// part of the entry adds its trades and declines to those of the parts before it.
+func decodeJournal(entry *models.JournalEntry, part int, commandJSON, tradesJSON []byte) error {
+	var command journalCommand
+	if err := json.Unmarshal(commandJSON, &command); err != nil {
+		return err
+	}
+	var trades []journalTrade
+	if err := json.Unmarshal(tradesJSON, &trades); err != nil {
+		return err
+	}
+
+	for orderID, declined := range command.Declined {
+		if entry.Declined == nil {
+			entry.Declined = make(map[uint64][]uint64)
+		}
+		entry.Declined[orderID] = append(entry.Declined[orderID], declined...)
+	}
+	for _, t := range trades {
+		entry.Trades = append(entry.Trades, &models.Trade{
+			TradeID:     t.TradeID,
+			Symbol:      entry.Symbol,
+			BuyOrderID:  t.BuyOrderID,
+			SellOrderID: t.SellOrderID,
+			Price:       t.Price,
+			Quantity:    t.Quantity,
+			CreatedAt:   entry.CreatedAt,
+			PrintType:   t.PrintType,
+		})
+	}
+	if part > 0 {
+		return nil
+	}
+
+	entry.OrderID, entry.GroupID, entry.Price, entry.Quantity = command.OrderID, command.GroupID, command.Price, command.Quantity
+	entry.Change = models.OrderChange{Actor: command.Actor, Reason: command.Reason}
+	entry.TickSize, entry.Policy, entry.Auction = command.TickSize, command.Policy, command.Auction
+	for _, o := range command.Orders {
+		order := &models.Order{
+			OrderID:           o.OrderID,
+			Symbol:            entry.Symbol,
+			Side:              o.Side,
+			Type:              o.Type,
+			Price:             o.Price,
+			InitialQuantity:   o.Quantity,
+			RemainingQuantity: o.Quantity,
+			OwnerID:           o.OwnerID,
+			TimeInForce:       o.TimeInForce,
+			TriggerPrice:      o.TriggerPrice,
+			DisplayQuantity:   o.DisplayQuantity,
+			ProtectionPrice:   o.ProtectionPrice,
+			QuoteQuantity:     o.QuoteQuantity,
+			CreatedAt:         o.CreatedAt,
+		}
+		if o.ParentID != nil {
+			order.ParentID = sql.NullInt64{Int64: *o.ParentID, Valid: true}
+		}
+		if o.ClientOrderID != nil {
+			order.ClientOrderID = sql.NullString{String: *o.ClientOrderID, Valid: true}
+		}
+		if o.ExpireAt != nil {
+			order.ExpireAt = sql.NullTime{Time: *o.ExpireAt, Valid: true}
+		}
+		entry.Orders = append(entry.Orders, order)
+	}
+	return nil
+}
+
+// AppendJournalTx appends a part of an order command to its symbol's engine journal within the
+// transaction that carries out that part
+func (r *MySQLRepository) AppendJournalTx(ctx context.Context, tx Tx, entry *models.JournalEntry) error {
+	return appendJournal(ctx, tx, entry)
+}
+
+func appendJournal(ctx context.Context, db execer, entry *models.JournalEntry) error {
+	command, trades, err := encodeJournal(entry)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO engine_journal (symbol, sequence, part, kind, command, trades, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = db.ExecContext(ctx, query, entry.Symbol, entry.Sequence, entry.Part, entry.Kind, command, trades, entry.CreatedAt)
+	return err
+}
+
+// GetJournalSequence returns the sequence of a symbol's last journaled command, 0 when none
+func (r *MySQLRepository) GetJournalSequence(ctx context.Context, symbol string) (uint64, error) {
+	var sequence uint64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM engine_journal WHERE symbol = ?`, symbol).Scan(&sequence)
+	return sequence, err
+}
+
+// GetJournal retrieves up to limit of a symbol's journaled commands after a sequence, in order,
+// each with all of its parts. A symbol's sequences run without gaps, so the next limit of them
+// are the commands wanted.
+func (r *MySQLRepository) GetJournal(ctx context.Context, symbol string, afterSequence uint64, limit int) ([]*models.JournalEntry, error) {
+	query := `
+		SELECT symbol, sequence, part, kind, command, trades, created_at
+		FROM engine_journal
+		WHERE symbol = ? AND sequence > ? AND sequence <= ?
+		ORDER BY sequence, part`
+	rows, err := r.db.QueryContext(ctx, query, symbol, afterSequence, afterSequence+uint64(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.JournalEntry
+	for rows.Next() {
+		var sequence uint64
+		var part int
+		var kind models.JournalKind
+		var createdAt time.Time
+		var command, trades []byte
+		if err := rows.Scan(&symbol, &sequence, &part, &kind, &command, &trades, &createdAt); err != nil {
+			return nil, err
+		}
+		if part == 0 || len(entries) == 0 || entries[len(entries)-1].Sequence != sequence {
+			entries = append(entries, &models.JournalEntry{Symbol: symbol, Sequence: sequence, Kind: kind, CreatedAt: createdAt})
+		}
+		if err := decodeJournal(entries[len(entries)-1], part, command, trades); err != nil {
+			return nil, err
+		}
+	}
+	return entries, rows.Err()
+}
+
+// GetJournalSymbols lists the symbols with journaled commands
+func (r *MySQLRepository) GetJournalSymbols(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT symbol FROM engine_journal ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"strings"
+	"time"
+)
+
+// SaveLastLooks persists the answered last looks of one incoming order in one transaction
+func (r *MySQLRepository) SaveLastLooks(ctx context.Context, looks []*models.LastLook) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO last_looks (last_look_id, symbol, order_id, provider_id, taker_order_id, side, price, quantity, outcome, requested_at, decided_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, l := range looks {
+		if _, err := tx.ExecContext(ctx, query, l.LastLookID, l.Symbol, l.OrderID, l.ProviderID, l.TakerOrderID, l.Side,
+			l.Price, l.Quantity, l.Outcome, l.RequestedAt, l.DecidedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLastLookStats counts each provider's last look answers requested within [from, to),
+// optionally for one symbol, by provider
+func (r *MySQLRepository) GetLastLookStats(ctx context.Context, symbol string, from, to time.Time) ([]*models.LastLookStats, error) {
+	conditions := []string{"requested_at >= ?", "requested_at < ?"}
+	args := []any{from, to}
+	if symbol != "" {
+		conditions = append(conditions, "symbol = ?")
+		args = append(args, symbol)
+	}
+	query := `
+		SELECT provider_id, COUNT(*), SUM(outcome = 'rejected'), SUM(outcome = 'timed_out')
+		FROM last_looks
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY provider_id
+		ORDER BY provider_id`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*models.LastLookStats
+	for rows.Next() {
+		s := &models.LastLookStats{}
+		if err := rows.Scan(&s.ProviderID, &s.Requests, &s.Rejects, &s.Timeouts); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
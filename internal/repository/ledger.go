@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+)
+
+// adjustBalance adds deltas to an account's settled, pending and held balance of a currency
+func (r *MySQLRepository) adjustBalance(ctx context.Context, e execer, accountID, currency string, settledDelta, pendingDelta, heldDelta float64) error {
+	upsert := `
+		ON DUPLICATE KEY UPDATE settled = settled + VALUES(settled), pending = pending + VALUES(pending), held = held + VALUES(held)`
+	if r.sqlite {
+		upsert = `
+		ON CONFLICT (account_id, currency) DO UPDATE SET settled = settled + excluded.settled, pending = pending + excluded.pending,
+			held = held + excluded.held`
+	}
+	query := `
+		INSERT INTO balances (account_id, currency, settled, pending, held)
+		VALUES (?, ?, ?, ?, ?)` + upsert
+	_, err := e.ExecContext(ctx, query, accountID, currency, settledDelta, pendingDelta, heldDelta)
+	return err
+}
+
+// PostLedgerTx records a ledger posting under its ID and its entries within a transaction and
+// applies the settled, pending and held entries to their balances, filling in the entry IDs
+func (r *MySQLRepository) PostLedgerTx(ctx context.Context, tx Tx, posting *models.LedgerPosting) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO ledger_postings (posting_id, kind, reference_id, created_at) VALUES (?, ?, ?, ?)`,
+		posting.PostingID, posting.Kind, posting.ReferenceID, posting.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO ledger_entries (posting_id, account_id, currency, bucket, amount)
+		VALUES (?, ?, ?, ?, ?)`
+	for _, entry := range posting.Entries {
+		entry.PostingID = posting.PostingID
+		account := sql.NullString{String: entry.AccountID, Valid: entry.AccountID != ""}
+		result, err := tx.ExecContext(ctx, query, entry.PostingID, account, entry.Currency, entry.Bucket, entry.Amount)
+		if err != nil {
+			return err
+		}
+		if entry.EntryID, err = insertID(result); err != nil {
+			return err
+		}
+
+		switch entry.Bucket {
+		case models.BucketSettled:
+			err = r.adjustBalance(ctx, tx, entry.AccountID, entry.Currency, entry.Amount, 0, 0)
+		case models.BucketPending:
+			err = r.adjustBalance(ctx, tx, entry.AccountID, entry.Currency, 0, entry.Amount, 0)
+		case models.BucketHeld:
+			err = r.adjustBalance(ctx, tx, entry.AccountID, entry.Currency, 0, 0, entry.Amount)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLedger retrieves the postings of an account's latest ledger entries, newest first, each
+// with only the account's own entries
+func (r *MySQLRepository) GetLedger(ctx context.Context, accountID string, limit int) ([]*models.LedgerPosting, error) {
+	query := `
+		SELECT p.posting_id, p.kind, p.reference_id, p.created_at, e.entry_id, e.currency, e.bucket, e.amount
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.posting_id = e.posting_id
+		WHERE e.account_id = ?
+		ORDER BY e.entry_id DESC
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postings []*models.LedgerPosting
+	for rows.Next() {
+		posting := &models.LedgerPosting{}
+		entry := &models.LedgerEntry{AccountID: accountID}
+		if err := rows.Scan(&posting.PostingID, &posting.Kind, &posting.ReferenceID, &posting.CreatedAt,
+			&entry.EntryID, &entry.Currency, &entry.Bucket, &entry.Amount); err != nil {
+			return nil, err
+		}
+		entry.PostingID = posting.PostingID
+		if n := len(postings); n > 0 && postings[n-1].PostingID == posting.PostingID {
+			postings[n-1].Entries = append(postings[n-1].Entries, entry)
+			continue
+		}
+		posting.Entries = []*models.LedgerEntry{entry}
+		postings = append(postings, posting)
+	}
+	return postings, rows.Err()
+}
+
+// ledgerSums sums the ledger entries of each account and currency by balance
+const ledgerSums = `(
+	SELECT account_id, currency,
+		SUM(CASE WHEN bucket = 'settled' THEN amount ELSE 0 END) AS settled,
+		SUM(CASE WHEN bucket = 'pending' THEN amount ELSE 0 END) AS pending,
+		SUM(CASE WHEN bucket = 'held' THEN amount ELSE 0 END) AS held
+	FROM ledger_entries
+	WHERE account_id IS NOT NULL
+	GROUP BY account_id, currency)`
+
+// Reconcile compares every balance with the sum of its account's ledger entries, counting an
+// account with entries but no balance row as holding nothing, and checks that every posting sums
+// to zero in each currency
+func (r *MySQLRepository) Reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	query := `
+		SELECT b.account_id, b.currency, b.settled, b.pending, b.held,
+			COALESCE(l.settled, 0), COALESCE(l.pending, 0), COALESCE(l.held, 0)
+		FROM balances b
+		LEFT JOIN ` + ledgerSums + ` l ON l.account_id = b.account_id AND l.currency = b.currency
+		WHERE b.settled <> COALESCE(l.settled, 0) OR b.pending <> COALESCE(l.pending, 0) OR b.held <> COALESCE(l.held, 0)
+		UNION ALL
+		SELECT l.account_id, l.currency, 0, 0, 0, l.settled, l.pending, l.held
+		FROM ` + ledgerSums + ` l
+		LEFT JOIN balances b ON b.account_id = l.account_id AND b.currency = l.currency
+		WHERE b.account_id IS NULL AND (l.settled <> 0 OR l.pending <> 0 OR l.held <> 0)
+		ORDER BY 1, 2`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reconciliation := &models.Reconciliation{}
+	for rows.Next() {
+		m := &models.LedgerMismatch{}
+		if err := rows.Scan(&m.AccountID, &m.Currency, &m.Settled, &m.Pending, &m.Held,
+			&m.LedgerSettled, &m.LedgerPending, &m.LedgerHeld); err != nil {
+			return nil, err
+		}
+		reconciliation.Mismatches = append(reconciliation.Mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT posting_id, currency, SUM(amount)
+		FROM ledger_entries
+		GROUP BY posting_id, currency
+		HAVING SUM(amount) <> 0
+		ORDER BY posting_id, currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		u := &models.UnbalancedPosting{}
+		if err := rows.Scan(&u.PostingID, &u.Currency, &u.Sum); err != nil {
+			return nil, err
+		}
+		reconciliation.Unbalanced = append(reconciliation.Unbalanced, u)
+	}
+	return reconciliation, rows.Err()
+}
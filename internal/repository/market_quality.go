@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// SaveMarketQuality persists a market quality sample to the database
+func (r *MySQLRepository) SaveMarketQuality(ctx context.Context, sample *models.MarketQuality) error {
+	query := `
+		INSERT INTO market_quality_samples (symbol, best_bid, best_ask, spread_bps, bid_depth, ask_depth, captured_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, sample.Symbol, sample.BestBid, sample.BestAsk, sample.SpreadBps,
+		sample.BidDepth, sample.AskDepth, sample.CapturedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	sample.SampleID = uint64(id)
+	return nil
+}
+
+// GetMarketQuality retrieves market quality samples for a symbol captured within [from, to], oldest first
+func (r *MySQLRepository) GetMarketQuality(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.MarketQuality, error) {
+	query := `
+		SELECT sample_id, symbol, best_bid, best_ask, spread_bps, bid_depth, ask_depth, captured_at
+		FROM market_quality_samples
+		WHERE symbol = ? AND captured_at >= ? AND captured_at <= ?
+		ORDER BY captured_at, sample_id
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, symbol, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []*models.MarketQuality
+	for rows.Next() {
+		sample := &models.MarketQuality{}
+		if err := rows.Scan(&sample.SampleID, &sample.Symbol, &sample.BestBid, &sample.BestAsk, &sample.SpreadBps,
+			&sample.BidDepth, &sample.AskDepth, &sample.CapturedAt); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
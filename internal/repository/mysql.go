@@ -1,29 +1,151 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"orderSystem/internal/crypto"
 	"orderSystem/internal/models"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // Repository defines database operations for the order matching system
 type Repository interface {
-	SaveOrder(order *models.Order) error
-	UpdateOrder(order *models.Order) error
-	GetOrder(orderID uint64) (*models.Order, error)
-	SaveTrade(trade *models.Trade) error
-	GetOrderBook(symbol string) ([]*models.Order, error)
-	GetTrades(symbol string) ([]*models.Trade, error)
-	BeginTx() (*sql.Tx, error)
-	SaveOrderTx(tx *sql.Tx, order *models.Order) error
-	UpdateOrderTx(tx *sql.Tx, order *models.Order) error
-	SaveTradeTx(tx *sql.Tx, trade *models.Trade) error
+	SaveOrder(ctx context.Context, order *models.Order) error
+	UpdateOrder(ctx context.Context, order *models.Order, change models.OrderChange) error
+	GetOrder(ctx context.Context, orderID uint64) (*models.Order, error)
+	GetOrderByClientID(ctx context.Context, ownerID, clientOrderID string) (*models.Order, error)
+	SaveTrade(ctx context.Context, trade *models.Trade) error
+	GetOrderBook(ctx context.Context, symbol string) ([]*models.Order, error)
+	SaveBookSnapshot(ctx context.Context, snapshot *models.BookSnapshot) error
+	GetBookSnapshot(ctx context.Context, symbol string) (*models.BookSnapshot, error)
+	GetChangedOrders(ctx context.Context, symbol string, afterEventID uint64) ([]*models.Order, error)
+	GetStaleOrders(ctx context.Context, symbol string, before time.Time) ([]*models.Order, error)
+	GetExpiredOrders(ctx context.Context, before time.Time, limit int) ([]*models.Order, error)
+	GetMaxOrderSequence(ctx context.Context) (uint64, error)
+	GetPendingStops(ctx context.Context) ([]*models.Order, error)
+	GetOpenOrders(ctx context.Context, ownerID string) ([]*models.Order, error)
+	GetOpenOrdersTx(ctx context.Context, tx Tx, ownerID string) ([]*models.Order, error)
+	GetOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error)
+	GetTrades(ctx context.Context, filter models.TradeFilter) ([]*models.Trade, error)
+	GetRecentTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error)
+	GetOrderTrades(ctx context.Context, orderID uint64) ([]*models.Trade, error)
+	GetLastBookTrade(ctx context.Context, symbol string) (*models.Trade, error)
+	GetTradesAfter(ctx context.Context, afterSequence uint64, limit int) ([]*models.Trade, error)
+	GetTradesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Trade, error)
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+	SaveOrderTx(ctx context.Context, tx Tx, order *models.Order) error
+	UpdateOrderTx(ctx context.Context, tx Tx, order *models.Order, change models.OrderChange) error
+	SaveTradeTx(ctx context.Context, tx Tx, trade *models.Trade) error
+	SaveDepthSnapshot(ctx context.Context, snapshot *models.DepthSnapshot) error
+	GetDepthSnapshots(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.DepthSnapshot, error)
+	SaveMarketQuality(ctx context.Context, sample *models.MarketQuality) error
+	GetMarketQuality(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.MarketQuality, error)
+	SaveCandles(ctx context.Context, candles []*models.Candle) error
+	GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]*models.Candle, error)
+	SaveLastLooks(ctx context.Context, looks []*models.LastLook) error
+	GetLastLookStats(ctx context.Context, symbol string, from, to time.Time) ([]*models.LastLookStats, error)
+	GetTickSize(ctx context.Context, symbol string) (models.Decimal, error)
+	AppendJournalTx(ctx context.Context, tx Tx, entry *models.JournalEntry) error
+	GetJournalSequence(ctx context.Context, symbol string) (uint64, error)
+	GetWriteBehindSequence(ctx context.Context, nodeID int) (uint64, error)
+	SaveWriteBehindSequenceTx(ctx context.Context, tx Tx, nodeID int, sequence uint64) error
+	GetJournal(ctx context.Context, symbol string, afterSequence uint64, limit int) ([]*models.JournalEntry, error)
+	GetJournalSymbols(ctx context.Context) ([]string, error)
+	SaveTickSizeTx(ctx context.Context, tx Tx, symbol string, tick models.Decimal) error
+	SaveExecutionTx(ctx context.Context, tx Tx, execution *models.Execution) error
+	GetExecutions(ctx context.Context, orderID uint64) ([]*models.Execution, error)
+	GetAveragePrices(ctx context.Context, orderIDs []uint64) (map[uint64]models.Decimal, error)
+	SaveParentOrder(ctx context.Context, parent *models.ParentOrder) error
+	UpdateParentOrder(ctx context.Context, parent *models.ParentOrder) error
+	GetParentOrder(ctx context.Context, parentID uint64) (*models.ParentOrder, error)
+	GetActiveParentOrders(ctx context.Context) ([]*models.ParentOrder, error)
+	GetChildOrders(ctx context.Context, parentID uint64) ([]*models.Order, error)
+	SaveOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error
+	UpdateOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error
+	GetOrderGroup(ctx context.Context, groupID uint64) (*models.OrderGroup, error)
+	GetActiveOrderGroups(ctx context.Context) ([]*models.OrderGroup, error)
+	SaveBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error
+	UpdateBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error
+	GetBlockReport(ctx context.Context, blockID uint64) (*models.BlockReport, error)
+	GetPendingBlockReports(ctx context.Context, accountID string) ([]*models.BlockReport, error)
+	GetGroupOrders(ctx context.Context, groupID uint64) ([]*models.Order, error)
+	PostLedgerTx(ctx context.Context, tx Tx, posting *models.LedgerPosting) error
+	GetLedger(ctx context.Context, accountID string, limit int) ([]*models.LedgerPosting, error)
+	Reconcile(ctx context.Context) (*models.Reconciliation, error)
+	GetBalances(ctx context.Context, accountID string) ([]*models.Balance, error)
+	GetBalanceTx(ctx context.Context, tx Tx, accountID, currency string) (*models.Balance, error)
+	GetHeldTx(ctx context.Context, tx Tx, accountID string) (map[string]float64, error)
+	SaveSettlementTx(ctx context.Context, tx Tx, settlement *models.Settlement) error
+	GetDueSettlementsTx(ctx context.Context, tx Tx, before time.Time, limit int) ([]*models.Settlement, error)
+	MarkSettlementSettledTx(ctx context.Context, tx Tx, settlementID uint64) error
+	GetSettlements(ctx context.Context, accountID string, status models.SettlementStatus) ([]*models.Settlement, error)
+	SaveFeeEntryTx(ctx context.Context, tx Tx, entry *models.FeeEntry) error
+	GetUninvoicedAccounts(ctx context.Context, from, to time.Time) ([]string, error)
+	GetFeeLinesTx(ctx context.Context, tx Tx, accountID string, from, to time.Time) ([]models.InvoiceLine, error)
+	SaveInvoiceTx(ctx context.Context, tx Tx, invoice *models.Invoice) error
+	GetInvoices(ctx context.Context, accountID string) ([]*models.Invoice, error)
+	GetInvoice(ctx context.Context, invoiceID uint64) (*models.Invoice, error)
+	GetOrderEvents(ctx context.Context, afterSequence uint64, limit int) ([]*models.OrderEvent, error)
+	GetOrderEventsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.OrderEvent, error)
+	GetOrderHistory(ctx context.Context, orderID uint64) ([]*models.OrderEvent, error)
+	SaveRejection(ctx context.Context, order *models.Order, reason models.ReasonCode) error
+	GetFirstTradeTime(ctx context.Context) (time.Time, error)
+	GetSettlementsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Settlement, error)
+	GetFeeEntriesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.FeeEntry, error)
+	SaveDailySummary(ctx context.Context, s *models.DailySummary) error
+	GetLastDailySummary(ctx context.Context) (*models.DailySummary, error)
+	GetDailySummaries(ctx context.Context, from, to time.Time) ([]*models.DailySummary, error)
+	GetLastOrderEventID(ctx context.Context) (uint64, error)
+	SequenceOutbox(ctx context.Context, outbox string, limit int) error
+	GetOutboxSequence(ctx context.Context, outbox string) (uint64, error)
+	GetEventCursors(ctx context.Context, subscriberID string) (map[int]uint64, error)
+	SaveEventCursor(ctx context.Context, subscriberID string, shard int, lastEventID uint64) error
+	SaveFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error
+	UpdateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error
+	GetFeeSchedule(ctx context.Context, scheduleID uint64) (*models.FeeSchedule, error)
+	GetFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error)
+	SaveSymbol(ctx context.Context, symbol *models.Symbol) error
+	UpdateSymbol(ctx context.Context, symbol *models.Symbol) error
+	GetSymbols(ctx context.Context) ([]*models.Symbol, error)
+	SaveAccount(ctx context.Context, account *models.Account) error
+	GetAccount(ctx context.Context, accountID string) (*models.Account, error)
+	RewrapSealed(ctx context.Context) (map[string]int, error)
+	SaveSigningKey(ctx context.Context, key *models.SigningKey) error
+	GetSigningKey(ctx context.Context, keyID string) (*models.SigningKey, error)
+	GetSigningKeys(ctx context.Context, accountID string) ([]*models.SigningKey, error)
+	RevokeSigningKey(ctx context.Context, keyID string, at time.Time) error
+	HasSigningKeyWithScope(ctx context.Context, scope models.KeyScope) (bool, error)
+	SaveWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, webhookID uint64) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context, accountID string) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint64) error
+	SaveWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// Shard routing; an unsharded repository is its own single shard
+	ForSymbol(symbol string) Repository
+	ShardIndex(symbol string) int
+	Shards() []Repository
+	Ping(ctx context.Context) error
+
+	// Sealing of sensitive fields at rest, set before the repository is used
+	SealWith(keys *crypto.Keyring)
 }
 
 // MySQLRepository implements Repository using MySQL
 type MySQLRepository struct {
 	db *sql.DB
+
+	// Set when db is SQLite, which runs the few statements MySQL spells its own way in their
+	// SQLite form; see SQLiteRepository
+	sqlite bool
+
+	// Seals sensitive fields on the way in and opens them on the way out; see sealed.go
+	keys *crypto.Keyring
 }
 
 // NewMySQLRepository creates a new MySQL repository
@@ -31,125 +153,447 @@ func NewMySQLRepository(db *sql.DB) *MySQLRepository {
 	return &MySQLRepository{db: db}
 }
 
+// SealWith has the repository seal sensitive fields under a keyring, nil storing them as they
+// are. It must be called before the repository is used.
+func (r *MySQLRepository) SealWith(keys *crypto.Keyring) {
+	r.keys = keys
+}
+
+// WithTimeouts returns a DSN whose connections fail to open after dial and fail any read or write
+// stalled for longer than query, unless the DSN sets its own; a 0 timeout leaves the DSN's as is
+func WithTimeouts(dsn string, dial, query time.Duration) (string, error) {
+	if dial == 0 && query == 0 {
+		return dsn, nil
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = dial
+	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = query
+	}
+	if cfg.WriteTimeout == 0 {
+		cfg.WriteTimeout = query
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// ForSymbol returns the repository holding a symbol's rows
+func (r *MySQLRepository) ForSymbol(symbol string) Repository {
+	return r
+}
+
+// ShardIndex returns the shard holding a symbol's rows
+func (r *MySQLRepository) ShardIndex(symbol string) int {
+	return 0
+}
+
+// Shards returns every shard of the repository
+func (r *MySQLRepository) Shards() []Repository {
+	return []Repository{r}
+}
+
+// Ping verifies the database connection is alive
+func (r *MySQLRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 // BeginTx starts a new transaction
-func (r *MySQLRepository) BeginTx() (*sql.Tx, error) {
-	return r.db.Begin()
+func (r *MySQLRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.BeginTx(ctx, nil)
 }
 
-// SaveOrder persists a new order to the database
-func (r *MySQLRepository) SaveOrder(order *models.Order) error {
-	query := `
-		INSERT INTO orders (order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, order.OrderID, order.Symbol, order.Side, order.Type, order.Price,
-		order.InitialQuantity, order.RemainingQuantity, order.Status, order.CreatedAt)
-	return err
+// orderColumns lists the orders table columns in the order used by insertOrder and scanOrder
+const orderColumns = `order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at, parent_id, owner_id,
+	time_in_force, trigger_price, display_quantity, group_id, sequence, client_order_id, expire_at,
+	protection_price, quote_quantity, version`
+
+// Tx is a transaction the Tx methods run within: a *sql.Tx from BeginTx, or one whose writes
+// are queued to be written behind the matching engine
+type Tx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	Commit() error
+	Rollback() error
 }
 
-// SaveOrderTx persists a new order to the database within a transaction
-func (r *MySQLRepository) SaveOrderTx(tx *sql.Tx, order *models.Order) error {
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// insertOrder inserts an order and its created event within a transaction
+func insertOrder(ctx context.Context, e execer, order *models.Order) error {
 	query := `
-		INSERT INTO orders (order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := tx.Exec(query, order.OrderID, order.Symbol, order.Side, order.Type, order.Price,
-		order.InitialQuantity, order.RemainingQuantity, order.Status, order.CreatedAt)
-	return err
+		INSERT INTO orders (` + orderColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := e.ExecContext(ctx, query, order.OrderID, order.Symbol, order.Side, order.Type, order.Price,
+		order.InitialQuantity, order.RemainingQuantity, order.Status, order.CreatedAt, order.ParentID, order.OwnerID,
+		order.TimeInForce, order.TriggerPrice, order.DisplayQuantity, order.GroupID, order.Sequence, order.ClientOrderID,
+		order.ExpireAt, order.ProtectionPrice, order.QuoteQuantity, order.Version)
+	if err != nil {
+		return err
+	}
+	return appendOrderEvent(ctx, e, models.EventCreated, order, models.OrderChange{Actor: order.OwnerID})
 }
 
-// UpdateOrder updates an existing order in the database
-func (r *MySQLRepository) UpdateOrder(order *models.Order) error {
+// updateOrder updates an order's mutable fields and records an updated event, attributed to the
+// change's actor and reason, within a transaction. The stored row must still be at the order's
+// version, else another writer changed it and ErrVersionConflict is returned; on success the
+// order moves to the next version.
+func updateOrder(ctx context.Context, e execer, order *models.Order, change models.OrderChange) error {
 	query := `
 		UPDATE orders
-		SET remaining_quantity = ?, status = ?
-		WHERE order_id = ?`
-	_, err := r.db.Exec(query, order.RemainingQuantity, order.Status, order.OrderID)
-	return err
+		SET price = ?, trigger_price = ?, initial_quantity = ?, remaining_quantity = ?, status = ?, sequence = ?,
+			version = version + 1
+		WHERE order_id = ? AND version = ?`
+	result, err := e.ExecContext(ctx, query, order.Price, order.TriggerPrice, order.InitialQuantity, order.RemainingQuantity, order.Status,
+		order.Sequence, order.OrderID, order.Version)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrVersionConflict
+	}
+	order.Version++
+	return appendOrderEvent(ctx, e, models.EventUpdated, order, change)
+}
+
+// scanOrder reads an order selected with orderColumns
+func scanOrder(row rowScanner) (*models.Order, error) {
+	order := &models.Order{}
+	err := row.Scan(&order.OrderID, &order.Symbol, &order.Side, &order.Type, &order.Price,
+		&order.InitialQuantity, &order.RemainingQuantity, &order.Status, &order.CreatedAt, &order.ParentID, &order.OwnerID,
+		&order.TimeInForce, &order.TriggerPrice, &order.DisplayQuantity, &order.GroupID, &order.Sequence, &order.ClientOrderID,
+		&order.ExpireAt, &order.ProtectionPrice, &order.QuoteQuantity, &order.Version)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// queryOrders runs a query selecting orderColumns and collects the resulting orders
+func queryOrders(ctx context.Context, q querier, query string, args ...any) ([]*models.Order, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// SaveOrder persists a new order to the database
+func (r *MySQLRepository) SaveOrder(ctx context.Context, order *models.Order) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error { return insertOrder(ctx, tx, order) })
+}
+
+// SaveOrderTx persists a new order to the database within a transaction
+func (r *MySQLRepository) SaveOrderTx(ctx context.Context, tx Tx, order *models.Order) error {
+	return insertOrder(ctx, tx, order)
+}
+
+// UpdateOrder updates an existing order in the database
+func (r *MySQLRepository) UpdateOrder(ctx context.Context, order *models.Order, change models.OrderChange) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error { return updateOrder(ctx, tx, order, change) })
 }
 
 // UpdateOrderTx updates an existing order in the database within a transaction
-func (r *MySQLRepository) UpdateOrderTx(tx *sql.Tx, order *models.Order) error {
-	query := `
-		UPDATE orders
-		SET remaining_quantity = ?, status = ?
-		WHERE order_id = ?`
-	_, err := tx.Exec(query, order.RemainingQuantity, order.Status, order.OrderID)
-	return err
+func (r *MySQLRepository) UpdateOrderTx(ctx context.Context, tx Tx, order *models.Order, change models.OrderChange) error {
+	return updateOrder(ctx, tx, order, change)
 }
 
 // GetOrder retrieves an order by its ID
-func (r *MySQLRepository) GetOrder(orderID uint64) (*models.Order, error) {
+func (r *MySQLRepository) GetOrder(ctx context.Context, orderID uint64) (*models.Order, error) {
 	query := `
-		SELECT order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE order_id = ?`
-	row := r.db.QueryRow(query, orderID)
-	order := &models.Order{}
-	var price sql.NullFloat64
-	err := row.Scan(&order.OrderID, &order.Symbol, &order.Side, &order.Type, &price,
-		&order.InitialQuantity, &order.RemainingQuantity, &order.Status, &order.CreatedAt)
+	order, err := scanOrder(r.db.QueryRowContext(ctx, query, orderID))
 	if err == sql.ErrNoRows {
 		return nil, models.ErrOrderNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-	order.Price = price
 	return order, nil
 }
 
-// SaveTrade persists a trade to the database
-func (r *MySQLRepository) SaveTrade(trade *models.Trade) error {
+// GetOrderByClientID retrieves an order by its owner's client order ID
+func (r *MySQLRepository) GetOrderByClientID(ctx context.Context, ownerID, clientOrderID string) (*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE owner_id = ? AND client_order_id = ?`
+	order, err := scanOrder(r.db.QueryRowContext(ctx, query, ownerID, clientOrderID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetPendingStops retrieves every stop order still waiting for its trigger, oldest first
+func (r *MySQLRepository) GetPendingStops(ctx context.Context) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE status = 'pending'
+		ORDER BY sequence, created_at, order_id`
+	return queryOrders(ctx, r.db, query)
+}
+
+// GetOpenOrders retrieves an account's resting and untriggered stop orders across all symbols
+func (r *MySQLRepository) GetOpenOrders(ctx context.Context, ownerID string) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE owner_id = ? AND status IN ('open', 'partially_filled', 'pending')
+		ORDER BY symbol, created_at`
+	return queryOrders(ctx, r.db, query, ownerID)
+}
+
+// GetOpenOrdersTx retrieves an account's open and pending orders within a transaction
+func (r *MySQLRepository) GetOpenOrdersTx(ctx context.Context, tx Tx, ownerID string) ([]*models.Order, error) {
 	query := `
-		INSERT INTO trades (symbol, buy_order_id, sell_order_id, price, quantity, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, trade.Symbol, trade.BuyOrderID, trade.SellOrderID, trade.Price,
-		trade.Quantity, trade.CreatedAt)
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE owner_id = ? AND status IN ('open', 'partially_filled', 'pending')`
+	return queryOrders(ctx, tx, query, ownerID)
+}
+
+// GetOrders retrieves the orders matching a filter, newest first
+func (r *MySQLRepository) GetOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	conditions := []string{"owner_id = ?"}
+	args := []any{filter.OwnerID}
+	if filter.Symbol != "" {
+		conditions = append(conditions, "symbol = ?")
+		args = append(args, filter.Symbol)
+	}
+	if filter.Side != "" {
+		conditions = append(conditions, "side = ?")
+		args = append(args, filter.Side)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+	if !filter.AfterTime.IsZero() {
+		conditions = append(conditions, "(created_at < ? OR created_at = ? AND order_id < ?)")
+		args = append(args, filter.AfterTime, filter.AfterTime, filter.AfterID)
+	}
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC, order_id DESC
+		LIMIT ?`
+	return queryOrders(ctx, r.db, query, append(args, filter.Limit)...)
+}
+
+// SaveTrade persists a trade to the database under the trade ID the service assigned it
+func (r *MySQLRepository) SaveTrade(ctx context.Context, trade *models.Trade) error {
+	query := `
+		INSERT INTO trades (trade_id, symbol, buy_order_id, sell_order_id, price, quantity, created_at, print_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, trade.TradeID, trade.Symbol, trade.BuyOrderID, trade.SellOrderID, trade.Price,
+		trade.Quantity, trade.CreatedAt, trade.PrintType)
 	return err
 }
 
-// SaveTradeTx persists a trade to the database within a transaction
-func (r *MySQLRepository) SaveTradeTx(tx *sql.Tx, trade *models.Trade) error {
+// SaveTradeTx persists a trade to the database within a transaction, under the trade ID the
+// service assigned it
+func (r *MySQLRepository) SaveTradeTx(ctx context.Context, tx Tx, trade *models.Trade) error {
 	query := `
-		INSERT INTO trades (symbol, buy_order_id, sell_order_id, price, quantity, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := tx.Exec(query, trade.Symbol, trade.BuyOrderID, trade.SellOrderID, trade.Price,
-		trade.Quantity, trade.CreatedAt)
+		INSERT INTO trades (trade_id, symbol, buy_order_id, sell_order_id, price, quantity, created_at, print_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, query, trade.TradeID, trade.Symbol, trade.BuyOrderID, trade.SellOrderID, trade.Price,
+		trade.Quantity, trade.CreatedAt, trade.PrintType)
 	return err
 }
 
-// GetOrderBook retrieves all open orders for a given symbol
-func (r *MySQLRepository) GetOrderBook(symbol string) ([]*models.Order, error) {
+// GetOrderBook retrieves all open orders for a given symbol in time priority order. Orders
+// written before sequences were recorded all have sequence 0 and fall back to creation time.
+func (r *MySQLRepository) GetOrderBook(ctx context.Context, symbol string) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE symbol = ? AND status IN ('open', 'partially_filled')
+		ORDER BY sequence, created_at, order_id`
+	return queryOrders(ctx, r.db, query, symbol)
+}
+
+// GetStaleOrders retrieves a symbol's resting orders that have not changed since before, oldest first
+func (r *MySQLRepository) GetStaleOrders(ctx context.Context, symbol string, before time.Time) ([]*models.Order, error) {
 	query := `
-		SELECT order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at
+		SELECT ` + orderColumns + `
 		FROM orders
-		WHERE symbol = ? AND status = 'open'`
-	rows, err := r.db.Query(query, symbol)
+		WHERE symbol = ? AND status IN ('open', 'partially_filled') AND updated_at < ?
+		ORDER BY updated_at, order_id`
+	return queryOrders(ctx, r.db, query, symbol, before)
+}
+
+// GetExpiredOrders retrieves up to limit resting and untriggered stop orders whose expiry time is
+// not after before, soonest expiring first
+func (r *MySQLRepository) GetExpiredOrders(ctx context.Context, before time.Time, limit int) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE status IN ('open', 'partially_filled', 'pending') AND expire_at <= ?
+		ORDER BY expire_at, order_id
+		LIMIT ?`
+	return queryOrders(ctx, r.db, query, before, limit)
+}
+
+// GetMaxOrderSequence returns the highest time priority sequence recorded for any order, 0 when there are none
+func (r *MySQLRepository) GetMaxOrderSequence(ctx context.Context) (uint64, error) {
+	var sequence uint64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM orders`).Scan(&sequence)
+	return sequence, err
+}
+
+// tradeColumns lists the trades table columns in the order used by queryTrades
+const tradeColumns = `trade_id, symbol, buy_order_id, sell_order_id, price, quantity, created_at, print_type`
+
+// queryTrades runs a query selecting tradeColumns and collects the resulting trades
+func (r *MySQLRepository) queryTrades(ctx context.Context, query string, args ...any) ([]*models.Trade, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orders []*models.Order
+	var trades []*models.Trade
 	for rows.Next() {
-		order := &models.Order{}
-		var price sql.NullFloat64
-		if err := rows.Scan(&order.OrderID, &order.Symbol, &order.Side, &order.Type, &price,
-			&order.InitialQuantity, &order.RemainingQuantity, &order.Status, &order.CreatedAt); err != nil {
+		trade := &models.Trade{}
+		if err := rows.Scan(&trade.TradeID, &trade.Symbol, &trade.BuyOrderID, &trade.SellOrderID,
+			&trade.Price, &trade.Quantity, &trade.CreatedAt, &trade.PrintType); err != nil {
 			return nil, err
 		}
-		order.Price = price
-		orders = append(orders, order)
+		trades = append(trades, trade)
+	}
+	return trades, rows.Err()
+}
+
+// GetTrades retrieves a page of a symbol's trades within the filter's bounds, newest first
+// unless the filter pages forward
+func (r *MySQLRepository) GetTrades(ctx context.Context, filter models.TradeFilter) ([]*models.Trade, error) {
+	conditions := []string{"symbol = ?"}
+	args := []any{filter.Symbol}
+	if filter.BeforeID != 0 {
+		conditions = append(conditions, "trade_id < ?")
+		args = append(args, filter.BeforeID)
+	}
+	if filter.AfterID != 0 {
+		conditions = append(conditions, "trade_id > ?")
+		args = append(args, filter.AfterID)
 	}
-	return orders, nil
+	if !filter.Before.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.Before)
+	}
+	if !filter.After.IsZero() {
+		conditions = append(conditions, "created_at > ?")
+		args = append(args, filter.After)
+	}
+	order := "DESC"
+	if filter.Forward() {
+		order = "ASC"
+	}
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY trade_id ` + order + `
+		LIMIT ?`
+	return r.queryTrades(ctx, query, append(args, filter.Limit)...)
 }
 
-// GetTrades retrieves all trades for a given symbol
-func (r *MySQLRepository) GetTrades(symbol string) ([]*models.Trade, error) {
+// GetRecentTrades retrieves a symbol's most recent trades, newest first
+func (r *MySQLRepository) GetRecentTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error) {
 	query := `
-		SELECT trade_id, symbol, buy_order_id, sell_order_id, price, quantity, created_at
+		SELECT ` + tradeColumns + `
 		FROM trades
-		WHERE symbol = ?`
-	rows, err := r.db.Query(query, symbol)
+		WHERE symbol = ?
+		ORDER BY trade_id DESC
+		LIMIT ?`
+	return r.queryTrades(ctx, query, symbol, limit)
+}
+
+// GetOrderTrades retrieves every trade an order took part in, on either side, oldest first
+func (r *MySQLRepository) GetOrderTrades(ctx context.Context, orderID uint64) ([]*models.Trade, error) {
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades
+		WHERE buy_order_id = ? OR sell_order_id = ?
+		ORDER BY trade_id`
+	return r.queryTrades(ctx, query, orderID, orderID)
+}
+
+// GetLastBookTrade retrieves a symbol's most recent trade matched in the book, nil when there is none
+func (r *MySQLRepository) GetLastBookTrade(ctx context.Context, symbol string) (*models.Trade, error) {
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades
+		WHERE symbol = ? AND print_type = 'book'
+		ORDER BY trade_id DESC
+		LIMIT 1`
+	trades, err := r.queryTrades(ctx, query, symbol)
+	if err != nil || len(trades) == 0 {
+		return nil, err
+	}
+	return trades[0], nil
+}
+
+// GetTradesAfter retrieves up to limit trades after the given commit sequence in commit sequence
+// order, along with the owner of each side; trades SequenceOutbox has yet to number are left for
+// a later call
+func (r *MySQLRepository) GetTradesAfter(ctx context.Context, afterSequence uint64, limit int) ([]*models.Trade, error) {
+	query := `
+		SELECT t.trade_id, t.commit_sequence, t.symbol, t.buy_order_id, t.sell_order_id, t.price, t.quantity, t.created_at,
+			t.print_type, COALESCE(b.owner_id, ''), COALESCE(s.owner_id, '')
+		FROM trades t
+		LEFT JOIN orders b ON b.order_id = t.buy_order_id
+		LEFT JOIN orders s ON s.order_id = t.sell_order_id
+		WHERE t.commit_sequence > ?
+		ORDER BY t.commit_sequence
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, afterSequence, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -158,11 +602,11 @@ func (r *MySQLRepository) GetTrades(symbol string) ([]*models.Trade, error) {
 	var trades []*models.Trade
 	for rows.Next() {
 		trade := &models.Trade{}
-		if err := rows.Scan(&trade.TradeID, &trade.Symbol, &trade.BuyOrderID, &trade.SellOrderID,
-			&trade.Price, &trade.Quantity, &trade.CreatedAt); err != nil {
+		if err := rows.Scan(&trade.TradeID, &trade.CommitSequence, &trade.Symbol, &trade.BuyOrderID, &trade.SellOrderID,
+			&trade.Price, &trade.Quantity, &trade.CreatedAt, &trade.PrintType, &trade.BuyOwnerID, &trade.SellOwnerID); err != nil {
 			return nil, err
 		}
 		trades = append(trades, trade)
 	}
-	return trades, nil
+	return trades, rows.Err()
 }
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// orderEventColumns lists the order_events table columns in the order used by GetOrderEvents
+const orderEventColumns = `event_id, COALESCE(commit_sequence, 0), kind, order_id, symbol, side, type, status, price, initial_quantity, remaining_quantity,
+	owner_id, created_at, actor, reason`
+
+// appendOrderEvent records a snapshot of an order in the outbox using the same database handle
+// or transaction as the write it describes, so the event commits if and only if the write does.
+// Events are only ever appended.
+func appendOrderEvent(ctx context.Context, e execer, kind models.OrderEventKind, order *models.Order, change models.OrderChange) error {
+	query := `
+		INSERT INTO order_events (kind, order_id, symbol, side, type, status, price, initial_quantity,
+			remaining_quantity, owner_id, created_at, actor, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := e.ExecContext(ctx, query, kind, order.OrderID, order.Symbol, order.Side, order.Type, order.Status, order.Price,
+		order.InitialQuantity, order.RemainingQuantity, order.OwnerID, time.Now(), change.Actor, change.Reason)
+	return err
+}
+
+// scanOrderEvent reads an order event selected with orderEventColumns
+func scanOrderEvent(row rowScanner) (*models.OrderEvent, error) {
+	event := &models.OrderEvent{}
+	err := row.Scan(&event.EventID, &event.CommitSequence, &event.Kind, &event.OrderID, &event.Symbol, &event.Side, &event.Type,
+		&event.Status, &event.Price, &event.InitialQuantity, &event.RemainingQuantity, &event.OwnerID,
+		&event.CreatedAt, &event.Actor, &event.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// queryOrderEvents runs a query selecting orderEventColumns and collects the resulting events
+func queryOrderEvents(ctx context.Context, q querier, query string, args ...any) ([]*models.OrderEvent, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.OrderEvent
+	for rows.Next() {
+		event, err := scanOrderEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// SaveRejection records the rejection of an order that was refused before it was stored
+func (r *MySQLRepository) SaveRejection(ctx context.Context, order *models.Order, reason models.ReasonCode) error {
+	rejected := *order
+	rejected.Status = models.StatusCanceled
+	rejected.RemainingQuantity = rejected.InitialQuantity
+	return appendOrderEvent(ctx, r.db, models.EventRejected, &rejected, models.OrderChange{Actor: order.OwnerID, Reason: reason})
+}
+
+// inTx runs fn in a transaction of its own, committing if it succeeds
+func (r *MySQLRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetOrderEvents retrieves up to limit order events after the given commit sequence, in commit
+// sequence order; events SequenceOutbox has yet to number are left for a later call
+func (r *MySQLRepository) GetOrderEvents(ctx context.Context, afterSequence uint64, limit int) ([]*models.OrderEvent, error) {
+	query := `
+		SELECT ` + orderEventColumns + `
+		FROM order_events
+		WHERE commit_sequence > ?
+		ORDER BY commit_sequence
+		LIMIT ?`
+	return queryOrderEvents(ctx, r.db, query, afterSequence, limit)
+}
+
+// GetOrderHistory retrieves every event of an order, oldest first
+func (r *MySQLRepository) GetOrderHistory(ctx context.Context, orderID uint64) ([]*models.OrderEvent, error) {
+	query := `
+		SELECT ` + orderEventColumns + `
+		FROM order_events
+		WHERE order_id = ?
+		ORDER BY event_id`
+	return queryOrderEvents(ctx, r.db, query, orderID)
+}
+
+// GetLastOrderEventID retrieves the ID of the latest order event, 0 when there is none
+func (r *MySQLRepository) GetLastOrderEventID(ctx context.Context) (uint64, error) {
+	var id uint64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(event_id), 0) FROM order_events`).Scan(&id)
+	return id, err
+}
+
+// GetEventCursors retrieves a subscriber's acknowledged event ID per shard
+func (r *MySQLRepository) GetEventCursors(ctx context.Context, subscriberID string) (map[int]uint64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT shard, last_event_id FROM event_cursors WHERE subscriber_id = ?`, subscriberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cursors := make(map[int]uint64)
+	for rows.Next() {
+		var shard int
+		var lastEventID uint64
+		if err := rows.Scan(&shard, &lastEventID); err != nil {
+			return nil, err
+		}
+		cursors[shard] = lastEventID
+	}
+	return cursors, rows.Err()
+}
+
+// SaveEventCursor records a subscriber's acknowledged event ID for a shard; a cursor never moves backwards
+func (r *MySQLRepository) SaveEventCursor(ctx context.Context, subscriberID string, shard int, lastEventID uint64) error {
+	upsert := `
+		ON DUPLICATE KEY UPDATE last_event_id = GREATEST(last_event_id, VALUES(last_event_id))`
+	if r.sqlite {
+		upsert = `
+		ON CONFLICT (subscriber_id, shard) DO UPDATE SET last_event_id = max(last_event_id, excluded.last_event_id)`
+	}
+	query := `
+		INSERT INTO event_cursors (subscriber_id, shard, last_event_id)
+		VALUES (?, ?, ?)` + upsert
+	_, err := r.db.ExecContext(ctx, query, subscriberID, shard, lastEventID)
+	return err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+)
+
+// orderGroupColumns lists the order_groups table columns in the order used by scanOrderGroup
+const orderGroupColumns = `group_id, type, symbol, status, owner_id, created_at`
+
+// scanOrderGroup reads an order group selected with orderGroupColumns
+func scanOrderGroup(row rowScanner) (*models.OrderGroup, error) {
+	group := &models.OrderGroup{}
+	err := row.Scan(&group.GroupID, &group.Type, &group.Symbol, &group.Status, &group.OwnerID, &group.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// SaveOrderGroupTx persists a new order group within a transaction
+func (r *MySQLRepository) SaveOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error {
+	query := `
+		INSERT INTO order_groups (` + orderGroupColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, query, group.GroupID, group.Type, group.Symbol, group.Status, group.OwnerID, group.CreatedAt)
+	return err
+}
+
+// UpdateOrderGroupTx updates an order group's status within a transaction
+func (r *MySQLRepository) UpdateOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error {
+	_, err := tx.ExecContext(ctx, `UPDATE order_groups SET status = ? WHERE group_id = ?`, group.Status, group.GroupID)
+	return err
+}
+
+// GetOrderGroup retrieves an order group by its ID
+func (r *MySQLRepository) GetOrderGroup(ctx context.Context, groupID uint64) (*models.OrderGroup, error) {
+	query := `
+		SELECT ` + orderGroupColumns + `
+		FROM order_groups
+		WHERE group_id = ?`
+	group, err := scanOrderGroup(r.db.QueryRowContext(ctx, query, groupID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetActiveOrderGroups retrieves all order groups whose orders are still linked
+func (r *MySQLRepository) GetActiveOrderGroups(ctx context.Context) ([]*models.OrderGroup, error) {
+	query := `
+		SELECT ` + orderGroupColumns + `
+		FROM order_groups
+		WHERE status = 'active'`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*models.OrderGroup
+	for rows.Next() {
+		group, err := scanOrderGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// GetGroupOrders retrieves the orders of an order group, oldest first
+func (r *MySQLRepository) GetGroupOrders(ctx context.Context, groupID uint64) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE group_id = ?
+		ORDER BY created_at, order_id`
+	return queryOrders(ctx, r.db, query, groupID)
+}
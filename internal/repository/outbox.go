@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Outboxes whose rows SequenceOutbox numbers in commit order
+const (
+	OutboxOrderEvents = "order_events"
+	OutboxTrades      = "trades"
+)
+
+// outboxKeys maps each outbox table to its key column, which is assigned before its row commits
+var outboxKeys = map[string]string{
+	OutboxOrderEvents: "event_id",
+	OutboxTrades:      "trade_id",
+}
+
+// readCommitted returns the options of a transaction each of whose reads sees everything
+// committed by then; SQLite runs one writer at a time and takes none
+func (r *MySQLRepository) readCommitted() *sql.TxOptions {
+	if r.sqlite {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+}
+
+// SequenceOutbox gives up to limit committed rows of an outbox that have no commit sequence yet
+// the next ones of the outbox's sequence. Keys are assigned when rows are inserted, so
+// transactions committing concurrently commit them out of order; a row is numbered only once it
+// is seen committed, so one committing after another is numbered after it, and readers paging by
+// commit sequence never pass a row that has yet to commit. Numbering takes the lock of the
+// outbox's counter, so callers number one at a time and each sees what the last one numbered.
+func (r *MySQLRepository) SequenceOutbox(ctx context.Context, outbox string, limit int) error {
+	key := outboxKeys[outbox]
+	var waiting bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+outbox+` WHERE commit_sequence IS NULL)`).Scan(&waiting)
+	if err != nil || !waiting {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, r.readCommitted())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var last uint64
+	query := `SELECT last_sequence FROM outbox_sequences WHERE outbox = ?` + r.forUpdate()
+	if err := tx.QueryRowContext(ctx, query, outbox).Scan(&last); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+key+`
+		FROM `+outbox+`
+		WHERE commit_sequence IS NULL
+		ORDER BY `+key+`
+		LIMIT ?`, limit)
+	if err != nil {
+		return err
+	}
+	var keys []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		keys = append(keys, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range keys {
+		last++
+		if _, err := tx.ExecContext(ctx, `UPDATE `+outbox+` SET commit_sequence = ? WHERE `+key+` = ?`, last, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE outbox_sequences SET last_sequence = ? WHERE outbox = ?`, last, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetOutboxSequence retrieves the last commit sequence given in an outbox, 0 when none has been
+func (r *MySQLRepository) GetOutboxSequence(ctx context.Context, outbox string) (uint64, error) {
+	var last uint64
+	err := r.db.QueryRowContext(ctx, `SELECT last_sequence FROM outbox_sequences WHERE outbox = ?`, outbox).Scan(&last)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return last, err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// parentOrderColumns lists the parent_orders table columns in the order used by scanParentOrder
+const parentOrderColumns = `parent_id, symbol, side, strategy, total_quantity, filled_quantity, limit_price,
+	participation_rate, slice_interval_ms, next_slice_at, end_at, status, created_at, owner_id`
+
+// scanParentOrder reads a parent order selected with parentOrderColumns
+func scanParentOrder(row rowScanner) (*models.ParentOrder, error) {
+	parent := &models.ParentOrder{}
+	var intervalMs int64
+	err := row.Scan(&parent.ParentID, &parent.Symbol, &parent.Side, &parent.Strategy, &parent.TotalQuantity,
+		&parent.FilledQuantity, &parent.LimitPrice, &parent.ParticipationRate, &intervalMs,
+		&parent.NextSliceAt, &parent.EndAt, &parent.Status, &parent.CreatedAt, &parent.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	parent.SliceInterval = time.Duration(intervalMs) * time.Millisecond
+	return parent, nil
+}
+
+// SaveParentOrder persists a new parent order
+func (r *MySQLRepository) SaveParentOrder(ctx context.Context, parent *models.ParentOrder) error {
+	query := `
+		INSERT INTO parent_orders (` + parentOrderColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, parent.ParentID, parent.Symbol, parent.Side, parent.Strategy, parent.TotalQuantity,
+		parent.FilledQuantity, parent.LimitPrice, parent.ParticipationRate, parent.SliceInterval.Milliseconds(),
+		parent.NextSliceAt, parent.EndAt, parent.Status, parent.CreatedAt, parent.OwnerID)
+	return err
+}
+
+// UpdateParentOrder updates a parent order's progress and status
+func (r *MySQLRepository) UpdateParentOrder(ctx context.Context, parent *models.ParentOrder) error {
+	query := `
+		UPDATE parent_orders
+		SET filled_quantity = ?, next_slice_at = ?, status = ?
+		WHERE parent_id = ?`
+	_, err := r.db.ExecContext(ctx, query, parent.FilledQuantity, parent.NextSliceAt, parent.Status, parent.ParentID)
+	return err
+}
+
+// GetParentOrder retrieves a parent order by its ID
+func (r *MySQLRepository) GetParentOrder(ctx context.Context, parentID uint64) (*models.ParentOrder, error) {
+	query := `
+		SELECT ` + parentOrderColumns + `
+		FROM parent_orders
+		WHERE parent_id = ?`
+	parent, err := scanParentOrder(r.db.QueryRowContext(ctx, query, parentID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrParentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// GetActiveParentOrders retrieves all parent orders that are still being worked
+func (r *MySQLRepository) GetActiveParentOrders(ctx context.Context) ([]*models.ParentOrder, error) {
+	query := `
+		SELECT ` + parentOrderColumns + `
+		FROM parent_orders
+		WHERE status = 'active'`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parents []*models.ParentOrder
+	for rows.Next() {
+		parent, err := scanParentOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, parent)
+	}
+	return parents, rows.Err()
+}
+
+// GetChildOrders retrieves the child orders of a parent order, oldest first
+func (r *MySQLRepository) GetChildOrders(ctx context.Context, parentID uint64) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE parent_id = ?
+		ORDER BY created_at`
+	return queryOrders(ctx, r.db, query, parentID)
+}
@@ -10,7 +10,7 @@ const (
 
 	SaveTrade = `INSERT INTO trades (symbol, buy_order_id, sell_order_id, price, quantity, created_at) VALUES (?,?,?,?,?,?)`
 
-	GetOrderBook = `SELECT order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at FROM orders WHERE symbol = ? AND status = 'open'`
+	GetOrderBook = `SELECT order_id, symbol, side, type, price, initial_quantity, remaining_quantity, status, created_at FROM orders WHERE symbol = ? AND status IN ('open', 'partially_filled')`
 
 	GetTrades = `SELECT trade_id, symbol, buy_order_id, sell_order_id, price, quantity, created_at FROM trades WHERE symbol = ? `
 )
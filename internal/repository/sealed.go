@@ -0,0 +1,109 @@
+package repository
+
+import "context"
+
+// rewrapBatch is how many values of a sealed column are read at a time while rewrapping
+const rewrapBatch = 500
+
+// sealedColumn is a column whose values are stored sealed by the repository's keyring, along with
+// the column identifying each row. A value is sealed for its table, column and row, so a sealed
+// value copied to another row does not open.
+type sealedColumn struct {
+	table, column, key string
+}
+
+// Columns stored sealed
+var (
+	accountName      = sealedColumn{"accounts", "name", "account_id"}
+	signingKeySecret = sealedColumn{"signing_keys", "secret", "key_id"}
+	webhookSecret    = sealedColumn{"webhooks", "secret", "webhook_id"}
+
+	sealedColumns = []sealedColumn{accountName, signingKeySecret, webhookSecret}
+)
+
+// String names the column as table.column
+func (c sealedColumn) String() string {
+	return c.table + "." + c.column
+}
+
+// field names a row's value of the column for the keyring
+func (c sealedColumn) field(row string) string {
+	return c.String() + ":" + row
+}
+
+// seal seals a row's value of a sealed column for storage
+func (r *MySQLRepository) seal(c sealedColumn, row, value string) (string, error) {
+	return r.keys.Seal(value, c.field(row))
+}
+
+// open opens a row's stored value of a sealed column
+func (r *MySQLRepository) open(c sealedColumn, row, value string) (string, error) {
+	return r.keys.Open(value, c.field(row))
+}
+
+// RewrapSealed seals every sealed column's values under the keyring's current master key,
+// rewrapping the data keys of values sealed under an older one and sealing values stored before
+// encryption was on, and returns how many values of each column changed. A value changed by
+// another writer meanwhile is left alone, already being sealed under the current key.
+func (r *MySQLRepository) RewrapSealed(ctx context.Context) (map[string]int, error) {
+	changed := make(map[string]int)
+	for _, c := range sealedColumns {
+		n, err := r.rewrapColumn(ctx, c)
+		changed[c.String()] = n
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// rowValue is a row's value of a sealed column
+type rowValue struct {
+	row, value string
+}
+
+// rewrapColumn rewraps a sealed column's values batch by batch, returning how many changed
+func (r *MySQLRepository) rewrapColumn(ctx context.Context, c sealedColumn) (int, error) {
+	query := `SELECT ` + c.key + `, ` + c.column + ` FROM ` + c.table + ` ORDER BY ` + c.key + ` LIMIT ? OFFSET ?`
+	update := `UPDATE ` + c.table + ` SET ` + c.column + ` = ? WHERE ` + c.key + ` = ? AND ` + c.column + ` = ?`
+	changed := 0
+	for offset := 0; ; offset += rewrapBatch {
+		rows, err := r.db.QueryContext(ctx, query, rewrapBatch, offset)
+		if err != nil {
+			return changed, err
+		}
+		var values []rowValue
+		for rows.Next() {
+			var v rowValue
+			if err := rows.Scan(&v.row, &v.value); err != nil {
+				rows.Close()
+				return changed, err
+			}
+			values = append(values, v)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return changed, err
+		}
+
+		for _, v := range values {
+			rewrapped, ok, err := r.keys.Rewrap(v.value, c.field(v.row))
+			if err != nil {
+				return changed, err
+			}
+			if !ok {
+				continue
+			}
+			result, err := r.db.ExecContext(ctx, update, rewrapped, v.row, v.value)
+			if err != nil {
+				return changed, err
+			}
+			if n, err := result.RowsAffected(); err == nil {
+				changed += int(n)
+			}
+		}
+		if len(values) < rewrapBatch {
+			return changed, nil
+		}
+	}
+}
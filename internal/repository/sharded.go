@@ -0,0 +1,884 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"orderSystem/internal/crypto"
+	"orderSystem/internal/models"
+	"sort"
+	"time"
+)
+
+// ShardedRepository partitions orders, trades and the rows derived from them across several
+// MySQL databases by symbol hash. Every shard carries the full schema, so a symbol's matching
+// transaction always runs on a single database; account-level reads merge all shards and
+// invoices live on shard 0. The shard count must not change without rebalancing, since it
+// determines which shard each symbol maps to.
+type ShardedRepository struct {
+	shards []*MySQLRepository
+}
+
+// NewShardedRepository creates a repository over one database per shard, shard 0 first
+func NewShardedRepository(dbs []*sql.DB) *ShardedRepository {
+	shards := make([]*MySQLRepository, len(dbs))
+	for i, db := range dbs {
+		shards[i] = NewMySQLRepository(db)
+	}
+	return &ShardedRepository{shards: shards}
+}
+
+// SealWith has every shard seal sensitive fields under a keyring, as MySQLRepository.SealWith
+func (r *ShardedRepository) SealWith(keys *crypto.Keyring) {
+	for _, shard := range r.shards {
+		shard.SealWith(keys)
+	}
+}
+
+// ShardIndex returns the shard holding a symbol's rows
+func (r *ShardedRepository) ShardIndex(symbol string) int {
+	h := fnv.New32a()
+	h.Write([]byte(symbol))
+	return int(h.Sum32() % uint32(len(r.shards)))
+}
+
+// shard returns the database holding a symbol's rows
+func (r *ShardedRepository) shard(symbol string) *MySQLRepository {
+	return r.shards[r.ShardIndex(symbol)]
+}
+
+// primary returns shard 0, which also holds the tables that are not partitioned by symbol
+func (r *ShardedRepository) primary() *MySQLRepository {
+	return r.shards[0]
+}
+
+// ForSymbol returns the repository holding a symbol's rows
+func (r *ShardedRepository) ForSymbol(symbol string) Repository {
+	return r.shard(symbol)
+}
+
+// Shards returns every shard of the repository
+func (r *ShardedRepository) Shards() []Repository {
+	shards := make([]Repository, len(r.shards))
+	for i, shard := range r.shards {
+		shards[i] = shard
+	}
+	return shards
+}
+
+// Ping verifies every shard connection is alive
+func (r *ShardedRepository) Ping(ctx context.Context) error {
+	for _, shard := range r.shards {
+		if err := shard.Ping(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gather runs a read on every shard and concatenates the results
+func gather[T any](ctx context.Context, r *ShardedRepository, read func(*MySQLRepository, context.Context) ([]T, error)) ([]T, error) {
+	var all []T
+	for _, shard := range r.shards {
+		items, err := read(shard, ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+	}
+	return all, nil
+}
+
+// BeginTx starts a transaction on shard 0; symbol transactions start from ForSymbol instead.
+// The Tx methods below run on whichever database the transaction belongs to.
+func (r *ShardedRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.primary().BeginTx(ctx)
+}
+
+// SaveOrder persists a new order on its symbol's shard
+func (r *ShardedRepository) SaveOrder(ctx context.Context, order *models.Order) error {
+	return r.shard(order.Symbol).SaveOrder(ctx, order)
+}
+
+// UpdateOrder updates an existing order on its symbol's shard
+func (r *ShardedRepository) UpdateOrder(ctx context.Context, order *models.Order, change models.OrderChange) error {
+	return r.shard(order.Symbol).UpdateOrder(ctx, order, change)
+}
+
+// GetOrder looks an order up on every shard
+func (r *ShardedRepository) GetOrder(ctx context.Context, orderID uint64) (*models.Order, error) {
+	for _, shard := range r.shards {
+		order, err := shard.GetOrder(ctx, orderID)
+		if err != models.ErrOrderNotFound {
+			return order, err
+		}
+	}
+	return nil, models.ErrOrderNotFound
+}
+
+// GetOrderByClientID looks an order up by its owner's client order ID on every shard
+func (r *ShardedRepository) GetOrderByClientID(ctx context.Context, ownerID, clientOrderID string) (*models.Order, error) {
+	for _, shard := range r.shards {
+		order, err := shard.GetOrderByClientID(ctx, ownerID, clientOrderID)
+		if err != models.ErrOrderNotFound {
+			return order, err
+		}
+	}
+	return nil, models.ErrOrderNotFound
+}
+
+// SaveTrade persists a trade on its symbol's shard
+func (r *ShardedRepository) SaveTrade(ctx context.Context, trade *models.Trade) error {
+	return r.shard(trade.Symbol).SaveTrade(ctx, trade)
+}
+
+// GetOrderBook retrieves all open orders for a symbol from its shard
+func (r *ShardedRepository) GetOrderBook(ctx context.Context, symbol string) ([]*models.Order, error) {
+	return r.shard(symbol).GetOrderBook(ctx, symbol)
+}
+
+// GetStaleOrders retrieves a symbol's stale resting orders from its shard
+func (r *ShardedRepository) GetStaleOrders(ctx context.Context, symbol string, before time.Time) ([]*models.Order, error) {
+	return r.shard(symbol).GetStaleOrders(ctx, symbol, before)
+}
+
+// GetExpiredOrders retrieves up to limit expired orders across all shards, soonest expiring first
+func (r *ShardedRepository) GetExpiredOrders(ctx context.Context, before time.Time, limit int) ([]*models.Order, error) {
+	orders, err := gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Order, error) {
+		return shard.GetExpiredOrders(ctx, before, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].ExpireAt.Time.Equal(orders[j].ExpireAt.Time) {
+			return orders[i].ExpireAt.Time.Before(orders[j].ExpireAt.Time)
+		}
+		return orders[i].OrderID < orders[j].OrderID
+	})
+	return orders[:min(len(orders), limit)], nil
+}
+
+// GetPendingStops retrieves every untriggered stop order across all shards, oldest first
+func (r *ShardedRepository) GetPendingStops(ctx context.Context) ([]*models.Order, error) {
+	orders, err := gather(ctx, r, (*MySQLRepository).GetPendingStops)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		if orders[i].Sequence != orders[j].Sequence {
+			return orders[i].Sequence < orders[j].Sequence
+		}
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders, nil
+}
+
+// GetMaxOrderSequence returns the highest order sequence across all shards
+func (r *ShardedRepository) GetMaxOrderSequence(ctx context.Context) (uint64, error) {
+	var highest uint64
+	for _, shard := range r.shards {
+		sequence, err := shard.GetMaxOrderSequence(ctx)
+		if err != nil {
+			return 0, err
+		}
+		highest = max(highest, sequence)
+	}
+	return highest, nil
+}
+
+// GetOpenOrders retrieves an account's open and pending orders across all shards
+func (r *ShardedRepository) GetOpenOrders(ctx context.Context, ownerID string) ([]*models.Order, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Order, error) {
+		return shard.GetOpenOrders(ctx, ownerID)
+	})
+}
+
+// GetOpenOrdersTx retrieves an account's open and pending orders on the transaction's shard
+func (r *ShardedRepository) GetOpenOrdersTx(ctx context.Context, tx Tx, ownerID string) ([]*models.Order, error) {
+	return r.primary().GetOpenOrdersTx(ctx, tx, ownerID)
+}
+
+// GetOrders retrieves the orders matching a filter from the filtered symbol's shard, or from
+// every shard merged newest first
+func (r *ShardedRepository) GetOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	if filter.Symbol != "" {
+		return r.shard(filter.Symbol).GetOrders(ctx, filter)
+	}
+	orders, err := gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Order, error) {
+		return shard.GetOrders(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.After(orders[j].CreatedAt)
+		}
+		return orders[i].OrderID > orders[j].OrderID
+	})
+	return orders[:min(len(orders), filter.Limit)], nil
+}
+
+// GetTrades retrieves all trades for a symbol from its shard
+func (r *ShardedRepository) GetTrades(ctx context.Context, filter models.TradeFilter) ([]*models.Trade, error) {
+	return r.shard(filter.Symbol).GetTrades(ctx, filter)
+}
+
+// GetOrderTrades retrieves an order's trades from whichever shard holds them
+func (r *ShardedRepository) GetOrderTrades(ctx context.Context, orderID uint64) ([]*models.Trade, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Trade, error) {
+		return shard.GetOrderTrades(ctx, orderID)
+	})
+}
+
+// GetRecentTrades retrieves a symbol's most recent trades from its shard
+func (r *ShardedRepository) GetRecentTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error) {
+	return r.shard(symbol).GetRecentTrades(ctx, symbol, limit)
+}
+
+// GetLastBookTrade retrieves a symbol's most recent book trade from its shard
+func (r *ShardedRepository) GetLastBookTrade(ctx context.Context, symbol string) (*models.Trade, error) {
+	return r.shard(symbol).GetLastBookTrade(ctx, symbol)
+}
+
+// SaveOrderTx persists a new order within a transaction
+func (r *ShardedRepository) SaveOrderTx(ctx context.Context, tx Tx, order *models.Order) error {
+	return r.primary().SaveOrderTx(ctx, tx, order)
+}
+
+// UpdateOrderTx updates an existing order within a transaction
+func (r *ShardedRepository) UpdateOrderTx(ctx context.Context, tx Tx, order *models.Order, change models.OrderChange) error {
+	return r.primary().UpdateOrderTx(ctx, tx, order, change)
+}
+
+// SaveTradeTx persists a trade within a transaction
+func (r *ShardedRepository) SaveTradeTx(ctx context.Context, tx Tx, trade *models.Trade) error {
+	return r.primary().SaveTradeTx(ctx, tx, trade)
+}
+
+// SaveDepthSnapshot persists a depth snapshot on its symbol's shard
+func (r *ShardedRepository) SaveDepthSnapshot(ctx context.Context, snapshot *models.DepthSnapshot) error {
+	return r.shard(snapshot.Symbol).SaveDepthSnapshot(ctx, snapshot)
+}
+
+// GetDepthSnapshots retrieves a symbol's depth snapshots from its shard
+func (r *ShardedRepository) GetDepthSnapshots(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.DepthSnapshot, error) {
+	return r.shard(symbol).GetDepthSnapshots(ctx, symbol, from, to, limit)
+}
+
+// SaveMarketQuality persists a market quality sample on its symbol's shard
+func (r *ShardedRepository) SaveMarketQuality(ctx context.Context, sample *models.MarketQuality) error {
+	return r.shard(sample.Symbol).SaveMarketQuality(ctx, sample)
+}
+
+// GetMarketQuality retrieves a symbol's market quality samples from its shard
+func (r *ShardedRepository) GetMarketQuality(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.MarketQuality, error) {
+	return r.shard(symbol).GetMarketQuality(ctx, symbol, from, to, limit)
+}
+
+// SaveCandles merges bars on their symbols' shards, one transaction per shard
+func (r *ShardedRepository) SaveCandles(ctx context.Context, candles []*models.Candle) error {
+	byShard := make(map[int][]*models.Candle)
+	for _, c := range candles {
+		index := r.ShardIndex(c.Symbol)
+		byShard[index] = append(byShard[index], c)
+	}
+	for index, shardCandles := range byShard {
+		if err := r.shards[index].SaveCandles(ctx, shardCandles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCandles retrieves a symbol's bars from its shard
+func (r *ShardedRepository) GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]*models.Candle, error) {
+	return r.shard(symbol).GetCandles(ctx, symbol, interval, from, to, limit)
+}
+
+// SaveLastLooks persists one incoming order's last looks on its symbol's shard
+func (r *ShardedRepository) SaveLastLooks(ctx context.Context, looks []*models.LastLook) error {
+	if len(looks) == 0 {
+		return nil
+	}
+	return r.shard(looks[0].Symbol).SaveLastLooks(ctx, looks)
+}
+
+// GetTickSize retrieves a symbol's changed tick size from its shard
+func (r *ShardedRepository) GetTickSize(ctx context.Context, symbol string) (models.Decimal, error) {
+	return r.shard(symbol).GetTickSize(ctx, symbol)
+}
+
+// SaveTickSizeTx records a symbol's new tick size within a transaction on its shard
+func (r *ShardedRepository) SaveTickSizeTx(ctx context.Context, tx Tx, symbol string, tick models.Decimal) error {
+	return r.primary().SaveTickSizeTx(ctx, tx, symbol, tick)
+}
+
+// SaveBookSnapshot stores a book snapshot on its symbol's shard
+func (r *ShardedRepository) SaveBookSnapshot(ctx context.Context, snapshot *models.BookSnapshot) error {
+	return r.shard(snapshot.Symbol).SaveBookSnapshot(ctx, snapshot)
+}
+
+// GetBookSnapshot retrieves a symbol's book snapshot from its shard
+func (r *ShardedRepository) GetBookSnapshot(ctx context.Context, symbol string) (*models.BookSnapshot, error) {
+	return r.shard(symbol).GetBookSnapshot(ctx, symbol)
+}
+
+// GetChangedOrders retrieves a symbol's orders changed after an order event of its shard
+func (r *ShardedRepository) GetChangedOrders(ctx context.Context, symbol string, afterEventID uint64) ([]*models.Order, error) {
+	return r.shard(symbol).GetChangedOrders(ctx, symbol, afterEventID)
+}
+
+// AppendJournalTx appends a part of an order command to the journal within a transaction on its
+// symbol's shard
+func (r *ShardedRepository) AppendJournalTx(ctx context.Context, tx Tx, entry *models.JournalEntry) error {
+	return r.shard(entry.Symbol).AppendJournalTx(ctx, tx, entry)
+}
+
+// GetJournalSequence returns the sequence of a symbol's last journaled command from its shard
+func (r *ShardedRepository) GetJournalSequence(ctx context.Context, symbol string) (uint64, error) {
+	return r.shard(symbol).GetJournalSequence(ctx, symbol)
+}
+
+// GetWriteBehindSequence retrieves the last write-behind batch of a node applied to shard 0;
+// each shard records the batches applied to it
+func (r *ShardedRepository) GetWriteBehindSequence(ctx context.Context, nodeID int) (uint64, error) {
+	return r.primary().GetWriteBehindSequence(ctx, nodeID)
+}
+
+// SaveWriteBehindSequenceTx records the last write-behind batch of a node applied to the
+// transaction's shard
+func (r *ShardedRepository) SaveWriteBehindSequenceTx(ctx context.Context, tx Tx, nodeID int, sequence uint64) error {
+	return r.primary().SaveWriteBehindSequenceTx(ctx, tx, nodeID, sequence)
+}
+
+// GetJournal retrieves a symbol's journaled commands from its shard
+func (r *ShardedRepository) GetJournal(ctx context.Context, symbol string, afterSequence uint64, limit int) ([]*models.JournalEntry, error) {
+	return r.shard(symbol).GetJournal(ctx, symbol, afterSequence, limit)
+}
+
+// GetJournalSymbols lists the symbols with journaled commands on every shard
+func (r *ShardedRepository) GetJournalSymbols(ctx context.Context) ([]string, error) {
+	symbols, err := gather(ctx, r, (*MySQLRepository).GetJournalSymbols)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// GetLastLookStats counts last look answers on the symbol's shard, or sums them across every
+// shard when no symbol is given
+func (r *ShardedRepository) GetLastLookStats(ctx context.Context, symbol string, from, to time.Time) ([]*models.LastLookStats, error) {
+	if symbol != "" {
+		return r.shard(symbol).GetLastLookStats(ctx, symbol, from, to)
+	}
+	all, err := gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.LastLookStats, error) {
+		return shard.GetLastLookStats(ctx, symbol, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	byProvider := make(map[string]*models.LastLookStats)
+	var stats []*models.LastLookStats
+	for _, s := range all {
+		total, ok := byProvider[s.ProviderID]
+		if !ok {
+			total = &models.LastLookStats{ProviderID: s.ProviderID}
+			byProvider[s.ProviderID] = total
+			stats = append(stats, total)
+		}
+		total.Requests += s.Requests
+		total.Rejects += s.Rejects
+		total.Timeouts += s.Timeouts
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ProviderID < stats[j].ProviderID })
+	return stats, nil
+}
+
+// SaveExecutionTx persists an execution within a transaction
+func (r *ShardedRepository) SaveExecutionTx(ctx context.Context, tx Tx, execution *models.Execution) error {
+	return r.primary().SaveExecutionTx(ctx, tx, execution)
+}
+
+// GetExecutions retrieves an order's executions from whichever shard holds them
+func (r *ShardedRepository) GetExecutions(ctx context.Context, orderID uint64) ([]*models.Execution, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Execution, error) {
+		return shard.GetExecutions(ctx, orderID)
+	})
+}
+
+// GetAveragePrices retrieves average fill prices from every shard, each order's executions
+// being on its symbol's shard
+func (r *ShardedRepository) GetAveragePrices(ctx context.Context, orderIDs []uint64) (map[uint64]models.Decimal, error) {
+	prices := make(map[uint64]models.Decimal)
+	for _, shard := range r.shards {
+		found, err := shard.GetAveragePrices(ctx, orderIDs)
+		if err != nil {
+			return nil, err
+		}
+		for orderID, price := range found {
+			prices[orderID] = price
+		}
+	}
+	return prices, nil
+}
+
+// SaveParentOrder persists a parent order on its symbol's shard
+func (r *ShardedRepository) SaveParentOrder(ctx context.Context, parent *models.ParentOrder) error {
+	return r.shard(parent.Symbol).SaveParentOrder(ctx, parent)
+}
+
+// UpdateParentOrder updates a parent order on its symbol's shard
+func (r *ShardedRepository) UpdateParentOrder(ctx context.Context, parent *models.ParentOrder) error {
+	return r.shard(parent.Symbol).UpdateParentOrder(ctx, parent)
+}
+
+// GetParentOrder looks a parent order up on every shard
+func (r *ShardedRepository) GetParentOrder(ctx context.Context, parentID uint64) (*models.ParentOrder, error) {
+	for _, shard := range r.shards {
+		parent, err := shard.GetParentOrder(ctx, parentID)
+		if err != models.ErrParentNotFound {
+			return parent, err
+		}
+	}
+	return nil, models.ErrParentNotFound
+}
+
+// GetActiveParentOrders retrieves active parent orders across all shards
+func (r *ShardedRepository) GetActiveParentOrders(ctx context.Context) ([]*models.ParentOrder, error) {
+	return gather(ctx, r, (*MySQLRepository).GetActiveParentOrders)
+}
+
+// GetChildOrders retrieves a parent order's children from whichever shard holds them
+func (r *ShardedRepository) GetChildOrders(ctx context.Context, parentID uint64) ([]*models.Order, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Order, error) {
+		return shard.GetChildOrders(ctx, parentID)
+	})
+}
+
+// SaveOrderGroupTx persists an order group within a transaction on its symbol's shard
+func (r *ShardedRepository) SaveOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error {
+	return r.primary().SaveOrderGroupTx(ctx, tx, group)
+}
+
+// UpdateOrderGroupTx updates an order group within a transaction on its symbol's shard
+func (r *ShardedRepository) UpdateOrderGroupTx(ctx context.Context, tx Tx, group *models.OrderGroup) error {
+	return r.primary().UpdateOrderGroupTx(ctx, tx, group)
+}
+
+// SaveBlockReportTx persists a block trade report within a transaction on its symbol's shard
+func (r *ShardedRepository) SaveBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error {
+	return r.primary().SaveBlockReportTx(ctx, tx, report)
+}
+
+// UpdateBlockReportTx updates a block trade report within a transaction on its symbol's shard
+func (r *ShardedRepository) UpdateBlockReportTx(ctx context.Context, tx Tx, report *models.BlockReport) error {
+	return r.primary().UpdateBlockReportTx(ctx, tx, report)
+}
+
+// GetBlockReport looks a block trade report up on every shard
+func (r *ShardedRepository) GetBlockReport(ctx context.Context, blockID uint64) (*models.BlockReport, error) {
+	for _, shard := range r.shards {
+		report, err := shard.GetBlockReport(ctx, blockID)
+		if err != models.ErrBlockNotFound {
+			return report, err
+		}
+	}
+	return nil, models.ErrBlockNotFound
+}
+
+// GetPendingBlockReports retrieves an account's pending block trade reports across all shards
+func (r *ShardedRepository) GetPendingBlockReports(ctx context.Context, accountID string) ([]*models.BlockReport, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.BlockReport, error) {
+		return shard.GetPendingBlockReports(ctx, accountID)
+	})
+}
+
+// GetOrderGroup looks an order group up on every shard
+func (r *ShardedRepository) GetOrderGroup(ctx context.Context, groupID uint64) (*models.OrderGroup, error) {
+	for _, shard := range r.shards {
+		group, err := shard.GetOrderGroup(ctx, groupID)
+		if err != models.ErrGroupNotFound {
+			return group, err
+		}
+	}
+	return nil, models.ErrGroupNotFound
+}
+
+// GetActiveOrderGroups retrieves active order groups across all shards
+func (r *ShardedRepository) GetActiveOrderGroups(ctx context.Context) ([]*models.OrderGroup, error) {
+	return gather(ctx, r, (*MySQLRepository).GetActiveOrderGroups)
+}
+
+// GetGroupOrders retrieves an order group's orders from whichever shard holds them
+func (r *ShardedRepository) GetGroupOrders(ctx context.Context, groupID uint64) ([]*models.Order, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Order, error) {
+		return shard.GetGroupOrders(ctx, groupID)
+	})
+}
+
+// PostLedgerTx records a posting in the ledger of the transaction's shard and applies it to the
+// shard's balance rows. Each shard keeps the part of an account's balance produced by its
+// symbols, and the postings that produced it; GetBalances sums them.
+func (r *ShardedRepository) PostLedgerTx(ctx context.Context, tx Tx, posting *models.LedgerPosting) error {
+	return r.primary().PostLedgerTx(ctx, tx, posting)
+}
+
+// GetLedger merges an account's latest ledger postings across all shards, newest first. Posting
+// IDs are only unique within a shard.
+func (r *ShardedRepository) GetLedger(ctx context.Context, accountID string, limit int) ([]*models.LedgerPosting, error) {
+	all, err := gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.LedgerPosting, error) {
+		return shard.GetLedger(ctx, accountID, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedAt.After(all[j].CreatedAt) })
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+// Reconcile reconciles each shard's balances with its own ledger
+func (r *ShardedRepository) Reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	reconciliation := &models.Reconciliation{}
+	for _, shard := range r.shards {
+		rec, err := shard.Reconcile(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reconciliation.Mismatches = append(reconciliation.Mismatches, rec.Mismatches...)
+		reconciliation.Unbalanced = append(reconciliation.Unbalanced, rec.Unbalanced...)
+	}
+	return reconciliation, nil
+}
+
+// GetBalances sums an account's per-shard balances by currency
+func (r *ShardedRepository) GetBalances(ctx context.Context, accountID string) ([]*models.Balance, error) {
+	all, err := gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Balance, error) {
+		return shard.GetBalances(ctx, accountID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	byCurrency := make(map[string]*models.Balance)
+	var balances []*models.Balance
+	for _, b := range all {
+		total, ok := byCurrency[b.Currency]
+		if !ok {
+			total = &models.Balance{AccountID: accountID, Currency: b.Currency}
+			byCurrency[b.Currency] = total
+			balances = append(balances, total)
+		}
+		total.Settled += b.Settled
+		total.Pending += b.Pending
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances, nil
+}
+
+// GetBalanceTx locks an account's balance row on the transaction's shard
+func (r *ShardedRepository) GetBalanceTx(ctx context.Context, tx Tx, accountID, currency string) (*models.Balance, error) {
+	return r.primary().GetBalanceTx(ctx, tx, accountID, currency)
+}
+
+// GetHeldTx locks an account's balance rows on the transaction's shard
+func (r *ShardedRepository) GetHeldTx(ctx context.Context, tx Tx, accountID string) (map[string]float64, error) {
+	return r.primary().GetHeldTx(ctx, tx, accountID)
+}
+
+// SaveSettlementTx persists a pending settlement within a transaction
+func (r *ShardedRepository) SaveSettlementTx(ctx context.Context, tx Tx, settlement *models.Settlement) error {
+	return r.primary().SaveSettlementTx(ctx, tx, settlement)
+}
+
+// GetDueSettlementsTx locks due settlements of the transaction's shard
+func (r *ShardedRepository) GetDueSettlementsTx(ctx context.Context, tx Tx, before time.Time, limit int) ([]*models.Settlement, error) {
+	return r.primary().GetDueSettlementsTx(ctx, tx, before, limit)
+}
+
+// MarkSettlementSettledTx marks a settlement on the transaction's shard as settled
+func (r *ShardedRepository) MarkSettlementSettledTx(ctx context.Context, tx Tx, settlementID uint64) error {
+	return r.primary().MarkSettlementSettledTx(ctx, tx, settlementID)
+}
+
+// GetSettlements retrieves an account's settlements across all shards
+func (r *ShardedRepository) GetSettlements(ctx context.Context, accountID string, status models.SettlementStatus) ([]*models.Settlement, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.Settlement, error) {
+		return shard.GetSettlements(ctx, accountID, status)
+	})
+}
+
+// SaveFeeEntryTx records a fee within a transaction
+func (r *ShardedRepository) SaveFeeEntryTx(ctx context.Context, tx Tx, entry *models.FeeEntry) error {
+	return r.primary().SaveFeeEntryTx(ctx, tx, entry)
+}
+
+// GetUninvoicedAccounts returns accounts with fees on any shard in [from, to) and no invoice on shard 0
+func (r *ShardedRepository) GetUninvoicedAccounts(ctx context.Context, from, to time.Time) ([]string, error) {
+	invoiced, err := r.primary().invoicedAccounts(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	all, err := gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]string, error) {
+		return shard.GetUninvoicedAccounts(ctx, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, accountID := range all {
+		if !invoiced[accountID] && !seen[accountID] {
+			seen[accountID] = true
+			accounts = append(accounts, accountID)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts, nil
+}
+
+// GetFeeLinesTx aggregates an account's fees across all shards, reading shard 0 within the
+// transaction. Invoiced months are closed, so the other shards need no transaction.
+func (r *ShardedRepository) GetFeeLinesTx(ctx context.Context, tx Tx, accountID string, from, to time.Time) ([]models.InvoiceLine, error) {
+	lines, err := r.primary().GetFeeLinesTx(ctx, tx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, shard := range r.shards[1:] {
+		more, err := queryInvoiceLines(ctx, shard.db, feeLinesQuery, accountID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, more...)
+	}
+
+	// A symbol lives on a single shard, so lines never need merging, only ordering
+	sort.Slice(lines, func(i, j int) bool {
+		if !lines[i].Day.Equal(lines[j].Day) {
+			return lines[i].Day.Before(lines[j].Day)
+		}
+		if lines[i].Symbol != lines[j].Symbol {
+			return lines[i].Symbol < lines[j].Symbol
+		}
+		return lines[i].Currency < lines[j].Currency
+	})
+	return lines, nil
+}
+
+// SaveInvoiceTx persists an invoice within a transaction on shard 0
+func (r *ShardedRepository) SaveInvoiceTx(ctx context.Context, tx Tx, invoice *models.Invoice) error {
+	return r.primary().SaveInvoiceTx(ctx, tx, invoice)
+}
+
+// GetInvoices retrieves an account's invoices from shard 0
+func (r *ShardedRepository) GetInvoices(ctx context.Context, accountID string) ([]*models.Invoice, error) {
+	return r.primary().GetInvoices(ctx, accountID)
+}
+
+// GetInvoice retrieves an invoice from shard 0
+func (r *ShardedRepository) GetInvoice(ctx context.Context, invoiceID uint64) (*models.Invoice, error) {
+	return r.primary().GetInvoice(ctx, invoiceID)
+}
+
+// GetOrderEvents retrieves order events from shard 0; commit sequences are per shard, so feeds
+// read each of Shards separately
+func (r *ShardedRepository) GetOrderEvents(ctx context.Context, afterSequence uint64, limit int) ([]*models.OrderEvent, error) {
+	return r.primary().GetOrderEvents(ctx, afterSequence, limit)
+}
+
+// GetOrderHistory retrieves an order's events from whichever shard holds them
+func (r *ShardedRepository) GetOrderHistory(ctx context.Context, orderID uint64) ([]*models.OrderEvent, error) {
+	return gather(ctx, r, func(shard *MySQLRepository, ctx context.Context) ([]*models.OrderEvent, error) {
+		return shard.GetOrderHistory(ctx, orderID)
+	})
+}
+
+// SaveRejection records an order's rejection on its symbol's shard
+func (r *ShardedRepository) SaveRejection(ctx context.Context, order *models.Order, reason models.ReasonCode) error {
+	return r.shard(order.Symbol).SaveRejection(ctx, order, reason)
+}
+
+// GetTradesAfter retrieves trades from shard 0; each shard is paged by its own commit sequence,
+// so publishers read each of Shards separately
+func (r *ShardedRepository) GetTradesAfter(ctx context.Context, afterSequence uint64, limit int) ([]*models.Trade, error) {
+	return r.primary().GetTradesAfter(ctx, afterSequence, limit)
+}
+
+// GetTradesBetween retrieves trades from shard 0; see GetTradesAfter
+func (r *ShardedRepository) GetTradesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Trade, error) {
+	return r.primary().GetTradesBetween(ctx, from, to, afterID, limit)
+}
+
+// GetOrderEventsBetween retrieves order events from shard 0; see GetOrderEvents
+func (r *ShardedRepository) GetOrderEventsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.OrderEvent, error) {
+	return r.primary().GetOrderEventsBetween(ctx, from, to, afterID, limit)
+}
+
+// GetFirstTradeTime returns when shard 0's first trade was made; see GetTradesAfter
+func (r *ShardedRepository) GetFirstTradeTime(ctx context.Context) (time.Time, error) {
+	return r.primary().GetFirstTradeTime(ctx)
+}
+
+// GetSettlementsBetween retrieves settlements from shard 0; see GetTradesAfter
+func (r *ShardedRepository) GetSettlementsBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.Settlement, error) {
+	return r.primary().GetSettlementsBetween(ctx, from, to, afterID, limit)
+}
+
+// GetFeeEntriesBetween retrieves fee ledger entries from shard 0; see GetTradesAfter
+func (r *ShardedRepository) GetFeeEntriesBetween(ctx context.Context, from, to time.Time, afterID uint64, limit int) ([]*models.FeeEntry, error) {
+	return r.primary().GetFeeEntriesBetween(ctx, from, to, afterID, limit)
+}
+
+// SaveDailySummary stores a day's summary on the primary, which keeps the chain for all shards
+func (r *ShardedRepository) SaveDailySummary(ctx context.Context, s *models.DailySummary) error {
+	return r.primary().SaveDailySummary(ctx, s)
+}
+
+// GetLastDailySummary retrieves the latest day's summary from the primary
+func (r *ShardedRepository) GetLastDailySummary(ctx context.Context) (*models.DailySummary, error) {
+	return r.primary().GetLastDailySummary(ctx)
+}
+
+// GetDailySummaries retrieves daily summaries from the primary
+func (r *ShardedRepository) GetDailySummaries(ctx context.Context, from, to time.Time) ([]*models.DailySummary, error) {
+	return r.primary().GetDailySummaries(ctx, from, to)
+}
+
+// GetLastOrderEventID retrieves the latest order event ID of shard 0; see GetOrderEvents
+func (r *ShardedRepository) GetLastOrderEventID(ctx context.Context) (uint64, error) {
+	return r.primary().GetLastOrderEventID(ctx)
+}
+
+// SequenceOutbox sequences an outbox of shard 0; see GetOrderEvents
+func (r *ShardedRepository) SequenceOutbox(ctx context.Context, outbox string, limit int) error {
+	return r.primary().SequenceOutbox(ctx, outbox, limit)
+}
+
+// GetOutboxSequence retrieves the last commit sequence of an outbox of shard 0; see GetOrderEvents
+func (r *ShardedRepository) GetOutboxSequence(ctx context.Context, outbox string) (uint64, error) {
+	return r.primary().GetOutboxSequence(ctx, outbox)
+}
+
+// GetEventCursors retrieves a subscriber's per-shard cursors from shard 0
+func (r *ShardedRepository) GetEventCursors(ctx context.Context, subscriberID string) (map[int]uint64, error) {
+	return r.primary().GetEventCursors(ctx, subscriberID)
+}
+
+// SaveEventCursor records a subscriber's cursor for a shard on shard 0
+func (r *ShardedRepository) SaveEventCursor(ctx context.Context, subscriberID string, shard int, lastEventID uint64) error {
+	return r.primary().SaveEventCursor(ctx, subscriberID, shard, lastEventID)
+}
+
+// SaveFeeSchedule persists a fee schedule on shard 0
+func (r *ShardedRepository) SaveFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	return r.primary().SaveFeeSchedule(ctx, schedule)
+}
+
+// UpdateFeeSchedule updates a fee schedule on shard 0
+func (r *ShardedRepository) UpdateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	return r.primary().UpdateFeeSchedule(ctx, schedule)
+}
+
+// GetFeeSchedule retrieves a fee schedule from shard 0
+func (r *ShardedRepository) GetFeeSchedule(ctx context.Context, scheduleID uint64) (*models.FeeSchedule, error) {
+	return r.primary().GetFeeSchedule(ctx, scheduleID)
+}
+
+// GetFeeSchedules retrieves every fee schedule from shard 0
+func (r *ShardedRepository) GetFeeSchedules(ctx context.Context) ([]*models.FeeSchedule, error) {
+	return r.primary().GetFeeSchedules(ctx)
+}
+
+// SaveSymbol lists a symbol in the registry on shard 0
+func (r *ShardedRepository) SaveSymbol(ctx context.Context, symbol *models.Symbol) error {
+	return r.primary().SaveSymbol(ctx, symbol)
+}
+
+// UpdateSymbol updates a listed symbol on shard 0
+func (r *ShardedRepository) UpdateSymbol(ctx context.Context, symbol *models.Symbol) error {
+	return r.primary().UpdateSymbol(ctx, symbol)
+}
+
+// GetSymbols retrieves every symbol in the registry from shard 0
+func (r *ShardedRepository) GetSymbols(ctx context.Context) ([]*models.Symbol, error) {
+	return r.primary().GetSymbols(ctx)
+}
+
+// SaveAccount persists an account on shard 0
+func (r *ShardedRepository) SaveAccount(ctx context.Context, account *models.Account) error {
+	return r.primary().SaveAccount(ctx, account)
+}
+
+// GetAccount retrieves an account from shard 0
+func (r *ShardedRepository) GetAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	return r.primary().GetAccount(ctx, accountID)
+}
+
+// RewrapSealed seals the sealed columns, all kept on shard 0, under the current master key
+func (r *ShardedRepository) RewrapSealed(ctx context.Context) (map[string]int, error) {
+	return r.primary().RewrapSealed(ctx)
+}
+
+// SaveSigningKey persists a signing key on shard 0
+func (r *ShardedRepository) SaveSigningKey(ctx context.Context, key *models.SigningKey) error {
+	return r.primary().SaveSigningKey(ctx, key)
+}
+
+// GetSigningKey retrieves a signing key from shard 0
+func (r *ShardedRepository) GetSigningKey(ctx context.Context, keyID string) (*models.SigningKey, error) {
+	return r.primary().GetSigningKey(ctx, keyID)
+}
+
+// GetSigningKeys retrieves an account's active signing keys from shard 0
+func (r *ShardedRepository) GetSigningKeys(ctx context.Context, accountID string) ([]*models.SigningKey, error) {
+	return r.primary().GetSigningKeys(ctx, accountID)
+}
+
+// RevokeSigningKey revokes a signing key on shard 0
+func (r *ShardedRepository) RevokeSigningKey(ctx context.Context, keyID string, at time.Time) error {
+	return r.primary().RevokeSigningKey(ctx, keyID, at)
+}
+
+// HasSigningKeyWithScope reports whether any active signing key on shard 0 holds the scope
+func (r *ShardedRepository) HasSigningKeyWithScope(ctx context.Context, scope models.KeyScope) (bool, error) {
+	return r.primary().HasSigningKeyWithScope(ctx, scope)
+}
+
+// SaveWebhook persists a webhook on shard 0
+func (r *ShardedRepository) SaveWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return r.primary().SaveWebhook(ctx, webhook)
+}
+
+// GetWebhook retrieves a webhook from shard 0
+func (r *ShardedRepository) GetWebhook(ctx context.Context, webhookID uint64) (*models.Webhook, error) {
+	return r.primary().GetWebhook(ctx, webhookID)
+}
+
+// GetWebhooks retrieves an account's webhooks from shard 0
+func (r *ShardedRepository) GetWebhooks(ctx context.Context, accountID string) ([]*models.Webhook, error) {
+	return r.primary().GetWebhooks(ctx, accountID)
+}
+
+// DeleteWebhook removes a webhook on shard 0
+func (r *ShardedRepository) DeleteWebhook(ctx context.Context, webhookID uint64) error {
+	return r.primary().DeleteWebhook(ctx, webhookID)
+}
+
+// SaveWebhookDeliveries queues webhook deliveries on shard 0
+func (r *ShardedRepository) SaveWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	return r.primary().SaveWebhookDeliveries(ctx, deliveries)
+}
+
+// GetDueWebhookDeliveries retrieves due webhook deliveries from shard 0
+func (r *ShardedRepository) GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	return r.primary().GetDueWebhookDeliveries(ctx, before, limit)
+}
+
+// UpdateWebhookDelivery records a webhook delivery attempt on shard 0
+func (r *ShardedRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.primary().UpdateWebhookDelivery(ctx, delivery)
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"strings"
+	"time"
+)
+
+// signingKeyColumns lists the signing_keys table columns in the order used by scanSigningKey
+const signingKeyColumns = `key_id, account_id, secret, scopes, allowed_ips, created_at, revoked_at`
+
+// SaveSigningKey persists a new signing key, its secret sealed
+func (r *MySQLRepository) SaveSigningKey(ctx context.Context, key *models.SigningKey) error {
+	secret, err := r.seal(signingKeySecret, key.KeyID, key.Secret)
+	if err != nil {
+		return err
+	}
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+	query := `
+		INSERT INTO signing_keys (key_id, account_id, secret, scopes, allowed_ips, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = r.db.ExecContext(ctx, query, key.KeyID, key.AccountID, secret, strings.Join(scopes, ","),
+		strings.Join(key.AllowedIPs, ","), key.CreatedAt)
+	return err
+}
+
+// scanSigningKey reads a signing key selected with signingKeyColumns, opening its secret
+func (r *MySQLRepository) scanSigningKey(row rowScanner) (*models.SigningKey, error) {
+	key := &models.SigningKey{}
+	var scopes, allowedIPs string
+	err := row.Scan(&key.KeyID, &key.AccountID, &key.Secret, &scopes, &allowedIPs, &key.CreatedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope != "" {
+			key.Scopes = append(key.Scopes, models.KeyScope(scope))
+		}
+	}
+	if allowedIPs != "" {
+		key.AllowedIPs = strings.Split(allowedIPs, ",")
+	}
+	if key.Secret, err = r.open(signingKeySecret, key.KeyID, key.Secret); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetSigningKey retrieves a signing key by its ID, revoked keys included
+func (r *MySQLRepository) GetSigningKey(ctx context.Context, keyID string) (*models.SigningKey, error) {
+	query := `
+		SELECT ` + signingKeyColumns + `
+		FROM signing_keys
+		WHERE key_id = ?`
+	key, err := r.scanSigningKey(r.db.QueryRowContext(ctx, query, keyID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrSigningKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetSigningKeys retrieves an account's active signing keys, oldest first
+func (r *MySQLRepository) GetSigningKeys(ctx context.Context, accountID string) ([]*models.SigningKey, error) {
+	query := `
+		SELECT ` + signingKeyColumns + `
+		FROM signing_keys
+		WHERE account_id = ? AND revoked_at IS NULL
+		ORDER BY created_at, key_id`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.SigningKey
+	for rows.Next() {
+		key, err := r.scanSigningKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// HasSigningKeyWithScope reports whether any active signing key holds the scope
+func (r *MySQLRepository) HasSigningKeyWithScope(ctx context.Context, scope models.KeyScope) (bool, error) {
+	// Scopes are stored comma separated
+	holds := `FIND_IN_SET(?, scopes) > 0`
+	if r.sqlite {
+		holds = `instr(',' || scopes || ',', ',' || ? || ',') > 0`
+	}
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM signing_keys
+			WHERE revoked_at IS NULL AND ` + holds + `
+		)`
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, string(scope)).Scan(&exists)
+	return exists, err
+}
+
+// RevokeSigningKey marks a signing key revoked, returning ErrSigningKeyNotFound when there is
+// no such key or it was already revoked
+func (r *MySQLRepository) RevokeSigningKey(ctx context.Context, keyID string, at time.Time) error {
+	query := `
+		UPDATE signing_keys
+		SET revoked_at = ?
+		WHERE key_id = ? AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, at, keyID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrSigningKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteRepository implements Repository on a single SQLite file, for development and embedded
+// use without a database server. It runs the MySQL repository's statements, which SQLite
+// accepts apart from the row locks and upserts given in SQLite form where they are made.
+// SQLite has no timestamp type: times are stored as text with their UTC offset and compared as
+// text, so the server should run in one time zone, such as TZ=UTC.
+type SQLiteRepository struct {
+	*MySQLRepository
+}
+
+// NewSQLiteRepository creates a repository on a database opened with OpenSQLite
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{&MySQLRepository{db: db, sqlite: true}}
+}
+
+// OpenSQLite opens the SQLite database at path, creating the file when it does not exist.
+// Transactions take the write lock as they begin, as InnoDB row locks would have serialized
+// their writes, and wait up to five seconds for it; reads proceed alongside them through the
+// write-ahead log.
+func OpenSQLite(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+	return sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+}
+
+// forUpdate returns the locking clause of a SELECT that locks the rows it reads, none on SQLite,
+// whose transactions hold the write lock from the start
+func (r *MySQLRepository) forUpdate() string {
+	if r.sqlite {
+		return ""
+	}
+	return `
+		FOR UPDATE`
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+)
+
+// GetActiveOrders retrieves every open and pending order of the shard, by order ID
+func (r *MySQLRepository) GetActiveOrders(ctx context.Context) ([]*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE status IN ('open', 'partially_filled', 'pending')
+		ORDER BY order_id`
+	return queryOrders(ctx, r.db, query)
+}
+
+// GetAllBalances retrieves every balance row of the shard, by account and currency
+func (r *MySQLRepository) GetAllBalances(ctx context.Context) ([]*models.Balance, error) {
+	query := `
+		SELECT account_id, currency, settled, pending
+		FROM balances
+		ORDER BY account_id, currency`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*models.Balance
+	for rows.Next() {
+		balance := &models.Balance{}
+		if err := rows.Scan(&balance.AccountID, &balance.Currency, &balance.Settled, &balance.Pending); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// GetLastTradeID retrieves the shard's latest trade ID, 0 when it has no trades
+func (r *MySQLRepository) GetLastTradeID(ctx context.Context) (uint64, error) {
+	var id uint64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(trade_id), 0) FROM trades`).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"orderSystem/internal/models"
+)
+
+// symbolColumns lists the symbols table columns in the order used by scanSymbol
+const symbolColumns = `symbol, base_currency, quote_currency, lot_size, min_notional, status, created_at, updated_at`
+
+// scanSymbol reads a listed symbol selected with symbolColumns
+func scanSymbol(row rowScanner) (*models.Symbol, error) {
+	symbol := &models.Symbol{}
+	err := row.Scan(&symbol.Symbol, &symbol.BaseCurrency, &symbol.QuoteCurrency, &symbol.LotSize,
+		&symbol.MinNotional, &symbol.Status, &symbol.CreatedAt, &symbol.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return symbol, nil
+}
+
+// SaveSymbol lists a new symbol in the registry
+func (r *MySQLRepository) SaveSymbol(ctx context.Context, symbol *models.Symbol) error {
+	query := `
+		INSERT INTO symbols (` + symbolColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, symbol.Symbol, symbol.BaseCurrency, symbol.QuoteCurrency, symbol.LotSize,
+		symbol.MinNotional, symbol.Status, symbol.CreatedAt, symbol.UpdatedAt)
+	return err
+}
+
+// UpdateSymbol updates a listed symbol's currencies, lot size, minimum notional and status
+func (r *MySQLRepository) UpdateSymbol(ctx context.Context, symbol *models.Symbol) error {
+	query := `
+		UPDATE symbols
+		SET base_currency = ?, quote_currency = ?, lot_size = ?, min_notional = ?, status = ?, updated_at = ?
+		WHERE symbol = ?`
+	_, err := r.db.ExecContext(ctx, query, symbol.BaseCurrency, symbol.QuoteCurrency, symbol.LotSize, symbol.MinNotional,
+		symbol.Status, symbol.UpdatedAt, symbol.Symbol)
+	return err
+}
+
+// GetSymbols retrieves every symbol in the registry, delisted ones included, by name
+func (r *MySQLRepository) GetSymbols(ctx context.Context) ([]*models.Symbol, error) {
+	query := `
+		SELECT ` + symbolColumns + `
+		FROM symbols
+		ORDER BY symbol`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []*models.Symbol
+	for rows.Next() {
+		symbol, err := scanSymbol(rows)
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"time"
+)
+
+// GetTickSize retrieves the tick size a symbol was last changed to, 0 when it never was
+func (r *MySQLRepository) GetTickSize(ctx context.Context, symbol string) (models.Decimal, error) {
+	var tick models.Decimal
+	err := r.db.QueryRowContext(ctx, `SELECT tick_size FROM tick_sizes WHERE symbol = ?`, symbol).Scan(&tick)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return tick, err
+}
+
+// SaveTickSizeTx records a symbol's new tick size within a transaction
+func (r *MySQLRepository) SaveTickSizeTx(ctx context.Context, tx Tx, symbol string, tick models.Decimal) error {
+	upsert := `
+		ON DUPLICATE KEY UPDATE tick_size = VALUES(tick_size), updated_at = VALUES(updated_at)`
+	if r.sqlite {
+		upsert = `
+		ON CONFLICT (symbol) DO UPDATE SET tick_size = excluded.tick_size, updated_at = excluded.updated_at`
+	}
+	query := `
+		INSERT INTO tick_sizes (symbol, tick_size, updated_at)
+		VALUES (?, ?, ?)` + upsert
+	_, err := tx.ExecContext(ctx, query, symbol, tick, time.Now())
+	return err
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"strconv"
+	"time"
+)
+
+// webhookColumns lists the webhooks table columns in the order used by scanWebhook
+const webhookColumns = `webhook_id, account_id, url, secret, created_at`
+
+// scanWebhook reads a webhook selected with webhookColumns, opening its secret
+func (r *MySQLRepository) scanWebhook(row rowScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	if err := row.Scan(&webhook.WebhookID, &webhook.AccountID, &webhook.URL, &webhook.Secret, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	secret, err := r.open(webhookSecret, strconv.FormatUint(webhook.WebhookID, 10), webhook.Secret)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// SaveWebhook persists a new webhook, setting its ID. The secret is sealed for the row, whose ID
+// the insert assigns, so it is stored by an update in the same transaction.
+func (r *MySQLRepository) SaveWebhook(ctx context.Context, webhook *models.Webhook) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO webhooks (account_id, url, secret, created_at)
+		VALUES (?, ?, '', ?)`
+	result, err := tx.ExecContext(ctx, query, webhook.AccountID, webhook.URL, webhook.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	secret, err := r.seal(webhookSecret, strconv.FormatInt(id, 10), webhook.Secret)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE webhooks SET secret = ? WHERE webhook_id = ?`, secret, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	webhook.WebhookID = uint64(id)
+	return nil
+}
+
+// GetWebhook retrieves a webhook by its ID
+func (r *MySQLRepository) GetWebhook(ctx context.Context, webhookID uint64) (*models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE webhook_id = ?`
+	webhook, err := r.scanWebhook(r.db.QueryRowContext(ctx, query, webhookID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// GetWebhooks retrieves an account's webhooks in registration order
+func (r *MySQLRepository) GetWebhooks(ctx context.Context, accountID string) ([]*models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE account_id = ?
+		ORDER BY webhook_id`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		webhook, err := r.scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook removes a webhook and gives up its pending deliveries
+func (r *MySQLRepository) DeleteWebhook(ctx context.Context, webhookID uint64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'failed', last_error = 'webhook deleted'
+		WHERE webhook_id = ? AND status = 'pending'`
+	if _, err := tx.ExecContext(ctx, query, webhookID); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE webhook_id = ?`, webhookID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrWebhookNotFound
+	}
+	return tx.Commit()
+}
+
+// SaveWebhookDeliveries queues deliveries, setting their IDs
+func (r *MySQLRepository) SaveWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	for _, delivery := range deliveries {
+		result, err := r.db.ExecContext(ctx, query, delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status,
+			delivery.Attempts, delivery.NextAttemptAt, delivery.CreatedAt)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		delivery.DeliveryID = uint64(id)
+	}
+	return nil
+}
+
+// GetDueWebhookDeliveries retrieves up to limit pending deliveries whose next attempt is due at
+// or before the given time, oldest first
+func (r *MySQLRepository) GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT delivery_id, webhook_id, event, payload, status, attempts, next_attempt_at, last_error, created_at
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, delivery_id
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		d := &models.WebhookDelivery{}
+		if err := rows.Scan(&d.DeliveryID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt
+func (r *MySQLRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?
+		WHERE delivery_id = ?`
+	_, err := r.db.ExecContext(ctx, query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError,
+		delivery.DeliveryID)
+	return err
+}
@@ -0,0 +1,28 @@
+package repository
+
+import "context"
+
+// GetWriteBehindSequence retrieves the sequence of the last write-behind batch of a node's log
+// applied to the database, 0 when none has been
+func (r *MySQLRepository) GetWriteBehindSequence(ctx context.Context, nodeID int) (uint64, error) {
+	var sequence uint64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM write_behind_checkpoints WHERE node_id = ?`,
+		nodeID).Scan(&sequence)
+	return sequence, err
+}
+
+// SaveWriteBehindSequenceTx records the sequence of the last write-behind batch of a node's log
+// applied, within the transaction applying it
+func (r *MySQLRepository) SaveWriteBehindSequenceTx(ctx context.Context, tx Tx, nodeID int, sequence uint64) error {
+	upsert := `
+		ON DUPLICATE KEY UPDATE sequence = VALUES(sequence)`
+	if r.sqlite {
+		upsert = `
+		ON CONFLICT (node_id) DO UPDATE SET sequence = excluded.sequence, updated_at = CURRENT_TIMESTAMP`
+	}
+	query := `
+		INSERT INTO write_behind_checkpoints (node_id, sequence)
+		VALUES (?, ?)` + upsert
+	_, err := tx.ExecContext(ctx, query, nodeID, sequence)
+	return err
+}
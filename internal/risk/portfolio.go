@@ -0,0 +1,171 @@
+package risk
+
+import (
+	"context"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/service"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// Position is an account's holding of one currency valued in the reporting currency
+type Position struct {
+	Currency string
+	Quantity float64 // settled plus pending
+	Rate     float64 // reporting currency per unit, 0 when no conversion is available
+	Value    float64
+	Valued   bool
+}
+
+// SymbolExposure is an account's resting order notional in one symbol
+type SymbolExposure struct {
+	Symbol        string
+	OpenOrders    int
+	BuyNotional   float64 // in the symbol's quote currency
+	SellNotional  float64
+	Value         float64 // buy plus sell notional in the reporting currency
+	Valued        bool
+	BuyCommitted  float64 // quote currency needed if every buy order fills
+	SellCommitted float64 // base currency needed if every sell order fills
+}
+
+// Portfolio summarizes an account's risk across all symbols in a single reporting currency
+type Portfolio struct {
+	AccountID         string
+	ReportingCurrency string
+	Positions         []Position
+	Exposures         []SymbolExposure
+	Equity            float64 // value of all valued positions
+	OpenOrderNotional float64 // value of all valued open orders
+	Committed         float64 // value of the balances open orders would consume
+	Utilization       float64 // Committed / Equity, 0 when equity is not positive
+	BuyingPower       float64 // collateral value of all positions net of what open orders commit
+	Unvalued          []string
+}
+
+// Reporter produces portfolio risk summaries from balances, open orders and last trade prices
+type Reporter struct {
+	service *service.MatchingService
+	repo    repository.Repository
+	cfg     *config.Config
+	logger  *zap.Logger
+}
+
+// NewReporter creates a portfolio risk reporter
+func NewReporter(s *service.MatchingService, repo repository.Repository, cfg *config.Config, logger *zap.Logger) *Reporter {
+	return &Reporter{service: s, repo: repo, cfg: cfg, logger: logger}
+}
+
+// rate converts one unit of currency into the reporting currency
+func (r *Reporter) rate(currency string) (float64, bool) {
+	return r.service.ConversionRate(currency)
+}
+
+// orderPrice is the price an open order's notional is measured at: its limit price, else its
+// trigger price, else the symbol's last trade price
+func (r *Reporter) orderPrice(order *models.Order) (models.Decimal, bool) {
+	if order.Price.Valid {
+		return order.Price.Decimal, true
+	}
+	if order.TriggerPrice.Valid {
+		return order.TriggerPrice.Decimal, true
+	}
+	return r.service.LastPrice(order.Symbol)
+}
+
+// GetPortfolio builds an account's portfolio risk summary. Currencies and symbols that cannot be
+// converted into the reporting currency are listed in Unvalued and excluded from the totals.
+func (r *Reporter) GetPortfolio(ctx context.Context, accountID string) (*Portfolio, error) {
+	balances, err := r.repo.GetBalances(ctx, accountID)
+	if err != nil {
+		r.logger.Error("Failed to get balances", zap.Error(err))
+		return nil, err
+	}
+	orders, err := r.repo.GetOpenOrders(ctx, accountID)
+	if err != nil {
+		r.logger.Error("Failed to get open orders", zap.Error(err))
+		return nil, err
+	}
+
+	portfolio := &Portfolio{AccountID: accountID, ReportingCurrency: r.cfg.Risk.ReportingCurrency}
+	unvalued := make(map[string]struct{})
+
+	for _, balance := range balances {
+		position := Position{Currency: balance.Currency, Quantity: balance.Settled + balance.Pending}
+		position.Rate, position.Valued = r.rate(balance.Currency)
+		if position.Valued {
+			position.Value = position.Quantity * position.Rate
+			portfolio.Equity += position.Value
+		} else {
+			unvalued[balance.Currency] = struct{}{}
+		}
+		portfolio.Positions = append(portfolio.Positions, position)
+	}
+
+	exposures := make(map[string]*SymbolExposure)
+	for _, order := range orders {
+		exposure, ok := exposures[order.Symbol]
+		if !ok {
+			exposure = &SymbolExposure{Symbol: order.Symbol}
+			exposures[order.Symbol] = exposure
+		}
+		exposure.OpenOrders++
+		price, ok := r.orderPrice(order)
+		if !ok {
+			unvalued[order.Symbol] = struct{}{}
+			continue
+		}
+		notional := models.Notional(price, order.RemainingQuantity)
+		if order.Side == models.SideBuy {
+			exposure.BuyNotional += notional
+			exposure.BuyCommitted += notional
+		} else {
+			exposure.SellNotional += notional
+			exposure.SellCommitted += order.RemainingQuantity.Float64()
+		}
+	}
+
+	for _, exposure := range exposures {
+		sc, ok := r.service.SymbolConfig(ctx, exposure.Symbol)
+		quoteRate, quoteOK := r.rate(sc.QuoteCurrency)
+		baseRate, baseOK := r.rate(sc.BaseCurrency)
+		_, unpriced := unvalued[exposure.Symbol]
+		if !ok || !quoteOK || !baseOK || unpriced {
+			unvalued[exposure.Symbol] = struct{}{}
+		} else {
+			exposure.Valued = true
+			exposure.Value = (exposure.BuyNotional + exposure.SellNotional) * quoteRate
+			portfolio.OpenOrderNotional += exposure.Value
+			portfolio.Committed += exposure.BuyCommitted*quoteRate + exposure.SellCommitted*baseRate
+		}
+		portfolio.Exposures = append(portfolio.Exposures, *exposure)
+	}
+	net := make(map[string]float64)
+	for _, position := range portfolio.Positions {
+		net[position.Currency] += position.Quantity
+	}
+	for _, exposure := range portfolio.Exposures {
+		if sc, ok := r.service.SymbolConfig(ctx, exposure.Symbol); ok {
+			net[sc.QuoteCurrency] -= exposure.BuyCommitted
+			net[sc.BaseCurrency] -= exposure.SellCommitted
+		}
+	}
+	for currency, quantity := range net {
+		if value, ok := r.service.CollateralValue(currency, quantity); ok {
+			portfolio.BuyingPower += value
+		}
+	}
+	sort.Slice(portfolio.Exposures, func(i, j int) bool { return portfolio.Exposures[i].Symbol < portfolio.Exposures[j].Symbol })
+
+	if portfolio.Equity > 0 {
+		portfolio.Utilization = portfolio.Committed / portfolio.Equity
+	}
+	for name := range unvalued {
+		portfolio.Unvalued = append(portfolio.Unvalued, name)
+	}
+	sort.Strings(portfolio.Unvalued)
+	return portfolio, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CreateAccount registers a new account along with its first signing key; the returned key is
+// the only place its secret is handed out
+func (s *MatchingService) CreateAccount(ctx context.Context, name string) (*models.Account, *models.SigningKey, error) {
+	account := &models.Account{
+		AccountID: randomHex(8),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SaveAccount(ctx, account); err != nil {
+		s.logger.Error("Failed to save account", zap.Error(err))
+		return nil, nil, repository.Classify(err)
+	}
+	key, err := s.CreateSigningKey(ctx, account.AccountID, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Account created", zap.String("account_id", account.AccountID))
+	return account, key, nil
+}
+
+// GetAccount returns a registered account, ErrAccountNotFound when there is none
+func (s *MatchingService) GetAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err == models.ErrAccountNotFound {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error("Failed to get account", zap.String("account_id", accountID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	return account, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// RejectionSink receives orders that were acknowledged before matching and then rejected by
+// their engine, along with the reason. It is called on the engine's goroutine and must not block.
+type RejectionSink interface {
+	Rejected(order *models.Order, err error)
+}
+
+// ReportRejectionsTo adds a sink receiving every rejected order accepted with AcceptOrder. It
+// must be called before the service handles orders.
+func (s *MatchingService) ReportRejectionsTo(sink RejectionSink) {
+	s.rejections = append(s.rejections, sink)
+}
+
+// AcceptOrder assigns a new order its ID and queues it for matching without waiting for the
+// result, which is then only visible through the order's state and the order event feed. The
+// credit check still runs before the order is accepted; a rejection by the engine is reported
+// to the rejection sinks. A resubmission under a used client order ID is given the earlier
+// order's ID instead of being queued. The caller's order is not touched after AcceptOrder returns.
+func (s *MatchingService) AcceptOrder(ctx context.Context, order *models.Order) error {
+	if order.Symbol == "" {
+		s.logger.Error("Invalid order parameters", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	// A lock on the owner's client order IDs is held until the engine has placed the order
+	unlock, err := s.lockClientOrder(ctx, order)
+	if err != nil {
+		return err
+	}
+	if existing, err := s.resubmission(ctx, order); err != nil || existing != nil {
+		unlock()
+		if err != nil {
+			return err
+		}
+		order.OrderID = existing.OrderID
+		return nil
+	}
+	if err := s.checkCredit(ctx, order); err != nil {
+		unlock()
+		s.recordRejection(ctx, order, err)
+		return err
+	}
+	s.delayAck()
+	order.OrderID = s.idgen.Next()
+	queued := *order
+
+	s.engine(order.Symbol).post(func(e *symbolEngine) {
+		defer unlock()
+		err := e.ensureLoaded()
+		if err == nil {
+			submitted := queued
+			e.journalPlacement(func() *models.JournalEntry {
+				submitted.CreatedAt = queued.CreatedAt
+				return &models.JournalEntry{Kind: models.JournalPlace, Orders: []*models.Order{&submitted}}
+			})
+			_, err = e.placeOrder(&queued)
+			e.countFlow(FlowNew, err)
+		}
+		if err != nil {
+			e.logger.Warn("Accepted order rejected", zap.Uint64("order_id", queued.OrderID), zap.Error(err))
+			e.recordRejection(ctx, &queued, err)
+			for _, sink := range e.rejections {
+				sink.Rejected(&queued, err)
+			}
+		}
+	})
+	return nil
+}
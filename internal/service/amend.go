@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// bookPosition finds a resting order in the in-memory book, returning the book's own copy, its
+// price level and its index within the level.
+func (e *symbolEngine) bookPosition(order *models.Order) (*models.Order, *models.OrderBookEntry, int) {
+	bids := order.Side == models.SideBuy
+	entry := findLevel(e.bookSide(bids)[order.Symbol], bids, order.Price.Decimal)
+	if entry == nil {
+		return nil, nil, 0
+	}
+	for i, o := range entry.Orders {
+		if o.OrderID == order.OrderID {
+			return o, entry, i
+		}
+	}
+	return nil, nil, 0
+}
+
+// AmendOrder changes the price and/or total quantity of an open limit order; an invalid price or
+// zero quantity leaves that attribute unchanged. A quantity decrease keeps the order's time
+// priority. A price change or quantity increase takes the order out of the book and re-enters it
+// as if newly arrived, matching it first if the new price crosses, all in one transaction.
+func (s *MatchingService) AmendOrder(ctx context.Context, orderID uint64, price models.NullDecimal, quantity models.Decimal) (*PlaceOrderResult, error) {
+	stored, err := s.commandOrder(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	if err := s.checkAmendmentCredit(ctx, stored, price, quantity); err != nil {
+		return nil, err
+	}
+	var result *PlaceOrderResult
+	err = s.engine(stored.Symbol).doContext(ctx, func(e *symbolEngine) error {
+		var err error
+		e.journal(&models.JournalEntry{Kind: models.JournalAmend, OrderID: orderID, Price: price, Quantity: quantity})
+		result, err = e.amendOrder(orderID, price, quantity)
+		e.countFlow(FlowModify, err)
+		return err
+	})
+	return result, err
+}
+
+// checkAmendmentCredit runs the credit check for an amendment that makes a stored order commit
+// more; amendments the engine will reject are left for it to report
+func (s *MatchingService) checkAmendmentCredit(ctx context.Context, stored *models.Order, price models.NullDecimal, quantity models.Decimal) error {
+	amended := *stored
+	if price.Valid {
+		amended.Price = price
+	}
+	if quantity > 0 {
+		amended.InitialQuantity = quantity
+		amended.RemainingQuantity = quantity - (stored.InitialQuantity - stored.RemainingQuantity)
+	}
+	if !stored.Status.Resting() || amended.RemainingQuantity <= 0 {
+		return nil
+	}
+	_, before := s.commitment(ctx, stored)
+	if _, after := s.commitment(ctx, &amended); after <= before {
+		return nil
+	}
+	return s.checkCredit(ctx, &amended)
+}
+
+// amendOrder amends an open order of the engine's symbol
+func (e *symbolEngine) amendOrder(orderID uint64, price models.NullDecimal, quantity models.Decimal) (*PlaceOrderResult, error) {
+	stored, err := e.storedOrder(orderID)
+	if err != nil {
+		e.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	if !stored.Status.Resting() {
+		e.logger.Warn("Attempt to amend non-open order", zap.Uint64("order_id", orderID))
+		return nil, models.ErrOrderNotOpen
+	}
+	order, _, _ := e.bookPosition(stored)
+	if order == nil {
+		e.logger.Warn("Attempt to amend order not resting in the book", zap.Uint64("order_id", orderID))
+		return nil, models.ErrOrderNotOpen
+	}
+
+	// Work out the amended attributes before touching the book
+	newPrice := order.Price
+	if price.Valid {
+		newPrice = price
+	}
+	newQuantity := order.InitialQuantity
+	if quantity > 0 {
+		newQuantity = quantity
+	}
+	filled := order.InitialQuantity - order.RemainingQuantity
+	if newPrice.Decimal <= 0 || newQuantity <= filled ||
+		(isIceberg(order) && order.DisplayQuantity.Decimal > newQuantity) {
+		e.logger.Warn("Invalid order amendment", zap.Uint64("order_id", orderID),
+			zap.Stringer("price", newPrice.Decimal), zap.Stringer("quantity", newQuantity))
+		return nil, models.ErrInvalidOrder
+	}
+	if !onTick(newPrice.Decimal, e.tickSize) {
+		e.logger.Warn("Amended price off the tick grid", zap.Uint64("order_id", orderID),
+			zap.Stringer("price", newPrice.Decimal), zap.Stringer("tick_size", e.tickSize))
+		return nil, models.ErrOffTick
+	}
+	if price.Valid && !e.withinBand(newPrice.Decimal) {
+		e.logger.Warn("Amended price outside the price band", zap.Uint64("order_id", orderID),
+			zap.Stringer("price", newPrice.Decimal))
+		return nil, models.ErrPriceBand
+	}
+	if e.halted() {
+		e.logger.Warn("Attempt to amend order of a halted symbol", zap.Uint64("order_id", orderID))
+		return nil, models.ErrSymbolHalted
+	}
+	if listing := e.listing(e.ctx, e.symbol); listing != nil {
+		if !onLot(newQuantity, listing.LotSize) {
+			e.logger.Warn("Amended quantity off the lot size", zap.Uint64("order_id", orderID),
+				zap.Stringer("quantity", newQuantity), zap.Stringer("lot_size", listing.LotSize))
+			return nil, models.ErrOffLot
+		}
+		if models.Notional(newPrice.Decimal, newQuantity) < listing.MinNotional {
+			e.logger.Warn("Amended order below the minimum notional", zap.Uint64("order_id", orderID),
+				zap.Stringer("price", newPrice.Decimal), zap.Stringer("quantity", newQuantity))
+			return nil, models.ErrBelowMinNotional
+		}
+	}
+	amended := *order
+	amended.Price = newPrice
+	amended.InitialQuantity = newQuantity
+	amended.RemainingQuantity = newQuantity - filled
+	if err := e.checkRiskLimits(&amended); err != nil {
+		e.logger.Warn("Amendment rejected by risk limits", zap.Uint64("order_id", orderID))
+		return nil, err
+	}
+
+	if newPrice.Decimal == order.Price.Decimal && newQuantity <= order.InitialQuantity {
+		return e.reduceOrder(order, &amended)
+	}
+
+	// Re-enter the book at the back of the queue, dropping the old slot first so the order
+	// can never match against itself or be filled twice
+	previous := *order
+	e.removeFromOrderBook(order)
+	order.Price = amended.Price
+	order.InitialQuantity = amended.InitialQuantity
+	order.RemainingQuantity = amended.RemainingQuantity
+	order.Sequence = e.nextSequence()
+	result, err := e.executeOrder(order, false)
+	if err != nil {
+		// executeOrder has already reverted its own book changes; the old sequence puts the
+		// order back in its former place in the queue
+		*order = previous
+		e.addToOrderBook(order)
+		return nil, err
+	}
+	e.logger.Info("Order amended", zap.Uint64("order_id", orderID), zap.Stringer("price", order.Price.Decimal),
+		zap.Stringer("quantity", order.InitialQuantity), zap.Int("trades", len(result.Trades)))
+	e.triggerStops(order.Symbol)
+	return result, nil
+}
+
+// reduceOrder applies a quantity decrease to a resting order in place, keeping its time priority.
+func (e *symbolEngine) reduceOrder(order, amended *models.Order) (*PlaceOrderResult, error) {
+	tx, err := e.beginTx(order.Symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("amend"), time.Now())
+
+	if err := e.updateOrderTx(tx, amended, models.OrderChange{Actor: amended.OwnerID}); err != nil {
+		e.logger.Error("Failed to update order", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if err := e.commit(tx, nil); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+
+	e.touchLevel(order.Side == models.SideBuy, order.Price.Decimal)
+	order.InitialQuantity = amended.InitialQuantity
+	order.RemainingQuantity = amended.RemainingQuantity
+	order.Version = amended.Version
+	if isIceberg(order) {
+		order.VisibleQuantity = min(order.VisibleQuantity, order.RemainingQuantity)
+	}
+	e.logger.Info("Order reduced", zap.Uint64("order_id", order.OrderID), zap.Stringer("quantity", order.InitialQuantity))
+	return &PlaceOrderResult{}, nil
+}
+
+// CancelQuantity cancels part of an open limit order's remaining quantity in place, keeping its
+// time priority, and returns the reduced order. The quantity must be less than what remains;
+// CancelOrder cancels the rest.
+func (s *MatchingService) CancelQuantity(ctx context.Context, orderID uint64, quantity models.Decimal) (*models.Order, error) {
+	stored, err := s.commandOrder(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	return callContext(ctx, s, stored.Symbol, func(e *symbolEngine) (*models.Order, error) {
+		e.journal(&models.JournalEntry{Kind: models.JournalCancelQuantity, OrderID: orderID, Quantity: quantity})
+		order, err := e.cancelQuantity(orderID, quantity)
+		e.countFlow(FlowCancel, err)
+		return order, err
+	})
+}
+
+// cancelQuantity reduces a resting order of the engine's symbol by quantity
+func (e *symbolEngine) cancelQuantity(orderID uint64, quantity models.Decimal) (*models.Order, error) {
+	stored, err := e.storedOrder(orderID)
+	if err != nil {
+		e.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	if !stored.Status.Resting() {
+		e.logger.Warn("Attempt to reduce non-open order", zap.Uint64("order_id", orderID))
+		return nil, models.ErrOrderNotOpen
+	}
+	order, _, _ := e.bookPosition(stored)
+	if order == nil {
+		e.logger.Warn("Attempt to reduce order not resting in the book", zap.Uint64("order_id", orderID))
+		return nil, models.ErrOrderNotOpen
+	}
+	if quantity <= 0 || quantity >= order.RemainingQuantity ||
+		(isIceberg(order) && order.DisplayQuantity.Decimal > order.InitialQuantity-quantity) {
+		e.logger.Warn("Invalid partial cancel", zap.Uint64("order_id", orderID), zap.Stringer("quantity", quantity))
+		return nil, models.ErrInvalidOrder
+	}
+	if listing := e.listing(e.ctx, e.symbol); listing != nil && !onLot(quantity, listing.LotSize) {
+		e.logger.Warn("Partial cancel off the lot size", zap.Uint64("order_id", orderID),
+			zap.Stringer("quantity", quantity), zap.Stringer("lot_size", listing.LotSize))
+		return nil, models.ErrOffLot
+	}
+
+	amended := *order
+	amended.InitialQuantity -= quantity
+	amended.RemainingQuantity -= quantity
+	if _, err := e.reduceOrder(order, &amended); err != nil {
+		return nil, err
+	}
+	reduced := *order
+	return &reduced, nil
+}
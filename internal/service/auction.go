@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"math"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// auctionCheckInterval is how often the auction monitor samples the book
+const auctionCheckInterval = time.Second
+
+// auctionState tracks a symbol that is collecting orders for an auction instead of matching continuously
+type auctionState struct {
+	Reason  string
+	EndsAt  time.Time
+	Started time.Time
+}
+
+// AuctionInfo describes a symbol's trading phase and its indicative uncrossing
+type AuctionInfo struct {
+	Symbol           string
+	Phase            models.TradingPhase
+	Reason           string
+	EndsAt           time.Time
+	IndicativePrice  models.Decimal
+	IndicativeVolume models.Decimal
+}
+
+// auctionFill is a single match produced by an uncrossing
+type auctionFill struct {
+	buy, sell *models.Order
+	quantity  models.Decimal
+}
+
+// inAuction reports whether new orders for the symbol must be collected rather than matched.
+func (e *symbolEngine) inAuction(symbol string) bool {
+	_, ok := e.auctions[symbol]
+	return ok
+}
+
+// startAuction moves a symbol into an auction phase
+func (e *symbolEngine) startAuction(symbol, reason string, duration time.Duration) {
+	now := time.Now()
+	e.auctions[symbol] = &auctionState{Reason: reason, Started: now, EndsAt: now.Add(duration)}
+	e.record(&models.JournalEntry{Kind: models.JournalAuction, Auction: reason})
+	e.logger.Info("Auction started", zap.String("symbol", symbol), zap.String("reason", reason),
+		zap.Duration("duration", duration))
+}
+
+// bookImbalance returns the ratio of the larger to the smaller side's quantity across the top levels
+func bookImbalance(bids, asks []*models.OrderBookEntry, levels int) float64 {
+	total := func(depth []models.PriceLevel) models.Decimal {
+		var qty models.Decimal
+		for _, level := range depth {
+			qty += level.Quantity
+		}
+		return qty
+	}
+	bidQty := total(depthLevels(bids, levels))
+	askQty := total(depthLevels(asks, levels))
+	if bidQty == 0 && askQty == 0 {
+		return 0
+	}
+	if bidQty == 0 || askQty == 0 {
+		return math.Inf(1)
+	}
+	return math.Max(bidQty.Float64()/askQty.Float64(), askQty.Float64()/bidQty.Float64())
+}
+
+// RunAuctionMonitor starts scheduled call auctions, uncrosses auctions whose collection period
+// has ended and watches every symbol for sustained one-sided imbalance, moving it into a
+// volatility auction when the configured ratio holds for the whole window
+func (s *MatchingService) RunAuctionMonitor(ctx context.Context) {
+	ticker := time.NewTicker(auctionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.checkAuctions(now)
+		}
+	}
+}
+
+// checkAuctions has every symbol engine end its expired auction or start one when scheduled or
+// imbalanced. Symbols with scheduled call auctions get an engine before their first order, so
+// the auction starts on time.
+func (s *MatchingService) checkAuctions(now time.Time) {
+	for symbol, sc := range s.cfg.Symbols {
+		if len(sc.CallAuctions) > 0 {
+			s.engine(symbol)
+		}
+	}
+	for _, engine := range s.allEngines() {
+		engine.inspect(func(e *symbolEngine) { e.checkAuctions(now) })
+	}
+}
+
+// checkAuctions starts a scheduled call auction once due, ends the symbol's auction once expired,
+// or starts one if the book is imbalanced. A halted symbol neither uncrosses nor enters an
+// auction until it resumes.
+func (e *symbolEngine) checkAuctions(now time.Time) {
+	if e.halted() {
+		return
+	}
+	e.checkCallAuction(now)
+	for symbol, state := range e.auctions {
+		if !now.Before(state.EndsAt) {
+			e.journal(&models.JournalEntry{Kind: models.JournalUncross})
+			if err := e.endAuction(symbol); err != nil {
+				e.logger.Error("Auction uncrossing failed, extending auction", zap.String("symbol", symbol), zap.Error(err))
+				state.EndsAt = now.Add(auctionCheckInterval)
+			}
+		}
+	}
+
+	engine := e.cfg.Engine
+	if engine.ImbalanceRatio == 0 {
+		return
+	}
+	for _, symbol := range e.bookSymbols() {
+		if e.inAuction(symbol) {
+			delete(e.imbalancedSince, symbol)
+			continue
+		}
+		ratio := bookImbalance(e.orderBook.Bids[symbol], e.orderBook.Asks[symbol], engine.ImbalanceLevels)
+		if ratio < engine.ImbalanceRatio {
+			delete(e.imbalancedSince, symbol)
+			continue
+		}
+		since, ok := e.imbalancedSince[symbol]
+		if !ok {
+			e.imbalancedSince[symbol] = now
+			since = now
+		}
+		if now.Sub(since) >= engine.ImbalanceWindow {
+			delete(e.imbalancedSince, symbol)
+			e.startAuction(symbol, "imbalance", engine.VolatilityAuctionDuration)
+		}
+	}
+}
+
+// endAuction uncrosses a symbol's auction and resumes continuous trading, triggering the stops
+// the uncrossing price reached. An auction with nothing to uncross still ends, so it is
+// journaled without a transaction.
+func (e *symbolEngine) endAuction(symbol string) error {
+	if err := e.uncross(symbol); err != nil {
+		return err
+	}
+	delete(e.auctions, symbol)
+	e.logger.Info("Auction ended, continuous trading resumed", zap.String("symbol", symbol))
+	e.flushJournal()
+	e.triggerStops(symbol)
+	return nil
+}
+
+// callAuctionDue returns the start of the symbol's scheduled call auction under way at now, if
+// the engine has not entered it yet
+func (e *symbolEngine) callAuctionDue(now time.Time) (time.Time, bool) {
+	sc := e.cfg.Symbols[e.symbol]
+	today := now.UTC().Truncate(24 * time.Hour)
+	var due time.Time
+	for _, offset := range sc.CallAuctions {
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			start := day.Add(offset)
+			if !start.After(now) && now.Before(start.Add(sc.CallAuctionDuration)) &&
+				start.After(e.callAuctionStart) && start.After(due) {
+				due = start
+			}
+		}
+	}
+	return due, !due.IsZero()
+}
+
+// checkCallAuction moves the symbol into its scheduled call auction once due, replacing any
+// volatility auction under way. The symbol's open orders are loaded first so they take part in
+// the uncrossing.
+func (e *symbolEngine) checkCallAuction(now time.Time) {
+	start, ok := e.callAuctionDue(now)
+	if !ok {
+		return
+	}
+	if e.ensureLoaded() != nil {
+		return
+	}
+	e.callAuctionStart = start
+	delete(e.imbalancedSince, e.symbol)
+	e.startAuction(e.symbol, "call", start.Add(e.cfg.Symbols[e.symbol].CallAuctionDuration).Sub(now))
+}
+
+// bookSymbols returns every symbol with orders on either side
+func (e *symbolEngine) bookSymbols() []string {
+	seen := make(map[string]struct{})
+	var symbols []string
+	for _, side := range []map[string][]*models.OrderBookEntry{e.orderBook.Bids, e.orderBook.Asks} {
+		for symbol := range side {
+			if _, ok := seen[symbol]; !ok {
+				seen[symbol] = struct{}{}
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// priorityOrders flattens one side of the book into price-time priority: the levels are already
+// in price order and each level's queue in time order
+func priorityOrders(entries []*models.OrderBookEntry) []*models.Order {
+	var orders []*models.Order
+	for _, entry := range entries {
+		orders = append(orders, entry.Orders...)
+	}
+	return orders
+}
+
+// equilibriumPrice finds the price maximizing executable volume, breaking ties by the
+// smallest surplus and then the lowest price
+func equilibriumPrice(bids, asks []*models.Order) (price, volume models.Decimal) {
+	candidates := make(map[models.Decimal]struct{})
+	for _, order := range append(append([]*models.Order{}, bids...), asks...) {
+		candidates[order.Price.Decimal] = struct{}{}
+	}
+
+	bestSurplus := models.Decimal(math.MaxInt64)
+	for p := range candidates {
+		var demand, supply models.Decimal
+		for _, bid := range bids {
+			if bid.Price.Decimal >= p {
+				demand += bid.RemainingQuantity
+			}
+		}
+		for _, ask := range asks {
+			if ask.Price.Decimal <= p {
+				supply += ask.RemainingQuantity
+			}
+		}
+		executable := min(demand, supply)
+		surplus := demand - supply
+		if surplus < 0 {
+			surplus = -surplus
+		}
+		if executable > volume ||
+			(executable == volume && executable > 0 && (surplus < bestSurplus || (surplus == bestSurplus && p < price))) {
+			price, volume, bestSurplus = p, executable, surplus
+		}
+	}
+	return price, volume
+}
+
+// auctionFills pairs crossing orders in price-time priority up to the equilibrium volume
+func auctionFills(bids, asks []*models.Order, price, volume models.Decimal) []auctionFill {
+	var fills []auctionFill
+	buyLeft, sellLeft := make(map[uint64]models.Decimal), make(map[uint64]models.Decimal)
+	i, j := 0, 0
+	for volume > 0 && i < len(bids) && j < len(asks) {
+		buy, sell := bids[i], asks[j]
+		if buy.Price.Decimal < price || sell.Price.Decimal > price {
+			break
+		}
+		if _, ok := buyLeft[buy.OrderID]; !ok {
+			buyLeft[buy.OrderID] = buy.RemainingQuantity
+		}
+		if _, ok := sellLeft[sell.OrderID]; !ok {
+			sellLeft[sell.OrderID] = sell.RemainingQuantity
+		}
+		qty := min(volume, min(buyLeft[buy.OrderID], sellLeft[sell.OrderID]))
+		fills = append(fills, auctionFill{buy: buy, sell: sell, quantity: qty})
+		volume -= qty
+		buyLeft[buy.OrderID] -= qty
+		sellLeft[sell.OrderID] -= qty
+		if buyLeft[buy.OrderID] == 0 {
+			i++
+		}
+		if sellLeft[sell.OrderID] == 0 {
+			j++
+		}
+	}
+	return fills
+}
+
+// uncross executes all crossing orders of a symbol at a single equilibrium price inside one
+// transaction, updating the in-memory book only after the transaction commits.
+func (e *symbolEngine) uncross(symbol string) error {
+	bids := priorityOrders(e.orderBook.Bids[symbol])
+	asks := priorityOrders(e.orderBook.Asks[symbol])
+	price, volume := equilibriumPrice(bids, asks)
+	if volume == 0 {
+		return nil
+	}
+	fills := auctionFills(bids, asks, price, volume)
+
+	tx, err := e.beginTx(symbol)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("auction"), time.Now())
+
+	// Work on copies so a failed transaction leaves the book untouched
+	updated := make(map[uint64]*models.Order)
+	working := func(order *models.Order) *models.Order {
+		if o, ok := updated[order.OrderID]; ok {
+			return o
+		}
+		o := *order
+		updated[order.OrderID] = &o
+		return &o
+	}
+
+	now := tradeTime()
+	trades := make([]*models.Trade, 0, len(fills))
+	for _, fill := range fills {
+		buy, sell := working(fill.buy), working(fill.sell)
+		buy.RemainingQuantity -= fill.quantity
+		sell.RemainingQuantity -= fill.quantity
+		trade := &models.Trade{
+			TradeID:     e.idgen.Next(),
+			Symbol:      symbol,
+			BuyOrderID:  buy.OrderID,
+			SellOrderID: sell.OrderID,
+			Price:       price,
+			Quantity:    fill.quantity,
+			CreatedAt:   now,
+			PrintType:   models.PrintBook,
+			BuyOwnerID:  buy.OwnerID,
+			SellOwnerID: sell.OwnerID,
+		}
+		if err := e.repo.SaveTradeTx(e.ctx, tx, trade); err != nil {
+			return err
+		}
+		trades = append(trades, trade)
+		for _, execution := range newExecutions(trade, 0) {
+			execution.Liquidity = models.LiquidityAuction
+			if err := e.repo.SaveExecutionTx(e.ctx, tx, execution); err != nil {
+				return err
+			}
+			if err := e.chargeFeeTx(e.ctx, tx, trade, execution); err != nil {
+				return err
+			}
+		}
+	}
+	if err := e.settleTradesTx(e.ctx, tx, trades); err != nil {
+		return err
+	}
+	for _, order := range updated {
+		if order.RemainingQuantity == 0 {
+			order.Status = models.StatusFilled
+		} else {
+			order.Status = models.StatusPartiallyFilled
+		}
+		if err := e.updateOrderTx(tx, order, models.OrderChange{Actor: models.ActorEngine}); err != nil {
+			return err
+		}
+	}
+	groups, err := e.completeGroupsTx(tx, firedLegs(nil, false, trades))
+	if err != nil {
+		return err
+	}
+	if err := e.commit(tx, trades); err != nil {
+		return err
+	}
+	e.recordVolume(trades)
+
+	for _, order := range append(bids, asks...) {
+		o, ok := updated[order.OrderID]
+		if !ok {
+			continue
+		}
+		e.touchLevel(order.Side == models.SideBuy, order.Price.Decimal)
+		order.RemainingQuantity, order.Status, order.Version = o.RemainingQuantity, o.Status, o.Version
+		if order.Status == models.StatusFilled {
+			e.removeFromOrderBook(order)
+		} else {
+			replenish(order)
+		}
+	}
+	e.finishGroups(groups)
+	e.logger.Info("Auction uncrossed", zap.String("symbol", symbol), zap.Stringer("price", price),
+		zap.Stringer("volume", volume), zap.Int("fills", len(fills)))
+	return nil
+}
+
+// GetAuctionInfo reports a symbol's trading phase, halted or not, and during an auction its
+// indicative price
+func (s *MatchingService) GetAuctionInfo(ctx context.Context, symbol string) *AuctionInfo {
+	info := &AuctionInfo{Symbol: symbol, Phase: models.PhaseContinuous}
+	if listing := s.listing(ctx, symbol); listing != nil && listing.Status == models.SymbolHalted {
+		info.Phase = models.PhaseHalted
+		return info
+	}
+	if engine := s.existingEngine(symbol); engine != nil {
+		engine.inspect(func(e *symbolEngine) { info = e.auctionInfo(symbol) })
+	}
+	return info
+}
+
+// auctionInfo reports the engine's trading phase and indicative auction price
+func (e *symbolEngine) auctionInfo(symbol string) *AuctionInfo {
+	info := &AuctionInfo{Symbol: symbol, Phase: models.PhaseContinuous}
+	state, ok := e.auctions[symbol]
+	if !ok {
+		return info
+	}
+	info.Phase = models.PhaseAuction
+	info.Reason = state.Reason
+	info.EndsAt = state.EndsAt
+	info.IndicativePrice, info.IndicativeVolume = equilibriumPrice(
+		priorityOrders(e.orderBook.Bids[symbol]),
+		priorityOrders(e.orderBook.Asks[symbol]),
+	)
+	return info
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BlockTrade is a pre-negotiated trade between two accounts reported for off-book execution
+type BlockTrade struct {
+	Symbol   string
+	BuyerID  string
+	SellerID string
+	Price    models.Decimal
+	Quantity models.Decimal
+}
+
+// BlockTradeResult holds the printed trade of an affirmed block and the filled order of each side
+type BlockTradeResult struct {
+	Trade      *models.Trade
+	BuyOrder   *models.Order
+	SellOrder  *models.Order
+	Executions []*models.Execution
+}
+
+// referencePrice returns the price block trades are banded around: the last book trade, or the
+// midpoint of the best bid and ask when the symbol has not traded yet.
+func (e *symbolEngine) referencePrice(symbol string) (models.Decimal, bool) {
+	if price, ok := e.lastPrice[symbol]; ok {
+		return price, true
+	}
+	bids := depthLevels(e.orderBook.Bids[symbol], 1)
+	asks := depthLevels(e.orderBook.Asks[symbol], 1)
+	if len(bids) == 0 || len(asks) == 0 {
+		return 0, false
+	}
+	return (bids[0].Price + asks[0].Price) / 2, true
+}
+
+// blockOrder creates the filled order representing one side of a block trade
+func blockOrder(orderID uint64, block *BlockTrade, side models.OrderSide, ownerID string, now time.Time) *models.Order {
+	return &models.Order{
+		OrderID:         orderID,
+		Symbol:          block.Symbol,
+		Side:            side,
+		Type:            models.TypeLimit,
+		Price:           models.NullDecimal{Decimal: block.Price, Valid: true},
+		InitialQuantity: block.Quantity,
+		Status:          models.StatusFilled,
+		CreatedAt:       now,
+		OwnerID:         ownerID,
+		TimeInForce:     models.TIFGTC,
+	}
+}
+
+// ReportBlockTrade records a pre-negotiated block trade reported by one of its sides, which is
+// only printed once the other side affirms it with AffirmBlockTrade, so no account can trade
+// on another's behalf. The block is checked as it will be when affirmed, so one that could not
+// print is refused up front.
+func (s *MatchingService) ReportBlockTrade(ctx context.Context, reporterID string, block *BlockTrade) (*models.BlockReport, error) {
+	if block.Symbol == "" || reporterID != block.BuyerID && reporterID != block.SellerID {
+		s.logger.Warn("Invalid block trade", zap.Any("block", block))
+		return nil, models.ErrInvalidBlockTrade
+	}
+	counterparty := block.BuyerID
+	if reporterID == block.BuyerID {
+		counterparty = block.SellerID
+	}
+	if _, err := s.GetAccount(ctx, counterparty); err != nil {
+		return nil, err
+	}
+	return call(s, block.Symbol, func(e *symbolEngine) (*models.BlockReport, error) {
+		return e.reportBlockTrade(reporterID, block)
+	})
+}
+
+// AffirmBlockTrade affirms a block trade reported by its other side, printing it to the tape
+// without touching the book. Each side is recorded as a filled order with a block execution,
+// charged fees at the taker rate and settled like any other trade. Block prints do not move the
+// last trade price, so they neither trigger stops nor shift the band for later blocks. Only the
+// counterparty of a pending block that has not expired can affirm it; to anyone else it does
+// not exist.
+func (s *MatchingService) AffirmBlockTrade(ctx context.Context, accountID string, blockID uint64) (*BlockTradeResult, error) {
+	report, err := s.commandBlock(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Counterparty() != accountID {
+		return nil, models.ErrBlockNotFound
+	}
+	return call(s, report.Symbol, func(e *symbolEngine) (*BlockTradeResult, error) {
+		return e.affirmBlockTrade(accountID, blockID)
+	})
+}
+
+// DeclineBlockTrade declines a pending block trade, which either side may do: the counterparty
+// to refuse it, the reporter to withdraw it
+func (s *MatchingService) DeclineBlockTrade(ctx context.Context, accountID string, blockID uint64) (*models.BlockReport, error) {
+	report, err := s.commandBlock(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+	if report.BuyerID != accountID && report.SellerID != accountID {
+		return nil, models.ErrBlockNotFound
+	}
+	return call(s, report.Symbol, func(e *symbolEngine) (*models.BlockReport, error) {
+		return e.declineBlockTrade(blockID)
+	})
+}
+
+// GetPendingBlockTrades retrieves the block trades either side of which is the account that are
+// still waiting to be affirmed
+func (s *MatchingService) GetPendingBlockTrades(ctx context.Context, accountID string) ([]*models.BlockReport, error) {
+	reports, err := s.repo.GetPendingBlockReports(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get block trades", zap.String("account_id", accountID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	now := time.Now()
+	pending := reports[:0]
+	for _, report := range reports {
+		if now.Before(report.ExpiresAt) {
+			pending = append(pending, report)
+		}
+	}
+	return pending, nil
+}
+
+// validateBlock checks a block trade of the engine's symbol could print now
+func (e *symbolEngine) validateBlock(block *BlockTrade) error {
+	if e.halted() {
+		e.logger.Warn("Block trade for a halted symbol", zap.String("symbol", block.Symbol))
+		return models.ErrSymbolHalted
+	}
+	if listing := e.listing(e.ctx, block.Symbol); listing == nil || listing.Status != models.SymbolActive {
+		e.logger.Warn("Block trade for a symbol that is not listed", zap.String("symbol", block.Symbol))
+		return models.ErrSymbolNotListed
+	}
+	risk := e.cfg.Risk
+	if block.Price <= 0 || block.Quantity <= 0 || block.Quantity < models.NewDecimal(risk.BlockMinQuantity) ||
+		block.BuyerID == "" || block.SellerID == "" || block.BuyerID == block.SellerID {
+		e.logger.Warn("Invalid block trade", zap.Any("block", block))
+		return models.ErrInvalidBlockTrade
+	}
+	if risk.BlockPriceBand > 0 {
+		reference, ok := e.referencePrice(block.Symbol)
+		if !ok {
+			e.logger.Warn("No reference price for block trade", zap.String("symbol", block.Symbol))
+			return models.ErrNoReferencePrice
+		}
+		deviation := block.Price - reference
+		if deviation < 0 {
+			deviation = -deviation
+		}
+		if deviation > reference.Mul(models.NewDecimal(risk.BlockPriceBand)) {
+			e.logger.Warn("Block trade outside price band", zap.String("symbol", block.Symbol),
+				zap.Stringer("price", block.Price), zap.Stringer("reference", reference))
+			return models.ErrOutsidePriceBand
+		}
+	}
+	return nil
+}
+
+// reportBlockTrade validates and records a block trade of the engine's symbol, pending its
+// counterparty's affirmation
+func (e *symbolEngine) reportBlockTrade(reporterID string, block *BlockTrade) (*models.BlockReport, error) {
+	if err := e.validateBlock(block); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	report := &models.BlockReport{
+		BlockID:    e.idgen.Next(),
+		Symbol:     block.Symbol,
+		BuyerID:    block.BuyerID,
+		SellerID:   block.SellerID,
+		ReporterID: reporterID,
+		Price:      block.Price,
+		Quantity:   block.Quantity,
+		Status:     models.BlockPending,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(e.cfg.Risk.BlockAffirmTTL),
+	}
+	tx, err := e.beginTx(block.Symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	defer tx.Rollback()
+	if err := e.repo.SaveBlockReportTx(e.ctx, tx, report); err != nil {
+		e.logger.Error("Failed to save block trade", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if err := tx.Commit(); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	e.logger.Info("Block trade reported", zap.Uint64("block_id", report.BlockID), zap.String("symbol", block.Symbol),
+		zap.String("reporter_id", reporterID), zap.Stringer("price", block.Price), zap.Stringer("quantity", block.Quantity))
+	return report, nil
+}
+
+// pendingBlock reads a block trade report of the engine's symbol once its queued writes are
+// written, refusing one that is no longer pending
+func (e *symbolEngine) pendingBlock(blockID uint64) (*models.BlockReport, error) {
+	if err := e.awaitWrites(); err != nil {
+		return nil, repository.Classify(err)
+	}
+	report, err := e.repo.GetBlockReport(e.ctx, blockID)
+	if err == models.ErrBlockNotFound {
+		return nil, err
+	} else if err != nil {
+		e.logger.Error("Failed to get block trade", zap.Uint64("block_id", blockID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if report.Status != models.BlockPending || !time.Now().Before(report.ExpiresAt) {
+		return nil, models.ErrBlockNotPending
+	}
+	return report, nil
+}
+
+// declineBlockTrade records that a pending block trade of the engine's symbol was declined
+func (e *symbolEngine) declineBlockTrade(blockID uint64) (*models.BlockReport, error) {
+	report, err := e.pendingBlock(blockID)
+	if err != nil {
+		return nil, err
+	}
+	report.Status = models.BlockDeclined
+	tx, err := e.beginTx(report.Symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	defer tx.Rollback()
+	if err := e.repo.UpdateBlockReportTx(e.ctx, tx, report); err != nil {
+		e.logger.Error("Failed to update block trade", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if err := tx.Commit(); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	e.logger.Info("Block trade declined", zap.Uint64("block_id", blockID))
+	return report, nil
+}
+
+// affirmBlockTrade validates a pending block trade of the engine's symbol again, as the book may
+// have moved since it was reported, and prints it
+func (e *symbolEngine) affirmBlockTrade(accountID string, blockID uint64) (*BlockTradeResult, error) {
+	report, err := e.pendingBlock(blockID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Counterparty() != accountID {
+		return nil, models.ErrBlockNotFound
+	}
+	block := &BlockTrade{
+		Symbol:   report.Symbol,
+		BuyerID:  report.BuyerID,
+		SellerID: report.SellerID,
+		Price:    report.Price,
+		Quantity: report.Quantity,
+	}
+	if err := e.validateBlock(block); err != nil {
+		return nil, err
+	}
+
+	now := tradeTime()
+	buy := blockOrder(e.idgen.Next(), block, models.SideBuy, block.BuyerID, now)
+	sell := blockOrder(e.idgen.Next(), block, models.SideSell, block.SellerID, now)
+	trade := &models.Trade{
+		TradeID:     e.idgen.Next(),
+		Symbol:      block.Symbol,
+		BuyOrderID:  buy.OrderID,
+		SellOrderID: sell.OrderID,
+		Price:       block.Price,
+		Quantity:    block.Quantity,
+		CreatedAt:   now,
+		PrintType:   models.PrintBlock,
+		BuyOwnerID:  buy.OwnerID,
+		SellOwnerID: sell.OwnerID,
+	}
+
+	tx, err := e.beginTx(block.Symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("block_trade"), time.Now())
+
+	for _, order := range []*models.Order{buy, sell} {
+		if err := e.repo.SaveOrderTx(e.ctx, tx, order); err != nil {
+			e.logger.Error("Failed to save block order", zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+	}
+	if err := e.repo.SaveTradeTx(e.ctx, tx, trade); err != nil {
+		e.logger.Error("Failed to save block trade", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	executions := newExecutions(trade, 0)
+	for _, execution := range executions {
+		execution.Liquidity = models.LiquidityBlock
+		if err := e.repo.SaveExecutionTx(e.ctx, tx, execution); err != nil {
+			e.logger.Error("Failed to save execution", zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+		if err := e.chargeFeeTx(e.ctx, tx, trade, execution); err != nil {
+			e.logger.Error("Failed to charge fee", zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+	}
+	report.Status, report.TradeID = models.BlockAffirmed, trade.TradeID
+	if err := e.repo.UpdateBlockReportTx(e.ctx, tx, report); err != nil {
+		e.logger.Error("Failed to update block trade", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if err := e.settleTradesTx(e.ctx, tx, []*models.Trade{trade}); err != nil {
+		e.logger.Error("Failed to settle block trade", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	for _, order := range []*models.Order{buy, sell} {
+		if err := e.coverTx(tx, order); err != nil {
+			return nil, repository.Classify(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	e.announceTrades([]*models.Trade{trade})
+
+	e.logger.Info("Block trade affirmed", zap.Uint64("block_id", blockID), zap.Uint64("trade_id", trade.TradeID),
+		zap.String("symbol", block.Symbol),
+		zap.Stringer("price", block.Price), zap.Stringer("quantity", block.Quantity))
+	return &BlockTradeResult{Trade: trade, BuyOrder: buy, SellOrder: sell, Executions: executions}, nil
+}
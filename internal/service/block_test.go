@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"testing"
+)
+
+// newBlockTestService creates a service listing BTCUSD without a block price band, along with
+// two registered accounts holding both currencies
+func newBlockTestService(t *testing.T) (*MatchingService, string, string) {
+	t.Helper()
+	s, _ := newTestService(t, testSetup{configure: func(cfg *config.Config) {
+		cfg.Risk.BlockPriceBand = 0
+	}}, "BTCUSD")
+	ctx := context.Background()
+	var ids []string
+	for _, name := range []string{"fund-a", "fund-b"} {
+		account, _, err := s.CreateAccount(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, currency := range []string{"BTC", "USD"} {
+			if err := s.Deposit(ctx, account.AccountID, currency, 1000); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ids = append(ids, account.AccountID)
+	}
+	return s, ids[0], ids[1]
+}
+
+// TestBlockTradeAwaitsAffirmation checks a reported block moves no balance until its
+// counterparty affirms it, and that neither the reporter nor anyone else can affirm it for them
+func TestBlockTradeAwaitsAffirmation(t *testing.T) {
+	s, reporter, counterparty := newBlockTestService(t)
+	ctx := context.Background()
+	block := &BlockTrade{Symbol: "BTCUSD", BuyerID: reporter, SellerID: counterparty,
+		Price: models.NewDecimal(100), Quantity: models.NewDecimal(2)}
+	report, err := s.ReportBlockTrade(ctx, reporter, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != models.BlockPending {
+		t.Fatalf("reported block is %s, want pending", report.Status)
+	}
+	if got := balanceOf(t, s, counterparty, "BTC").Settled; got != 1000 {
+		t.Errorf("counterparty BTC before affirming = %v, want 1000", got)
+	}
+
+	for _, account := range []string{reporter, "someone-else"} {
+		if _, err := s.AffirmBlockTrade(ctx, account, report.BlockID); err != models.ErrBlockNotFound {
+			t.Errorf("affirming as %s: %v, want %v", account, err, models.ErrBlockNotFound)
+		}
+	}
+	if got := balanceOf(t, s, counterparty, "BTC").Settled; got != 1000 {
+		t.Errorf("counterparty BTC without its affirmation = %v, want 1000", got)
+	}
+
+	result, err := s.AffirmBlockTrade(ctx, counterparty, report.BlockID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Trade.PrintType != models.PrintBlock || result.Trade.SellOwnerID != counterparty {
+		t.Errorf("printed trade %+v, want a block sold by the counterparty", result.Trade)
+	}
+	if got := balanceOf(t, s, counterparty, "BTC").Settled; got != 998 {
+		t.Errorf("counterparty BTC after affirming = %v, want 998", got)
+	}
+	if _, err := s.AffirmBlockTrade(ctx, counterparty, report.BlockID); err != models.ErrBlockNotPending {
+		t.Errorf("affirming twice: %v, want %v", err, models.ErrBlockNotPending)
+	}
+	assertReconciles(t, s)
+}
+
+func TestDeclinedBlockTradeCannotBeAffirmed(t *testing.T) {
+	s, reporter, counterparty := newBlockTestService(t)
+	ctx := context.Background()
+	block := &BlockTrade{Symbol: "BTCUSD", BuyerID: counterparty, SellerID: reporter,
+		Price: models.NewDecimal(100), Quantity: models.NewDecimal(1)}
+	report, err := s.ReportBlockTrade(ctx, reporter, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeclineBlockTrade(ctx, counterparty, report.BlockID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AffirmBlockTrade(ctx, counterparty, report.BlockID); err != models.ErrBlockNotPending {
+		t.Errorf("affirming a declined block: %v, want %v", err, models.ErrBlockNotPending)
+	}
+	if got := balanceOf(t, s, counterparty, "USD").Settled; got != 1000 {
+		t.Errorf("counterparty USD after declining = %v, want 1000", got)
+	}
+}
+
+func TestBlockTradeNeedsRegisteredCounterparty(t *testing.T) {
+	s, reporter, _ := newBlockTestService(t)
+	block := &BlockTrade{Symbol: "BTCUSD", BuyerID: reporter, SellerID: "unregistered",
+		Price: models.NewDecimal(100), Quantity: models.NewDecimal(1)}
+	if _, err := s.ReportBlockTrade(context.Background(), reporter, block); err != models.ErrAccountNotFound {
+		t.Errorf("reporting against an unregistered account: %v, want %v", err, models.ErrAccountNotFound)
+	}
+}
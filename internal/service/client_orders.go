@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// GetOrderByClientID retrieves one of an account's orders by the client order ID it was placed with
+func (s *MatchingService) GetOrderByClientID(ctx context.Context, ownerID, clientOrderID string) (*models.Order, error) {
+	order, err := s.repo.GetOrderByClientID(ctx, ownerID, clientOrderID)
+	if err == models.ErrOrderNotFound {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error("Failed to get order by client order ID", zap.String("client_order_id", clientOrderID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	s.withFills(ctx, order)
+	return order, nil
+}
+
+// resubmission returns the order already placed under a new order's client order ID, nil when
+// the order has none or it is unused. A new order repeating the existing one's parameters is a
+// resubmission; one that differs is rejected with ErrDuplicateClientID.
+func (s *MatchingService) resubmission(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if !order.ClientOrderID.Valid {
+		return nil, nil
+	}
+	existing, err := s.GetOrderByClientID(ctx, order.OwnerID, order.ClientOrderID.String)
+	if err == models.ErrOrderNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !repeats(order, existing) {
+		s.logger.Warn("Client order ID reused for a different order", zap.String("owner_id", order.OwnerID),
+			zap.String("client_order_id", order.ClientOrderID.String), zap.Uint64("order_id", existing.OrderID))
+		return nil, models.ErrDuplicateClientID
+	}
+	return existing, nil
+}
+
+// repeats reports whether a new order has the parameters existing was placed with. A maximum
+// slippage became a protection price, and a quote quantity a quantity, against the book of the
+// time, so those results are not compared.
+func repeats(order, existing *models.Order) bool {
+	tif := order.TimeInForce
+	if tif == "" {
+		tif = models.TIFGTC
+	}
+	price := order.Price
+	if !isLimitPriced(order) {
+		price = models.NullDecimal{}
+	}
+	return order.Symbol == existing.Symbol && order.Side == existing.Side && order.Type == existing.Type &&
+		price == existing.Price && tif == existing.TimeInForce && order.QuoteQuantity == existing.QuoteQuantity &&
+		(order.QuoteQuantity.Valid || order.InitialQuantity == existing.InitialQuantity) &&
+		order.TriggerPrice == existing.TriggerPrice && order.DisplayQuantity == existing.DisplayQuantity &&
+		order.ExpireAt.Valid == existing.ExpireAt.Valid &&
+		order.ExpireAt.Time.Truncate(time.Microsecond).Equal(existing.ExpireAt.Time) &&
+		(order.MaxSlippage.Valid || order.ProtectionPrice == existing.ProtectionPrice)
+}
+
+// replay answers a resubmission with the existing order, copied into the resubmitted one, and
+// the executions it has had so far
+func (s *MatchingService) replay(ctx context.Context, order, existing *models.Order) (*PlaceOrderResult, error) {
+	executions, err := s.repo.GetExecutions(ctx, existing.OrderID)
+	if err != nil {
+		s.logger.Error("Failed to get executions", zap.Uint64("order_id", existing.OrderID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	s.logger.Info("Order resubmitted", zap.Uint64("order_id", existing.OrderID),
+		zap.String("client_order_id", existing.ClientOrderID.String))
+	*order = *existing
+	return &PlaceOrderResult{Executions: executions, Resubmitted: true}, nil
+}
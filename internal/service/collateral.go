@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+)
+
+// ConversionRate converts one unit of currency into the reporting currency through the last
+// trade price of a configured symbol quoting one against the other, directly or inverted
+func (s *MatchingService) ConversionRate(currency string) (float64, bool) {
+	reporting := s.cfg.Risk.ReportingCurrency
+	if currency == reporting {
+		return 1, true
+	}
+	for symbol, sc := range s.cfg.Symbols {
+		price, ok := s.LastPrice(symbol)
+		if !ok || price <= 0 {
+			continue
+		}
+		if sc.BaseCurrency == currency && sc.QuoteCurrency == reporting {
+			return price.Float64(), true
+		}
+		if sc.BaseCurrency == reporting && sc.QuoteCurrency == currency {
+			return 1 / price.Float64(), true
+		}
+	}
+	return 0, false
+}
+
+// Haircut returns the fraction of a currency's value not counted as collateral
+func (s *MatchingService) Haircut(currency string) float64 {
+	if haircut, ok := s.cfg.Risk.Haircuts[currency]; ok {
+		return haircut
+	}
+	return s.cfg.Risk.DefaultHaircut
+}
+
+// CollateralValue values a net holding of a currency as collateral in the reporting currency:
+// a holding at its mark price less its haircut, a shortfall at its full mark price. It returns
+// false when the currency cannot be converted.
+func (s *MatchingService) CollateralValue(currency string, quantity float64) (float64, bool) {
+	rate, ok := s.ConversionRate(currency)
+	if !ok {
+		return 0, false
+	}
+	if quantity < 0 {
+		return quantity * rate, true
+	}
+	return quantity * rate * (1 - s.Haircut(currency)), true
+}
+
+// CollateralCreditChecker is the built-in cross-collateral credit check. An order passes when
+// the account's balance of the currency it consumes, net of what its other open orders commit,
+// covers it, or when the shortfall at its full mark price is covered by the account's buying
+// power: the collateral value of its other currencies, each net of the open orders' commitments.
+// Converting through mark prices means the order's currency needs a last trade price against the
+// reporting currency to be funded from others; other currencies without one add nothing, and one
+// that is short rejects the order. Buys that cannot be valued only need some buying power left.
+type CollateralCreditChecker struct {
+	service *MatchingService
+	repo    repository.Repository
+}
+
+// NewCollateralCreditChecker creates a credit check against the collateral value of account balances
+func NewCollateralCreditChecker(s *MatchingService, repo repository.Repository) *CollateralCreditChecker {
+	return &CollateralCreditChecker{service: s, repo: repo}
+}
+
+// CheckCredit implements CreditChecker
+func (c *CollateralCreditChecker) CheckCredit(ctx context.Context, req *CreditRequest) error {
+	balances, err := c.repo.GetBalances(ctx, req.Order.OwnerID)
+	if err != nil {
+		return err
+	}
+	net := make(map[string]float64)
+	for _, balance := range balances {
+		net[balance.Currency] = balance.Settled + balance.Pending
+	}
+	for currency, committed := range req.Exposure.ByCurrency {
+		net[currency] -= committed
+	}
+
+	// A shortfall also covers any deficit the open orders already leave in the currency
+	own := net[req.Currency]
+	shortfall := req.Required - own
+	if shortfall <= 0 && own > 0 {
+		return nil
+	}
+	rate, ok := c.service.ConversionRate(req.Currency)
+	if !ok {
+		return models.ErrInsufficientCredit
+	}
+	power := 0.0
+	for currency, quantity := range net {
+		if currency == req.Currency {
+			continue
+		}
+		value, ok := c.service.CollateralValue(currency, quantity)
+		if !ok && quantity < 0 {
+			return models.ErrInsufficientCredit
+		}
+		power += value
+	}
+	if power <= 0 || shortfall*rate > power {
+		return models.ErrInsufficientCredit
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CreditRequest describes an order awaiting its pre-trade credit check. The order has not been
+// accepted yet, so it has no ID unless it is an amendment of a resting order.
+type CreditRequest struct {
+	Order *models.Order
+
+	// What the order consumes if it fills completely: quote currency notional for buys, base
+	// quantity for sells. Required is 0 for a buy whose price cannot be estimated.
+	Currency string
+	Required float64
+
+	Exposure CreditExposure
+}
+
+// CreditExposure is what the account's other open orders already commit
+type CreditExposure struct {
+	OpenOrders int
+	Committed  float64            // amount of the request's Currency they consume if they all fill
+	ByCurrency map[string]float64 // amount of every currency they consume if they all fill
+}
+
+// CreditChecker approves or rejects orders before they are matched, so deployments can plug in
+// their own credit systems, e.g. through a client of an external service. CheckCredit returns
+// models.ErrInsufficientCredit to reject an order; any other error, including ctx expiring,
+// rejects it as models.ErrCreditUnavailable.
+type CreditChecker interface {
+	CheckCredit(ctx context.Context, req *CreditRequest) error
+}
+
+// SetCreditChecker makes every new order and amendment of an account pass checker before it is
+// matched. It must be called before the service handles orders.
+func (s *MatchingService) SetCreditChecker(checker CreditChecker) {
+	s.credit = checker
+}
+
+// checkCredit runs the credit check for an order of a configured symbol. Anonymous orders and
+// symbols without currencies move no balances and are not checked. The check runs before the
+// order reaches its engine, so a slow credit system never holds up matching.
+func (s *MatchingService) checkCredit(ctx context.Context, order *models.Order) error {
+	if s.credit == nil || order.OwnerID == "" {
+		return nil
+	}
+	if _, ok := s.symbolConfig(ctx, order.Symbol); !ok {
+		return nil
+	}
+	req := &CreditRequest{Order: order, Exposure: CreditExposure{ByCurrency: make(map[string]float64)}}
+	req.Currency, req.Required = s.commitment(ctx, order)
+
+	open, err := s.repo.GetOpenOrders(ctx, order.OwnerID)
+	if err != nil {
+		s.logger.Error("Failed to get open orders for credit check", zap.Error(err))
+		return repository.Classify(err)
+	}
+	for _, o := range open {
+		if o.OrderID == order.OrderID {
+			continue
+		}
+		req.Exposure.OpenOrders++
+		currency, amount := s.commitment(ctx, o)
+		if currency == req.Currency {
+			req.Exposure.Committed += amount
+		}
+		if currency != "" {
+			req.Exposure.ByCurrency[currency] += amount
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Risk.CreditTimeout)
+	defer cancel()
+	err = s.credit.CheckCredit(ctx, req)
+	if err != nil {
+		// The order never reaches its engine, so the rejection is counted here
+		s.engine(order.Symbol).flow.add(FlowReject, 1, time.Now())
+	}
+	switch err {
+	case nil:
+		return nil
+	case models.ErrInsufficientCredit:
+		s.logger.Warn("Order rejected by credit check", zap.String("owner_id", order.OwnerID),
+			zap.String("currency", req.Currency), zap.Float64("required", req.Required))
+		metrics.OrdersRejected.WithLabelValues(order.Symbol, string(models.ReasonInsufficientCredit)).Inc()
+		return err
+	default:
+		s.logger.Error("Credit check failed", zap.String("owner_id", order.OwnerID), zap.Error(err))
+		metrics.OrdersRejected.WithLabelValues(order.Symbol, string(models.ReasonCreditUnavailable)).Inc()
+		return models.ErrCreditUnavailable
+	}
+}
+
+// commitment returns the currency and amount an order of a configured symbol consumes if its
+// remainder fills. Buys without a limit price are valued at their trigger price or, failing
+// that, the last trade price, and buys sized by their quote quantity at that amount.
+func (s *MatchingService) commitment(ctx context.Context, order *models.Order) (string, float64) {
+	sc, ok := s.symbolConfig(ctx, order.Symbol)
+	if !ok {
+		return "", 0
+	}
+	if order.Side == models.SideSell {
+		return sc.BaseCurrency, order.RemainingQuantity.Float64()
+	}
+	if order.QuoteQuantity.Valid {
+		return sc.QuoteCurrency, order.QuoteQuantity.Decimal.Float64()
+	}
+	price, ok := order.Price.Decimal, order.Price.Valid
+	if !ok && order.TriggerPrice.Valid {
+		price, ok = order.TriggerPrice.Decimal, true
+	}
+	if !ok {
+		price, ok = s.LastPrice(order.Symbol)
+	}
+	if !ok {
+		return sc.QuoteCurrency, 0
+	}
+	return sc.QuoteCurrency, models.Notional(price, order.RemainingQuantity)
+}
+
+// BalanceCreditChecker is the built-in credit check: an order passes when the account's balance
+// of the currency it consumes, settled plus pending, covers it on top of what the account's
+// other open orders commit. Buys that cannot be valued only need some balance left uncommitted.
+type BalanceCreditChecker struct {
+	repo repository.Repository
+}
+
+// NewBalanceCreditChecker creates a credit check against account balances
+func NewBalanceCreditChecker(repo repository.Repository) *BalanceCreditChecker {
+	return &BalanceCreditChecker{repo: repo}
+}
+
+// CheckCredit implements CreditChecker
+func (b *BalanceCreditChecker) CheckCredit(ctx context.Context, req *CreditRequest) error {
+	balances, err := b.repo.GetBalances(ctx, req.Order.OwnerID)
+	if err != nil {
+		return err
+	}
+	available := 0.0
+	for _, balance := range balances {
+		if balance.Currency == req.Currency {
+			available = balance.Settled + balance.Pending
+		}
+	}
+	headroom := available - req.Exposure.Committed
+	if headroom < req.Required || headroom <= 0 {
+		return models.ErrInsufficientCredit
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// depthLevels aggregates the best price levels on one side of a symbol's book
+func depthLevels(entries []*models.OrderBookEntry, levels int) []models.PriceLevel {
+	best := bestLevels(entries, levels)
+	result := make([]models.PriceLevel, 0, len(best))
+	for _, entry := range best {
+		level := models.PriceLevel{Price: entry.Price, OrderCount: len(entry.Orders)}
+		for _, order := range entry.Orders {
+			level.Quantity += visibleQuantity(order)
+		}
+		result = append(result, level)
+	}
+	return result
+}
+
+// SnapshotDepth captures the top levels of every symbol currently in the in-memory book
+func (s *MatchingService) SnapshotDepth(levels int) []*models.DepthSnapshot {
+	now := time.Now()
+	var snapshots []*models.DepthSnapshot
+	for _, engine := range s.allEngines() {
+		engine.inspect(func(e *symbolEngine) {
+			if snapshot := e.snapshotDepth(levels, now); snapshot != nil {
+				snapshots = append(snapshots, snapshot)
+			}
+		})
+	}
+	return snapshots
+}
+
+// snapshotDepth captures the top levels of the engine's book, nil when both sides are empty
+func (e *symbolEngine) snapshotDepth(levels int, now time.Time) *models.DepthSnapshot {
+	bids, hasBids := e.orderBook.Bids[e.symbol]
+	asks, hasAsks := e.orderBook.Asks[e.symbol]
+	if !hasBids && !hasAsks {
+		return nil
+	}
+	return &models.DepthSnapshot{
+		Symbol:     e.symbol,
+		Bids:       depthLevels(bids, levels),
+		Asks:       depthLevels(asks, levels),
+		CapturedAt: now,
+	}
+}
+
+// BookDepth returns the price levels and visible quantity on each side of every symbol currently
+// in the in-memory book
+func (s *MatchingService) BookDepth() []metrics.BookDepth {
+	var depth []metrics.BookDepth
+	for _, engine := range s.allEngines() {
+		engine.inspect(func(e *symbolEngine) {
+			for _, side := range []models.OrderSide{models.SideBuy, models.SideSell} {
+				entries := e.bookSide(side == models.SideBuy)[e.symbol]
+				d := metrics.BookDepth{Symbol: e.symbol, Side: string(side), Levels: len(entries)}
+				for _, entry := range entries {
+					for _, order := range entry.Orders {
+						d.Quantity += visibleQuantity(order).Float64()
+					}
+				}
+				depth = append(depth, d)
+			}
+		})
+	}
+	return depth
+}
+
+// RunDepthSnapshots persists top-N depth snapshots for every symbol on each interval until ctx is done
+func (s *MatchingService) RunDepthSnapshots(ctx context.Context, interval time.Duration, levels int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, snapshot := range s.SnapshotDepth(levels) {
+				if err := s.repo.SaveDepthSnapshot(ctx, snapshot); err != nil {
+					s.logger.Error("Failed to save depth snapshot", zap.String("symbol", snapshot.Symbol), zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// GetDepthSnapshots retrieves persisted depth snapshots for a symbol within a time range
+func (s *MatchingService) GetDepthSnapshots(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.DepthSnapshot, error) {
+	snapshots, err := s.repo.GetDepthSnapshots(ctx, symbol, from, to, limit)
+	if err != nil {
+		s.logger.Error("Failed to get depth snapshots", zap.Error(err))
+		return nil, err
+	}
+	return snapshots, nil
+}
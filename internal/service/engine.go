@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/config"
+	"orderSystem/internal/ids"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/writebehind"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// engineQueueSize is the number of commands that can wait for a busy symbol engine before
+// senders block
+const engineQueueSize = 256
+
+// core holds the dependencies and cross-symbol state shared by the service and every symbol engine
+type core struct {
+	repo   repository.Repository
+	cfg    *config.Config
+	logger *zap.Logger
+
+	// Fee schedules in creation order, guarded by feeMutex
+	feeSchedules []*models.FeeSchedule
+	feeMutex     sync.RWMutex
+
+	// Last time priority sequence assigned to any order
+	sequence atomic.Uint64
+
+	// Generates order, order group, last look and trade IDs
+	idgen *ids.Generator
+
+	// Receive the market events of every engine, none when nothing subscribes
+	events []MarketEventSink
+
+	// Pre-trade credit check of account orders, nil when disabled
+	credit CreditChecker
+
+	// Receive the orders accepted without waiting that the engine then rejected
+	rejections []RejectionSink
+
+	// Symbol registry by symbol, nil until loaded, guarded by symbolsMutex
+	listings     map[string]*models.Symbol
+	symbolsMutex sync.RWMutex
+
+	// Set while maintenance mode refuses order entry and cancels
+	maintenance atomic.Bool
+
+	// Current sandbox mode settings, guarded by sandboxMutex
+	sandbox      config.SandboxConfig
+	sandboxMutex sync.RWMutex
+
+	// Offer liquidity providers their last looks, which await an answer by ID in pendingLooks,
+	// guarded by lookMutex
+	lastLookSinks []LastLookSink
+	pendingLooks  map[uint64]*pendingLook
+	lookMutex     sync.Mutex
+
+	// Queue the engines' transactions are written behind through, nil when they commit to the
+	// database; with it, placements under client order IDs take clientOrderLocks
+	writeBehind      *writebehind.Queue
+	clientOrderLocks [clientOrderStripes]sync.Mutex
+
+	// Symbol engines by symbol, guarded by enginesMutex
+	engines      map[string]*symbolEngine
+	enginesMutex sync.RWMutex
+}
+
+// symbolEngine owns the in-memory state of a single symbol: its book, auction, volume and
+// last price, pending stops and order groups. A single goroutine runs the commands sent to the
+// engine one at a time and is the only one to touch that state, so each symbol matches
+// independently of the others without any lock. Engine methods must only be called from
+// commands running on the engine.
+type symbolEngine struct {
+	*core
+	symbol   string
+	commands chan func()
+
+	// State kept in the per-symbol shape the book helpers use; the maps only ever hold this
+	// engine's symbol
+	orderBook       *OrderBook
+	auctions        map[string]*auctionState
+	imbalancedSince map[string]time.Time
+	tradedVolume    map[string]models.Decimal
+	lastPrice       map[string]models.Decimal
+	lastTradeAt     map[string]time.Time
+
+	// Start of the last scheduled call auction the engine entered
+	callAuctionStart time.Time
+
+	// Untriggered stop orders by trigger price
+	stops stopLadder
+
+	// Accounts whose holds the open transaction may have moved, posted when it commits
+	holdOwners map[string]bool
+
+	// Journal of book changes made by the open matching transaction, nil outside one
+	undo *bookUndo
+
+	// Active order groups by group ID and by the IDs of their live orders
+	groups    map[uint64]*orderGroup
+	groupLegs map[uint64]*orderGroup
+
+	// Whether the symbol's open orders have been loaded into the book
+	loaded bool
+
+	// Market events of the running command: the prior state of each level it changed and the
+	// trades it printed, along with the sequence of the last book and trade event published
+	touched       map[levelKey]models.PriceLevel
+	printed       []*models.Trade
+	bookSequence  uint64
+	tradeSequence uint64
+
+	// Order flow counts per minute
+	flow *flowCounter
+
+	// Prices must be multiples of tickSize, 0 accepts any price
+	tickSize models.Decimal
+
+	// Trade prices within the circuit breaker window, oldest first
+	recentPrices []pricePoint
+
+	// Resting orders whose providers rejected the executing order's match on last look, nil
+	// outside executeOrder
+	declined map[uint64]bool
+
+	// Trades printed by the running command, and the sequence of the symbol's last command
+	// appended to the engine journal
+	journalTrades   []*models.Trade
+	journalSequence uint64
+
+	// The journal entry of the running order command, built by journalBuild when the command
+	// first commits, the number of its parts appended so far, and the last look declines of the
+	// order being executed, which go in the part its transaction appends
+	journalBuild    func() *models.JournalEntry
+	journalEntry    *models.JournalEntry
+	journalParts    int
+	journalDeclines map[uint64][]uint64
+
+	// While the journal is replayed, the last look declines it recorded by executing order,
+	// taken in place of offering last looks
+	replayedDeclines map[uint64][]uint64
+
+	// Context of the running command, which bounds its database calls: the request's for a
+	// command sent on a client's behalf, background for the engine's own work
+	ctx context.Context
+
+	// Commands queued or running, which keep the engine from being evicted. Commands are queued
+	// under sendMutex, so once an eviction retires the engine none reach it and they go to the
+	// symbol's next engine instead.
+	pending   atomic.Int64
+	sendMutex sync.Mutex
+	retired   bool
+
+	// When the engine last ran a command sent with do, which idleness is measured from
+	usedAt time.Time
+}
+
+// newSymbolEngine creates an engine for a symbol and starts its goroutine
+func newSymbolEngine(c *core, symbol string) *symbolEngine {
+	e := &symbolEngine{
+		core:     c,
+		symbol:   symbol,
+		commands: make(chan func(), engineQueueSize),
+
+		orderBook:       NewOrderBook(),
+		auctions:        make(map[string]*auctionState),
+		imbalancedSince: make(map[string]time.Time),
+		tradedVolume:    make(map[string]models.Decimal),
+		lastPrice:       make(map[string]models.Decimal),
+		lastTradeAt:     make(map[string]time.Time),
+		groups:          make(map[uint64]*orderGroup),
+		groupLegs:       make(map[uint64]*orderGroup),
+		touched:         make(map[levelKey]models.PriceLevel),
+		holdOwners:      make(map[string]bool),
+		flow:            newFlowCounter(c.cfg.Engine.FlowRetention),
+		tickSize:        models.NewDecimal(c.cfg.Symbols[symbol].TickSize),
+		ctx:             context.Background(),
+		usedAt:          time.Now(),
+	}
+	go e.run()
+	return e
+}
+
+// run executes the engine's commands in the order they were sent
+func (e *symbolEngine) run() {
+	for command := range e.commands {
+		command()
+	}
+}
+
+// commandResult is what a command hands back to the goroutine that sent it
+type commandResult struct {
+	err       error
+	recovered any
+}
+
+// enqueue queues a command on the engine, reporting false when the engine was retired
+func (e *symbolEngine) enqueue(command func()) bool {
+	e.sendMutex.Lock()
+	defer e.sendMutex.Unlock()
+	if e.retired {
+		return false
+	}
+	e.pending.Add(1)
+	e.commands <- func() {
+		defer e.pending.Add(-1)
+		command()
+	}
+	return true
+}
+
+// send runs fn on the engine's goroutine and waits for it to finish, then publishes the market
+// events fn produced. A panic in fn is re-raised in the sender, so it is handled where the
+// request came from and the engine keeps running. A retired engine passes fn on to the
+// symbol's current one.
+func (e *symbolEngine) send(fn func(e *symbolEngine) error) error {
+	done := make(chan commandResult, 1)
+	queued := e.enqueue(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				e.endUndo(false)
+				e.publishMarketEvents()
+				done <- commandResult{recovered: r}
+			}
+		}()
+		e.journalTrades, e.journalBuild = nil, nil
+		err := fn(e)
+		e.publishMarketEvents()
+		done <- commandResult{err: err}
+	})
+	if !queued {
+		return e.engine(e.symbol).send(fn)
+	}
+	result := <-done
+	if result.recovered != nil {
+		panic(result.recovered)
+	}
+	return result.err
+}
+
+// post queues fn on the engine and returns without waiting for it. A panic in fn undoes the
+// command's changes and is logged, as there is no caller to re-raise it in.
+func (e *symbolEngine) post(fn func(e *symbolEngine)) {
+	queued := e.enqueue(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				e.endUndo(false)
+				e.publishMarketEvents()
+				e.logger.Error("Queued command panicked", zap.String("symbol", e.symbol), zap.Any("panic", r))
+			}
+		}()
+		e.journalTrades, e.journalBuild, e.ctx = nil, nil, context.Background()
+		fn(e)
+		e.publishMarketEvents()
+	})
+	if !queued {
+		e.engine(e.symbol).post(fn)
+	}
+}
+
+// do runs fn on the engine once the symbol's open orders are loaded into the book
+func (e *symbolEngine) do(fn func(e *symbolEngine) error) error {
+	return e.doContext(context.Background(), fn)
+}
+
+// doContext runs fn on the engine like do, unless ctx is done by the time the command comes up,
+// as when its request timed out waiting behind the engine's other commands. The command's
+// database calls run under ctx, so one that ends while they run fails them and the command's
+// changes are rolled back and undone together, and the book and the database never see half of
+// it.
+func (e *symbolEngine) doContext(ctx context.Context, fn func(e *symbolEngine) error) error {
+	return e.send(func(e *symbolEngine) error {
+		if ctx.Err() != nil {
+			e.logger.Warn("Request timed out waiting for the engine", zap.String("symbol", e.symbol))
+			return models.ErrRequestTimeout
+		}
+		e.ctx, e.usedAt = ctx, time.Now()
+		if err := e.ensureLoaded(); err != nil {
+			return err
+		}
+		return fn(e)
+	})
+}
+
+// inspect runs fn on the engine without loading the symbol first, for work that only concerns
+// state already in memory
+func (e *symbolEngine) inspect(fn func(e *symbolEngine)) {
+	e.send(func(e *symbolEngine) error {
+		e.ctx = context.Background()
+		fn(e)
+		return nil
+	})
+}
+
+// call runs fn on a symbol's engine once the symbol is loaded and returns its result
+func call[T any](s *MatchingService, symbol string, fn func(e *symbolEngine) (T, error)) (T, error) {
+	return callContext(context.Background(), s, symbol, fn)
+}
+
+// callContext runs fn on a symbol's engine like call, unless ctx is done before fn starts
+func callContext[T any](ctx context.Context, s *MatchingService, symbol string, fn func(e *symbolEngine) (T, error)) (T, error) {
+	var result T
+	err := s.engine(symbol).doContext(ctx, func(e *symbolEngine) error {
+		var err error
+		result, err = fn(e)
+		return err
+	})
+	return result, err
+}
+
+// engine returns a symbol's engine, starting one the first time the symbol is seen
+func (c *core) engine(symbol string) *symbolEngine {
+	c.enginesMutex.RLock()
+	e, ok := c.engines[symbol]
+	c.enginesMutex.RUnlock()
+	if ok {
+		return e
+	}
+
+	c.enginesMutex.Lock()
+	defer c.enginesMutex.Unlock()
+	if e, ok = c.engines[symbol]; !ok {
+		e = newSymbolEngine(c, symbol)
+		c.engines[symbol] = e
+	}
+	return e
+}
+
+// existingEngine returns a symbol's engine, nil when the symbol has not been seen
+func (s *MatchingService) existingEngine(symbol string) *symbolEngine {
+	s.enginesMutex.RLock()
+	defer s.enginesMutex.RUnlock()
+	return s.engines[symbol]
+}
+
+// allEngines returns every running engine in symbol order
+func (s *MatchingService) allEngines() []*symbolEngine {
+	s.enginesMutex.RLock()
+	engines := make([]*symbolEngine, 0, len(s.engines))
+	for _, e := range s.engines {
+		engines = append(engines, e)
+	}
+	s.enginesMutex.RUnlock()
+
+	sort.Slice(engines, func(i, j int) bool { return engines[i].symbol < engines[j].symbol })
+	return engines
+}
+
+// orderEngine returns the engine of a stored order's symbol
+func (s *MatchingService) orderEngine(ctx context.Context, orderID uint64) (*symbolEngine, error) {
+	order, err := s.commandOrder(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	return s.engine(order.Symbol), nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"orderSystem/internal/models"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// benchClients is how many clients per CPU send orders at once, so orders contend for engines
+// even on a single CPU
+const benchClients = 8
+
+// benchSymbols returns n symbols to spread orders over
+func benchSymbols(n int) []string {
+	symbols := make([]string, n)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%02dUSD", i)
+	}
+	return symbols
+}
+
+// benchEngines runs a benchmark for each storage mode with orders on one symbol, where every
+// order waits on the one engine, and spread over several, each with an engine of its own
+func benchEngines(b *testing.B, run func(b *testing.B, s *MatchingService, symbols []string)) {
+	for _, writeBehind := range []bool{false, true} {
+		for _, n := range []int{1, 8} {
+			b.Run(fmt.Sprintf("write_behind=%t/symbols=%d", writeBehind, n), func(b *testing.B) {
+				symbols := benchSymbols(n)
+				s, _ := newTestService(b, testSetup{writeBehind: writeBehind}, symbols...)
+				s.WarmLoad(symbols)
+				b.SetParallelism(benchClients)
+				b.ResetTimer()
+				run(b, s, symbols)
+			})
+		}
+	}
+}
+
+// BenchmarkPlaceOrder places resting limit orders, each committed with its order event
+func BenchmarkPlaceOrder(b *testing.B) {
+	benchEngines(b, func(b *testing.B, s *MatchingService, symbols []string) {
+		var placed atomic.Uint64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				n := placed.Add(1)
+				order := limitOrder(symbols[n%uint64(len(symbols))], models.SideBuy, float64(100-n%50), 1)
+				if _, err := s.PlaceOrder(context.Background(), order); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkMatchOrder rests a sell and takes it with a buy per iteration, each match committing
+// the trade, both orders' executions and their events
+func BenchmarkMatchOrder(b *testing.B) {
+	benchEngines(b, func(b *testing.B, s *MatchingService, symbols []string) {
+		var matched atomic.Uint64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				symbol := symbols[matched.Add(1)%uint64(len(symbols))]
+				for _, side := range []models.OrderSide{models.SideSell, models.SideBuy} {
+					if _, err := s.PlaceOrder(context.Background(), limitOrder(symbol, side, 100, 1)); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}
+		})
+	})
+}
+
+func TestUnknownSymbolStartsNoEngine(t *testing.T) {
+	s, _ := newTestService(t, testSetup{}, "BTCUSD")
+	if _, _, err := s.GetDepth("NOPEUSD", 10); err != models.ErrSymbolNotFound {
+		t.Errorf("depth of an unknown symbol: %v, want %v", err, models.ErrSymbolNotFound)
+	}
+	if _, err := s.GetTicker("NOPEUSD"); err != models.ErrSymbolNotFound {
+		t.Errorf("ticker of an unknown symbol: %v, want %v", err, models.ErrSymbolNotFound)
+	}
+	if s.existingEngine("NOPEUSD") != nil {
+		t.Error("reading an unknown symbol started an engine")
+	}
+}
+
+// TestIdleEngineEvicted checks an engine only read from is stopped once idle, that a command
+// sent to it afterwards runs on the symbol's next engine, and that one holding orders is kept
+func TestIdleEngineEvicted(t *testing.T) {
+	s, _ := newTestService(t, testSetup{}, "BTCUSD", "ETHUSD")
+	ttl := time.Minute
+	for _, symbol := range []string{"BTCUSD", "ETHUSD"} {
+		if _, _, err := s.GetDepth(symbol, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	placeAll(t, s, limitOrder("ETHUSD", models.SideSell, 100, 1))
+	old := s.existingEngine("BTCUSD")
+
+	if n := s.EvictIdleEngines(time.Now(), ttl); n != 0 {
+		t.Errorf("evicted %d engines used within the TTL, want 0", n)
+	}
+	if n := s.EvictIdleEngines(time.Now().Add(2*ttl), ttl); n != 1 {
+		t.Errorf("evicted %d idle engines, want 1", n)
+	}
+	if s.existingEngine("BTCUSD") != nil {
+		t.Error("idle empty engine kept")
+	}
+	if s.existingEngine("ETHUSD") == nil {
+		t.Error("engine holding a resting order evicted")
+	}
+
+	var ran *symbolEngine
+	if err := old.do(func(e *symbolEngine) error { ran = e; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if ran == old || ran != s.existingEngine("BTCUSD") {
+		t.Error("command sent to an evicted engine did not run on the symbol's next engine")
+	}
+}
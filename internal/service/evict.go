@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunEngineEviction evicts the engines idle for ttl, checking every ttl until ctx is done
+func (s *MatchingService) RunEngineEviction(ctx context.Context, ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.EvictIdleEngines(now, ttl)
+		}
+	}
+}
+
+// EvictIdleEngines stops the engines that ran no command for ttl and hold nothing a new engine
+// would not load again, and returns how many stopped. A symbol's next command starts a fresh
+// engine. Engines that ever published market data are kept, so stream sequences never restart.
+func (s *MatchingService) EvictIdleEngines(now time.Time, ttl time.Duration) int {
+	s.enginesMutex.Lock()
+	defer s.enginesMutex.Unlock()
+
+	evicted := 0
+	for symbol, e := range s.engines {
+		if e.retire(now, now.Add(-ttl)) {
+			delete(s.engines, symbol)
+			evicted++
+			s.logger.Info("Evicted idle engine", zap.String("symbol", symbol))
+		}
+	}
+	return evicted
+}
+
+// retire stops the engine when it has nothing queued or running and is evictable, after which
+// commands sent to it go to the symbol's next engine. An engine busy queuing a command is passed
+// over rather than waited for.
+func (e *symbolEngine) retire(now, idleSince time.Time) bool {
+	if !e.sendMutex.TryLock() {
+		return false
+	}
+	defer e.sendMutex.Unlock()
+	// With nothing pending, the engine's goroutine has finished with its state
+	if e.pending.Load() != 0 || !e.evictable(now, idleSince) {
+		return false
+	}
+	e.retired = true
+	close(e.commands)
+	return true
+}
+
+// evictable reports whether the engine has been idle since idleSince with an empty book, no
+// stops, groups or auction, no order flow within its retention and no market data published.
+// Symbols with scheduled call auctions keep their engines so the auctions start on time.
+func (e *symbolEngine) evictable(now, idleSince time.Time) bool {
+	return e.usedAt.Before(idleSince) &&
+		len(e.orderBook.Bids[e.symbol]) == 0 && len(e.orderBook.Asks[e.symbol]) == 0 &&
+		len(e.stops.buys) == 0 && len(e.stops.sells) == 0 &&
+		len(e.groups) == 0 && len(e.auctions) == 0 &&
+		e.bookSequence == 0 && e.tradeSequence == 0 &&
+		!e.flow.counted(now) &&
+		len(e.cfg.Symbols[e.symbol].CallAuctions) == 0
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// expiryBatch is the most expired orders read at once by an expiry sweep
+const expiryBatch = 500
+
+// RunExpirySweep expires good-till-date orders past their expiry time on each interval until ctx is done
+func (s *MatchingService) RunExpirySweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ExpireOrders(ctx, time.Now())
+		}
+	}
+}
+
+// ExpireOrders expires every resting or untriggered stop order whose expiry time is not after
+// now and returns how many expired. Orders are expired on their symbol's engine, symbol by
+// symbol, each expiry journaled like a cancel.
+func (s *MatchingService) ExpireOrders(ctx context.Context, now time.Time) int {
+	expired := 0
+	for {
+		orders, err := s.repo.GetExpiredOrders(ctx, now, expiryBatch)
+		if err != nil {
+			s.logger.Error("Failed to get expired orders", zap.Error(err))
+			return expired
+		}
+
+		bySymbol := make(map[string][]uint64)
+		for _, order := range orders {
+			bySymbol[order.Symbol] = append(bySymbol[order.Symbol], order.OrderID)
+		}
+		symbols := make([]string, 0, len(bySymbol))
+		for symbol := range bySymbol {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+
+		progressed := false
+		for _, symbol := range symbols {
+			err := s.engine(symbol).do(func(e *symbolEngine) error {
+				for _, orderID := range bySymbol[symbol] {
+					e.journal(&models.JournalEntry{Kind: models.JournalExpire, OrderID: orderID})
+					err := e.expireOrder(orderID)
+					if err == models.ErrOrderNotOpen {
+						// Filled or canceled since it was read
+						continue
+					}
+					if err != nil {
+						return err
+					}
+					expired++
+					progressed = true
+				}
+				return nil
+			})
+			if err != nil {
+				s.logger.Error("Failed to expire orders", zap.String("symbol", symbol), zap.Error(err))
+			}
+		}
+		if len(orders) < expiryBatch || !progressed {
+			return expired
+		}
+	}
+}
+
+// expireOrder expires an open or pending order of the engine's symbol, removing it from the
+// book or the stops. An order linked in a group cancels its group, as canceling it would.
+func (e *symbolEngine) expireOrder(orderID uint64) error {
+	// Re-read on the engine: the order may have filled or been canceled since it was read
+	order, err := e.storedOrder(orderID)
+	if err != nil {
+		e.logger.Error("Failed to get order", zap.Error(err))
+		return err
+	}
+	if !order.Status.Resting() && order.Status != models.StatusPending {
+		return models.ErrOrderNotOpen
+	}
+	change := models.OrderChange{Actor: models.ActorSystem, Reason: models.ReasonExpireAt}
+	if g, ok := e.groupLegs[orderID]; ok {
+		return e.cancelGroup(g, change)
+	}
+
+	pending := order.Status == models.StatusPending
+	order.Status = models.StatusExpired
+	if err := e.updateOrder(order, change); err != nil {
+		e.logger.Error("Failed to update order status", zap.Error(err))
+		return err
+	}
+
+	if pending {
+		e.removeStop(order)
+	} else {
+		e.removeFromOrderBook(order)
+	}
+	e.logger.Info("Order expired", zap.Uint64("order_id", orderID), zap.String("symbol", e.symbol),
+		zap.Time("expire_at", order.ExpireAt.Time))
+	return nil
+}
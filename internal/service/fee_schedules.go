@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// feeBuckets is the number of buckets accounts are hashed into for fee schedule assignment,
+// one per percentage point of allocation
+const feeBuckets = 100
+
+// feeBucket returns an account's stable fee assignment bucket, in [0, feeBuckets)
+func feeBucket(accountID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(accountID))
+	return int(h.Sum32() % feeBuckets)
+}
+
+// loadFeeSchedules reads the fee schedules into memory
+func (c *core) loadFeeSchedules(ctx context.Context) {
+	schedules, err := c.repo.GetFeeSchedules(ctx)
+	if err != nil {
+		c.logger.Error("Failed to load fee schedules", zap.Error(err))
+		return
+	}
+	c.feeSchedules = schedules
+}
+
+// assignedSchedule returns the active fee schedule an account is assigned to, nil for accounts
+// on the configured default rates. Active schedules claim consecutive ranges of buckets in
+// schedule ID order, each as wide as its allocation. Callers must hold the fee schedule lock.
+func (c *core) assignedSchedule(accountID string) *models.FeeSchedule {
+	bucket := feeBucket(accountID)
+	start := 0
+	for _, schedule := range c.feeSchedules {
+		if !schedule.Active {
+			continue
+		}
+		if bucket < start+schedule.Allocation {
+			return schedule
+		}
+		start += schedule.Allocation
+	}
+	return nil
+}
+
+// validateFeeSchedule checks a schedule's rates and allocation against the limits applied to
+// the configured rates, and that the active schedules would not cover more than every account.
+// Callers must hold the fee schedule lock.
+func (c *core) validateFeeSchedule(schedule *models.FeeSchedule) error {
+	if schedule.TakerRate < 0 || schedule.TakerRate >= 1 || schedule.MakerRate <= -1 || schedule.MakerRate >= 1 ||
+		schedule.MakerRate+schedule.TakerRate < 0 || schedule.Allocation < 0 || schedule.Allocation > feeBuckets {
+		return models.ErrInvalidSchedule
+	}
+	if !schedule.Active {
+		return nil
+	}
+	total := schedule.Allocation
+	for _, other := range c.feeSchedules {
+		if other.Active && other.ScheduleID != schedule.ScheduleID {
+			total += other.Allocation
+		}
+	}
+	if total > feeBuckets {
+		return models.ErrOverAllocated
+	}
+	return nil
+}
+
+// CreateFeeSchedule adds a fee schedule; when active, its allocation takes effect for the next execution
+func (s *MatchingService) CreateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	s.feeMutex.Lock()
+	defer s.feeMutex.Unlock()
+
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" || len(schedule.Name) > 64 {
+		s.logger.Warn("Invalid fee schedule name", zap.String("name", schedule.Name))
+		return models.ErrInvalidSchedule
+	}
+	if err := s.validateFeeSchedule(schedule); err != nil {
+		s.logger.Warn("Invalid fee schedule", zap.Any("schedule", schedule), zap.Error(err))
+		return err
+	}
+	schedule.CreatedAt = time.Now()
+	if err := s.repo.SaveFeeSchedule(ctx, schedule); err != nil {
+		s.logger.Error("Failed to save fee schedule", zap.Error(err))
+		return repository.Classify(err)
+	}
+	s.feeSchedules = append(s.feeSchedules, schedule)
+
+	s.logger.Info("Fee schedule created", zap.Uint64("schedule_id", schedule.ScheduleID),
+		zap.String("name", schedule.Name), zap.Int("allocation", schedule.Allocation))
+	return nil
+}
+
+// UpdateFeeSchedule replaces a fee schedule's rates, allocation and active flag, returning the
+// updated schedule. Fees already charged keep the rate they were charged at.
+func (s *MatchingService) UpdateFeeSchedule(ctx context.Context, update *models.FeeSchedule) (*models.FeeSchedule, error) {
+	s.feeMutex.Lock()
+	defer s.feeMutex.Unlock()
+
+	var current *models.FeeSchedule
+	for _, schedule := range s.feeSchedules {
+		if schedule.ScheduleID == update.ScheduleID {
+			current = schedule
+		}
+	}
+	if current == nil {
+		return nil, models.ErrScheduleNotFound
+	}
+	updated := *current
+	updated.MakerRate = update.MakerRate
+	updated.TakerRate = update.TakerRate
+	updated.Allocation = update.Allocation
+	updated.Active = update.Active
+	if err := s.validateFeeSchedule(&updated); err != nil {
+		s.logger.Warn("Invalid fee schedule", zap.Any("schedule", &updated), zap.Error(err))
+		return nil, err
+	}
+	if err := s.repo.UpdateFeeSchedule(ctx, &updated); err != nil {
+		s.logger.Error("Failed to update fee schedule", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	*current = updated
+
+	s.logger.Info("Fee schedule updated", zap.Uint64("schedule_id", updated.ScheduleID),
+		zap.Int("allocation", updated.Allocation), zap.Bool("active", updated.Active))
+	return &updated, nil
+}
+
+// FeeSchedules returns every fee schedule in creation order
+func (s *MatchingService) FeeSchedules() []*models.FeeSchedule {
+	s.feeMutex.RLock()
+	defer s.feeMutex.RUnlock()
+
+	schedules := make([]*models.FeeSchedule, 0, len(s.feeSchedules))
+	for _, schedule := range s.feeSchedules {
+		copied := *schedule
+		schedules = append(schedules, &copied)
+	}
+	return schedules
+}
+
+// AccountFees returns the maker and taker rates an account is currently charged and the fee
+// schedule they come from, nil when the account is on the configured default rates
+func (s *MatchingService) AccountFees(accountID string) (*models.FeeSchedule, float64, float64) {
+	s.feeMutex.RLock()
+	defer s.feeMutex.RUnlock()
+
+	schedule := s.assignedSchedule(accountID)
+	if schedule == nil {
+		return nil, s.cfg.Fees.MakerRate, s.cfg.Fees.TakerRate
+	}
+	copied := *schedule
+	return &copied, schedule.MakerRate, schedule.TakerRate
+}
+
+// scheduleRef returns the nullable schedule ID recorded with a fee charged under schedule
+func scheduleRef(schedule *models.FeeSchedule) sql.NullInt64 {
+	if schedule == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(schedule.ScheduleID), Valid: true}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+)
+
+// feeRate returns the rate an account pays for an execution's liquidity and the fee schedule
+// it comes from, nil for the configured default rates; auction and block fills pay the taker rate.
+func (c *core) feeRate(accountID string, liquidity models.Liquidity) (float64, *models.FeeSchedule) {
+	c.feeMutex.RLock()
+	var schedule *models.FeeSchedule
+	if assigned := c.assignedSchedule(accountID); assigned != nil {
+		copied := *assigned
+		schedule = &copied
+	}
+	c.feeMutex.RUnlock()
+
+	maker, taker := c.cfg.Fees.MakerRate, c.cfg.Fees.TakerRate
+	if schedule != nil {
+		maker, taker = schedule.MakerRate, schedule.TakerRate
+	}
+	if liquidity == models.LiquidityMaker {
+		return maker, schedule
+	}
+	return taker, schedule
+}
+
+// chargeFeeTx records an execution's fee in the fee ledger within the matching transaction and
+// posts it to the ledger, debiting the account's settled balance in favour of the exchange's fees
+// (a rebate credits it). Fees are charged in the symbol's quote currency, so anonymous orders,
+// symbols without currencies and zero default rates are skipped; a zero rate from a fee schedule
+// is still recorded so the experiment's executions can be attributed to it, but posts nothing.
+func (c *core) chargeFeeTx(ctx context.Context, tx repository.Tx, trade *models.Trade, execution *models.Execution) error {
+	accountID := trade.BuyOwnerID
+	if execution.Side == models.SideSell {
+		accountID = trade.SellOwnerID
+	}
+	symbol, ok := c.symbolConfig(ctx, execution.Symbol)
+	rate, schedule := c.feeRate(accountID, execution.Liquidity)
+	if accountID == "" || !ok || (rate == 0 && schedule == nil) {
+		return nil
+	}
+
+	notional := models.Notional(execution.Price, execution.Quantity)
+	entry := &models.FeeEntry{
+		ExecID:     execution.ExecID,
+		TradeID:    execution.TradeID,
+		AccountID:  accountID,
+		Symbol:     execution.Symbol,
+		Currency:   symbol.QuoteCurrency,
+		Liquidity:  execution.Liquidity,
+		Rate:       rate,
+		Notional:   notional,
+		Amount:     notional * rate,
+		CreatedAt:  execution.CreatedAt,
+		ScheduleID: scheduleRef(schedule),
+	}
+	if err := c.repo.SaveFeeEntryTx(ctx, tx, entry); err != nil {
+		return err
+	}
+	if entry.Amount == 0 {
+		return nil
+	}
+	posting := c.newPosting(models.LedgerFee, execution.TradeID, execution.CreatedAt)
+	posting.Entries = []*models.LedgerEntry{
+		{AccountID: accountID, Currency: entry.Currency, Bucket: models.BucketSettled, Amount: -entry.Amount},
+		{Currency: entry.Currency, Bucket: models.BucketFees, Amount: entry.Amount},
+	}
+	return postTx(ctx, c.repo, tx, posting)
+}
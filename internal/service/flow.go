@@ -0,0 +1,141 @@
+package service
+
+import (
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"sync"
+	"time"
+)
+
+// FlowKind is a kind of order flow counted per symbol
+type FlowKind int
+
+// Order flow kinds
+const (
+	FlowNew    FlowKind = iota // new order requests accepted, an OCO group counting once
+	FlowCancel                 // cancels, partial cancels and order group cancels
+	FlowModify                 // amendments
+	FlowReject                 // requests refused for their parameters, risk, credit or the order's state
+	FlowTrade                  // trades printed, book and block alike
+	flowKinds
+)
+
+// FlowBucket is a symbol's order flow within one time bucket
+type FlowBucket struct {
+	Start     time.Time
+	NewOrders int
+	Cancels   int
+	Modifies  int
+	Rejects   int
+	Trades    int
+}
+
+// flowMinute counts a symbol's order flow within one minute
+type flowMinute struct {
+	minute int64 // Unix minute counted, stale slots are reset when reused
+	counts [flowKinds]int
+}
+
+// flowCounter keeps a symbol's order flow per minute in a ring covering the retention period.
+// Its engine counts requests as it handles them, but credit rejections are counted before an
+// order reaches the engine and operators read it from request goroutines, so it has its own lock.
+type flowCounter struct {
+	mutex   sync.Mutex
+	minutes []flowMinute
+}
+
+// newFlowCounter creates an order flow counter keeping retention worth of minutes
+func newFlowCounter(retention time.Duration) *flowCounter {
+	return &flowCounter{minutes: make([]flowMinute, max(1, int(retention/time.Minute)))}
+}
+
+// add counts n events of a kind at now
+func (f *flowCounter) add(kind FlowKind, n int, now time.Time) {
+	minute := now.Unix() / 60
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	slot := &f.minutes[minute%int64(len(f.minutes))]
+	if slot.minute != minute {
+		*slot = flowMinute{minute: minute}
+	}
+	slot.counts[kind] += n
+}
+
+// counted reports whether anything was counted within the retention period ending at now
+func (f *flowCounter) counted(now time.Time) bool {
+	oldest := now.Unix()/60 - int64(len(f.minutes)) + 1
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, slot := range f.minutes {
+		if slot.minute >= oldest {
+			return true
+		}
+	}
+	return false
+}
+
+// buckets sums the minutes of the last count buckets of the given length ending with the one
+// holding now, oldest first; minutes older than the retention period count as empty
+func (f *flowCounter) buckets(length time.Duration, count int, now time.Time) []FlowBucket {
+	perBucket := int64(length / time.Minute)
+	last := now.Unix() / 60 / perBucket * perBucket
+	first := last - int64(count-1)*perBucket
+	oldest := now.Unix()/60 - int64(len(f.minutes)) + 1
+
+	buckets := make([]FlowBucket, count)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i := range buckets {
+		start := first + int64(i)*perBucket
+		var counts [flowKinds]int
+		for minute := max(start, oldest); minute < start+perBucket; minute++ {
+			slot := f.minutes[minute%int64(len(f.minutes))]
+			if slot.minute != minute {
+				continue
+			}
+			for kind, n := range slot.counts {
+				counts[kind] += n
+			}
+		}
+		buckets[i] = FlowBucket{
+			Start:     time.Unix(start*60, 0).UTC(),
+			NewOrders: counts[FlowNew],
+			Cancels:   counts[FlowCancel],
+			Modifies:  counts[FlowModify],
+			Rejects:   counts[FlowReject],
+			Trades:    counts[FlowTrade],
+		}
+	}
+	return buckets
+}
+
+// countFlow counts a handled order request: as kind when it succeeded, as a rejection when it
+// was refused for the request itself rather than a storage failure
+func (e *symbolEngine) countFlow(kind FlowKind, err error) {
+	switch {
+	case err == nil:
+		e.flow.add(kind, 1, time.Now())
+		if kind == FlowNew {
+			metrics.OrdersPlaced.WithLabelValues(e.symbol).Inc()
+		}
+	case models.RejectReason(err) != models.ReasonInternal || err == models.ErrOrderNotOpen:
+		e.flow.add(FlowReject, 1, time.Now())
+		metrics.OrdersRejected.WithLabelValues(e.symbol, string(models.ErrorCode(err))).Inc()
+	}
+}
+
+// OrderFlow returns a symbol's order flow in the last count buckets of the given length, a
+// whole number of minutes, oldest first
+func (s *MatchingService) OrderFlow(symbol string, length time.Duration, count int) []FlowBucket {
+	e := s.existingEngine(symbol)
+	if e == nil {
+		// Nothing was ever sent for the symbol, so every bucket is empty
+		return newFlowCounter(time.Minute).buckets(length, count, time.Now())
+	}
+	return e.flow.buckets(length, count, time.Now())
+}
+
+// FlowRetention returns how far back order flow is kept
+func (s *MatchingService) FlowRetention() time.Duration {
+	return s.cfg.Engine.FlowRetention
+}
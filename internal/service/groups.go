@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// orderGroup is an active order group with its live in-memory legs
+type orderGroup struct {
+	group *models.OrderGroup
+	legs  []*models.Order
+}
+
+// loadGroups restores active order groups at startup, handing each to its symbol's engine, and
+// relinks their pending stop legs. Legs resting in the book are relinked as their symbols are loaded.
+func (s *MatchingService) loadGroups(ctx context.Context) {
+	groups, err := s.repo.GetActiveOrderGroups(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load order groups", zap.Error(err))
+		return
+	}
+	for _, group := range groups {
+		s.engine(group.Symbol).inspect(func(e *symbolEngine) {
+			e.groups[group.GroupID] = &orderGroup{group: group}
+			for _, order := range e.stops.orders() {
+				if order.GroupID.Valid && uint64(order.GroupID.Int64) == group.GroupID {
+					e.linkGroupLeg(order)
+				}
+			}
+		})
+	}
+}
+
+// linkGroupLeg attaches a restored order to its active group, if any
+func (e *symbolEngine) linkGroupLeg(order *models.Order) {
+	if !order.GroupID.Valid {
+		return
+	}
+	if g, ok := e.groups[uint64(order.GroupID.Int64)]; ok {
+		g.legs = append(g.legs, order)
+		e.groupLegs[order.OrderID] = g
+	}
+}
+
+// PlaceOCO places a limit order and a stop order for the same symbol and side as a
+// one-cancels-other group: as soon as the limit order fills or the stop order triggers, the
+// other is canceled in the same transaction. Both orders and the group are stored atomically
+// before the limit order is matched.
+func (s *MatchingService) PlaceOCO(ctx context.Context, limit, stop *models.Order) (*models.OrderGroup, *PlaceOrderResult, error) {
+	if limit.Symbol == "" {
+		s.logger.Error("Invalid OCO orders", zap.Any("limit", limit), zap.Any("stop", stop))
+		return nil, nil, models.ErrInvalidOrder
+	}
+	for _, order := range []*models.Order{limit, stop} {
+		if err := s.checkCredit(ctx, order); err != nil {
+			s.recordRejection(ctx, limit, err)
+			s.recordRejection(ctx, stop, err)
+			return nil, nil, err
+		}
+	}
+	var group *models.OrderGroup
+	result, err := callContext(ctx, s, limit.Symbol, func(e *symbolEngine) (*PlaceOrderResult, error) {
+		var err error
+		var result *PlaceOrderResult
+		submittedLimit, submittedStop := *limit, *stop
+		e.journalPlacement(func() *models.JournalEntry {
+			submittedLimit.OrderID, submittedLimit.CreatedAt = limit.OrderID, limit.CreatedAt
+			submittedStop.OrderID, submittedStop.CreatedAt = stop.OrderID, stop.CreatedAt
+			return &models.JournalEntry{Kind: models.JournalOCO, Orders: []*models.Order{&submittedLimit, &submittedStop},
+				GroupID: uint64(limit.GroupID.Int64)}
+		})
+		group, result, err = e.placeOCO(limit, stop)
+		e.countFlow(FlowNew, err)
+		return result, err
+	})
+	if err != nil {
+		s.recordRejection(ctx, limit, err)
+		s.recordRejection(ctx, stop, err)
+	}
+	return group, result, err
+}
+
+// placeOCO validates and places an OCO group on the limit order's symbol
+func (e *symbolEngine) placeOCO(limit, stop *models.Order) (*models.OrderGroup, *PlaceOrderResult, error) {
+	if err := e.prepareOrder(limit); err != nil {
+		return nil, nil, err
+	}
+	if err := e.prepareOrder(stop); err != nil {
+		return nil, nil, err
+	}
+	if limit.Type != models.TypeLimit || !isStop(stop) || limit.TimeInForce != models.TIFGTC ||
+		stop.TimeInForce != models.TIFGTC || limit.Symbol != stop.Symbol || limit.Side != stop.Side ||
+		limit.OwnerID != stop.OwnerID {
+		e.logger.Error("Invalid OCO orders", zap.Any("limit", limit), zap.Any("stop", stop))
+		return nil, nil, models.ErrInvalidOrder
+	}
+
+	group := &models.OrderGroup{
+		GroupID:   e.idgen.Next(),
+		Type:      models.GroupOCO,
+		Symbol:    limit.Symbol,
+		Status:    models.GroupActive,
+		OwnerID:   limit.OwnerID,
+		CreatedAt: time.Now(),
+	}
+	limit.GroupID = sql.NullInt64{Int64: int64(group.GroupID), Valid: true}
+	stop.GroupID = limit.GroupID
+	stop.Status = models.StatusPending
+
+	tx, err := e.beginTx(group.Symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("oco"), time.Now())
+
+	if err := e.repo.SaveOrderGroupTx(e.ctx, tx, group); err != nil {
+		e.logger.Error("Failed to save order group", zap.Error(err))
+		return nil, nil, repository.Classify(err)
+	}
+	for _, order := range []*models.Order{limit, stop} {
+		if err := e.reserveTx(tx, order); err != nil {
+			return nil, nil, repository.Classify(err)
+		}
+		if err := e.saveOrderTx(tx, order); err != nil {
+			e.logger.Error("Failed to save order", zap.Error(err))
+			return nil, nil, repository.Classify(err)
+		}
+	}
+	if err := e.commit(tx, nil); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, nil, repository.Classify(err)
+	}
+
+	g := &orderGroup{group: group, legs: []*models.Order{limit, stop}}
+	e.groups[group.GroupID] = g
+	e.groupLegs[limit.OrderID] = g
+	e.groupLegs[stop.OrderID] = g
+	e.stops.add(stop)
+
+	result, err := e.executeOrder(limit, false)
+	if err != nil {
+		// The limit order never reached the book, so take the whole group down
+		if cancelErr := e.cancelGroup(g, models.OrderChange{Actor: models.ActorEngine, Reason: models.ReasonGroup}); cancelErr != nil {
+			e.logger.Error("Failed to cancel order group", zap.Uint64("group_id", group.GroupID), zap.Error(cancelErr))
+		}
+		return nil, nil, err
+	}
+	e.triggerStops(group.Symbol)
+	e.logger.Info("OCO group placed", zap.Uint64("group_id", group.GroupID),
+		zap.Uint64("limit_order_id", limit.OrderID), zap.Uint64("stop_order_id", stop.OrderID))
+	return group, result, nil
+}
+
+// firedLegs returns the IDs of group legs a transaction filled or triggered: every order on
+// either side of its trades, plus the order itself when it is a triggered stop
+func firedLegs(order *models.Order, triggered bool, trades []*models.Trade) []uint64 {
+	var fired []uint64
+	if triggered {
+		fired = append(fired, order.OrderID)
+	}
+	for _, trade := range trades {
+		fired = append(fired, trade.BuyOrderID, trade.SellOrderID)
+	}
+	return fired
+}
+
+// completeGroupsTx cancels, within the transaction, the other legs of every active group one of
+// whose legs fired, and marks those groups completed. The returned groups must be passed to
+// finishGroups once the transaction commits.
+func (e *symbolEngine) completeGroupsTx(tx repository.Tx, fired []uint64) ([]firedGroup, error) {
+	var done []firedGroup
+	seen := make(map[uint64]bool)
+	for _, orderID := range fired {
+		g, ok := e.groupLegs[orderID]
+		if !ok || seen[g.group.GroupID] {
+			continue
+		}
+		seen[g.group.GroupID] = true
+
+		for _, leg := range g.legs {
+			if leg.OrderID == orderID {
+				continue
+			}
+			canceled := *leg
+			canceled.Status = models.StatusCanceled
+			change := models.OrderChange{Actor: models.ActorEngine, Reason: models.ReasonGroup}
+			if err := e.updateOrderTx(tx, &canceled, change); err != nil {
+				return nil, err
+			}
+		}
+		completed := *g.group
+		completed.Status = models.GroupCompleted
+		if err := e.repo.UpdateOrderGroupTx(e.ctx, tx, &completed); err != nil {
+			return nil, err
+		}
+		done = append(done, firedGroup{group: g, orderID: orderID})
+	}
+	return done, nil
+}
+
+// firedGroup is a group completed by one of its legs firing
+type firedGroup struct {
+	group   *orderGroup
+	orderID uint64 // the leg that fired
+}
+
+// finishGroups applies committed group completions to memory, dropping the canceled legs from
+// the book or the stop store.
+func (e *symbolEngine) finishGroups(done []firedGroup) {
+	for _, f := range done {
+		for _, leg := range f.group.legs {
+			delete(e.groupLegs, leg.OrderID)
+			if leg.OrderID == f.orderID {
+				continue
+			}
+			e.dropLeg(leg)
+			e.logger.Info("OCO leg canceled", zap.Uint64("group_id", f.group.group.GroupID),
+				zap.Uint64("order_id", leg.OrderID), zap.Uint64("fired_order_id", f.orderID))
+		}
+		f.group.group.Status = models.GroupCompleted
+		delete(e.groups, f.group.group.GroupID)
+	}
+}
+
+// dropLeg marks a group leg canceled and removes it from the book or the stop store.
+func (e *symbolEngine) dropLeg(leg *models.Order) {
+	pending := leg.Status == models.StatusPending
+	leg.Status = models.StatusCanceled
+	if pending {
+		e.removeStop(leg)
+	} else {
+		e.removeFromOrderBook(leg)
+	}
+}
+
+// cancelGroup cancels every live leg of an active group and the group itself in one transaction,
+// recording the change on each leg as cancelOrder does. Legs are matched to the book and the stop
+// store by ID, so they may be copies.
+func (e *symbolEngine) cancelGroup(g *orderGroup, change models.OrderChange) error {
+	tx, err := e.beginTx(g.group.Symbol)
+	if err != nil {
+		return repository.Classify(err)
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("cancel_group"), time.Now())
+
+	for _, leg := range g.legs {
+		if !leg.Status.Resting() && leg.Status != models.StatusPending {
+			continue
+		}
+		canceled := *leg
+		canceled.Status = models.StatusCanceled
+		if err := e.updateOrderTx(tx, &canceled, attributed(change, leg)); err != nil {
+			return repository.Classify(err)
+		}
+	}
+	canceled := *g.group
+	canceled.Status = models.GroupCanceled
+	if err := e.repo.UpdateOrderGroupTx(e.ctx, tx, &canceled); err != nil {
+		return repository.Classify(err)
+	}
+	if err := e.commit(tx, nil); err != nil {
+		return repository.Classify(err)
+	}
+
+	for _, leg := range g.legs {
+		delete(e.groupLegs, leg.OrderID)
+		if leg.Status.Resting() || leg.Status == models.StatusPending {
+			e.dropLeg(leg)
+		}
+	}
+	g.group.Status = models.GroupCanceled
+	delete(e.groups, g.group.GroupID)
+	e.logger.Info("Order group canceled", zap.Uint64("group_id", g.group.GroupID))
+	return nil
+}
+
+// CancelOrderGroup cancels an active order group along with all of its open orders
+func (s *MatchingService) CancelOrderGroup(ctx context.Context, groupID uint64) error {
+	group, err := s.commandGroup(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get order group", zap.Error(err))
+		return err
+	}
+	return s.engine(group.Symbol).doContext(ctx, func(e *symbolEngine) error {
+		e.journal(&models.JournalEntry{Kind: models.JournalCancelGroup, GroupID: groupID})
+		err := e.cancelOrderGroup(groupID)
+		e.countFlow(FlowCancel, err)
+		return err
+	})
+}
+
+// cancelOrderGroup cancels an active group of the engine's symbol
+func (e *symbolEngine) cancelOrderGroup(groupID uint64) error {
+	// Re-read on the engine: the group may have completed since the caller read it
+	if err := e.awaitWrites(); err != nil {
+		return err
+	}
+	group, err := e.repo.GetOrderGroup(e.ctx, groupID)
+	if err != nil {
+		e.logger.Error("Failed to get order group", zap.Error(err))
+		return err
+	}
+	if group.Status != models.GroupActive {
+		return models.ErrGroupNotActive
+	}
+	legs, err := e.repo.GetGroupOrders(e.ctx, groupID)
+	if err != nil {
+		e.logger.Error("Failed to get group orders", zap.Error(err))
+		return err
+	}
+	return e.cancelGroup(&orderGroup{group: group, legs: legs}, requested)
+}
+
+// GetOrderGroup retrieves an order group and its orders
+func (s *MatchingService) GetOrderGroup(ctx context.Context, groupID uint64) (*models.OrderGroup, []*models.Order, error) {
+	group, err := s.repo.GetOrderGroup(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get order group", zap.Error(err))
+		return nil, nil, err
+	}
+	orders, err := s.repo.GetGroupOrders(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get group orders", zap.Error(err))
+		return nil, nil, err
+	}
+	s.withFills(ctx, orders...)
+	return group, orders, nil
+}
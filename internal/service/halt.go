@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// HaltSymbol halts trading in a listed symbol: its engine takes cancels but rejects new orders,
+// amendments and block trades until the symbol is resumed, and auctions wait for the resumption
+// to uncross. With cancelOrders the symbol's resting and pending stop orders are canceled too,
+// and their IDs returned. The halt is stored in the symbol registry, so it survives restarts.
+func (s *MatchingService) HaltSymbol(ctx context.Context, symbol string, cancelOrders bool) ([]uint64, error) {
+	if err := s.loadSymbols(ctx); err != nil {
+		return nil, err
+	}
+	if s.listing(ctx, symbol) == nil {
+		return nil, models.ErrSymbolNotFound
+	}
+	return call(s, symbol, func(e *symbolEngine) ([]uint64, error) {
+		return e.halt(cancelOrders)
+	})
+}
+
+// halt halts the engine's symbol, canceling its orders when asked
+func (e *symbolEngine) halt(cancelOrders bool) ([]uint64, error) {
+	if e.listing(e.ctx, e.symbol).Status == models.SymbolDelisted {
+		return nil, models.ErrSymbolNotListed
+	}
+	if err := e.setStatus(models.SymbolHalted); err != nil {
+		return nil, err
+	}
+	canceled := []uint64{}
+	if cancelOrders {
+		var err error
+		if canceled, err = e.cancelAll(models.OrderChange{Actor: models.ActorOperator, Reason: models.ReasonHalted}); err != nil {
+			return canceled, err
+		}
+	}
+	e.logger.Info("Trading halted", zap.String("symbol", e.symbol), zap.Int("canceled", len(canceled)))
+	return canceled, nil
+}
+
+// ResumeSymbol resumes trading in a halted symbol; resuming one that trades already does nothing
+func (s *MatchingService) ResumeSymbol(ctx context.Context, symbol string) error {
+	if err := s.loadSymbols(ctx); err != nil {
+		return err
+	}
+	if s.listing(ctx, symbol) == nil {
+		return models.ErrSymbolNotFound
+	}
+	return s.engine(symbol).do(func(e *symbolEngine) error {
+		if e.listing(ctx, e.symbol).Status == models.SymbolDelisted {
+			return models.ErrSymbolNotListed
+		}
+		if err := e.setStatus(models.SymbolActive); err != nil {
+			return err
+		}
+		e.logger.Info("Trading resumed", zap.String("symbol", e.symbol))
+		return nil
+	})
+}
+
+// halted reports whether trading in the engine's symbol is halted
+func (e *symbolEngine) halted() bool {
+	listing := e.listing(e.ctx, e.symbol)
+	return listing != nil && listing.Status == models.SymbolHalted
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// recordRejection appends a rejected event for an order refused for the request itself, so the
+// order's history shows why it never traded. Storage failures are not rejections and are not
+// recorded; an order refused before it was given an ID is given one here.
+func (c *core) recordRejection(ctx context.Context, order *models.Order, err error) {
+	reason := models.RejectReason(err)
+	if reason == models.ReasonInternal {
+		return
+	}
+	rejected := *order
+	if rejected.OrderID == 0 {
+		rejected.OrderID = c.idgen.Next()
+	}
+	if err := c.repo.SaveRejection(ctx, &rejected, reason); err != nil {
+		c.logger.Error("Failed to record order rejection", zap.Uint64("order_id", rejected.OrderID), zap.Error(err))
+	}
+}
+
+// GetOrderHistory retrieves every recorded event of an order, oldest first
+func (s *MatchingService) GetOrderHistory(ctx context.Context, orderID uint64) ([]*models.OrderEvent, error) {
+	events, err := s.repo.GetOrderHistory(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to get order history", zap.Error(err))
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, models.ErrOrderNotFound
+	}
+	return events, nil
+}
+
+// withFills sets the filled quantity and average fill price of orders read for a caller. An error
+// reading the prices is logged and leaves the orders without them.
+func (c *core) withFills(ctx context.Context, orders ...*models.Order) {
+	var filled []uint64
+	for _, order := range orders {
+		order.FilledQuantity = order.InitialQuantity - order.RemainingQuantity
+		if order.FilledQuantity > 0 {
+			filled = append(filled, order.OrderID)
+		}
+	}
+	if len(filled) == 0 {
+		return
+	}
+	prices, err := c.repo.GetAveragePrices(ctx, filled)
+	if err != nil {
+		c.logger.Error("Failed to get average fill prices", zap.Error(err))
+		return
+	}
+	for _, order := range orders {
+		if price, ok := prices[order.OrderID]; ok {
+			order.AvgPrice = models.NullDecimal{Decimal: price, Valid: true}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"math"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// holding reports whether an order holds the balance it consumes while open: in the holds credit
+// check, for account orders of symbols with currencies
+func (c *core) holding(ctx context.Context, order *models.Order) bool {
+	if c.cfg.Risk.CreditCheck != config.CreditHolds || order.OwnerID == "" {
+		return false
+	}
+	_, ok := c.symbolConfig(ctx, order.Symbol)
+	return ok
+}
+
+// hold returns the currency and amount an open order of a configured symbol holds: the base
+// quantity of a sell's remainder or the quote notional of a buy's at its limit price. Buys
+// without a limit price hold nothing, their fills being covered as they happen instead.
+func (c *core) hold(ctx context.Context, order *models.Order) (string, float64) {
+	sc, ok := c.symbolConfig(ctx, order.Symbol)
+	if !ok {
+		return "", 0
+	}
+	if order.Side == models.SideSell {
+		return sc.BaseCurrency, order.RemainingQuantity.Float64()
+	}
+	if !isLimitPriced(order) || !order.Price.Valid {
+		return sc.QuoteCurrency, 0
+	}
+	return sc.QuoteCurrency, models.Notional(order.Price.Decimal, order.RemainingQuantity)
+}
+
+// holds sums what open orders hold by currency. Only one leg of an OCO group can fill, so a
+// group holds what its largest leg does.
+func (c *core) holds(ctx context.Context, open []*models.Order) map[string]float64 {
+	held := make(map[string]float64)
+	legs := make(map[int64]float64) // largest leg hold by group ID
+	for _, o := range open {
+		currency, amount := c.hold(ctx, o)
+		if currency == "" {
+			continue
+		}
+		if o.GroupID.Valid {
+			largest, ok := legs[o.GroupID.Int64]
+			if ok && amount <= largest {
+				continue
+			}
+			held[currency] -= largest
+			legs[o.GroupID.Int64] = amount
+		}
+		held[currency] += amount
+	}
+	return held
+}
+
+// reserveTx places an account order's hold within the transaction that accepts it, rejecting the
+// order with ErrInsufficientCredit unless the account's balance of the currency, settled plus
+// pending, covers the hold on top of those of its other open orders. Buys without a limit price
+// only need some of the balance left unheld.
+func (e *symbolEngine) reserveTx(tx repository.Tx, order *models.Order) error {
+	if !e.holding(e.ctx, order) {
+		return nil
+	}
+	currency, amount := e.hold(e.ctx, order)
+	unheld, err := e.unheldTx(tx, order, currency)
+	if err != nil {
+		return err
+	}
+	if unheld < amount || unheld <= 0 {
+		e.logger.Warn("Order rejected for insufficient balance", zap.String("owner_id", order.OwnerID),
+			zap.String("currency", currency), zap.Float64("required", amount), zap.Float64("unheld", unheld))
+		return models.ErrInsufficientCredit
+	}
+	return nil
+}
+
+// coverTx checks, once an account order's fills have moved balances within its transaction,
+// that the account's balance of the currency the order consumes still covers the holds of its
+// other open orders, rejecting the order with ErrInsufficientCredit otherwise
+func (e *symbolEngine) coverTx(tx repository.Tx, order *models.Order) error {
+	if !e.holding(e.ctx, order) {
+		return nil
+	}
+	currency, _ := e.hold(e.ctx, order)
+	unheld, err := e.unheldTx(tx, order, currency)
+	if err != nil {
+		return err
+	}
+	if unheld < 0 {
+		e.logger.Warn("Order fills exceed unheld balance", zap.Uint64("order_id", order.OrderID),
+			zap.String("owner_id", order.OwnerID), zap.String("currency", currency), zap.Float64("unheld", unheld))
+		return models.ErrInsufficientCredit
+	}
+	return nil
+}
+
+// unheldTx locks an order's account's balance of a currency and returns what of it, settled plus
+// pending, the account's other open orders leave unheld; the order's own group does not count.
+// Taking the lock before reading the orders makes the account's orders on other symbols wait for
+// the transaction instead of holding the same balance.
+func (e *symbolEngine) unheldTx(tx repository.Tx, order *models.Order, currency string) (float64, error) {
+	balance, err := e.repo.GetBalanceTx(e.ctx, tx, order.OwnerID, currency)
+	if err != nil {
+		e.logger.Error("Failed to lock balance", zap.Error(err))
+		return 0, err
+	}
+	open, err := e.repo.GetOpenOrdersTx(e.ctx, tx, order.OwnerID)
+	if err != nil {
+		e.logger.Error("Failed to get open orders for hold", zap.Error(err))
+		return 0, err
+	}
+	others := open[:0]
+	for _, o := range open {
+		if o.OrderID != order.OrderID && (!order.GroupID.Valid || o.GroupID != order.GroupID) {
+			others = append(others, o)
+		}
+	}
+	return balance.Settled + balance.Pending - e.holds(e.ctx, others)[currency], nil
+}
+
+// saveOrderTx persists a new order within the engine's transaction, noting the account of an
+// order that holds balance so the transaction's commit posts its holds
+func (e *symbolEngine) saveOrderTx(tx repository.Tx, order *models.Order) error {
+	if e.holding(e.ctx, order) {
+		e.holdOwners[order.OwnerID] = true
+	}
+	return e.repo.SaveOrderTx(e.ctx, tx, order)
+}
+
+// updateOrderTx persists an order's change within the engine's transaction, noting its account
+// as saveOrderTx does
+func (e *symbolEngine) updateOrderTx(tx repository.Tx, order *models.Order, change models.OrderChange) error {
+	if e.holding(e.ctx, order) {
+		e.holdOwners[order.OwnerID] = true
+	}
+	return e.repo.UpdateOrderTx(e.ctx, tx, order, change)
+}
+
+// postHoldsTx posts to the ledger, within the transaction about to commit, how the holds of each
+// account whose orders it saved have moved: a reserve where the account's open orders now hold
+// more of a currency than its held balance records, a release where they hold less. Each
+// account's holds are recomputed from its open orders, so an account noted by a transaction that
+// rolled back is only brought up to date by the next one.
+func (e *symbolEngine) postHoldsTx(tx repository.Tx) error {
+	owners := make([]string, 0, len(e.holdOwners))
+	for owner := range e.holdOwners {
+		owners = append(owners, owner)
+	}
+	clear(e.holdOwners)
+	sort.Strings(owners) // lock balances in the same order as engines committing the same accounts
+
+	now := time.Now()
+	for _, owner := range owners {
+		recorded, err := e.repo.GetHeldTx(e.ctx, tx, owner)
+		if err != nil {
+			e.logger.Error("Failed to lock held balances", zap.Error(err))
+			return err
+		}
+		open, err := e.repo.GetOpenOrdersTx(e.ctx, tx, owner)
+		if err != nil {
+			e.logger.Error("Failed to get open orders for hold", zap.Error(err))
+			return err
+		}
+		held := e.holds(e.ctx, open)
+		for currency := range recorded {
+			if _, ok := held[currency]; !ok {
+				held[currency] = 0
+			}
+		}
+		currencies := make([]string, 0, len(held))
+		for currency := range held {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+
+		for _, currency := range currencies {
+			// Balances are stored to 8 decimals, so what the decimals cannot record is not posted
+			delta := math.Round((held[currency]-recorded[currency])*1e8) / 1e8
+			if delta == 0 {
+				continue
+			}
+			kind := models.LedgerReserve
+			if delta < 0 {
+				kind = models.LedgerRelease
+			}
+			posting := e.newPosting(kind, 0, now)
+			posting.Entries = []*models.LedgerEntry{
+				{AccountID: owner, Currency: currency, Bucket: models.BucketUnheld, Amount: -delta},
+				{AccountID: owner, Currency: currency, Bucket: models.BucketHeld, Amount: delta},
+			}
+			if err := postTx(e.ctx, e.repo, tx, posting); err != nil {
+				e.logger.Error("Failed to post hold", zap.String("owner_id", owner), zap.Error(err))
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Holds sums what an account's open orders hold by currency
+func (s *MatchingService) Holds(ctx context.Context, accountID string) (map[string]float64, error) {
+	open, err := s.repo.GetOpenOrders(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get open orders", zap.Error(err))
+		return nil, err
+	}
+	return s.holds(ctx, open), nil
+}
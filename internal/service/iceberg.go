@@ -0,0 +1,43 @@
+package service
+
+import "orderSystem/internal/models"
+
+// isIceberg reports whether an order shows only a slice of its size in the book
+func isIceberg(order *models.Order) bool {
+	return order.DisplayQuantity.Valid
+}
+
+// visibleQuantity returns the part of a resting order other participants can see and match against
+func visibleQuantity(order *models.Order) models.Decimal {
+	if isIceberg(order) {
+		return order.VisibleQuantity
+	}
+	return order.RemainingQuantity
+}
+
+// replenish refreshes an iceberg order's visible slice from its hidden reserve
+func replenish(order *models.Order) {
+	if isIceberg(order) {
+		order.VisibleQuantity = min(order.DisplayQuantity.Decimal, order.RemainingQuantity)
+	}
+}
+
+// requeue moves an order that has just taken a new sequence to the back of its price level.
+func requeue(entry *models.OrderBookEntry, order *models.Order) {
+	for i, o := range entry.Orders {
+		if o.OrderID == order.OrderID {
+			entry.Orders = append(append(entry.Orders[:i], entry.Orders[i+1:]...), order)
+			return
+		}
+	}
+}
+
+// maskIceberg hides an iceberg order's reserve, reporting only its current visible slice
+func maskIceberg(order *models.Order, visible models.Decimal) {
+	if !isIceberg(order) {
+		return
+	}
+	order.RemainingQuantity = visible
+	order.InitialQuantity = order.DisplayQuantity.Decimal
+	order.DisplayQuantity.Valid = false
+}
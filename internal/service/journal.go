@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/writebehind"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// journal starts the journal entry of an order command about to run on the engine. The entry
+// is appended within each transaction the command commits, as a part carrying the trades that
+// transaction printed, so the journal holds exactly the changes the database does; a command
+// that commits nothing, having failed, is not journaled.
+func (e *symbolEngine) journal(entry *models.JournalEntry) {
+	e.journalPlacement(func() *models.JournalEntry { return entry })
+}
+
+// journalPlacement starts the journal entry of a command placing orders like journal, building
+// it when the command first commits, by when the orders have their IDs
+func (e *symbolEngine) journalPlacement(build func() *models.JournalEntry) {
+	e.journalBuild, e.journalEntry, e.journalParts = build, nil, 0
+}
+
+// record journals a command that changed only the engine's memory, appending its entry at once
+func (e *symbolEngine) record(entry *models.JournalEntry) {
+	e.journal(entry)
+	e.flushJournal()
+	e.journalBuild = nil
+}
+
+// flushJournal appends the running command's entry on its own when none of its transactions
+// did, for a command that succeeded without committing any. The command has already taken
+// effect, so a failed append is logged and the command goes unjournaled rather than undone.
+func (e *symbolEngine) flushJournal() {
+	if e.journalParts > 0 {
+		return
+	}
+	part := e.journalPart(nil)
+	if part == nil {
+		return
+	}
+	tx, err := e.beginTx(e.symbol)
+	if err == nil {
+		defer tx.Rollback()
+		if err = e.repo.AppendJournalTx(e.ctx, tx, part); err == nil {
+			err = tx.Commit()
+		}
+	}
+	if err != nil {
+		e.logger.Error("Failed to append to engine journal", zap.String("symbol", e.symbol),
+			zap.String("kind", string(part.Kind)), zap.Uint64("sequence", part.Sequence), zap.Error(err))
+		return
+	}
+	e.journalSequence, e.journalParts = part.Sequence, 1
+}
+
+// commit posts the holds tx moved and appends the next part of the running command's journal
+// entry within tx, carrying the trades tx printed, and commits tx. A failed commit to the database
+// may still have gone through, so the journal sequence is then read back; one written behind
+// failed to reach the log.
+func (e *symbolEngine) commit(tx repository.Tx, trades []*models.Trade) error {
+	if err := e.postHoldsTx(tx); err != nil {
+		return err
+	}
+	part := e.journalPart(trades)
+	if part != nil {
+		if err := e.repo.AppendJournalTx(e.ctx, tx, part); err != nil {
+			e.logger.Error("Failed to append to engine journal", zap.String("symbol", e.symbol),
+				zap.String("kind", string(part.Kind)), zap.Uint64("sequence", part.Sequence), zap.Error(err))
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		if _, queued := tx.(*writebehind.Tx); part != nil && !queued {
+			e.reloadJournalSequence()
+		}
+		return err
+	}
+	if part != nil {
+		e.journalSequence = part.Sequence
+		e.journalParts++
+		e.journalDeclines = nil
+	}
+	return nil
+}
+
+// journalPart returns the running command's next journal part, nil when the journal is off or
+// the command is not journaled
+func (e *symbolEngine) journalPart(trades []*models.Trade) *models.JournalEntry {
+	if !e.cfg.Engine.Journal || e.journalBuild == nil {
+		return nil
+	}
+	if e.journalEntry == nil {
+		e.journalEntry = e.journalBuild()
+		e.journalEntry.Symbol = e.symbol
+		e.journalEntry.Sequence = e.journalSequence + 1
+		e.journalEntry.CreatedAt = time.Now()
+	}
+	part := *e.journalEntry
+	part.Part, part.Trades, part.Declined = e.journalParts, trades, e.journalDeclines
+	return &part
+}
+
+// reloadJournalSequence reads back the sequence of the symbol's last journaled command, which a
+// commit that reported failure may still have advanced, so the next command does not reuse it
+func (e *symbolEngine) reloadJournalSequence() {
+	sequence, err := e.repo.GetJournalSequence(context.Background(), e.symbol)
+	if err != nil {
+		e.logger.Error("Failed to reload engine journal sequence", zap.String("symbol", e.symbol), zap.Error(err))
+		return
+	}
+	if sequence != e.journalSequence {
+		// The command's first part went through
+		e.journalSequence, e.journalParts = sequence, 1
+	}
+}
+
+// journalLookDeclines keeps the last look declines of an order about to be executed for the
+// journal part of its transaction
+func (e *symbolEngine) journalLookDeclines(order *models.Order) {
+	if !e.cfg.Engine.Journal || len(e.declined) == 0 {
+		return
+	}
+	declined := make([]uint64, 0, len(e.declined))
+	for orderID := range e.declined {
+		declined = append(declined, orderID)
+	}
+	slices.Sort(declined)
+	e.journalDeclines = map[uint64][]uint64{order.OrderID: declined}
+}
+
+// Replayer applies journaled order commands to a service in journal order, rebuilding the books
+// and re-deriving the trades they printed. Orders keep their journaled IDs; order groups get new
+// ones, which the replayer maps journaled group IDs to. Last looks are not offered: the
+// providers' journaled rejections are taken instead. The service's risk limits and symbol
+// settings should be those the journal was written under, and it should be given an empty
+// database with the journal off.
+type Replayer struct {
+	s      *MatchingService
+	groups map[uint64]uint64 // replayed group ID by journaled group ID
+}
+
+// NewReplayer creates a replayer applying commands to a service
+func NewReplayer(s *MatchingService) *Replayer {
+	return &Replayer{s: s, groups: make(map[uint64]uint64)}
+}
+
+// Apply applies a journaled command, skipping the credit check as the journal holds only
+// commands that passed one, and returns the trades it printed
+func (r *Replayer) Apply(entry *models.JournalEntry) ([]*models.Trade, error) {
+	return call(r.s, entry.Symbol, func(e *symbolEngine) ([]*models.Trade, error) {
+		e.replayedDeclines = entry.Declined
+		defer func() { e.replayedDeclines = nil }()
+		if err := r.apply(e, entry); err != nil {
+			return nil, err
+		}
+		return e.journalTrades, nil
+	})
+}
+
+// apply runs a journaled command on its symbol's engine
+func (r *Replayer) apply(e *symbolEngine, entry *models.JournalEntry) error {
+	var err error
+	switch entry.Kind {
+	case models.JournalPlace:
+		if len(entry.Orders) != 1 {
+			return models.ErrInvalidOrder
+		}
+		order := *entry.Orders[0]
+		_, err = e.placeOrder(&order)
+	case models.JournalOCO:
+		if len(entry.Orders) != 2 {
+			return models.ErrInvalidOrder
+		}
+		limit, stop := *entry.Orders[0], *entry.Orders[1]
+		var group *models.OrderGroup
+		if group, _, err = e.placeOCO(&limit, &stop); err == nil {
+			r.groups[entry.GroupID] = group.GroupID
+		}
+	case models.JournalAmend:
+		_, err = e.amendOrder(entry.OrderID, entry.Price, entry.Quantity)
+	case models.JournalCancel:
+		err = e.cancelOrder(entry.OrderID, entry.Change)
+	case models.JournalCancelQuantity:
+		_, err = e.cancelQuantity(entry.OrderID, entry.Quantity)
+	case models.JournalCancelGroup:
+		groupID, ok := r.groups[entry.GroupID]
+		if !ok {
+			return models.ErrGroupNotFound
+		}
+		err = e.cancelOrderGroup(groupID)
+	case models.JournalExpire:
+		err = e.expireOrder(entry.OrderID)
+	case models.JournalStale:
+		var order *models.Order
+		if order, err = e.repo.GetOrder(e.ctx, entry.OrderID); err == nil {
+			err = e.expireStale(order)
+		}
+	case models.JournalTickSize:
+		_, err = e.changeTickSize(entry.TickSize, TickPolicy(entry.Policy))
+	case models.JournalAuction:
+		// The journal records when the auction ended
+		e.startAuction(e.symbol, entry.Auction, 0)
+	case models.JournalUncross:
+		err = e.endAuction(e.symbol)
+	default:
+		return models.ErrInvalidOrder
+	}
+	return err
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LastLookSink offers a designated liquidity provider's resting order's match a last look,
+// returning whether the provider was reached. It is called on the engine's goroutine and must
+// not block; the provider answers through RespondLastLook.
+type LastLookSink interface {
+	LastLook(look *models.LastLook) bool
+}
+
+// OfferLastLooksTo adds a sink offering last looks to liquidity providers. Without one, last
+// look is off whatever the symbol settings. It must be called before the service handles orders.
+func (s *MatchingService) OfferLastLooksTo(sink LastLookSink) {
+	s.lastLookSinks = append(s.lastLookSinks, sink)
+}
+
+// pendingLook is a last look awaiting its provider's answer
+type pendingLook struct {
+	look   *models.LastLook
+	answer chan bool // buffered, so answering never waits for the engine
+}
+
+// RespondLastLook answers a pending last look offered to an account, accepting the match or
+// rejecting it. A look that has passed its deadline, was answered already or was offered to
+// another account is not found.
+func (s *MatchingService) RespondLastLook(accountID string, lastLookID uint64, accept bool) error {
+	s.lookMutex.Lock()
+	defer s.lookMutex.Unlock()
+	p, ok := s.pendingLooks[lastLookID]
+	if !ok || p.look.ProviderID != accountID || !time.Now().Before(p.look.Deadline) {
+		return models.ErrLastLookNotFound
+	}
+	delete(s.pendingLooks, lastLookID)
+	p.answer <- accept
+	return nil
+}
+
+// GetLastLookStats counts each provider's last look answers requested within [from, to),
+// optionally for one symbol
+func (s *MatchingService) GetLastLookStats(ctx context.Context, symbol string, from, to time.Time) ([]*models.LastLookStats, error) {
+	stats, err := s.repo.GetLastLookStats(ctx, symbol, from, to)
+	if err != nil {
+		s.logger.Error("Failed to get last look stats", zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
+
+// lastLook offers the matches an incoming order would make with designated liquidity providers'
+// resting orders to the providers, waiting until each answered or the symbol's window passed,
+// and returns the resting orders whose providers rejected their match. A provider that does
+// not answer in time is taken to accept. Every provider order the incoming order could reach
+// if all of them rejected is offered, and the answers are persisted for surveillance. Orders
+// meeting an auction are not offered, as the uncrossing prints without a last look. While the
+// journal is replayed, the rejections it recorded for the order are returned instead.
+func (e *symbolEngine) lastLook(order *models.Order) map[uint64]bool {
+	if declined, ok := e.replayedDeclines[order.OrderID]; ok {
+		return replayedLook(declined)
+	}
+	sc := e.cfg.Symbols[order.Symbol]
+	if sc.LastLookWindow == 0 || len(e.lastLookSinks) == 0 || e.inAuction(order.Symbol) {
+		return nil
+	}
+
+	now := time.Now()
+	deadline := now.Add(sc.LastLookWindow)
+	var offered []*pendingLook
+	var covered models.Decimal // quantity resting ahead that no provider can reject
+	for _, entry := range e.bookSide(order.Side == models.SideSell)[order.Symbol] {
+		if !crosses(order, entry.Price) || covered >= order.RemainingQuantity {
+			break
+		}
+		for _, restingOrder := range entry.Orders {
+			if covered >= order.RemainingQuantity {
+				break
+			}
+			if !slices.Contains(sc.LastLookProviders, restingOrder.OwnerID) || restingOrder.OwnerID == order.OwnerID {
+				covered += visibleQuantity(restingOrder)
+				continue
+			}
+			p := &pendingLook{
+				look: &models.LastLook{
+					LastLookID:   e.idgen.Next(),
+					Symbol:       order.Symbol,
+					OrderID:      restingOrder.OrderID,
+					ProviderID:   restingOrder.OwnerID,
+					TakerOrderID: order.OrderID,
+					Side:         restingOrder.Side,
+					Price:        entry.Price,
+					Quantity:     min(order.RemainingQuantity, restingOrder.RemainingQuantity),
+					RequestedAt:  now,
+					Deadline:     deadline,
+				},
+				answer: make(chan bool, 1),
+			}
+			if e.offerLook(p) {
+				offered = append(offered, p)
+			}
+		}
+	}
+	if len(offered) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	answered := make(map[*pendingLook]bool)
+	for _, p := range offered {
+		select {
+		case accept := <-p.answer:
+			answered[p] = accept
+			p.look.DecidedAt = time.Now()
+		case <-ctx.Done():
+		}
+	}
+
+	// An answer may have come in between the deadline and the looks being withdrawn
+	e.lookMutex.Lock()
+	for _, p := range offered {
+		delete(e.pendingLooks, p.look.LastLookID)
+	}
+	e.lookMutex.Unlock()
+
+	declined := make(map[uint64]bool)
+	looks := make([]*models.LastLook, len(offered))
+	for i, p := range offered {
+		accept, ok := answered[p]
+		if !ok {
+			select {
+			case accept = <-p.answer:
+				ok = true
+			default:
+			}
+			p.look.DecidedAt = time.Now()
+		}
+		switch {
+		case !ok:
+			p.look.Outcome = models.LastLookTimedOut
+		case accept:
+			p.look.Outcome = models.LastLookAccepted
+		default:
+			p.look.Outcome = models.LastLookRejected
+			declined[p.look.OrderID] = true
+		}
+		looks[i] = p.look
+	}
+	if err := e.repo.SaveLastLooks(e.ctx, looks); err != nil {
+		e.logger.Error("Failed to save last looks", zap.Uint64("order_id", order.OrderID), zap.Error(err))
+	}
+	return declined
+}
+
+// replayedLook returns the journaled rejections of an order's last look
+func replayedLook(declined []uint64) map[uint64]bool {
+	rejected := make(map[uint64]bool, len(declined))
+	for _, orderID := range declined {
+		rejected[orderID] = true
+	}
+	return rejected
+}
+
+// offerLook registers a last look as pending and offers it to the sinks, withdrawing it again
+// when no sink reached the provider
+func (e *symbolEngine) offerLook(p *pendingLook) bool {
+	e.lookMutex.Lock()
+	e.pendingLooks[p.look.LastLookID] = p
+	e.lookMutex.Unlock()
+
+	reached := false
+	for _, sink := range e.lastLookSinks {
+		if sink.LastLook(p.look) {
+			reached = true
+		}
+	}
+	if !reached {
+		e.lookMutex.Lock()
+		delete(e.pendingLooks, p.look.LastLookID)
+		e.lookMutex.Unlock()
+	}
+	return reached
+}
+
+// LastLookPeriod returns the default period last look surveillance reports on
+func (s *MatchingService) LastLookPeriod() time.Duration {
+	return s.cfg.LastLook.Period
+}
+
+// LastLookFlagged reports whether a provider answered enough last looks and rejected so large
+// a share of them that surveillance should look into it
+func (s *MatchingService) LastLookFlagged(stats *models.LastLookStats) bool {
+	cfg := s.cfg.LastLook
+	return stats.Requests > 0 && stats.Requests >= cfg.MinRequests &&
+		float64(stats.Rejects)/float64(stats.Requests) > cfg.MaxRejectRatio
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newPosting starts a ledger posting of a balance mutation, referencing the trade or settlement
+// posted when referenceID is not 0. Its ID is generated rather than assigned by the database, so
+// its entries can refer to it in a transaction written behind.
+func (c *core) newPosting(kind models.LedgerKind, referenceID uint64, at time.Time) *models.LedgerPosting {
+	return &models.LedgerPosting{
+		PostingID:   c.idgen.Next(),
+		Kind:        kind,
+		ReferenceID: sql.NullInt64{Int64: int64(referenceID), Valid: referenceID != 0},
+		CreatedAt:   at,
+	}
+}
+
+// postTx records a posting in the ledger and applies it to the balances within a transaction,
+// refusing one whose entries do not sum to zero in every currency
+func postTx(ctx context.Context, repo repository.Repository, tx repository.Tx, posting *models.LedgerPosting) error {
+	sums := make(map[string]float64)
+	for _, entry := range posting.Entries {
+		sums[entry.Currency] += entry.Amount
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return models.ErrUnbalancedPosting
+		}
+	}
+	return repo.PostLedgerTx(ctx, tx, posting)
+}
+
+// GetLedger retrieves an account's latest ledger postings, newest first, with only its own entries
+func (s *MatchingService) GetLedger(ctx context.Context, accountID string, limit int) ([]*models.LedgerPosting, error) {
+	postings, err := s.repo.GetLedger(ctx, accountID, limit)
+	if err != nil {
+		s.logger.Error("Failed to get ledger", zap.Error(err))
+		return nil, err
+	}
+	return postings, nil
+}
+
+// Reconcile checks the ledger against the balances, logging any disagreement found
+func (s *MatchingService) Reconcile(ctx context.Context) (*models.Reconciliation, error) {
+	reconciliation, err := s.repo.Reconcile(ctx)
+	if err != nil {
+		s.logger.Error("Failed to reconcile ledger", zap.Error(err))
+		return nil, err
+	}
+	if len(reconciliation.Mismatches) > 0 || len(reconciliation.Unbalanced) > 0 {
+		s.logger.Warn("Ledger does not reconcile", zap.Int("mismatches", len(reconciliation.Mismatches)),
+			zap.Int("unbalanced_postings", len(reconciliation.Unbalanced)))
+	}
+	return reconciliation, nil
+}
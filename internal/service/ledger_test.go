@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"slices"
+	"testing"
+)
+
+// ledgerKinds returns the kinds of an account's ledger postings, oldest first
+func ledgerKinds(t *testing.T, s *MatchingService, accountID string) []models.LedgerKind {
+	t.Helper()
+	postings, err := s.GetLedger(context.Background(), accountID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make([]models.LedgerKind, len(postings))
+	for i, posting := range postings {
+		kinds[len(postings)-1-i] = posting.Kind
+	}
+	return kinds
+}
+
+// balanceOf returns an account's balance of a currency, with what its held balance records in
+// place of what its open orders hold
+func balanceOf(t *testing.T, s *MatchingService, accountID, currency string) models.Balance {
+	t.Helper()
+	tx, err := s.repo.BeginTx(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	balance, err := s.repo.GetBalanceTx(context.Background(), tx, accountID, currency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	held, err := s.repo.GetHeldTx(context.Background(), tx, accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance.Held = held[currency]
+	return *balance
+}
+
+// assertReconciles fails the test unless the ledger reconciles to the balances
+func assertReconciles(t *testing.T, s *MatchingService) {
+	t.Helper()
+	reconciliation, err := s.Reconcile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range reconciliation.Mismatches {
+		t.Errorf("%s %s: balance settled %v pending %v held %v, ledger settled %v pending %v held %v", m.AccountID,
+			m.Currency, m.Settled, m.Pending, m.Held, m.LedgerSettled, m.LedgerPending, m.LedgerHeld)
+	}
+	for _, u := range reconciliation.Unbalanced {
+		t.Errorf("posting %d sums to %v %s", u.PostingID, u.Sum, u.Currency)
+	}
+}
+
+func TestFeesPostedToLedger(t *testing.T) {
+	s, _ := newTestService(t, testSetup{configure: func(cfg *config.Config) {
+		cfg.Fees.MakerRate, cfg.Fees.TakerRate = -0.001, 0.002
+	}}, "BTCUSD")
+	ctx := context.Background()
+	for _, account := range []string{"maker", "taker"} {
+		for _, currency := range []string{"BTC", "USD"} {
+			if err := s.Deposit(ctx, account, currency, 1000); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	sell := limitOrder("BTCUSD", models.SideSell, 100, 2)
+	sell.OwnerID = "maker"
+	buy := limitOrder("BTCUSD", models.SideBuy, 100, 2)
+	buy.OwnerID = "taker"
+	for _, order := range []*models.Order{sell, buy} {
+		if _, err := s.PlaceOrder(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The taker pays 0.2% of the 200 notional and the maker earns a 0.1% rebate
+	if got := balanceOf(t, s, "taker", "USD").Settled; got != 1000-200-0.4 {
+		t.Errorf("taker USD settled = %v, want %v", got, 1000-200-0.4)
+	}
+	if got := balanceOf(t, s, "maker", "USD").Settled; got != 1000+200+0.2 {
+		t.Errorf("maker USD settled = %v, want %v", got, 1000+200+0.2)
+	}
+	for _, account := range []string{"maker", "taker"} {
+		want := []models.LedgerKind{models.LedgerDeposit, models.LedgerDeposit, models.LedgerFee, models.LedgerTrade}
+		if kinds := ledgerKinds(t, s, account); !slices.Equal(kinds, want) {
+			t.Errorf("%s ledger = %v, want %v", account, kinds, want)
+		}
+	}
+	assertReconciles(t, s)
+}
+
+func TestHoldsPostedToLedger(t *testing.T) {
+	s, _ := newTestService(t, testSetup{configure: func(cfg *config.Config) {
+		cfg.Risk.CreditCheck = config.CreditHolds
+	}}, "BTCUSD")
+	ctx := context.Background()
+	if err := s.Deposit(ctx, "buyer", "USD", 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deposit(ctx, "seller", "BTC", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	buy := limitOrder("BTCUSD", models.SideBuy, 100, 4)
+	buy.OwnerID = "buyer"
+	if _, err := s.PlaceOrder(ctx, buy); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, s, "buyer", "USD").Held; got != 400 {
+		t.Errorf("held after placing = %v, want 400", got)
+	}
+
+	sell := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	sell.OwnerID = "seller"
+	if _, err := s.PlaceOrder(ctx, sell); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, s, "buyer", "USD").Held; got != 300 {
+		t.Errorf("held after partial fill = %v, want 300", got)
+	}
+	if got := balanceOf(t, s, "seller", "BTC").Held; got != 0 {
+		t.Errorf("seller held after fill = %v, want 0", got)
+	}
+
+	if err := s.CancelOrder(ctx, buy.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, s, "buyer", "USD"); got.Held != 0 || got.Settled != 900 {
+		t.Errorf("after cancel settled = %v, held = %v, want 900 and 0", got.Settled, got.Held)
+	}
+	want := []models.LedgerKind{models.LedgerDeposit, models.LedgerReserve, models.LedgerTrade, models.LedgerRelease,
+		models.LedgerRelease}
+	if kinds := ledgerKinds(t, s, "buyer"); !slices.Equal(kinds, want) {
+		t.Errorf("buyer ledger = %v, want %v", kinds, want)
+	}
+	assertReconciles(t, s)
+}
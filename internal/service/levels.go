@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// Each side of a symbol's book keeps its price levels in priority order, best first: bids by
+// descending price, asks by ascending price. Levels are located by binary search and each
+// level's orders form a FIFO queue, so the best price is always the first level and matching
+// walks the side from the front without sorting it.
+//
+// Within a level, orders are queued by their sequence, a counter taken each time an order
+// enters the book (on arrival, when a stop triggers, when an amendment re-enters it and when an
+// iceberg refreshes its slice). Sequences are persisted with the order, so a book restored from
+// the database lines its queues up exactly as they were.
+
+// ahead reports whether a level at price a has priority over one at price b on the given side
+func ahead(bids bool, a, b models.Decimal) bool {
+	if bids {
+		return a > b
+	}
+	return a < b
+}
+
+// levelIndex returns the position of price among a side's levels and whether a level with that
+// price exists there; otherwise the position is where such a level belongs
+func levelIndex(entries []*models.OrderBookEntry, bids bool, price models.Decimal) (int, bool) {
+	i := sort.Search(len(entries), func(i int) bool {
+		return !ahead(bids, entries[i].Price, price)
+	})
+	return i, i < len(entries) && entries[i].Price == price
+}
+
+// findLevel returns the level of a side holding price, nil when there is none
+func findLevel(entries []*models.OrderBookEntry, bids bool, price models.Decimal) *models.OrderBookEntry {
+	if i, ok := levelIndex(entries, bids, price); ok {
+		return entries[i]
+	}
+	return nil
+}
+
+// insertLevel inserts a new level at position i, keeping the side in priority order
+func insertLevel(entries []*models.OrderBookEntry, i int, entry *models.OrderBookEntry) []*models.OrderBookEntry {
+	entries = append(entries, nil)
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	return entries
+}
+
+// bestLevels returns up to levels of a side's non-empty levels in priority order, all of them
+// when levels is 0
+func bestLevels(entries []*models.OrderBookEntry, levels int) []*models.OrderBookEntry {
+	size := len(entries)
+	if levels > 0 && levels < size {
+		size = levels
+	}
+	best := make([]*models.OrderBookEntry, 0, size)
+	for _, entry := range entries {
+		if levels > 0 && len(best) == levels {
+			break
+		}
+		if len(entry.Orders) > 0 {
+			best = append(best, entry)
+		}
+	}
+	return best
+}
+
+// enqueue inserts an order into a level's queue after every order with an equal or lower
+// sequence. New arrivals carry the highest sequence, so this is an append in all but restores.
+func enqueue(orders []*models.Order, order *models.Order) []*models.Order {
+	i := len(orders)
+	for i > 0 && orders[i-1].Sequence > order.Sequence {
+		i--
+	}
+	orders = append(orders, nil)
+	copy(orders[i+1:], orders[i:])
+	orders[i] = order
+	return orders
+}
+
+// loadSequence resumes the time priority sequence after the highest one stored
+func (c *core) loadSequence(ctx context.Context) {
+	sequence, err := c.repo.GetMaxOrderSequence(ctx)
+	if err != nil {
+		c.logger.Error("Failed to load order sequence", zap.Error(err))
+		return
+	}
+	c.sequence.Store(sequence)
+}
+
+// nextSequence returns a new time priority sequence, later than every one assigned before
+func (c *core) nextSequence() uint64 {
+	return c.sequence.Add(1)
+}
+
+// raiseSequence makes sure sequences assigned from now on are later than a restored order's
+func (c *core) raiseSequence(sequence uint64) {
+	for {
+		current := c.sequence.Load()
+		if current >= sequence || c.sequence.CompareAndSwap(current, sequence) {
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"orderSystem/internal/models"
+	"slices"
+	"testing"
+)
+
+// sideOf builds a side's levels from prices given in priority order, each level with one order
+func sideOf(prices ...float64) []*models.OrderBookEntry {
+	entries := make([]*models.OrderBookEntry, len(prices))
+	for i, price := range prices {
+		entries[i] = &models.OrderBookEntry{Price: models.NewDecimal(price), Orders: []*models.Order{{}}}
+	}
+	return entries
+}
+
+// pricesOf returns the prices of a side's levels in the order they are kept
+func pricesOf(entries []*models.OrderBookEntry) []models.Decimal {
+	prices := make([]models.Decimal, len(entries))
+	for i, entry := range entries {
+		prices[i] = entry.Price
+	}
+	return prices
+}
+
+func TestAhead(t *testing.T) {
+	low, high := models.NewDecimal(99), models.NewDecimal(101)
+	tests := []struct {
+		name string
+		bids bool
+		a, b models.Decimal
+		want bool
+	}{
+		{"higher bid", true, high, low, true},
+		{"lower bid", true, low, high, false},
+		{"lower ask", false, low, high, true},
+		{"higher ask", false, high, low, false},
+		{"equal bid", true, low, low, false},
+		{"equal ask", false, low, low, false},
+	}
+	for _, tt := range tests {
+		if got := ahead(tt.bids, tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: ahead(%t, %v, %v) = %t, want %t", tt.name, tt.bids, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLevelIndex(t *testing.T) {
+	bids := sideOf(103, 101, 99)
+	asks := sideOf(99, 101, 103)
+	tests := []struct {
+		name    string
+		entries []*models.OrderBookEntry
+		bids    bool
+		price   float64
+		index   int
+		found   bool
+	}{
+		{"best bid", bids, true, 103, 0, true},
+		{"inner bid", bids, true, 101, 1, true},
+		{"worst bid", bids, true, 99, 2, true},
+		{"bid above the best", bids, true, 104, 0, false},
+		{"bid between levels", bids, true, 100, 2, false},
+		{"bid below the worst", bids, true, 98, 3, false},
+		{"best ask", asks, false, 99, 0, true},
+		{"worst ask", asks, false, 103, 2, true},
+		{"ask below the best", asks, false, 98, 0, false},
+		{"ask between levels", asks, false, 102, 2, false},
+		{"ask above the worst", asks, false, 104, 3, false},
+		{"empty side", nil, true, 100, 0, false},
+	}
+	for _, tt := range tests {
+		index, found := levelIndex(tt.entries, tt.bids, models.NewDecimal(tt.price))
+		if index != tt.index || found != tt.found {
+			t.Errorf("%s: levelIndex(%v) = %d, %t, want %d, %t", tt.name, tt.price, index, found, tt.index, tt.found)
+		}
+	}
+}
+
+func TestFindLevel(t *testing.T) {
+	asks := sideOf(99, 101)
+	if level := findLevel(asks, false, models.NewDecimal(101)); level != asks[1] {
+		t.Errorf("findLevel(101) = %v, want the 101 level", level)
+	}
+	if level := findLevel(asks, false, models.NewDecimal(100)); level != nil {
+		t.Errorf("findLevel(100) = %v, want nil", level)
+	}
+	if level := findLevel(nil, true, models.NewDecimal(100)); level != nil {
+		t.Errorf("findLevel on an empty side = %v, want nil", level)
+	}
+}
+
+// TestInsertLevelKeepsPriorityOrder inserts levels at shuffled prices where levelIndex places
+// them and checks each side comes out sorted best first, without duplicate levels
+func TestInsertLevelKeepsPriorityOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, bids := range []bool{true, false} {
+		var entries []*models.OrderBookEntry
+		var want []models.Decimal
+		for _, n := range random.Perm(50) {
+			price := models.NewDecimal(float64(90 + n%25)) // repeats every price at least once
+			i, found := levelIndex(entries, bids, price)
+			if found {
+				continue
+			}
+			entries = insertLevel(entries, i, &models.OrderBookEntry{Price: price})
+			want = append(want, price)
+		}
+		slices.Sort(want)
+		if bids {
+			slices.Reverse(want)
+		}
+		if got := pricesOf(entries); !slices.Equal(got, want) {
+			t.Errorf("bids=%t: levels %v, want %v", bids, got, want)
+		}
+	}
+}
+
+func TestBestLevels(t *testing.T) {
+	asks := sideOf(99, 100, 101, 102)
+	asks[1].Orders = nil // emptied by a fill, not yet removed
+	tests := []struct {
+		levels int
+		want   []float64
+	}{
+		{0, []float64{99, 101, 102}},
+		{1, []float64{99}},
+		{2, []float64{99, 101}},
+		{10, []float64{99, 101, 102}},
+	}
+	for _, tt := range tests {
+		want := make([]models.Decimal, len(tt.want))
+		for i, price := range tt.want {
+			want[i] = models.NewDecimal(price)
+		}
+		if got := pricesOf(bestLevels(asks, tt.levels)); !slices.Equal(got, want) {
+			t.Errorf("bestLevels(%d) = %v, want %v", tt.levels, got, want)
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	var orders []*models.Order
+	for _, sequence := range []uint64{2, 5, 1, 4, 3} {
+		orders = enqueue(orders, &models.Order{Sequence: sequence})
+	}
+	for i, order := range orders {
+		if order.Sequence != uint64(i+1) {
+			t.Fatalf("queue position %d holds sequence %d, want %d", i, order.Sequence, i+1)
+		}
+	}
+}
+
+// TestBuyWalksAsksBestFirst sweeps three ask levels rested out of price order with one buy, which
+// must fill them from the lowest price up and leave only the level it did not reach
+func TestBuyWalksAsksBestFirst(t *testing.T) {
+	s, _ := newTestService(t, testSetup{}, "BTCUSD")
+	ctx := context.Background()
+	for _, price := range []float64{102, 100, 103, 101} {
+		if _, err := s.PlaceOrder(ctx, limitOrder("BTCUSD", models.SideSell, price, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := s.PlaceOrder(ctx, limitOrder("BTCUSD", models.SideBuy, 102, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prices []models.Decimal
+	for _, trade := range result.Trades {
+		prices = append(prices, trade.Price)
+	}
+	want := []models.Decimal{models.NewDecimal(100), models.NewDecimal(101), models.NewDecimal(102)}
+	if !slices.Equal(prices, want) {
+		t.Errorf("trade prices %v, want %v", prices, want)
+	}
+
+	bids, asks, err := s.GetDepth("BTCUSD", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bids) != 0 || len(asks) != 1 || asks[0].Price != models.NewDecimal(103) {
+		t.Errorf("depth after the sweep: bids %v, asks %v, want only the 103 ask", bids, asks)
+	}
+}
@@ -0,0 +1,16 @@
+package service
+
+import "go.uber.org/zap"
+
+// Maintenance reports whether maintenance mode is on, in which the API refuses order entry and
+// cancels while market data keeps serving
+func (s *MatchingService) Maintenance() bool {
+	return s.maintenance.Load()
+}
+
+// SetMaintenance turns maintenance mode on or off
+func (s *MatchingService) SetMaintenance(on bool) {
+	if s.maintenance.Swap(on) != on {
+		s.logger.Warn("Maintenance mode changed", zap.Bool("maintenance", on))
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Ticker summarizes a symbol's top of book and last trade
+type Ticker struct {
+	Symbol      string
+	BestBid     models.NullDecimal
+	BestAsk     models.NullDecimal
+	LastPrice   models.NullDecimal
+	LastTradeAt time.Time
+}
+
+// RestingOrder is one visible order in a symbol's book as shown in order-level market data
+type RestingOrder struct {
+	OrderID   uint64
+	Price     models.Decimal
+	Quantity  models.Decimal // visible quantity only for iceberg orders
+	CreatedAt time.Time
+}
+
+// restingOrders lists the orders of the best price levels on one side of a symbol's book in priority order
+func restingOrders(entries []*models.OrderBookEntry, levels int) []RestingOrder {
+	var result []RestingOrder
+	for _, entry := range bestLevels(entries, levels) {
+		for _, order := range entry.Orders {
+			result = append(result, RestingOrder{
+				OrderID:   order.OrderID,
+				Price:     entry.Price,
+				Quantity:  visibleQuantity(order),
+				CreatedAt: order.CreatedAt,
+			})
+		}
+	}
+	return result
+}
+
+// knownSymbol checks market data is read for a configured or listed symbol, so that reads of
+// unknown symbols are refused rather than each starting an engine
+func (s *MatchingService) knownSymbol(symbol string) error {
+	if _, ok := s.symbolConfig(context.Background(), symbol); !ok {
+		return models.ErrSymbolNotFound
+	}
+	return nil
+}
+
+// GetBookOrders lists the individual orders of the best price levels of both sides of a symbol's in-memory book
+func (s *MatchingService) GetBookOrders(symbol string, levels int) (bids, asks []RestingOrder, err error) {
+	if err := s.knownSymbol(symbol); err != nil {
+		return nil, nil, err
+	}
+	err = s.engine(symbol).do(func(e *symbolEngine) error {
+		bids, asks = restingOrders(e.orderBook.Bids[symbol], levels), restingOrders(e.orderBook.Asks[symbol], levels)
+		return nil
+	})
+	return bids, asks, err
+}
+
+// GetDepth aggregates the best price levels of both sides of a symbol's in-memory book
+func (s *MatchingService) GetDepth(symbol string, levels int) (bids, asks []models.PriceLevel, err error) {
+	if err := s.knownSymbol(symbol); err != nil {
+		return nil, nil, err
+	}
+	err = s.engine(symbol).do(func(e *symbolEngine) error {
+		bids, asks = depthLevels(e.orderBook.Bids[symbol], levels), depthLevels(e.orderBook.Asks[symbol], levels)
+		return nil
+	})
+	return bids, asks, err
+}
+
+// GetRecentTrades retrieves a symbol's most recent trades, newest first
+func (s *MatchingService) GetRecentTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error) {
+	trades, err := s.repo.GetRecentTrades(ctx, symbol, limit)
+	if err != nil {
+		s.logger.Error("Failed to get recent trades", zap.Error(err))
+		return nil, err
+	}
+	return trades, nil
+}
+
+// GetTicker reports a symbol's best bid and ask and its last book trade, all kept by the engine
+// as orders rest and trade, so no book scan or database read is needed
+func (s *MatchingService) GetTicker(symbol string) (*Ticker, error) {
+	if err := s.knownSymbol(symbol); err != nil {
+		return nil, err
+	}
+	return call(s, symbol, func(e *symbolEngine) (*Ticker, error) {
+		ticker := &Ticker{Symbol: symbol}
+		if best := bestLevels(e.orderBook.Bids[symbol], 1); len(best) > 0 {
+			ticker.BestBid = models.NullDecimal{Decimal: best[0].Price, Valid: true}
+		}
+		if best := bestLevels(e.orderBook.Asks[symbol], 1); len(best) > 0 {
+			ticker.BestAsk = models.NullDecimal{Decimal: best[0].Price, Valid: true}
+		}
+		if price, ok := e.lastPrice[symbol]; ok {
+			ticker.LastPrice = models.NullDecimal{Decimal: price, Valid: true}
+			ticker.LastTradeAt = e.lastTradeAt[symbol]
+		}
+		return ticker, nil
+	})
+}
+
+// GetCandles retrieves a symbol's OHLCV bars of an interval opening within a time range
+func (s *MatchingService) GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]*models.Candle, error) {
+	candles, err := s.repo.GetCandles(ctx, symbol, interval, from, to, limit)
+	if err != nil {
+		s.logger.Error("Failed to get candles", zap.Error(err))
+		return nil, err
+	}
+	return candles, nil
+}
@@ -1,22 +1,28 @@
 package service
 
 import (
-	"database/sql"
+	"context"
+	"math"
+	"math/big"
+	"math/rand"
+	"orderSystem/internal/config"
+	"orderSystem/internal/ids"
+	"orderSystem/internal/metrics"
 	"orderSystem/internal/models"
 	"orderSystem/internal/repository"
 	"sort"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-// OrderBook manages the in-memory order book
+// OrderBook manages the in-memory order book. Each symbol's bids and asks are price levels kept
+// in priority order, best first.
 type OrderBook struct {
-	Bids  map[string][]*models.OrderBookEntry
-	Asks  map[string][]*models.OrderBookEntry
-	mutex sync.RWMutex
+	Bids      map[string][]*models.OrderBookEntry
+	Asks      map[string][]*models.OrderBookEntry
+	evictions uint64 // number of symbol sides released after going empty
 }
 
 // NewOrderBook initializes a new order book
@@ -27,335 +33,849 @@ func NewOrderBook() *OrderBook {
 	}
 }
 
-// MatchingService handles order matching logic
+// MatchingService handles order matching logic. Each symbol is matched by its own engine; the
+// service routes every request to the engine of the symbol it concerns.
 type MatchingService struct {
-	orderBook *OrderBook
-	repo      repository.Repository
-	logger    *zap.Logger
+	*core
 }
 
 // NewMatchingService creates a new matching service
-func NewMatchingService(repo repository.Repository, logger *zap.Logger) *MatchingService {
+func NewMatchingService(ctx context.Context, repo repository.Repository, cfg *config.Config, logger *zap.Logger) *MatchingService {
 	service := &MatchingService{
-		orderBook: NewOrderBook(),
-		repo:      repo,
-		logger:    logger,
+		core: &core{repo: repo, cfg: cfg, logger: logger, idgen: ids.NewGenerator(cfg.Engine.NodeID), sandbox: cfg.Sandbox,
+			pendingLooks: make(map[uint64]*pendingLook), engines: make(map[string]*symbolEngine)},
 	}
 
-	// Load open orders from database
-	orders, err := repo.GetOrderBook("BTC-USD") // TODO: Load for all symbols
+	service.maintenance.Store(cfg.Server.Maintenance)
+
+	// Open orders are loaded per symbol on first use; see ensureLoaded
+	service.loadSequence(ctx)
+	service.loadStops(ctx)
+	service.loadGroups(ctx)
+	service.loadFeeSchedules(ctx)
+	service.loadSymbols(ctx)
+
+	return service
+}
+
+// NextID returns a new exchange-wide ID, from the same generator as order and trade IDs
+func (s *MatchingService) NextID() uint64 {
+	return s.idgen.Next()
+}
+
+// PlaceOrderResult holds the outcome of placing an order
+type PlaceOrderResult struct {
+	Trades     []*models.Trade
+	Executions []*models.Execution // the placed order's own side of each trade
+
+	// Resubmitted is set when the order repeated an earlier one's client order ID; the order
+	// then holds the earlier order and Executions all of its executions so far
+	Resubmitted bool
+}
+
+// PlaceOrder processes a new order and attempts to match it. Stop orders are held off-book
+// until their trigger price is reached. An order repeating one its owner already placed under
+// the same client order ID is not placed again.
+func (s *MatchingService) PlaceOrder(ctx context.Context, order *models.Order) (*PlaceOrderResult, error) {
+	if order.Symbol == "" {
+		s.logger.Error("Invalid order parameters", zap.Any("order", order))
+		return nil, models.ErrInvalidOrder
+	}
+	unlock, err := s.lockClientOrder(ctx, order)
 	if err != nil {
-		logger.Error("Failed to load order book", zap.Error(err))
-	} else {
-		for _, order := range orders {
-			service.addToOrderBook(order)
+		return nil, err
+	}
+	defer unlock()
+	if existing, err := s.resubmission(ctx, order); err != nil || existing != nil {
+		if err != nil {
+			return nil, err
 		}
+		return s.replay(ctx, order, existing)
 	}
-
-	return service
+	if err := s.checkCredit(ctx, order); err != nil {
+		s.recordRejection(ctx, order, err)
+		return nil, err
+	}
+	s.delayAck()
+	result, err := callContext(ctx, s, order.Symbol, func(e *symbolEngine) (*PlaceOrderResult, error) {
+		submitted := *order
+		e.journalPlacement(func() *models.JournalEntry {
+			submitted.OrderID, submitted.CreatedAt = order.OrderID, order.CreatedAt
+			return &models.JournalEntry{Kind: models.JournalPlace, Orders: []*models.Order{&submitted}}
+		})
+		result, err := e.placeOrder(order)
+		e.countFlow(FlowNew, err)
+		return result, err
+	})
+	if err == repository.ErrDuplicateKey && order.ClientOrderID.Valid {
+		// A concurrent submission with the same client order ID was saved first
+		existing, lookupErr := s.resubmission(ctx, order)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if existing != nil {
+			return s.replay(ctx, order, existing)
+		}
+	}
+	if err != nil {
+		s.recordRejection(ctx, order, err)
+	}
+	return result, err
 }
 
-// PlaceOrder processes a new order and attempts to match it
-func (s *MatchingService) PlaceOrder(order *models.Order) ([]*models.Trade, error) {
-	s.orderBook.mutex.Lock()
-	defer s.orderBook.mutex.Unlock()
+// placeOrder validates and executes or holds a new order
+func (e *symbolEngine) placeOrder(order *models.Order) (*PlaceOrderResult, error) {
+	if err := e.prepareOrder(order); err != nil {
+		return nil, err
+	}
+	if isStop(order) {
+		return e.placeStop(order)
+	}
+	if e.inAuction(order.Symbol) && (order.Type == models.TypeMarket || !rests(order)) {
+		e.logger.Warn("Order rejected during auction", zap.String("symbol", order.Symbol))
+		return nil, models.ErrSymbolInAuction
+	}
+	result, err := e.executeOrder(order, true)
+	if err != nil {
+		return nil, err
+	}
+	e.triggerStops(order.Symbol)
+	return result, nil
+}
 
+// prepareOrder assigns a new order its ID, unless it was accepted with one already, and its
+// initial state and validates its parameters and the risk limits
+func (e *symbolEngine) prepareOrder(order *models.Order) error {
 	// Assign order ID and initialize fields
-	order.OrderID = uint64(uuid.New().ID())
+	if order.OrderID == 0 {
+		order.OrderID = e.idgen.Next()
+	}
 	order.Status = models.StatusOpen
 	order.CreatedAt = time.Now()
+	order.Sequence = e.nextSequence()
 
-	// Validate order parameters
+	// Validate order parameters; a quote-sized order is sized within its protection first
+	if !validProtection(order) {
+		e.logger.Error("Invalid price protection", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	e.protect(order)
+	if err := e.sizeQuoteOrder(order); err != nil {
+		return err
+	}
 	if order.Symbol == "" || order.InitialQuantity <= 0 {
-		s.logger.Error("Invalid order parameters", zap.Any("order", order))
-		return nil, models.ErrInvalidOrder
+		e.logger.Error("Invalid order parameters", zap.Any("order", order))
+		return models.ErrInvalidOrder
 	}
-	if order.Type == models.TypeLimit && (!order.Price.Valid || order.Price.Float64 <= 0) {
-		s.logger.Error("Invalid price for limit order", zap.Any("order", order))
-		return nil, models.ErrInvalidOrder
+	if order.Type != models.TypeLimit && order.Type != models.TypeMarket &&
+		order.Type != models.TypeStop && order.Type != models.TypeStopLimit {
+		e.logger.Error("Invalid order type", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	if isLimitPriced(order) && (!order.Price.Valid || order.Price.Decimal <= 0) {
+		e.logger.Error("Invalid price for limit order", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	if !isLimitPriced(order) {
+		order.Price = models.NullDecimal{} // Market orders have no price
+	}
+	if isStop(order) != (order.TriggerPrice.Valid && order.TriggerPrice.Decimal > 0) {
+		e.logger.Error("Invalid trigger price", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	if err := e.checkListing(order); err != nil {
+		return err
+	}
+	if offTick(order, e.tickSize) {
+		e.logger.Warn("Order price off the tick grid", zap.Any("order", order), zap.Stringer("tick_size", e.tickSize))
+		return models.ErrOffTick
+	}
+	if order.Type == models.TypeLimit && !e.withinBand(order.Price.Decimal) {
+		e.logger.Warn("Order price outside the price band", zap.Any("order", order))
+		return models.ErrPriceBand
+	}
+	if order.TimeInForce == "" {
+		order.TimeInForce = models.TIFGTC
+	}
+	if order.TimeInForce != models.TIFGTC && order.TimeInForce != models.TIFIOC && order.TimeInForce != models.TIFFOK &&
+		order.TimeInForce != models.TIFGTD {
+		e.logger.Error("Invalid time in force", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	if (order.TimeInForce == models.TIFGTD) != order.ExpireAt.Valid {
+		e.logger.Error("Invalid expiry time", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	if isIceberg(order) && (!isLimitPriced(order) || !rests(order) ||
+		order.DisplayQuantity.Decimal <= 0 || order.DisplayQuantity.Decimal > order.InitialQuantity) {
+		e.logger.Error("Invalid display quantity", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	if err := e.checkRiskLimits(order); err != nil {
+		e.logger.Warn("Order rejected by risk limits", zap.Any("order", order))
+		return err
+	}
+	return nil
+}
+
+// isLimitPriced reports whether an order executes with a limit price once active
+func isLimitPriced(order *models.Order) bool {
+	return order.Type == models.TypeLimit || order.Type == models.TypeStopLimit
+}
+
+// rests reports whether an order's time in force lets its unfilled remainder rest on the book
+func rests(order *models.Order) bool {
+	return order.TimeInForce == models.TIFGTC || order.TimeInForce == models.TIFGTD
+}
+
+// executeOrder matches an active order against the book within one transaction and rests any
+// remainder. New orders are inserted; triggered stop orders already exist and are updated.
+// A transaction that loses a deadlock or times out waiting for a lock is rolled back along with
+// its in-memory book changes and retried from scratch, failing with ErrContention once the
+// retries run out. The matches it would make with designated liquidity providers are first
+// offered to them for a last look, and those they reject are skipped.
+func (e *symbolEngine) executeOrder(order *models.Order, isNew bool) (*PlaceOrderResult, error) {
+	defer metrics.Since(metrics.MatchLatency.WithLabelValues(e.symbol), time.Now())
+	e.declined = e.lastLook(order)
+	e.journalLookDeclines(order)
+	defer func() { e.declined, e.journalDeclines = nil, nil }()
+	for attempt := 0; ; attempt++ {
+		e.beginUndo()
+		e.journalOrder(order)
+		result, err := e.executeOrderTx(order, isNew)
+		e.endUndo(err == nil)
+		if err == nil {
+			return result, nil
+		}
+
+		err = repository.Classify(err)
+		if err != repository.ErrDeadlock && err != repository.ErrLockWaitTimeout {
+			return nil, err
+		}
+		if attempt == e.cfg.Engine.DeadlockRetries {
+			e.logger.Error("Matching transaction kept conflicting, giving up", zap.Uint64("order_id", order.OrderID),
+				zap.Int("attempts", attempt+1), zap.Error(err))
+			return nil, models.ErrContention
+		}
+		delay := retryDelay(e.cfg.Engine.DeadlockBackoff, attempt)
+		e.logger.Warn("Matching transaction conflicted, retrying", zap.Uint64("order_id", order.OrderID),
+			zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+		time.Sleep(delay)
 	}
-	if order.Type == models.TypeMarket {
-		order.Price = sql.NullFloat64{Valid: false} // Market orders have no price
+}
+
+// retryDelay returns the jittered exponential backoff before a retry: a random delay between
+// half and all of base doubled once per earlier attempt
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	d := base << attempt
+	if d <= 0 {
+		return 0
 	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// executeOrderTx runs one attempt of executeOrder. Callers must journal book changes.
+func (e *symbolEngine) executeOrderTx(order *models.Order, isNew bool) (*PlaceOrderResult, error) {
+	auction := e.inAuction(order.Symbol)
 
-	// Begin database transaction
-	tx, err := s.repo.BeginTx()
+	// Begin database transaction on the shard holding the symbol
+	tx, err := e.beginTx(order.Symbol)
 	if err != nil {
-		s.logger.Error("Failed to start transaction", zap.Error(err))
+		e.logger.Error("Failed to start transaction", zap.Error(err))
 		return nil, err
 	}
 	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("match"), time.Now())
+
+	// Hold what new and amended orders consume; triggered stops hold it from placement
+	if isNew || !isStop(order) {
+		if err := e.reserveTx(tx, order); err != nil {
+			return nil, err
+		}
+	}
 
 	// Save order to database
-	if err := s.repo.SaveOrderTx(tx, order); err != nil {
-		s.logger.Error("Failed to save order", zap.Error(err))
-		return nil, err
+	if isNew {
+		if err := e.saveOrderTx(tx, order); err != nil {
+			e.logger.Error("Failed to save order", zap.Error(err))
+			return nil, err
+		}
 	}
 
-	// Match order
+	// Match order; during an auction it only rests until the uncrossing
 	var trades []*models.Trade
 	remainingQty := order.RemainingQuantity
-	if order.Type == models.TypeMarket {
-		trades, remainingQty, err = s.matchMarketOrder(tx, order)
-	} else {
-		trades, remainingQty, err = s.matchLimitOrder(tx, order)
+	capped, cut := false, false
+	// Fill-or-kill orders that cannot fill completely are canceled without trading
+	killed := order.TimeInForce == models.TIFFOK && e.fillableQuantity(order) < order.RemainingQuantity
+	if !auction && !killed {
+		trades, remainingQty, capped, cut, err = e.matchOrder(tx, order)
+		if err != nil {
+			e.logger.Error("Matching failed", zap.Error(err))
+			return nil, err
+		}
 	}
-	if err != nil {
-		s.logger.Error("Matching failed", zap.Error(err))
-		return nil, err
+	if capped {
+		e.logger.Warn("Order reached book walk cap", zap.Uint64("order_id", order.OrderID),
+			zap.Int("fills", len(trades)), zap.Stringer("remaining", remainingQty))
 	}
 
-	// Update order status and quantity
+	// Update order status and quantity; a triggered stop is the engine's doing
+	change := models.OrderChange{Actor: order.OwnerID}
+	if !isNew && isStop(order) {
+		change.Actor = models.ActorEngine
+	}
 	order.RemainingQuantity = remainingQty
 	if order.RemainingQuantity == 0 {
 		order.Status = models.StatusFilled
-	} else if order.Type == models.TypeMarket {
+	} else if !isLimitPriced(order) && e.protectionReached(order) {
+		order.Status = models.StatusCanceled
+		change.Reason = models.ReasonProtection
+	} else if !isLimitPriced(order) || !rests(order) {
 		order.Status = models.StatusCanceled
+		change.Reason = models.ReasonUnfilled
+	} else if (capped || cut) && e.cfg.Engine.WalkCapAction == config.WalkCapCancel {
+		order.Status = models.StatusCanceled
+		change.Reason = models.ReasonWalkCap
+	} else {
+		order.Status = models.RestingStatus(order)
 	}
-	if err := s.repo.UpdateOrderTx(tx, order); err != nil {
-		s.logger.Error("Failed to update order", zap.Error(err))
+	if err := e.updateOrderTx(tx, order, change); err != nil {
+		e.logger.Error("Failed to update order", zap.Error(err))
 		return nil, err
 	}
 
-	// Save trades
+	// Save trades along with an execution and fee for each side
+	result := &PlaceOrderResult{Trades: trades}
 	for _, trade := range trades {
-		if err := s.repo.SaveTradeTx(tx, trade); err != nil {
-			s.logger.Error("Failed to save trade", zap.Error(err))
+		if err := e.repo.SaveTradeTx(e.ctx, tx, trade); err != nil {
+			e.logger.Error("Failed to save trade", zap.Error(err))
+			return nil, err
+		}
+		for _, execution := range newExecutions(trade, order.OrderID) {
+			if err := e.repo.SaveExecutionTx(e.ctx, tx, execution); err != nil {
+				e.logger.Error("Failed to save execution", zap.Error(err))
+				return nil, err
+			}
+			if err := e.chargeFeeTx(e.ctx, tx, trade, execution); err != nil {
+				e.logger.Error("Failed to record fee", zap.Error(err))
+				return nil, err
+			}
+			if execution.OrderID == order.OrderID {
+				result.Executions = append(result.Executions, execution)
+			}
+		}
+	}
+	if err := e.settleTradesTx(e.ctx, tx, trades); err != nil {
+		e.logger.Error("Failed to settle trades", zap.Error(err))
+		return nil, err
+	}
+	if order.Side == models.SideBuy && !isLimitPriced(order) && len(trades) > 0 {
+		if err := e.coverTx(tx, order); err != nil {
 			return nil, err
 		}
 	}
 
+	// Cancel the other orders of any group one of whose orders filled or triggered
+	groups, err := e.completeGroupsTx(tx, firedLegs(order, !isNew && isStop(order), trades))
+	if err != nil {
+		e.logger.Error("Failed to complete order groups", zap.Error(err))
+		return nil, err
+	}
+
 	// Add to order book if limit order and still open
-	if order.Type == models.TypeLimit && order.Status == models.StatusOpen {
-		s.addToOrderBook(order)
+	if isLimitPriced(order) && order.Status.Resting() {
+		e.addToOrderBook(order)
+		e.joinQueue(order)
 	}
 
 	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		s.logger.Error("Failed to commit transaction", zap.Error(err))
+	if err := e.commit(tx, trades); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
 		return nil, err
 	}
+	e.recordVolume(trades)
+	e.finishGroups(groups)
 
-	return trades, nil
+	return result, nil
 }
 
-// matchLimitOrder matches a limit order against the order book
-func (s *MatchingService) matchLimitOrder(tx *sql.Tx, order *models.Order) ([]*models.Trade, float64, error) {
-	var trades []*models.Trade
-	remainingQty := order.RemainingQuantity
-	oppositeSide := s.orderBook.Asks[order.Symbol]
-	if order.Side == models.SideSell {
-		oppositeSide = s.orderBook.Bids[order.Symbol]
+// recordVolume adds executed trades to the per-symbol traded volume and the trade feed
+func (e *symbolEngine) recordVolume(trades []*models.Trade) {
+	e.announceTrades(trades)
+	for _, trade := range trades {
+		e.tradedVolume[trade.Symbol] += trade.Quantity
+		e.lastPrice[trade.Symbol] = trade.Price
+		e.lastTradeAt[trade.Symbol] = trade.CreatedAt
 	}
+	e.checkCircuitBreaker(trades)
+}
 
-	// Sort opposite side by price (bids: descending, asks: ascending)
-	sort.Slice(oppositeSide, func(i, j int) bool {
-		if order.Side == models.SideSell {
-			return oppositeSide[i].Price > oppositeSide[j].Price
-		}
-		return oppositeSide[i].Price < oppositeSide[j].Price
-	})
+// TradedVolume returns the cumulative quantity traded in a symbol since startup
+func (s *MatchingService) TradedVolume(symbol string) models.Decimal {
+	var volume models.Decimal
+	if engine := s.existingEngine(symbol); engine != nil {
+		engine.inspect(func(e *symbolEngine) { volume = e.tradedVolume[symbol] })
+	}
+	return volume
+}
 
-	for _, entry := range oppositeSide {
-		if remainingQty == 0 {
-			break
+// LastPrice returns the last trade price of a symbol known to the engine
+func (s *MatchingService) LastPrice(symbol string) (models.Decimal, bool) {
+	var price models.Decimal
+	var ok bool
+	if engine := s.existingEngine(symbol); engine != nil {
+		engine.inspect(func(e *symbolEngine) { price, ok = e.lastPrice[symbol] })
+	}
+	return price, ok
+}
+
+// tradeTime returns the time of a trade printed now, at the microsecond precision trades are
+// stored with, so a trade reads back as it was reported
+func tradeTime() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// newExecutions creates the buy and sell executions of a trade, each with a distinct execution ID
+func newExecutions(trade *models.Trade, takerOrderID uint64) []*models.Execution {
+	execution := func(orderID uint64, side models.OrderSide) *models.Execution {
+		liquidity := models.LiquidityMaker
+		if orderID == takerOrderID {
+			liquidity = models.LiquidityTaker
 		}
-		if (order.Side == models.SideBuy && entry.Price > order.Price.Float64) ||
-			(order.Side == models.SideSell && entry.Price < order.Price.Float64) {
-			continue
+		return &models.Execution{
+			ExecID:    uuid.NewString(),
+			TradeID:   trade.TradeID,
+			OrderID:   orderID,
+			Symbol:    trade.Symbol,
+			Side:      side,
+			Liquidity: liquidity,
+			Price:     trade.Price,
+			Quantity:  trade.Quantity,
+			CreatedAt: trade.CreatedAt,
 		}
+	}
+	return []*models.Execution{
+		execution(trade.BuyOrderID, models.SideBuy),
+		execution(trade.SellOrderID, models.SideSell),
+	}
+}
 
-		for _, restingOrder := range entry.Orders {
-			if remainingQty == 0 {
-				break
-			}
-			matchQty := min(remainingQty, restingOrder.RemainingQuantity)
-			tradePrice := restingOrder.Price.Float64
-			trade := &models.Trade{
-				TradeID:     uint64(uuid.New().ID()),
-				Symbol:      order.Symbol,
-				BuyOrderID:  order.OrderID,
-				SellOrderID: restingOrder.OrderID,
-				Price:       tradePrice,
-				Quantity:    matchQty,
-				CreatedAt:   time.Now(),
-			}
-			if order.Side == models.SideSell {
-				trade.BuyOrderID, trade.SellOrderID = restingOrder.OrderID, order.OrderID
-			}
+// checkRiskLimits enforces the configured per-order quantity and notional limits
+func (c *core) checkRiskLimits(order *models.Order) error {
+	risk := c.cfg.Risk
+	if risk.MaxOrderQuantity > 0 && order.InitialQuantity > models.NewDecimal(risk.MaxOrderQuantity) {
+		return models.ErrRiskLimitExceeded
+	}
+	if risk.MaxOrderNotional > 0 && order.Price.Valid &&
+		models.Notional(order.Price.Decimal, order.InitialQuantity) > risk.MaxOrderNotional {
+		return models.ErrRiskLimitExceeded
+	}
+	if risk.MaxOrderNotional > 0 && order.QuoteQuantity.Valid && order.QuoteQuantity.Decimal.Float64() > risk.MaxOrderNotional {
+		return models.ErrRiskLimitExceeded
+	}
+	return nil
+}
 
-			trades = append(trades, trade)
-			remainingQty -= matchQty
-			restingOrder.RemainingQuantity -= matchQty
+// crosses reports whether a book level is at or better than an order's limit price
+func crosses(order *models.Order, levelPrice models.Decimal) bool {
+	limit := order.Price
+	if !isLimitPriced(order) {
+		limit = order.ProtectionPrice
+	}
+	if !limit.Valid {
+		return true
+	}
+	if order.Side == models.SideBuy {
+		return levelPrice <= limit.Decimal
+	}
+	return levelPrice >= limit.Decimal
+}
 
-			if restingOrder.RemainingQuantity == 0 {
-				restingOrder.Status = models.StatusFilled
-			}
-			if err := s.repo.UpdateOrderTx(tx, restingOrder); err != nil {
-				s.logger.Error("Failed to update resting order", zap.Error(err))
-				return nil, 0, err
-			}
-			s.removeFromOrderBook(restingOrder)
+// validProtection reports whether an order is unprotected or is a market or stop order with
+// either a positive protection price or a maximum slippage under 100%
+func validProtection(order *models.Order) bool {
+	protection, slippage := order.ProtectionPrice, order.MaxSlippage
+	if !protection.Valid && !slippage.Valid {
+		return true
+	}
+	if isLimitPriced(order) || protection.Valid == slippage.Valid {
+		return false
+	}
+	if protection.Valid {
+		return protection.Decimal > 0
+	}
+	return slippage.Decimal > 0 && slippage.Decimal < models.DecimalFromInt(100)
+}
+
+// protect turns an order's maximum slippage into its protection price, that percentage worse
+// than a stop's trigger price or else the best opposite price, snapped onto the tick grid
+// towards the reference. A market order meeting an empty book is left unprotected.
+func (e *symbolEngine) protect(order *models.Order) {
+	if !order.MaxSlippage.Valid || order.ProtectionPrice.Valid {
+		return
+	}
+	reference := order.TriggerPrice.Decimal
+	if !isStop(order) {
+		best := bestLevels(e.bookSide(order.Side == models.SideSell)[order.Symbol], 1)
+		if len(best) == 0 {
+			return
 		}
+		reference = best[0].Price
 	}
+	price := slippagePrice(reference, order.MaxSlippage.Decimal, order.Side == models.SideBuy)
+	if e.tickSize > 0 {
+		price = snapTick(price, e.tickSize, order.Side == models.SideSell)
+	}
+	order.ProtectionPrice = models.NullDecimal{Decimal: price, Valid: true}
+}
 
-	return trades, remainingQty, nil
+// slippagePrice returns the price pct percent above a reference price, or below it unless up.
+// It is worked out exactly, so a high reference cannot overflow, and kept within the prices a
+// Decimal holds: at most the largest Decimal and at least its smallest positive unit.
+func slippagePrice(reference, pct models.Decimal, up bool) models.Decimal {
+	price := new(big.Int).Mul(big.NewInt(int64(reference)), big.NewInt(int64(pct)))
+	price.Quo(price, big.NewInt(int64(models.DecimalFromInt(100))))
+	if !up {
+		price.Neg(price)
+	}
+	price.Add(price, big.NewInt(int64(reference)))
+	switch {
+	case price.Sign() <= 0:
+		return 1
+	case !price.IsInt64():
+		return math.MaxInt64
+	}
+	return models.Decimal(price.Int64())
 }
 
-// matchMarketOrder matches a market order against the order book
-func (s *MatchingService) matchMarketOrder(tx *sql.Tx, order *models.Order) ([]*models.Trade, float64, error) {
-	var trades []*models.Trade
-	remainingQty := order.RemainingQuantity
-	oppositeSide := s.orderBook.Asks[order.Symbol]
+// protectionReached reports whether an unfilled market order stopped short of the opposite
+// side's best level because it is beyond the order's protection price
+func (e *symbolEngine) protectionReached(order *models.Order) bool {
+	best := bestLevels(e.bookSide(order.Side == models.SideSell)[order.Symbol], 1)
+	return order.ProtectionPrice.Valid && len(best) > 0 && !crosses(order, best[0].Price)
+}
+
+// fillableQuantity returns how much of an order could execute immediately against the book,
+// up to the order's remaining quantity and honoring its limit price, the book walk caps and the
+// matches rejected on last look, without modifying anything
+func (e *symbolEngine) fillableQuantity(order *models.Order) models.Decimal {
+	oppositeSide := e.orderBook.Asks[order.Symbol]
 	if order.Side == models.SideSell {
-		oppositeSide = s.orderBook.Bids[order.Symbol]
+		oppositeSide = e.orderBook.Bids[order.Symbol]
 	}
 
-	// Sort opposite side by price (bids: descending, asks: ascending)
-	sort.Slice(oppositeSide, func(i, j int) bool {
-		if order.Side == models.SideSell {
-			return oppositeSide[i].Price > oppositeSide[j].Price
+	engine := e.cfg.Engine
+	var fillable models.Decimal
+	levels, fills := 0, 0
+	for _, entry := range bestLevels(oppositeSide, 0) {
+		if !crosses(order, entry.Price) {
+			break
 		}
-		return oppositeSide[i].Price < oppositeSide[j].Price
-	})
-
-	for _, entry := range oppositeSide {
-		if remainingQty == 0 {
+		if engine.MaxWalkLevels > 0 && levels == engine.MaxWalkLevels {
 			break
 		}
+		levels++
 
+		// Replay the level's queue so replenished iceberg slices count as further fills
+		type slot struct{ visible, remaining, display models.Decimal }
+		queue := make([]slot, 0, len(entry.Orders))
 		for _, restingOrder := range entry.Orders {
-			if remainingQty == 0 {
+			if e.declined[restingOrder.OrderID] {
+				continue
+			}
+			queue = append(queue, slot{visibleQuantity(restingOrder), restingOrder.RemainingQuantity,
+				restingOrder.DisplayQuantity.Decimal})
+		}
+		for len(queue) > 0 && fillable < order.RemainingQuantity {
+			if engine.MaxWalkFills > 0 && fills == engine.MaxWalkFills {
+				return fillable
+			}
+			next := queue[0]
+			queue = queue[1:]
+			fills++
+			fillable += next.visible
+			next.remaining -= next.visible
+			if next.remaining > 0 {
+				next.visible = min(next.display, next.remaining)
+				queue = append(queue, next)
+			}
+		}
+		if fillable >= order.RemainingQuantity {
+			return fillable
+		}
+	}
+	return fillable
+}
+
+// matchOrder matches an incoming order against the opposite side of the book. Limit orders only
+// match levels at or better than their price; market orders walk any level. The walk stops early
+// once the configured level or fill cap is reached, reporting capped so the caller can dispose
+// of the remainder. In sandbox mode it also stops, reporting cut, after a simulated partial fill
+// or on reaching an order with simulated interest still queued ahead of it, which takes the
+// incoming quantity it meets as another participant would. Resting orders whose providers
+// rejected the match on last look are passed over.
+func (e *symbolEngine) matchOrder(tx repository.Tx, order *models.Order) (trades []*models.Trade, remainingQty models.Decimal, capped, cut bool, err error) {
+	remainingQty = order.RemainingQuantity
+	opposite := e.bookSide(order.Side == models.SideSell)
+
+	engine := e.cfg.Engine
+	levels := 0
+	// Walk the opposite side from its best level. A level that fills completely is dropped
+	// from the side, moving the next level into position, so the index only advances past
+	// levels that still hold orders.
+	for next := 0; remainingQty > 0 && next < len(opposite[order.Symbol]); {
+		entry := opposite[order.Symbol][next]
+		if !crosses(order, entry.Price) {
+			break
+		}
+		if engine.MaxWalkLevels > 0 && levels == engine.MaxWalkLevels {
+			capped = true
+			break
+		}
+		levels++
+		e.journalLevel(entry)
+		e.touchLevel(order.Side == models.SideSell, entry.Price)
+
+		// Index loop because fills remove orders from the level and iceberg replenishment
+		// moves them to its back, both shifting the next order into position i
+		for i := 0; i < len(entry.Orders) && remainingQty > 0; {
+			restingOrder := entry.Orders[i]
+			if engine.MaxWalkFills > 0 && len(trades) == engine.MaxWalkFills {
+				capped = true
 				break
 			}
-			matchQty := min(remainingQty, restingOrder.RemainingQuantity)
-			tradePrice := restingOrder.Price.Float64
+			if e.declined[restingOrder.OrderID] {
+				i++
+				continue
+			}
+			e.journalOrder(restingOrder)
+			if ahead := e.queueAhead(order, restingOrder, time.Now()); ahead > 0 {
+				restingOrder.QueueAhead -= min(ahead, remainingQty)
+				cut = true
+				break
+			}
+			matchQty, partial := e.partialFill(order, min(remainingQty, visibleQuantity(restingOrder)))
+			tradePrice := restingOrder.Price.Decimal
 			trade := &models.Trade{
-				TradeID:     uint64(uuid.New().ID()),
+				TradeID:     e.idgen.Next(),
 				Symbol:      order.Symbol,
 				BuyOrderID:  order.OrderID,
 				SellOrderID: restingOrder.OrderID,
 				Price:       tradePrice,
 				Quantity:    matchQty,
-				CreatedAt:   time.Now(),
+				CreatedAt:   tradeTime(),
+				PrintType:   models.PrintBook,
+				BuyOwnerID:  order.OwnerID,
+				SellOwnerID: restingOrder.OwnerID,
 			}
 			if order.Side == models.SideSell {
 				trade.BuyOrderID, trade.SellOrderID = restingOrder.OrderID, order.OrderID
+				trade.BuyOwnerID, trade.SellOwnerID = restingOrder.OwnerID, order.OwnerID
 			}
 
 			trades = append(trades, trade)
 			remainingQty -= matchQty
 			restingOrder.RemainingQuantity -= matchQty
+			restingOrder.VisibleQuantity -= matchQty
 
+			refresh := isIceberg(restingOrder) && restingOrder.VisibleQuantity == 0 && restingOrder.RemainingQuantity > 0
 			if restingOrder.RemainingQuantity == 0 {
 				restingOrder.Status = models.StatusFilled
+			} else {
+				restingOrder.Status = models.StatusPartiallyFilled
+				if refresh {
+					restingOrder.Sequence = e.nextSequence()
+				}
 			}
-			if err := s.repo.UpdateOrderTx(tx, restingOrder); err != nil {
-				s.logger.Error("Failed to update resting order", zap.Error(err))
-				return nil, 0, err
+			if err := e.updateOrderTx(tx, restingOrder, models.OrderChange{Actor: models.ActorEngine}); err != nil {
+				e.logger.Error("Failed to update resting order", zap.Error(err))
+				return nil, 0, false, false, err
+			}
+
+			switch {
+			case restingOrder.Status == models.StatusFilled:
+				e.removeFromOrderBook(restingOrder)
+			case refresh:
+				replenish(restingOrder)
+				requeue(entry, restingOrder)
+			default:
+				i++
+			}
+			if partial {
+				cut = true
+				break
 			}
-			s.removeFromOrderBook(restingOrder)
+		}
+		if capped || cut {
+			break
+		}
+		if len(entry.Orders) > 0 {
+			next++
 		}
 	}
 
-	return trades, remainingQty, nil
+	return trades, remainingQty, capped, cut, nil
 }
 
 // addToOrderBook adds a limit order to the order book
-func (s *MatchingService) addToOrderBook(order *models.Order) {
-	side := s.orderBook.Bids
-	if order.Side == models.SideSell {
-		side = s.orderBook.Asks
-	}
-
-	entries, exists := side[order.Symbol]
-	if !exists {
-		entries = []*models.OrderBookEntry{}
-	}
-
-	for _, entry := range entries {
-		if entry.Price == order.Price.Float64 {
-			entry.Orders = append(entry.Orders, order)
-			side[order.Symbol] = entries
-			return
-		}
+func (e *symbolEngine) addToOrderBook(order *models.Order) {
+	e.journalOrder(order)
+	replenish(order)
+	bids := order.Side == models.SideBuy
+	side := e.bookSide(bids)
+	e.touchLevel(bids, order.Price.Decimal)
+
+	entries := side[order.Symbol]
+	i, exists := levelIndex(entries, bids, order.Price.Decimal)
+	if exists {
+		e.journalLevel(entries[i])
+		entries[i].Orders = enqueue(entries[i].Orders, order)
+		return
 	}
 
-	entries = append(entries, &models.OrderBookEntry{
-		Price:  order.Price.Float64,
+	e.journalSide(bids, order.Symbol)
+	side[order.Symbol] = insertLevel(entries, i, &models.OrderBookEntry{
+		Price:  order.Price.Decimal,
 		Orders: []*models.Order{order},
 	})
-	side[order.Symbol] = entries
 }
 
 // removeFromOrderBook removes an order from the order book
-func (s *MatchingService) removeFromOrderBook(order *models.Order) {
-	side := s.orderBook.Bids
-	if order.Side == models.SideSell {
-		side = s.orderBook.Asks
-	}
+func (e *symbolEngine) removeFromOrderBook(order *models.Order) {
+	bids := order.Side == models.SideBuy
+	side := e.bookSide(bids)
 
 	entries, exists := side[order.Symbol]
 	if !exists {
 		return
 	}
+	i, found := levelIndex(entries, bids, order.Price.Decimal)
+	if !found {
+		return
+	}
+	e.journalSide(bids, order.Symbol)
+	e.touchLevel(bids, order.Price.Decimal)
 
-	for i, entry := range entries {
-		if entry.Price == order.Price.Float64 {
-			for j, o := range entry.Orders {
-				if o.OrderID == order.OrderID {
-					entry.Orders = append(entry.Orders[:j], entry.Orders[j+1:]...)
-					if len(entry.Orders) == 0 {
-						entries = append(entries[:i], entries[i+1:]...)
-					}
-					break
-				}
+	entry := entries[i]
+	e.journalLevel(entry)
+	for j, o := range entry.Orders {
+		if o.OrderID == order.OrderID {
+			entry.Orders = shrink(append(entry.Orders[:j], entry.Orders[j+1:]...))
+			if len(entry.Orders) == 0 {
+				entries = shrink(append(entries[:i], entries[i+1:]...))
 			}
 			break
 		}
 	}
+
+	// Release the symbol entirely once its side is empty
+	if len(entries) == 0 {
+		delete(side, order.Symbol)
+		e.orderBook.evictions++
+		return
+	}
 	side[order.Symbol] = entries
 }
 
 // CancelOrder cancels an existing order
-func (s *MatchingService) CancelOrder(orderID uint64) error {
-	s.orderBook.mutex.Lock()
-	defer s.orderBook.mutex.Unlock()
+func (s *MatchingService) CancelOrder(ctx context.Context, orderID uint64) error {
+	engine, err := s.orderEngine(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return engine.doContext(ctx, func(e *symbolEngine) error {
+		e.journal(&models.JournalEntry{Kind: models.JournalCancel, OrderID: orderID, Change: requested})
+		err := e.cancelOrder(orderID, requested)
+		e.countFlow(FlowCancel, err)
+		return err
+	})
+}
+
+// requested is the change of an account canceling its own order
+var requested = models.OrderChange{Reason: models.ReasonRequested}
+
+// attributed returns a change made by the order's owner when it names no actor
+func attributed(change models.OrderChange, order *models.Order) models.OrderChange {
+	if change.Actor == "" {
+		change.Actor = order.OwnerID
+	}
+	return change
+}
 
-	order, err := s.repo.GetOrder(orderID)
+// cancelOrder cancels an order of the engine's symbol, recording the change; a change with no
+// actor is attributed to the order's owner
+func (e *symbolEngine) cancelOrder(orderID uint64, change models.OrderChange) error {
+	order, err := e.storedOrder(orderID)
 	if err != nil {
-		s.logger.Error("Failed to get order", zap.Error(err))
+		e.logger.Error("Failed to get order", zap.Error(err))
 		return err
 	}
-	if order.Status != models.StatusOpen {
-		s.logger.Warn("Attempt to cancel non-open order", zap.Uint64("order_id", orderID))
+	if !order.Status.Resting() && order.Status != models.StatusPending {
+		e.logger.Warn("Attempt to cancel non-open order", zap.Uint64("order_id", orderID))
 		return models.ErrOrderNotOpen
 	}
+	// Canceling one order of a group cancels the whole group
+	if g, ok := e.groupLegs[orderID]; ok {
+		return e.cancelGroup(g, change)
+	}
 
+	pending := order.Status == models.StatusPending
 	order.Status = models.StatusCanceled
-	if err := s.repo.UpdateOrder(order); err != nil {
-		s.logger.Error("Failed to update order status", zap.Error(err))
+	if err := e.updateOrder(order, attributed(change, order)); err != nil {
+		e.logger.Error("Failed to update order status", zap.Error(err))
 		return err
 	}
 
-	s.removeFromOrderBook(order)
-	s.logger.Info("Order canceled", zap.Uint64("order_id", orderID))
+	if pending {
+		e.removeStop(order)
+	} else {
+		e.removeFromOrderBook(order)
+	}
+	e.logger.Info("Order canceled", zap.Uint64("order_id", orderID))
 	return nil
 }
 
-// GetOrderBook retrieves the current order book for a symbol
-func (s *MatchingService) GetOrderBook(symbol string) ([]*models.Order, error) {
-	orders, err := s.repo.GetOrderBook(symbol)
+// updateOrder stores a change to an order of the engine's symbol in a transaction of its own,
+// journaled with the running command
+func (e *symbolEngine) updateOrder(order *models.Order, change models.OrderChange) error {
+	tx, err := e.beginTx(e.symbol)
 	if err != nil {
-		s.logger.Error("Failed to get order book", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+	if err := e.updateOrderTx(tx, order, change); err != nil {
+		return err
+	}
+	return e.commit(tx, nil)
+}
+
+// GetOrderBook retrieves the resting orders of a symbol's in-memory book in time priority order,
+// showing only the visible size of iceberg orders. The orders are copies, read on the symbol's
+// engine, so they are the book matching trades against.
+func (s *MatchingService) GetOrderBook(symbol string) ([]*models.Order, error) {
+	if err := s.knownSymbol(symbol); err != nil {
 		return nil, err
 	}
-	return orders, nil
+	return call(s, symbol, func(e *symbolEngine) ([]*models.Order, error) {
+		var orders []*models.Order
+		for _, side := range []map[string][]*models.OrderBookEntry{e.orderBook.Bids, e.orderBook.Asks} {
+			for _, entry := range side[symbol] {
+				for _, resting := range entry.Orders {
+					order := *resting
+					maskIceberg(&order, resting.VisibleQuantity)
+					orders = append(orders, &order)
+				}
+			}
+		}
+		sort.Slice(orders, func(i, j int) bool { return orders[i].Sequence < orders[j].Sequence })
+		return orders, nil
+	})
 }
 
-// GetTrades retrieves all trades for a symbol
-func (s *MatchingService) GetTrades(symbol string) ([]*models.Trade, error) {
-	trades, err := s.repo.GetTrades(symbol)
+// GetTrades retrieves a page of a symbol's trades
+func (s *MatchingService) GetTrades(ctx context.Context, filter models.TradeFilter) ([]*models.Trade, error) {
+	trades, err := s.repo.GetTrades(ctx, filter)
 	if err != nil {
 		s.logger.Error("Failed to get trades", zap.Error(err))
 		return nil, err
@@ -363,18 +883,58 @@ func (s *MatchingService) GetTrades(symbol string) ([]*models.Trade, error) {
 	return trades, nil
 }
 
+// GetExecutions retrieves all executions of an order
+func (s *MatchingService) GetExecutions(ctx context.Context, orderID uint64) ([]*models.Execution, error) {
+	if _, err := s.repo.GetOrder(ctx, orderID); err != nil {
+		s.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	executions, err := s.repo.GetExecutions(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to get executions", zap.Error(err))
+		return nil, err
+	}
+	return executions, nil
+}
+
+// GetOrderTrades retrieves every trade an order took part in, oldest first
+func (s *MatchingService) GetOrderTrades(ctx context.Context, orderID uint64) ([]*models.Trade, error) {
+	if _, err := s.repo.GetOrder(ctx, orderID); err != nil {
+		s.logger.Error("Failed to get order", zap.Error(err))
+		return nil, err
+	}
+	trades, err := s.repo.GetOrderTrades(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to get order trades", zap.Error(err))
+		return nil, err
+	}
+	return trades, nil
+}
+
 // GetOrder retrieves an order by ID
-func (s *MatchingService) GetOrder(orderID uint64) (*models.Order, error) {
-	order, err := s.repo.GetOrder(orderID)
+func (s *MatchingService) GetOrder(ctx context.Context, orderID uint64) (*models.Order, error) {
+	order, err := s.repo.GetOrder(ctx, orderID)
 	if err != nil {
 		s.logger.Error("Failed to get order", zap.Error(err))
 		return nil, err
 	}
+	s.withFills(ctx, order)
 	return order, nil
 }
 
-// min returns the minimum of two float64 values
-func min(a, b float64) float64 {
+// GetOrders retrieves an account's orders matching a filter, newest first
+func (s *MatchingService) GetOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	orders, err := s.repo.GetOrders(ctx, filter)
+	if err != nil {
+		s.logger.Error("Failed to get orders", zap.String("owner_id", filter.OwnerID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	s.withFills(ctx, orders...)
+	return orders, nil
+}
+
+// min returns the minimum of two decimals
+func min(a, b models.Decimal) models.Decimal {
 	if a < b {
 		return a
 	}
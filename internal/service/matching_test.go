@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"math"
+	"orderSystem/internal/models"
+	"slices"
+	"testing"
+)
+
+// placeAll places orders in turn, failing the test on the first error
+func placeAll(t *testing.T, s *MatchingService, orders ...*models.Order) {
+	t.Helper()
+	for _, order := range orders {
+		if _, err := s.PlaceOrder(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sellFills returns the IDs of the resting sells a buy filled against, in the order it filled them
+func sellFills(t *testing.T, s *MatchingService, buy *models.Order) []uint64 {
+	t.Helper()
+	result, err := s.PlaceOrder(context.Background(), buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var filled []uint64
+	for _, trade := range result.Trades {
+		filled = append(filled, trade.SellOrderID)
+	}
+	return filled
+}
+
+func TestSamePriceFillsInArrivalOrder(t *testing.T) {
+	s, _ := newTestService(t, testSetup{}, "BTCUSD")
+	first := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	second := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	third := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	placeAll(t, s, first, second, third)
+
+	want := []uint64{first.OrderID, second.OrderID, third.OrderID}
+	if filled := sellFills(t, s, limitOrder("BTCUSD", models.SideBuy, 100, 3)); !slices.Equal(filled, want) {
+		t.Errorf("filled sells %v, want %v", filled, want)
+	}
+}
+
+func TestBetterPriceFillsBeforeEarlierArrival(t *testing.T) {
+	s, _ := newTestService(t, testSetup{}, "BTCUSD")
+	early := limitOrder("BTCUSD", models.SideSell, 101, 1)
+	better := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	placeAll(t, s, early, better)
+
+	want := []uint64{better.OrderID, early.OrderID}
+	if filled := sellFills(t, s, limitOrder("BTCUSD", models.SideBuy, 101, 2)); !slices.Equal(filled, want) {
+		t.Errorf("filled sells %v, want %v", filled, want)
+	}
+}
+
+func TestQuantityIncreaseLosesPriority(t *testing.T) {
+	s, _ := newTestService(t, testSetup{}, "BTCUSD")
+	first := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	second := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	placeAll(t, s, first, second)
+	if _, err := s.AmendOrder(context.Background(), first.OrderID, models.NullDecimal{}, models.NewDecimal(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []uint64{second.OrderID, first.OrderID}
+	if filled := sellFills(t, s, limitOrder("BTCUSD", models.SideBuy, 100, 3)); !slices.Equal(filled, want) {
+		t.Errorf("filled sells %v, want %v", filled, want)
+	}
+}
+
+// TestRestoredBookKeepsPriority restarts the service on its database once an amendment has sent
+// the earliest order to the back of its level, so arrival time alone would misorder the restored
+// queue, and checks the restored queue and a new arrival fill in persisted sequence order
+func TestRestoredBookKeepsPriority(t *testing.T) {
+	s, repo := newTestService(t, testSetup{}, "BTCUSD")
+	first := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	second := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	placeAll(t, s, first, second)
+	if _, err := s.AmendOrder(context.Background(), first.OrderID, models.NullDecimal{}, models.NewDecimal(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := startTestService(t, repo, testSetup{}, "BTCUSD")
+	third := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	placeAll(t, restarted, third)
+	amended, err := repo.GetOrder(context.Background(), first.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Sequence <= amended.Sequence {
+		t.Errorf("sequence after restart %d, want above the restored %d", third.Sequence, amended.Sequence)
+	}
+
+	want := []uint64{second.OrderID, first.OrderID, third.OrderID}
+	if filled := sellFills(t, restarted, limitOrder("BTCUSD", models.SideBuy, 100, 4)); !slices.Equal(filled, want) {
+		t.Errorf("filled sells %v, want %v", filled, want)
+	}
+}
+
+// TestQueuedOrderHasOwner checks an order just placed with write-behind on resolves to its owner
+// before its queued writes reach the database
+func TestQueuedOrderHasOwner(t *testing.T) {
+	s, _ := newTestService(t, testSetup{writeBehind: true}, "BTCUSD")
+	order := limitOrder("BTCUSD", models.SideSell, 100, 1)
+	order.OwnerID = "owner"
+	placeAll(t, s, order)
+
+	owner, err := s.OrderOwner(context.Background(), order.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != "owner" {
+		t.Errorf("order owner = %q, want %q", owner, "owner")
+	}
+}
+
+func TestSlippagePriceStaysInRange(t *testing.T) {
+	high := models.Decimal(math.MaxInt64 / 4 * 3)
+	for _, c := range []struct {
+		reference, pct float64
+		up             bool
+		want           models.Decimal
+	}{
+		{100, 5, true, models.NewDecimal(105)},
+		{100, 5, false, models.NewDecimal(95)},
+		{100, 100, false, 1},
+	} {
+		if got := slippagePrice(models.NewDecimal(c.reference), models.NewDecimal(c.pct), c.up); got != c.want {
+			t.Errorf("%v%% from %v (up %t) = %s, want %s", c.pct, c.reference, c.up, got, c.want)
+		}
+	}
+	if got := slippagePrice(high, models.NewDecimal(99), true); got != math.MaxInt64 {
+		t.Errorf("99%% above %s = %s, want the largest Decimal", high, got)
+	}
+}
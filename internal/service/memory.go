@@ -0,0 +1,92 @@
+package service
+
+import (
+	"orderSystem/internal/models"
+	"unsafe"
+)
+
+// Approximate in-memory sizes used for book memory accounting
+const (
+	orderSize     = int(unsafe.Sizeof(models.Order{}))
+	entrySize     = int(unsafe.Sizeof(models.OrderBookEntry{}))
+	pointerSize   = int(unsafe.Sizeof(uintptr(0)))
+	sliceHeader   = int(unsafe.Sizeof([]*models.Order{}))
+	mapEntrySize  = int(unsafe.Sizeof("")) + sliceHeader
+	shrinkDivisor = 4
+)
+
+// BookMemoryStats describes the memory held by one symbol in the in-memory book
+type BookMemoryStats struct {
+	Symbol         string
+	BidLevels      int
+	AskLevels      int
+	Orders         int
+	EstimatedBytes int
+}
+
+// BookMemoryReport holds memory accounting for the whole in-memory book
+type BookMemoryReport struct {
+	Symbols        []BookMemoryStats
+	EstimatedBytes int
+	Evictions      uint64
+}
+
+// shrink reallocates a slice whose capacity has grown far beyond its length
+// so long-running processes give memory back after a burst of activity
+func shrink[T any](items []T) []T {
+	if cap(items) < shrinkDivisor || cap(items) < shrinkDivisor*len(items) {
+		return items
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return append(make([]T, 0, len(items)), items...)
+}
+
+// sideMemory estimates the bytes held by one side of a symbol's book
+func sideMemory(symbol string, entries []*models.OrderBookEntry) (levels, orders, bytes int) {
+	if entries == nil {
+		return 0, 0, 0
+	}
+	bytes = mapEntrySize + len(symbol) + cap(entries)*pointerSize
+	for _, entry := range entries {
+		bytes += entrySize + cap(entry.Orders)*pointerSize
+		for _, order := range entry.Orders {
+			bytes += orderSize + len(order.Symbol)
+		}
+		orders += len(entry.Orders)
+	}
+	return len(entries), orders, bytes
+}
+
+// BookMemory reports the estimated memory usage of the in-memory book per symbol
+func (s *MatchingService) BookMemory() *BookMemoryReport {
+	report := &BookMemoryReport{}
+	for _, engine := range s.allEngines() {
+		engine.inspect(func(e *symbolEngine) {
+			st, evictions := e.bookMemory()
+			report.Evictions += evictions
+			if st != nil {
+				report.Symbols = append(report.Symbols, *st)
+				report.EstimatedBytes += st.EstimatedBytes
+			}
+		})
+	}
+	return report
+}
+
+// bookMemory estimates the memory held by the engine's book, nil when both sides are empty,
+// along with the number of sides it has released
+func (e *symbolEngine) bookMemory() (*BookMemoryStats, uint64) {
+	bids, hasBids := e.orderBook.Bids[e.symbol]
+	asks, hasAsks := e.orderBook.Asks[e.symbol]
+	if !hasBids && !hasAsks {
+		return nil, e.orderBook.evictions
+	}
+	st := &BookMemoryStats{Symbol: e.symbol}
+	bidLevels, bidOrders, bidBytes := sideMemory(e.symbol, bids)
+	askLevels, askOrders, askBytes := sideMemory(e.symbol, asks)
+	st.BidLevels, st.AskLevels = bidLevels, askLevels
+	st.Orders, st.EstimatedBytes = bidOrders+askOrders, bidBytes+askBytes
+	return st, e.orderBook.evictions
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"orderSystem/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pricePoint is a trade price and when it printed
+type pricePoint struct {
+	price models.Decimal
+	at    time.Time
+}
+
+// withinBand reports whether a limit price lies within the symbol's price band around its
+// reference price, always when the band is off or there is no reference price yet
+func (e *symbolEngine) withinBand(price models.Decimal) bool {
+	band := e.cfg.Symbols[e.symbol].PriceBand
+	if band == 0 {
+		return true
+	}
+	reference, ok := e.referencePrice(e.symbol)
+	if !ok {
+		return true
+	}
+	deviation := price - reference
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	return deviation <= reference.Mul(models.NewDecimal(band))
+}
+
+// checkCircuitBreaker adds printed trades to the prices within the circuit breaker window and
+// halts the symbol once a trade moved more than the configured fraction from any price printed
+// within the window before it. The halted symbol resumes through ResumeSymbol, measuring moves
+// afresh from then on.
+func (e *symbolEngine) checkCircuitBreaker(trades []*models.Trade) {
+	sc := e.cfg.Symbols[e.symbol]
+	if sc.CircuitBreakerMove == 0 || len(trades) == 0 {
+		return
+	}
+	for _, trade := range trades {
+		cutoff := trade.CreatedAt.Add(-sc.CircuitBreakerWindow)
+		start := 0
+		for start < len(e.recentPrices) && e.recentPrices[start].at.Before(cutoff) {
+			start++
+		}
+		e.recentPrices = append(e.recentPrices[start:], pricePoint{trade.Price, trade.CreatedAt})
+	}
+
+	last := e.recentPrices[len(e.recentPrices)-1].price
+	for _, point := range e.recentPrices {
+		move := (last - point.price).Float64() / point.price.Float64()
+		if move < 0 {
+			move = -move
+		}
+		if move <= sc.CircuitBreakerMove {
+			continue
+		}
+		if err := e.setStatus(models.SymbolHalted); err != nil {
+			e.logger.Error("Failed to halt symbol on circuit breaker", zap.String("symbol", e.symbol), zap.Error(err))
+			return
+		}
+		e.logger.Warn("Circuit breaker tripped, trading halted", zap.String("symbol", e.symbol),
+			zap.Stringer("from", point.price), zap.Stringer("to", last), zap.Duration("window", sc.CircuitBreakerWindow))
+		e.recentPrices = nil
+		return
+	}
+}
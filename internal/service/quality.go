@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// QualityAlert names a market quality threshold a symbol has breached
+type QualityAlert string
+
+// Market quality alerts
+const (
+	AlertWideSpread QualityAlert = "wide_spread" // spread above the limit, or a side of the book is empty
+	AlertThinBids   QualityAlert = "thin_bids"   // bid notional within the band below the minimum
+	AlertThinAsks   QualityAlert = "thin_asks"   // ask notional within the band below the minimum
+)
+
+// bandDepth returns the visible notional of a side's levels priced no worse than limit
+func bandDepth(entries []*models.OrderBookEntry, bids bool, limit models.Decimal) float64 {
+	var notional float64
+	for _, entry := range entries {
+		if ahead(bids, limit, entry.Price) {
+			break
+		}
+		for _, order := range entry.Orders {
+			notional += models.Notional(entry.Price, visibleQuantity(order))
+		}
+	}
+	return notional
+}
+
+// MeasureMarketQuality measures the spread and near-touch depth of every configured symbol and
+// every other symbol with orders in the in-memory book
+func (s *MatchingService) MeasureMarketQuality() []*models.MarketQuality {
+	now := time.Now()
+	var samples []*models.MarketQuality
+	for _, engine := range s.allEngines() {
+		engine.inspect(func(e *symbolEngine) {
+			if sample := e.marketQuality(now); sample != nil {
+				samples = append(samples, sample)
+			}
+		})
+	}
+	return samples
+}
+
+// marketQuality measures the engine's book, nil when both sides are empty and the symbol is not
+// configured. An empty configured market is measured too, as it is the thinnest market of all.
+func (e *symbolEngine) marketQuality(now time.Time) *models.MarketQuality {
+	bids := bestLevels(e.orderBook.Bids[e.symbol], 1)
+	asks := bestLevels(e.orderBook.Asks[e.symbol], 1)
+	if _, configured := e.cfg.Symbols[e.symbol]; len(bids) == 0 && len(asks) == 0 && !configured {
+		return nil
+	}
+
+	sample := &models.MarketQuality{Symbol: e.symbol, CapturedAt: now}
+	if len(bids) > 0 {
+		sample.BestBid = models.NullDecimal{Decimal: bids[0].Price, Valid: true}
+	}
+	if len(asks) > 0 {
+		sample.BestAsk = models.NullDecimal{Decimal: asks[0].Price, Valid: true}
+	}
+
+	var reference models.Decimal
+	switch {
+	case sample.BestBid.Valid && sample.BestAsk.Valid:
+		reference = (sample.BestBid.Decimal + sample.BestAsk.Decimal) / 2
+		sample.SpreadBps = sql.NullFloat64{
+			Float64: (sample.BestAsk.Decimal - sample.BestBid.Decimal).Float64() / reference.Float64() * 10000,
+			Valid:   true,
+		}
+	case sample.BestBid.Valid:
+		reference = sample.BestBid.Decimal
+	case sample.BestAsk.Valid:
+		reference = sample.BestAsk.Decimal
+	}
+	band := models.NewDecimal(e.cfg.Quality.DepthBand)
+	sample.BidDepth = bandDepth(e.orderBook.Bids[e.symbol], true, reference-reference.Mul(band))
+	sample.AskDepth = bandDepth(e.orderBook.Asks[e.symbol], false, reference+reference.Mul(band))
+	return sample
+}
+
+// QualityThresholds returns the market quality depth band and alert thresholds
+func (s *MatchingService) QualityThresholds() config.QualityConfig {
+	return s.cfg.Quality
+}
+
+// QualityAlerts returns the configured thresholds a market quality sample breaches
+func (s *MatchingService) QualityAlerts(sample *models.MarketQuality) []QualityAlert {
+	cfg := s.cfg.Quality
+	alerts := []QualityAlert{}
+	if cfg.MaxSpreadBps > 0 && (!sample.SpreadBps.Valid || sample.SpreadBps.Float64 > cfg.MaxSpreadBps) {
+		alerts = append(alerts, AlertWideSpread)
+	}
+	if cfg.MinDepthNotional > 0 && sample.BidDepth < cfg.MinDepthNotional {
+		alerts = append(alerts, AlertThinBids)
+	}
+	if cfg.MinDepthNotional > 0 && sample.AskDepth < cfg.MinDepthNotional {
+		alerts = append(alerts, AlertThinAsks)
+	}
+	return alerts
+}
+
+// RunMarketQuality persists a market quality sample for every measured symbol on each interval
+// until ctx is done, warning about each symbol that breaches an alert threshold
+func (s *MatchingService) RunMarketQuality(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, sample := range s.MeasureMarketQuality() {
+				if err := s.repo.SaveMarketQuality(ctx, sample); err != nil {
+					s.logger.Error("Failed to save market quality sample", zap.String("symbol", sample.Symbol), zap.Error(err))
+				}
+				if alerts := s.QualityAlerts(sample); len(alerts) > 0 {
+					fields := []zap.Field{zap.String("symbol", sample.Symbol), zap.Any("alerts", alerts),
+						zap.Float64("bid_depth", sample.BidDepth), zap.Float64("ask_depth", sample.AskDepth)}
+					if sample.SpreadBps.Valid {
+						fields = append(fields, zap.Float64("spread_bps", sample.SpreadBps.Float64))
+					}
+					s.logger.Warn("Market too thin", fields...)
+				}
+			}
+		}
+	}
+}
+
+// GetMarketQuality retrieves persisted market quality samples for a symbol within a time range
+func (s *MatchingService) GetMarketQuality(ctx context.Context, symbol string, from, to time.Time, limit int) ([]*models.MarketQuality, error) {
+	samples, err := s.repo.GetMarketQuality(ctx, symbol, from, to, limit)
+	if err != nil {
+		s.logger.Error("Failed to get market quality samples", zap.Error(err))
+		return nil, err
+	}
+	return samples, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"orderSystem/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// quantityStep is the lot size of symbols without one, 0.01
+const quantityStep = models.Decimal(1_000_000)
+
+// sizeQuoteOrder gives a market buy sized by its quote quantity the base quantity that amount
+// buys from the asks as they stand, best first and within any protection price, rounded down
+// to the symbol's lot size. Matching then fills that quantity like any market order's. An order
+// that already has a quantity, as a journaled one does, keeps it.
+func (e *symbolEngine) sizeQuoteOrder(order *models.Order) error {
+	if !order.QuoteQuantity.Valid || order.InitialQuantity > 0 {
+		return nil
+	}
+	if order.Type != models.TypeMarket || order.Side != models.SideBuy || order.QuoteQuantity.Decimal <= 0 {
+		e.logger.Error("Invalid quote quantity", zap.Any("order", order))
+		return models.ErrInvalidOrder
+	}
+	step := quantityStep
+	if listing := e.listing(e.ctx, order.Symbol); listing != nil && listing.LotSize > 0 {
+		step = listing.LotSize
+	}
+
+	budget := order.QuoteQuantity.Decimal
+	var quantity models.Decimal
+	for _, entry := range bestLevels(e.orderBook.Asks[order.Symbol], 0) {
+		if !crosses(order, entry.Price) || entry.Price <= 0 {
+			break
+		}
+		var size models.Decimal
+		for _, resting := range entry.Orders {
+			size += resting.RemainingQuantity
+		}
+		// A deep level's cost can be beyond a Decimal, so it is only taken exactly once its
+		// Notional shows the budget covers it
+		if models.Notional(entry.Price, size) <= budget.Float64() {
+			if cost := entry.Price.Mul(size); cost <= budget {
+				quantity += size
+				budget -= cost
+				continue
+			}
+		}
+		affordable := budget.Div(entry.Price)
+		if affordable.Mul(entry.Price) > budget {
+			affordable--
+		}
+		quantity += affordable
+		break
+	}
+	quantity -= quantity % step
+	if quantity <= 0 {
+		e.logger.Warn("Quote quantity buys nothing from the book", zap.String("symbol", order.Symbol),
+			zap.Stringer("quote_quantity", order.QuoteQuantity.Decimal))
+		return models.ErrNoLiquidity
+	}
+	order.InitialQuantity, order.RemainingQuantity = quantity, quantity
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import "orderSystem/internal/models"
+
+// PlacementReport returns the execution report of a placed order, summing the fills of this
+// placement into its last fill. A resubmission reports the earlier order's state and averages
+// all of its fills, with no last fill.
+func PlacementReport(order *models.Order, result *PlaceOrderResult) *models.ExecutionReport {
+	report := &models.ExecutionReport{
+		OrderID:        order.OrderID,
+		ClientOrderID:  order.ClientOrderID.String,
+		Symbol:         order.Symbol,
+		Side:           order.Side,
+		Type:           order.Type,
+		Status:         order.Status,
+		Price:          order.Price,
+		Quantity:       order.InitialQuantity,
+		CumQuantity:    order.InitialQuantity - order.RemainingQuantity,
+		LeavesQuantity: order.RemainingQuantity,
+		AvgPrice:       models.AveragePrice(result.Executions),
+		QuoteQuantity:  order.QuoteQuantity,
+		CumQuote:       models.FilledNotional(result.Executions),
+		Time:           order.CreatedAt,
+	}
+	if n := len(result.Executions); n > 0 {
+		report.Time = result.Executions[n-1].CreatedAt
+	}
+	// A canceled order keeps its unfilled quantity, but none of it can fill any more
+	if order.Status == models.StatusCanceled || order.Status == models.StatusExpired {
+		report.LeavesQuantity = 0
+	}
+
+	switch {
+	case len(result.Executions) > 0 && !result.Resubmitted:
+		report.ExecType = models.ExecTrade
+		var filled models.Decimal
+		for _, execution := range result.Executions {
+			filled += execution.Quantity
+		}
+		report.LastQuantity = models.NullDecimal{Decimal: filled, Valid: true}
+		report.LastPrice = report.AvgPrice
+	case order.Status == models.StatusFilled:
+		report.ExecType = models.ExecTrade
+	case order.Status == models.StatusCanceled:
+		report.ExecType = models.ExecCanceled
+	case order.Status == models.StatusExpired:
+		report.ExecType = models.ExecExpired
+	default:
+		report.ExecType = models.ExecNew
+	}
+	return report
+}
+
+// AmendReport returns the execution report of an amended order, which is a replacement unless
+// the amendment made it trade; executions are all of the order's, for its average price
+func AmendReport(order *models.Order, result *PlaceOrderResult, executions []*models.Execution) *models.ExecutionReport {
+	report := PlacementReport(order, result)
+	report.AvgPrice = models.AveragePrice(executions)
+	report.CumQuote = models.FilledNotional(executions)
+	if report.ExecType == models.ExecNew {
+		report.ExecType = models.ExecReplaced
+	}
+	return report
+}
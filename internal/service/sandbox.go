@@ -0,0 +1,123 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sandboxLot is the smallest quantity a simulated partial fill is cut to, and what it is rounded
+// down to, 0.01
+const sandboxLot = models.Decimal(1_000_000)
+
+// SandboxSettings returns the current sandbox settings and whether sandbox mode is on
+func (s *MatchingService) SandboxSettings() (config.SandboxConfig, bool) {
+	s.sandboxMutex.RLock()
+	defer s.sandboxMutex.RUnlock()
+	return s.sandbox, s.sandbox.Enabled
+}
+
+// UpdateSandboxSettings replaces the simulated latency, partial fill rate and queue settings of
+// sandbox mode; incoming orders use them from then on, while orders already resting keep the
+// queue ahead they were given
+func (s *MatchingService) UpdateSandboxSettings(settings config.SandboxConfig) (config.SandboxConfig, error) {
+	s.sandboxMutex.Lock()
+	defer s.sandboxMutex.Unlock()
+	if !s.sandbox.Enabled {
+		return s.sandbox, models.ErrInvalidSandbox
+	}
+	if !config.ValidSandbox(settings) {
+		s.logger.Warn("Invalid sandbox settings", zap.Any("settings", settings))
+		return s.sandbox, models.ErrInvalidSandbox
+	}
+	settings.Enabled = true
+	s.sandbox = settings
+	s.logger.Info("Sandbox settings updated", zap.Any("settings", settings))
+	return settings, nil
+}
+
+// sandboxSettings returns the sandbox settings when sandbox mode is on
+func (c *core) sandboxSettings() (config.SandboxConfig, bool) {
+	c.sandboxMutex.RLock()
+	defer c.sandboxMutex.RUnlock()
+	return c.sandbox, c.sandbox.Enabled
+}
+
+// delayAck holds a new order back for the simulated order entry latency
+func (c *core) delayAck() {
+	settings, ok := c.sandboxSettings()
+	if !ok {
+		return
+	}
+	delay := settings.AckLatency
+	if settings.AckJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(settings.AckJitter) + 1))
+	}
+	time.Sleep(delay)
+}
+
+// simulated reports whether an incoming order meets the simulated frictions of sandbox mode;
+// fill-or-kill orders never do, so that they still fill completely or not at all
+func (e *symbolEngine) simulated(order *models.Order) (config.SandboxConfig, bool) {
+	settings, ok := e.sandboxSettings()
+	return settings, ok && order.TimeInForce != models.TIFFOK
+}
+
+// partialFill returns the quantity a fill of an incoming order is cut to, and whether it was
+// cut: at random at the partial fill rate, to a random number of lots below its size
+func (e *symbolEngine) partialFill(order *models.Order, quantity models.Decimal) (models.Decimal, bool) {
+	settings, ok := e.simulated(order)
+	if !ok || quantity <= sandboxLot || rand.Float64() >= settings.PartialFillRate {
+		return quantity, false
+	}
+	lots := int64((quantity - 1) / sandboxLot)
+	return models.Decimal(1+rand.Int63n(lots)) * sandboxLot, true
+}
+
+// queueAhead returns the simulated interest still queued ahead of a resting order for an
+// incoming one, decaying it to now first. Callers must journal the resting order.
+func (e *symbolEngine) queueAhead(order, restingOrder *models.Order, now time.Time) models.Decimal {
+	if restingOrder.QueueAhead == 0 {
+		return 0
+	}
+	settings, ok := e.simulated(order)
+	if !ok {
+		return 0
+	}
+	if settings.QueueHalfLife > 0 {
+		halfLives := now.Sub(restingOrder.QueueDecayedAt).Seconds() / settings.QueueHalfLife.Seconds()
+		restingOrder.QueueAhead = models.Decimal(float64(restingOrder.QueueAhead) * math.Pow(0.5, halfLives))
+		restingOrder.QueueDecayedAt = now
+		if restingOrder.QueueAhead < sandboxLot {
+			restingOrder.QueueAhead = 0
+		}
+	}
+	return restingOrder.QueueAhead
+}
+
+// joinQueue gives an order that has just come to rest its simulated queue ahead, in proportion
+// to the visible quantity resting before it at its price
+func (e *symbolEngine) joinQueue(order *models.Order) {
+	settings, ok := e.simulated(order)
+	if !ok || settings.QueueAhead == 0 {
+		return
+	}
+	entries := e.bookSide(order.Side == models.SideBuy)[order.Symbol]
+	i, exists := levelIndex(entries, order.Side == models.SideBuy, order.Price.Decimal)
+	if !exists {
+		return
+	}
+	var ahead models.Decimal
+	for _, o := range entries[i].Orders {
+		if o == order {
+			break
+		}
+		ahead += visibleQuantity(o)
+	}
+	order.QueueAhead = models.NewDecimal(ahead.Float64() * settings.QueueAhead)
+	order.QueueDecayedAt = time.Now()
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log"
+	"orderSystem/internal/config"
+	"orderSystem/internal/migration"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/writebehind"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestMain silences the standard logger, which migrations report to
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testSetup is how newTestService builds a service
+type testSetup struct {
+	configure   func(*config.Config) // changes the default configuration, when set
+	writeBehind bool                 // writes the engines' transactions behind them
+}
+
+// newTestService creates a service on a fresh SQLite database in a temporary directory, with
+// the given symbols configured and listed, each quoted in USD
+func newTestService(tb testing.TB, setup testSetup, symbols ...string) (*MatchingService, repository.Repository) {
+	tb.Helper()
+	db, err := repository.OpenSQLite(filepath.Join(tb.TempDir(), "test.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	if err := migration.RunSQLiteMigrations(db); err != nil {
+		tb.Fatal(err)
+	}
+	repo := repository.NewSQLiteRepository(db)
+	return startTestService(tb, repo, setup, symbols...), repo
+}
+
+// startTestService creates a service on a repository, as a server starting on its database does
+func startTestService(tb testing.TB, repo repository.Repository, setup testSetup, symbols ...string) *MatchingService {
+	tb.Helper()
+	logger := zap.NewNop()
+	cfg, err := config.Load(logger, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	cfg.Symbols = make(map[string]config.SymbolConfig)
+	for _, symbol := range symbols {
+		cfg.Symbols[symbol] = config.SymbolConfig{BaseCurrency: symbol[:len(symbol)-3], QuoteCurrency: "USD"}
+	}
+	if setup.configure != nil {
+		setup.configure(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tb.Cleanup(cancel)
+	var queue *writebehind.Queue
+	if setup.writeBehind {
+		if queue, err = writebehind.Open(ctx, filepath.Join(tb.TempDir(), "write_behind"), repo, cfg.Engine.NodeID, logger); err != nil {
+			tb.Fatal(err)
+		}
+		go queue.Run(ctx)
+	}
+	s := NewMatchingService(ctx, repo, cfg, logger)
+	if queue != nil {
+		s.SetWriteBehind(queue)
+	}
+	return s
+}
+
+// limitOrder returns an anonymous good-till-canceled limit order
+func limitOrder(symbol string, side models.OrderSide, price, quantity float64) *models.Order {
+	return &models.Order{
+		Symbol:            symbol,
+		Side:              side,
+		Type:              models.TypeLimit,
+		Price:             models.NullDecimal{Decimal: models.NewDecimal(price), Valid: true},
+		InitialQuantity:   models.NewDecimal(quantity),
+		RemainingQuantity: models.NewDecimal(quantity),
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// settlementBatchSize bounds how many due settlements are finalized per transaction
+const settlementBatchSize = 500
+
+// balanceDelta is one party's change in one currency caused by a trade; accountID is empty for
+// the anonymous side
+type balanceDelta struct {
+	accountID string
+	currency  string
+	amount    float64
+}
+
+// tradeDeltas returns the balance changes a trade causes for both parties
+func tradeDeltas(trade *models.Trade, base, quote string) []balanceDelta {
+	quantity := trade.Quantity.Float64()
+	notional := models.Notional(trade.Price, trade.Quantity)
+	return []balanceDelta{
+		{trade.BuyOwnerID, base, quantity},
+		{trade.BuyOwnerID, quote, -notional},
+		{trade.SellOwnerID, base, -quantity},
+		{trade.SellOwnerID, quote, notional},
+	}
+}
+
+// settleTradesTx posts the balance effects of persisted trades to the ledger within the matching
+// transaction, the anonymous side of a trade being posted to the anonymous bucket. Symbols with
+// no settlement lag settle immediately; others create pending deltas that RunSettlement
+// finalizes once the lag has elapsed. Trades between anonymous orders move no balances.
+func (c *core) settleTradesTx(ctx context.Context, tx repository.Tx, trades []*models.Trade) error {
+	for _, trade := range trades {
+		symbol, ok := c.symbolConfig(ctx, trade.Symbol)
+		if !ok || (trade.BuyOwnerID == "" && trade.SellOwnerID == "") {
+			continue
+		}
+		posting := c.newPosting(models.LedgerTrade, trade.TradeID, trade.CreatedAt)
+		for _, delta := range tradeDeltas(trade, symbol.BaseCurrency, symbol.QuoteCurrency) {
+			if delta.accountID == "" {
+				posting.Entries = append(posting.Entries, &models.LedgerEntry{Currency: delta.currency,
+					Bucket: models.BucketAnonymous, Amount: delta.amount})
+				continue
+			}
+			if symbol.SettlementLag == 0 {
+				posting.Entries = append(posting.Entries, &models.LedgerEntry{AccountID: delta.accountID,
+					Currency: delta.currency, Bucket: models.BucketSettled, Amount: delta.amount})
+				continue
+			}
+			settlement := &models.Settlement{
+				TradeID:   trade.TradeID,
+				AccountID: delta.accountID,
+				Currency:  delta.currency,
+				Amount:    delta.amount,
+				SettleAt:  trade.CreatedAt.Add(symbol.SettlementLag),
+				Status:    models.SettlementPending,
+			}
+			if err := c.repo.SaveSettlementTx(ctx, tx, settlement); err != nil {
+				return err
+			}
+			posting.Entries = append(posting.Entries, &models.LedgerEntry{AccountID: delta.accountID,
+				Currency: delta.currency, Bucket: models.BucketPending, Amount: delta.amount})
+		}
+		if err := postTx(ctx, c.repo, tx, posting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunSettlement finalizes due pending settlements on each interval until ctx is done
+func (s *MatchingService) RunSettlement(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.settleDue(ctx, now); err != nil {
+				s.logger.Error("Failed to finalize settlements", zap.Error(err))
+			}
+		}
+	}
+}
+
+// settleDue finalizes due settlements on every shard, each shard in its own transaction
+func (s *MatchingService) settleDue(ctx context.Context, now time.Time) error {
+	for _, shard := range s.repo.Shards() {
+		if err := s.settleShard(ctx, shard, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleShard moves a shard's due pending deltas into settled balances in a single transaction
+func (s *MatchingService) settleShard(ctx context.Context, shard repository.Repository, now time.Time) error {
+	tx, err := shard.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("settlement"), time.Now())
+
+	due, err := shard.GetDueSettlementsTx(ctx, tx, now, settlementBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	for _, settlement := range due {
+		posting := s.newPosting(models.LedgerSettlement, settlement.SettlementID, now)
+		posting.Entries = []*models.LedgerEntry{
+			{AccountID: settlement.AccountID, Currency: settlement.Currency, Bucket: models.BucketPending, Amount: -settlement.Amount},
+			{AccountID: settlement.AccountID, Currency: settlement.Currency, Bucket: models.BucketSettled, Amount: settlement.Amount},
+		}
+		if err := postTx(ctx, shard, tx, posting); err != nil {
+			return err
+		}
+		if err := shard.MarkSettlementSettledTx(ctx, tx, settlement.SettlementID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.logger.Info("Settlements finalized", zap.Int("count", len(due)))
+	return nil
+}
+
+// Deposit credits a settled balance to an account
+func (s *MatchingService) Deposit(ctx context.Context, accountID, currency string, amount float64) error {
+	if accountID == "" || currency == "" || amount <= 0 {
+		return models.ErrInvalidDeposit
+	}
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("deposit"), time.Now())
+
+	posting := s.newPosting(models.LedgerDeposit, 0, time.Now())
+	posting.Entries = []*models.LedgerEntry{
+		{Currency: currency, Bucket: models.BucketExternal, Amount: -amount},
+		{AccountID: accountID, Currency: currency, Bucket: models.BucketSettled, Amount: amount},
+	}
+	if err := postTx(ctx, s.repo, tx, posting); err != nil {
+		s.logger.Error("Failed to credit deposit", zap.Error(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetBalances retrieves an account's settled and pending balances along with what its open
+// orders hold, by currency
+func (s *MatchingService) GetBalances(ctx context.Context, accountID string) ([]*models.Balance, error) {
+	balances, err := s.repo.GetBalances(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get balances", zap.Error(err))
+		return nil, err
+	}
+	held, err := s.Holds(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range balances {
+		balance.Held = held[balance.Currency]
+		delete(held, balance.Currency)
+	}
+	for currency, amount := range held {
+		if amount > 0 {
+			balances = append(balances, &models.Balance{AccountID: accountID, Currency: currency, Held: amount})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances, nil
+}
+
+// GetSettlements retrieves an account's settlements with the given status
+func (s *MatchingService) GetSettlements(ctx context.Context, accountID string, status models.SettlementStatus) ([]*models.Settlement, error) {
+	settlements, err := s.repo.GetSettlements(ctx, accountID, status)
+	if err != nil {
+		s.logger.Error("Failed to get settlements", zap.Error(err))
+		return nil, err
+	}
+	return settlements, nil
+}
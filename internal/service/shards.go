@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sort"
+)
+
+// ShardStatus describes one database shard and the symbols it holds
+type ShardStatus struct {
+	Index   int
+	Healthy bool
+	Error   string
+	Symbols []string // configured or in-memory symbols mapped to the shard
+}
+
+// ShardTopology reports every database shard's health and the symbols routed to it
+func (s *MatchingService) ShardTopology(ctx context.Context) []ShardStatus {
+	shards := s.repo.Shards()
+	statuses := make([]ShardStatus, len(shards))
+	for i, shard := range shards {
+		statuses[i] = ShardStatus{Index: i, Healthy: true, Symbols: []string{}}
+		if err := shard.Ping(ctx); err != nil {
+			statuses[i].Healthy = false
+			statuses[i].Error = err.Error()
+		}
+	}
+
+	symbols := make(map[string]struct{})
+	for symbol := range s.cfg.Symbols {
+		symbols[symbol] = struct{}{}
+	}
+	for _, engine := range s.allEngines() {
+		engine.inspect(func(e *symbolEngine) {
+			for _, symbol := range e.bookSymbols() {
+				symbols[symbol] = struct{}{}
+			}
+		})
+	}
+
+	for symbol := range symbols {
+		i := s.repo.ShardIndex(symbol)
+		statuses[i].Symbols = append(statuses[i].Symbols, symbol)
+	}
+	for i := range statuses {
+		sort.Strings(statuses[i].Symbols)
+	}
+	return statuses
+}
+
+// ShardFor returns the index of the database shard holding a symbol's rows
+func (s *MatchingService) ShardFor(symbol string) int {
+	return s.repo.ShardIndex(symbol)
+}
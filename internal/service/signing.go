@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// randomHex returns n random bytes hex encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// CreateSigningKey issues a new HMAC signing key for a registered account, with the default
+// scopes when none are given and usable from any IP without allowed ones; the returned key is
+// the only place its secret is handed out
+func (s *MatchingService) CreateSigningKey(ctx context.Context, accountID string, scopes []models.KeyScope, allowedIPs []string) (*models.SigningKey, error) {
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	for _, scope := range scopes {
+		switch scope {
+		case models.ScopeRead, models.ScopeTrade, models.ScopeCancel, models.ScopeAdmin, models.ScopeCompliance:
+		default:
+			return nil, models.ErrInvalidSigningKey
+		}
+	}
+	for _, allowed := range allowedIPs {
+		if _, err := models.ParseAllowedIP(allowed); err != nil {
+			return nil, models.ErrInvalidSigningKey
+		}
+	}
+	if _, err := s.GetAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	key := &models.SigningKey{
+		KeyID:      randomHex(16),
+		AccountID:  accountID,
+		Secret:     randomHex(32),
+		Scopes:     scopes,
+		AllowedIPs: allowedIPs,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.SaveSigningKey(ctx, key); err != nil {
+		s.logger.Error("Failed to save signing key", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+
+	s.logger.Info("Signing key created", zap.String("key_id", key.KeyID), zap.String("account_id", accountID),
+		zap.Any("scopes", key.Scopes), zap.Strings("allowed_ips", allowedIPs))
+	return key, nil
+}
+
+// SigningKey returns an active signing key, ErrSigningKeyNotFound when it is unknown or revoked
+func (s *MatchingService) SigningKey(ctx context.Context, keyID string) (*models.SigningKey, error) {
+	key, err := s.repo.GetSigningKey(ctx, keyID)
+	if err == models.ErrSigningKeyNotFound || err == nil && key.RevokedAt.Valid {
+		return nil, models.ErrSigningKeyNotFound
+	}
+	if err != nil {
+		s.logger.Error("Failed to get signing key", zap.String("key_id", keyID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	return key, nil
+}
+
+// HasAdminSigningKey reports whether any active signing key holds the admin scope
+func (s *MatchingService) HasAdminSigningKey(ctx context.Context) (bool, error) {
+	exists, err := s.repo.HasSigningKeyWithScope(ctx, models.ScopeAdmin)
+	if err != nil {
+		s.logger.Error("Failed to look up admin signing keys", zap.Error(err))
+		return false, repository.Classify(err)
+	}
+	return exists, nil
+}
+
+// SigningKeys returns an account's active signing keys, oldest first
+func (s *MatchingService) SigningKeys(ctx context.Context, accountID string) ([]*models.SigningKey, error) {
+	if _, err := s.GetAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.GetSigningKeys(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get signing keys", zap.String("account_id", accountID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	return keys, nil
+}
+
+// RevokeSigningKey revokes one of an account's signing keys, or any account's key when accountID
+// is empty; requests signed with it are rejected from then on
+func (s *MatchingService) RevokeSigningKey(ctx context.Context, accountID, keyID string) error {
+	key, err := s.SigningKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if accountID != "" && key.AccountID != accountID {
+		return models.ErrSigningKeyNotFound
+	}
+	err = s.repo.RevokeSigningKey(ctx, keyID, time.Now())
+	if err == models.ErrSigningKeyNotFound {
+		return err
+	}
+	if err != nil {
+		s.logger.Error("Failed to revoke signing key", zap.String("key_id", keyID), zap.Error(err))
+		return repository.Classify(err)
+	}
+
+	s.logger.Info("Signing key revoked", zap.String("key_id", keyID), zap.String("account_id", key.AccountID))
+	return nil
+}
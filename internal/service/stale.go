@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunStaleOrderSweep expires stale resting orders on each interval until ctx is done
+func (s *MatchingService) RunStaleOrderSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.SweepStaleOrders(time.Now())
+		}
+	}
+}
+
+// SweepStaleOrders expires the stale resting orders of every symbol with a stale_order_ttl and
+// returns how many expired. A symbol is swept only when it has not traded on the book for its
+// TTL; its orders that have not changed for as long then expire, so quotes left behind in a
+// dormant market cannot execute when it wakes up.
+func (s *MatchingService) SweepStaleOrders(now time.Time) int {
+	symbols := make([]string, 0, len(s.cfg.Symbols))
+	for symbol, sc := range s.cfg.Symbols {
+		if sc.StaleOrderTTL > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	expired := 0
+	for _, symbol := range symbols {
+		ttl := s.cfg.Symbols[symbol].StaleOrderTTL
+		err := s.engine(symbol).do(func(e *symbolEngine) error {
+			n, err := e.expireStaleOrders(now.Add(-ttl))
+			expired += n
+			return err
+		})
+		if err != nil {
+			s.logger.Error("Failed to sweep stale orders", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}
+	return expired
+}
+
+// expireStaleOrders expires the engine's resting orders unchanged since cutoff, unless the symbol
+// traded after it, journaling each expiry on its own
+func (e *symbolEngine) expireStaleOrders(cutoff time.Time) (int, error) {
+	if err := e.awaitWrites(); err != nil {
+		return 0, err
+	}
+	trade, err := e.repo.GetLastBookTrade(e.ctx, e.symbol)
+	if err != nil {
+		return 0, err
+	}
+	if trade != nil && trade.CreatedAt.After(cutoff) {
+		return 0, nil
+	}
+	orders, err := e.repo.GetStaleOrders(e.ctx, e.symbol, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, order := range orders {
+		e.journal(&models.JournalEntry{Kind: models.JournalStale, OrderID: order.OrderID})
+		if err := e.expireStale(order); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// expireStale expires a stale resting order of the engine's symbol. An order linked in a group
+// cancels its group, as canceling it would.
+func (e *symbolEngine) expireStale(order *models.Order) error {
+	change := models.OrderChange{Actor: models.ActorSystem, Reason: models.ReasonStale}
+	if g, ok := e.groupLegs[order.OrderID]; ok {
+		return e.cancelGroup(g, change)
+	}
+	order.Status = models.StatusExpired
+	if err := e.updateOrder(order, change); err != nil {
+		return err
+	}
+	e.removeFromOrderBook(order)
+	e.logger.Info("Stale order expired", zap.Uint64("order_id", order.OrderID), zap.String("symbol", e.symbol))
+	return nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// isStop reports whether an order waits for a trigger price before executing
+func isStop(order *models.Order) bool {
+	return order.Type == models.TypeStop || order.Type == models.TypeStopLimit
+}
+
+// stopTriggered reports whether a last trade price reaches a stop order's trigger: at or above
+// it for buys, at or below it for sells
+func stopTriggered(order *models.Order, lastPrice models.Decimal) bool {
+	if order.Side == models.SideBuy {
+		return lastPrice >= order.TriggerPrice.Decimal
+	}
+	return lastPrice <= order.TriggerPrice.Decimal
+}
+
+// stopLadder holds a symbol's untriggered stop orders sorted by trigger price: buy stops lowest
+// trigger first and sell stops highest first, with equal triggers in arrival order. The stops a
+// last price triggers are then always the front of each side, so a trade that triggers nothing
+// only looks at the two front stops.
+type stopLadder struct {
+	buys, sells []*models.Order
+}
+
+// side returns the side of the ladder an order belongs on
+func (l *stopLadder) side(order *models.Order) *[]*models.Order {
+	if order.Side == models.SideBuy {
+		return &l.buys
+	}
+	return &l.sells
+}
+
+// position returns the index of the first stop on a side that triggers after an order would,
+// or at the same trigger but arrived later
+func (l *stopLadder) position(order *models.Order) int {
+	side := *l.side(order)
+	return sort.Search(len(side), func(i int) bool {
+		if side[i].TriggerPrice.Decimal != order.TriggerPrice.Decimal {
+			return !ahead(order.Side == models.SideSell, side[i].TriggerPrice.Decimal, order.TriggerPrice.Decimal)
+		}
+		return side[i].Sequence > order.Sequence
+	})
+}
+
+// add inserts a stop order at its place on the ladder
+func (l *stopLadder) add(order *models.Order) {
+	side := l.side(order)
+	i := l.position(order)
+	*side = append(*side, nil)
+	copy((*side)[i+1:], (*side)[i:])
+	(*side)[i] = order
+}
+
+// remove drops a stop order from the ladder, reporting whether it was there
+func (l *stopLadder) remove(order *models.Order) bool {
+	side := l.side(order)
+	for i, o := range *side {
+		if o.OrderID == order.OrderID {
+			*side = append((*side)[:i], (*side)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the earliest arrived of the stops a last trade price triggers, nil when none does
+func (l *stopLadder) next(lastPrice models.Decimal) *models.Order {
+	var first *models.Order
+	for _, side := range [][]*models.Order{l.buys, l.sells} {
+		for _, order := range side {
+			if !stopTriggered(order, lastPrice) {
+				break
+			}
+			if first == nil || order.Sequence < first.Sequence {
+				first = order
+			}
+		}
+	}
+	return first
+}
+
+// orders returns every stop on the ladder in arrival order
+func (l *stopLadder) orders() []*models.Order {
+	orders := append(append([]*models.Order(nil), l.buys...), l.sells...)
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Sequence < orders[j].Sequence })
+	return orders
+}
+
+// loadStops restores untriggered stop orders and the last trade price of their symbols at
+// startup, handing each symbol's stops to its engine
+func (s *MatchingService) loadStops(ctx context.Context) {
+	orders, err := s.repo.GetPendingStops(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load stop orders", zap.Error(err))
+		return
+	}
+	stops := make(map[string][]*models.Order)
+	for _, order := range orders {
+		stops[order.Symbol] = append(stops[order.Symbol], order)
+		s.raiseSequence(order.Sequence)
+	}
+	for symbol, pending := range stops {
+		trade, err := s.repo.GetLastBookTrade(ctx, symbol)
+		if err != nil {
+			s.logger.Error("Failed to load last trade price", zap.String("symbol", symbol), zap.Error(err))
+		}
+		s.engine(symbol).inspect(func(e *symbolEngine) {
+			for _, order := range pending {
+				e.stops.add(order)
+			}
+			if trade != nil {
+				e.lastPrice[symbol] = trade.Price
+				e.lastTradeAt[symbol] = trade.CreatedAt
+			}
+		})
+	}
+}
+
+// placeStop persists a validated stop order as pending and holds it off-book, triggering it
+// straight away if the last trade price has already reached its trigger.
+func (e *symbolEngine) placeStop(order *models.Order) (*PlaceOrderResult, error) {
+	order.Status = models.StatusPending
+	tx, err := e.beginTx(order.Symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("stop"), time.Now())
+
+	if err := e.reserveTx(tx, order); err != nil {
+		return nil, repository.Classify(err)
+	}
+	if err := e.saveOrderTx(tx, order); err != nil {
+		e.logger.Error("Failed to save stop order", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if err := e.commit(tx, nil); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	e.stops.add(order)
+	e.triggerStops(order.Symbol)
+	return &PlaceOrderResult{}, nil
+}
+
+// removeStop drops a stop order from the pending trigger store
+func (e *symbolEngine) removeStop(order *models.Order) {
+	e.stops.remove(order)
+}
+
+// triggerStops executes, in arrival order, every stop order of the symbol whose trigger the last
+// trade price has reached. Trades from triggered orders move the last price, so this repeats until
+// no further stop triggers. Stops stay pending while the symbol is in an auction or halted. A stop
+// that fails to execute goes back on the ladder in its old place.
+func (e *symbolEngine) triggerStops(symbol string) {
+	for !e.inAuction(symbol) && !e.halted() {
+		lastPrice, ok := e.lastPrice[symbol]
+		if !ok {
+			return
+		}
+		order := e.stops.next(lastPrice)
+		if order == nil {
+			return
+		}
+
+		e.removeStop(order)
+		sequence := order.Sequence
+		order.Status = models.StatusOpen
+		order.Sequence = e.nextSequence()
+		triggered := *order
+		_, err := e.executeOrder(order, false)
+		if err == models.ErrInsufficientCredit {
+			// Its fills would spend balance held for the account's other orders, which a retry
+			// would not change
+			*order = triggered
+			e.cancelTriggered(order)
+			continue
+		}
+		if err != nil {
+			// Leave it pending so the next trade retries the trigger
+			e.logger.Error("Failed to execute triggered stop order", zap.Uint64("order_id", order.OrderID), zap.Error(err))
+			order.Status, order.Sequence = models.StatusPending, sequence
+			e.stops.add(order)
+			return
+		}
+		e.logger.Info("Stop order triggered", zap.Uint64("order_id", order.OrderID),
+			zap.Stringer("last_price", lastPrice), zap.String("status", string(order.Status)))
+	}
+}
+
+// cancelTriggered cancels a triggered stop order its account's balance cannot cover, along with
+// the rest of its group
+func (e *symbolEngine) cancelTriggered(order *models.Order) {
+	var err error
+	change := models.OrderChange{Actor: models.ActorEngine, Reason: models.ReasonInsufficientCredit}
+	if g, ok := e.groupLegs[order.OrderID]; ok {
+		err = e.cancelGroup(g, change)
+	} else {
+		order.Status = models.StatusCanceled
+		err = e.updateOrder(order, change)
+	}
+	if err != nil {
+		e.logger.Error("Failed to cancel triggered stop order", zap.Uint64("order_id", order.OrderID), zap.Error(err))
+		return
+	}
+	e.logger.Warn("Triggered stop order canceled for insufficient balance", zap.Uint64("order_id", order.OrderID))
+}
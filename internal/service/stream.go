@@ -0,0 +1,189 @@
+package service
+
+import (
+	"hash/crc32"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MarketEventKind is the type of a public market data event
+type MarketEventKind string
+
+// Market event kinds
+const (
+	EventTrade    MarketEventKind = "trade"    // trades printed to the tape
+	EventBook     MarketEventKind = "book"     // incremental price level changes
+	EventSnapshot MarketEventKind = "snapshot" // every price level of the book, see SnapshotBook
+)
+
+// MarketEvent is a change to a symbol's public market data. Trade and book events each carry a
+// sequence that increases by one per event of that kind and symbol, so a subscriber applying
+// book changes to a snapshot can tell it missed one. Book events and snapshots also carry the
+// checksum of the book they leave, so a subscriber can tell its copy went wrong.
+type MarketEvent struct {
+	Symbol       string
+	Kind         MarketEventKind
+	Sequence     uint64
+	PrevSequence uint64              // book events: the sequence of the book event before
+	Checksum     uint32              // book events and snapshots: see bookChecksum
+	Trades       []*models.Trade     // trade events
+	Changes      []BookChange        // book events: the new state of each changed level
+	Bids         []models.PriceLevel // snapshots
+	Asks         []models.PriceLevel
+	Time         time.Time
+}
+
+// checksumLevels is the number of best levels on each side a book checksum covers
+const checksumLevels = 10
+
+// bookChecksum returns the CRC32 (IEEE) checksum of the engine's best checksumLevels levels on
+// each side. The checksummed text alternates bid and ask levels from the best down, each as its
+// price and quantity, all joined by colons, e.g. "101:5:102:3.5" for one level a side; a side
+// that runs out of levels adds nothing further.
+func (e *symbolEngine) bookChecksum() uint32 {
+	bids := depthLevels(e.orderBook.Bids[e.symbol], checksumLevels)
+	asks := depthLevels(e.orderBook.Asks[e.symbol], checksumLevels)
+	var fields []string
+	for i := 0; i < checksumLevels; i++ {
+		for _, side := range [][]models.PriceLevel{bids, asks} {
+			if i < len(side) {
+				fields = append(fields, side[i].Price.String(), side[i].Quantity.String())
+			}
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(strings.Join(fields, ":")))
+}
+
+// BookChange is the new state of a price level on one side of the book; a zero quantity means
+// the level is gone
+type BookChange struct {
+	Side  models.OrderSide
+	Level models.PriceLevel
+}
+
+// MarketEventSink receives the market events of every symbol. Publish is called on the
+// publishing symbol's engine goroutine, so it must not block.
+type MarketEventSink interface {
+	Publish(event *MarketEvent)
+}
+
+// PublishTo adds a sink receiving every market event. It must be called before the service
+// handles orders.
+func (s *MatchingService) PublishTo(sink MarketEventSink) {
+	s.events = append(s.events, sink)
+}
+
+// levelKey identifies a price level on one side of the engine's book
+type levelKey struct {
+	bids  bool
+	price models.Decimal
+}
+
+// touchLevel records a level's state before the running command first changes it, so its
+// change can be published once the command finishes. Loading the book publishes nothing.
+func (e *symbolEngine) touchLevel(bids bool, price models.Decimal) {
+	if len(e.events) == 0 || !e.loaded {
+		return
+	}
+	key := levelKey{bids, price}
+	if _, ok := e.touched[key]; !ok {
+		e.touched[key] = e.levelDepth(bids, price)
+	}
+}
+
+// levelDepth aggregates the visible orders of one price level, zero quantity when it is empty
+func (e *symbolEngine) levelDepth(bids bool, price models.Decimal) models.PriceLevel {
+	level := models.PriceLevel{Price: price}
+	if entry := findLevel(e.bookSide(bids)[e.symbol], bids, price); entry != nil {
+		level.OrderCount = len(entry.Orders)
+		for _, order := range entry.Orders {
+			level.Quantity += visibleQuantity(order)
+		}
+	}
+	return level
+}
+
+// announceTrades counts committed trades towards the order flow and queues them for publication
+// once the running command finishes
+func (e *symbolEngine) announceTrades(trades []*models.Trade) {
+	if len(trades) > 0 {
+		e.flow.add(FlowTrade, len(trades), time.Now())
+		metrics.TradesExecuted.WithLabelValues(e.symbol).Add(float64(len(trades)))
+	}
+	if len(e.events) > 0 {
+		e.printed = append(e.printed, trades...)
+	}
+	e.journalTrades = append(e.journalTrades, trades...)
+}
+
+// publishMarketEvents publishes the book changes and trades of the command that just finished.
+// Levels a rolled-back transaction restored are unchanged and publish nothing.
+func (e *symbolEngine) publishMarketEvents() {
+	if len(e.events) == 0 {
+		return
+	}
+	now := time.Now()
+
+	var changes []BookChange
+	for key, before := range e.touched {
+		if after := e.levelDepth(key.bids, key.price); after != before {
+			side := models.SideSell
+			if key.bids {
+				side = models.SideBuy
+			}
+			changes = append(changes, BookChange{Side: side, Level: after})
+		}
+	}
+	clear(e.touched)
+	if len(changes) > 0 {
+		// Bids best first, then asks best first
+		sort.Slice(changes, func(i, j int) bool {
+			a, b := changes[i], changes[j]
+			if a.Side != b.Side {
+				return a.Side == models.SideBuy
+			}
+			return ahead(a.Side == models.SideBuy, a.Level.Price, b.Level.Price)
+		})
+		e.bookSequence++
+		e.publish(&MarketEvent{Symbol: e.symbol, Kind: EventBook, Sequence: e.bookSequence, PrevSequence: e.bookSequence - 1,
+			Checksum: e.bookChecksum(), Changes: changes, Time: now})
+	}
+
+	if len(e.printed) > 0 {
+		e.tradeSequence++
+		e.publish(&MarketEvent{Symbol: e.symbol, Kind: EventTrade, Sequence: e.tradeSequence, Trades: e.printed, Time: now})
+		e.printed = nil
+	}
+}
+
+// publish hands an event to every sink
+func (e *symbolEngine) publish(event *MarketEvent) {
+	for _, sink := range e.events {
+		sink.Publish(event)
+	}
+}
+
+// SnapshotBook takes a snapshot of every price level of a symbol's book, tagged with the
+// sequence of the last book event, and hands it to fn. fn runs on the symbol's engine, so no
+// book event is published between the snapshot and fn's return; a subscriber registering in fn
+// receives exactly the changes that follow the snapshot.
+func (s *MatchingService) SnapshotBook(symbol string, fn func(snapshot *MarketEvent)) error {
+	if err := s.knownSymbol(symbol); err != nil {
+		return err
+	}
+	return s.engine(symbol).do(func(e *symbolEngine) error {
+		fn(&MarketEvent{
+			Symbol:   symbol,
+			Kind:     EventSnapshot,
+			Sequence: e.bookSequence,
+			Checksum: e.bookChecksum(),
+			Bids:     depthLevels(e.orderBook.Bids[symbol], 0),
+			Asks:     depthLevels(e.orderBook.Asks[symbol], 0),
+			Time:     time.Now(),
+		})
+		return nil
+	})
+}
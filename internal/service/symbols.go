@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// loadSymbols reads the symbol registry into memory unless already loaded, first listing every
+// configured symbol the registry does not hold yet so configurations predating the registry
+// keep trading. A failed load is retried on the next lookup.
+func (c *core) loadSymbols(ctx context.Context) error {
+	c.symbolsMutex.RLock()
+	loaded := c.listings != nil
+	c.symbolsMutex.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	c.symbolsMutex.Lock()
+	defer c.symbolsMutex.Unlock()
+	if c.listings != nil {
+		return nil
+	}
+
+	symbols, err := c.repo.GetSymbols(ctx)
+	if err != nil {
+		c.logger.Error("Failed to load symbol registry", zap.Error(err))
+		return repository.Classify(err)
+	}
+	listings := make(map[string]*models.Symbol, len(symbols))
+	for _, symbol := range symbols {
+		listings[symbol.Symbol] = symbol
+	}
+
+	configured := make([]string, 0, len(c.cfg.Symbols))
+	for symbol := range c.cfg.Symbols {
+		if _, ok := listings[symbol]; !ok {
+			configured = append(configured, symbol)
+		}
+	}
+	sort.Strings(configured)
+	now := time.Now()
+	for _, symbol := range configured {
+		sc := c.cfg.Symbols[symbol]
+		listing := &models.Symbol{
+			Symbol:        symbol,
+			BaseCurrency:  sc.BaseCurrency,
+			QuoteCurrency: sc.QuoteCurrency,
+			LotSize:       models.NewDecimal(sc.LotSize),
+			Status:        models.SymbolActive,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		// Another instance sharing the database may have listed it first
+		if err := c.repo.SaveSymbol(ctx, listing); err != nil && repository.Classify(err) != repository.ErrDuplicateKey {
+			c.logger.Error("Failed to list configured symbol", zap.String("symbol", symbol), zap.Error(err))
+			return repository.Classify(err)
+		}
+		c.logger.Info("Configured symbol listed", zap.String("symbol", symbol))
+		listings[symbol] = listing
+	}
+	c.listings = listings
+	return nil
+}
+
+// listing returns a copy of a symbol's registry entry, nil when it is not listed or the
+// registry cannot be loaded
+func (c *core) listing(ctx context.Context, symbol string) *models.Symbol {
+	if c.loadSymbols(ctx) != nil {
+		return nil
+	}
+	c.symbolsMutex.RLock()
+	defer c.symbolsMutex.RUnlock()
+	listing, ok := c.listings[symbol]
+	if !ok {
+		return nil
+	}
+	copied := *listing
+	return &copied
+}
+
+// symbolConfig returns a symbol's settings with the currencies it is listed under, reporting
+// false when the symbol is neither configured nor listed
+func (c *core) symbolConfig(ctx context.Context, symbol string) (config.SymbolConfig, bool) {
+	sc, ok := c.cfg.Symbols[symbol]
+	if listing := c.listing(ctx, symbol); listing != nil {
+		sc.BaseCurrency, sc.QuoteCurrency, ok = listing.BaseCurrency, listing.QuoteCurrency, true
+	}
+	return sc, ok
+}
+
+// SymbolConfig returns a symbol's settings with the currencies it is listed under, reporting
+// false when the symbol is neither configured nor listed
+func (s *MatchingService) SymbolConfig(ctx context.Context, symbol string) (config.SymbolConfig, bool) {
+	return s.symbolConfig(ctx, symbol)
+}
+
+// onLot reports whether a quantity is a multiple of the lot size, always when the lot is 0
+func onLot(quantity, lot models.Decimal) bool {
+	return lot == 0 || quantity%lot == 0
+}
+
+// checkListing checks an order against its symbol's registry entry: the symbol must be listed
+// and neither halted nor delisted, the order's total and displayed quantities multiples of the
+// lot size and, when limit-priced, its value at least the symbol's minimum notional
+func (e *symbolEngine) checkListing(order *models.Order) error {
+	listing := e.listing(e.ctx, order.Symbol)
+	if listing != nil && listing.Status == models.SymbolHalted {
+		e.logger.Warn("Order for a halted symbol", zap.String("symbol", order.Symbol))
+		return models.ErrSymbolHalted
+	}
+	if listing == nil || listing.Status != models.SymbolActive {
+		e.logger.Warn("Order for a symbol that is not listed", zap.String("symbol", order.Symbol))
+		return models.ErrSymbolNotListed
+	}
+	if !onLot(order.InitialQuantity, listing.LotSize) || isIceberg(order) && !onLot(order.DisplayQuantity.Decimal, listing.LotSize) {
+		e.logger.Warn("Order quantity off the lot size", zap.Any("order", order), zap.Stringer("lot_size", listing.LotSize))
+		return models.ErrOffLot
+	}
+	if isLimitPriced(order) && models.Notional(order.Price.Decimal, order.InitialQuantity) < listing.MinNotional {
+		e.logger.Warn("Order below the minimum notional", zap.Any("order", order), zap.Float64("min_notional", listing.MinNotional))
+		return models.ErrBelowMinNotional
+	}
+	return nil
+}
+
+// validListing reports whether a registry entry's currencies, lot size and minimum notional
+// are usable
+func validListing(listing *models.Symbol) bool {
+	return listing.BaseCurrency != "" && listing.QuoteCurrency != "" && listing.BaseCurrency != listing.QuoteCurrency &&
+		(listing.LotSize == 0 || config.ValidTickSize(listing.LotSize.Float64())) && listing.MinNotional >= 0
+}
+
+// Symbols returns the symbol registry by symbol, delisted symbols included, with their current
+// tick sizes
+func (s *MatchingService) Symbols(ctx context.Context) ([]*models.Symbol, error) {
+	if err := s.loadSymbols(ctx); err != nil {
+		return nil, err
+	}
+	s.symbolsMutex.RLock()
+	symbols := make([]*models.Symbol, 0, len(s.listings))
+	for _, listing := range s.listings {
+		copied := *listing
+		symbols = append(symbols, &copied)
+	}
+	s.symbolsMutex.RUnlock()
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+	for _, symbol := range symbols {
+		if err := s.fillTickSize(ctx, symbol); err != nil {
+			return nil, err
+		}
+	}
+	return symbols, nil
+}
+
+// Symbol returns a symbol's registry entry with its current tick size
+func (s *MatchingService) Symbol(ctx context.Context, symbol string) (*models.Symbol, error) {
+	if err := s.loadSymbols(ctx); err != nil {
+		return nil, err
+	}
+	listing := s.listing(ctx, symbol)
+	if listing == nil {
+		return nil, models.ErrSymbolNotFound
+	}
+	if err := s.fillTickSize(ctx, listing); err != nil {
+		return nil, err
+	}
+	return listing, nil
+}
+
+// fillTickSize sets a listed symbol's tick size to the one it was last changed to or, failing
+// that, the configured one
+func (s *MatchingService) fillTickSize(ctx context.Context, listing *models.Symbol) error {
+	tick, err := s.repo.GetTickSize(ctx, listing.Symbol)
+	if err != nil {
+		s.logger.Error("Failed to get tick size", zap.String("symbol", listing.Symbol), zap.Error(err))
+		return repository.Classify(err)
+	}
+	if tick == 0 {
+		tick = models.NewDecimal(s.cfg.Symbols[listing.Symbol].TickSize)
+	}
+	listing.TickSize = tick
+	return nil
+}
+
+// ListSymbol adds an active symbol to the registry, then moves it to a tick size unless tick is
+// 0; a failed tick size change leaves the symbol listed on its previous tick size. A symbol
+// already in the registry, even delisted, cannot be listed again.
+func (s *MatchingService) ListSymbol(ctx context.Context, listing *models.Symbol, tick models.Decimal) error {
+	if listing.Symbol == "" || !validListing(listing) || tick != 0 && !config.ValidTickSize(tick.Float64()) {
+		s.logger.Warn("Invalid symbol", zap.Any("symbol", listing), zap.Stringer("tick_size", tick))
+		return models.ErrInvalidSymbol
+	}
+	if err := s.loadSymbols(ctx); err != nil {
+		return err
+	}
+
+	s.symbolsMutex.Lock()
+	listing.Status = models.SymbolActive
+	listing.CreatedAt = time.Now()
+	listing.UpdatedAt = listing.CreatedAt
+	if err := s.repo.SaveSymbol(ctx, listing); err != nil {
+		s.symbolsMutex.Unlock()
+		s.logger.Error("Failed to save symbol", zap.Error(err))
+		return repository.Classify(err)
+	}
+	copied := *listing
+	s.listings[listing.Symbol] = &copied
+	s.symbolsMutex.Unlock()
+
+	s.logger.Info("Symbol listed", zap.String("symbol", listing.Symbol), zap.String("base_currency", listing.BaseCurrency),
+		zap.String("quote_currency", listing.QuoteCurrency), zap.Stringer("lot_size", listing.LotSize))
+	if tick != 0 {
+		// The registry lock is released first, as the engine looks listings up
+		if _, err := s.ChangeTickSize(listing.Symbol, tick, TickReprice); err != nil {
+			return err
+		}
+	}
+	return s.fillTickSize(ctx, listing)
+}
+
+// UpdateSymbol replaces a listed symbol's lot size and minimum notional, returning the updated
+// entry. Orders already resting keep their quantities; only new orders and amendments are
+// checked against the new values.
+func (s *MatchingService) UpdateSymbol(ctx context.Context, update *models.Symbol) (*models.Symbol, error) {
+	if err := s.loadSymbols(ctx); err != nil {
+		return nil, err
+	}
+
+	s.symbolsMutex.Lock()
+	current, ok := s.listings[update.Symbol]
+	if !ok {
+		s.symbolsMutex.Unlock()
+		return nil, models.ErrSymbolNotFound
+	}
+	updated := *current
+	updated.LotSize = update.LotSize
+	updated.MinNotional = update.MinNotional
+	updated.UpdatedAt = time.Now()
+	if !validListing(&updated) {
+		s.symbolsMutex.Unlock()
+		s.logger.Warn("Invalid symbol", zap.Any("symbol", &updated))
+		return nil, models.ErrInvalidSymbol
+	}
+	if err := s.repo.UpdateSymbol(ctx, &updated); err != nil {
+		s.symbolsMutex.Unlock()
+		s.logger.Error("Failed to update symbol", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	*current = updated
+	s.symbolsMutex.Unlock()
+
+	s.logger.Info("Symbol updated", zap.String("symbol", updated.Symbol), zap.Stringer("lot_size", updated.LotSize),
+		zap.Float64("min_notional", updated.MinNotional))
+	if err := s.fillTickSize(ctx, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DelistSymbol stops a symbol taking orders and cancels its resting and pending stop orders,
+// returning the IDs of the orders canceled. The symbol keeps its registry entry and history.
+// Delisting a symbol again cancels any orders a failed delisting left behind.
+func (s *MatchingService) DelistSymbol(ctx context.Context, symbol string) ([]uint64, error) {
+	if err := s.loadSymbols(ctx); err != nil {
+		return nil, err
+	}
+	if s.listing(ctx, symbol) == nil {
+		return nil, models.ErrSymbolNotFound
+	}
+	return call(s, symbol, func(e *symbolEngine) ([]uint64, error) {
+		return e.delist()
+	})
+}
+
+// delist marks the engine's symbol delisted, so no order placed after it is accepted, then
+// cancels its orders
+func (e *symbolEngine) delist() ([]uint64, error) {
+	if err := e.setStatus(models.SymbolDelisted); err != nil {
+		return nil, err
+	}
+	canceled, err := e.cancelAll(models.OrderChange{Actor: models.ActorOperator, Reason: models.ReasonDelisted})
+	if err != nil {
+		return canceled, err
+	}
+	e.logger.Info("Symbol delisted", zap.String("symbol", e.symbol), zap.Int("canceled", len(canceled)))
+	return canceled, nil
+}
+
+// setStatus stores and applies a new registry status for the engine's symbol
+func (e *symbolEngine) setStatus(status models.SymbolStatus) error {
+	e.symbolsMutex.Lock()
+	defer e.symbolsMutex.Unlock()
+	current, ok := e.listings[e.symbol]
+	if !ok {
+		return models.ErrSymbolNotFound
+	}
+	if current.Status == status {
+		return nil
+	}
+	updated := *current
+	updated.Status = status
+	updated.UpdatedAt = time.Now()
+	if err := e.repo.UpdateSymbol(e.ctx, &updated); err != nil {
+		e.logger.Error("Failed to update symbol status", zap.String("status", string(status)), zap.Error(err))
+		return repository.Classify(err)
+	}
+	*current = updated
+	return nil
+}
+
+// cancelAll cancels the engine's resting and pending stop orders one at a time, as if each had
+// been canceled on its own with the change, and returns their IDs
+func (e *symbolEngine) cancelAll(change models.OrderChange) ([]uint64, error) {
+	var orderIDs []uint64
+	for _, bids := range []bool{true, false} {
+		for _, entry := range e.bookSide(bids)[e.symbol] {
+			for _, order := range entry.Orders {
+				orderIDs = append(orderIDs, order.OrderID)
+			}
+		}
+	}
+	for _, order := range e.stops.orders() {
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+
+	canceled := make([]uint64, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		e.journal(&models.JournalEntry{Kind: models.JournalCancel, OrderID: orderID, Change: change})
+		err := e.cancelOrder(orderID, change)
+		e.countFlow(FlowCancel, err)
+		// An order that is no longer open went with another leg of its group
+		if err != nil && err != models.ErrOrderNotOpen {
+			e.logger.Error("Failed to cancel order", zap.Uint64("order_id", orderID), zap.Error(err))
+			return canceled, err
+		}
+		canceled = append(canceled, orderID)
+	}
+	return canceled, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"testing"
+)
+
+// TestListSymbolWithEightDecimalLot lists a symbol whose lot and tick are the smallest Decimal
+// unit, finer than any cent grid, and checks orders are held to the lot
+func TestListSymbolWithEightDecimalLot(t *testing.T) {
+	s, _ := newTestService(t, testSetup{})
+	ctx := context.Background()
+	lot := models.NewDecimal(0.00000001)
+	listing := &models.Symbol{Symbol: "SATUSD", BaseCurrency: "SAT", QuoteCurrency: "USD", LotSize: lot}
+	if err := s.ListSymbol(ctx, listing, lot); err != nil {
+		t.Fatalf("listing an 8-decimal lot: %v", err)
+	}
+	listed, err := s.Symbol(ctx, "SATUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.LotSize != lot || listed.TickSize != lot {
+		t.Errorf("listed lot %s and tick %s, want %s", listed.LotSize, listed.TickSize, lot)
+	}
+
+	placeAll(t, s, limitOrder("SATUSD", models.SideSell, 1.00000001, 0.00000003))
+	update := &models.Symbol{Symbol: "SATUSD", LotSize: models.NewDecimal(0.00000002)}
+	if _, err := s.UpdateSymbol(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PlaceOrder(ctx, limitOrder("SATUSD", models.SideSell, 2, 0.00000003)); err != models.ErrOffLot {
+		t.Errorf("quantity off the updated lot: %v, want %v", err, models.ErrOffLot)
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"orderSystem/internal/config"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TickPolicy is what a tick size change does with orders whose prices leave the new grid
+type TickPolicy string
+
+// Tick size change policies
+const (
+	TickReprice TickPolicy = "reprice" // move prices to the nearest tick away from the market, canceling what cannot move
+	TickCancel  TickPolicy = "cancel"
+)
+
+// TickChange is the outcome of a symbol's tick size change: its orders as they stand after it
+type TickChange struct {
+	Symbol   string
+	Previous models.Decimal // 0 when any price was accepted
+	TickSize models.Decimal
+	Repriced []*models.Order
+	Canceled []*models.Order
+}
+
+// onTick reports whether a price is a multiple of the tick size, always when the tick is 0
+func onTick(price, tick models.Decimal) bool {
+	return tick == 0 || price%tick == 0
+}
+
+// offTick reports whether an order's price or trigger price is off a tick grid
+func offTick(order *models.Order, tick models.Decimal) bool {
+	return order.Price.Valid && !onTick(order.Price.Decimal, tick) ||
+		order.TriggerPrice.Valid && !onTick(order.TriggerPrice.Decimal, tick)
+}
+
+// snapTick moves a price to a multiple of the tick size, up or down
+func snapTick(price, tick models.Decimal, up bool) models.Decimal {
+	floor := price - price%tick
+	if up && floor != price {
+		return floor + tick
+	}
+	return floor
+}
+
+// retick moves an order's prices onto a new tick grid away from the market, so no order becomes
+// more aggressive: a limit price down for buys and up for sells, a trigger price up for buy stops
+// and down for sell stops. It reports false when a price would fall to zero.
+func retick(order *models.Order, tick models.Decimal) bool {
+	buy := order.Side == models.SideBuy
+	if order.Price.Valid {
+		order.Price.Decimal = snapTick(order.Price.Decimal, tick, !buy)
+	}
+	if order.TriggerPrice.Valid {
+		order.TriggerPrice.Decimal = snapTick(order.TriggerPrice.Decimal, tick, buy)
+	}
+	return (!order.Price.Valid || order.Price.Decimal > 0) && (!order.TriggerPrice.Valid || order.TriggerPrice.Decimal > 0)
+}
+
+// ChangeTickSize moves a symbol to a new tick size. The symbol's engine stops taking orders
+// while its resting and pending stop orders that leave the new grid are repriced or canceled
+// according to policy, and the order changes and the new tick size are committed in one
+// transaction before trading resumes on the new grid. An order canceled as a leg of an order
+// group cancels its group. The new tick size is stored, overriding the configured one.
+func (s *MatchingService) ChangeTickSize(symbol string, tick models.Decimal, policy TickPolicy) (*TickChange, error) {
+	if !config.ValidTickSize(tick.Float64()) {
+		s.logger.Warn("Invalid tick size", zap.String("symbol", symbol), zap.Stringer("tick_size", tick))
+		return nil, models.ErrInvalidTickSize
+	}
+	return call(s, symbol, func(e *symbolEngine) (*TickChange, error) {
+		e.journal(&models.JournalEntry{Kind: models.JournalTickSize, TickSize: tick, Policy: string(policy)})
+		return e.changeTickSize(tick, policy)
+	})
+}
+
+// TickSize returns a symbol's current tick size, 0 when any price is accepted
+func (s *MatchingService) TickSize(symbol string) (models.Decimal, error) {
+	if err := s.knownSymbol(symbol); err != nil {
+		return 0, err
+	}
+	return call(s, symbol, func(e *symbolEngine) (models.Decimal, error) {
+		return e.tickSize, nil
+	})
+}
+
+// changeTickSize moves the engine's symbol to a new tick size. Every change is worked out and
+// committed before the book is touched, so a failed transaction leaves the symbol as it was.
+func (e *symbolEngine) changeTickSize(tick models.Decimal, policy TickPolicy) (*TickChange, error) {
+	var affected []*models.Order
+	for _, bids := range []bool{true, false} {
+		for _, entry := range e.bookSide(bids)[e.symbol] {
+			affected = append(affected, entry.Orders...)
+		}
+	}
+	affected = append(affected, e.stops.orders()...)
+
+	change := &TickChange{Symbol: e.symbol, Previous: e.tickSize, TickSize: tick}
+	repriced := make(map[*models.Order]models.Order)
+	var canceled []*models.Order
+	groups := make(map[uint64]*orderGroup)
+	for _, order := range affected {
+		if !offTick(order, tick) {
+			continue
+		}
+		amended := *order
+		if policy == TickReprice && retick(&amended, tick) {
+			if order.Status.Resting() {
+				amended.Sequence = e.nextSequence()
+			}
+			repriced[order] = amended
+			continue
+		}
+		if g, ok := e.groupLegs[order.OrderID]; ok {
+			groups[g.group.GroupID] = g
+			continue
+		}
+		canceled = append(canceled, order)
+	}
+	// A group canceled for one leg takes its other leg down even if it could be repriced
+	for order := range repriced {
+		if g, ok := e.groupLegs[order.OrderID]; ok && groups[g.group.GroupID] != nil {
+			delete(repriced, order)
+		}
+	}
+
+	tx, err := e.beginTx(e.symbol)
+	if err != nil {
+		e.logger.Error("Failed to start transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	defer tx.Rollback()
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("tick_size"), time.Now())
+
+	repricing := models.OrderChange{Actor: models.ActorOperator}
+	cancel := models.OrderChange{Actor: models.ActorOperator, Reason: models.ReasonTickSize}
+	for _, order := range affected {
+		amended, ok := repriced[order]
+		if !ok {
+			continue
+		}
+		if err := e.updateOrderTx(tx, &amended, repricing); err != nil {
+			e.logger.Error("Failed to reprice order", zap.Uint64("order_id", order.OrderID), zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+	}
+	for _, order := range canceled {
+		update := *order
+		update.Status = models.StatusCanceled
+		if err := e.updateOrderTx(tx, &update, cancel); err != nil {
+			e.logger.Error("Failed to cancel order", zap.Uint64("order_id", order.OrderID), zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+	}
+	for _, g := range groups {
+		for _, leg := range g.legs {
+			if !leg.Status.Resting() && leg.Status != models.StatusPending {
+				continue
+			}
+			update := *leg
+			update.Status = models.StatusCanceled
+			if err := e.updateOrderTx(tx, &update, cancel); err != nil {
+				e.logger.Error("Failed to cancel order", zap.Uint64("order_id", leg.OrderID), zap.Error(err))
+				return nil, repository.Classify(err)
+			}
+		}
+		update := *g.group
+		update.Status = models.GroupCanceled
+		if err := e.repo.UpdateOrderGroupTx(e.ctx, tx, &update); err != nil {
+			e.logger.Error("Failed to cancel order group", zap.Uint64("group_id", g.group.GroupID), zap.Error(err))
+			return nil, repository.Classify(err)
+		}
+	}
+	if err := e.repo.SaveTickSizeTx(e.ctx, tx, e.symbol, tick); err != nil {
+		e.logger.Error("Failed to save tick size", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	if err := e.commit(tx, nil); err != nil {
+		e.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+
+	// Repriced orders rejoin the book at the back of their new level; stops move to their new
+	// trigger on the ladder, keeping their arrival order
+	for _, order := range affected {
+		amended, ok := repriced[order]
+		if !ok {
+			continue
+		}
+		if order.Status.Resting() {
+			e.removeFromOrderBook(order)
+			*order = amended
+			e.addToOrderBook(order)
+		} else {
+			e.removeStop(order)
+			*order = amended
+			e.stops.add(order)
+		}
+		change.Repriced = append(change.Repriced, order)
+	}
+	for _, order := range canceled {
+		e.dropLeg(order)
+		change.Canceled = append(change.Canceled, order)
+	}
+	for _, g := range groups {
+		for _, leg := range g.legs {
+			delete(e.groupLegs, leg.OrderID)
+			if leg.Status.Resting() || leg.Status == models.StatusPending {
+				e.dropLeg(leg)
+				change.Canceled = append(change.Canceled, leg)
+			}
+		}
+		g.group.Status = models.GroupCanceled
+		delete(e.groups, g.group.GroupID)
+	}
+	e.tickSize = tick
+	e.logger.Info("Tick size changed", zap.String("symbol", e.symbol), zap.Stringer("previous", change.Previous),
+		zap.Stringer("tick_size", tick), zap.Int("repriced", len(change.Repriced)), zap.Int("canceled", len(change.Canceled)))
+	return change, nil
+}
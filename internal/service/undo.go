@@ -0,0 +1,100 @@
+package service
+
+import "orderSystem/internal/models"
+
+// bookUndo journals the in-memory book changes made while a matching transaction is open, so
+// the book can be put back exactly as it was if the transaction rolls back
+type bookUndo struct {
+	orders    map[*models.Order]models.Order
+	levels    map[*models.OrderBookEntry][]*models.Order
+	sides     []sideUndo
+	evictions uint64
+}
+
+// sideUndo is the saved price levels of one side of a symbol's book
+type sideUndo struct {
+	bids    bool
+	symbol  string
+	entries []*models.OrderBookEntry
+	exists  bool
+}
+
+// beginUndo starts journaling book changes
+func (e *symbolEngine) beginUndo() {
+	e.undo = &bookUndo{
+		orders:    make(map[*models.Order]models.Order),
+		levels:    make(map[*models.OrderBookEntry][]*models.Order),
+		evictions: e.orderBook.evictions,
+	}
+}
+
+// endUndo stops journaling, reverting every journaled change unless the transaction committed.
+func (e *symbolEngine) endUndo(committed bool) {
+	undo := e.undo
+	e.undo = nil
+	if committed || undo == nil {
+		return
+	}
+	for order, saved := range undo.orders {
+		*order = saved
+	}
+	for entry, saved := range undo.levels {
+		entry.Orders = saved
+	}
+	for _, saved := range undo.sides {
+		side := e.bookSide(saved.bids)
+		if saved.exists {
+			side[saved.symbol] = saved.entries
+		} else {
+			delete(side, saved.symbol)
+		}
+	}
+	e.orderBook.evictions = undo.evictions
+}
+
+// journalOrder saves an order's fields before its first change in the transaction
+func (e *symbolEngine) journalOrder(order *models.Order) {
+	if e.undo == nil {
+		return
+	}
+	if _, ok := e.undo.orders[order]; !ok {
+		e.undo.orders[order] = *order
+	}
+}
+
+// journalLevel saves a price level's queue before its first change in the transaction
+func (e *symbolEngine) journalLevel(entry *models.OrderBookEntry) {
+	if e.undo == nil {
+		return
+	}
+	if _, ok := e.undo.levels[entry]; !ok {
+		e.undo.levels[entry] = append([]*models.Order(nil), entry.Orders...)
+	}
+}
+
+// journalSide saves one side of a symbol's book before its first change in the transaction
+func (e *symbolEngine) journalSide(bids bool, symbol string) {
+	if e.undo == nil {
+		return
+	}
+	for _, saved := range e.undo.sides {
+		if saved.bids == bids && saved.symbol == symbol {
+			return
+		}
+	}
+	entries, exists := e.bookSide(bids)[symbol]
+	e.undo.sides = append(e.undo.sides, sideUndo{
+		bids:    bids,
+		symbol:  symbol,
+		entries: append([]*models.OrderBookEntry(nil), entries...),
+		exists:  exists,
+	})
+}
+
+// bookSide returns the bid or ask side of the book
+func (e *symbolEngine) bookSide(bids bool) map[string][]*models.OrderBookEntry {
+	if bids {
+		return e.orderBook.Bids
+	}
+	return e.orderBook.Asks
+}
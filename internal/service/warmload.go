@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"orderSystem/internal/models"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ensureLoaded warm-loads the symbol's open orders from the database into the book before the
+// engine's first command that needs them, relinking any that are legs of active order groups,
+// along with any tick size it was changed to. The engine runs one command at a time, so
+// requests arriving while the symbol loads queue behind the load. A failed fetch leaves the
+// symbol unloaded so the next command retries it.
+func (e *symbolEngine) ensureLoaded() error {
+	if e.loaded {
+		return nil
+	}
+	if err := e.awaitWrites(); err != nil {
+		return err
+	}
+	orders, err := e.loadOrders()
+	if err != nil {
+		e.logger.Error("Failed to load order book", zap.String("symbol", e.symbol), zap.Error(err))
+		return err
+	}
+	trade, err := e.repo.GetLastBookTrade(e.ctx, e.symbol)
+	if err != nil {
+		e.logger.Error("Failed to load last trade price", zap.String("symbol", e.symbol), zap.Error(err))
+		return err
+	}
+	tick, err := e.repo.GetTickSize(e.ctx, e.symbol)
+	if err != nil {
+		e.logger.Error("Failed to load tick size", zap.String("symbol", e.symbol), zap.Error(err))
+		return err
+	}
+	if e.cfg.Engine.Journal {
+		if e.journalSequence, err = e.repo.GetJournalSequence(e.ctx, e.symbol); err != nil {
+			e.logger.Error("Failed to load engine journal sequence", zap.String("symbol", e.symbol), zap.Error(err))
+			return err
+		}
+	}
+
+	for _, order := range orders {
+		e.addToOrderBook(order)
+		e.linkGroupLeg(order)
+		e.raiseSequence(order.Sequence)
+	}
+	if _, ok := e.lastPrice[e.symbol]; !ok && trade != nil {
+		e.lastPrice[e.symbol] = trade.Price
+		e.lastTradeAt[e.symbol] = trade.CreatedAt
+	}
+	if tick > 0 {
+		e.tickSize = tick
+	}
+	e.loaded = true
+	e.logger.Info("Symbol loaded", zap.String("symbol", e.symbol), zap.Int("orders", len(orders)))
+	return nil
+}
+
+// WarmLoad loads the given symbols into the book ahead of their first use, each on its own
+// engine in parallel, logging failures; symbols that fail are retried on demand
+func (s *MatchingService) WarmLoad(symbols []string) {
+	var wg sync.WaitGroup
+	for _, symbol := range symbols {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			if err := s.engine(symbol).do(func(*symbolEngine) error { return nil }); err != nil {
+				s.logger.Warn("Failed to warm-load symbol", zap.String("symbol", symbol), zap.Error(err))
+			}
+		}(symbol)
+	}
+	wg.Wait()
+}
+
+// loadOrders returns the symbol's open orders in time priority. With a book snapshot stored, it
+// reads the snapshot and only the orders changed since, rather than every open order; a snapshot
+// that cannot be read is passed over.
+func (e *symbolEngine) loadOrders() ([]*models.Order, error) {
+	snapshot, err := e.repo.GetBookSnapshot(e.ctx, e.symbol)
+	if err != nil {
+		e.logger.Warn("Failed to read book snapshot, loading orders", zap.String("symbol", e.symbol), zap.Error(err))
+	}
+	if snapshot == nil {
+		return e.repo.GetOrderBook(e.ctx, e.symbol)
+	}
+	changed, err := e.repo.GetChangedOrders(e.ctx, e.symbol, snapshot.LastEventID)
+	if err != nil {
+		return nil, err
+	}
+
+	open := make(map[uint64]*models.Order, len(snapshot.Orders))
+	for _, order := range snapshot.Orders {
+		open[order.OrderID] = order
+	}
+	for _, order := range changed {
+		if order.Status.Resting() {
+			open[order.OrderID] = order
+		} else {
+			delete(open, order.OrderID)
+		}
+	}
+	orders := make([]*models.Order, 0, len(open))
+	for _, order := range open {
+		orders = append(orders, order)
+	}
+	// The same order GetOrderBook reads them in
+	sort.Slice(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		if a.Sequence != b.Sequence {
+			return a.Sequence < b.Sequence
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.OrderID < b.OrderID
+	})
+	e.logger.Info("Book snapshot read", zap.String("symbol", e.symbol), zap.Time("taken_at", snapshot.CreatedAt),
+		zap.Int("orders", len(snapshot.Orders)), zap.Int("changed", len(changed)))
+	return orders, nil
+}
+
+// snapshotBook copies the symbol's book, level by level in time priority, along with the last
+// order event of its shard; no command is running, so once the engine's queued writes are written
+// every change to the book so far is committed and no later one is
+func (e *symbolEngine) snapshotBook() (*models.BookSnapshot, error) {
+	if err := e.awaitWrites(); err != nil {
+		return nil, err
+	}
+	lastEventID, err := e.repo.ForSymbol(e.symbol).GetLastOrderEventID(e.ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &models.BookSnapshot{Symbol: e.symbol, LastEventID: lastEventID, CreatedAt: time.Now()}
+	for _, bids := range []bool{true, false} {
+		for _, entry := range e.bookSide(bids)[e.symbol] {
+			for _, order := range entry.Orders {
+				copied := *order
+				snapshot.Orders = append(snapshot.Orders, &copied)
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// RunBookSnapshots stores a snapshot of every loaded symbol's book each interval until ctx is
+// canceled, so the next start loads it with only the orders changed since. Each book is copied
+// on its engine, then serialized and stored while the engine goes on matching.
+func (s *MatchingService) RunBookSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, e := range s.allEngines() {
+				var snapshot *models.BookSnapshot
+				var err error
+				e.inspect(func(e *symbolEngine) {
+					if e.loaded {
+						snapshot, err = e.snapshotBook()
+					}
+				})
+				if err == nil && snapshot != nil {
+					err = s.repo.SaveBookSnapshot(ctx, snapshot)
+				}
+				if err != nil {
+					s.logger.Error("Failed to save book snapshot", zap.String("symbol", e.symbol), zap.Error(err))
+				}
+			}
+		}
+	}
+}
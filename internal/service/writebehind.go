@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/writebehind"
+)
+
+// clientOrderStripes is how many locks placements under client order IDs are spread over by owner
+const clientOrderStripes = 64
+
+// SetWriteBehind has the engines commit their transactions to a write-behind queue rather than
+// to the database, so matching waits on the queue's log and not on the database. It must be
+// called before the service handles orders.
+func (s *MatchingService) SetWriteBehind(queue *writebehind.Queue) {
+	s.writeBehind = queue
+}
+
+// beginTx begins a transaction for the engine's writes to a symbol's shard, queued to be written
+// behind when write-behind is on
+func (e *symbolEngine) beginTx(symbol string) (repository.Tx, error) {
+	if e.writeBehind != nil {
+		return e.writeBehind.Begin(e.repo.ShardIndex(symbol)), nil
+	}
+	tx, err := e.repo.ForSymbol(symbol).BeginTx(e.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// awaitWrites waits until the writes the engine's shard has queued are written, so what the
+// engine reads from the database reflects every command it has run
+func (e *symbolEngine) awaitWrites() error {
+	if e.writeBehind == nil {
+		return nil
+	}
+	return e.writeBehind.Flush(e.ctx, e.repo.ShardIndex(e.symbol))
+}
+
+// storedOrder reads an order from the database once the engine's queued writes are written
+func (e *symbolEngine) storedOrder(orderID uint64) (*models.Order, error) {
+	if err := e.awaitWrites(); err != nil {
+		return nil, err
+	}
+	return e.repo.GetOrder(e.ctx, orderID)
+}
+
+// lockClientOrder takes, with write-behind on, the lock on placing orders under the owner's
+// client order IDs and waits for every queued write, so the order's resubmission check sees every
+// order placed before it and no other order can be queued under the same client order ID before
+// it is. A queued write is past the unique index that otherwise catches a concurrent duplicate,
+// and would stop its shard's writer. The returned function releases the lock; without
+// write-behind, or for an order without a client order ID, nothing is locked.
+func (s *MatchingService) lockClientOrder(ctx context.Context, order *models.Order) (func(), error) {
+	if s.writeBehind == nil || !order.ClientOrderID.Valid {
+		return func() {}, nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(order.OwnerID))
+	mutex := &s.clientOrderLocks[h.Sum32()%clientOrderStripes]
+	mutex.Lock()
+	if err := s.writeBehind.FlushAll(ctx); err != nil {
+		mutex.Unlock()
+		return nil, err
+	}
+	return mutex.Unlock, nil
+}
+
+// commandOrder reads the order a command names, to send the command to its engine. With
+// write-behind on, an order not found may still be queued, so it is read again once every queued
+// write is written.
+func (s *MatchingService) commandOrder(ctx context.Context, orderID uint64) (*models.Order, error) {
+	order, err := s.repo.GetOrder(ctx, orderID)
+	if err != models.ErrOrderNotFound || s.writeBehind == nil {
+		return order, err
+	}
+	if err := s.writeBehind.FlushAll(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrder(ctx, orderID)
+}
+
+// commandGroup reads the order group a command names as commandOrder reads an order
+func (s *MatchingService) commandGroup(ctx context.Context, groupID uint64) (*models.OrderGroup, error) {
+	group, err := s.repo.GetOrderGroup(ctx, groupID)
+	if err != models.ErrGroupNotFound || s.writeBehind == nil {
+		return group, err
+	}
+	if err := s.writeBehind.FlushAll(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderGroup(ctx, groupID)
+}
+
+// OrderOwner returns the account that placed an order, reading it as a command would so an order
+// still queued by write-behind is found
+func (s *MatchingService) OrderOwner(ctx context.Context, orderID uint64) (string, error) {
+	order, err := s.commandOrder(ctx, orderID)
+	if err != nil {
+		return "", err
+	}
+	return order.OwnerID, nil
+}
+
+// OrderGroupOwner returns the account that placed an order group, like OrderOwner
+func (s *MatchingService) OrderGroupOwner(ctx context.Context, groupID uint64) (string, error) {
+	group, err := s.commandGroup(ctx, groupID)
+	if err != nil {
+		return "", err
+	}
+	return group.OwnerID, nil
+}
+
+// commandBlock reads the block trade report a command names as commandOrder reads an order
+func (s *MatchingService) commandBlock(ctx context.Context, blockID uint64) (*models.BlockReport, error) {
+	report, err := s.repo.GetBlockReport(ctx, blockID)
+	if err != models.ErrBlockNotFound || s.writeBehind == nil {
+		return report, err
+	}
+	if err := s.writeBehind.FlushAll(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBlockReport(ctx, blockID)
+}
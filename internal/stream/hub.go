@@ -0,0 +1,362 @@
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/service"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Topic is a market data channel of one symbol
+type Topic struct {
+	Symbol string
+	Kind   service.MarketEventKind
+}
+
+// Hub fans the matching engine's market events out to the subscribers of each topic. Publishing
+// never waits on a subscriber: one whose queue is full is dropped and its queue closed, so a
+// slow client cannot hold up matching.
+//
+// Each subscriber is a session that outlives its connection for the resume TTL: a client that
+// reconnects with the session's resume token gets its subscriptions back, along with whatever
+// was published while it was away.
+type Hub struct {
+	bufferSize       int
+	maxConnections   int
+	maxSubscriptions int
+	resumeTTL        time.Duration
+	logger           *zap.Logger
+
+	// Subscribers by topic, guarded by mutex
+	topics map[Topic]map[*Subscriber]struct{}
+	mutex  sync.RWMutex
+
+	// Sessions by resume token and what each key holds open, guarded by sessionMutex
+	sessions     map[string]*Subscriber
+	usage        map[string]*usage
+	sessionMutex sync.Mutex
+}
+
+// usage is the attached connections and the subscriptions of every session of one key
+type usage struct {
+	connections   int
+	subscriptions int
+}
+
+// NewHub creates a market event hub
+func NewHub(cfg config.StreamingConfig, logger *zap.Logger) *Hub {
+	return &Hub{
+		bufferSize:       cfg.BufferSize,
+		maxConnections:   cfg.MaxConnections,
+		maxSubscriptions: cfg.MaxSubscriptions,
+		resumeTTL:        cfg.ResumeTTL,
+		logger:           logger,
+		topics:           make(map[Topic]map[*Subscriber]struct{}),
+		sessions:         make(map[string]*Subscriber),
+		usage:            make(map[string]*usage),
+	}
+}
+
+// Subscriber receives the events of the topics it watches, in publication order per topic
+type Subscriber struct {
+	hub    *Hub
+	key    string
+	token  string
+	events chan *service.MarketEvent
+
+	// Watched topics with the sequence last delivered on each, the event whose send failed,
+	// whether a connection is attached, whether events has been closed and whether the session
+	// has ended, guarded by mutex
+	watched  map[Topic]uint64
+	unsent   *service.MarketEvent
+	attached bool
+	closed   bool
+	ended    bool
+	expiry   *time.Timer
+	mutex    sync.Mutex
+}
+
+// Connect opens a session watching no topics for a connection of key, such as a client IP,
+// failing with ErrTooManyConnections when key already has the most connections allowed
+func (h *Hub) Connect(key string) (*Subscriber, error) {
+	if !h.reserve(key, 1, 0) {
+		return nil, models.ErrTooManyConnections
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	s := &Subscriber{
+		hub:      h,
+		key:      key,
+		token:    hex.EncodeToString(secret),
+		events:   make(chan *service.MarketEvent, h.bufferSize),
+		watched:  make(map[Topic]uint64),
+		attached: true,
+	}
+
+	h.sessionMutex.Lock()
+	defer h.sessionMutex.Unlock()
+	h.sessions[s.token] = s
+	return s, nil
+}
+
+// Resume attaches a new connection to the detached session of a resume token. The connection
+// counts against the key that opened the session.
+func (h *Hub) Resume(token string) (*Subscriber, error) {
+	h.sessionMutex.Lock()
+	s, ok := h.sessions[token]
+	h.sessionMutex.Unlock()
+	if !ok {
+		return nil, models.ErrSessionNotFound
+	}
+	if !h.reserve(s.key, 1, 0) {
+		return nil, models.ErrTooManyConnections
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ended || s.closed || s.attached {
+		h.release(s.key, 1, 0)
+		return nil, models.ErrSessionNotFound
+	}
+	s.attached = true
+	s.expiry.Stop()
+	return s, nil
+}
+
+// Publish delivers an event to every subscriber of its topic
+func (h *Hub) Publish(event *service.MarketEvent) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for subscriber := range h.topics[Topic{Symbol: event.Symbol, Kind: event.Kind}] {
+		subscriber.deliver(event)
+	}
+}
+
+// Token returns the secret that resumes the session
+func (s *Subscriber) Token() string {
+	return s.token
+}
+
+// Events returns the subscriber's queue, closed once the subscriber is closed or falls behind
+func (s *Subscriber) Events() <-chan *service.MarketEvent {
+	return s.events
+}
+
+// Watching reports whether the subscriber watches a topic
+func (s *Subscriber) Watching(topic Topic) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.watched[topic]
+	return ok
+}
+
+// Subscriptions returns the watched topics with the sequence last delivered on each, zero
+// before the first
+func (s *Subscriber) Subscriptions() map[Topic]uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	subscriptions := make(map[Topic]uint64, len(s.watched))
+	for topic, sequence := range s.watched {
+		subscriptions[topic] = sequence
+	}
+	return subscriptions
+}
+
+// Watch starts delivering a topic's events after first queuing initial, such as a book
+// snapshot, when it is not nil, failing with ErrSubscriptionLimit when the subscriber's key
+// already has the most subscriptions allowed. Called on the topic's engine, as SnapshotBook
+// does, nothing is published between initial and the events that follow it.
+func (s *Subscriber) Watch(topic Topic, initial *service.MarketEvent) error {
+	if !s.hub.reserve(s.key, 0, 1) {
+		return models.ErrSubscriptionLimit
+	}
+	if initial != nil {
+		s.deliver(initial)
+	}
+	s.mutex.Lock()
+	if _, ok := s.watched[topic]; ok || s.ended {
+		s.mutex.Unlock()
+		s.hub.release(s.key, 0, 1)
+		return nil
+	}
+	s.watched[topic] = 0
+	s.mutex.Unlock()
+
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	if s.hub.topics[topic] == nil {
+		s.hub.topics[topic] = make(map[*Subscriber]struct{})
+	}
+	s.hub.topics[topic][s] = struct{}{}
+	return nil
+}
+
+// Unwatch stops delivering a topic's events
+func (s *Subscriber) Unwatch(topic Topic) {
+	s.mutex.Lock()
+	_, ok := s.watched[topic]
+	delete(s.watched, topic)
+	s.mutex.Unlock()
+	if ok {
+		s.hub.remove(s, topic)
+		s.hub.release(s.key, 0, 1)
+	}
+}
+
+// Delivered records that an event reached the client, so a resumed session reports the
+// sequence it stopped at
+func (s *Subscriber) Delivered(event *service.MarketEvent) {
+	topic := Topic{Symbol: event.Symbol, Kind: event.Kind}
+	if event.Kind == service.EventSnapshot {
+		topic.Kind = service.EventBook
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.watched[topic]; ok {
+		s.watched[topic] = event.Sequence
+	}
+}
+
+// Unsent holds an event taken from the queue that could not be sent, to be sent first when the
+// session resumes
+func (s *Subscriber) Unsent(event *service.MarketEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.unsent = event
+}
+
+// TakeUnsent returns and forgets the event held by Unsent, nil if there is none
+func (s *Subscriber) TakeUnsent() *service.MarketEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	event := s.unsent
+	s.unsent = nil
+	return event
+}
+
+// Detach releases the session's connection, keeping its subscriptions and queuing their events
+// for the resume TTL. A subscriber that fell behind, or any subscriber when resuming is
+// disabled, is closed instead.
+func (s *Subscriber) Detach() {
+	s.mutex.Lock()
+	if s.hub.resumeTTL == 0 || s.closed || s.ended || !s.attached {
+		s.mutex.Unlock()
+		s.Close()
+		return
+	}
+	s.attached = false
+	s.expiry = time.AfterFunc(s.hub.resumeTTL, s.expire)
+	s.mutex.Unlock()
+	s.hub.release(s.key, 1, 0)
+}
+
+// Close ends the session, stopping every delivery and closing the subscriber's queue
+func (s *Subscriber) Close() {
+	s.end(false)
+}
+
+// expire closes a session that is still detached when its resume TTL runs out
+func (s *Subscriber) expire() {
+	s.end(true)
+}
+
+// end ends the session unless it already has, or detachedOnly is set and a connection resumed it
+func (s *Subscriber) end(detachedOnly bool) {
+	s.mutex.Lock()
+	if s.ended || detachedOnly && s.attached {
+		s.mutex.Unlock()
+		return
+	}
+	topics := s.watched
+	connections := 0
+	if s.attached {
+		connections = 1
+	}
+	s.watched = make(map[Topic]uint64)
+	s.attached = false
+	s.ended = true
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	if s.expiry != nil {
+		s.expiry.Stop()
+	}
+	s.mutex.Unlock()
+
+	for topic := range topics {
+		s.hub.remove(s, topic)
+	}
+	s.hub.sessionMutex.Lock()
+	delete(s.hub.sessions, s.token)
+	s.hub.sessionMutex.Unlock()
+	s.hub.release(s.key, connections, len(topics))
+}
+
+// deliver queues an event without waiting, closing the queue when it is full
+func (s *Subscriber) deliver(event *service.MarketEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		s.closed = true
+		close(s.events)
+		s.hub.logger.Warn("Dropped slow market data subscriber", zap.String("symbol", event.Symbol),
+			zap.String("kind", string(event.Kind)), zap.Bool("attached", s.attached))
+	}
+}
+
+// remove unregisters a subscriber from a topic
+func (h *Hub) remove(s *Subscriber, topic Topic) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.topics[topic], s)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+// reserve counts connections and subscriptions against a key, refusing when that would take it
+// over either limit; a zero limit is unlimited
+func (h *Hub) reserve(key string, connections, subscriptions int) bool {
+	h.sessionMutex.Lock()
+	defer h.sessionMutex.Unlock()
+	u := h.usage[key]
+	if u == nil {
+		u = &usage{}
+	}
+	if h.maxConnections > 0 && u.connections+connections > h.maxConnections ||
+		h.maxSubscriptions > 0 && u.subscriptions+subscriptions > h.maxSubscriptions {
+		return false
+	}
+	u.connections += connections
+	u.subscriptions += subscriptions
+	h.usage[key] = u
+	return true
+}
+
+// release gives back connections and subscriptions counted by reserve
+func (h *Hub) release(key string, connections, subscriptions int) {
+	h.sessionMutex.Lock()
+	defer h.sessionMutex.Unlock()
+	u := h.usage[key]
+	if u == nil {
+		return
+	}
+	u.connections -= connections
+	u.subscriptions -= subscriptions
+	if u.connections <= 0 && u.subscriptions <= 0 {
+		delete(h.usage, key)
+	}
+}
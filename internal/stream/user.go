@@ -0,0 +1,439 @@
+package stream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"orderSystem/internal/compliance"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// UpdateKind is what happened to an order in a private order update
+type UpdateKind string
+
+// Order update kinds
+const (
+	UpdateNew             UpdateKind = "new" // the order was accepted, resting or pending its trigger
+	UpdatePartiallyFilled UpdateKind = "partially_filled"
+	UpdateFilled          UpdateKind = "filled"
+	UpdateCanceled        UpdateKind = "canceled"
+	UpdateExpired         UpdateKind = "expired"   // canceled by the stale order sweep
+	UpdateChanged         UpdateKind = "changed"   // amended or triggered without filling
+	UpdateRejected        UpdateKind = "rejected"  // accepted without waiting, then refused by matching
+	UpdateLastLook        UpdateKind = "last_look" // a match awaiting the provider's last look
+)
+
+// OrderUpdate is a change to one of an account's orders. FilledQuantity is the order's
+// cumulative fill; LastFillQuantity is what this change filled, when the router saw the
+// order's previous state. The router prices fills from the order's executions. Rejections
+// carry the reason and an unsaved snapshot of the order. Last look requests carry the look
+// instead of an event.
+type OrderUpdate struct {
+	Kind             UpdateKind
+	Event            *models.OrderEvent
+	FilledQuantity   models.Decimal
+	LastFillQuantity models.NullDecimal
+	AvgPrice         models.NullDecimal
+	CumQuote         float64
+	LastPrice        models.NullDecimal
+	ReasonCode       models.ReasonCode
+	Reason           string
+	LastLook         *models.LastLook
+}
+
+// Update kinds as execution report types
+var execTypes = map[UpdateKind]models.ExecType{
+	UpdateNew:             models.ExecNew,
+	UpdatePartiallyFilled: models.ExecTrade,
+	UpdateFilled:          models.ExecTrade,
+	UpdateCanceled:        models.ExecCanceled,
+	UpdateExpired:         models.ExecExpired,
+	UpdateChanged:         models.ExecReplaced,
+	UpdateRejected:        models.ExecRejected,
+}
+
+// Report returns the update as an execution report
+func (u *OrderUpdate) Report() *models.ExecutionReport {
+	event := u.Event
+	report := &models.ExecutionReport{
+		ExecType:       execTypes[u.Kind],
+		OrderID:        event.OrderID,
+		Symbol:         event.Symbol,
+		Side:           event.Side,
+		Type:           event.Type,
+		Status:         event.Status,
+		Price:          event.Price,
+		Quantity:       event.InitialQuantity,
+		CumQuantity:    u.FilledQuantity,
+		LeavesQuantity: event.RemainingQuantity,
+		AvgPrice:       u.AvgPrice,
+		CumQuote:       u.CumQuote,
+		LastQuantity:   u.LastFillQuantity,
+		LastPrice:      u.LastPrice,
+		Reason:         u.ReasonCode,
+		Text:           u.Reason,
+		Time:           event.CreatedAt,
+	}
+	if event.Status == models.StatusCanceled || event.Status == models.StatusExpired {
+		report.LeavesQuantity = 0
+	}
+	return report
+}
+
+// UserStream is an account's queue of order updates
+type UserStream struct {
+	accountID string
+	updates   chan *OrderUpdate
+
+	// Whether updates has been closed, guarded by mutex
+	closed bool
+	mutex  sync.Mutex
+}
+
+// Updates returns the stream's queue, closed once the stream is closed or falls behind
+func (s *UserStream) Updates() <-chan *OrderUpdate {
+	return s.updates
+}
+
+// deliver queues an update without waiting, closing the queue when it is full
+func (s *UserStream) deliver(update *OrderUpdate) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.updates <- update:
+		return true
+	default:
+		s.closed = true
+		close(s.updates)
+		return false
+	}
+}
+
+// shut closes the queue unless it already is
+func (s *UserStream) shut() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.updates)
+	}
+}
+
+// Router tails the order event outbox and routes each order's changes to the private streams of
+// the account that owns it. Only events committed after the router starts are routed.
+type Router struct {
+	feed       *compliance.Feed
+	repo       repository.Repository
+	bufferSize int
+	keyTTL     time.Duration
+	logger     *zap.Logger
+
+	// Open streams by account and listen keys with their expiry, guarded by mutex
+	streams map[string]map[*UserStream]struct{}
+	keys    map[string]*listenKey
+	mutex   sync.Mutex
+
+	// Tells fills from amendments; only touched by Run
+	classifier *Classifier
+}
+
+// listenKey is a secret granting access to an account's private stream until it expires
+type listenKey struct {
+	accountID string
+	expiresAt time.Time
+}
+
+// NewRouter creates a private stream router reading from feed
+func NewRouter(feed *compliance.Feed, repo repository.Repository, cfg config.StreamingConfig, logger *zap.Logger) *Router {
+	return &Router{
+		feed:       feed,
+		repo:       repo,
+		bufferSize: cfg.BufferSize,
+		keyTTL:     cfg.ListenKeyTTL,
+		logger:     logger,
+		streams:    make(map[string]map[*UserStream]struct{}),
+		keys:       make(map[string]*listenKey),
+		classifier: NewClassifier(),
+	}
+}
+
+// CreateListenKey issues a listen key for an account's private stream and returns it with its expiry
+func (r *Router) CreateListenKey(accountID string) (string, time.Time) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	key := hex.EncodeToString(secret)
+	expiresAt := time.Now().Add(r.keyTTL)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sweepKeys()
+	r.keys[key] = &listenKey{accountID: accountID, expiresAt: expiresAt}
+	return key, expiresAt
+}
+
+// KeepAlive extends an account's listen key by the configured TTL and returns the new expiry
+func (r *Router) KeepAlive(key, accountID string) (time.Time, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lk, ok := r.keys[key]
+	if !ok || lk.accountID != accountID || !time.Now().Before(lk.expiresAt) {
+		return time.Time{}, models.ErrListenKeyNotFound
+	}
+	lk.expiresAt = time.Now().Add(r.keyTTL)
+	return lk.expiresAt, nil
+}
+
+// CloseListenKey revokes an account's listen key
+func (r *Router) CloseListenKey(key, accountID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lk, ok := r.keys[key]
+	if !ok || lk.accountID != accountID {
+		return models.ErrListenKeyNotFound
+	}
+	delete(r.keys, key)
+	return nil
+}
+
+// ValidKey reports whether a listen key is still valid
+func (r *Router) ValidKey(key string) bool {
+	_, ok := r.account(key)
+	return ok
+}
+
+// account returns the account of a valid listen key
+func (r *Router) account(key string) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lk, ok := r.keys[key]
+	if !ok || !time.Now().Before(lk.expiresAt) {
+		return "", false
+	}
+	return lk.accountID, true
+}
+
+// sweepKeys drops expired listen keys; the caller holds mutex
+func (r *Router) sweepKeys() {
+	now := time.Now()
+	for key, lk := range r.keys {
+		if !now.Before(lk.expiresAt) {
+			delete(r.keys, key)
+		}
+	}
+}
+
+// Open starts a private stream for the account of a valid listen key
+func (r *Router) Open(key string) (*UserStream, error) {
+	accountID, ok := r.account(key)
+	if !ok {
+		return nil, models.ErrListenKeyNotFound
+	}
+	stream := &UserStream{accountID: accountID, updates: make(chan *OrderUpdate, r.bufferSize)}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.streams[accountID] == nil {
+		r.streams[accountID] = make(map[*UserStream]struct{})
+	}
+	r.streams[accountID][stream] = struct{}{}
+	return stream, nil
+}
+
+// Close stops routing to a stream and closes its queue
+func (r *Router) Close(stream *UserStream) {
+	r.mutex.Lock()
+	delete(r.streams[stream.accountID], stream)
+	if len(r.streams[stream.accountID]) == 0 {
+		delete(r.streams, stream.accountID)
+	}
+	r.mutex.Unlock()
+	stream.shut()
+}
+
+// Run routes the outbox's new events until ctx is done
+func (r *Router) Run(ctx context.Context, wait time.Duration) {
+	var cursor compliance.Cursor
+	for ctx.Err() == nil {
+		if cursor == nil {
+			head, err := r.feed.Head(ctx)
+			if err != nil {
+				r.pause(ctx, wait)
+				continue
+			}
+			cursor = head
+		}
+
+		events, next, err := r.feed.Poll(ctx, cursor, 0, wait)
+		if err != nil {
+			r.pause(ctx, wait)
+			continue
+		}
+		for _, event := range events {
+			update := r.classifier.Classify(event.Event)
+			if update.Kind == UpdateRejected {
+				// Rejected requests got their answer, or a Rejected update when accepted without waiting
+				continue
+			}
+			r.price(ctx, update)
+			r.route(update)
+		}
+		cursor = next
+	}
+}
+
+// price sets a fill's average and last prices and its cumulative quote amount from the order's executions. Without them, as
+// when the read fails, the update goes out unpriced rather than late.
+func (r *Router) price(ctx context.Context, update *OrderUpdate) {
+	if update.Event.OwnerID == "" || update.FilledQuantity == 0 ||
+		update.Kind != UpdatePartiallyFilled && update.Kind != UpdateFilled {
+		return
+	}
+	executions, err := r.repo.GetExecutions(ctx, update.Event.OrderID)
+	if err != nil {
+		r.logger.Warn("Failed to price order update", zap.Uint64("order_id", update.Event.OrderID), zap.Error(err))
+		return
+	}
+	// Executions committed after this event are left out, so prices match its cumulative fill
+	n := 0
+	for filled := models.Decimal(0); n < len(executions) && filled < update.FilledQuantity; n++ {
+		filled += executions[n].Quantity
+	}
+	executions = executions[:n]
+	update.AvgPrice = models.AveragePrice(executions)
+	update.CumQuote = models.FilledNotional(executions)
+	if !update.LastFillQuantity.Valid {
+		return
+	}
+
+	// The last fill is the newest executions covering its quantity, the oldest of them in part
+	remaining := update.LastFillQuantity.Decimal
+	var last []*models.Execution
+	for i := len(executions) - 1; i >= 0 && remaining > 0; i-- {
+		fill := *executions[i]
+		fill.Quantity = min(fill.Quantity, remaining)
+		remaining -= fill.Quantity
+		last = append(last, &fill)
+	}
+	update.LastPrice = models.AveragePrice(last)
+}
+
+// pause waits before retrying after a storage error
+func (r *Router) pause(ctx context.Context, wait time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+	}
+}
+
+// Classifier turns order events into updates, tracking each live order's cumulative fill so a
+// fill can be told apart from an amendment. It is not safe for concurrent use.
+type Classifier struct {
+	filled map[uint64]models.Decimal
+}
+
+// NewClassifier creates a classifier that has seen no events yet
+func NewClassifier() *Classifier {
+	return &Classifier{filled: make(map[uint64]models.Decimal)}
+}
+
+// Classify returns the update an event represents. The first event seen of an order that was
+// created earlier is taken as a partial fill when the order has filled at all.
+func (c *Classifier) Classify(event *models.OrderEvent) *OrderUpdate {
+	filled := event.InitialQuantity - event.RemainingQuantity
+	previous, seen := c.filled[event.OrderID]
+	update := &OrderUpdate{Event: event, FilledQuantity: filled, ReasonCode: event.Reason}
+	if seen && filled > previous {
+		update.LastFillQuantity = models.NullDecimal{Decimal: filled - previous, Valid: true}
+	}
+
+	switch {
+	case event.Kind == models.EventRejected:
+		update.Kind = UpdateRejected
+	case event.Status == models.StatusCanceled:
+		update.Kind = UpdateCanceled
+	case event.Status == models.StatusExpired:
+		update.Kind = UpdateExpired
+	case event.Status == models.StatusFilled:
+		update.Kind = UpdateFilled
+	case event.Kind == models.EventCreated:
+		update.Kind = UpdateNew
+	case filled > previous:
+		update.Kind = UpdatePartiallyFilled
+	default:
+		update.Kind = UpdateChanged
+	}
+
+	if event.Status == models.StatusCanceled || event.Status == models.StatusExpired || event.Status == models.StatusFilled {
+		delete(c.filled, event.OrderID)
+	} else {
+		c.filled[event.OrderID] = filled
+	}
+	return update
+}
+
+// Rejected routes an update for an order that was accepted without waiting and then rejected;
+// it implements service.RejectionSink
+func (r *Router) Rejected(order *models.Order, err error) {
+	r.route(&OrderUpdate{
+		Kind: UpdateRejected,
+		Event: &models.OrderEvent{
+			OrderID:           order.OrderID,
+			Symbol:            order.Symbol,
+			Side:              order.Side,
+			Type:              order.Type,
+			Status:            models.StatusCanceled,
+			Price:             order.Price,
+			InitialQuantity:   order.InitialQuantity,
+			RemainingQuantity: order.InitialQuantity,
+			OwnerID:           order.OwnerID,
+			CreatedAt:         time.Now(),
+		},
+		ReasonCode: models.RejectReason(err),
+		Reason:     err.Error(),
+	})
+}
+
+// LastLook offers a last look to the provider's open private streams, reporting whether any
+// of them took it; it implements service.LastLookSink
+func (r *Router) LastLook(look *models.LastLook) bool {
+	return r.deliver(look.ProviderID, &OrderUpdate{Kind: UpdateLastLook, LastLook: look})
+}
+
+// route delivers an update to every open stream of the order's owner
+func (r *Router) route(update *OrderUpdate) {
+	r.deliver(update.Event.OwnerID, update)
+}
+
+// deliver queues an update on every open stream of an account, dropping streams that have
+// fallen behind, and reports whether any stream took it
+func (r *Router) deliver(accountID string, update *OrderUpdate) bool {
+	if accountID == "" {
+		return false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delivered := false
+	for stream := range r.streams[accountID] {
+		if stream.deliver(update) {
+			delivered = true
+		} else {
+			delete(r.streams[accountID], stream)
+			r.logger.Warn("Dropped slow private stream", zap.String("account_id", accountID))
+		}
+	}
+	if len(r.streams[accountID]) == 0 {
+		delete(r.streams, accountID)
+	}
+	return delivered
+}
@@ -0,0 +1,368 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"orderSystem/internal/compliance"
+	"orderSystem/internal/config"
+	"orderSystem/internal/models"
+	"orderSystem/internal/repository"
+	"orderSystem/internal/stream"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// subscriberID is the event cursor subscriber under which dispatch progress is saved
+const subscriberID = "webhooks"
+
+// maxErrorLength is the longest last error recorded with a delivery
+const maxErrorLength = 255
+
+// Notification events and the order updates that raise them
+var notificationEvents = map[stream.UpdateKind]string{
+	stream.UpdatePartiallyFilled: "order.partially_filled",
+	stream.UpdateFilled:          "order.filled",
+	stream.UpdateCanceled:        "order.canceled",
+	stream.UpdateExpired:         "order.canceled",
+}
+
+// Payload is the JSON body posted for a notification. Shard and EventID identify the order
+// event it was raised for, so receivers can drop redelivered notifications.
+type Payload struct {
+	Event             string             `json:"event"`
+	Shard             int                `json:"shard"`
+	EventID           uint64             `json:"event_id"`
+	OrderID           uint64             `json:"order_id"`
+	Symbol            string             `json:"symbol"`
+	Side              models.OrderSide   `json:"side"`
+	Type              models.OrderType   `json:"type"`
+	Status            models.OrderStatus `json:"status"`
+	Price             *models.Decimal    `json:"price,omitempty"`
+	Quantity          models.Decimal     `json:"quantity"`
+	RemainingQuantity models.Decimal     `json:"remaining_quantity"`
+	FilledQuantity    models.Decimal     `json:"filled_quantity"`
+	LastFillQuantity  *models.Decimal    `json:"last_fill_quantity,omitempty"`
+	Time              time.Time          `json:"time"`
+}
+
+// Notifier posts signed notifications of order fills and cancels to the webhooks accounts
+// register. It tails the order event outbox, queues a delivery per event and webhook, and
+// retries failed deliveries with exponential backoff until they succeed or run out of attempts.
+type Notifier struct {
+	feed   *compliance.Feed
+	repo   repository.Repository
+	cfg    config.WebhooksConfig
+	client *http.Client
+	logger *zap.Logger
+
+	// Dispatch state, only touched by Run: the cursor of the last queued event, nil until
+	// loaded, and the fills seen so far
+	cursor     compliance.Cursor
+	classifier *stream.Classifier
+}
+
+// NewNotifier creates a webhook notifier. Redirects are not followed, so a webhook must accept
+// notifications at the URL it was registered with.
+func NewNotifier(feed *compliance.Feed, repo repository.Repository, cfg config.WebhooksConfig, logger *zap.Logger) *Notifier {
+	return &Notifier{
+		feed: feed,
+		repo: repo,
+		cfg:  cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger:     logger,
+		classifier: stream.NewClassifier(),
+	}
+}
+
+// Register adds a webhook for an account; the returned webhook is the only place its secret
+// is handed out
+func (n *Notifier) Register(ctx context.Context, accountID, rawURL string) (*models.Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		n.logger.Warn("Invalid webhook URL", zap.String("url", rawURL))
+		return nil, models.ErrInvalidWebhook
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	webhook := &models.Webhook{
+		AccountID: accountID,
+		URL:       rawURL,
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: time.Now(),
+	}
+	if err := n.repo.SaveWebhook(ctx, webhook); err != nil {
+		n.logger.Error("Failed to save webhook", zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+
+	n.logger.Info("Webhook registered", zap.Uint64("webhook_id", webhook.WebhookID), zap.String("account_id", accountID))
+	return webhook, nil
+}
+
+// Webhooks returns an account's webhooks in registration order
+func (n *Notifier) Webhooks(ctx context.Context, accountID string) ([]*models.Webhook, error) {
+	webhooks, err := n.repo.GetWebhooks(ctx, accountID)
+	if err != nil {
+		n.logger.Error("Failed to get webhooks", zap.String("account_id", accountID), zap.Error(err))
+		return nil, repository.Classify(err)
+	}
+	return webhooks, nil
+}
+
+// Delete removes one of an account's webhooks; its pending deliveries are given up
+func (n *Notifier) Delete(ctx context.Context, accountID string, webhookID uint64) error {
+	webhook, err := n.repo.GetWebhook(ctx, webhookID)
+	if err == nil && webhook.AccountID != accountID {
+		err = models.ErrWebhookNotFound
+	}
+	if err == nil {
+		err = n.repo.DeleteWebhook(ctx, webhookID)
+	}
+	if err == models.ErrWebhookNotFound {
+		return err
+	}
+	if err != nil {
+		n.logger.Error("Failed to delete webhook", zap.Uint64("webhook_id", webhookID), zap.Error(err))
+		return repository.Classify(err)
+	}
+
+	n.logger.Info("Webhook deleted", zap.Uint64("webhook_id", webhookID), zap.String("account_id", accountID))
+	return nil
+}
+
+// Run queues notifications for new order events and delivers the due ones every poll interval
+// until ctx is canceled
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := n.dispatch(ctx); err != nil {
+			n.logger.Warn("Failed to queue webhook notifications", zap.Error(err))
+		}
+		n.deliver(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch queues a delivery to each of the owner's webhooks for every new order event that
+// fills or cancels an order, then saves the cursor past the events. Notifications are queued
+// at least once: a failure before the cursor is saved queues them again on the next pass.
+func (n *Notifier) dispatch(ctx context.Context) error {
+	if n.cursor == nil {
+		cursor, err := n.feed.Position(ctx, subscriberID)
+		if err != nil {
+			return err
+		}
+		n.cursor = cursor
+	}
+	events, next, err := n.feed.Poll(ctx, n.cursor, n.cfg.BatchSize, 0)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	// Look up the webhooks of every owner whose order may have filled or been canceled before
+	// classifying, so a failed lookup leaves the fills seen unchanged
+	webhooks := make(map[string][]*models.Webhook)
+	for _, se := range events {
+		e := se.Event
+		if e.OwnerID == "" || e.RemainingQuantity == e.InitialQuantity && e.Status != models.StatusCanceled && e.Status != models.StatusExpired {
+			continue
+		}
+		if _, ok := webhooks[e.OwnerID]; !ok {
+			owned, err := n.repo.GetWebhooks(ctx, e.OwnerID)
+			if err != nil {
+				return repository.Classify(err)
+			}
+			webhooks[e.OwnerID] = owned
+		}
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for _, se := range events {
+		update := n.classifier.Classify(se.Event)
+		event, ok := notificationEvents[update.Kind]
+		if !ok || len(webhooks[se.Event.OwnerID]) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(newPayload(event, se, update))
+		if err != nil {
+			return err
+		}
+		for _, webhook := range webhooks[se.Event.OwnerID] {
+			deliveries = append(deliveries, &models.WebhookDelivery{
+				WebhookID:     webhook.WebhookID,
+				Event:         event,
+				Payload:       string(payload),
+				Status:        models.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			})
+		}
+	}
+	if err := n.repo.SaveWebhookDeliveries(ctx, deliveries); err != nil {
+		// The events are read again, so forget the fills they showed
+		n.classifier = stream.NewClassifier()
+		return repository.Classify(err)
+	}
+	n.cursor = next
+	return n.feed.Ack(ctx, subscriberID, next)
+}
+
+// newPayload builds the notification of an order update
+func newPayload(event string, se compliance.ShardEvent, update *stream.OrderUpdate) Payload {
+	e := se.Event
+	payload := Payload{
+		Event:             event,
+		Shard:             se.Shard,
+		EventID:           e.EventID,
+		OrderID:           e.OrderID,
+		Symbol:            e.Symbol,
+		Side:              e.Side,
+		Type:              e.Type,
+		Status:            e.Status,
+		Quantity:          e.InitialQuantity,
+		RemainingQuantity: e.RemainingQuantity,
+		FilledQuantity:    update.FilledQuantity,
+		Time:              e.CreatedAt,
+	}
+	if e.Price.Valid {
+		payload.Price = &e.Price.Decimal
+	}
+	if update.LastFillQuantity.Valid {
+		payload.LastFillQuantity = &update.LastFillQuantity.Decimal
+	}
+	return payload
+}
+
+// deliver attempts the due deliveries, each webhook's in queue order on its own goroutine so a
+// slow endpoint only delays its own notifications. A webhook's remaining deliveries wait for
+// the next pass after one of them fails.
+func (n *Notifier) deliver(ctx context.Context) {
+	due, err := n.repo.GetDueWebhookDeliveries(ctx, time.Now(), n.cfg.BatchSize)
+	if err != nil {
+		n.logger.Error("Failed to get due webhook deliveries", zap.Error(err))
+		return
+	}
+	queues := make(map[uint64][]*models.WebhookDelivery)
+	for _, delivery := range due {
+		queues[delivery.WebhookID] = append(queues[delivery.WebhookID], delivery)
+	}
+
+	var wg sync.WaitGroup
+	for webhookID, queue := range queues {
+		wg.Add(1)
+		go func(webhookID uint64, queue []*models.WebhookDelivery) {
+			defer wg.Done()
+			webhook, err := n.repo.GetWebhook(ctx, webhookID)
+			if err != nil && err != models.ErrWebhookNotFound {
+				n.logger.Error("Failed to get webhook", zap.Uint64("webhook_id", webhookID), zap.Error(err))
+				return
+			}
+			for _, delivery := range queue {
+				if !n.attempt(ctx, webhook, delivery) {
+					return
+				}
+			}
+		}(webhookID, queue)
+	}
+	wg.Wait()
+}
+
+// attempt posts a delivery to its webhook, nil when the webhook was deleted, and records the
+// outcome, returning whether it was delivered
+func (n *Notifier) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) bool {
+	err := models.ErrWebhookNotFound
+	if webhook != nil {
+		err = n.post(ctx, webhook, delivery)
+	}
+	delivery.Attempts++
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.LastError = ""
+	case webhook == nil || delivery.Attempts >= n.cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = truncate(err.Error())
+	default:
+		delivery.NextAttemptAt = time.Now().Add(n.backoff(delivery.Attempts))
+		delivery.LastError = truncate(err.Error())
+	}
+	if err != nil {
+		n.logger.Warn("Webhook delivery failed", zap.Uint64("delivery_id", delivery.DeliveryID),
+			zap.Int("attempts", delivery.Attempts), zap.Error(err))
+	}
+	if err := n.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		n.logger.Error("Failed to update webhook delivery", zap.Uint64("delivery_id", delivery.DeliveryID), zap.Error(err))
+		return false
+	}
+	return err == nil
+}
+
+// post sends a delivery, signed with the webhook's secret over its timestamp and body
+func (n *Notifier) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(timestamp + "." + delivery.Payload))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(delivery.DeliveryID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the wait before the attempt following the given number of failed ones
+func (n *Notifier) backoff(attempts int) time.Duration {
+	wait := n.cfg.RetryBackoff
+	for i := 1; i < attempts && wait < n.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, n.cfg.MaxBackoff)
+}
+
+// truncate shortens an error message to what a delivery records
+func truncate(message string) string {
+	if runes := []rune(message); len(runes) > maxErrorLength {
+		return string(runes[:maxErrorLength])
+	}
+	return message
+}
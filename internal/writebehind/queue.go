@@ -0,0 +1,301 @@
+// Package writebehind takes database writes off the matching engines' path. A transaction begun
+// from the queue records its statements rather than running them; committing it appends them to
+// a local write-ahead log, synced before the commit returns, and queues them to the shard they
+// belong to, where a writer applies the queued transactions in order, several to a database
+// transaction. Each shard records the sequence of the last transaction applied to it alongside
+// its writes, so at startup whatever the log holds beyond that is applied before anything else
+// runs, and a crash loses nothing a commit returned for.
+package writebehind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"orderSystem/internal/metrics"
+	"orderSystem/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize is how many committed transactions may wait for a shard's writer before commits
+	// to the shard block
+	queueSize = 4096
+	// groupSize is the most transactions a writer applies in one database transaction
+	groupSize = 64
+	// maxBackoff caps the wait between attempts to write to a failing shard
+	maxBackoff = 5 * time.Second
+)
+
+// Errors writing behind
+var (
+	errClosed = errors.New("write-behind queue is closed")
+	// ErrNoRowChanged is a queued write guarded on a row that did not change it when written, as
+	// an order updated at a version it had already left. It means the database was written
+	// meanwhile by something other than the engines; the shard's writer retries it, logging each
+	// failure, until an operator resolves it.
+	ErrNoRowChanged = errors.New("write-behind statement changed no row")
+)
+
+// Queue logs committed transactions and writes them behind to their shards. It is safe for
+// concurrent use.
+type Queue struct {
+	node    int
+	logger  *zap.Logger
+	writers []*writer
+
+	// Logs committed batches and hands them to the writers in sequence order, guarded by mutex
+	mutex    sync.Mutex
+	log      *wal
+	sequence uint64 // of the last batch logged
+}
+
+// writer writes the batches queued to one shard
+type writer struct {
+	shard   int
+	repo    repository.Repository
+	batches chan *batch
+
+	// Sequences of the last batch queued to and written to the shard and how many batches are
+	// queued and not yet written, guarded by mutex; advanced is closed and replaced each time
+	// written moves
+	mutex    sync.Mutex
+	queued   uint64
+	written  uint64
+	pending  int
+	advanced chan struct{}
+}
+
+// Open opens the write-ahead log in a directory and applies to each of the repository's shards
+// the batches logged for it beyond the last the shard recorded, so the database is current before
+// it is read. The node ID keeps the shards' records of servers sharing the databases apart.
+func Open(ctx context.Context, dir string, repo repository.Repository, node int, logger *zap.Logger) (*Queue, error) {
+	log, logged, err := openWAL(dir)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{node: node, logger: logger, log: log}
+	for i, shard := range repo.Shards() {
+		written, err := shard.GetWriteBehindSequence(ctx, node)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %v", i, err)
+		}
+		q.writers = append(q.writers, &writer{shard: i, repo: shard, batches: make(chan *batch, queueSize),
+			written: written, advanced: make(chan struct{})})
+		q.sequence = max(q.sequence, written)
+	}
+
+	// Apply what the shards have yet to record, a group at a time per shard
+	pending := make([][]*batch, len(q.writers))
+	for _, b := range logged {
+		if b.Shard < 0 || b.Shard >= len(q.writers) {
+			return nil, fmt.Errorf("write-behind log holds batch %d for shard %d of %d", b.Sequence, b.Shard, len(q.writers))
+		}
+		q.sequence = max(q.sequence, b.Sequence)
+		if b.Sequence > q.writers[b.Shard].written {
+			pending[b.Shard] = append(pending[b.Shard], b)
+		}
+	}
+	for i, w := range q.writers {
+		for start := 0; start < len(pending[i]); start += groupSize {
+			group := pending[i][start:min(start+groupSize, len(pending[i]))]
+			if err := q.write(ctx, w, group); err != nil {
+				return nil, fmt.Errorf("shard %d: applying logged batch %d: %v", i, group[0].Sequence, err)
+			}
+		}
+		if len(pending[i]) > 0 {
+			logger.Info("Applied logged write-behind batches", zap.Int("shard", i), zap.Int("batches", len(pending[i])))
+		}
+		w.queued = q.sequence
+		w.written = q.sequence
+	}
+	return q, nil
+}
+
+// Begin begins a transaction to be written behind to a shard of the repository
+func (q *Queue) Begin(shard int) *Tx {
+	return &Tx{queue: q, shard: shard}
+}
+
+// commit logs a committed transaction's statements and queues them to their shard. It blocks
+// while the shard's writer is queueSize batches behind, holding back every commit meanwhile so
+// the log stays in the order batches are queued.
+func (q *Queue) commit(shard int, statements []statement) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	b := &batch{Sequence: q.sequence + 1, Shard: shard, Statements: statements}
+	if err := q.log.append(b, q.writtenThrough()); err != nil {
+		return err
+	}
+	q.sequence = b.Sequence
+
+	w := q.writers[shard]
+	w.mutex.Lock()
+	w.queued = b.Sequence
+	w.pending++
+	pending := w.pending
+	w.mutex.Unlock()
+	metrics.WriteBehindLag.WithLabelValues(strconv.Itoa(shard)).Set(float64(pending))
+	w.batches <- b
+	return nil
+}
+
+// writtenThrough returns the sequence at or below which every logged batch has been written:
+// that of the last batch logged when every shard is caught up, else the last written to the
+// furthest behind
+func (q *Queue) writtenThrough() uint64 {
+	through := q.sequence
+	for _, w := range q.writers {
+		w.mutex.Lock()
+		if w.written < w.queued {
+			through = min(through, w.written)
+		}
+		w.mutex.Unlock()
+	}
+	return through
+}
+
+// Run writes queued batches to their shards until the context is canceled. A shard that fails is
+// retried with backoff, its commits blocking once its queue fills; what is left queued when Run
+// returns is applied by Open at the next start.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, w := range q.writers {
+		wg.Add(1)
+		go func(w *writer) {
+			defer wg.Done()
+			q.runWriter(ctx, w)
+		}(w)
+	}
+	wg.Wait()
+}
+
+// runWriter writes one shard's batches, as many as are queued up to groupSize at a time
+func (q *Queue) runWriter(ctx context.Context, w *writer) {
+	for {
+		var group []*batch
+		select {
+		case <-ctx.Done():
+			return
+		case b := <-w.batches:
+			group = append(group, b)
+		}
+	more:
+		for len(group) < groupSize {
+			select {
+			case b := <-w.batches:
+				group = append(group, b)
+			default:
+				break more
+			}
+		}
+
+		backoff := 50 * time.Millisecond
+		for {
+			err := q.write(ctx, w, group)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			q.logger.Error("Failed to write behind to shard; retrying", zap.Int("shard", w.shard),
+				zap.Uint64("sequence", group[0].Sequence), zap.Int("batches", len(group)), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, maxBackoff)
+		}
+
+		w.mutex.Lock()
+		w.written = group[len(group)-1].Sequence
+		w.pending -= len(group)
+		pending := w.pending
+		close(w.advanced)
+		w.advanced = make(chan struct{})
+		w.mutex.Unlock()
+		metrics.WriteBehindLag.WithLabelValues(strconv.Itoa(w.shard)).Set(float64(pending))
+	}
+}
+
+// write applies a group of batches to a shard in one database transaction, recording the last
+// one's sequence with them
+func (q *Queue) write(ctx context.Context, w *writer, group []*batch) error {
+	defer metrics.Since(metrics.DBTransactionDuration.WithLabelValues("write_behind"), time.Now())
+	tx, err := w.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, b := range group {
+		for _, s := range b.Statements {
+			args := make([]any, len(s.Args))
+			for i, a := range s.Args {
+				args[i] = a.value()
+			}
+			result, err := tx.ExecContext(ctx, s.Query, args...)
+			if err != nil {
+				return fmt.Errorf("batch %d: %v", b.Sequence, err)
+			}
+			if !s.Affects {
+				continue
+			}
+			if n, err := result.RowsAffected(); err != nil || n == 0 {
+				return fmt.Errorf("batch %d: %w", b.Sequence, ErrNoRowChanged)
+			}
+		}
+	}
+	if err := w.repo.SaveWriteBehindSequenceTx(ctx, tx, q.node, group[len(group)-1].Sequence); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Flush waits until every batch queued to a shard so far has been written to it, so a read of
+// the shard sees them
+func (q *Queue) Flush(ctx context.Context, shard int) error {
+	w := q.writers[shard]
+	w.mutex.Lock()
+	target := w.queued
+	w.mutex.Unlock()
+	for {
+		w.mutex.Lock()
+		written, advanced := w.written, w.advanced
+		w.mutex.Unlock()
+		if written >= target {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-advanced:
+		}
+	}
+}
+
+// FlushAll waits until every batch queued so far has been written to its shard
+func (q *Queue) FlushAll(ctx context.Context) error {
+	for shard := range q.writers {
+		if err := q.Flush(ctx, shard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops taking commits and waits, until the context is done, for what is queued to be
+// written; Run may then be stopped. Whatever is not written by then is applied at the next start.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mutex.Lock()
+	err := q.log.close()
+	q.mutex.Unlock()
+	if flushErr := q.FlushAll(ctx); flushErr != nil {
+		return flushErr
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+package writebehind
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"orderSystem/internal/repository"
+	"reflect"
+	"time"
+)
+
+// ErrRead is returned by a query within a transaction written behind: the database has neither
+// its writes nor those queued before it, so what it read would be stale
+var ErrRead = errors.New("a transaction written behind cannot read from the database")
+
+// batch is a committed transaction's statements, written to one shard in a transaction of its own
+type batch struct {
+	Sequence   uint64      `json:"sequence"`
+	Shard      int         `json:"shard"`
+	Statements []statement `json:"statements"`
+}
+
+// statement is a write recorded within a transaction
+type statement struct {
+	Query string `json:"query"`
+	Args  []arg  `json:"args,omitempty"`
+	// Affects marks a statement whose caller checked it changed a row, as a guard on an order's
+	// version does, so writing it must change one too
+	Affects bool `json:"affects,omitempty"`
+}
+
+// arg is a statement argument as the driver would receive it, with its type kept through JSON;
+// every field nil is a NULL
+type arg struct {
+	Int    *int64     `json:"i,omitempty"`
+	Uint   *uint64    `json:"u,omitempty"`
+	Float  *float64   `json:"f,omitempty"`
+	Bool   *bool      `json:"b,omitempty"`
+	String *string    `json:"s,omitempty"`
+	Bytes  *[]byte    `json:"x,omitempty"`
+	Time   *time.Time `json:"t,omitempty"`
+}
+
+// encodeArg converts a statement argument for the log. Unsigned integers are kept as they are,
+// since the driver's default conversion refuses those with the high bit set.
+func encodeArg(v any) (arg, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return arg{}, err
+		}
+	}
+	if rv := reflect.ValueOf(v); rv.IsValid() {
+		switch rv.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u := rv.Uint()
+			return arg{Uint: &u}, nil
+		}
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return arg{}, err
+	}
+	switch x := v.(type) {
+	case nil:
+		return arg{}, nil
+	case int64:
+		return arg{Int: &x}, nil
+	case float64:
+		return arg{Float: &x}, nil
+	case bool:
+		return arg{Bool: &x}, nil
+	case []byte:
+		return arg{Bytes: &x}, nil
+	case string:
+		return arg{String: &x}, nil
+	case time.Time:
+		return arg{Time: &x}, nil
+	}
+	return arg{}, fmt.Errorf("cannot log a statement argument of type %T", v)
+}
+
+// value returns the argument to pass to the driver
+func (a arg) value() any {
+	switch {
+	case a.Int != nil:
+		return *a.Int
+	case a.Uint != nil:
+		return *a.Uint
+	case a.Float != nil:
+		return *a.Float
+	case a.Bool != nil:
+		return *a.Bool
+	case a.String != nil:
+		return *a.String
+	case a.Bytes != nil:
+		return *a.Bytes
+	case a.Time != nil:
+		return *a.Time
+	}
+	return nil
+}
+
+// Tx records a transaction's writes in place of running them. Committing it logs them and queues
+// them to be written to its shard; rolling it back discards them. It is not safe for concurrent
+// use, as a *sql.Tx used by one engine is not.
+type Tx struct {
+	queue      *Queue
+	shard      int
+	statements []statement
+	done       bool
+}
+
+// ExecContext records a write, returning a result whose RowsAffected is 1 on the understanding
+// that the write will change a row when it is written, which is then checked
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if t.done {
+		return nil, sql.ErrTxDone
+	}
+	s := statement{Query: query, Args: make([]arg, len(args))}
+	for i, v := range args {
+		a, err := encodeArg(v)
+		if err != nil {
+			return nil, err
+		}
+		s.Args[i] = a
+	}
+	t.statements = append(t.statements, s)
+	return result{t, len(t.statements) - 1}, nil
+}
+
+// QueryContext refuses to read; see ErrRead
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, ErrRead
+}
+
+// Commit logs the transaction and queues it to be written, returning once it is durable in the
+// log. A transaction with no writes has nothing to log.
+func (t *Tx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if len(t.statements) == 0 {
+		return nil
+	}
+	return t.queue.commit(t.shard, t.statements)
+}
+
+// Rollback discards the transaction's writes
+func (t *Tx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	t.statements = nil
+	return nil
+}
+
+// result is the result of a recorded write
+type result struct {
+	tx    *Tx
+	index int
+}
+
+// LastInsertId is not known until the write is written; callers needing the ID assign it instead
+func (r result) LastInsertId() (int64, error) {
+	return 0, repository.ErrInsertIDQueued
+}
+
+// RowsAffected reports the one row the write must change when written, marking the statement to be
+// checked for it
+func (r result) RowsAffected() (int64, error) {
+	r.tx.statements[r.index].Affects = true
+	return 1, nil
+}
+
+var _ repository.Tx = (*Tx)(nil)
@@ -0,0 +1,232 @@
+package writebehind
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// segmentSize is the size past which the log moves on to a new segment file
+const segmentSize = 64 << 20
+
+// segmentSuffix ends the name of every segment file, the rest being the sequence of its first batch
+const segmentSuffix = ".wal"
+
+// wal is the write-ahead log: segment files in a directory, each holding batches one per line as
+// a checksum of the batch's JSON followed by the JSON. Every append is synced before it returns.
+type wal struct {
+	dir      string
+	file     *os.File  // the last segment, being appended to
+	size     int64     // of the last segment
+	segments []segment // oldest first
+	err      error     // a failed append, after which the log takes no more
+}
+
+// segment is one file of the log
+type segment struct {
+	path string
+	last uint64 // sequence of its last batch, 0 while it has none
+}
+
+// openWAL opens the log in a directory, creating the directory when missing, and reads every
+// batch in it in sequence order. A batch torn by a crash part way through its append ends the
+// last segment and is dropped; a corrupt batch anywhere else is an error.
+func openWAL(dir string) (*wal, []*batch, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(paths, func(i, j int) bool { return segmentStart(paths[i]) < segmentStart(paths[j]) })
+
+	w := &wal{dir: dir}
+	var batches []*batch
+	for i, path := range paths {
+		read, good, err := readSegment(path)
+		if err != nil && (i < len(paths)-1 || err != errTorn) {
+			return nil, nil, fmt.Errorf("write-behind log %s: %v", path, err)
+		}
+		s := segment{path: path}
+		for _, b := range read {
+			if len(batches) > 0 && b.Sequence <= batches[len(batches)-1].Sequence {
+				return nil, nil, fmt.Errorf("write-behind log %s: batch %d is out of order", path, b.Sequence)
+			}
+			batches = append(batches, b)
+			s.last = b.Sequence
+		}
+		w.segments = append(w.segments, s)
+		if err == errTorn {
+			if err := os.Truncate(path, good); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return w, batches, nil
+}
+
+// errTorn reports a segment ending in a batch whose append did not complete
+var errTorn = errors.New("torn batch at the end")
+
+// readSegment reads the batches of a segment file, returning with them the length of the file
+// they take up
+func readSegment(path string) ([]*batch, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var batches []*batch
+	var good int64
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return batches, good, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		b, ok := decodeLine(line)
+		if !ok {
+			// Only a batch at the very end may be torn; one followed by more is corrupt
+			if _, peek := r.Peek(1); peek == io.EOF {
+				return batches, good, errTorn
+			}
+			return nil, 0, fmt.Errorf("corrupt batch at offset %d", good)
+		}
+		batches = append(batches, b)
+		good += int64(len(line))
+	}
+}
+
+// encodeLine encodes a batch as a log line
+func encodeLine(b *batch) ([]byte, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(fmt.Sprintf("%08x ", crc32.ChecksumIEEE(data))), append(data, '\n')...), nil
+}
+
+// decodeLine decodes a log line, reporting false for one that is incomplete or fails its checksum
+func decodeLine(line []byte) (*batch, bool) {
+	sum, data, ok := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !ok || len(line) == 0 || line[len(line)-1] != '\n' {
+		return nil, false
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(want) != crc32.ChecksumIEEE(data) {
+		return nil, false
+	}
+	b := &batch{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// segmentStart returns the sequence a segment file's name starts at
+func segmentStart(path string) uint64 {
+	start, _ := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), segmentSuffix), 10, 64)
+	return start
+}
+
+// append writes a batch to the log and syncs it. Once the last segment reaches segmentSize the
+// batch starts a new one, and segments whose batches are all at or below written are deleted.
+// An append that fails leaves the log refusing every later one, since what reached the disk is
+// unknown.
+func (w *wal) append(b *batch, written uint64) error {
+	if w.err != nil {
+		return w.err
+	}
+	line, err := encodeLine(b)
+	if err != nil {
+		return err
+	}
+	if w.file == nil || w.size+int64(len(line)) > segmentSize && w.size > 0 {
+		if err := w.rotate(b.Sequence, written); err != nil {
+			w.err = fmt.Errorf("write-behind log: %v", err)
+			return w.err
+		}
+	}
+	if _, err := w.file.Write(line); err != nil {
+		w.err = fmt.Errorf("write-behind log: %v", err)
+		return w.err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.err = fmt.Errorf("write-behind log: %v", err)
+		return w.err
+	}
+	w.size += int64(len(line))
+	w.segments[len(w.segments)-1].last = b.Sequence
+	return nil
+}
+
+// rotate starts a new segment at a sequence, first deleting the segments already written
+func (w *wal) rotate(start, written uint64) error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	kept := w.segments[:0]
+	for _, s := range w.segments {
+		if s.last > written {
+			kept = append(kept, s)
+			continue
+		}
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	w.segments = kept
+
+	path := filepath.Join(w.dir, fmt.Sprintf("%020d%s", start, segmentSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := syncDir(w.dir); err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, 0
+	w.segments = append(w.segments, segment{path: path})
+	return nil
+}
+
+// close closes the segment being appended to, the log taking no more appends
+func (w *wal) close() error {
+	if w.err == nil {
+		w.err = errClosed
+	}
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// syncDir syncs a directory, making files created in it durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE TABLE depth_snapshots (
+    snapshot_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    symbol VARCHAR(10) NOT NULL,
+    bids JSON NOT NULL,
+    asks JSON NOT NULL,
+    captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_symbol_captured (symbol, captured_at)
+);
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE executions (
+    exec_id CHAR(36) PRIMARY KEY,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    liquidity ENUM('maker', 'taker') NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    quantity DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_order_id (order_id),
+    FOREIGN KEY (trade_id) REFERENCES trades(trade_id),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id)
+);
@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE parent_orders (
+    parent_id BIGINT UNSIGNED PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    strategy ENUM('twap', 'pov') NOT NULL,
+    total_quantity DECIMAL(10,2) NOT NULL,
+    filled_quantity DECIMAL(10,2) NOT NULL DEFAULT 0,
+    limit_price DECIMAL(10,2) DEFAULT NULL,
+    participation_rate DECIMAL(5,4) NOT NULL DEFAULT 0,
+    slice_interval_ms BIGINT UNSIGNED NOT NULL,
+    next_slice_at TIMESTAMP(3) NOT NULL,
+    end_at TIMESTAMP NOT NULL,
+    status ENUM('active', 'completed', 'canceled') NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_status (status),
+    CHECK (total_quantity > 0),
+    CHECK (filled_quantity <= total_quantity)
+);
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_parent_id,
+    DROP COLUMN parent_id;
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN parent_id BIGINT UNSIGNED DEFAULT NULL,
+    ADD INDEX idx_parent_id (parent_id);
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_owner_id,
+    DROP COLUMN owner_id;
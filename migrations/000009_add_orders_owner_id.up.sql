@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    ADD INDEX idx_owner_id (owner_id);
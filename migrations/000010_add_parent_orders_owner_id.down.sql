@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE parent_orders DROP COLUMN owner_id;
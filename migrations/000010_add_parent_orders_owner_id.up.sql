@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE parent_orders ADD COLUMN owner_id VARCHAR(64) NOT NULL DEFAULT '';
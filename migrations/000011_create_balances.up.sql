@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE TABLE balances (
+    account_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    settled DECIMAL(20,8) NOT NULL DEFAULT 0,
+    pending DECIMAL(20,8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, currency)
+);
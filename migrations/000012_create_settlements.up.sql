@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE settlements (
+    settlement_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    account_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    settle_at TIMESTAMP NOT NULL,
+    status ENUM('pending', 'settled') NOT NULL,
+    INDEX idx_status_settle_at (status, settle_at),
+    INDEX idx_account_id (account_id),
+    FOREIGN KEY (trade_id) REFERENCES trades(trade_id)
+);
@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE orders DROP COLUMN time_in_force;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE orders ADD COLUMN time_in_force ENUM('gtc', 'ioc', 'fok') NOT NULL DEFAULT 'gtc';
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE fee_ledger (
+    entry_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    exec_id CHAR(36) NOT NULL UNIQUE,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    account_id VARCHAR(64) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    liquidity ENUM('maker', 'taker', 'auction') NOT NULL,
+    rate DECIMAL(10,6) NOT NULL,
+    notional DECIMAL(30,8) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_account_created_at (account_id, created_at),
+    FOREIGN KEY (exec_id) REFERENCES executions(exec_id)
+);
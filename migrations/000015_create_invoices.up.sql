@@ -0,0 +1,8 @@
+-- +migrate Up
+CREATE TABLE invoices (
+    invoice_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id VARCHAR(64) NOT NULL,
+    period_start DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE KEY uk_account_period (account_id, period_start)
+);
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE invoice_lines (
+    invoice_id BIGINT UNSIGNED NOT NULL,
+    day DATE NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    fills INT NOT NULL,
+    notional DECIMAL(30,8) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    PRIMARY KEY (invoice_id, day, symbol, currency),
+    FOREIGN KEY (invoice_id) REFERENCES invoices(invoice_id)
+);
@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE orders
+    MODIFY COLUMN type ENUM('limit', 'market') NOT NULL,
+    MODIFY COLUMN status ENUM('open', 'filled', 'canceled') NOT NULL,
+    DROP COLUMN trigger_price;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE orders
+    MODIFY COLUMN type ENUM('limit', 'market', 'stop', 'stop_limit') NOT NULL,
+    MODIFY COLUMN status ENUM('open', 'filled', 'canceled', 'pending') NOT NULL,
+    ADD COLUMN trigger_price DECIMAL(10,2) DEFAULT NULL;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE orders ADD COLUMN display_quantity DECIMAL(10,2) DEFAULT NULL;
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE order_groups (
+    group_id BIGINT UNSIGNED PRIMARY KEY,
+    type ENUM('oco') NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    status ENUM('active', 'completed', 'canceled') NOT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_status (status)
+);
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_group_id,
+    DROP COLUMN group_id;
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN group_id BIGINT UNSIGNED DEFAULT NULL,
+    ADD INDEX idx_group_id (group_id);
@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE trades DROP COLUMN print_type;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE trades ADD COLUMN print_type ENUM('book', 'block') NOT NULL DEFAULT 'book';
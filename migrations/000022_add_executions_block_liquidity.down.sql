@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE executions MODIFY liquidity ENUM('maker', 'taker', 'auction') NOT NULL;
@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE fee_ledger MODIFY liquidity ENUM('maker', 'taker', 'auction') NOT NULL;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE fee_ledger MODIFY liquidity ENUM('maker', 'taker', 'auction', 'block') NOT NULL;
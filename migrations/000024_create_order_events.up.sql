@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE order_events (
+    event_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    kind ENUM('created', 'updated') NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    type ENUM('limit', 'market', 'stop', 'stop_limit') NOT NULL,
+    status ENUM('open', 'filled', 'canceled', 'pending') NOT NULL,
+    price DECIMAL(10,2) DEFAULT NULL,
+    initial_quantity DECIMAL(10,2) NOT NULL,
+    remaining_quantity DECIMAL(10,2) NOT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_order_id (order_id)
+);
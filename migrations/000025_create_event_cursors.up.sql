@@ -0,0 +1,8 @@
+-- +migrate Up
+CREATE TABLE event_cursors (
+    subscriber_id VARCHAR(64) NOT NULL,
+    shard INT UNSIGNED NOT NULL,
+    last_event_id BIGINT UNSIGNED NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscriber_id, shard)
+);
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE fee_schedules (
+    schedule_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    maker_rate DECIMAL(10,6) NOT NULL,
+    taker_rate DECIMAL(10,6) NOT NULL,
+    allocation TINYINT UNSIGNED NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (allocation <= 100)
+);
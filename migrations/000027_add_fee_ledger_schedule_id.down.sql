@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE fee_ledger DROP INDEX idx_schedule_id, DROP COLUMN schedule_id;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE fee_ledger ADD COLUMN schedule_id BIGINT UNSIGNED DEFAULT NULL, ADD INDEX idx_schedule_id (schedule_id);
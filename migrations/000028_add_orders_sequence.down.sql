@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_sequence,
+    DROP COLUMN sequence,
+    MODIFY created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE orders
+    MODIFY created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN sequence BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD INDEX idx_sequence (sequence);
@@ -0,0 +1,2 @@
+-- +migrate Down
+DROP TABLE market_quality_samples;
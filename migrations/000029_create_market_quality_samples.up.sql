@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE market_quality_samples (
+    sample_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    symbol VARCHAR(10) NOT NULL,
+    best_bid DECIMAL(10,2) DEFAULT NULL,
+    best_ask DECIMAL(10,2) DEFAULT NULL,
+    spread_bps DECIMAL(12,4) DEFAULT NULL,
+    bid_depth DECIMAL(30,8) NOT NULL,
+    ask_depth DECIMAL(30,8) NOT NULL,
+    captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_symbol_captured (symbol, captured_at)
+);
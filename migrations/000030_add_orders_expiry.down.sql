@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP COLUMN updated_at,
+    MODIFY status ENUM('open', 'filled', 'canceled', 'pending') NOT NULL;
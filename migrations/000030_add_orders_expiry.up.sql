@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE orders
+    MODIFY status ENUM('open', 'filled', 'canceled', 'pending', 'expired') NOT NULL,
+    ADD COLUMN updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6);
@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE TABLE signing_keys (
+    key_id CHAR(32) PRIMARY KEY,
+    account_id VARCHAR(64) NOT NULL,
+    secret CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_account_id (account_id)
+);
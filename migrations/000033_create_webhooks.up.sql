@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE TABLE webhooks (
+    webhook_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id VARCHAR(64) NOT NULL,
+    url VARCHAR(512) NOT NULL,
+    secret CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_account_id (account_id)
+);
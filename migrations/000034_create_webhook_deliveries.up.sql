@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE webhook_deliveries (
+    delivery_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    webhook_id BIGINT UNSIGNED NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status ENUM('pending', 'delivered', 'failed') NOT NULL,
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(6) NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_status_next_attempt (status, next_attempt_at),
+    INDEX idx_webhook_id (webhook_id)
+);
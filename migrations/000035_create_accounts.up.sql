@@ -0,0 +1,6 @@
+-- +migrate Up
+CREATE TABLE accounts (
+    account_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+-- +migrate Up
+INSERT IGNORE INTO accounts (account_id)
+SELECT owner_id FROM orders WHERE owner_id <> ''
+UNION
+SELECT account_id FROM signing_keys;
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX uk_owner_client_order_id,
+    DROP COLUMN client_order_id;
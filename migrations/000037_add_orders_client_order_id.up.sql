@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN client_order_id VARCHAR(64) DEFAULT NULL,
+    ADD UNIQUE KEY uk_owner_client_order_id (owner_id, client_order_id);
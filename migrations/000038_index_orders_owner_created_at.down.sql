@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_owner_created_at,
+    ADD INDEX idx_owner_id (owner_id);
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE orders
+    DROP INDEX idx_owner_id,
+    ADD INDEX idx_owner_created_at (owner_id, created_at);
@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE trades DROP INDEX idx_created_at;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE trades ADD INDEX idx_created_at (created_at);
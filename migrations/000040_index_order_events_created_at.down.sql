@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE order_events DROP INDEX idx_created_at;
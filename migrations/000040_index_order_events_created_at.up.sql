@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE order_events ADD INDEX idx_created_at (created_at);
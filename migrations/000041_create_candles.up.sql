@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE candles (
+    symbol VARCHAR(10) NOT NULL,
+    period ENUM('1m', '5m', '1h', '1d') NOT NULL,
+    open_time TIMESTAMP NOT NULL,
+    open DECIMAL(10,2) NOT NULL,
+    high DECIMAL(10,2) NOT NULL,
+    low DECIMAL(10,2) NOT NULL,
+    close DECIMAL(10,2) NOT NULL,
+    volume DECIMAL(20,8) NOT NULL,
+    trade_count INT UNSIGNED NOT NULL,
+    first_trade_id BIGINT UNSIGNED NOT NULL,
+    last_trade_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (symbol, period, open_time)
+);
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE trades ADD INDEX idx_symbol_trade_id (symbol, trade_id);
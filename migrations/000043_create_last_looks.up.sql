@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE last_looks (
+    last_look_id BIGINT UNSIGNED PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    provider_id VARCHAR(64) NOT NULL,
+    taker_order_id BIGINT UNSIGNED NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    quantity DECIMAL(10,2) NOT NULL,
+    outcome ENUM('accepted', 'rejected', 'timed_out') NOT NULL,
+    requested_at TIMESTAMP(6) NOT NULL,
+    decided_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_symbol_requested_at (symbol, requested_at),
+    INDEX idx_provider_requested_at (provider_id, requested_at)
+);
@@ -0,0 +1,6 @@
+-- +migrate Up
+CREATE TABLE tick_sizes (
+    symbol VARCHAR(10) PRIMARY KEY,
+    tick_size DECIMAL(10,2) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL
+);
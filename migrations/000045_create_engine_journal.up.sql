@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE engine_journal (
+    symbol VARCHAR(10) NOT NULL,
+    sequence BIGINT UNSIGNED NOT NULL,
+    kind ENUM('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group') NOT NULL,
+    command JSON NOT NULL,
+    trades JSON NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (symbol, sequence)
+);
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE daily_summaries (
+    day DATE PRIMARY KEY,
+    trades INT UNSIGNED NOT NULL,
+    trade_digest CHAR(64) NOT NULL,
+    ledger_entries INT UNSIGNED NOT NULL,
+    ledger_digest CHAR(64) NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP(6) NOT NULL
+);
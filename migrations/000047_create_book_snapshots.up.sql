@@ -0,0 +1,8 @@
+-- +migrate Up
+CREATE TABLE book_snapshots (
+    symbol VARCHAR(10) PRIMARY KEY,
+    last_event_id BIGINT UNSIGNED NOT NULL,
+    orders INT UNSIGNED NOT NULL,
+    book LONGBLOB NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL
+);
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE symbols (
+    symbol VARCHAR(10) PRIMARY KEY,
+    base_currency VARCHAR(10) NOT NULL,
+    quote_currency VARCHAR(10) NOT NULL,
+    lot_size DECIMAL(10,2) NOT NULL,
+    min_notional DECIMAL(30,8) NOT NULL,
+    status ENUM('active', 'delisted') NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL
+);
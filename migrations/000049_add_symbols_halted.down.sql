@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE symbols MODIFY status ENUM('active', 'delisted') NOT NULL;
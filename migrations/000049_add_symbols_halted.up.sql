@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE symbols MODIFY status ENUM('active', 'halted', 'delisted') NOT NULL;
@@ -0,0 +1,8 @@
+-- +migrate Up
+CREATE TABLE ledger_postings (
+    posting_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    kind ENUM('opening', 'deposit', 'trade', 'settlement') NOT NULL,
+    reference_id BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_kind_reference (kind, reference_id)
+);
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE ledger_entries (
+    entry_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    posting_id BIGINT UNSIGNED NOT NULL,
+    account_id VARCHAR(64) NULL,
+    currency VARCHAR(10) NOT NULL,
+    bucket ENUM('settled', 'pending', 'external', 'anonymous') NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    INDEX idx_account_currency (account_id, currency),
+    FOREIGN KEY (posting_id) REFERENCES ledger_postings(posting_id)
+);
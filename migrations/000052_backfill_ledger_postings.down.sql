@@ -0,0 +1,2 @@
+-- +migrate Down
+DELETE FROM ledger_postings WHERE kind = 'opening';
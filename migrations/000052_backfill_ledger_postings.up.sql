@@ -0,0 +1,3 @@
+-- +migrate Up
+INSERT INTO ledger_postings (kind, created_at)
+SELECT 'opening', NOW(6) FROM DUAL WHERE EXISTS (SELECT 1 FROM balances WHERE settled <> 0 OR pending <> 0);
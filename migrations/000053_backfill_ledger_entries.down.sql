@@ -0,0 +1,2 @@
+-- +migrate Down
+DELETE FROM ledger_entries WHERE posting_id IN (SELECT posting_id FROM ledger_postings WHERE kind = 'opening');
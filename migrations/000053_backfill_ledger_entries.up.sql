@@ -0,0 +1,14 @@
+-- +migrate Up
+INSERT INTO ledger_entries (posting_id, account_id, currency, bucket, amount)
+SELECT p.posting_id, b.account_id, b.currency, 'settled', b.settled
+FROM balances b JOIN ledger_postings p ON p.kind = 'opening'
+WHERE b.settled <> 0
+UNION ALL
+SELECT p.posting_id, b.account_id, b.currency, 'pending', b.pending
+FROM balances b JOIN ledger_postings p ON p.kind = 'opening'
+WHERE b.pending <> 0
+UNION ALL
+SELECT p.posting_id, NULL, b.currency, 'external', -SUM(b.settled + b.pending)
+FROM balances b JOIN ledger_postings p ON p.kind = 'opening'
+GROUP BY p.posting_id, b.currency
+HAVING SUM(b.settled + b.pending) <> 0;
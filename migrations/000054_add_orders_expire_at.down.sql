@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_status_expire_at,
+    DROP COLUMN expire_at,
+    MODIFY time_in_force ENUM('gtc', 'ioc', 'fok') NOT NULL DEFAULT 'gtc';
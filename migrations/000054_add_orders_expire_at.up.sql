@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE orders
+    MODIFY time_in_force ENUM('gtc', 'ioc', 'fok', 'gtd') NOT NULL DEFAULT 'gtc',
+    ADD COLUMN expire_at TIMESTAMP(6) NULL DEFAULT NULL,
+    ADD INDEX idx_status_expire_at (status, expire_at);
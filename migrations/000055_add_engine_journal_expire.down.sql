@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE engine_journal
+    MODIFY kind ENUM('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group') NOT NULL;
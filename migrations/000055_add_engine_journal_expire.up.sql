@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE engine_journal
+    MODIFY kind ENUM('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group', 'expire') NOT NULL;
@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE order_events
+    DROP COLUMN reason,
+    DROP COLUMN actor,
+    MODIFY kind ENUM('created', 'updated') NOT NULL;
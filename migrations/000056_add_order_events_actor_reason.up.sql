@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE order_events
+    MODIFY kind ENUM('created', 'updated', 'rejected') NOT NULL,
+    ADD COLUMN actor VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN reason VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE trades
+    MODIFY trade_id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    MODIFY created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
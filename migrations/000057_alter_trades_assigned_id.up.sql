@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE trades
+    MODIFY trade_id BIGINT UNSIGNED NOT NULL,
+    MODIFY created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
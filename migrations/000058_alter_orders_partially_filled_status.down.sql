@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE orders
+    MODIFY status ENUM('open', 'filled', 'canceled', 'pending', 'expired') NOT NULL;
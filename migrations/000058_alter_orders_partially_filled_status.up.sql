@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE orders
+    MODIFY status ENUM('open', 'partially_filled', 'filled', 'canceled', 'pending', 'expired') NOT NULL;
@@ -0,0 +1,4 @@
+-- +migrate Down
+UPDATE orders
+SET status = 'open'
+WHERE status = 'partially_filled';
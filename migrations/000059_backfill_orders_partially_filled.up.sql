@@ -0,0 +1,4 @@
+-- +migrate Up
+UPDATE orders
+SET status = 'partially_filled'
+WHERE status = 'open' AND remaining_quantity < initial_quantity;
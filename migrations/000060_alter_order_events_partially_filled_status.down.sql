@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE order_events
+    MODIFY status ENUM('open', 'filled', 'canceled', 'pending', 'expired') NOT NULL;
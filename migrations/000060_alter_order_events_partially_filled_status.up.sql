@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE order_events
+    MODIFY status ENUM('open', 'partially_filled', 'filled', 'canceled', 'pending', 'expired') NOT NULL;
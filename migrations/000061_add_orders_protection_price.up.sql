@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN protection_price DECIMAL(10,2) DEFAULT NULL;
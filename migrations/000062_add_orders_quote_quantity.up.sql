@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN quote_quantity DECIMAL(20,8) DEFAULT NULL;
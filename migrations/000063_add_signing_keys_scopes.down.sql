@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE signing_keys
+    DROP COLUMN allowed_ips,
+    DROP COLUMN scopes;
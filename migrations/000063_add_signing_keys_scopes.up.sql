@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE signing_keys
+    ADD COLUMN scopes SET('read','trade','cancel','admin') NOT NULL DEFAULT 'read,trade,cancel' AFTER secret,
+    ADD COLUMN allowed_ips VARCHAR(1024) NOT NULL DEFAULT '' AFTER scopes;
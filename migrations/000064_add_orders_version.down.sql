@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE orders
+    DROP COLUMN version;
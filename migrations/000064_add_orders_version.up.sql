@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 0 AFTER quote_quantity;
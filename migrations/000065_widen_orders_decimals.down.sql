@@ -0,0 +1,8 @@
+-- +migrate Down
+ALTER TABLE orders
+    MODIFY COLUMN price DECIMAL(10,2) DEFAULT NULL,
+    MODIFY COLUMN initial_quantity DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN remaining_quantity DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN trigger_price DECIMAL(10,2) DEFAULT NULL,
+    MODIFY COLUMN display_quantity DECIMAL(10,2) DEFAULT NULL,
+    MODIFY COLUMN protection_price DECIMAL(10,2) DEFAULT NULL;
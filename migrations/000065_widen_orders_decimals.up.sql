@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE orders
+    MODIFY COLUMN price DECIMAL(20,8) DEFAULT NULL,
+    MODIFY COLUMN initial_quantity DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN remaining_quantity DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN trigger_price DECIMAL(20,8) DEFAULT NULL,
+    MODIFY COLUMN display_quantity DECIMAL(20,8) DEFAULT NULL,
+    MODIFY COLUMN protection_price DECIMAL(20,8) DEFAULT NULL;
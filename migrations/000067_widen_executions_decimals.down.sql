@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE executions
+    MODIFY COLUMN price DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN quantity DECIMAL(10,2) NOT NULL;
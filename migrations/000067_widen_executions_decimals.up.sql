@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE executions
+    MODIFY COLUMN price DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN quantity DECIMAL(20,8) NOT NULL;
@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE parent_orders
+    MODIFY COLUMN total_quantity DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN filled_quantity DECIMAL(10,2) NOT NULL DEFAULT 0,
+    MODIFY COLUMN limit_price DECIMAL(10,2) DEFAULT NULL;
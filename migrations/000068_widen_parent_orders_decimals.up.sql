@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE parent_orders
+    MODIFY COLUMN total_quantity DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN filled_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    MODIFY COLUMN limit_price DECIMAL(20,8) DEFAULT NULL;
@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE order_events
+    MODIFY COLUMN price DECIMAL(10,2) DEFAULT NULL,
+    MODIFY COLUMN initial_quantity DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN remaining_quantity DECIMAL(10,2) NOT NULL;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE order_events
+    MODIFY COLUMN price DECIMAL(20,8) DEFAULT NULL,
+    MODIFY COLUMN initial_quantity DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN remaining_quantity DECIMAL(20,8) NOT NULL;
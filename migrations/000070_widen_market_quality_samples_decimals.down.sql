@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE market_quality_samples
+    MODIFY COLUMN best_bid DECIMAL(10,2) DEFAULT NULL,
+    MODIFY COLUMN best_ask DECIMAL(10,2) DEFAULT NULL;
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE market_quality_samples
+    MODIFY COLUMN best_bid DECIMAL(20,8) DEFAULT NULL,
+    MODIFY COLUMN best_ask DECIMAL(20,8) DEFAULT NULL;
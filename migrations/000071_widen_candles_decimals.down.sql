@@ -0,0 +1,6 @@
+-- +migrate Down
+ALTER TABLE candles
+    MODIFY COLUMN open DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN high DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN low DECIMAL(10,2) NOT NULL,
+    MODIFY COLUMN close DECIMAL(10,2) NOT NULL;
@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE candles
+    MODIFY COLUMN open DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN high DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN low DECIMAL(20,8) NOT NULL,
+    MODIFY COLUMN close DECIMAL(20,8) NOT NULL;
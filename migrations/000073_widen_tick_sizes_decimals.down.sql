@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE tick_sizes
+    MODIFY COLUMN tick_size DECIMAL(10,2) NOT NULL;
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE tick_sizes
+    MODIFY COLUMN tick_size DECIMAL(20,8) NOT NULL;
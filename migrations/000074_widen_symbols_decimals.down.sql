@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE symbols
+    MODIFY COLUMN lot_size DECIMAL(10,2) NOT NULL;
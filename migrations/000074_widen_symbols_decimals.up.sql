@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE symbols
+    MODIFY COLUMN lot_size DECIMAL(20,8) NOT NULL;
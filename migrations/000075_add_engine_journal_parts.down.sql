@@ -0,0 +1,7 @@
+-- +migrate Down
+DELETE FROM engine_journal WHERE part > 0 OR kind IN ('stale', 'tick_size', 'auction', 'uncross');
+ALTER TABLE engine_journal
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (symbol, sequence),
+    DROP COLUMN part,
+    MODIFY kind ENUM('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group', 'expire') NOT NULL;
@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE engine_journal
+    ADD COLUMN part INT UNSIGNED NOT NULL DEFAULT 0 AFTER sequence,
+    MODIFY kind ENUM('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group', 'expire', 'stale', 'tick_size', 'auction', 'uncross') NOT NULL,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (symbol, sequence, part);
@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE accounts
+    MODIFY COLUMN name VARCHAR(128) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE accounts
+    MODIFY COLUMN name VARCHAR(512) NOT NULL DEFAULT '';
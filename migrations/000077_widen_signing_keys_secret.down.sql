@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE signing_keys
+    MODIFY COLUMN secret CHAR(64) NOT NULL;
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE signing_keys
+    MODIFY COLUMN secret VARCHAR(512) NOT NULL;
@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE webhooks
+    MODIFY COLUMN secret CHAR(64) NOT NULL;
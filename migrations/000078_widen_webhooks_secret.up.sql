@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE webhooks
+    MODIFY COLUMN secret VARCHAR(512) NOT NULL;
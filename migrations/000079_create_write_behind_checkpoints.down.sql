@@ -0,0 +1,2 @@
+-- +migrate Down
+DROP TABLE write_behind_checkpoints;
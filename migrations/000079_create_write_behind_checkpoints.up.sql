@@ -0,0 +1,6 @@
+-- +migrate Up
+CREATE TABLE write_behind_checkpoints (
+    node_id SMALLINT UNSIGNED PRIMARY KEY,
+    sequence BIGINT UNSIGNED NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
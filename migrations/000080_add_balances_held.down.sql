@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE balances
+    DROP COLUMN held;
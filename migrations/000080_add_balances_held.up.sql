@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE balances
+    ADD COLUMN held DECIMAL(20,8) NOT NULL DEFAULT 0 AFTER pending;
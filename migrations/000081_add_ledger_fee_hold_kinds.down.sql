@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE ledger_postings
+    MODIFY COLUMN kind ENUM('opening', 'deposit', 'trade', 'settlement') NOT NULL;
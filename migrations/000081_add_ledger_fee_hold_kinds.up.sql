@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE ledger_postings
+    MODIFY COLUMN kind ENUM('opening', 'deposit', 'trade', 'settlement', 'fee', 'reserve', 'release') NOT NULL;
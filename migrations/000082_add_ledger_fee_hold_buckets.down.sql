@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE ledger_entries
+    MODIFY COLUMN bucket ENUM('settled', 'pending', 'external', 'anonymous') NOT NULL;
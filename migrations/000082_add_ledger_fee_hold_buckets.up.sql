@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE ledger_entries
+    MODIFY COLUMN bucket ENUM('settled', 'pending', 'held', 'unheld', 'external', 'anonymous', 'fees') NOT NULL;
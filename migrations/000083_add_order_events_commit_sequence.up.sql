@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE order_events
+    ADD COLUMN commit_sequence BIGINT UNSIGNED NULL AFTER event_id,
+    ADD UNIQUE INDEX idx_commit_sequence (commit_sequence);
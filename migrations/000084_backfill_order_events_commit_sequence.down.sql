@@ -0,0 +1,2 @@
+-- +migrate Down
+UPDATE order_events SET commit_sequence = NULL;
@@ -0,0 +1,2 @@
+-- +migrate Up
+UPDATE order_events SET commit_sequence = event_id;
@@ -0,0 +1,5 @@
+-- +migrate Up
+CREATE TABLE outbox_sequences (
+    outbox VARCHAR(32) PRIMARY KEY,
+    last_sequence BIGINT UNSIGNED NOT NULL
+);
@@ -0,0 +1,3 @@
+-- +migrate Up
+INSERT INTO outbox_sequences (outbox, last_sequence)
+SELECT 'order_events', COALESCE(MAX(event_id), 0) FROM order_events;
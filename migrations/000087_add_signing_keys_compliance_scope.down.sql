@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE signing_keys
+    MODIFY COLUMN scopes SET('read','trade','cancel','admin') NOT NULL DEFAULT 'read,trade,cancel';
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE signing_keys
+    MODIFY COLUMN scopes SET('read','trade','cancel','admin','compliance') NOT NULL DEFAULT 'read,trade,cancel';
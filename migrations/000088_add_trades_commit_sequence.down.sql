@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE trades
+    DROP INDEX idx_commit_sequence,
+    DROP COLUMN commit_sequence;
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE trades
+    ADD COLUMN commit_sequence BIGINT UNSIGNED NULL AFTER trade_id,
+    ADD UNIQUE INDEX idx_commit_sequence (commit_sequence);
@@ -0,0 +1,2 @@
+-- +migrate Down
+UPDATE trades SET commit_sequence = NULL;
@@ -0,0 +1,2 @@
+-- +migrate Up
+UPDATE trades SET commit_sequence = trade_id;
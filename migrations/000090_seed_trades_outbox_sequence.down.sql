@@ -0,0 +1,2 @@
+-- +migrate Down
+DELETE FROM outbox_sequences WHERE outbox = 'trades';
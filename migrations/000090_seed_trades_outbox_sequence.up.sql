@@ -0,0 +1,3 @@
+-- +migrate Up
+INSERT INTO outbox_sequences (outbox, last_sequence)
+SELECT 'trades', COALESCE(MAX(trade_id), 0) FROM trades;
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE candles
+    RENAME COLUMN first_sequence TO first_trade_id,
+    RENAME COLUMN last_sequence TO last_trade_id;
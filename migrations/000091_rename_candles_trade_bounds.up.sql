@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE candles
+    RENAME COLUMN first_trade_id TO first_sequence,
+    RENAME COLUMN last_trade_id TO last_sequence;
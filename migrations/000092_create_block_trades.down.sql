@@ -0,0 +1,2 @@
+-- +migrate Down
+DROP TABLE block_trades;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE block_trades (
+    block_id BIGINT UNSIGNED PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    buyer_id VARCHAR(64) NOT NULL,
+    seller_id VARCHAR(64) NOT NULL,
+    reporter_id VARCHAR(64) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    status ENUM('pending', 'affirmed', 'declined') NOT NULL,
+    trade_id BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    INDEX idx_buyer_status (buyer_id, status),
+    INDEX idx_seller_status (seller_id, status)
+);
@@ -0,0 +1,15 @@
+// Package migrations embeds the database migrations, so binaries run them from wherever they
+// are started.
+package migrations
+
+import "embed"
+
+// MySQL holds the MySQL migrations
+//
+//go:embed *.sql
+var MySQL embed.FS
+
+// SQLite holds the SQLite migrations, under sqlite/
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
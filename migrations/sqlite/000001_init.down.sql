@@ -0,0 +1,32 @@
+-- +migrate Down
+DROP TABLE outbox_sequences;
+DROP TABLE write_behind_checkpoints;
+DROP TABLE accounts;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+DROP TABLE signing_keys;
+DROP TABLE ledger_entries;
+DROP TABLE ledger_postings;
+DROP TABLE symbols;
+DROP TABLE book_snapshots;
+DROP TABLE daily_summaries;
+DROP TABLE engine_journal;
+DROP TABLE tick_sizes;
+DROP TABLE last_looks;
+DROP TABLE candles;
+DROP TABLE market_quality_samples;
+DROP TABLE fee_schedules;
+DROP TABLE event_cursors;
+DROP TABLE order_events;
+DROP TABLE block_trades;
+DROP TABLE order_groups;
+DROP TABLE invoice_lines;
+DROP TABLE invoices;
+DROP TABLE fee_ledger;
+DROP TABLE settlements;
+DROP TABLE balances;
+DROP TABLE parent_orders;
+DROP TABLE executions;
+DROP TABLE depth_snapshots;
+DROP TABLE trades;
+DROP TABLE orders;
@@ -0,0 +1,390 @@
+-- +migrate Up
+-- The SQLite schema as of MySQL migration 000064. SQLite has no unsigned, enum, set or
+-- fixed-point types: IDs are INTEGER, enums are TEXT with a CHECK, decimals keep their
+-- declared type for NUMERIC affinity and timestamps are stored as text.
+CREATE TABLE orders (
+    order_id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    type TEXT NOT NULL CHECK (type IN ('limit', 'market', 'stop', 'stop_limit')),
+    price DECIMAL(20,8) DEFAULT NULL,
+    initial_quantity DECIMAL(20,8) NOT NULL,
+    remaining_quantity DECIMAL(20,8) NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('open', 'partially_filled', 'filled', 'canceled', 'pending', 'expired')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    parent_id INTEGER DEFAULT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    time_in_force TEXT NOT NULL DEFAULT 'gtc' CHECK (time_in_force IN ('gtc', 'ioc', 'fok', 'gtd')),
+    trigger_price DECIMAL(20,8) DEFAULT NULL,
+    display_quantity DECIMAL(20,8) DEFAULT NULL,
+    group_id INTEGER DEFAULT NULL,
+    sequence INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    client_order_id VARCHAR(64) DEFAULT NULL,
+    expire_at TIMESTAMP NULL DEFAULT NULL,
+    protection_price DECIMAL(20,8) DEFAULT NULL,
+    quote_quantity DECIMAL(20,8) DEFAULT NULL,
+    version INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (owner_id, client_order_id),
+    CHECK (initial_quantity >= 0),
+    CHECK (remaining_quantity >= 0),
+    CHECK (price > 0 OR price IS NULL),
+    CHECK (trigger_price > 0 OR trigger_price IS NULL),
+    CHECK (remaining_quantity <= initial_quantity)
+);
+CREATE INDEX orders_symbol_status ON orders (symbol, status);
+CREATE INDEX orders_parent_id ON orders (parent_id);
+CREATE INDEX orders_group_id ON orders (group_id);
+CREATE INDEX orders_owner_created_at ON orders (owner_id, created_at);
+CREATE INDEX orders_sequence ON orders (sequence);
+CREATE INDEX orders_status_expire_at ON orders (status, expire_at);
+
+-- Stands in for MySQL's ON UPDATE CURRENT_TIMESTAMP, in the format the driver writes times in
+CREATE TRIGGER orders_updated_at AFTER UPDATE ON orders FOR EACH ROW
+BEGIN
+    UPDATE orders SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE order_id = NEW.order_id;
+END;
+
+CREATE TABLE trades (
+    trade_id INTEGER PRIMARY KEY,
+    commit_sequence INTEGER NULL,
+    symbol VARCHAR(10) NOT NULL,
+    buy_order_id INTEGER NOT NULL REFERENCES orders (order_id),
+    sell_order_id INTEGER NOT NULL REFERENCES orders (order_id),
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    print_type TEXT NOT NULL DEFAULT 'book' CHECK (print_type IN ('book', 'block')),
+    CHECK (price > 0),
+    CHECK (quantity > 0)
+);
+CREATE UNIQUE INDEX trades_commit_sequence ON trades (commit_sequence);
+CREATE INDEX trades_created_at ON trades (created_at);
+CREATE INDEX trades_symbol_trade_id ON trades (symbol, trade_id);
+
+CREATE TABLE depth_snapshots (
+    snapshot_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol VARCHAR(10) NOT NULL,
+    bids TEXT NOT NULL,
+    asks TEXT NOT NULL,
+    captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX depth_snapshots_symbol_captured ON depth_snapshots (symbol, captured_at);
+
+CREATE TABLE executions (
+    exec_id CHAR(36) PRIMARY KEY,
+    trade_id INTEGER NOT NULL REFERENCES trades (trade_id),
+    order_id INTEGER NOT NULL REFERENCES orders (order_id),
+    symbol VARCHAR(10) NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    liquidity TEXT NOT NULL CHECK (liquidity IN ('maker', 'taker', 'auction', 'block')),
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX executions_order_id ON executions (order_id);
+
+CREATE TABLE parent_orders (
+    parent_id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    strategy TEXT NOT NULL CHECK (strategy IN ('twap', 'pov')),
+    total_quantity DECIMAL(20,8) NOT NULL,
+    filled_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    limit_price DECIMAL(20,8) DEFAULT NULL,
+    participation_rate DECIMAL(5,4) NOT NULL DEFAULT 0,
+    slice_interval_ms INTEGER NOT NULL,
+    next_slice_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('active', 'completed', 'canceled')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    CHECK (total_quantity > 0),
+    CHECK (filled_quantity <= total_quantity)
+);
+CREATE INDEX parent_orders_status ON parent_orders (status);
+
+CREATE TABLE balances (
+    account_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    settled DECIMAL(20,8) NOT NULL DEFAULT 0,
+    pending DECIMAL(20,8) NOT NULL DEFAULT 0,
+    held DECIMAL(20,8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, currency)
+);
+
+CREATE TABLE settlements (
+    settlement_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trade_id INTEGER NOT NULL REFERENCES trades (trade_id),
+    account_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    settle_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'settled'))
+);
+CREATE INDEX settlements_status_settle_at ON settlements (status, settle_at);
+CREATE INDEX settlements_account_id ON settlements (account_id);
+
+CREATE TABLE fee_ledger (
+    entry_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    exec_id CHAR(36) NOT NULL UNIQUE REFERENCES executions (exec_id),
+    trade_id INTEGER NOT NULL,
+    account_id VARCHAR(64) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    liquidity TEXT NOT NULL CHECK (liquidity IN ('maker', 'taker', 'auction', 'block')),
+    rate DECIMAL(10,6) NOT NULL,
+    notional DECIMAL(30,8) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    schedule_id INTEGER DEFAULT NULL
+);
+CREATE INDEX fee_ledger_account_created_at ON fee_ledger (account_id, created_at);
+CREATE INDEX fee_ledger_schedule_id ON fee_ledger (schedule_id);
+
+CREATE TABLE invoices (
+    invoice_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id VARCHAR(64) NOT NULL,
+    period_start DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (account_id, period_start)
+);
+
+CREATE TABLE invoice_lines (
+    invoice_id INTEGER NOT NULL REFERENCES invoices (invoice_id),
+    day DATE NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    fills INTEGER NOT NULL,
+    notional DECIMAL(30,8) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    PRIMARY KEY (invoice_id, day, symbol, currency)
+);
+
+CREATE TABLE order_groups (
+    group_id INTEGER PRIMARY KEY,
+    type TEXT NOT NULL CHECK (type IN ('oco')),
+    symbol VARCHAR(10) NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('active', 'completed', 'canceled')),
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX order_groups_status ON order_groups (status);
+
+CREATE TABLE block_trades (
+    block_id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    buyer_id VARCHAR(64) NOT NULL,
+    seller_id VARCHAR(64) NOT NULL,
+    reporter_id VARCHAR(64) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'affirmed', 'declined')),
+    trade_id INTEGER NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX block_trades_buyer_status ON block_trades (buyer_id, status);
+CREATE INDEX block_trades_seller_status ON block_trades (seller_id, status);
+
+CREATE TABLE order_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    commit_sequence INTEGER NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('created', 'updated', 'rejected')),
+    order_id INTEGER NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    type TEXT NOT NULL CHECK (type IN ('limit', 'market', 'stop', 'stop_limit')),
+    status TEXT NOT NULL CHECK (status IN ('open', 'partially_filled', 'filled', 'canceled', 'pending', 'expired')),
+    price DECIMAL(20,8) DEFAULT NULL,
+    initial_quantity DECIMAL(20,8) NOT NULL,
+    remaining_quantity DECIMAL(20,8) NOT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    actor VARCHAR(64) NOT NULL DEFAULT '',
+    reason VARCHAR(32) NOT NULL DEFAULT ''
+);
+CREATE UNIQUE INDEX order_events_commit_sequence ON order_events (commit_sequence);
+CREATE INDEX order_events_order_id ON order_events (order_id);
+CREATE INDEX order_events_created_at ON order_events (created_at);
+
+CREATE TABLE event_cursors (
+    subscriber_id VARCHAR(64) NOT NULL,
+    shard INTEGER NOT NULL,
+    last_event_id INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscriber_id, shard)
+);
+
+CREATE TABLE fee_schedules (
+    schedule_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    maker_rate DECIMAL(10,6) NOT NULL,
+    taker_rate DECIMAL(10,6) NOT NULL,
+    allocation INTEGER NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (allocation >= 0 AND allocation <= 100)
+);
+
+CREATE TABLE market_quality_samples (
+    sample_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol VARCHAR(10) NOT NULL,
+    best_bid DECIMAL(20,8) DEFAULT NULL,
+    best_ask DECIMAL(20,8) DEFAULT NULL,
+    spread_bps DECIMAL(12,4) DEFAULT NULL,
+    bid_depth DECIMAL(30,8) NOT NULL,
+    ask_depth DECIMAL(30,8) NOT NULL,
+    captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX market_quality_samples_symbol_captured ON market_quality_samples (symbol, captured_at);
+
+CREATE TABLE candles (
+    symbol VARCHAR(10) NOT NULL,
+    period TEXT NOT NULL CHECK (period IN ('1m', '5m', '1h', '1d')),
+    open_time TIMESTAMP NOT NULL,
+    open DECIMAL(20,8) NOT NULL,
+    high DECIMAL(20,8) NOT NULL,
+    low DECIMAL(20,8) NOT NULL,
+    close DECIMAL(20,8) NOT NULL,
+    volume DECIMAL(20,8) NOT NULL,
+    trade_count INTEGER NOT NULL,
+    first_sequence INTEGER NOT NULL,
+    last_sequence INTEGER NOT NULL,
+    PRIMARY KEY (symbol, period, open_time)
+);
+
+CREATE TABLE last_looks (
+    last_look_id INTEGER PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    order_id INTEGER NOT NULL,
+    provider_id VARCHAR(64) NOT NULL,
+    taker_order_id INTEGER NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('accepted', 'rejected', 'timed_out')),
+    requested_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP NOT NULL
+);
+CREATE INDEX last_looks_symbol_requested_at ON last_looks (symbol, requested_at);
+CREATE INDEX last_looks_provider_requested_at ON last_looks (provider_id, requested_at);
+
+CREATE TABLE tick_sizes (
+    symbol VARCHAR(10) PRIMARY KEY,
+    tick_size DECIMAL(20,8) NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE engine_journal (
+    symbol VARCHAR(10) NOT NULL,
+    sequence INTEGER NOT NULL,
+    part INTEGER NOT NULL DEFAULT 0,
+    kind TEXT NOT NULL CHECK (kind IN ('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group', 'expire', 'stale', 'tick_size', 'auction', 'uncross')),
+    command TEXT NOT NULL,
+    trades TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (symbol, sequence, part)
+);
+
+CREATE TABLE daily_summaries (
+    day DATE PRIMARY KEY,
+    trades INTEGER NOT NULL,
+    trade_digest CHAR(64) NOT NULL,
+    ledger_entries INTEGER NOT NULL,
+    ledger_digest CHAR(64) NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE book_snapshots (
+    symbol VARCHAR(10) PRIMARY KEY,
+    last_event_id INTEGER NOT NULL,
+    orders INTEGER NOT NULL,
+    book BLOB NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE symbols (
+    symbol VARCHAR(10) PRIMARY KEY,
+    base_currency VARCHAR(10) NOT NULL,
+    quote_currency VARCHAR(10) NOT NULL,
+    lot_size DECIMAL(20,8) NOT NULL,
+    min_notional DECIMAL(30,8) NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('active', 'halted', 'delisted')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE ledger_postings (
+    posting_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('opening', 'deposit', 'trade', 'settlement', 'fee', 'reserve', 'release')),
+    reference_id INTEGER NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX ledger_postings_kind_reference ON ledger_postings (kind, reference_id);
+
+CREATE TABLE ledger_entries (
+    entry_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    posting_id INTEGER NOT NULL REFERENCES ledger_postings (posting_id),
+    account_id VARCHAR(64) NULL,
+    currency VARCHAR(10) NOT NULL,
+    bucket TEXT NOT NULL CHECK (bucket IN ('settled', 'pending', 'held', 'unheld', 'external', 'anonymous', 'fees')),
+    amount DECIMAL(20,8) NOT NULL
+);
+CREATE INDEX ledger_entries_account_currency ON ledger_entries (account_id, currency);
+
+CREATE TABLE signing_keys (
+    key_id CHAR(32) PRIMARY KEY,
+    account_id VARCHAR(64) NOT NULL,
+    secret VARCHAR(512) NOT NULL,
+    scopes TEXT NOT NULL DEFAULT 'read,trade,cancel',
+    allowed_ips VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL DEFAULT NULL
+);
+CREATE INDEX signing_keys_account_id ON signing_keys (account_id);
+
+CREATE TABLE webhooks (
+    webhook_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id VARCHAR(64) NOT NULL,
+    url VARCHAR(512) NOT NULL,
+    secret VARCHAR(512) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX webhooks_account_id ON webhooks (account_id);
+
+CREATE TABLE webhook_deliveries (
+    delivery_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX webhook_deliveries_status_next_attempt ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+
+CREATE TABLE accounts (
+    account_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE write_behind_checkpoints (
+    node_id INTEGER PRIMARY KEY,
+    sequence INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE outbox_sequences (
+    outbox VARCHAR(32) PRIMARY KEY,
+    last_sequence INTEGER NOT NULL
+);
+
+INSERT INTO outbox_sequences (outbox, last_sequence) VALUES ('order_events', 0), ('trades', 0);
@@ -6,29 +6,387 @@ CREATE TABLE orders (
     order_id BIGINT UNSIGNED PRIMARY KEY,
     symbol VARCHAR(10) NOT NULL,
     side ENUM('buy', 'sell') NOT NULL,
-    type ENUM('limit', 'market') NOT NULL,
-    price DECIMAL(10,2) DEFAULT NULL,
-    initial_quantity DECIMAL(10,2) NOT NULL,
-    remaining_quantity DECIMAL(10,2) NOT NULL,
-    status ENUM('open', 'filled', 'canceled') NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    type ENUM('limit', 'market', 'stop', 'stop_limit') NOT NULL,
+    price DECIMAL(20,8) DEFAULT NULL,
+    initial_quantity DECIMAL(20,8) NOT NULL,
+    remaining_quantity DECIMAL(20,8) NOT NULL,
+    status ENUM('open', 'partially_filled', 'filled', 'canceled', 'pending', 'expired') NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    parent_id BIGINT UNSIGNED DEFAULT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    time_in_force ENUM('gtc', 'ioc', 'fok', 'gtd') NOT NULL DEFAULT 'gtc',
+    trigger_price DECIMAL(20,8) DEFAULT NULL,
+    display_quantity DECIMAL(20,8) DEFAULT NULL,
+    group_id BIGINT UNSIGNED DEFAULT NULL,
+    sequence BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    client_order_id VARCHAR(64) DEFAULT NULL,
+    expire_at TIMESTAMP(6) NULL DEFAULT NULL,
+    protection_price DECIMAL(20,8) DEFAULT NULL,
+    quote_quantity DECIMAL(20,8) DEFAULT NULL,
+    version INT UNSIGNED NOT NULL DEFAULT 0,
     INDEX idx_symbol_status (symbol, status),
+    INDEX idx_parent_id (parent_id),
+    INDEX idx_group_id (group_id),
+    INDEX idx_owner_created_at (owner_id, created_at),
+    INDEX idx_sequence (sequence),
+    INDEX idx_status_expire_at (status, expire_at),
+    UNIQUE KEY uk_owner_client_order_id (owner_id, client_order_id),
     CHECK (initial_quantity >= 0),
     CHECK (remaining_quantity >= 0),
     CHECK (price > 0 OR price IS NULL),
+    CHECK (trigger_price > 0 OR trigger_price IS NULL),
     CHECK (remaining_quantity <= initial_quantity)
 );
 
 CREATE TABLE trades (
-    trade_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    trade_id BIGINT UNSIGNED PRIMARY KEY,
+    commit_sequence BIGINT UNSIGNED NULL,
     symbol VARCHAR(10) NOT NULL,
     buy_order_id BIGINT UNSIGNED NOT NULL,
     sell_order_id BIGINT UNSIGNED NOT NULL,
-    price DECIMAL(10,2) NOT NULL,
-    quantity DECIMAL(10,2) NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    print_type ENUM('book', 'block') NOT NULL DEFAULT 'book',
+    UNIQUE INDEX idx_commit_sequence (commit_sequence),
+    INDEX idx_created_at (created_at),
+    INDEX idx_symbol_trade_id (symbol, trade_id),
     FOREIGN KEY (buy_order_id) REFERENCES orders(order_id),
     FOREIGN KEY (sell_order_id) REFERENCES orders(order_id),
     CHECK (price > 0),
     CHECK (quantity > 0)
-);
\ No newline at end of file
+);
+
+CREATE TABLE depth_snapshots (
+    snapshot_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    symbol VARCHAR(10) NOT NULL,
+    bids JSON NOT NULL,
+    asks JSON NOT NULL,
+    captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_symbol_captured (symbol, captured_at)
+);
+
+CREATE TABLE executions (
+    exec_id CHAR(36) PRIMARY KEY,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    liquidity ENUM('maker', 'taker', 'auction', 'block') NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_order_id (order_id),
+    FOREIGN KEY (trade_id) REFERENCES trades(trade_id),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id)
+);
+
+CREATE TABLE parent_orders (
+    parent_id BIGINT UNSIGNED PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    strategy ENUM('twap', 'pov') NOT NULL,
+    total_quantity DECIMAL(20,8) NOT NULL,
+    filled_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    limit_price DECIMAL(20,8) DEFAULT NULL,
+    participation_rate DECIMAL(5,4) NOT NULL DEFAULT 0,
+    slice_interval_ms BIGINT UNSIGNED NOT NULL,
+    next_slice_at TIMESTAMP(3) NOT NULL,
+    end_at TIMESTAMP NOT NULL,
+    status ENUM('active', 'completed', 'canceled') NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    INDEX idx_status (status),
+    CHECK (total_quantity > 0),
+    CHECK (filled_quantity <= total_quantity)
+);
+
+CREATE TABLE balances (
+    account_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    settled DECIMAL(20,8) NOT NULL DEFAULT 0,
+    pending DECIMAL(20,8) NOT NULL DEFAULT 0,
+    held DECIMAL(20,8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, currency)
+);
+
+CREATE TABLE settlements (
+    settlement_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    account_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    settle_at TIMESTAMP NOT NULL,
+    status ENUM('pending', 'settled') NOT NULL,
+    INDEX idx_status_settle_at (status, settle_at),
+    INDEX idx_account_id (account_id),
+    FOREIGN KEY (trade_id) REFERENCES trades(trade_id)
+);
+
+CREATE TABLE fee_ledger (
+    entry_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    exec_id CHAR(36) NOT NULL UNIQUE,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    account_id VARCHAR(64) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    liquidity ENUM('maker', 'taker', 'auction', 'block') NOT NULL,
+    rate DECIMAL(10,6) NOT NULL,
+    notional DECIMAL(30,8) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    schedule_id BIGINT UNSIGNED DEFAULT NULL,
+    INDEX idx_account_created_at (account_id, created_at),
+    INDEX idx_schedule_id (schedule_id),
+    FOREIGN KEY (exec_id) REFERENCES executions(exec_id)
+);
+
+CREATE TABLE invoices (
+    invoice_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id VARCHAR(64) NOT NULL,
+    period_start DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE KEY uk_account_period (account_id, period_start)
+);
+
+CREATE TABLE invoice_lines (
+    invoice_id BIGINT UNSIGNED NOT NULL,
+    day DATE NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    fills INT NOT NULL,
+    notional DECIMAL(30,8) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    PRIMARY KEY (invoice_id, day, symbol, currency),
+    FOREIGN KEY (invoice_id) REFERENCES invoices(invoice_id)
+);
+
+CREATE TABLE order_groups (
+    group_id BIGINT UNSIGNED PRIMARY KEY,
+    type ENUM('oco') NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    status ENUM('active', 'completed', 'canceled') NOT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_status (status)
+);
+
+CREATE TABLE block_trades (
+    block_id BIGINT UNSIGNED PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    buyer_id VARCHAR(64) NOT NULL,
+    seller_id VARCHAR(64) NOT NULL,
+    reporter_id VARCHAR(64) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    status ENUM('pending', 'affirmed', 'declined') NOT NULL,
+    trade_id BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    INDEX idx_buyer_status (buyer_id, status),
+    INDEX idx_seller_status (seller_id, status)
+);
+
+CREATE TABLE order_events (
+    event_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    commit_sequence BIGINT UNSIGNED NULL,
+    kind ENUM('created', 'updated', 'rejected') NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    type ENUM('limit', 'market', 'stop', 'stop_limit') NOT NULL,
+    status ENUM('open', 'partially_filled', 'filled', 'canceled', 'pending', 'expired') NOT NULL,
+    price DECIMAL(20,8) DEFAULT NULL,
+    initial_quantity DECIMAL(20,8) NOT NULL,
+    remaining_quantity DECIMAL(20,8) NOT NULL,
+    owner_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(6) NOT NULL,
+    actor VARCHAR(64) NOT NULL DEFAULT '',
+    reason VARCHAR(32) NOT NULL DEFAULT '',
+    UNIQUE INDEX idx_commit_sequence (commit_sequence),
+    INDEX idx_order_id (order_id),
+    INDEX idx_created_at (created_at)
+);
+
+CREATE TABLE event_cursors (
+    subscriber_id VARCHAR(64) NOT NULL,
+    shard INT UNSIGNED NOT NULL,
+    last_event_id BIGINT UNSIGNED NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscriber_id, shard)
+);
+
+CREATE TABLE fee_schedules (
+    schedule_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    maker_rate DECIMAL(10,6) NOT NULL,
+    taker_rate DECIMAL(10,6) NOT NULL,
+    allocation TINYINT UNSIGNED NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (allocation <= 100)
+);
+
+CREATE TABLE market_quality_samples (
+    sample_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    symbol VARCHAR(10) NOT NULL,
+    best_bid DECIMAL(20,8) DEFAULT NULL,
+    best_ask DECIMAL(20,8) DEFAULT NULL,
+    spread_bps DECIMAL(12,4) DEFAULT NULL,
+    bid_depth DECIMAL(30,8) NOT NULL,
+    ask_depth DECIMAL(30,8) NOT NULL,
+    captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_symbol_captured (symbol, captured_at)
+);
+
+CREATE TABLE candles (
+    symbol VARCHAR(10) NOT NULL,
+    period ENUM('1m', '5m', '1h', '1d') NOT NULL,
+    open_time TIMESTAMP NOT NULL,
+    open DECIMAL(20,8) NOT NULL,
+    high DECIMAL(20,8) NOT NULL,
+    low DECIMAL(20,8) NOT NULL,
+    close DECIMAL(20,8) NOT NULL,
+    volume DECIMAL(20,8) NOT NULL,
+    trade_count INT UNSIGNED NOT NULL,
+    first_sequence BIGINT UNSIGNED NOT NULL,
+    last_sequence BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (symbol, period, open_time)
+);
+
+CREATE TABLE last_looks (
+    last_look_id BIGINT UNSIGNED PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    provider_id VARCHAR(64) NOT NULL,
+    taker_order_id BIGINT UNSIGNED NOT NULL,
+    side ENUM('buy', 'sell') NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    outcome ENUM('accepted', 'rejected', 'timed_out') NOT NULL,
+    requested_at TIMESTAMP(6) NOT NULL,
+    decided_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_symbol_requested_at (symbol, requested_at),
+    INDEX idx_provider_requested_at (provider_id, requested_at)
+);
+
+CREATE TABLE tick_sizes (
+    symbol VARCHAR(10) PRIMARY KEY,
+    tick_size DECIMAL(20,8) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL
+);
+
+CREATE TABLE engine_journal (
+    symbol VARCHAR(10) NOT NULL,
+    sequence BIGINT UNSIGNED NOT NULL,
+    part INT UNSIGNED NOT NULL DEFAULT 0,
+    kind ENUM('place', 'oco', 'amend', 'cancel', 'cancel_quantity', 'cancel_group', 'expire', 'stale', 'tick_size', 'auction', 'uncross') NOT NULL,
+    command JSON NOT NULL,
+    trades JSON NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (symbol, sequence, part)
+);
+
+CREATE TABLE daily_summaries (
+    day DATE PRIMARY KEY,
+    trades INT UNSIGNED NOT NULL,
+    trade_digest CHAR(64) NOT NULL,
+    ledger_entries INT UNSIGNED NOT NULL,
+    ledger_digest CHAR(64) NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP(6) NOT NULL
+);
+
+CREATE TABLE book_snapshots (
+    symbol VARCHAR(10) PRIMARY KEY,
+    last_event_id BIGINT UNSIGNED NOT NULL,
+    orders INT UNSIGNED NOT NULL,
+    book LONGBLOB NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL
+);
+
+CREATE TABLE symbols (
+    symbol VARCHAR(10) PRIMARY KEY,
+    base_currency VARCHAR(10) NOT NULL,
+    quote_currency VARCHAR(10) NOT NULL,
+    lot_size DECIMAL(20,8) NOT NULL,
+    min_notional DECIMAL(30,8) NOT NULL,
+    status ENUM('active', 'halted', 'delisted') NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL
+);
+
+CREATE TABLE ledger_postings (
+    posting_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    kind ENUM('opening', 'deposit', 'trade', 'settlement', 'fee', 'reserve', 'release') NOT NULL,
+    reference_id BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_kind_reference (kind, reference_id)
+);
+
+CREATE TABLE ledger_entries (
+    entry_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    posting_id BIGINT UNSIGNED NOT NULL,
+    account_id VARCHAR(64) NULL,
+    currency VARCHAR(10) NOT NULL,
+    bucket ENUM('settled', 'pending', 'held', 'unheld', 'external', 'anonymous', 'fees') NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    INDEX idx_account_currency (account_id, currency),
+    FOREIGN KEY (posting_id) REFERENCES ledger_postings(posting_id)
+);
+
+CREATE TABLE signing_keys (
+    key_id CHAR(32) PRIMARY KEY,
+    account_id VARCHAR(64) NOT NULL,
+    secret VARCHAR(512) NOT NULL,
+    scopes SET('read','trade','cancel','admin','compliance') NOT NULL DEFAULT 'read,trade,cancel',
+    allowed_ips VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_account_id (account_id)
+);
+
+CREATE TABLE webhooks (
+    webhook_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id VARCHAR(64) NOT NULL,
+    url VARCHAR(512) NOT NULL,
+    secret VARCHAR(512) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_account_id (account_id)
+);
+
+CREATE TABLE webhook_deliveries (
+    delivery_id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    webhook_id BIGINT UNSIGNED NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status ENUM('pending', 'delivered', 'failed') NOT NULL,
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(6) NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_status_next_attempt (status, next_attempt_at),
+    INDEX idx_webhook_id (webhook_id)
+);
+
+CREATE TABLE accounts (
+    account_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE write_behind_checkpoints (
+    node_id SMALLINT UNSIGNED PRIMARY KEY,
+    sequence BIGINT UNSIGNED NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE outbox_sequences (
+    outbox VARCHAR(32) PRIMARY KEY,
+    last_sequence BIGINT UNSIGNED NOT NULL
+);
+
+INSERT INTO outbox_sequences (outbox, last_sequence) VALUES ('order_events', 0), ('trades', 0);